@@ -328,6 +328,27 @@ func (r *ProtocolIncus) RebuildInstance(instanceName string, instance api.Instan
 	return r.rebuildInstance(instanceName, instance)
 }
 
+// ForkInstance creates new instances resuming from the current state of a running virtual machine.
+func (r *ProtocolIncus) ForkInstance(instanceName string, fork api.InstanceForkPost) (Operation, error) {
+	err := r.CheckExtension("instance_fork")
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/fork", path, url.PathEscape(instanceName)), fork, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
 // GetInstancesFull returns a list of instances including snapshots, backups and state.
 func (r *ProtocolIncus) GetInstancesFull(instanceType api.InstanceType) ([]api.InstanceFull, error) {
 	instances := []api.InstanceFull{}
@@ -793,6 +814,10 @@ func (r *ProtocolIncus) CopyInstance(source InstanceServer, instance api.Instanc
 			}
 		}
 
+		if args.Postcopy && !source.HasExtension("migration_postcopy") {
+			return nil, errors.New("The source server is missing the required \"migration_postcopy\" API extension")
+		}
+
 		// Allow overriding the target name
 		if args.Name != "" {
 			req.Name = args.Name
@@ -862,6 +887,10 @@ func (r *ProtocolIncus) CopyInstance(source InstanceServer, instance api.Instanc
 		AllowInconsistent: req.Source.AllowInconsistent,
 	}
 
+	if args != nil && req.Source.Live {
+		sourceReq.Postcopy = args.Postcopy
+	}
+
 	// Push mode migration
 	if args != nil && args.Mode == "push" {
 		// Get target server connection information
@@ -2727,6 +2756,201 @@ func (r *ProtocolIncus) ConsoleInstanceDynamic(instanceName string, console api.
 	return op, f, nil
 }
 
+// CaptureInstance starts a packet capture on an instance NIC and streams it to the provided writer.
+func (r *ProtocolIncus) CaptureInstance(instanceName string, capture api.InstanceCapturePost, args *InstanceCaptureArgs) (Operation, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("instance_nic_capture") {
+		return nil, errors.New(`The server is missing the required "instance_nic_capture" API extension`)
+	}
+
+	if args == nil || args.Output == nil {
+		return nil, errors.New("An output must be set")
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/capture", path, url.PathEscape(instanceName)), capture, "")
+	if err != nil {
+		return nil, err
+	}
+
+	opAPI := op.Get()
+
+	// Parse the fds
+	fds := map[string]string{}
+
+	value, ok := opAPI.Metadata["fds"]
+	if ok {
+		values, ok := value.(map[string]any)
+		if ok {
+			for k, v := range values {
+				val, ok := v.(string)
+				if ok {
+					fds[k] = val
+				}
+			}
+		}
+	}
+
+	if fds["0"] == "" {
+		return nil, errors.New("Did not receive a file descriptor for the capture")
+	}
+
+	// Connect to the websocket
+	conn, err := r.GetOperationWebsocket(opAPI.ID, fds["0"])
+	if err != nil {
+		return nil, err
+	}
+
+	// And copy the capture to the output
+	go func() {
+		<-ws.MirrorWrite(conn, args.Output)
+		_ = conn.Close()
+
+		if args.DataDone != nil {
+			close(args.DataDone)
+		}
+	}()
+
+	return op, nil
+}
+
+// GetInstanceNetworkPath returns the network path of the instance NICs, optionally limited to a single device.
+func (r *ProtocolIncus) GetInstanceNetworkPath(instanceName string, device string) ([]api.InstanceNetworkPath, error) {
+	err := r.CheckExtension("instance_network_path")
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := fmt.Sprintf("%s/%s/network-path", path, url.PathEscape(instanceName))
+	if device != "" {
+		uri += "?device=" + url.QueryEscape(device)
+	}
+
+	paths := []api.InstanceNetworkPath{}
+
+	_, err = r.queryStruct("GET", uri, nil, "", &paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// TraceInstanceNetwork traces a flow originating from one of the instance NICs.
+func (r *ProtocolIncus) TraceInstanceNetwork(instanceName string, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	err := r.CheckExtension("instance_network_path")
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := api.InstanceNetworkTrace{}
+
+	_, err = r.queryStruct("POST", fmt.Sprintf("%s/%s/network-trace", path, url.PathEscape(instanceName)), req, "", &trace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trace, nil
+}
+
+// GetInstanceAttestation retrieves an attestation report from a confidential virtual machine.
+func (r *ProtocolIncus) GetInstanceAttestation(instanceName string, req api.InstanceAttestationPost) (*api.InstanceAttestation, error) {
+	err := r.CheckExtension("instance_tdx")
+	if err != nil {
+		return nil, err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	attestation := api.InstanceAttestation{}
+
+	_, err = r.queryStruct("POST", fmt.Sprintf("%s/%s/attestation", path, url.PathEscape(instanceName)), req, "", &attestation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attestation, nil
+}
+
+// GetInstanceUEFIVars returns the content of the UEFI variable store of a virtual machine.
+func (r *ProtocolIncus) GetInstanceUEFIVars(instanceName string) (*api.InstanceUEFIVars, string, error) {
+	err := r.CheckExtension("instance_uefi_vars")
+	if err != nil {
+		return nil, "", err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, "", err
+	}
+
+	uefiVars := api.InstanceUEFIVars{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("%s/%s/uefi-vars", path, url.PathEscape(instanceName)), nil, "", &uefiVars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &uefiVars, etag, nil
+}
+
+// UpdateInstanceUEFIVars replaces the content of the UEFI variable store of a virtual machine.
+func (r *ProtocolIncus) UpdateInstanceUEFIVars(instanceName string, uefiVars api.InstanceUEFIVars, ETag string) error {
+	err := r.CheckExtension("instance_uefi_vars")
+	if err != nil {
+		return err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("PUT", fmt.Sprintf("%s/%s/uefi-vars", path, url.PathEscape(instanceName)), uefiVars, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EnrollInstanceUEFIKeys enrolls custom Secure Boot keys in a virtual machine.
+func (r *ProtocolIncus) EnrollInstanceUEFIKeys(instanceName string, keys api.InstanceUEFIKeysPost) error {
+	err := r.CheckExtension("instance_uefi_vars")
+	if err != nil {
+		return err
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = r.query("POST", fmt.Sprintf("%s/%s/uefi-vars/keys", path, url.PathEscape(instanceName)), keys, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetInstanceConsoleLog requests that Incus attaches to the console device of a instance.
 //
 // Note that it's the caller's responsibility to close the returned ReadCloser.
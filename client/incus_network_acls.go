@@ -77,6 +77,23 @@ func (r *ProtocolIncus) GetNetworkACL(name string) (*api.NetworkACL, string, err
 	return &acl, etag, nil
 }
 
+// GetNetworkACLState returns the hit counters of the rules of the Network ACL.
+func (r *ProtocolIncus) GetNetworkACLState(name string) (*api.NetworkACLState, error) {
+	if !r.HasExtension("network_acl_state") {
+		return nil, errors.New(`The server is missing the required "network_acl_state" API extension`)
+	}
+
+	state := api.NetworkACLState{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", fmt.Sprintf("/network-acls/%s/state", url.PathEscape(name)), nil, "", &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
 // GetNetworkACLLogfile returns a reader for the ACL log file.
 //
 // Note that it's the caller's responsibility to close the returned ReadCloser.
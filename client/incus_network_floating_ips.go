@@ -0,0 +1,105 @@
+package incus
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// GetNetworkFloatingIPAddresses returns a list of floating IP addresses.
+func (r *ProtocolIncus) GetNetworkFloatingIPAddresses() ([]string, error) {
+	if !r.HasExtension("network_floating_ips") {
+		return nil, errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := "/network-floating-ips"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetNetworkFloatingIPs returns a list of floating IP structs.
+func (r *ProtocolIncus) GetNetworkFloatingIPs() ([]api.NetworkFloatingIP, error) {
+	if !r.HasExtension("network_floating_ips") {
+		return nil, errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	floatingIPs := []api.NetworkFloatingIP{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", "/network-floating-ips?recursion=1", nil, "", &floatingIPs)
+	if err != nil {
+		return nil, err
+	}
+
+	return floatingIPs, nil
+}
+
+// GetNetworkFloatingIP returns a floating IP entry for the provided address.
+func (r *ProtocolIncus) GetNetworkFloatingIP(address string) (*api.NetworkFloatingIP, string, error) {
+	if !r.HasExtension("network_floating_ips") {
+		return nil, "", errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	floatingIP := api.NetworkFloatingIP{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", "/network-floating-ips/"+url.PathEscape(address), nil, "", &floatingIP)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &floatingIP, etag, nil
+}
+
+// CreateNetworkFloatingIP allocates a new floating IP using the provided struct.
+func (r *ProtocolIncus) CreateNetworkFloatingIP(floatingIP api.NetworkFloatingIPsPost) error {
+	if !r.HasExtension("network_floating_ips") {
+		return errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", "/network-floating-ips", floatingIP, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateNetworkFloatingIP updates the floating IP to match the provided struct.
+func (r *ProtocolIncus) UpdateNetworkFloatingIP(address string, floatingIP api.NetworkFloatingIPPut, ETag string) error {
+	if !r.HasExtension("network_floating_ips") {
+		return errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("PUT", "/network-floating-ips/"+url.PathEscape(address), floatingIP, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkFloatingIP releases an existing floating IP.
+func (r *ProtocolIncus) DeleteNetworkFloatingIP(address string) error {
+	if !r.HasExtension("network_floating_ips") {
+		return errors.New(`The server is missing the required "network_floating_ips" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("DELETE", "/network-floating-ips/"+url.PathEscape(address), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
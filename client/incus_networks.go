@@ -151,6 +151,23 @@ func (r *ProtocolIncus) GetNetworkState(name string) (*api.NetworkState, error)
 	return &state, nil
 }
 
+// GetNetworkFirewall returns the firewall rules of the network, compared with the ones applied by the server.
+func (r *ProtocolIncus) GetNetworkFirewall(name string) (*api.NetworkFirewall, error) {
+	if !r.HasExtension("network_firewall_drift") {
+		return nil, errors.New("The server is missing the required \"network_firewall_drift\" API extension")
+	}
+
+	firewall := api.NetworkFirewall{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/firewall", url.PathEscape(name)), nil, "", &firewall)
+	if err != nil {
+		return nil, err
+	}
+
+	return &firewall, nil
+}
+
 // CreateNetwork defines a new network using the provided Network struct.
 func (r *ProtocolIncus) CreateNetwork(network api.NetworksPost) error {
 	if !r.HasExtension("network") {
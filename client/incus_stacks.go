@@ -0,0 +1,140 @@
+package incus
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// GetStackNames returns a list of stack names.
+func (r *ProtocolIncus) GetStackNames() ([]string, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := "/stacks"
+	_, err = r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetStacks returns a list of Stack structs.
+func (r *ProtocolIncus) GetStacks() ([]api.Stack, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	stacks := []api.Stack{}
+
+	// Fetch the raw value.
+	_, err = r.queryStruct("GET", "/stacks?recursion=1", nil, "", &stacks)
+	if err != nil {
+		return nil, err
+	}
+
+	return stacks, nil
+}
+
+// GetStack returns a Stack entry for the provided name.
+func (r *ProtocolIncus) GetStack(name string) (*api.Stack, string, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, "", err
+	}
+
+	stack := api.Stack{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/stacks/%s", url.PathEscape(name)), nil, "", &stack)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &stack, etag, nil
+}
+
+// GetStackDiff returns the changes needed to apply the provided manifest to a stack, optionally adopting the
+// existing resources which aren't managed by the stack.
+func (r *ProtocolIncus) GetStackDiff(name string, stack api.StackPut, adopt bool) (*api.StackDiff, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	diff := api.StackDiff{}
+
+	// Send the request.
+	_, err = r.queryStruct("POST", fmt.Sprintf("/stacks/%s/diff%s", url.PathEscape(name), stackAdoptParam(adopt)), stack, "", &diff)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diff, nil
+}
+
+// CreateStack defines a new stack and creates its resources, optionally adopting the existing ones.
+func (r *ProtocolIncus) CreateStack(stack api.StacksPost, adopt bool) (Operation, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/stacks%s", stackAdoptParam(adopt)), stack, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// UpdateStack applies a new manifest to a stack, optionally adopting the existing resources which aren't managed
+// by the stack.
+func (r *ProtocolIncus) UpdateStack(name string, stack api.StackPut, adopt bool, ETag string) (Operation, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("PUT", fmt.Sprintf("/stacks/%s%s", url.PathEscape(name), stackAdoptParam(adopt)), stack, ETag)
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteStack deletes a stack and the resources it created.
+func (r *ProtocolIncus) DeleteStack(name string) (Operation, error) {
+	err := r.CheckExtension("stacks")
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("/stacks/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// stackAdoptParam returns the query string requesting existing resources to be adopted, if needed.
+func stackAdoptParam(adopt bool) string {
+	if adopt {
+		return "?adopt=1"
+	}
+
+	return ""
+}
@@ -119,9 +119,17 @@ type InstanceServer interface {
 	UpdateInstances(state api.InstancesPut, ETag string) (op Operation, err error)
 	RebuildInstance(instanceName string, req api.InstanceRebuildPost) (op Operation, err error)
 	RebuildInstanceFromImage(source ImageServer, image api.Image, instanceName string, req api.InstanceRebuildPost) (op RemoteOperation, err error)
+	ForkInstance(instanceName string, fork api.InstanceForkPost) (op Operation, err error)
 
 	ExecInstance(instanceName string, exec api.InstanceExecPost, args *InstanceExecArgs) (op Operation, err error)
 	ConsoleInstance(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (op Operation, err error)
+	CaptureInstance(instanceName string, capture api.InstanceCapturePost, args *InstanceCaptureArgs) (op Operation, err error)
+	GetInstanceNetworkPath(instanceName string, device string) (paths []api.InstanceNetworkPath, err error)
+	TraceInstanceNetwork(instanceName string, req api.InstanceNetworkTracePost) (trace *api.InstanceNetworkTrace, err error)
+	GetInstanceAttestation(instanceName string, req api.InstanceAttestationPost) (attestation *api.InstanceAttestation, err error)
+	GetInstanceUEFIVars(instanceName string) (uefiVars *api.InstanceUEFIVars, ETag string, err error)
+	UpdateInstanceUEFIVars(instanceName string, uefiVars api.InstanceUEFIVars, ETag string) (err error)
+	EnrollInstanceUEFIKeys(instanceName string, keys api.InstanceUEFIKeysPost) (err error)
 	ConsoleInstanceDynamic(instanceName string, console api.InstanceConsolePost, args *InstanceConsoleArgs) (Operation, func(io.ReadWriteCloser) error, error)
 
 	GetInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (content io.ReadCloser, err error)
@@ -201,6 +209,7 @@ type InstanceServer interface {
 	GetNetwork(name string) (network *api.Network, ETag string, err error)
 	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
 	GetNetworkState(name string) (state *api.NetworkState, err error)
+	GetNetworkFirewall(name string) (firewall *api.NetworkFirewall, err error)
 	CreateNetwork(network api.NetworksPost) (err error)
 	UpdateNetwork(name string, network api.NetworkPut, ETag string) (err error)
 	RenameNetwork(name string, network api.NetworkPost) (err error)
@@ -214,6 +223,14 @@ type InstanceServer interface {
 	UpdateNetworkForward(networkName string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
 	DeleteNetworkForward(networkName string, listenAddress string) (err error)
 
+	// Network floating IP functions ("network_floating_ips" API extension)
+	GetNetworkFloatingIPAddresses() ([]string, error)
+	GetNetworkFloatingIPs() ([]api.NetworkFloatingIP, error)
+	GetNetworkFloatingIP(address string) (floatingIP *api.NetworkFloatingIP, ETag string, err error)
+	CreateNetworkFloatingIP(floatingIP api.NetworkFloatingIPsPost) error
+	UpdateNetworkFloatingIP(address string, floatingIP api.NetworkFloatingIPPut, ETag string) (err error)
+	DeleteNetworkFloatingIP(address string) (err error)
+
 	// Network load balancer functions ("network_load_balancer" API extension)
 	GetNetworkLoadBalancerAddresses(networkName string) ([]string, error)
 	GetNetworkLoadBalancers(networkName string) ([]api.NetworkLoadBalancer, error)
@@ -236,6 +253,7 @@ type InstanceServer interface {
 	GetNetworkACLs() (acls []api.NetworkACL, err error)
 	GetNetworkACLsAllProjects() (acls []api.NetworkACL, err error)
 	GetNetworkACL(name string) (acl *api.NetworkACL, ETag string, err error)
+	GetNetworkACLState(name string) (state *api.NetworkACLState, err error)
 	GetNetworkACLLogfile(name string) (log io.ReadCloser, err error)
 	CreateNetworkACL(acl api.NetworkACLsPost) (err error)
 	UpdateNetworkACL(name string, acl api.NetworkACLPut, ETag string) (err error)
@@ -316,6 +334,15 @@ type InstanceServer interface {
 	DeleteProject(name string) (err error)
 	DeleteProjectForce(name string) (err error)
 
+	// Stack functions ("stacks" API extension)
+	GetStackNames() (names []string, err error)
+	GetStacks() (stacks []api.Stack, err error)
+	GetStack(name string) (stack *api.Stack, ETag string, err error)
+	GetStackDiff(name string, stack api.StackPut, adopt bool) (diff *api.StackDiff, err error)
+	CreateStack(stack api.StacksPost, adopt bool) (op Operation, err error)
+	UpdateStack(name string, stack api.StackPut, adopt bool, ETag string) (op Operation, err error)
+	DeleteStack(name string) (op Operation, err error)
+
 	// Storage pool functions ("storage" API extension)
 	GetStoragePoolNames() (names []string, err error)
 	GetStoragePools() (pools []api.StoragePool, err error)
@@ -610,6 +637,10 @@ type InstanceCopyArgs struct {
 
 	// API extension: instance_allow_inconsistent_copy
 	AllowInconsistent bool
+
+	// API extension: migration_postcopy
+	// If set, a live migration may switch to post-copy mode
+	Postcopy bool
 }
 
 // The InstanceSnapshotCopyArgs struct is used to pass additional options during instance copy.
@@ -660,6 +691,15 @@ type InstanceExecArgs struct {
 	DataDone chan bool
 }
 
+// The InstanceCaptureArgs struct is used to pass additional options during a packet capture.
+type InstanceCaptureArgs struct {
+	// Writer receiving the capture (pcap format)
+	Output io.Writer
+
+	// Channel that will be closed when the capture is complete
+	DataDone chan bool
+}
+
 // The InstanceFileArgs struct is used to pass the various options for a instance file upload.
 type InstanceFileArgs struct {
 	// File content
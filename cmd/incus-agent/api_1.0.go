@@ -25,8 +25,10 @@ var api10Cmd = APIEndpoint{
 
 var api10 = []APIEndpoint{
 	api10Cmd,
+	attestationCmd,
 	execCmd,
 	eventsCmd,
+	identityCmd,
 	metricsCmd,
 	operationsCmd,
 	operationCmd,
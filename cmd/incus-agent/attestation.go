@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+var attestationCmd = APIEndpoint{
+	Name: "attestation",
+	Path: "attestation",
+
+	Post: APIEndpointAction{Handler: attestationPost},
+}
+
+func attestationPost(d *Daemon, r *http.Request) response.Response {
+	req := api.InstanceAttestationPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	reportData, err := base64.StdEncoding.DecodeString(req.ReportData)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid report data: %w", err))
+	}
+
+	if len(reportData) > 64 {
+		return response.BadRequest(errors.New("Report data can't be longer than 64 bytes"))
+	}
+
+	attestation, err := osGetAttestationReport(reportData)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.SyncResponse(true, attestation)
+}
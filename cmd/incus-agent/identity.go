@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lxc/incus/v6/internal/server/response"
+	agentAPI "github.com/lxc/incus/v6/shared/api/agent"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+var identityCmd = APIEndpoint{
+	Name: "identity",
+	Path: "identity",
+
+	Post: APIEndpointAction{Handler: identityPost},
+}
+
+func identityPost(d *Daemon, r *http.Request) response.Response {
+	req := agentAPI.IdentityPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = osRefreshIdentity(req.Hostname, req.HWAddrs)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	logger.Infof("Refreshed the instance identity (hostname %q)", req.Hostname)
+
+	return response.EmptySyncResponse
+}
@@ -6,6 +6,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -772,6 +775,183 @@ func osReconfigureNetworkInterfaces() {
 	}
 }
 
+func osRefreshIdentity(hostname string, hwaddrs map[string]string) error {
+	// Generate a new machine-id, only if the guest uses one.
+	if util.PathExists("/etc/machine-id") {
+		machineID := make([]byte, 16)
+		_, err := rand.Read(machineID)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile("/etc/machine-id", []byte(hex.EncodeToString(machineID)+"\n"), 0o444)
+		if err != nil {
+			return fmt.Errorf("Failed to update machine-id: %w", err)
+		}
+	}
+
+	// Update the hostname.
+	if hostname != "" {
+		err := unix.Sethostname([]byte(hostname))
+		if err != nil {
+			return fmt.Errorf("Failed to set hostname: %w", err)
+		}
+
+		err = os.WriteFile("/etc/hostname", []byte(hostname+"\n"), 0o644)
+		if err != nil {
+			return fmt.Errorf("Failed to update /etc/hostname: %w", err)
+		}
+	}
+
+	// Apply the new MAC addresses to the interfaces still using the old ones.
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+
+	changedIfaces := []string{}
+	for _, iface := range ifaces {
+		newAddr, ok := hwaddrs[iface.HardwareAddr.String()]
+		if !ok {
+			continue
+		}
+
+		hwaddr, err := net.ParseMAC(newAddr)
+		if err != nil {
+			return err
+		}
+
+		link := ip.Link{Name: iface.Name}
+
+		err = link.SetDown()
+		if err != nil {
+			return err
+		}
+
+		err = link.SetAddress(hwaddr)
+		if err != nil {
+			_ = link.SetUp()
+			return fmt.Errorf("Failed to set MAC address of %q: %w", iface.Name, err)
+		}
+
+		err = link.SetUp()
+		if err != nil {
+			return err
+		}
+
+		// Drop the addresses leased or derived from the old MAC address.
+		addr := &ip.Addr{
+			DevName: iface.Name,
+			Scope:   "global",
+			Family:  ip.FamilyAll,
+		}
+
+		err = addr.Flush()
+		if err != nil {
+			return err
+		}
+
+		changedIfaces = append(changedIfaces, iface.Name)
+	}
+
+	if len(changedIfaces) > 0 {
+		err = osRefreshNetwork(changedIfaces)
+		if err != nil {
+			return fmt.Errorf("Failed to reconfigure the network: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// osRefreshNetwork gets new addresses for the interfaces through the network manager of the guest.
+func osRefreshNetwork(ifaces []string) error {
+	// systemd-networkd.
+	_, err := subprocess.RunCommand("systemctl", "is-active", "--quiet", "systemd-networkd")
+	if err == nil {
+		_, err = subprocess.RunCommand("networkctl", append([]string{"reconfigure"}, ifaces...)...)
+		if err != nil {
+			_, err = subprocess.RunCommand("systemctl", "restart", "systemd-networkd")
+		}
+
+		return err
+	}
+
+	// NetworkManager.
+	_, err = subprocess.RunCommand("systemctl", "is-active", "--quiet", "NetworkManager")
+	if err == nil {
+		for _, iface := range ifaces {
+			_, err = subprocess.RunCommand("nmcli", "device", "connect", iface)
+			if err != nil {
+				_, err = subprocess.RunCommand("systemctl", "restart", "NetworkManager")
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// Plain DHCP client.
+	_, err = exec.LookPath("dhclient")
+	if err == nil {
+		for _, iface := range ifaces {
+			_, _ = subprocess.RunCommand("dhclient", "-r", iface)
+
+			_, err = subprocess.RunCommand("dhclient", iface)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func osGetAttestationReport(reportData []byte) (*api.InstanceAttestation, error) {
+	tsmPath := "/sys/kernel/config/tsm/report"
+
+	// Make sure configfs is mounted.
+	if !util.PathExists(tsmPath) && util.PathExists("/sys/kernel/config") {
+		_ = unix.Mount("configfs", "/sys/kernel/config", "configfs", 0, "")
+	}
+
+	if !util.PathExists(tsmPath) {
+		return nil, errors.New("Attestation reports aren't supported by the guest kernel")
+	}
+
+	// Each report is requested through its own configfs entry.
+	reportPath, err := os.MkdirTemp(tsmPath, "incus-")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create the report request: %w", err)
+	}
+
+	defer func() { _ = os.Remove(reportPath) }()
+
+	// The report data is always 64 bytes.
+	inblob := make([]byte, 64)
+	copy(inblob, reportData)
+
+	err = os.WriteFile(filepath.Join(reportPath, "inblob"), inblob, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to write the report data: %w", err)
+	}
+
+	report, err := os.ReadFile(filepath.Join(reportPath, "outblob"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate the attestation report: %w", err)
+	}
+
+	provider, err := os.ReadFile(filepath.Join(reportPath, "provider"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.InstanceAttestation{
+		Provider: strings.TrimSpace(string(provider)),
+		Report:   base64.StdEncoding.EncodeToString(report),
+	}, nil
+}
+
 func osGetInteractiveConsole(s *execWs) (*os.File, *os.File, error) {
 	pty, tty, err := linux.OpenPty(int64(s.uid), int64(s.gid))
 	if err != nil {
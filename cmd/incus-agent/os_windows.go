@@ -156,6 +156,14 @@ func osReconfigureNetworkInterfaces() {
 	return
 }
 
+func osRefreshIdentity(hostname string, hwaddrs map[string]string) error {
+	return errors.New("Identity refresh isn't currently supported on Windows")
+}
+
+func osGetAttestationReport(reportData []byte) (*api.InstanceAttestation, error) {
+	return nil, errors.New("Attestation reports aren't currently supported on Windows")
+}
+
 func osGetInteractiveConsole(s *execWs) (io.ReadWriteCloser, io.ReadWriteCloser, error) {
 	return nil, nil, errors.New("Only non-interactive exec sessions are currently supported on Windows")
 }
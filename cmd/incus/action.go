@@ -11,6 +11,7 @@ import (
 
 	cli "github.com/lxc/incus/v6/internal/cmd"
 	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/internal/instance"
 	"github.com/lxc/incus/v6/shared/api"
 	config "github.com/lxc/incus/v6/shared/cliconfig"
 )
@@ -202,10 +203,11 @@ func (c *cmdAction) doActionAll(action string, resource remoteResource) error {
 
 	req := api.InstancesPut{
 		State: &api.InstanceStatePut{
-			Action:   action,
-			Timeout:  c.flagTimeout,
-			Force:    c.flagForce,
-			Stateful: state,
+			Action:    action,
+			Timeout:   c.flagTimeout,
+			Force:     c.flagForce,
+			Stateful:  state,
+			Stateless: action == "start" && c.flagStateless,
 		},
 	}
 
@@ -292,10 +294,11 @@ func (c *cmdAction) doAction(action string, conf *config.Config, nameArg string)
 	}
 
 	req := api.InstanceStatePut{
-		Action:   action,
-		Timeout:  c.flagTimeout,
-		Force:    c.flagForce,
-		Stateful: state,
+		Action:    action,
+		Timeout:   c.flagTimeout,
+		Force:     c.flagForce,
+		Stateful:  state,
+		Stateless: action == "start" && c.flagStateless,
 	}
 
 	op, err := d.UpdateInstanceState(name, req, "")
@@ -434,8 +437,16 @@ func (c *cmdAction) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Run the action for every listed instance
-	results := runBatch(names, func(name string) error { return c.doAction(cmd.Name(), conf, name) })
+	// Run the action for every listed instance, starting dependencies first and stopping them last.
+	batches := [][]string{names}
+	if len(names) > 1 && (cmd.Name() == "start" || cmd.Name() == "stop") {
+		batches = c.dependencyBatches(conf, names, cmd.Name() == "stop")
+	}
+
+	results := []batchResult{}
+	for _, batch := range batches {
+		results = append(results, runBatch(batch, func(name string) error { return c.doAction(cmd.Name(), conf, name) })...)
+	}
 
 	// Single instance is easy
 	if len(results) == 1 {
@@ -464,3 +475,59 @@ func (c *cmdAction) Run(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// dependencyBatches splits the instances into batches following their boot.depends_on setting, so that the
+// dependencies are started before the instances depending on them, or stopped after them if reverse is set.
+// Instances which can't be retrieved are put in the first batch, so their error gets reported.
+func (c *cmdAction) dependencyBatches(conf *config.Config, names []string, reverse bool) [][]string {
+	keys := make(map[string]string, len(names))
+	dependencies := make(map[string][]string, len(names))
+	for _, nameArg := range names {
+		keys[nameArg] = nameArg
+
+		remote, name, err := conf.ParseRemote(nameArg)
+		if err != nil {
+			continue
+		}
+
+		// Refer to the instances the same way as their dependencies.
+		key := fmt.Sprintf("%s:%s", remote, name)
+		keys[nameArg] = key
+		dependencies[key] = []string{}
+
+		d, err := conf.GetInstanceServer(remote)
+		if err != nil {
+			continue
+		}
+
+		inst, _, err := d.GetInstance(name)
+		if err != nil {
+			continue
+		}
+
+		for _, dependency := range instance.Dependencies(inst.ExpandedConfig) {
+			dependencies[key] = append(dependencies[key], fmt.Sprintf("%s:%s", remote, dependency))
+		}
+	}
+
+	if reverse {
+		dependencies = instance.ReverseDependencies(dependencies)
+	}
+
+	levels, err := instance.DependencyLevels(dependencies)
+	if err != nil {
+		return [][]string{names}
+	}
+
+	batches := [][]string{}
+	for _, nameArg := range names {
+		level := levels[keys[nameArg]]
+		for len(batches) <= level {
+			batches = append(batches, []string{})
+		}
+
+		batches[level] = append(batches[level], nameArg)
+	}
+
+	return batches
+}
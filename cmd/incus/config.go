@@ -73,6 +73,10 @@ func (c *cmdConfig) Command() *cobra.Command {
 	configTrustCmd := cmdConfigTrust{global: c.global, config: c}
 	cmd.AddCommand(configTrustCmd.Command())
 
+	// UEFI
+	configUEFICmd := cmdConfigUEFI{global: c.global, config: c}
+	cmd.AddCommand(configUEFICmd.Command())
+
 	// Unset
 	configUnsetCmd := cmdConfigUnset{global: c.global, config: c, configSet: &configSetCmd}
 	cmd.AddCommand(configUnsetCmd.Command())
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/termios"
+)
+
+type cmdConfigUEFI struct {
+	global *cmdGlobal
+	config *cmdConfig
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFI) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("uefi")
+	cmd.Short = i18n.G("Manage the UEFI variables of virtual machines")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage the UEFI variables of virtual machines
+
+Variables are identified by their name and vendor GUID, for example:
+BootOrder-8be4dfcb-90b5-11d2-aa0d-00e098032b8c
+
+Their values are hex encoded.`))
+
+	// Edit
+	configUEFIEditCmd := cmdConfigUEFIEdit{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFIEditCmd.Command())
+
+	// Enroll
+	configUEFIEnrollCmd := cmdConfigUEFIEnroll{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFIEnrollCmd.Command())
+
+	// Get
+	configUEFIGetCmd := cmdConfigUEFIGet{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFIGetCmd.Command())
+
+	// Set
+	configUEFISetCmd := cmdConfigUEFISet{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFISetCmd.Command())
+
+	// Show
+	configUEFIShowCmd := cmdConfigUEFIShow{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFIShowCmd.Command())
+
+	// Unset
+	configUEFIUnsetCmd := cmdConfigUEFIUnset{global: c.global, config: c.config, configUEFI: c}
+	cmd.AddCommand(configUEFIUnsetCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, _ []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// parseInstance parses the remote and name of the instance argument.
+func (c *cmdConfigUEFI) parseInstance(arg string) (*remoteResource, error) {
+	resources, err := c.global.parseServers(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return nil, errors.New(i18n.G("Missing instance name"))
+	}
+
+	return &resource, nil
+}
+
+// Edit.
+type cmdConfigUEFIEdit struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFIEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("edit", i18n.G("[<remote>:]<instance>"))
+	cmd.Short = i18n.G("Edit the UEFI variables of a virtual machine")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Edit the UEFI variables of a virtual machine
+
+The virtual machine must be stopped.`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+func (c *cmdConfigUEFIEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the UEFI variables.
+### Any line starting with a '# will be ignored.
+###
+### A sample configuration looks like:
+###
+### variables:
+###   BootOrder-8be4dfcb-90b5-11d2-aa0d-00e098032b8c:
+###     data: "01000000"
+###     attributes: 7
+###     timestamp: 0001-01-01T00:00:00Z`)
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFIEdit) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Edit the variables
+	if !termios.IsTerminal(getStdinFd()) {
+		uefiVars := api.InstanceUEFIVars{}
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.Unmarshal(content, &uefiVars)
+		if err != nil {
+			return err
+		}
+
+		return resource.server.UpdateInstanceUEFIVars(resource.name, uefiVars, "")
+	}
+
+	uefiVars, etag, err := resource.server.GetInstanceUEFIVars(resource.name)
+	if err != nil {
+		return err
+	}
+
+	origContent, err := yaml.Marshal(uefiVars)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor
+	content, err := textEditor("", []byte(c.helpTemplate()+"\n\n"+string(origContent)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		uefiVars := api.InstanceUEFIVars{}
+		err = yaml.Unmarshal(content, &uefiVars)
+		if err == nil {
+			err = resource.server.UpdateInstanceUEFIVars(resource.name, uefiVars, etag)
+		}
+
+		// Respawn the editor
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again or ctrl+c to abort change"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = textEditor("", content)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// Enroll.
+type cmdConfigUEFIEnroll struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+
+	flagPK  string
+	flagKEK string
+	flagDB  string
+	flagDBX string
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFIEnroll) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("enroll", i18n.G("[<remote>:]<instance>"))
+	cmd.Short = i18n.G("Enroll custom Secure Boot keys in a virtual machine")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Enroll custom Secure Boot keys in a virtual machine
+
+The files contain PEM encoded certificates.
+The db and dbx files may also contain hex encoded SHA-256 hashes.
+Only the provided keys are replaced and the virtual machine must be stopped.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus config uefi enroll v1 --pk pk.crt --kek kek.crt --db db.crt
+    Replace the Platform Key, Key Exchange Key and signature database of v1.`))
+
+	cmd.Flags().StringVar(&c.flagPK, "pk", "", i18n.G("File containing the Platform Key")+"``")
+	cmd.Flags().StringVar(&c.flagKEK, "kek", "", i18n.G("File containing the Key Exchange Keys")+"``")
+	cmd.Flags().StringVar(&c.flagDB, "db", "", i18n.G("File containing the allowed signatures")+"``")
+	cmd.Flags().StringVar(&c.flagDBX, "dbx", "", i18n.G("File containing the forbidden signatures")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFIEnroll) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	if c.flagPK == "" && c.flagKEK == "" && c.flagDB == "" && c.flagDBX == "" {
+		return errors.New(i18n.G("At least one of --pk, --kek, --db or --dbx must be provided"))
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	readFile := func(path string) (string, error) {
+		if path == "" {
+			return "", nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		return string(content), nil
+	}
+
+	keys := api.InstanceUEFIKeysPost{}
+
+	keys.PK, err = readFile(c.flagPK)
+	if err != nil {
+		return err
+	}
+
+	keys.KEK, err = readFile(c.flagKEK)
+	if err != nil {
+		return err
+	}
+
+	keys.DB, err = readFile(c.flagDB)
+	if err != nil {
+		return err
+	}
+
+	keys.DBX, err = readFile(c.flagDBX)
+	if err != nil {
+		return err
+	}
+
+	return resource.server.EnrollInstanceUEFIKeys(resource.name, keys)
+}
+
+// Get.
+type cmdConfigUEFIGet struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFIGet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("get", i18n.G("[<remote>:]<instance> <variable>"))
+	cmd.Short = i18n.G("Get the value of a UEFI variable")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Get the hex encoded value of a UEFI variable`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFIGet) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	uefiVars, _, err := resource.server.GetInstanceUEFIVars(resource.name)
+	if err != nil {
+		return err
+	}
+
+	variable, ok := uefiVars.Variables[args[1]]
+	if !ok {
+		return fmt.Errorf(i18n.G("UEFI variable %q doesn't exist"), args[1])
+	}
+
+	fmt.Println(variable.Data)
+
+	return nil
+}
+
+// Set.
+type cmdConfigUEFISet struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+
+	flagAttributes uint32
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFISet) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("set", i18n.G("[<remote>:]<instance> <variable> <value>"))
+	cmd.Short = i18n.G("Set the value of a UEFI variable")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Set the hex encoded value of a UEFI variable
+
+The attributes of existing variables are kept unless --attributes is provided.
+The virtual machine must be stopped.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus config uefi set v1 BootOrder-8be4dfcb-90b5-11d2-aa0d-00e098032b8c 02000100
+    Boot from Boot0002 first, then from Boot0001.`))
+
+	cmd.Flags().Uint32Var(&c.flagAttributes, "attributes", 0, i18n.G("Attributes of the variable (defaults to 7 for new variables)")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFISet) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 3, 3)
+	if exit {
+		return err
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	uefiVars, etag, err := resource.server.GetInstanceUEFIVars(resource.name)
+	if err != nil {
+		return err
+	}
+
+	variable, ok := uefiVars.Variables[args[1]]
+	if !ok {
+		// Non-volatile, boot service and runtime access.
+		variable.Attributes = 7
+	}
+
+	if cmd.Flags().Changed("attributes") {
+		variable.Attributes = c.flagAttributes
+	}
+
+	variable.Data = args[2]
+	uefiVars.Variables[args[1]] = variable
+
+	return resource.server.UpdateInstanceUEFIVars(resource.name, *uefiVars, etag)
+}
+
+// Show.
+type cmdConfigUEFIShow struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFIShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<instance>"))
+	cmd.Short = i18n.G("Show the UEFI variables of a virtual machine")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show the UEFI variables of a virtual machine`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFIShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	uefiVars, _, err := resource.server.GetInstanceUEFIVars(resource.name)
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(uefiVars)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", content)
+
+	return nil
+}
+
+// Unset.
+type cmdConfigUEFIUnset struct {
+	global     *cmdGlobal
+	config     *cmdConfig
+	configUEFI *cmdConfigUEFI
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdConfigUEFIUnset) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("unset", i18n.G("[<remote>:]<instance> <variable>"))
+	cmd.Short = i18n.G("Remove a UEFI variable")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Remove a UEFI variable
+
+The virtual machine must be stopped.`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdConfigUEFIUnset) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	resource, err := c.configUEFI.parseInstance(args[0])
+	if err != nil {
+		return err
+	}
+
+	uefiVars, etag, err := resource.server.GetInstanceUEFIVars(resource.name)
+	if err != nil {
+		return err
+	}
+
+	_, ok := uefiVars.Variables[args[1]]
+	if !ok {
+		return fmt.Errorf(i18n.G("UEFI variable %q doesn't exist"), args[1])
+	}
+
+	delete(uefiVars.Variables, args[1])
+
+	return resource.server.UpdateInstanceUEFIVars(resource.name, *uefiVars, etag)
+}
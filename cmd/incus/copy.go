@@ -33,6 +33,7 @@ type cmdCopy struct {
 	flagRefresh             bool
 	flagRefreshExcludeOlder bool
 	flagAllowInconsistent   bool
+	flagPostcopy            bool
 }
 
 // Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
@@ -268,6 +269,7 @@ func (c *cmdCopy) copyInstance(conf *config.Config, sourceResource string, destR
 			Refresh:             c.flagRefresh,
 			RefreshExcludeOlder: c.flagRefreshExcludeOlder,
 			AllowInconsistent:   c.flagAllowInconsistent,
+			Postcopy:            c.flagPostcopy,
 		}
 
 		// Copy of an instance into a new instance
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// Fork.
+type cmdFork struct {
+	global *cmdGlobal
+
+	flagEphemeral bool
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdFork) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("fork", i18n.G("[<remote>:]<instance> <name> [<name>...]"))
+	cmd.Short = i18n.G("Fork running virtual machines")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Fork running virtual machines
+
+The source virtual machine is briefly paused while its state is saved.
+The new instances resume from that state with new MAC addresses, and their agent refreshes
+the hostname, machine-id and MAC addresses inside the guest.
+
+This requires migration.stateful to be enabled on the source virtual machine.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus fork v1 v1-clone1 v1-clone2
+    Create two running copies of the v1 virtual machine.`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().BoolVarP(&c.flagEphemeral, "ephemeral", "e", false, i18n.G("Create ephemeral instances"))
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdFork) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, -1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing instance name"))
+	}
+
+	if strings.Contains(resource.name, instance.SnapshotDelimiter) {
+		return fmt.Errorf(i18n.G("Instance snapshots cannot be forked: %s"), resource.name)
+	}
+
+	req := api.InstanceForkPost{
+		Names:     args[1:],
+		Ephemeral: c.flagEphemeral,
+	}
+
+	op, err := resource.server.ForkInstance(resource.name, req)
+	if err != nil {
+		return err
+	}
+
+	progress := cli.ProgressRenderer{
+		Format: i18n.G("Forking instance: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = cli.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	progress.Done("")
+
+	// Report the instances whose guest identity couldn't be refreshed.
+	opAPI := op.Get()
+	refreshErrors, ok := opAPI.Metadata["identity_refresh_errors"].(map[string]any)
+	if ok {
+		for _, name := range req.Names {
+			refreshErr, ok := refreshErrors[name]
+			if ok {
+				fmt.Fprintf(os.Stderr, i18n.G("Warning: Failed refreshing the identity of %q: %v")+"\n", name, refreshErr)
+			}
+		}
+	}
+
+	return nil
+}
@@ -686,6 +686,22 @@ func (c *cmdInfo) instanceInfo(d incus.InstanceServer, name string, showLog bool
 			fmt.Print(osInfo)
 		}
 
+		// Health check status
+		if inst.State.Health != nil {
+			fmt.Println("\n" + i18n.G("Health:"))
+			healthInfo := fmt.Sprintf("  %s: %s\n", i18n.G("Status"), inst.State.Health.Status)
+			healthInfo += fmt.Sprintf("  %s: %d\n", i18n.G("Failures"), inst.State.Health.Failures)
+			if !inst.State.Health.LastCheck.IsZero() {
+				healthInfo += fmt.Sprintf("  %s: %s\n", i18n.G("Last check"), inst.State.Health.LastCheck.Local().Format(dateLayout))
+			}
+
+			if inst.State.Health.LastError != "" {
+				healthInfo += fmt.Sprintf("  %s: %s\n", i18n.G("Last error"), inst.State.Health.LastError)
+			}
+
+			fmt.Print(healthInfo)
+		}
+
 		fmt.Println("\n" + i18n.G("Resources:"))
 		// Processes
 		fmt.Printf("  "+i18n.G("Processes: %d")+"\n", inst.State.Processes)
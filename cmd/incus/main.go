@@ -183,6 +183,10 @@ Custom commands can be defined through aliases, use "incus alias" to control tho
 	fileCmd := cmdFile{global: &globalCmd}
 	app.AddCommand(fileCmd.Command())
 
+	// fork sub-command
+	forkCmd := cmdFork{global: &globalCmd}
+	app.AddCommand(forkCmd.Command())
+
 	// import sub-command
 	importCmd := cmdImport{global: &globalCmd}
 	app.AddCommand(importCmd.Command())
@@ -267,6 +271,10 @@ Custom commands can be defined through aliases, use "incus alias" to control tho
 	snapshotCmd := cmdSnapshot{global: &globalCmd}
 	app.AddCommand(snapshotCmd.Command())
 
+	// stack sub-command
+	stackCmd := cmdStack{global: &globalCmd}
+	app.AddCommand(stackCmd.Command())
+
 	// storage sub-command
 	storageCmd := cmdStorage{global: &globalCmd}
 	app.AddCommand(storageCmd.Command())
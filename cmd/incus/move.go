@@ -27,6 +27,7 @@ type cmdMove struct {
 	flagTarget            string
 	flagTargetProject     string
 	flagAllowInconsistent bool
+	flagPostcopy          bool
 }
 
 // Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
@@ -67,6 +68,7 @@ incus move <instance>/<old snapshot name> <instance>/<new snapshot name>
 	cmd.Flags().StringVar(&c.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.Flags().StringVar(&c.flagTargetProject, "target-project", "", i18n.G("Copy to a project different from the source")+"``")
 	cmd.Flags().BoolVar(&c.flagAllowInconsistent, "allow-inconsistent", false, i18n.G("Ignore copy errors for volatile files"))
+	cmd.Flags().BoolVar(&c.flagPostcopy, "postcopy", false, i18n.G("Switch the live migration of a virtual machine to post-copy mode"))
 
 	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
@@ -153,6 +155,10 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 
 	stateful := !c.flagStateless
 
+	if c.flagPostcopy && !stateful {
+		return errors.New(i18n.G("--postcopy can't be used with --stateless"))
+	}
+
 	isServerSide := func() bool {
 		// Check if same source and destination.
 		if sourceRemote != destRemote {
@@ -195,6 +201,11 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 			return false
 		}
 
+		// Check if server supports post-copy migration.
+		if c.flagPostcopy && !source.HasExtension("migration_postcopy") {
+			return false
+		}
+
 		return true
 	}()
 
@@ -212,6 +223,7 @@ func (c *cmdMove) Run(cmd *cobra.Command, args []string) error {
 	cpy.flagProfile = c.flagProfile
 	cpy.flagNoProfiles = c.flagNoProfiles
 	cpy.flagAllowInconsistent = c.flagAllowInconsistent
+	cpy.flagPostcopy = c.flagPostcopy
 
 	instanceOnly := c.flagInstanceOnly
 
@@ -282,6 +294,7 @@ func (c *cmdMove) moveInstance(sourceResource string, destResource string, state
 		Pool:         c.flagStorage,
 		Project:      c.flagTargetProject,
 		Live:         stateful,
+		Postcopy:     c.flagPostcopy,
 	}
 
 	// Override profiles.
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v2"
 
+	incus "github.com/lxc/incus/v6/client"
 	cli "github.com/lxc/incus/v6/internal/cmd"
 	"github.com/lxc/incus/v6/internal/i18n"
 	"github.com/lxc/incus/v6/shared/api"
@@ -49,6 +50,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkAttachProfileCmd := cmdNetworkAttachProfile{global: c.global, network: c}
 	cmd.AddCommand(networkAttachProfileCmd.Command())
 
+	// Capture
+	networkCaptureCmd := cmdNetworkCapture{global: c.global, network: c}
+	cmd.AddCommand(networkCaptureCmd.Command())
+
 	// Create
 	networkCreateCmd := cmdNetworkCreate{global: c.global, network: c}
 	cmd.AddCommand(networkCreateCmd.Command())
@@ -101,6 +106,14 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkShowCmd := cmdNetworkShow{global: c.global, network: c}
 	cmd.AddCommand(networkShowCmd.Command())
 
+	// Show firewall
+	networkShowFirewallCmd := cmdNetworkShowFirewall{global: c.global, network: c}
+	cmd.AddCommand(networkShowFirewallCmd.Command())
+
+	// Trace
+	networkTraceCmd := cmdNetworkTrace{global: c.global, network: c}
+	cmd.AddCommand(networkTraceCmd.Command())
+
 	// Unset
 	networkUnsetCmd := cmdNetworkUnset{global: c.global, network: c, networkSet: &networkSetCmd}
 	cmd.AddCommand(networkUnsetCmd.Command())
@@ -113,6 +126,10 @@ func (c *cmdNetwork) Command() *cobra.Command {
 	networkAddressSetCmd := cmdNetworkAddressSet{global: c.global}
 	cmd.AddCommand(networkAddressSetCmd.Command())
 
+	// Floating IP
+	networkFloatingIPCmd := cmdNetworkFloatingIP{global: c.global}
+	cmd.AddCommand(networkFloatingIPCmd.Command())
+
 	// Forward
 	networkForwardCmd := cmdNetworkForward{global: c.global}
 	cmd.AddCommand(networkForwardCmd.Command())
@@ -337,6 +354,125 @@ func (c *cmdNetworkAttachProfile) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Capture.
+type cmdNetworkCapture struct {
+	global  *cmdGlobal
+	network *cmdNetwork
+
+	flagOutput   string
+	flagFilter   string
+	flagDuration int
+	flagPackets  int
+	flagSnaplen  int
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkCapture) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("capture", i18n.G("[<remote>:]<instance> <device name>"))
+	cmd.Short = i18n.G("Capture the traffic of instance network interfaces")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Capture the traffic of instance network interfaces
+
+The capture is taken on the host side of the interface and written in pcap format,
+either to the file provided with --output or to standard output.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus network capture c1 eth0 --duration 30 -o c1.pcap
+    Capture the traffic of the eth0 interface of c1 for 30 seconds.
+
+incus network capture c1 eth0 --filter "tcp port 80" | tcpdump -r -
+    Decode the HTTP traffic of the eth0 interface of c1 as it's captured.`))
+
+	cmd.Flags().StringVarP(&c.flagOutput, "output", "o", "", i18n.G("Write the capture to a file")+"``")
+	cmd.Flags().StringVar(&c.flagFilter, "filter", "", i18n.G("Capture filter (pcap-filter syntax)")+"``")
+	cmd.Flags().IntVar(&c.flagDuration, "duration", 60, i18n.G("Maximum duration of the capture in seconds")+"``")
+	cmd.Flags().IntVarP(&c.flagPackets, "count", "c", 0, i18n.G("Maximum number of packets to capture")+"``")
+	cmd.Flags().IntVar(&c.flagSnaplen, "snaplen", 0, i18n.G("Number of bytes to capture per packet")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		if len(args) == 1 {
+			return c.global.cmpInstanceDeviceNames(args[0])
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkCapture) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing instance name"))
+	}
+
+	// Prepare the output
+	var output *os.File
+	if c.flagOutput == "" || c.flagOutput == "-" {
+		if termios.IsTerminal(getStdoutFd()) {
+			return errors.New(i18n.G("Refusing to write the capture to a terminal, use --output or redirect the output"))
+		}
+
+		output = os.Stdout
+	} else {
+		output, err = os.Create(c.flagOutput)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = output.Close() }()
+	}
+
+	req := api.InstanceCapturePost{
+		Device:   args[1],
+		Filter:   c.flagFilter,
+		Duration: c.flagDuration,
+		Packets:  c.flagPackets,
+		Snaplen:  c.flagSnaplen,
+	}
+
+	dataDone := make(chan bool)
+	captureArgs := incus.InstanceCaptureArgs{
+		Output:   output,
+		DataDone: dataDone,
+	}
+
+	op, err := resource.server.CaptureInstance(resource.name, req, &captureArgs)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the capture to complete
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	<-dataDone
+
+	return nil
+}
+
 // Create.
 type cmdNetworkCreate struct {
 	global  *cmdGlobal
@@ -1706,6 +1842,216 @@ func (c *cmdNetworkShow) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Show firewall.
+type cmdNetworkShowFirewall struct {
+	global  *cmdGlobal
+	network *cmdNetwork
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkShowFirewall) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show-firewall", i18n.G("[<remote>:]<network>"))
+	cmd.Short = i18n.G("Show network firewall rules")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show network firewall rules
+
+The firewall rules of the network currently in the kernel are shown, along with the rules
+which were changed outside of Incus since they were last applied.`))
+
+	cmd.Flags().StringVar(&c.network.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return c.global.cmpNetworks(toComplete)
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkShowFirewall) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	client := resource.server
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing network name"))
+	}
+
+	// Show the firewall rules
+	if c.network.flagTarget != "" {
+		client = client.UseTarget(c.network.flagTarget)
+	}
+
+	firewall, err := client.GetNetworkFirewall(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&firewall)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Trace.
+type cmdNetworkTrace struct {
+	global  *cmdGlobal
+	network *cmdNetwork
+
+	flagProtocol string
+	flagPort     int
+	flagSource   string
+	flagTimeout  int
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkTrace) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("trace", i18n.G("[<remote>:]<instance> <device name> [<destination>]"))
+	cmd.Short = i18n.G("Show and trace the network path of instance network interfaces")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show and trace the network path of instance network interfaces
+
+Without a destination, the network elements the traffic of the interface goes through are shown
+along with the ACLs, forwards, load balancers and peers applying to them.
+
+With a destination, a flow to that address is also traced. OVN networks simulate the flow using ovn-trace,
+while other bridged interfaces wait for matching traffic to be traced through the firewall.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus network trace c1 eth0
+    Show the network path of the eth0 interface of c1.
+
+incus network trace c1 eth0 192.0.2.10 --protocol tcp --port 443
+    Trace a HTTPS connection from the eth0 interface of c1 to 192.0.2.10.`))
+
+	cmd.Flags().StringVar(&c.flagProtocol, "protocol", "", i18n.G("Protocol of the traced flow (tcp, udp or icmp)")+"``")
+	cmd.Flags().IntVar(&c.flagPort, "port", 0, i18n.G("Destination port of the traced flow")+"``")
+	cmd.Flags().StringVar(&c.flagSource, "source", "", i18n.G("Source address of the traced flow")+"``")
+	cmd.Flags().IntVar(&c.flagTimeout, "timeout", 0, i18n.G("Maximum time to wait for matching traffic in seconds")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		if len(args) == 1 {
+			return c.global.cmpInstanceDeviceNames(args[0])
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkTrace) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 3)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing instance name"))
+	}
+
+	paths, err := resource.server.GetInstanceNetworkPath(resource.name, args[1])
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Printf(i18n.G("Network path of %s:")+"\n", path.Device)
+
+		for i, hop := range path.Hops {
+			fmt.Printf("  %d. %s (%s)\n", i+1, hop.Name, hop.Type)
+
+			if len(hop.Addresses) > 0 {
+				fmt.Printf("     "+i18n.G("Addresses: %s")+"\n", strings.Join(hop.Addresses, ", "))
+			}
+
+			if len(hop.ACLs) > 0 {
+				fmt.Printf("     "+i18n.G("ACLs: %s")+"\n", strings.Join(hop.ACLs, ", "))
+			}
+
+			if len(hop.Forwards) > 0 {
+				fmt.Printf("     "+i18n.G("Forwards: %s")+"\n", strings.Join(hop.Forwards, ", "))
+			}
+
+			if len(hop.LoadBalancers) > 0 {
+				fmt.Printf("     "+i18n.G("Load balancers: %s")+"\n", strings.Join(hop.LoadBalancers, ", "))
+			}
+
+			if len(hop.Peers) > 0 {
+				fmt.Printf("     "+i18n.G("Peers: %s")+"\n", strings.Join(hop.Peers, ", "))
+			}
+		}
+	}
+
+	if len(args) < 3 {
+		return nil
+	}
+
+	req := api.InstanceNetworkTracePost{
+		Device:          args[1],
+		Protocol:        c.flagProtocol,
+		Source:          c.flagSource,
+		Destination:     args[2],
+		DestinationPort: c.flagPort,
+		Timeout:         c.flagTimeout,
+	}
+
+	trace, err := resource.server.TraceInstanceNetwork(resource.name, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("")
+	fmt.Printf(i18n.G("Verdict: %s")+"\n", trace.Verdict)
+
+	if len(trace.Output) > 0 {
+		fmt.Println(i18n.G("Trace:"))
+
+		for _, line := range trace.Output {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	return nil
+}
+
 // Unset.
 type cmdNetworkUnset struct {
 	global     *cmdGlobal
@@ -42,6 +42,10 @@ func (c *cmdNetworkACL) Command() *cobra.Command {
 	networkACLShowLogCmd := cmdNetworkACLShowLog{global: c.global, networkACL: c}
 	cmd.AddCommand(networkACLShowLogCmd.Command())
 
+	// Show state.
+	networkACLShowStateCmd := cmdNetworkACLShowState{global: c.global, networkACL: c}
+	cmd.AddCommand(networkACLShowStateCmd.Command())
+
 	// Get.
 	networkACLGetCmd := cmdNetworkACLGet{global: c.global, networkACL: c}
 	cmd.AddCommand(networkACLGetCmd.Command())
@@ -305,6 +309,103 @@ func (c *cmdNetworkACLShowLog) Run(cmd *cobra.Command, args []string) error {
 	return err
 }
 
+// Show state.
+type cmdNetworkACLShowState struct {
+	global     *cmdGlobal
+	networkACL *cmdNetworkACL
+
+	flagFormat string
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkACLShowState) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show-state", i18n.G("[<remote>:]<ACL>"))
+	cmd.Short = i18n.G("Show network ACL rule counters")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Show network ACL rule counters
+
+The counters are the number of packets and bytes matched by each rule across all cluster members.`))
+	cmd.RunE = c.Run
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", c.global.defaultListFormat(), i18n.G(`Format (csv|json|table|yaml|compact|markdown), use suffix ",noheader" to disable headers and ",header" to enable it if missing, e.g. csv,header`)+"``")
+
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return cli.ValidateFlagFormatForListOutput(cmd.Flag("format").Value.String())
+	}
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpNetworkACLs(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkACLShowState) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing network ACL name"))
+	}
+
+	// Get the ACL and its rule counters.
+	netACL, _, err := resource.server.GetNetworkACL(resource.name)
+	if err != nil {
+		return err
+	}
+
+	aclState, err := resource.server.GetNetworkACLState(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	addRows := func(direction string, rules []api.NetworkACLRule, counters []api.NetworkACLRuleCounters) {
+		for i, rule := range rules {
+			if i >= len(counters) {
+				break
+			}
+
+			data = append(data, []string{
+				direction,
+				fmt.Sprintf("%d", i),
+				rule.Action,
+				rule.Description,
+				fmt.Sprintf("%d", counters[i].Packets),
+				fmt.Sprintf("%d", counters[i].Bytes),
+			})
+		}
+	}
+
+	addRows("ingress", netACL.Ingress, aclState.Ingress)
+	addRows("egress", netACL.Egress, aclState.Egress)
+
+	header := []string{
+		i18n.G("DIRECTION"),
+		i18n.G("RULE"),
+		i18n.G("ACTION"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("PACKETS"),
+		i18n.G("BYTES"),
+	}
+
+	return cli.RenderTable(os.Stdout, c.flagFormat, header, data, aclState)
+}
+
 // Get.
 type cmdNetworkACLGet struct {
 	global     *cmdGlobal
@@ -52,7 +52,8 @@ Pre-defined column shorthand chars:
   a - Address
   t - Type
   n - NAT
-  m - Mac Address`))
+  m - Mac Address
+  i - IPAM`))
 
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.MaximumNArgs(1)
@@ -79,6 +80,7 @@ func (c *cmdNetworkListAllocations) parseColumns() ([]networkAllocationColumn, e
 		't': {i18n.G("TYPE"), c.typeColumnData},
 		'n': {i18n.G("NAT"), c.natColumnData},
 		'm': {i18n.G("MAC ADDRESS"), c.macAddressColumnData},
+		'i': {i18n.G("IPAM"), c.ipamColumnData},
 	}
 
 	columnList := strings.Split(c.flagColumns, ",")
@@ -127,6 +129,10 @@ func (c *cmdNetworkListAllocations) macAddressColumnData(alloc api.NetworkAlloca
 	return alloc.Hwaddr
 }
 
+func (c *cmdNetworkListAllocations) ipamColumnData(alloc api.NetworkAllocations) string {
+	return alloc.IPAM
+}
+
 // Run runs the actual command logic.
 func (c *cmdNetworkListAllocations) Run(_ *cobra.Command, args []string) error {
 	remote := ""
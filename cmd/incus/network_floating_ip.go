@@ -0,0 +1,686 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/termios"
+)
+
+type cmdNetworkFloatingIP struct {
+	global *cmdGlobal
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIP) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("floating-ip")
+	cmd.Short = i18n.G("Manage floating IPs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Manage floating IPs
+
+Floating IPs are addresses allocated from the floating IP ranges of a network
+which can be moved between instances without changing the address clients connect to.`))
+
+	// List.
+	networkFloatingIPListCmd := cmdNetworkFloatingIPList{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPListCmd.Command())
+
+	// Show.
+	networkFloatingIPShowCmd := cmdNetworkFloatingIPShow{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPShowCmd.Command())
+
+	// Create.
+	networkFloatingIPCreateCmd := cmdNetworkFloatingIPCreate{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPCreateCmd.Command())
+
+	// Attach.
+	networkFloatingIPAttachCmd := cmdNetworkFloatingIPAttach{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPAttachCmd.Command())
+
+	// Detach.
+	networkFloatingIPDetachCmd := cmdNetworkFloatingIPDetach{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPDetachCmd.Command())
+
+	// Edit.
+	networkFloatingIPEditCmd := cmdNetworkFloatingIPEdit{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPEditCmd.Command())
+
+	// Delete.
+	networkFloatingIPDeleteCmd := cmdNetworkFloatingIPDelete{global: c.global, networkFloatingIP: c}
+	cmd.AddCommand(networkFloatingIPDeleteCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, _ []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// parseAddress parses a "[<remote>:]<address>" argument.
+// As IPv6 addresses contain colons, anything that parses as an IP is used as is against the default remote.
+func (c *cmdNetworkFloatingIP) parseAddress(arg string) (*remoteResource, error) {
+	if net.ParseIP(arg) != nil {
+		resources, err := c.global.parseServers("")
+		if err != nil {
+			return nil, err
+		}
+
+		resources[0].name = arg
+
+		return &resources[0], nil
+	}
+
+	resources, err := c.global.parseServers(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	if resources[0].name == "" {
+		return nil, errors.New(i18n.G("Missing floating IP address"))
+	}
+
+	return &resources[0], nil
+}
+
+// List.
+type cmdNetworkFloatingIPList struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+
+	flagFormat  string
+	flagColumns string
+}
+
+type networkFloatingIPColumn struct {
+	Name string
+	Data func(api.NetworkFloatingIP) string
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List available floating IPs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List available floating IPs
+
+Default column layout: anidtD
+
+== Columns ==
+The -c option takes a comma separated list of arguments that control
+which floating IP attributes to output when displaying in table or csv
+format.
+
+Commas between consecutive shorthand chars are optional.
+
+Pre-defined column shorthand chars:
+a - Address
+n - Network
+p - Pool
+i - Instance
+d - Device
+t - Target Address
+D - Description
+L - Location of the floating IP (e.g. its cluster member)`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", c.global.defaultListFormat(), i18n.G(`Format (csv|json|table|yaml|compact|markdown), use suffix ",noheader" to disable headers and ",header" to enable it if missing, e.g. csv,header`)+"``")
+	cmd.Flags().StringVarP(&c.flagColumns, "columns", "c", defaultNetworkFloatingIPColumns, i18n.G("Columns")+"``")
+
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return cli.ValidateFlagFormatForListOutput(cmd.Flag("format").Value.String())
+	}
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpRemotes(toComplete, false)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+const defaultNetworkFloatingIPColumns = "anidtD"
+
+func (c *cmdNetworkFloatingIPList) parseColumns(clustered bool) ([]networkFloatingIPColumn, error) {
+	columnsShorthandMap := map[rune]networkFloatingIPColumn{
+		'a': {i18n.G("ADDRESS"), func(f api.NetworkFloatingIP) string { return f.Address }},
+		'n': {i18n.G("NETWORK"), func(f api.NetworkFloatingIP) string { return f.Network }},
+		'p': {i18n.G("POOL"), func(f api.NetworkFloatingIP) string { return f.Pool }},
+		'i': {i18n.G("INSTANCE"), func(f api.NetworkFloatingIP) string { return f.Instance }},
+		'd': {i18n.G("DEVICE"), func(f api.NetworkFloatingIP) string { return f.Device }},
+		't': {i18n.G("TARGET ADDRESS"), func(f api.NetworkFloatingIP) string { return f.TargetAddress }},
+		'D': {i18n.G("DESCRIPTION"), func(f api.NetworkFloatingIP) string { return f.Description }},
+		'L': {i18n.G("LOCATION"), func(f api.NetworkFloatingIP) string { return f.Location }},
+	}
+
+	columnList := strings.Split(c.flagColumns, ",")
+	columns := []networkFloatingIPColumn{}
+	if c.flagColumns == defaultNetworkFloatingIPColumns && clustered {
+		columnList = append(columnList, "L")
+	}
+
+	for _, columnEntry := range columnList {
+		if columnEntry == "" {
+			return nil, fmt.Errorf(i18n.G("Empty column entry (redundant, leading or trailing command) in '%s'"), c.flagColumns)
+		}
+
+		for _, columnRune := range columnEntry {
+			column, ok := columnsShorthandMap[columnRune]
+			if !ok {
+				return nil, fmt.Errorf(i18n.G("Unknown column shorthand char '%c' in '%s'"), columnRune, columnEntry)
+			}
+
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, nil
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.parseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name != "" {
+		return errors.New(i18n.G("Filtering isn't supported yet"))
+	}
+
+	floatingIPs, err := resource.server.GetNetworkFloatingIPs()
+	if err != nil {
+		return err
+	}
+
+	// Parse column flags.
+	columns, err := c.parseColumns(resource.server.IsClustered())
+	if err != nil {
+		return err
+	}
+
+	data := make([][]string, 0, len(floatingIPs))
+	for _, floatingIP := range floatingIPs {
+		line := []string{}
+		for _, column := range columns {
+			line = append(line, column.Data(floatingIP))
+		}
+
+		data = append(data, line)
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+
+	header := []string{}
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+
+	return cli.RenderTable(os.Stdout, c.flagFormat, header, data, floatingIPs)
+}
+
+// Show.
+type cmdNetworkFloatingIPShow struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<address>"))
+	cmd.Short = i18n.G("Show floating IP configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Show floating IP configurations"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resource, err := c.networkFloatingIP.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Show the floating IP config.
+	floatingIP, _, err := resource.server.GetNetworkFloatingIP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&floatingIP)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
+
+// Create.
+type cmdNetworkFloatingIPCreate struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+
+	flagDescription string
+	flagInstance    string
+	flagDevice      string
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPCreate) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("create", i18n.G("[<remote>:]<network> [<address>]"))
+	cmd.Aliases = []string{"add"}
+	cmd.Short = i18n.G("Allocate new floating IPs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Allocate new floating IPs
+
+If no address is provided, the next free one from the network's floating IP ranges is used.`))
+	cmd.Example = cli.FormatSection("", i18n.G(`incus network floating-ip create ovn0
+    Allocate the next free floating IP for network ovn0
+
+incus network floating-ip create ovn0 192.0.2.50 --instance c1
+    Allocate 192.0.2.50 on network ovn0 and attach it to instance c1`))
+
+	cmd.RunE = c.Run
+
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Floating IP description")+"``")
+	cmd.Flags().StringVar(&c.flagInstance, "instance", "", i18n.G("Instance to attach the floating IP to")+"``")
+	cmd.Flags().StringVar(&c.flagDevice, "device", "", i18n.G("Instance NIC to attach the floating IP to")+"``")
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpNetworks(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPCreate) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing network name"))
+	}
+
+	// If stdin isn't a terminal, read yaml from it.
+	var floatingIPPut api.NetworkFloatingIPPut
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		err = yaml.UnmarshalStrict(contents, &floatingIPPut)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.flagDescription != "" {
+		floatingIPPut.Description = c.flagDescription
+	}
+
+	if c.flagInstance != "" {
+		floatingIPPut.Instance = c.flagInstance
+	}
+
+	if c.flagDevice != "" {
+		floatingIPPut.Device = c.flagDevice
+	}
+
+	// Create the floating IP.
+	floatingIP := api.NetworkFloatingIPsPost{
+		Network:              resource.name,
+		NetworkFloatingIPPut: floatingIPPut,
+	}
+
+	if len(args) > 1 {
+		floatingIP.Address = args[1]
+	}
+
+	floatingIP.Normalise()
+
+	err = resource.server.CreateNetworkFloatingIP(floatingIP)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		if floatingIP.Address != "" {
+			fmt.Printf(i18n.G("Floating IP %s created")+"\n", floatingIP.Address)
+		} else {
+			fmt.Println(i18n.G("Floating IP created"))
+		}
+	}
+
+	return nil
+}
+
+// Attach.
+type cmdNetworkFloatingIPAttach struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPAttach) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("attach", i18n.G("[<remote>:]<address> <instance> [<device>]"))
+	cmd.Short = i18n.G("Attach floating IPs to instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Attach floating IPs to instances
+
+If the floating IP is already attached to another instance, it is moved.`))
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return c.global.cmpInstances(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPAttach) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 3)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resource, err := c.networkFloatingIP.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Get the current floating IP.
+	floatingIP, etag, err := resource.server.GetNetworkFloatingIP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	put := floatingIP.Writable()
+	put.Instance = args[1]
+	put.Device = ""
+	if len(args) > 2 {
+		put.Device = args[2]
+	}
+
+	return resource.server.UpdateNetworkFloatingIP(resource.name, put, etag)
+}
+
+// Detach.
+type cmdNetworkFloatingIPDetach struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPDetach) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("detach", i18n.G("[<remote>:]<address>"))
+	cmd.Short = i18n.G("Detach floating IPs from instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Detach floating IPs from instances
+
+The address remains allocated until the floating IP is deleted.`))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPDetach) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resource, err := c.networkFloatingIP.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Get the current floating IP.
+	floatingIP, etag, err := resource.server.GetNetworkFloatingIP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if floatingIP.Instance == "" {
+		return fmt.Errorf(i18n.G("Floating IP %s isn't attached to any instance"), resource.name)
+	}
+
+	put := floatingIP.Writable()
+	put.Instance = ""
+	put.Device = ""
+
+	return resource.server.UpdateNetworkFloatingIP(resource.name, put, etag)
+}
+
+// Edit.
+type cmdNetworkFloatingIPEdit struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPEdit) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("edit", i18n.G("[<remote>:]<address>"))
+	cmd.Short = i18n.G("Edit floating IP configurations as YAML")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Edit floating IP configurations as YAML"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdNetworkFloatingIPEdit) helpTemplate() string {
+	return i18n.G(
+		`### This is a YAML representation of the floating IP.
+### Any line starting with a '# will be ignored.
+###
+### A floating IP is an address which can be attached to an instance NIC.
+###
+### An example would look like:
+### description: web server
+### instance: c1
+### device: eth0
+### address: 192.0.2.50
+### network: ovn0
+### pool: UPLINK
+### target_address: 10.0.0.2
+### location: none
+###
+### Note that only the description, instance and device can be changed.`)
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPEdit) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resource, err := c.networkFloatingIP.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	client := resource.server
+
+	// If stdin isn't a terminal, read text from it
+	if !termios.IsTerminal(getStdinFd()) {
+		contents, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		// Allow output of `incus network floating-ip show` command to be passed in here, but only take the
+		// contents of the NetworkFloatingIPPut fields when updating. The other fields are silently discarded.
+		newData := api.NetworkFloatingIP{}
+		err = yaml.UnmarshalStrict(contents, &newData)
+		if err != nil {
+			return err
+		}
+
+		newData.NetworkFloatingIPPut.Normalise()
+
+		return client.UpdateNetworkFloatingIP(resource.name, newData.Writable(), "")
+	}
+
+	// Get the current config.
+	floatingIP, etag, err := client.GetNetworkFloatingIP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&floatingIP)
+	if err != nil {
+		return err
+	}
+
+	// Spawn the editor.
+	content, err := textEditor("", []byte(c.helpTemplate()+"\n\n"+string(data)))
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Parse the text received from the editor.
+		newData := api.NetworkFloatingIP{} // We show the full info, but only send the writable fields.
+		err = yaml.UnmarshalStrict(content, &newData)
+		if err == nil {
+			newData.NetworkFloatingIPPut.Normalise()
+			err = client.UpdateNetworkFloatingIP(resource.name, newData.Writable(), etag)
+		}
+
+		// Respawn the editor.
+		if err != nil {
+			fmt.Fprintf(os.Stderr, i18n.G("Config parsing error: %s")+"\n", err)
+			fmt.Println(i18n.G("Press enter to open the editor again or ctrl+c to abort change"))
+
+			_, err := os.Stdin.Read(make([]byte, 1))
+			if err != nil {
+				return err
+			}
+
+			content, err = textEditor("", content)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// Delete.
+type cmdNetworkFloatingIPDelete struct {
+	global            *cmdGlobal
+	networkFloatingIP *cmdNetworkFloatingIP
+}
+
+// Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
+func (c *cmdNetworkFloatingIPDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("delete", i18n.G("[<remote>:]<address>"))
+	cmd.Aliases = []string{"rm", "remove"}
+	cmd.Short = i18n.G("Release floating IPs")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Release floating IPs"))
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+// Run runs the actual command logic.
+func (c *cmdNetworkFloatingIPDelete) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote.
+	resource, err := c.networkFloatingIP.parseAddress(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Delete the floating IP.
+	err = resource.server.DeleteNetworkFloatingIP(resource.name)
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Floating IP %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"maps"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 
@@ -1096,6 +1097,8 @@ type cmdNetworkLoadBalancerPort struct {
 	networkLoadBalancer *cmdNetworkLoadBalancer
 	flagRemoveForce     bool
 	flagDescription     string
+	flagHost            string
+	flagPath            string
 }
 
 // Command returns a cobra.Command for use with (*cobra.Command).AddCommand.
@@ -1120,11 +1123,21 @@ func (c *cmdNetworkLoadBalancerPort) CommandAdd() *cobra.Command {
 	cmd.Use = usage("add", i18n.G("[<remote>:]<network> <listen_address> <protocol> <listen_port(s)> <backend_name>[,<backend_name>...]"))
 	cmd.Aliases = []string{"create"}
 	cmd.Short = i18n.G("Add ports to a load balancer")
-	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G("Add ports to a load balancer"))
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(`Add ports to a load balancer
+
+The protocol is one of tcp, udp, http, https or tls.
+With http, https and tls, requests can be routed to different backends based on their host and path.`))
+	cmd.Example = cli.FormatSection("", i18n.G(`incus network load-balancer port add mynet 192.0.2.1 tcp 22 ssh
+    Forward port 22 of 192.0.2.1 to the "ssh" backend.
+
+incus network load-balancer port add mynet 192.0.2.1 https 443 api --host www.example.net --path /api
+    Send the HTTPS requests for www.example.net/api to the "api" backend.`))
 	cmd.RunE = c.RunAdd
 
 	cmd.Flags().StringVar(&c.networkLoadBalancer.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Port description")+"``")
+	cmd.Flags().StringVar(&c.flagHost, "host", "", i18n.G("Host name to route (http, https and tls only)")+"``")
+	cmd.Flags().StringVar(&c.flagPath, "path", "", i18n.G("Path prefix to route (http and https only)")+"``")
 
 	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) == 0 {
@@ -1183,6 +1196,8 @@ func (c *cmdNetworkLoadBalancerPort) RunAdd(cmd *cobra.Command, args []string) e
 		ListenPort:    args[3],
 		TargetBackend: util.SplitNTrimSpace(args[4], ",", -1, false),
 		Description:   c.flagDescription,
+		Host:          c.flagHost,
+		Path:          c.flagPath,
 	}
 
 	loadBalancer.Ports = append(loadBalancer.Ports, port)
@@ -1360,23 +1375,34 @@ func (c *cmdNetworkLoadBalancerInfo) Run(cmd *cobra.Command, args []string) erro
 	}
 
 	// Render the state.
-	if lbState.BackendHealth == nil {
-		// Currently the only field in the state endpoint is the backend health, fail if it's missing.
+	if lbState.BackendHealth == nil && lbState.BackendMetrics == nil {
 		return errors.New(i18n.G("No load-balancer health information available"))
 	}
 
-	fmt.Println(i18n.G("Backend health:"))
-	for backend, info := range lbState.BackendHealth {
-		if len(info.Ports) == 0 {
-			continue
-		}
+	if lbState.BackendHealth != nil {
+		fmt.Println(i18n.G("Backend health:"))
+		for backend, info := range lbState.BackendHealth {
+			if len(info.Ports) == 0 {
+				continue
+			}
 
-		fmt.Printf("  %s (%s):\n", backend, info.Address)
-		for _, port := range info.Ports {
-			fmt.Printf("    - %s/%d: %s\n", port.Protocol, port.Port, port.Status)
+			fmt.Printf("  %s (%s):\n", backend, info.Address)
+			for _, port := range info.Ports {
+				fmt.Printf("    - %s/%d: %s\n", port.Protocol, port.Port, port.Status)
+			}
+
+			fmt.Println("")
 		}
+	}
+
+	if lbState.BackendMetrics != nil {
+		fmt.Println(i18n.G("Backend requests:"))
 
-		fmt.Println("")
+		backends := slices.Sorted(maps.Keys(lbState.BackendMetrics))
+		for _, backend := range backends {
+			metrics := lbState.BackendMetrics[backend]
+			fmt.Printf("  %s: %s\n", backend, fmt.Sprintf(i18n.G("%d requests, %d failures"), metrics.Requests, metrics.Failures))
+		}
 	}
 
 	return nil
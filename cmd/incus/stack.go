@@ -0,0 +1,506 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	incus "github.com/lxc/incus/v6/client"
+	cli "github.com/lxc/incus/v6/internal/cmd"
+	"github.com/lxc/incus/v6/internal/i18n"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+type cmdStack struct {
+	global *cmdGlobal
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStack) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("stack")
+	cmd.Short = i18n.G("Manage stacks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Manage stacks
+
+Stacks are groups of profiles, networks, storage volumes, instances and
+network forwards described by a YAML manifest and applied as a whole.`))
+
+	// Apply
+	stackApplyCmd := cmdStackApply{global: c.global, stack: c}
+	cmd.AddCommand(stackApplyCmd.Command())
+
+	// Delete
+	stackDeleteCmd := cmdStackDelete{global: c.global, stack: c}
+	cmd.AddCommand(stackDeleteCmd.Command())
+
+	// Diff
+	stackDiffCmd := cmdStackDiff{global: c.global, stack: c}
+	cmd.AddCommand(stackDiffCmd.Command())
+
+	// List
+	stackListCmd := cmdStackList{global: c.global, stack: c}
+	cmd.AddCommand(stackListCmd.Command())
+
+	// Show
+	stackShowCmd := cmdStackShow{global: c.global, stack: c}
+	cmd.AddCommand(stackShowCmd.Command())
+
+	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
+	cmd.Args = cobra.NoArgs
+	cmd.Run = func(cmd *cobra.Command, _ []string) { _ = cmd.Usage() }
+	return cmd
+}
+
+// readManifest reads a stack manifest from a YAML file, or from stdin if the path is "-".
+func (c *cmdStack) readManifest(path string) (*api.StackManifest, error) {
+	var contents []byte
+	var err error
+	if path == "-" {
+		contents, err = io.ReadAll(os.Stdin)
+	} else {
+		contents, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := api.StackManifest{}
+	err = yaml.Unmarshal(contents, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("Failed parsing stack manifest: %w"), err)
+	}
+
+	return &manifest, nil
+}
+
+// Apply.
+type cmdStackApply struct {
+	global *cmdGlobal
+	stack  *cmdStack
+
+	flagAdopt       bool
+	flagDescription string
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStackApply) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("apply", i18n.G("[<remote>:]<stack> <manifest>"))
+	cmd.Short = i18n.G("Apply stack manifests")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Apply stack manifests
+
+The stack is created if it doesn't exist yet. Otherwise its resources are
+updated to match the manifest. The resources which were removed from it are
+deleted if the stack created them, and left alone otherwise. If any change
+fails, the changes already made are reverted.
+
+Existing resources which aren't managed by the stack are refused, unless
+--adopt is given to have the stack manage them.
+
+Config keys set in the manifest are applied to the resources and the keys
+which were removed from the manifest since it was last applied are unset.
+Other config keys are left alone. Devices, profiles and ports are replaced
+by the ones of the manifest.
+
+The manifest is read from stdin if "-" is given as its path.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus stack apply webapp webapp.yaml
+    Create or update the stack webapp with the resources of webapp.yaml`))
+
+	cmd.Flags().BoolVar(&c.flagAdopt, "adopt", false, i18n.G("Adopt the existing resources of the manifest"))
+	cmd.Flags().StringVar(&c.flagDescription, "description", "", i18n.G("Stack description")+"``")
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpStacks(toComplete)
+		}
+
+		if len(args) == 1 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run actually performs the action.
+func (c *cmdStackApply) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing stack name"))
+	}
+
+	manifest, err := c.stack.readManifest(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Create the stack if it doesn't exist yet, update it otherwise.
+	var op incus.Operation
+	stack, etag, err := resource.server.GetStack(resource.name)
+	if err != nil {
+		if !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return err
+		}
+
+		req := api.StacksPost{Name: resource.name}
+		req.Description = c.flagDescription
+		req.Manifest = *manifest
+
+		op, err = resource.server.CreateStack(req, c.flagAdopt)
+	} else {
+		put := stack.Writable()
+		if cmd.Flags().Changed("description") {
+			put.Description = c.flagDescription
+		}
+
+		put.Manifest = *manifest
+
+		op, err = resource.server.UpdateStack(resource.name, put, c.flagAdopt, etag)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Stack %s applied")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Delete.
+type cmdStackDelete struct {
+	global *cmdGlobal
+	stack  *cmdStack
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStackDelete) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("delete", i18n.G("[<remote>:]<stack>"))
+	cmd.Aliases = []string{"rm", "remove"}
+	cmd.Short = i18n.G("Delete stacks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Delete stacks
+
+This deletes the resources the stack created. The resources it adopted are
+left alone.`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpStacks(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run actually performs the action.
+func (c *cmdStackDelete) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing stack name"))
+	}
+
+	// Delete the stack
+	op, err := resource.server.DeleteStack(resource.name)
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	if !c.global.flagQuiet {
+		fmt.Printf(i18n.G("Stack %s deleted")+"\n", resource.name)
+	}
+
+	return nil
+}
+
+// Diff.
+type cmdStackDiff struct {
+	global *cmdGlobal
+	stack  *cmdStack
+
+	flagAdopt  bool
+	flagFormat string
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStackDiff) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("diff", i18n.G("[<remote>:]<stack> <manifest>"))
+	cmd.Short = i18n.G("Show the changes needed to apply stack manifests")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show the changes needed to apply stack manifests
+
+The manifest is read from stdin if "-" is given as its path.`))
+	cmd.Example = cli.FormatSection("", i18n.G(
+		`incus stack diff webapp webapp.yaml
+    Show the resources which "incus stack apply webapp webapp.yaml" would create, update or delete`))
+
+	cmd.Flags().BoolVar(&c.flagAdopt, "adopt", false, i18n.G("Adopt the existing resources of the manifest"))
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", c.global.defaultListFormat(), i18n.G(`Format (csv|json|table|yaml|compact|markdown), use suffix ",noheader" to disable headers and ",header" to enable it if missing, e.g. csv,header`)+"``")
+
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return cli.ValidateFlagFormatForListOutput(cmd.Flag("format").Value.String())
+	}
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpStacks(toComplete)
+		}
+
+		if len(args) == 1 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run actually performs the action.
+func (c *cmdStackDiff) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 2, 2)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing stack name"))
+	}
+
+	manifest, err := c.stack.readManifest(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff, err := resource.server.GetStackDiff(resource.name, api.StackPut{Manifest: *manifest}, c.flagAdopt)
+	if err != nil {
+		return err
+	}
+
+	// Keep the order of the changes, as it's the order they are applied in.
+	data := [][]string{}
+	for _, change := range diff.Changes {
+		data = append(data, []string{change.Action, change.Type, change.Name, strings.Join(change.Fields, ", ")})
+	}
+
+	header := []string{
+		i18n.G("ACTION"),
+		i18n.G("TYPE"),
+		i18n.G("NAME"),
+		i18n.G("FIELDS"),
+	}
+
+	return cli.RenderTable(os.Stdout, c.flagFormat, header, data, diff.Changes)
+}
+
+// List.
+type cmdStackList struct {
+	global *cmdGlobal
+	stack  *cmdStack
+
+	flagFormat string
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStackList) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("list", i18n.G("[<remote>:]"))
+	cmd.Aliases = []string{"ls"}
+	cmd.Short = i18n.G("List stacks")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`List stacks`))
+
+	cmd.Flags().StringVarP(&c.flagFormat, "format", "f", c.global.defaultListFormat(), i18n.G(`Format (csv|json|table|yaml|compact|markdown), use suffix ",noheader" to disable headers and ",header" to enable it if missing, e.g. csv,header`)+"``")
+
+	cmd.PreRunE = func(cmd *cobra.Command, _ []string) error {
+		return cli.ValidateFlagFormatForListOutput(cmd.Flag("format").Value.String())
+	}
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpRemotes(toComplete, false)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run actually performs the action.
+func (c *cmdStackList) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 0, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	remote := ""
+	if len(args) > 0 {
+		remote = args[0]
+	}
+
+	resources, err := c.global.parseServers(remote)
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	// List the stacks
+	stacks, err := resource.server.GetStacks()
+	if err != nil {
+		return err
+	}
+
+	data := [][]string{}
+	for _, stack := range stacks {
+		data = append(data, []string{stack.Name, stack.Description, fmt.Sprintf("%d", len(stack.UsedBy))})
+	}
+
+	sort.Sort(cli.SortColumnsNaturally(data))
+
+	header := []string{
+		i18n.G("NAME"),
+		i18n.G("DESCRIPTION"),
+		i18n.G("RESOURCES"),
+	}
+
+	return cli.RenderTable(os.Stdout, c.flagFormat, header, data, stacks)
+}
+
+// Show.
+type cmdStackShow struct {
+	global *cmdGlobal
+	stack  *cmdStack
+}
+
+// Command returns a cobra command for inclusion.
+func (c *cmdStackShow) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("show", i18n.G("[<remote>:]<stack>"))
+	cmd.Short = i18n.G("Show stack configurations")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Show stack configurations`))
+
+	cmd.RunE = c.Run
+
+	cmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return c.global.cmpStacks(toComplete)
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cmd
+}
+
+// Run actually performs the action.
+func (c *cmdStackShow) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	exit, err := c.global.checkArgs(cmd, args, 1, 1)
+	if exit {
+		return err
+	}
+
+	// Parse remote
+	resources, err := c.global.parseServers(args[0])
+	if err != nil {
+		return err
+	}
+
+	resource := resources[0]
+
+	if resource.name == "" {
+		return errors.New(i18n.G("Missing stack name"))
+	}
+
+	// Show the stack
+	stack, _, err := resource.server.GetStack(resource.name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&stack)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s", data)
+
+	return nil
+}
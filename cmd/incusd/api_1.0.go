@@ -55,6 +55,9 @@ var api10 = []APIEndpoint{
 	instanceBackupExportCmd,
 	instanceBackupsCmd,
 	instanceCmd,
+	instanceCaptureCmd,
+	instanceForkCmd,
+	instanceAttestationCmd,
 	instanceConsoleCmd,
 	instanceExecCmd,
 	instanceFileCmd,
@@ -64,12 +67,16 @@ var api10 = []APIEndpoint{
 	instanceLogsCmd,
 	instanceMetadataCmd,
 	instanceMetadataTemplatesCmd,
+	instanceNetworkPathCmd,
+	instanceNetworkTraceCmd,
 	instancesCmd,
 	instanceRebuildCmd,
 	instanceSFTPCmd,
 	instanceSnapshotCmd,
 	instanceSnapshotsCmd,
 	instanceStateCmd,
+	instanceUEFIVarsCmd,
+	instanceUEFIKeysCmd,
 	instanceAccessCmd,
 	instanceDebugMemoryCmd,
 	eventsCmd,
@@ -82,15 +89,19 @@ var api10 = []APIEndpoint{
 	imageSecretCmd,
 	metadataConfigurationCmd,
 	networkCmd,
+	networkFirewallCmd,
 	networkLeasesCmd,
 	networksCmd,
 	networkStateCmd,
 	networkACLCmd,
 	networkACLsCmd,
 	networkACLLogCmd,
+	networkACLStateCmd,
 	networkAddressSetCmd,
 	networkAddressSetsCmd,
 	networkAllocationsCmd,
+	networkFloatingIPCmd,
+	networkFloatingIPsCmd,
 	networkForwardCmd,
 	networkForwardsCmd,
 	networkIntegrationCmd,
@@ -114,6 +125,9 @@ var api10 = []APIEndpoint{
 	projectsCmd,
 	projectStateCmd,
 	projectAccessCmd,
+	stackCmd,
+	stacksCmd,
+	stackDiffCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
 	storagePoolsCmd,
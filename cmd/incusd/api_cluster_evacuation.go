@@ -18,6 +18,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	incus "github.com/lxc/incus/v6/client"
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
 	"github.com/lxc/incus/v6/internal/server/cluster"
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
@@ -25,6 +26,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/instance"
 	"github.com/lxc/incus/v6/internal/server/lifecycle"
 	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/project"
 	"github.com/lxc/incus/v6/internal/server/response"
 	"github.com/lxc/incus/v6/internal/server/scriptlet"
 	"github.com/lxc/incus/v6/internal/server/state"
@@ -233,7 +235,7 @@ func evacuateInstancesFunc(ctx context.Context, inst instance.Instance, opts eva
 	}
 
 	// Find a new location for the instance.
-	sourceMemberInfo, targetMemberInfo, err := evacuateClusterSelectTarget(ctx, opts.s, inst)
+	sourceMemberInfo, targetMemberInfo, err := evacuateClusterSelectTarget(ctx, opts.s, inst, false)
 	if err != nil {
 		if api.StatusErrorCheck(err, http.StatusNotFound) {
 			// Skip migration if no target is available.
@@ -346,9 +348,9 @@ func restoreClusterMember(d *Daemon, r *http.Request) response.Response {
 			metadata["evacuation_progress"] = fmt.Sprintf("Starting %q in project %q", inst.Name(), inst.Project().Name)
 			_ = op.UpdateMetadata(metadata)
 
-			// If configured for stateful stop, try restoring its state.
+			// If configured for stateful stop or hibernated, try restoring its state.
 			action := inst.CanMigrate()
-			if action == "stateful-stop" {
+			if action == "stateful-stop" || internalInstance.Hibernated(inst.LocalConfig()) {
 				err = inst.Start(true)
 			} else {
 				err = inst.Start(false)
@@ -525,7 +527,9 @@ func restoreClusterMemberFunc(inst instance.Instance, op *operations.Operation,
 	return nil
 }
 
-func evacuateClusterSelectTarget(ctx context.Context, s *state.State, inst instance.Instance) (*db.NodeInfo, *db.NodeInfo, error) {
+// evacuateClusterSelectTarget picks the cluster member to move an instance to.
+// When skipSource is set, the current location of the instance is never picked, even if it isn't evacuated.
+func evacuateClusterSelectTarget(ctx context.Context, s *state.State, inst instance.Instance, skipSource bool) (*db.NodeInfo, *db.NodeInfo, error) {
 	var sourceMemberInfo *db.NodeInfo
 	var targetMemberInfo *db.NodeInfo
 
@@ -547,10 +551,14 @@ func evacuateClusterSelectTarget(ctx context.Context, s *state.State, inst insta
 
 		// Filter candidates by group if needed.
 		group := inst.LocalConfig()["volatile.cluster.group"]
-		if group != "" {
+		if group != "" || skipSource {
 			newMembers := make([]db.NodeInfo, 0, len(allMembers))
 			for _, member := range allMembers {
-				if !slices.Contains(member.Groups, group) {
+				if group != "" && !slices.Contains(member.Groups, group) {
+					continue
+				}
+
+				if skipSource && member.Name == inst.Location() {
 					continue
 				}
 
@@ -560,8 +568,11 @@ func evacuateClusterSelectTarget(ctx context.Context, s *state.State, inst insta
 			allMembers = newMembers
 		}
 
-		// Filter offline servers.
-		candidateMembers, err = tx.GetCandidateMembers(ctx, allMembers, []int{inst.Architecture()}, "", nil, s.GlobalConfig.OfflineThreshold())
+		// Filter offline servers and the members outside of the project's allowed cluster groups.
+		instProject := inst.Project()
+		clusterGroupsAllowed := project.GetRestrictedClusterGroups(&instProject)
+
+		candidateMembers, err = tx.GetCandidateMembers(ctx, allMembers, []int{inst.Architecture()}, "", clusterGroupsAllowed, s.GlobalConfig.OfflineThreshold())
 		if err != nil {
 			return err
 		}
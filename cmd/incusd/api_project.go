@@ -22,6 +22,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/instance/drivers/edk2"
 	"github.com/lxc/incus/v6/internal/server/lifecycle"
 	"github.com/lxc/incus/v6/internal/server/network"
 	"github.com/lxc/incus/v6/internal/server/operations"
@@ -1771,6 +1772,15 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		//  shortdesc: Which network names can be used as uplink in this project
 		"restricted.networks.uplinks": validate.Optional(validate.IsListOf(validate.IsAny)),
 
+		// gendoc:generate(entity=project, group=restricted, key=restricted.networks.peers)
+		// Specify a comma-delimited list of projects whose networks can be peered with networks in this project.
+		// Peering between networks of the same project is always allowed.
+		// ---
+		//  type: string
+		//  defaultdesc: empty (no other project)
+		//  shortdesc: Which projects networks in this project can peer with
+		"restricted.networks.peers": validate.Optional(validate.IsListOf(validate.IsAny)),
+
 		// gendoc:generate(entity=project, group=restricted, key=restricted.networks.subnets)
 		// Specify a comma-delimited list of network subnets from the uplink networks that are allocated for use in this project.
 		// Use the form `<uplink>:<subnet>`.
@@ -1797,6 +1807,46 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		//  defaultdesc: `block`
 		//  shortdesc: Whether to prevent creating instance or volume snapshots
 		"restricted.snapshots": isEitherAllowOrBlock,
+
+		// gendoc:generate(entity=project, group=specific, key=secureboot.pk)
+		// Specify a single PEM encoded certificate.
+		// It is enrolled as the Platform Key of new virtual machines using Secure Boot in the project.
+		// ---
+		//  type: string
+		//  shortdesc: Default Secure Boot Platform Key
+		"secureboot.pk": validate.Optional(func(value string) error {
+			return edk2.ValidateSecureBootKeys(edk2.SecureBootKeys{PK: value})
+		}),
+
+		// gendoc:generate(entity=project, group=specific, key=secureboot.kek)
+		// Specify one or more PEM encoded certificates.
+		// They are enrolled as the Key Exchange Keys of new virtual machines using Secure Boot in the project.
+		// ---
+		//  type: string
+		//  shortdesc: Default Secure Boot Key Exchange Keys
+		"secureboot.kek": validate.Optional(func(value string) error {
+			return edk2.ValidateSecureBootKeys(edk2.SecureBootKeys{KEK: value})
+		}),
+
+		// gendoc:generate(entity=project, group=specific, key=secureboot.db)
+		// Specify PEM encoded certificates and hex encoded SHA-256 hashes.
+		// They are enrolled in the signature database of new virtual machines using Secure Boot in the project.
+		// ---
+		//  type: string
+		//  shortdesc: Default Secure Boot allowed signatures
+		"secureboot.db": validate.Optional(func(value string) error {
+			return edk2.ValidateSecureBootKeys(edk2.SecureBootKeys{DB: value})
+		}),
+
+		// gendoc:generate(entity=project, group=specific, key=secureboot.dbx)
+		// Specify PEM encoded certificates and hex encoded SHA-256 hashes.
+		// They are enrolled in the forbidden signature database of new virtual machines using Secure Boot in the project.
+		// ---
+		//  type: string
+		//  shortdesc: Default Secure Boot forbidden signatures
+		"secureboot.dbx": validate.Optional(func(value string) error {
+			return edk2.ValidateSecureBootKeys(edk2.SecureBootKeys{DBX: value})
+		}),
 	}
 
 	// Add the storage pool keys.
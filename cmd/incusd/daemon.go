@@ -24,6 +24,7 @@ import (
 	"github.com/cowsql/go-cowsql/driver"
 	"github.com/gorilla/mux"
 	liblxc "github.com/lxc/go-lxc"
+	miekgdns "github.com/miekg/dns"
 	"golang.org/x/sys/unix"
 
 	internalIO "github.com/lxc/incus/v6/internal/io"
@@ -573,6 +574,35 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 	return false, "", "", nil
 }
 
+// isDaemonForwarded returns whether a unix socket request was made by the daemon itself on behalf of a requestor.
+func isDaemonForwarded(r *http.Request) bool {
+	if r.Header.Get(request.HeaderForwardedUsername) == "" {
+		return false
+	}
+
+	cred, err := ucred.GetCredFromContext(r.Context())
+	if err != nil {
+		return false
+	}
+
+	return int(cred.Pid) == os.Getpid()
+}
+
+// forwardedRequestorContext adds the forwarded requestor data of a request to its context.
+// It is only trusted from other cluster members and from the daemon itself, the identity headers sent by any
+// other client are ignored.
+func forwardedRequestorContext(ctx context.Context, r *http.Request, protocol string) context.Context {
+	if protocol != "cluster" && (protocol != "unix" || !isDaemonForwarded(r)) {
+		return ctx
+	}
+
+	ctx = context.WithValue(ctx, request.CtxForwardedAddress, r.Header.Get(request.HeaderForwardedAddress))
+	ctx = context.WithValue(ctx, request.CtxForwardedUsername, r.Header.Get(request.HeaderForwardedUsername))
+	ctx = context.WithValue(ctx, request.CtxForwardedProtocol, r.Header.Get(request.HeaderForwardedProtocol))
+
+	return ctx
+}
+
 // State creates a new State instance linked to our internal db and os.
 func (d *Daemon) State() *state.State {
 	// If the daemon is shutting down, the context will be cancelled.
@@ -685,12 +715,7 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 			ctx = context.WithValue(ctx, request.CtxProtocol, protocol)
 
 			// Add forwarded requestor data.
-			if protocol == "cluster" {
-				// Add authentication/authorization context data.
-				ctx = context.WithValue(ctx, request.CtxForwardedAddress, r.Header.Get(request.HeaderForwardedAddress))
-				ctx = context.WithValue(ctx, request.CtxForwardedUsername, r.Header.Get(request.HeaderForwardedUsername))
-				ctx = context.WithValue(ctx, request.CtxForwardedProtocol, r.Header.Get(request.HeaderForwardedProtocol))
-			}
+			ctx = forwardedRequestorContext(ctx, r, protocol)
 
 			r = r.WithContext(ctx)
 		} else if untrustedOk && r.Header.Get("X-Incus-authenticated") == "" {
@@ -1527,6 +1552,14 @@ func (d *Daemon) init() error {
 		}
 
 		return resp, nil
+	}, func(name string, updates []miekgdns.RR) error {
+		// Fetch the zone.
+		zone, err := networkZone.LoadByName(d.State(), name)
+		if err != nil {
+			return err
+		}
+
+		return zone.ApplyUpdate(updates)
 	})
 	if dnsAddress != "" {
 		err := d.dns.Start(dnsAddress)
@@ -1695,6 +1728,18 @@ func (d *Daemon) init() error {
 
 		// Remove expired tokens (hourly)
 		d.tasks.Add(autoRemoveExpiredTokensTask(d))
+
+		// Check network firewall rules (every 5 minutes)
+		d.tasks.Add(networkFirewallCheckTask(d))
+
+		// Run instance health checks (every 10 seconds, configurable per instance)
+		d.tasks.Add(instanceHealthCheckTask(d))
+
+		// Start, stop and restart instances (minutely check of configurable cron expression)
+		d.tasks.Add(instanceScheduledActionsTask(d))
+
+		// Adjust instance CPU and memory limits (every minute)
+		d.tasks.Add(instanceAutoscaleTask(d))
 	}
 
 	// Start all background tasks
@@ -2286,6 +2331,12 @@ func (d *Daemon) setupSyslogSocket(enable bool) error {
 		return err
 	}
 
+	// Also turn the ACL log entries of bridge networks into events.
+	err = syslog.ListenKernel(ctx, d.events)
+	if err != nil {
+		logger.Warn("Failed starting kernel log monitor", logger.Ctx{"err": err})
+	}
+
 	return nil
 }
 
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/request"
+)
+
+// TestHelperUnixClient connects to the unix socket given in the environment and waits for stdin to be closed.
+// It is run in a separate process by Test_forwardedRequestorContext to act as another unix socket client.
+func TestHelperUnixClient(t *testing.T) {
+	socketPath := os.Getenv("INCUS_TEST_UNIX_CLIENT")
+	if socketPath == "" {
+		return
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	_, _ = io.Copy(io.Discard, os.Stdin)
+	_ = conn.Close()
+	os.Exit(0)
+}
+
+func Test_forwardedRequestorContext(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "unix.socket")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	defer func() { _ = listener.Close() }()
+
+	// accept returns the server side of the next connection to the socket.
+	accept := func() net.Conn {
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+
+		t.Cleanup(func() { _ = conn.Close() })
+
+		return conn
+	}
+
+	// Connection made by this process, as done by the daemon when acting on behalf of a requestor.
+	ownClient, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+
+	defer func() { _ = ownClient.Close() }()
+
+	ownConn := accept()
+
+	// Connection made by another process.
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperUnixClient")
+	cmd.Env = append(os.Environ(), "INCUS_TEST_UNIX_CLIENT="+socketPath)
+	stdin, err := cmd.StdinPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	defer func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	}()
+
+	otherConn := accept()
+
+	tests := []struct {
+		name      string
+		conn      net.Conn
+		protocol  string
+		headers   bool
+		forwarded bool
+	}{
+		{
+			name:      "Daemon on the unix socket",
+			conn:      ownConn,
+			protocol:  "unix",
+			headers:   true,
+			forwarded: true,
+		},
+		{
+			name:      "Daemon on the unix socket without identity headers",
+			conn:      ownConn,
+			protocol:  "unix",
+			forwarded: false,
+		},
+		{
+			name:      "Other client on the unix socket",
+			conn:      otherConn,
+			protocol:  "unix",
+			headers:   true,
+			forwarded: false,
+		},
+		{
+			name:      "Other cluster member",
+			conn:      ownConn,
+			protocol:  "cluster",
+			headers:   true,
+			forwarded: true,
+		},
+		{
+			name:      "Remote client",
+			conn:      ownConn,
+			protocol:  "tls",
+			headers:   true,
+			forwarded: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequestWithContext(request.SaveConnectionInContext(context.Background(), tt.conn), "GET", "/1.0", nil)
+			require.NoError(t, err)
+
+			if tt.headers {
+				r.Header.Set(request.HeaderForwardedAddress, "192.0.2.1")
+				r.Header.Set(request.HeaderForwardedUsername, "alice")
+				r.Header.Set(request.HeaderForwardedProtocol, "tls")
+			}
+
+			ctx := forwardedRequestorContext(r.Context(), r, tt.protocol)
+			if !tt.forwarded {
+				require.Nil(t, ctx.Value(request.CtxForwardedUsername))
+				require.Nil(t, ctx.Value(request.CtxForwardedProtocol))
+				require.Nil(t, ctx.Value(request.CtxForwardedAddress))
+				return
+			}
+
+			require.Equal(t, r.Header.Get(request.HeaderForwardedUsername), ctx.Value(request.CtxForwardedUsername))
+			require.Equal(t, r.Header.Get(request.HeaderForwardedProtocol), ctx.Value(request.CtxForwardedProtocol))
+			require.Equal(t, r.Header.Get(request.HeaderForwardedAddress), ctx.Value(request.CtxForwardedAddress))
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// swagger:operation POST /1.0/instances/{name}/attestation instances instance_attestation_post
+//
+//	Get an attestation report
+//
+//	Retrieves an attestation report from a confidential virtual machine (Intel TDX or AMD SEV) through its agent.
+//	The provided report data (such as a nonce) is bound into the report.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: attestation
+//	    description: Attestation request
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceAttestationPost"
+//	responses:
+//	  "200":
+//	    description: Attestation report
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/InstanceAttestation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceAttestationPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(errors.New("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different member.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceAttestationPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	reportData, err := base64.StdEncoding.DecodeString(req.ReportData)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid report data: %w", err))
+	}
+
+	if len(reportData) > 64 {
+		return response.BadRequest(errors.New("Report data can't be longer than 64 bytes"))
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	vm, ok := inst.(instance.VM)
+	if !ok {
+		return response.BadRequest(errors.New("Attestation reports are only available for virtual machines"))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(errors.New("Instance is not running"))
+	}
+
+	attestation, err := vm.AttestationReport(reportData)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, attestation)
+}
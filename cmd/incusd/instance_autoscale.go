@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/server/db"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/drivers/qemudefault"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/instance/operationlock"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/metrics"
+	projecthelpers "github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/server/task"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/units"
+)
+
+// instanceAutoscaleState tracks the autoscaling of a single instance between two runs.
+type instanceAutoscaleState struct {
+	// Time and total busy CPU time of the last sample.
+	sampled time.Time
+	cpuBusy float64
+
+	// Time of the last change of each limit.
+	changed map[string]time.Time
+}
+
+// instanceAutoscaler adjusts the CPU and memory limits of the local instances based on their usage.
+type instanceAutoscaler struct {
+	mu     sync.Mutex
+	states map[int]*instanceAutoscaleState
+}
+
+func instanceAutoscaleTask(d *Daemon) (task.Func, task.Schedule) {
+	autoscaler := &instanceAutoscaler{states: map[int]*instanceAutoscaleState{}}
+
+	f := func(ctx context.Context) {
+		autoscaler.run(ctx, d.State())
+	}
+
+	return f, task.Every(time.Minute)
+}
+
+// run samples the usage of the local instances with autoscaling enabled and adjusts their limits.
+// Each cluster member only handles the instances it hosts.
+func (a *instanceAutoscaler) run(ctx context.Context, s *state.State) {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for autoscaling", logger.Ctx{"err": err})
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seen := map[int]bool{}
+
+	for _, inst := range insts {
+		config := inst.ExpandedConfig()
+		if config["autoscale.cpu.max"] == "" && config["autoscale.memory.max"] == "" {
+			continue
+		}
+
+		if !inst.IsRunning() || inst.IsFrozen() {
+			continue
+		}
+
+		seen[inst.ID()] = true
+
+		err := a.autoscale(ctx, s, inst)
+		if err != nil {
+			logger.Warn("Failed autoscaling instance", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "err": err})
+		}
+	}
+
+	// Forget about the instances which are gone, stopped or no longer autoscaled.
+	for id := range a.states {
+		if !seen[id] {
+			delete(a.states, id)
+		}
+	}
+}
+
+// autoscale samples the usage of an instance and adjusts its limits.
+func (a *instanceAutoscaler) autoscale(ctx context.Context, s *state.State, inst instance.Instance) error {
+	config := inst.ExpandedConfig()
+
+	autoscaleConfig, err := internalInstance.ParseAutoscaleConfig(config)
+	if err != nil {
+		return err
+	}
+
+	m, err := inst.UsageMetrics()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	st, ok := a.states[inst.ID()]
+	if !ok {
+		st = &instanceAutoscaleState{changed: map[string]time.Time{}}
+		a.states[inst.ID()] = st
+	}
+
+	// Compute the number of CPUs in use since the last sample.
+	cpuUsed := float64(-1)
+	if len(m.CPU) > 0 {
+		cpuBusy := float64(0)
+		for _, cpu := range m.CPU {
+			cpuBusy += cpu.SecondsUser + cpu.SecondsNice + cpu.SecondsSystem + cpu.SecondsIRQ + cpu.SecondsSoftIRQ + cpu.SecondsSteal
+		}
+
+		if !st.sampled.IsZero() && cpuBusy >= st.cpuBusy {
+			cpuUsed = (cpuBusy - st.cpuBusy) / now.Sub(st.sampled).Seconds()
+		}
+
+		st.sampled = now
+		st.cpuBusy = cpuBusy
+	}
+
+	changes := map[string]string{}
+
+	// Adjust the CPU limit.
+	if autoscaleConfig.CPUMax > 0 && cpuUsed >= 0 && now.Sub(st.changed["limits.cpu"]) >= autoscaleConfig.Cooldown {
+		current, err := instanceAutoscaleCurrentCPU(inst, m)
+		if err == nil {
+			target := autoscaleConfig.CPUTarget(current, cpuUsed)
+			if target != current {
+				changes["limits.cpu"] = strconv.FormatInt(target, 10)
+			}
+		}
+	}
+
+	// Adjust the memory limit.
+	if autoscaleConfig.MemoryMax > 0 && m.Memory.MemTotalBytes > 0 && now.Sub(st.changed["limits.memory"]) >= autoscaleConfig.Cooldown {
+		current, err := instanceAutoscaleCurrentMemory(inst, m)
+		if err == nil {
+			used := int64(m.Memory.MemTotalBytes) - int64(m.Memory.MemAvailableBytes)
+
+			target := autoscaleConfig.MemoryTarget(current, used)
+			if target != current {
+				changes["limits.memory"] = fmt.Sprintf("%dMiB", target/1024/1024)
+			}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Start the cooldown even if the change fails to avoid retrying it every run.
+	for key := range changes {
+		st.changed[key] = now
+	}
+
+	err = instanceAutoscaleApply(ctx, s, inst, changes)
+	if err != nil {
+		logger.Warn("Failed applying autoscaled limits", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "changes": changes, "err": err})
+	}
+
+	return nil
+}
+
+// instanceAutoscaleCurrentCPU returns the current number of CPUs of an instance.
+// Instances using CPU pinning aren't autoscaled.
+func instanceAutoscaleCurrentCPU(inst instance.Instance, m *metrics.Metrics) (int64, error) {
+	value := inst.ExpandedConfig()["limits.cpu"]
+	if value == "" {
+		if inst.Type() == instancetype.VM {
+			return qemudefault.CPUCores, nil
+		}
+
+		return int64(m.CPUs), nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// instanceAutoscaleCurrentMemory returns the current memory limit of an instance in bytes.
+// Instances using a percentage of the host memory aren't autoscaled.
+func instanceAutoscaleCurrentMemory(inst instance.Instance, m *metrics.Metrics) (int64, error) {
+	value := inst.ExpandedConfig()["limits.memory"]
+	if value == "" {
+		if inst.Type() == instancetype.VM {
+			value = qemudefault.MemSize
+		} else {
+			return int64(m.Memory.MemTotalBytes), nil
+		}
+	}
+
+	if strings.HasSuffix(value, "%") {
+		return -1, errors.New("Percentage memory limits can't be autoscaled")
+	}
+
+	return units.ParseByteSizeString(value)
+}
+
+// instanceAutoscaleApply sets new values for the limits of an instance, within the limits of its project.
+// The instance is left alone if its configuration changed since its usage was sampled.
+func instanceAutoscaleApply(ctx context.Context, s *state.State, sampled instance.Instance, changes map[string]string) error {
+	// Hold the operation lock of the instance for the duration of the update.
+	// It is inherited by the update of the instance.
+	op, err := operationlock.Create(sampled.Project().Name, sampled.Name(), nil, operationlock.ActionAutoscale, false, false)
+	if err != nil {
+		return err
+	}
+
+	defer op.Done(nil)
+
+	inst, err := instance.LoadByProjectAndName(s, sampled.Project().Name, sampled.Name())
+	if err != nil {
+		return err
+	}
+
+	if !inst.IsRunning() || !maps.Equal(inst.ExpandedConfig(), sampled.ExpandedConfig()) {
+		logger.Debug("Skipping autoscaling of changed instance", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
+		return nil
+	}
+
+	config := maps.Clone(inst.LocalConfig())
+	maps.Copy(config, changes)
+
+	profileNames := make([]string, 0, len(inst.Profiles()))
+	for _, profile := range inst.Profiles() {
+		profileNames = append(profileNames, profile.Name)
+	}
+
+	req := api.InstancePut{
+		Config:   config,
+		Devices:  inst.LocalDevices().CloneNative(),
+		Profiles: profileNames,
+	}
+
+	// Check the project limits.
+	err = s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		return projecthelpers.AllowInstanceUpdate(tx, inst.Project().Name, inst.Name(), req, inst.LocalConfig())
+	})
+	if err != nil {
+		return err
+	}
+
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Config:       config,
+		Description:  inst.Description(),
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project().Name,
+		ExpiryDate:   inst.ExpiryDate(),
+	}
+
+	oldConfig := maps.Clone(inst.ExpandedConfig())
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{"limits.cpu", "limits.memory"} {
+		value, ok := changes[key]
+		if !ok {
+			continue
+		}
+
+		logger.Info("Autoscaled instance limit", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "key": key, "old": oldConfig[key], "new": value})
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceAutoscaled.Event(inst, map[string]any{"key": key, "old_value": oldConfig[key], "new_value": value}))
+	}
+
+	return nil
+}
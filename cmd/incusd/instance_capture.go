@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/jmap"
+	"github.com/lxc/incus/v6/internal/server/cluster"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/util"
+	"github.com/lxc/incus/v6/shared/ws"
+)
+
+// Default and maximum duration of a packet capture (in seconds).
+const (
+	instanceCaptureDefaultDuration = 60
+	instanceCaptureMaxDuration     = 3600
+)
+
+// How long to wait for the client to connect to the capture websocket.
+const instanceCaptureConnectTimeout = 30 * time.Second
+
+type captureWs struct {
+	// instance currently worked on
+	instance instance.Instance
+
+	// host-side interface to capture on
+	hostName string
+
+	// capture request
+	req api.InstanceCapturePost
+
+	// secret of the data websocket
+	secret string
+
+	// websocket connection the capture is sent to
+	conn *websocket.Conn
+
+	// lock needed to access the "conn" member
+	connLock sync.Mutex
+
+	// channel closed once the websocket is connected
+	connected chan struct{}
+
+	// function stopping the capture
+	stop context.CancelFunc
+}
+
+func (s *captureWs) metadata() any {
+	return jmap.Map{"fds": jmap.Map{"0": s.secret}}
+}
+
+func (s *captureWs) connect(op *operations.Operation, r *http.Request, w http.ResponseWriter) error {
+	// Check that the user connecting is the same who started the capture.
+	if !op.IsSameRequestor(r) {
+		return api.StatusErrorf(http.StatusForbidden, "Requestor mismatch")
+	}
+
+	secret := r.FormValue("secret")
+	if secret == "" {
+		return errors.New("missing secret")
+	}
+
+	if secret != s.secret {
+		return api.StatusErrorf(http.StatusForbidden, "Invalid secret")
+	}
+
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+
+	if s.conn != nil {
+		return api.StatusErrorf(http.StatusConflict, "Capture websocket already connected")
+	}
+
+	conn, err := ws.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+	close(s.connected)
+
+	return nil
+}
+
+func (s *captureWs) do(op *operations.Operation) error {
+	defer logger.Debug("Capture websocket finished")
+
+	select {
+	case <-s.connected:
+	case <-time.After(instanceCaptureConnectTimeout):
+		return errors.New("Timed out waiting for the capture websocket to be connected")
+	}
+
+	s.connLock.Lock()
+	conn := s.conn
+	s.connLock.Unlock()
+
+	defer func() { _ = conn.Close() }()
+
+	args := []string{"-n", "-U", "-i", s.hostName, "-w", "-"}
+
+	if s.req.Snaplen > 0 {
+		args = append(args, "-s", strconv.Itoa(s.req.Snaplen))
+	}
+
+	if s.req.Packets > 0 {
+		args = append(args, "-c", strconv.Itoa(s.req.Packets))
+	}
+
+	// Stop option parsing so the filter can't be used to pass extra options.
+	args = append(args, "--")
+
+	if s.req.Filter != "" {
+		args = append(args, s.req.Filter)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.req.Duration)*time.Second)
+	defer cancel()
+
+	s.connLock.Lock()
+	s.stop = cancel
+	s.connLock.Unlock()
+
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "tcpdump", args...)
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("Failed starting packet capture: %w", err)
+	}
+
+	// Stream the capture to the client.
+	mirrorDone := ws.MirrorRead(conn, stdout)
+	err = <-mirrorDone
+	if err != nil {
+		// The client went away, stop capturing.
+		cancel()
+	}
+
+	err = cmd.Wait()
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("Packet capture failed: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Let the client know the capture is complete.
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	_ = conn.WriteMessage(websocket.CloseMessage, msg)
+
+	return nil
+}
+
+// Cancel is responsible for stopping the capture and closing the websocket connection.
+func (s *captureWs) cancel(*operations.Operation) error {
+	s.connLock.Lock()
+	defer s.connLock.Unlock()
+
+	if s.stop != nil {
+		s.stop()
+	}
+
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+
+	return nil
+}
+
+// swagger:operation POST /1.0/instances/{name}/capture instances instance_capture_post
+//
+//	Capture NIC traffic
+//
+//	Starts a bounded packet capture on the host-side interface of an instance NIC.
+//
+//	The returned operation metadata will contain a websocket streaming the capture in pcap format.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: capture
+//	    description: Capture request
+//	    schema:
+//	      $ref: "#/definitions/InstanceCapturePost"
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceCapturePost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(errors.New("Invalid instance name"))
+	}
+
+	post := api.InstanceCapturePost{}
+	err = json.NewDecoder(r.Body).Decode(&post)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Forward the request if the instance is remote.
+	client, err := cluster.ConnectIfInstanceIsRemote(s, projectName, name, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if client != nil {
+		url := api.NewURL().Path(version.APIVersion, "instances", name, "capture").Project(projectName)
+		resp, _, err := client.RawQuery("POST", url.String(), post, "")
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		opAPI, err := resp.MetadataAsOperation()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return operations.ForwardedOperationResponse(projectName, opAPI)
+	}
+
+	// Basic parameter validation.
+	if post.Duration == 0 {
+		post.Duration = instanceCaptureDefaultDuration
+	}
+
+	if post.Duration < 0 || post.Duration > instanceCaptureMaxDuration {
+		return response.BadRequest(fmt.Errorf("Capture duration must be between 1 and %d seconds", instanceCaptureMaxDuration))
+	}
+
+	if post.Packets < 0 {
+		return response.BadRequest(errors.New("Packet count can't be negative"))
+	}
+
+	if post.Snaplen < 0 {
+		return response.BadRequest(errors.New("Snapshot length can't be negative"))
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(errors.New("Instance is not running"))
+	}
+
+	dev, ok := inst.ExpandedDevices()[post.Device]
+	if !ok || dev["type"] != "nic" {
+		return response.BadRequest(fmt.Errorf("Instance doesn't have a NIC device named %q", post.Device))
+	}
+
+	hostName := inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", post.Device)]
+	if hostName == "" || !util.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+		return response.BadRequest(fmt.Errorf("NIC %q doesn't have a host-side interface to capture on", post.Device))
+	}
+
+	_, err = exec.LookPath("tcpdump")
+	if err != nil {
+		return response.InternalError(errors.New("Packet capture requires the tcpdump command"))
+	}
+
+	ws := &captureWs{}
+	ws.secret, err = internalUtil.RandomHexString(32)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	ws.instance = inst
+	ws.hostName = hostName
+	ws.req = post
+	ws.connected = make(chan struct{})
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", ws.instance.Name())}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassWebsocket, operationtype.InstanceCapture, resources, ws.metadata(), ws.do, ws.cancel, ws.connect, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
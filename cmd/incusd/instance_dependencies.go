@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+// instancesDependencyLevels returns the level of each instance in the order to start them in, or to stop them in
+// if reverse is set, keyed by project and instance name. Only the dependencies between the given instances are
+// considered, so the instances of the same level can be started (or stopped) together.
+func instancesDependencyLevels(instances []instance.Instance, reverse bool) map[string]int {
+	dependencies := make(map[string][]string, len(instances))
+	for _, inst := range instances {
+		instanceDependencies := []string{}
+		for _, name := range internalInstance.Dependencies(inst.ExpandedConfig()) {
+			instanceDependencies = append(instanceDependencies, project.Instance(inst.Project().Name, name))
+		}
+
+		dependencies[project.Instance(inst.Project().Name, inst.Name())] = instanceDependencies
+	}
+
+	if reverse {
+		dependencies = internalInstance.ReverseDependencies(dependencies)
+	}
+
+	levels, err := internalInstance.DependencyLevels(dependencies)
+	if err != nil {
+		// Cycles are refused when the dependencies are set, but don't block the instances if one got through.
+		logger.Warn("Ignoring instance dependencies", logger.Ctx{"err": err})
+		return map[string]int{}
+	}
+
+	return levels
+}
+
+// instancesByDependencyLevel splits the instances into batches which can be started together, or stopped
+// together if reverse is set, with the batches in the order to run them in.
+func instancesByDependencyLevel(instances []instance.Instance, reverse bool) [][]instance.Instance {
+	levels := instancesDependencyLevels(instances, reverse)
+
+	batches := [][]instance.Instance{}
+	for _, inst := range instances {
+		level := levels[project.Instance(inst.Project().Name, inst.Name())]
+		for len(batches) <= level {
+			batches = append(batches, []instance.Instance{})
+		}
+
+		batches[level] = append(batches[level], inst)
+	}
+
+	return batches
+}
+
+// instanceDependencyReady returns whether an instance is ready for the instances depending on it to be started.
+// An instance with a health check is ready once the check passes. Otherwise it is ready once it reported so, or
+// for virtual machines once their agent is running.
+func instanceDependencyReady(ctx context.Context, inst instance.Instance) bool {
+	if !inst.IsRunning() {
+		return false
+	}
+
+	if inst.ExpandedConfig()["healthcheck.type"] != "" {
+		return instanceHealthProbe(ctx, inst) == nil
+	}
+
+	if util.IsTrue(inst.LocalConfig()["volatile.last_state.ready"]) {
+		return true
+	}
+
+	if inst.Type() == instancetype.VM {
+		vm, ok := inst.(instance.VM)
+		return ok && vm.AgentRunning()
+	}
+
+	return true
+}
+
+// instanceWaitDependencies waits for the running dependencies of an instance to be ready, for up to
+// boot.depends_on.timeout seconds. Dependencies which aren't running or are on another cluster member are only
+// used to order the instances and aren't waited for.
+func instanceWaitDependencies(s *state.State, inst instance.Instance) {
+	dependencies := internalInstance.Dependencies(inst.ExpandedConfig())
+	if len(dependencies) == 0 {
+		return
+	}
+
+	timeout := 120 * time.Second
+	value, err := strconv.Atoi(inst.ExpandedConfig()["boot.depends_on.timeout"])
+	if err == nil {
+		timeout = time.Duration(value) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(s.ShutdownCtx, timeout)
+	defer cancel()
+
+	l := logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
+
+	for _, name := range dependencies {
+		for {
+			// Reload the dependency each time to see its current state.
+			dependency, err := instance.LoadByProjectAndName(s, inst.Project().Name, name)
+			if err != nil || (s.ServerClustered && dependency.Location() != s.ServerName) || !dependency.IsRunning() {
+				break
+			}
+
+			if instanceDependencyReady(ctx, dependency) {
+				break
+			}
+
+			if ctx.Err() != nil {
+				l.Warn("Timed out waiting for instance dependency to be ready, starting anyway", logger.Ctx{"dependency": name})
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/revert"
+)
+
+// swagger:operation POST /1.0/instances/{name}/fork instances instance_fork_post
+//
+//	Fork the instance
+//
+//	Creates new virtual machines which resume from the current state of a running virtual machine.
+//
+//	The source is paused while its memory state and a snapshot of its storage are taken.
+//	The new instances get new MAC addresses and their agent is told to refresh the guest identity
+//	(hostname, machine-id and MAC addresses).
+//	Failures to refresh the guest identity are reported in the `identity_refresh_errors` field of the
+//	operation metadata. NICs with a static MAC address aren't supported.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: fork
+//	    description: Fork request
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceForkPost"
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceForkPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return response.BadRequest(errors.New("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	// The new instances are created in the project of the source.
+	err = s.Authorizer.CheckPermission(r.Context(), r, auth.ObjectProject(projectName), auth.EntitlementCanCreateInstances)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.InstanceForkPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if len(req.Names) == 0 {
+		return response.BadRequest(errors.New("No names provided for the new instances"))
+	}
+
+	for i, newName := range req.Names {
+		err = instance.ValidName(newName, false)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		if slices.Contains(req.Names[:i], newName) {
+			return response.BadRequest(fmt.Errorf("Duplicate instance name %q", newName))
+		}
+	}
+
+	if s.ServerClustered && s.DB.Cluster.LocalNodeIsEvacuated() {
+		return response.Forbidden(errors.New("Cluster member is evacuated"))
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(errors.New("Only virtual machines can be forked"))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(errors.New("Only running virtual machines can be forked"))
+	}
+
+	if !inst.CanLiveMigrate() {
+		return response.BadRequest(errors.New("Forking requires migration.stateful to be set to true"))
+	}
+
+	err = instanceForkValidateDevices(inst.ExpandedDevices())
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		for _, newName := range req.Names {
+			exists, err := dbCluster.InstanceExists(ctx, tx.Tx(), projectName, newName)
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				return api.StatusErrorf(http.StatusConflict, "Instance %q already exists", newName)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	run := func(op *operations.Operation) error {
+		return instanceFork(s, inst, req, op)
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", name)}
+	for _, newName := range req.Names {
+		resources["instances"] = append(resources["instances"], *api.NewURL().Path(version.APIVersion, "instances", newName))
+	}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.InstanceFork, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceFork takes a stateful snapshot of a running VM and creates the new instances from it, resuming them from
+// the saved state.
+func instanceFork(s *state.State, source instance.Instance, req api.InstanceForkPost, op *operations.Operation) error {
+	projectName := source.Project().Name
+	l := logger.AddContext(logger.Ctx{"project": projectName, "instance": source.Name()})
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	// Take a stateful snapshot, this pauses the VM until its memory and storage have been saved.
+	snapName := "fork-" + strings.Split(uuid.New().String(), "-")[0]
+	source.SetOperation(op)
+	err := source.Snapshot(snapName, time.Time{}, true)
+	if err != nil {
+		return fmt.Errorf("Failed taking stateful snapshot: %w", err)
+	}
+
+	snap, err := instance.LoadByProjectAndName(s, projectName, source.Name()+internalInstance.SnapshotDelimiter+snapName)
+	if err != nil {
+		return err
+	}
+
+	// The snapshot is only needed to create the new instances.
+	defer func() {
+		err := snap.Delete(true)
+		if err != nil {
+			l.Warn("Failed deleting fork snapshot", logger.Ctx{"snapshot": snapName, "err": err})
+		}
+	}()
+
+	snapConfig := snap.LocalConfig()
+	forks := make([]instanceForkTarget, 0, len(req.Names))
+	for _, newName := range req.Names {
+		// Copy the configuration without the volatile keys, so the new instance gets its own MAC addresses and
+		// identifiers, but keep the machine definition needed to restore the state.
+		config := map[string]string{}
+		for key, value := range snapConfig {
+			if internalInstance.InstanceIncludeWhenCopying(key, false) {
+				config[key] = value
+			}
+		}
+
+		config["volatile.vm.definition"] = snapConfig["volatile.vm.definition"]
+
+		args := db.InstanceArgs{
+			Project:      projectName,
+			Architecture: snap.Architecture(),
+			Config:       config,
+			Type:         instancetype.VM,
+			Description:  source.Description(),
+			Devices:      snap.LocalDevices().Clone(),
+			Ephemeral:    req.Ephemeral,
+			Name:         newName,
+			Profiles:     snap.Profiles(),
+			Stateful:     true,
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			profileNames := make([]string, 0, len(args.Profiles))
+			for _, profile := range args.Profiles {
+				profileNames = append(profileNames, profile.Name)
+			}
+
+			return project.AllowInstanceCreation(tx, projectName, api.InstancesPost{
+				Name: newName,
+				Type: api.InstanceTypeVM,
+				InstancePut: api.InstancePut{
+					Config:   args.Config,
+					Devices:  args.Devices.CloneNative(),
+					Profiles: profileNames,
+				},
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = instanceCreateAsCopy(s, instanceCreateAsCopyOpts{
+			sourceInstance: snap,
+			targetInstance: args,
+			instanceOnly:   true,
+		}, op)
+		if err != nil {
+			return fmt.Errorf("Failed creating instance %q: %w", newName, err)
+		}
+
+		reverter.Add(func() {
+			inst, err := instance.LoadByProjectAndName(s, projectName, newName)
+			if err != nil {
+				return
+			}
+
+			if inst.IsRunning() {
+				_ = inst.Stop(false)
+			}
+
+			_ = inst.Delete(true)
+		})
+
+		fork, err := instanceForkStart(s, projectName, newName, snapConfig, op)
+		if err != nil {
+			return fmt.Errorf("Failed starting instance %q: %w", newName, err)
+		}
+
+		forks = append(forks, *fork)
+	}
+
+	reverter.Success()
+
+	// The guests may not have an agent, so failing to refresh their identity isn't fatal but is reported.
+	refreshErrors := instanceForkRefreshIdentity(forks)
+	if len(refreshErrors) > 0 {
+		for forkName, refreshErr := range refreshErrors {
+			l.Warn("Failed refreshing the identity of forked instance", logger.Ctx{"fork": forkName, "err": refreshErr})
+		}
+
+		_ = op.UpdateMetadata(map[string]any{"identity_refresh_errors": refreshErrors})
+	}
+
+	return nil
+}
+
+// instanceForkValidateDevices checks that the devices of the fork source can be given to the new instances.
+// NICs with a static MAC address can't, as the address would be duplicated.
+func instanceForkValidateDevices(devices deviceConfig.Devices) error {
+	for _, dev := range devices.Sorted() {
+		if dev.Config["type"] == "nic" && dev.Config["hwaddr"] != "" {
+			return fmt.Errorf("Forking isn't supported with a static MAC address on NIC %q", dev.Name)
+		}
+	}
+
+	return nil
+}
+
+// instanceForkTarget is a new instance resumed from the state of the fork source, along with the mapping of the
+// MAC addresses of the source to its own.
+type instanceForkTarget struct {
+	vm      instance.VM
+	hwaddrs map[string]string
+}
+
+// instanceForkStart resumes a new instance from the state of the fork source.
+func instanceForkStart(s *state.State, projectName string, name string, sourceConfig map[string]string, op *operations.Operation) (*instanceForkTarget, error) {
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.SetOperation(op)
+
+	err = inst.Start(true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload the instance to get the MAC addresses generated on start.
+	inst, err = instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the MAC addresses of the source to the new ones.
+	hwaddrs := map[string]string{}
+	for key, value := range inst.LocalConfig() {
+		if !strings.HasPrefix(key, internalInstance.ConfigVolatilePrefix) || !strings.HasSuffix(key, ".hwaddr") {
+			continue
+		}
+
+		if sourceConfig[key] != "" && sourceConfig[key] != value {
+			hwaddrs[sourceConfig[key]] = value
+		}
+	}
+
+	vm, ok := inst.(instance.VM)
+	if !ok {
+		return nil, errors.New("Instance is not a virtual machine")
+	}
+
+	return &instanceForkTarget{vm: vm, hwaddrs: hwaddrs}, nil
+}
+
+// instanceForkRefreshIdentity has the agents of the new instances refresh their guest identity.
+// This is done concurrently as each refresh waits for the agent to come up, and the errors are returned keyed by
+// instance name.
+func instanceForkRefreshIdentity(forks []instanceForkTarget) map[string]string {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	refreshErrors := map[string]string{}
+	for _, fork := range forks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := fork.vm.RefreshIdentity(maps.Clone(fork.hwaddrs))
+			if err != nil {
+				mu.Lock()
+				refreshErrors[fork.vm.Name()] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return refreshErrors
+}
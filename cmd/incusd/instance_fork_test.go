@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+)
+
+func Test_instanceForkValidateDevices(t *testing.T) {
+	tests := []struct {
+		name        string
+		devices     deviceConfig.Devices
+		expectedErr string
+	}{
+		{
+			name: "Generated MAC addresses",
+			devices: deviceConfig.Devices{
+				"root": {"type": "disk", "path": "/", "pool": "default"},
+				"eth0": {"type": "nic", "network": "incusbr0"},
+			},
+		},
+		{
+			name: "Static MAC address",
+			devices: deviceConfig.Devices{
+				"eth0": {"type": "nic", "network": "incusbr0"},
+				"eth1": {"type": "nic", "network": "incusbr0", "hwaddr": "10:66:6a:00:00:01"},
+			},
+			expectedErr: `Forking isn't supported with a static MAC address on NIC "eth1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := instanceForkValidateDevices(tt.devices)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kballard/go-shellquote"
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/incus/v6/internal/server/cluster"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	instanceDrivers "github.com/lxc/incus/v6/internal/server/instance/drivers"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/server/task"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// instanceHealthCheck tracks the health checks of a local instance.
+type instanceHealthCheck struct {
+	health      api.InstanceStateHealth
+	nextCheck   time.Time
+	probing     bool
+	remediating bool
+}
+
+// instanceHealthChecks holds the health checks of the local instances, keyed by instance ID.
+// Note that any access to this map or its entries must be done while holding instanceHealthChecksMu.
+var instanceHealthChecks = map[int]*instanceHealthCheck{}
+
+// instanceHealthChecksMu is used to access instanceHealthChecks safely.
+var instanceHealthChecksMu sync.Mutex
+
+func instanceHealthCheckTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		instanceHealthCheckRun(ctx, d.State())
+	}
+
+	return f, task.Every(10 * time.Second)
+}
+
+// instanceHealthConfigInt returns an integer health check setting of an instance, or its default value.
+func instanceHealthConfigInt(inst instance.Instance, key string, defaultValue int) int {
+	value, err := strconv.Atoi(inst.ExpandedConfig()[key])
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// instanceHealthCheckRun runs the health checks which are due on the running local instances.
+func instanceHealthCheckRun(ctx context.Context, s *state.State) {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for health checks", logger.Ctx{"err": err})
+		return
+	}
+
+	now := time.Now()
+	checked := map[int]bool{}
+
+	for _, inst := range insts {
+		if inst.ExpandedConfig()["healthcheck.type"] == "" || !inst.IsRunning() || inst.IsFrozen() {
+			continue
+		}
+
+		checked[inst.ID()] = true
+		interval := time.Duration(instanceHealthConfigInt(inst, "healthcheck.interval", 30)) * time.Second
+
+		instanceHealthChecksMu.Lock()
+		check, ok := instanceHealthChecks[inst.ID()]
+		if !ok {
+			// Give the instance one interval to start up before the first check.
+			check = &instanceHealthCheck{
+				health:    api.InstanceStateHealth{Status: "unknown"},
+				nextCheck: now.Add(interval),
+			}
+
+			instanceHealthChecks[inst.ID()] = check
+		}
+
+		due := !check.probing && !check.remediating && !now.Before(check.nextCheck)
+		if due {
+			check.probing = true
+		}
+
+		instanceHealthChecksMu.Unlock()
+
+		if !due {
+			continue
+		}
+
+		// Run each check in the background, so a slow probe doesn't delay the checks of the other instances.
+		go instanceHealthCheckInstance(ctx, s, inst, check)
+	}
+
+	// Forget the instances which were stopped or no longer have a health check.
+	instanceHealthChecksMu.Lock()
+	defer instanceHealthChecksMu.Unlock()
+
+	for id, check := range instanceHealthChecks {
+		if checked[id] || check.probing || check.remediating {
+			continue
+		}
+
+		delete(instanceHealthChecks, id)
+		instanceDrivers.SetInstanceHealth(id, nil)
+	}
+}
+
+// instanceHealthCheckInstance runs the health check of an instance, records its result and takes the configured
+// action if the instance became unhealthy.
+func instanceHealthCheckInstance(ctx context.Context, s *state.State, inst instance.Instance, check *instanceHealthCheck) {
+	l := logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
+
+	interval := time.Duration(instanceHealthConfigInt(inst, "healthcheck.interval", 30)) * time.Second
+	threshold := int64(instanceHealthConfigInt(inst, "healthcheck.threshold", 3))
+	action := inst.ExpandedConfig()["healthcheck.action"]
+	if action == "" {
+		action = "none"
+	}
+
+	checkErr := instanceHealthProbe(ctx, inst)
+
+	instanceHealthChecksMu.Lock()
+	previousStatus := check.health.Status
+	check.probing = false
+	check.nextCheck = time.Now().Add(interval)
+	check.health.LastCheck = time.Now()
+
+	if checkErr == nil {
+		check.health.Status = "healthy"
+		check.health.Failures = 0
+		check.health.LastError = ""
+	} else {
+		check.health.Failures++
+		check.health.LastError = checkErr.Error()
+
+		if check.health.Failures >= threshold {
+			check.health.Status = "unhealthy"
+		}
+	}
+
+	health := check.health
+	remediate := health.Status == "unhealthy" && previousStatus != "unhealthy" && action != "none"
+	check.remediating = remediate
+	instanceDrivers.SetInstanceHealth(inst.ID(), &health)
+	instanceHealthChecksMu.Unlock()
+
+	if health.Status == previousStatus {
+		return
+	}
+
+	if health.Status == "unhealthy" {
+		l.Warn("Instance is unhealthy", logger.Ctx{"err": health.LastError, "action": action})
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceUnhealthy.Event(inst, map[string]any{"error": health.LastError, "action": action}))
+	} else if previousStatus == "unhealthy" {
+		l.Info("Instance is healthy again")
+		s.Events.SendLifecycle(inst.Project().Name, lifecycle.InstanceHealthy.Event(inst, nil))
+	}
+
+	if !remediate {
+		return
+	}
+
+	// Take the action in the background, so it doesn't delay the checks of the other instances.
+	go func() {
+		err := instanceHealthRemediate(context.Background(), s, inst, action)
+		if err != nil {
+			l.Error("Failed handling unhealthy instance", logger.Ctx{"action": action, "err": err})
+		}
+
+		// Start checking the instance again as if it was just started.
+		instanceHealthChecksMu.Lock()
+		check.remediating = false
+		check.health = api.InstanceStateHealth{Status: "unknown"}
+		check.nextCheck = time.Now().Add(interval)
+		instanceDrivers.SetInstanceHealth(inst.ID(), &check.health)
+		instanceHealthChecksMu.Unlock()
+	}()
+}
+
+// instanceHealthProbe runs the configured health check against an instance.
+func instanceHealthProbe(ctx context.Context, inst instance.Instance) error {
+	config := inst.ExpandedConfig()
+	timeout := time.Duration(instanceHealthConfigInt(inst, "healthcheck.timeout", 5)) * time.Second
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if config["healthcheck.type"] == "exec" {
+		return instanceHealthProbeExec(ctx, inst, config["healthcheck.command"])
+	}
+
+	if config["healthcheck.port"] == "" {
+		return errors.New("No healthcheck.port configured")
+	}
+
+	address := config["healthcheck.address"]
+	if address == "" {
+		var err error
+
+		address, err = instanceHealthAddress(inst)
+		if err != nil {
+			return err
+		}
+	}
+
+	host := net.JoinHostPort(address, config["healthcheck.port"])
+
+	if config["healthcheck.type"] == "tcp" {
+		dialer := net.Dialer{}
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return fmt.Errorf("Failed connecting to %q: %w", host, err)
+		}
+
+		_ = conn.Close()
+
+		return nil
+	}
+
+	path := config["healthcheck.path"]
+	if path == "" {
+		path = "/"
+	}
+
+	u := url.URL{Scheme: "http", Host: host, Path: path}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed requesting %q: %w", u.String(), err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("Request to %q returned %q", u.String(), resp.Status)
+	}
+
+	return nil
+}
+
+// instanceHealthProbeExec runs a health check command in an instance.
+func instanceHealthProbeExec(ctx context.Context, inst instance.Instance, command string) error {
+	args, err := shellquote.Split(command)
+	if err != nil {
+		return fmt.Errorf("Invalid healthcheck.command: %w", err)
+	}
+
+	if len(args) == 0 {
+		return errors.New("No healthcheck.command configured")
+	}
+
+	env := map[string]string{
+		"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"LANG": "C.UTF-8",
+	}
+
+	for k, v := range inst.ExpandedConfig() {
+		envKey, ok := strings.CutPrefix(k, "environment.")
+		if ok {
+			env[envKey] = v
+		}
+	}
+
+	cmd, err := inst.Exec(api.InstanceExecPost{Command: args, Environment: env}, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("Failed running command: %w", err)
+	}
+
+	type result struct {
+		exitStatus int
+		err        error
+	}
+
+	chResult := make(chan result, 1)
+	go func() {
+		exitStatus, err := cmd.Wait()
+		chResult <- result{exitStatus: exitStatus, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		_ = cmd.Signal(unix.SIGKILL)
+		return errors.New("Command timed out")
+
+	case res := <-chResult:
+		if res.err != nil {
+			return fmt.Errorf("Failed running command: %w", res.err)
+		}
+
+		if res.exitStatus != 0 {
+			return fmt.Errorf("Command returned exit status %d", res.exitStatus)
+		}
+	}
+
+	return nil
+}
+
+// instanceHealthAddress returns the first global address of an instance, preferring IPv4.
+func instanceHealthAddress(inst instance.Instance) (string, error) {
+	hostInterfaces, _ := net.Interfaces()
+	instState, err := inst.RenderState(hostInterfaces)
+	if err != nil {
+		return "", fmt.Errorf("Failed getting instance state: %w", err)
+	}
+
+	names := make([]string, 0, len(instState.Network))
+	for name := range instState.Network {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, family := range []string{"inet", "inet6"} {
+		for _, name := range names {
+			if name == "lo" {
+				continue
+			}
+
+			for _, addr := range instState.Network[name].Addresses {
+				if addr.Family == family && addr.Scope == "global" {
+					return addr.Address, nil
+				}
+			}
+		}
+	}
+
+	return "", errors.New("Couldn't find a global address of the instance")
+}
+
+// instanceHealthRemediate takes the configured action on an unhealthy instance, as an operation.
+func instanceHealthRemediate(ctx context.Context, s *state.State, inst instance.Instance, action string) error {
+	var opType operationtype.Type
+	var run func(op *operations.Operation) error
+
+	switch action {
+	case "restart":
+		opType = operationtype.InstanceRestart
+		run = func(op *operations.Operation) error {
+			return inst.Restart(0)
+		}
+
+	case "rebuild":
+		opType = operationtype.InstanceRebuild
+		run = func(op *operations.Operation) error {
+			return instanceHealthRebuild(ctx, s, inst, op)
+		}
+
+	case "evacuate":
+		opType = operationtype.InstanceMigrate
+		run = func(op *operations.Operation) error {
+			return instanceHealthEvacuate(ctx, s, inst, op)
+		}
+
+	default:
+		return fmt.Errorf("Unknown action %q", action)
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", inst.Name())}
+
+	op, err := operations.OperationCreate(s, inst.Project().Name, operations.OperationClassTask, opType, resources, nil, run, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = op.Start()
+	if err != nil {
+		return err
+	}
+
+	return op.Wait(ctx)
+}
+
+// instanceHealthRebuild rebuilds an unhealthy instance from the image it was created from and starts it again.
+func instanceHealthRebuild(ctx context.Context, s *state.State, inst instance.Instance, op *operations.Operation) error {
+	fingerprint := inst.LocalConfig()["volatile.base_image"]
+	if fingerprint == "" {
+		return errors.New("Instance wasn't created from an image")
+	}
+
+	var img *api.Image
+	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		projectName := inst.Project().Name
+
+		var err error
+		_, img, err = tx.GetImage(ctx, fingerprint, dbCluster.ImageFilter{Project: &projectName})
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed loading image %q: %w", fingerprint, err)
+	}
+
+	err = inst.Stop(false)
+	if err != nil && !errors.Is(err, instanceDrivers.ErrInstanceIsStopped) {
+		return fmt.Errorf("Failed stopping instance: %w", err)
+	}
+
+	err = instanceRebuildFromImage(ctx, s, nil, inst, img, op)
+	if err != nil {
+		return err
+	}
+
+	return inst.Start(false)
+}
+
+// instanceHealthEvacuate moves an unhealthy instance to another cluster member and starts it there.
+func instanceHealthEvacuate(ctx context.Context, s *state.State, inst instance.Instance, op *operations.Operation) error {
+	if !s.ServerClustered {
+		return errors.New("Evacuating an instance requires a cluster")
+	}
+
+	// Unlike for cluster evacuation, the current location isn't evacuated so must be skipped explicitly.
+	sourceMemberInfo, targetMemberInfo, err := evacuateClusterSelectTarget(ctx, s, inst, true)
+	if err != nil {
+		return err
+	}
+
+	err = inst.Stop(false)
+	if err != nil && !errors.Is(err, instanceDrivers.ErrInstanceIsStopped) {
+		return fmt.Errorf("Failed stopping instance: %w", err)
+	}
+
+	err = migrateInstance(ctx, s, inst, api.InstancePost{Migration: true}, sourceMemberInfo, targetMemberInfo, "", op)
+	if err != nil {
+		return fmt.Errorf("Failed moving instance to %q: %w", targetMemberInfo.Name, err)
+	}
+
+	dest, err := cluster.Connect(targetMemberInfo.Address, s.Endpoints.NetworkCert(), s.ServerCert(), nil, true)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to %q: %w", targetMemberInfo.Name, err)
+	}
+
+	dest = dest.UseProject(inst.Project().Name)
+
+	startOp, err := dest.UpdateInstanceState(inst.Name(), api.InstanceStatePut{Action: "start"}, "")
+	if err != nil {
+		return err
+	}
+
+	return startOp.Wait()
+}
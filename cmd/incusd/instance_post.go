@@ -263,6 +263,17 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		req.Live = false
 	}
 
+	// Post-copy only applies to live migrations of virtual machines.
+	if req.Postcopy {
+		if inst.Type() != instancetype.VM {
+			return response.BadRequest(errors.New("Post-copy migration is only supported for virtual machines"))
+		}
+
+		if !req.Live {
+			req.Postcopy = false
+		}
+	}
+
 	// Check for offline sources.
 	if sourceMemberInfo != nil && sourceMemberInfo.IsOffline(s.GlobalConfig.OfflineThreshold()) && (req.Pool != "" || req.Project != "" || req.Name != "") {
 		return response.BadRequest(errors.New("Instance server is currently offline"))
@@ -508,6 +519,8 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		return response.InternalError(err)
 	}
 
+	ws.postcopy = req.Postcopy
+
 	resources := map[string][]api.URL{}
 	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", name)}
 	run := func(op *operations.Operation) error {
@@ -872,6 +885,8 @@ func migrateInstance(ctx context.Context, s *state.State, inst instance.Instance
 			return fmt.Errorf("Failed setting up instance migration on source: %w", err)
 		}
 
+		sourceMigration.postcopy = req.Postcopy
+
 		run := func(_ *operations.Operation) error {
 			return sourceMigration.do(op)
 		}
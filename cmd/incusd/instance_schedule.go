@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/server/task"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// instanceScheduledActions lists the actions which can be scheduled, in the order they're considered in.
+// Stopping comes first so that an instance scheduled to be both stopped and started at the same time is restarted.
+var instanceScheduledActions = []string{"stop", "start", "restart"}
+
+func instanceScheduledActionsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		instanceScheduledActionsRun(ctx, d.State())
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// instanceScheduledActionsRun takes the actions scheduled for the current minute on the local instances.
+// Each cluster member only handles the instances it hosts.
+func instanceScheduledActionsRun(ctx context.Context, s *state.State) {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		logger.Error("Failed loading instances for scheduled actions", logger.Ctx{"err": err})
+		return
+	}
+
+	wg := sync.WaitGroup{}
+
+	for _, inst := range insts {
+		actions := instanceScheduledActionsDue(inst.ExpandedConfig(), inst.IsRunning(), func(schedule string) bool {
+			return snapshotIsScheduledNow(schedule, int64(inst.ID()))
+		})
+
+		if len(actions) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for _, action := range actions {
+				err := instanceScheduledAction(ctx, s, inst, action)
+				if err != nil {
+					logger.Error("Failed scheduled instance action", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "action": action, "err": err})
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// instanceScheduledActionsDue returns the actions to take, in order, on an instance given its config, whether it's
+// running and a function telling whether a schedule is due. The actions which don't apply to the state the instance
+// will be in after the previous ones are skipped.
+func instanceScheduledActionsDue(config map[string]string, running bool, isDue func(schedule string) bool) []string {
+	actions := []string{}
+
+	for _, action := range instanceScheduledActions {
+		schedule := config["schedule."+action]
+		if schedule == "" || !isDue(schedule) {
+			continue
+		}
+
+		// Skip the actions which don't apply to the state the instance will be in.
+		if (action == "start") == running {
+			continue
+		}
+
+		actions = append(actions, action)
+		running = action != "stop"
+	}
+
+	return actions
+}
+
+// instanceScheduledAction runs a scheduled action on an instance as a background operation.
+func instanceScheduledAction(ctx context.Context, s *state.State, inst instance.Instance, action string) error {
+	opType, err := instanceActionToOpType(action)
+	if err != nil {
+		return err
+	}
+
+	// Give the instance the same time to shut down as on host shutdown.
+	timeout := 30
+	value, ok := inst.ExpandedConfig()["boot.host_shutdown_timeout"]
+	if ok {
+		timeout, _ = strconv.Atoi(value)
+	}
+
+	req := api.InstanceStatePut{
+		Action:  action,
+		Timeout: timeout,
+	}
+
+	run := func(op *operations.Operation) error {
+		inst.SetOperation(op)
+
+		err := doInstanceStatePut(s, inst, req)
+		if err == nil || action == "start" {
+			return err
+		}
+
+		// Forcefully stop the instances which didn't shut down in time.
+		logger.Warn("Failed shutting down instance, forcefully stopping", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "action": action, "err": err})
+		req.Force = true
+
+		return doInstanceStatePut(s, inst, req)
+	}
+
+	resources := map[string][]api.URL{}
+	resources["instances"] = []api.URL{*api.NewURL().Path(version.APIVersion, "instances", inst.Name())}
+
+	op, err := operations.OperationCreate(s, inst.Project().Name, operations.OperationClassTask, opType, resources, nil, run, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Running scheduled instance action", logger.Ctx{"project": inst.Project().Name, "instance": inst.Name(), "action": action})
+
+	err = op.Start()
+	if err != nil {
+		return err
+	}
+
+	return op.Wait(ctx)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_instanceScheduledActionsDue(t *testing.T) {
+	config := map[string]string{
+		"schedule.stop":    "0 18 * * *",
+		"schedule.start":   "0 8 * * *",
+		"schedule.restart": "0 3 * * *",
+	}
+
+	tests := []struct {
+		name     string
+		config   map[string]string
+		running  bool
+		due      []string
+		expected []string
+	}{
+		{
+			name:     "Nothing due",
+			config:   config,
+			running:  true,
+			due:      []string{},
+			expected: []string{},
+		},
+		{
+			name:     "Start stopped instance",
+			config:   config,
+			running:  false,
+			due:      []string{"0 8 * * *"},
+			expected: []string{"start"},
+		},
+		{
+			name:     "Start skipped on running instance",
+			config:   config,
+			running:  true,
+			due:      []string{"0 8 * * *"},
+			expected: []string{},
+		},
+		{
+			name:     "Stop running instance",
+			config:   config,
+			running:  true,
+			due:      []string{"0 18 * * *"},
+			expected: []string{"stop"},
+		},
+		{
+			name:     "Stop skipped on stopped instance",
+			config:   config,
+			running:  false,
+			due:      []string{"0 18 * * *"},
+			expected: []string{},
+		},
+		{
+			name:     "Restart running instance",
+			config:   config,
+			running:  true,
+			due:      []string{"0 3 * * *"},
+			expected: []string{"restart"},
+		},
+		{
+			name:     "Restart skipped on stopped instance",
+			config:   config,
+			running:  false,
+			due:      []string{"0 3 * * *"},
+			expected: []string{},
+		},
+		{
+			name:     "Stop and start in the same minute",
+			config:   map[string]string{"schedule.stop": "0 8 * * *", "schedule.start": "0 8 * * *"},
+			running:  true,
+			due:      []string{"0 8 * * *"},
+			expected: []string{"stop", "start"},
+		},
+		{
+			name:     "Stop and start in the same minute on stopped instance",
+			config:   map[string]string{"schedule.stop": "0 8 * * *", "schedule.start": "0 8 * * *"},
+			running:  false,
+			due:      []string{"0 8 * * *"},
+			expected: []string{"start"},
+		},
+		{
+			name:     "Start then restart in the same minute",
+			config:   map[string]string{"schedule.start": "0 8 * * *", "schedule.restart": "0 8 * * *"},
+			running:  false,
+			due:      []string{"0 8 * * *"},
+			expected: []string{"start", "restart"},
+		},
+		{
+			name:     "Stop then restart skipped in the same minute",
+			config:   map[string]string{"schedule.stop": "0 8 * * *", "schedule.restart": "0 8 * * *"},
+			running:  true,
+			due:      []string{"0 8 * * *"},
+			expected: []string{"stop"},
+		},
+		{
+			name:     "No schedules",
+			config:   map[string]string{},
+			running:  true,
+			due:      []string{"0 8 * * *"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := instanceScheduledActionsDue(tt.config, tt.running, func(schedule string) bool {
+				return slices.Contains(tt.due, schedule)
+			})
+
+			require.Equal(t, tt.expected, actions)
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/operations"
 	"github.com/lxc/incus/v6/internal/server/request"
 	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
 )
@@ -189,7 +190,7 @@ func instanceStatePut(d *Daemon, r *http.Request) response.Response {
 	do := func(op *operations.Operation) error {
 		inst.SetOperation(op)
 
-		return doInstanceStatePut(inst, req)
+		return doInstanceStatePut(s, inst, req)
 	}
 
 	resources := map[string][]api.URL{}
@@ -214,12 +215,14 @@ func instanceActionToOpType(action string) (operationtype.Type, error) {
 		return operationtype.InstanceFreeze, nil
 	case internalInstance.Unfreeze:
 		return operationtype.InstanceUnfreeze, nil
+	case internalInstance.Hibernate:
+		return operationtype.InstanceHibernate, nil
 	default:
 		return operationtype.Unknown, fmt.Errorf("Unknown action: '%s'", action)
 	}
 }
 
-func doInstanceStatePut(inst instance.Instance, req api.InstanceStatePut) error {
+func doInstanceStatePut(s *state.State, inst instance.Instance, req api.InstanceStatePut) error {
 	if req.Force {
 		// A zero timeout indicates to do a forced stop/restart.
 		req.Timeout = 0
@@ -233,7 +236,10 @@ func doInstanceStatePut(inst instance.Instance, req api.InstanceStatePut) error
 
 	switch internalInstance.InstanceAction(req.Action) {
 	case internalInstance.Start:
-		return inst.Start(req.Stateful)
+		instanceWaitDependencies(s, inst)
+
+		// Resume hibernated instances unless a stateless start was requested.
+		return inst.Start(internalInstance.RestoreState(inst.LocalConfig(), req.Stateful, req.Stateless))
 	case internalInstance.Stop:
 		if req.Stateful {
 			return inst.Stop(req.Stateful)
@@ -249,6 +255,13 @@ func doInstanceStatePut(inst instance.Instance, req api.InstanceStatePut) error
 		return inst.Freeze()
 	case internalInstance.Unfreeze:
 		return inst.Unfreeze()
+	case internalInstance.Hibernate:
+		vm, ok := inst.(instance.VM)
+		if !ok {
+			return api.StatusErrorf(http.StatusBadRequest, "Hibernation is only supported for virtual machines")
+		}
+
+		return vm.Hibernate()
 	}
 
 	return fmt.Errorf("Unknown action: '%s'", req.Action)
@@ -369,6 +369,41 @@ func (suite *containerTestSuite) TestContainer_Rename() {
 	suite.Req.Equal(internalUtil.VarPath("containers", "testFoo2"), c.Path())
 }
 
+func (suite *containerTestSuite) TestContainer_DependenciesCreate() {
+	args := db.InstanceArgs{
+		Type:      instancetype.Container,
+		Ephemeral: false,
+		Name:      "testFoo",
+		Config:    map[string]string{"boot.depends_on": "testBar"},
+	}
+
+	// Dependencies must exist when the instance is created.
+	_, _, _, err := instance.CreateInternal(suite.d.State(), args, nil, true, true)
+	suite.Req.EqualError(err, `Dependency "testBar" doesn't exist`)
+
+	args.Config = nil
+	c, op, _, err := instance.CreateInternal(suite.d.State(), args, nil, true, true)
+	suite.Req.Nil(err)
+	op.Done(nil)
+	defer func() { _ = c.Delete(true) }()
+
+	// Make testFoo depend on the instance about to be created.
+	err = suite.d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.UpdateInstanceConfig(c.ID(), map[string]string{"boot.depends_on": "testBar"})
+	})
+	suite.Req.Nil(err)
+
+	args = db.InstanceArgs{
+		Type:      instancetype.Container,
+		Ephemeral: false,
+		Name:      "testBar",
+		Config:    map[string]string{"boot.depends_on": "testFoo"},
+	}
+
+	_, _, _, err = instance.CreateInternal(suite.d.State(), args, nil, true, true)
+	suite.Req.EqualError(err, "Dependency cycle between instances: testBar -> testFoo -> testBar")
+}
+
 func (suite *containerTestSuite) TestContainer_findIdmap_isolated() {
 	c1, op, _, err := instance.CreateInternal(suite.d.State(), db.InstanceArgs{
 		Type: instancetype.Container,
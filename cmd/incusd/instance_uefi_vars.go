@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/instance/drivers/edk2"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
+	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// instanceUEFIVarsLoad loads the virtual machine targeted by a UEFI variables request.
+// It returns a response instead when the request must be forwarded to another member.
+func instanceUEFIVarsLoad(s *state.State, r *http.Request) (instance.VM, response.Response) {
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if internalInstance.IsSnapshot(name) {
+		return nil, response.BadRequest(errors.New("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different member.
+	resp, err := forwardedResponseIfInstanceIsRemote(s, r, projectName, name)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if resp != nil {
+		return nil, resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	vm, ok := inst.(instance.VM)
+	if !ok {
+		return nil, response.BadRequest(errors.New("UEFI variables are only available for virtual machines"))
+	}
+
+	return vm, nil
+}
+
+// swagger:operation GET /1.0/instances/{name}/uefi-vars instances instance_uefi_vars_get
+//
+//	Get the UEFI variables
+//
+//	Gets the content of the UEFI variable store of a virtual machine.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: UEFI variables
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/InstanceUEFIVars"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceUEFIVarsGet(d *Daemon, r *http.Request) response.Response {
+	vm, resp := instanceUEFIVarsLoad(d.State(), r)
+	if resp != nil {
+		return resp
+	}
+
+	uefiVars, err := vm.UEFIVariables()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, uefiVars, uefiVars)
+}
+
+// swagger:operation PUT /1.0/instances/{name}/uefi-vars instances instance_uefi_vars_put
+//
+//	Update the UEFI variables
+//
+//	Replaces the content of the UEFI variable store of a stopped virtual machine.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: variables
+//	    description: UEFI variables
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceUEFIVars"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "412":
+//	    $ref: "#/responses/PreconditionFailed"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceUEFIVarsPut(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	vm, resp := instanceUEFIVarsLoad(s, r)
+	if resp != nil {
+		return resp
+	}
+
+	if vm.IsRunning() {
+		return response.BadRequest(errors.New("UEFI variables can only be changed while the instance is stopped"))
+	}
+
+	current, err := vm.UEFIVariables()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate ETag
+	err = localUtil.EtagCheck(r, current)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.InstanceUEFIVars{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = vm.UEFIVariablesUpdate(req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	s.Events.SendLifecycle(vm.Project().Name, lifecycle.InstanceUpdated.Event(vm, nil))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation POST /1.0/instances/{name}/uefi-vars/keys instances instance_uefi_keys_post
+//
+//	Enroll Secure Boot keys
+//
+//	Enrolls custom Secure Boot keys (PK, KEK, db and dbx) in the UEFI variable store of a stopped virtual machine.
+//	Only the provided keys are replaced.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: keys
+//	    description: Secure Boot keys
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/InstanceUEFIKeysPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func instanceUEFIKeysPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	vm, resp := instanceUEFIVarsLoad(s, r)
+	if resp != nil {
+		return resp
+	}
+
+	if vm.IsRunning() {
+		return response.BadRequest(errors.New("Secure Boot keys can only be enrolled while the instance is stopped"))
+	}
+
+	req := api.InstanceUEFIKeysPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req == (api.InstanceUEFIKeysPost{}) {
+		return response.BadRequest(errors.New("No Secure Boot keys provided"))
+	}
+
+	err = edk2.ValidateSecureBootKeys(edk2.SecureBootKeys{PK: req.PK, KEK: req.KEK, DB: req.DB, DBX: req.DBX})
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = vm.UEFIKeysEnroll(req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	s.Events.SendLifecycle(vm.Project().Name, lifecycle.InstanceUpdated.Event(vm, nil))
+
+	return response.EmptySyncResponse
+}
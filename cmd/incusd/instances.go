@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	internalInstance "github.com/lxc/incus/v6/internal/instance"
 	"github.com/lxc/incus/v6/internal/server/auth"
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
@@ -109,6 +110,56 @@ var instanceConsoleCmd = APIEndpoint{
 	Delete: APIEndpointAction{Handler: instanceConsoleLogDelete, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
 }
 
+var instanceCaptureCmd = APIEndpoint{
+	Name: "instanceCapture",
+	Path: "instances/{name}/capture",
+
+	Post: APIEndpointAction{Handler: instanceCapturePost, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanCaptureTraffic, "name")},
+}
+
+var instanceAttestationCmd = APIEndpoint{
+	Name: "instanceAttestation",
+	Path: "instances/{name}/attestation",
+
+	Post: APIEndpointAction{Handler: instanceAttestationPost, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanView, "name")},
+}
+
+var instanceForkCmd = APIEndpoint{
+	Name: "instanceFork",
+	Path: "instances/{name}/fork",
+
+	Post: APIEndpointAction{Handler: instanceForkPost, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanManageSnapshots, "name")},
+}
+
+var instanceUEFIVarsCmd = APIEndpoint{
+	Name: "instanceUEFIVars",
+	Path: "instances/{name}/uefi-vars",
+
+	Get: APIEndpointAction{Handler: instanceUEFIVarsGet, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanView, "name")},
+	Put: APIEndpointAction{Handler: instanceUEFIVarsPut, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
+}
+
+var instanceUEFIKeysCmd = APIEndpoint{
+	Name: "instanceUEFIKeys",
+	Path: "instances/{name}/uefi-vars/keys",
+
+	Post: APIEndpointAction{Handler: instanceUEFIKeysPost, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
+}
+
+var instanceNetworkPathCmd = APIEndpoint{
+	Name: "instanceNetworkPath",
+	Path: "instances/{name}/network-path",
+
+	Get: APIEndpointAction{Handler: instanceNetworkPathGet, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanView, "name")},
+}
+
+var instanceNetworkTraceCmd = APIEndpoint{
+	Name: "instanceNetworkTrace",
+	Path: "instances/{name}/network-trace",
+
+	Post: APIEndpointAction{Handler: instanceNetworkTracePost, AccessHandler: allowPermission(auth.ObjectTypeInstance, auth.EntitlementCanEdit, "name")},
+}
+
 var instanceExecCmd = APIEndpoint{
 	Name: "instanceExec",
 	Path: "instances/{name}/exec",
@@ -198,13 +249,14 @@ func (slice instanceAutostartList) Swap(i, j int) {
 var instancesStartMu sync.Mutex
 
 // instanceShouldAutoStart returns whether the instance should be auto-started.
-// Returns true if boot.autostart is enabled or boot.autostart is not set and instance was previously running.
+// Returns true if boot.autostart is enabled or boot.autostart is not set and instance was previously running or
+// was hibernated.
 func instanceShouldAutoStart(inst instance.Instance) bool {
 	config := inst.ExpandedConfig()
 	autoStart := config["boot.autostart"]
 	lastState := config["volatile.last_state.power"]
 
-	return util.IsTrue(autoStart) || (autoStart == "" && lastState == instance.PowerStateRunning)
+	return util.IsTrue(autoStart) || (autoStart == "" && (lastState == instance.PowerStateRunning || internalInstance.Hibernated(config)))
 }
 
 func instancesStart(s *state.State, instances []instance.Instance) {
@@ -217,9 +269,14 @@ func instancesStart(s *state.State, instances []instance.Instance) {
 	instancesStartMu.Lock()
 	defer instancesStartMu.Unlock()
 
-	// Sort based on instance boot priority.
+	// Sort based on instance boot priority, then make sure dependencies are started first.
 	sort.Sort(instanceAutostartList(instances))
 
+	levels := instancesDependencyLevels(instances, false)
+	sort.SliceStable(instances, func(i, j int) bool {
+		return levels[project.Instance(instances[i].Project().Name, instances[i].Name())] < levels[project.Instance(instances[j].Project().Name, instances[j].Name())]
+	})
+
 	// Let's make up to 3 attempts to start instances.
 	maxAttempts := 3
 
@@ -241,13 +298,16 @@ func instancesStart(s *state.State, instances []instance.Instance) {
 
 		instLogger := logger.AddContext(logger.Ctx{"project": inst.Project().Name, "instance": inst.Name()})
 
+		// Wait for the dependencies to be ready.
+		instanceWaitDependencies(s, inst)
+
 		// Try to start the instance.
 		attempt := 0
 		for {
 			attempt++
 
 			var err error
-			if shutdownAction == "stateful-stop" {
+			if shutdownAction == "stateful-stop" || internalInstance.Hibernated(config) {
 				// Attempt to restore state.
 				err = inst.Start(true)
 			} else {
@@ -392,8 +452,18 @@ func instancesOnDisk(s *state.State) ([]instance.Instance, error) {
 }
 
 func instancesShutdown(instances []instance.Instance) {
+	// Sort based on instance stop priority, then make sure dependencies are stopped last.
 	sort.Sort(instanceStopList(instances))
 
+	levels := instancesDependencyLevels(instances, true)
+	instanceLevel := func(inst instance.Instance) int {
+		return levels[project.Instance(inst.Project().Name, inst.Name())]
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instanceLevel(instances[i]) < instanceLevel(instances[j])
+	})
+
 	// Limit shutdown concurrency to number of instances or number of CPU cores (which ever is less).
 	var wg sync.WaitGroup
 	instShutdownCh := make(chan instance.Instance)
@@ -448,6 +518,7 @@ func instancesShutdown(instances []instance.Instance) {
 	}
 
 	var currentBatchPriority int
+	var currentBatchLevel int
 	for i, inst := range instances {
 		// Skip stopped instances.
 		if !inst.IsRunning() {
@@ -455,14 +526,16 @@ func instancesShutdown(instances []instance.Instance) {
 		}
 
 		priority, _ := strconv.Atoi(inst.ExpandedConfig()["boot.stop.priority"])
+		level := instanceLevel(inst)
 
-		// Shutdown instances in priority batches, logging at the start of each batch.
-		if i == 0 || priority != currentBatchPriority {
+		// Shutdown instances in dependency and priority batches, logging at the start of each batch.
+		if i == 0 || priority != currentBatchPriority || level != currentBatchLevel {
 			currentBatchPriority = priority
+			currentBatchLevel = level
 
-			// Wait for instances with higher priority to finish before starting next batch.
+			// Wait for instances with higher priority or depending on the next ones to finish before starting next batch.
 			wg.Wait()
-			logger.Info("Stopping instances", logger.Ctx{"stopPriority": currentBatchPriority})
+			logger.Info("Stopping instances", logger.Ctx{"stopPriority": currentBatchPriority, "dependencyLevel": currentBatchLevel})
 		}
 
 		wg.Add(1)
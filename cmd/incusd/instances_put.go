@@ -139,6 +139,11 @@ func instancesPut(d *Daemon, r *http.Request) response.Response {
 			if !inst.IsFrozen() {
 				continue
 			}
+
+		case internalInstance.Hibernate:
+			if !inst.IsRunning() || inst.Type() != instancetype.VM {
+				continue
+			}
 		}
 
 		instances = append(instances, inst)
@@ -158,22 +163,31 @@ func instancesPut(d *Daemon, r *http.Request) response.Response {
 			failuresLock := sync.Mutex{}
 			wgAction := sync.WaitGroup{}
 
-			for _, inst := range instances {
-				wgAction.Add(1)
-				go func(inst instance.Instance) {
-					defer wgAction.Done()
+			// Start the dependencies of the instances first and stop them last.
+			batches := [][]instance.Instance{instances}
+			if action == internalInstance.Start || action == internalInstance.Stop {
+				batches = instancesByDependencyLevel(instances, action == internalInstance.Stop)
+			}
 
-					inst.SetOperation(op)
-					err := doInstanceStatePut(inst, *req.State)
-					if err != nil {
-						failuresLock.Lock()
-						failures[inst.Name()] = err
-						failuresLock.Unlock()
-					}
-				}(inst)
+			for _, batch := range batches {
+				for _, inst := range batch {
+					wgAction.Add(1)
+					go func(inst instance.Instance) {
+						defer wgAction.Done()
+
+						inst.SetOperation(op)
+						err := doInstanceStatePut(s, inst, *req.State)
+						if err != nil {
+							failuresLock.Lock()
+							failures[inst.Name()] = err
+							failuresLock.Unlock()
+						}
+					}(inst)
+				}
+
+				wgAction.Wait()
 			}
 
-			wgAction.Wait()
 			return coalesceErrors(local, failures)
 		}
 
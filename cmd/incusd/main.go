@@ -142,6 +142,10 @@ func main() {
 	forkcoreschedCmd := cmdForkcoresched{global: &globalCmd}
 	app.AddCommand(forkcoreschedCmd.command())
 
+	// forklbproxy sub-command
+	forklbproxyCmd := cmdForklbproxy{global: &globalCmd}
+	app.AddCommand(forklbproxyCmd.command())
+
 	// forkmount sub-command
 	forkmountCmd := cmdForkmount{global: &globalCmd}
 	app.AddCommand(forkmountCmd.command())
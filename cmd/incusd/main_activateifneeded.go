@@ -138,6 +138,13 @@ func (c *cmdActivateifneeded) run(_ *cobra.Command, _ []string) error {
 			_, err := incus.ConnectIncusUnix("", nil)
 			return err
 		}
+
+		// Check for scheduled instance actions
+		if config["schedule.start"] != "" || config["schedule.stop"] != "" || config["schedule.restart"] != "" {
+			logger.Debugf("Daemon has scheduled instance actions, activating...")
+			_, err := incus.ConnectIncusUnix("", nil)
+			return err
+		}
 	}
 
 	// Check for scheduled volume snapshots
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lxc/incus/v6/internal/server/network/lbproxy"
+)
+
+type cmdForklbproxy struct {
+	global *cmdGlobal
+}
+
+func (c *cmdForklbproxy) command() *cobra.Command {
+	// Main subcommand
+	cmd := &cobra.Command{}
+	cmd.Use = "forklbproxy <control socket>"
+	cmd.Short = "Run the layer 7 proxy of a network load balancer"
+	cmd.Long = `Description:
+  Run the layer 7 proxy of a network load balancer
+
+  This internal command is used to run the proxy handling the http, https and tls ports of a network load balancer.
+  The proxy is configured by the daemon through the control socket.
+`
+	cmd.RunE = c.run
+	cmd.Hidden = true
+
+	return cmd
+}
+
+func (c *cmdForklbproxy) run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	if len(args) != 1 {
+		_ = cmd.Help()
+
+		if len(args) == 0 {
+			return nil
+		}
+
+		return errors.New("Missing required arguments")
+	}
+
+	// Only root should run this
+	if os.Geteuid() != 0 {
+		return errors.New("This must be run as root")
+	}
+
+	return lbproxy.Serve(args[0])
+}
@@ -142,6 +142,9 @@ type migrationSourceWs struct {
 
 	clusterMoveSourceName string
 
+	// Whether to switch a live migration to post-copy mode.
+	postcopy bool
+
 	pushCertificate  string
 	pushOperationURL string
 	pushSecrets      map[string]string
@@ -148,6 +148,7 @@ func (s *migrationSourceWs) do(migrateOp *operations.Operation) error {
 			StoragePool:           s.storagePool,
 		},
 		AllowInconsistent: s.allowInconsistent,
+		Postcopy:          s.postcopy,
 	})
 	if err != nil {
 		l.Error("Failed migration on source", logger.Ctx{"err": err})
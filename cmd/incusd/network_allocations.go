@@ -152,6 +152,12 @@ func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
 
 			netConf := n.Config()
 
+			// Load balancer addresses are always managed by Incus, other addresses by the IPAM of the network.
+			ipamDriver := netConf["ipam.driver"]
+			if ipamDriver == "" {
+				ipamDriver = "builtin"
+			}
+
 			for _, keyPrefix := range []string{"ipv4", "ipv6"} {
 				ipNet, _ := network.ParseIPCIDRToNet(netConf[fmt.Sprintf("%s.address", keyPrefix)])
 				if ipNet == nil {
@@ -162,6 +168,7 @@ func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
 					Address: ipNet.String(),
 					UsedBy:  api.NewURL().Path(version.APIVersion, "networks", networkName).Project(projectName).String(),
 					Type:    "network",
+					IPAM:    ipamDriver,
 					NAT:     util.IsTrue(netConf[fmt.Sprintf("%s.nat", keyPrefix)]),
 				})
 			}
@@ -182,6 +189,7 @@ func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
 						Address: cidrAddr,
 						UsedBy:  api.NewURL().Path(version.APIVersion, "instances", lease.Hostname).Project(projectName).String(),
 						Type:    "instance",
+						IPAM:    ipamDriver,
 						Hwaddr:  lease.Hwaddr,
 						NAT:     nat,
 					})
@@ -221,15 +229,20 @@ func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
 					return response.SmartError(err)
 				}
 
-				result = append(
-					result,
-					api.NetworkAllocations{
-						Address: cidrAddr,
-						UsedBy:  api.NewURL().Path(version.APIVersion, "networks", networkName, "forwards", forward.ListenAddress).Project(projectName).String(),
-						Type:    "network-forward",
-						NAT:     false, // Network forwards are ingress and so aren't affected by SNAT.
-					},
-				)
+				allocation := api.NetworkAllocations{
+					Address: cidrAddr,
+					UsedBy:  api.NewURL().Path(version.APIVersion, "networks", networkName, "forwards", forward.ListenAddress).Project(projectName).String(),
+					Type:    "network-forward",
+					IPAM:    ipamDriver,
+					NAT:     false, // Network forwards are ingress and so aren't affected by SNAT.
+				}
+
+				if network.IsFloatingIPForward(forward) {
+					allocation.UsedBy = api.NewURL().Path(version.APIVersion, "network-floating-ips", forward.ListenAddress).Project(projectName).String()
+					allocation.Type = "network-floating-ip"
+				}
+
+				result = append(result, allocation)
 			}
 
 			var dbLoadBalancers []dbCluster.NetworkLoadBalancer
@@ -260,6 +273,7 @@ func networkAllocationsGet(d *Daemon, r *http.Request) response.Response {
 						Address: cidrAddr,
 						UsedBy:  api.NewURL().Path(version.APIVersion, "networks", networkName, "load-balancers", loadBalancer.ListenAddress).Project(projectName).String(),
 						Type:    "network-load-balancer",
+						IPAM:    "builtin",
 						NAT:     false, // Network load-balancers are ingress and so aren't affected by SNAT.
 					},
 				)
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/internal/server/db/warningtype"
+	"github.com/lxc/incus/v6/internal/server/network"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/server/task"
+	"github.com/lxc/incus/v6/internal/server/warnings"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+var networkFirewallCmd = APIEndpoint{
+	Path: "networks/{networkName}/firewall",
+
+	Get: APIEndpointAction{Handler: networkFirewallGet, AccessHandler: allowPermission(auth.ObjectTypeNetwork, auth.EntitlementCanView, "networkName")},
+}
+
+// swagger:operation GET /1.0/networks/{name}/firewall networks networks_firewall_get
+//
+//	Get the network firewall rules
+//
+//	Returns the firewall rules of the network on the server, compared with the ones last applied by the server.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: target
+//	    description: Cluster member name
+//	    type: string
+//	    example: server01
+//	responses:
+//	  "200":
+//	    description: Network firewall rules
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/NetworkFirewall"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFirewallGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(s, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, reqProject, err := project.NetworkProject(s.DB.Cluster, request.ProjectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName, err := url.PathUnescape(mux.Vars(r)["networkName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(s, projectName, networkName)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	// Check if project allows access to network.
+	if !project.NetworkAllowed(reqProject.Config, networkName, n.IsManaged()) {
+		return response.SmartError(api.StatusErrorf(http.StatusNotFound, "Network not found"))
+	}
+
+	if n.Type() != "bridge" {
+		return response.BadRequest(fmt.Errorf("Network type %q doesn't use the server firewall", n.Type()))
+	}
+
+	ruleset, err := s.Firewall.NetworkRuleset(n.Name())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	fw := api.NetworkFirewall{
+		Driver:     s.Firewall.String(),
+		Drift:      len(ruleset.Missing) > 0 || len(ruleset.Unexpected) > 0,
+		Rules:      ruleset.Rules,
+		Missing:    ruleset.Missing,
+		Unexpected: ruleset.Unexpected,
+	}
+
+	return response.SyncResponse(true, fw)
+}
+
+func networkFirewallCheckTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		networkFirewallCheck(ctx, d.State())
+	}
+
+	return f, task.Every(5 * time.Minute)
+}
+
+// networkFirewallCheck compares the firewall rules of the local bridge networks with the ones last applied.
+// Networks whose rules were changed outside of Incus get a warning, and have their rules re-applied when
+// network.firewall.auto_repair is enabled.
+func networkFirewallCheck(ctx context.Context, s *state.State) {
+	var networks map[string][]string
+
+	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		projectNames, err := dbCluster.GetProjectNames(ctx, tx.Tx())
+		if err != nil {
+			return err
+		}
+
+		networks = make(map[string][]string, len(projectNames))
+		for _, projectName := range projectNames {
+			networks[projectName], err = tx.GetCreatedNetworkNamesByProject(ctx, projectName)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed loading networks for firewall check", logger.Ctx{"err": err})
+		return
+	}
+
+	for projectName, networkNames := range networks {
+		for _, networkName := range networkNames {
+			n, err := network.LoadByName(s, projectName, networkName)
+			if err != nil || n.Type() != "bridge" {
+				continue
+			}
+
+			l := logger.AddContext(logger.Ctx{"project": projectName, "network": networkName})
+
+			ruleset, err := s.Firewall.NetworkRuleset(n.Name())
+			if err != nil {
+				l.Warn("Failed checking network firewall rules", logger.Ctx{"err": err})
+				continue
+			}
+
+			// Skip networks whose rules weren't applied since startup.
+			if !ruleset.Tracked {
+				continue
+			}
+
+			if len(ruleset.Missing) == 0 && len(ruleset.Unexpected) == 0 {
+				_ = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(s.DB.Cluster, projectName, warningtype.NetworkFirewallDrift, dbCluster.TypeNetwork, int(n.ID()))
+				continue
+			}
+
+			msg := fmt.Sprintf("%d firewall rules missing and %d unexpected", len(ruleset.Missing), len(ruleset.Unexpected))
+			l.Warn("Network firewall rules changed outside of Incus", logger.Ctx{"missing": len(ruleset.Missing), "unexpected": len(ruleset.Unexpected)})
+
+			_ = s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+				return tx.UpsertWarningLocalNode(ctx, projectName, dbCluster.TypeNetwork, int(n.ID()), warningtype.NetworkFirewallDrift, msg)
+			})
+
+			if !s.GlobalConfig.NetworkFirewallAutoRepair() {
+				continue
+			}
+
+			// Re-apply the rules by going through the network setup again.
+			err = n.Start()
+			if err != nil {
+				l.Error("Failed re-applying network firewall rules", logger.Ctx{"err": err})
+				continue
+			}
+
+			l.Info("Re-applied network firewall rules")
+		}
+	}
+}
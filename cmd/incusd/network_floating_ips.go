@@ -0,0 +1,684 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/incus/v6/internal/server/auth"
+	clusterRequest "github.com/lxc/incus/v6/internal/server/cluster/request"
+	"github.com/lxc/incus/v6/internal/server/db"
+	"github.com/lxc/incus/v6/internal/server/instance"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/network"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
+	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+var networkFloatingIPsCmd = APIEndpoint{
+	Path: "network-floating-ips",
+
+	Get:  APIEndpointAction{Handler: networkFloatingIPsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkFloatingIPsPost, AccessHandler: allowAuthenticated},
+}
+
+var networkFloatingIPCmd = APIEndpoint{
+	Path: "network-floating-ips/{address}",
+
+	Delete: APIEndpointAction{Handler: networkFloatingIPDelete, AccessHandler: allowAuthenticated},
+	Get:    APIEndpointAction{Handler: networkFloatingIPGet, AccessHandler: allowAuthenticated},
+	Put:    APIEndpointAction{Handler: networkFloatingIPPut, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkFloatingIPPut, AccessHandler: allowAuthenticated},
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/network-floating-ips network-floating-ips network_floating_ips_get
+//
+//  Get the floating IPs
+//
+//  Returns a list of floating IPs (URLs).
+//
+//  ---
+//  produces:
+//    - application/json
+//  parameters:
+//    - in: query
+//      name: project
+//      description: Project name
+//      type: string
+//      example: default
+//  responses:
+//    "200":
+//      description: API endpoints
+//      schema:
+//        type: object
+//        description: Sync response
+//        properties:
+//          type:
+//            type: string
+//            description: Response type
+//            example: sync
+//          status:
+//            type: string
+//            description: Status description
+//            example: Success
+//          status_code:
+//            type: integer
+//            description: Status code
+//            example: 200
+//          metadata:
+//            type: array
+//            description: List of endpoints
+//            items:
+//              type: string
+//            example: |-
+//              [
+//                "/1.0/network-floating-ips/192.0.2.50",
+//                "/1.0/network-floating-ips/192.0.2.51"
+//              ]
+//    "403":
+//      $ref: "#/responses/Forbidden"
+//    "500":
+//      $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/network-floating-ips?recursion=1 network-floating-ips network_floating_ips_get_recursion1
+//
+//	Get the floating IPs
+//
+//	Returns a list of floating IPs (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of floating IPs
+//	          items:
+//	            $ref: "#/definitions/NetworkFloatingIP"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFloatingIPsGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName, reqProject, err := project.NetworkProject(s.DB.Cluster, request.ProjectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	recursion := localUtil.IsRecursionRequest(r)
+
+	userHasPermission, err := s.Authorizer.GetPermissionChecker(r.Context(), r, auth.EntitlementCanView, auth.ObjectTypeNetwork)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	var networkNames []string
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		networkNames, err = tx.GetCreatedNetworkNamesByProject(ctx, projectName)
+
+		return err
+	})
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading networks: %w", err))
+	}
+
+	linkResults := make([]string, 0)
+	fullResults := make([]api.NetworkFloatingIP, 0)
+
+	for _, networkName := range networkNames {
+		if !userHasPermission(auth.ObjectNetwork(projectName, networkName)) {
+			continue
+		}
+
+		n, err := network.LoadByName(s, projectName, networkName)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading network %q: %w", networkName, err))
+		}
+
+		// Only consider the networks supporting floating IPs the project has access to.
+		if !slices.Contains([]string{"bridge", "ovn"}, n.Type()) || !project.NetworkAllowed(reqProject.Config, networkName, n.IsManaged()) {
+			continue
+		}
+
+		floatingIPs, err := network.GetFloatingIPs(s, n)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		for _, floatingIP := range floatingIPs {
+			fullResults = append(fullResults, floatingIP)
+			linkResults = append(linkResults, api.NewURL().Path(version.APIVersion, "network-floating-ips", floatingIP.Address).String())
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, linkResults)
+	}
+
+	return response.SyncResponse(true, fullResults)
+}
+
+// networkFloatingIPTarget resolves the instance NIC a floating IP allocated from the pool of a network gets
+// attached to. It returns the network the NIC is connected to and the address the traffic is forwarded to.
+// The device of the request is set to the selected NIC if unspecified.
+func networkFloatingIPTarget(s *state.State, r *http.Request, reqProjectName string, poolNet network.Network, address string, req *api.NetworkFloatingIPPut) (network.Network, string, error) {
+	poolProject, poolName, err := network.FloatingIPPool(poolNet)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inst, err := instance.LoadByProjectAndName(s, reqProjectName, req.Instance)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed loading instance %q: %w", req.Instance, err)
+	}
+
+	// Attaching a floating IP changes how the instance can be reached.
+	err = s.Authorizer.CheckPermission(r.Context(), r, auth.ObjectInstance(reqProjectName, inst.Name()), auth.EntitlementCanEdit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	networkProjectName, _, err := project.NetworkProject(s.DB.Cluster, reqProjectName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, entry := range inst.ExpandedDevices().Sorted() {
+		if entry.Config["type"] != "nic" || entry.Config["network"] == "" {
+			continue
+		}
+
+		if req.Device != "" && entry.Name != req.Device {
+			continue
+		}
+
+		n, err := network.LoadByName(s, networkProjectName, entry.Config["network"])
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed loading network %q: %w", entry.Config["network"], err)
+		}
+
+		nicPoolProject, nicPoolName, err := network.FloatingIPPool(n)
+		if err != nil || nicPoolProject != poolProject || nicPoolName != poolName {
+			if req.Device != "" {
+				return nil, "", api.StatusErrorf(http.StatusBadRequest, "NIC %q isn't connected to a network using floating IPs from %q", req.Device, poolName)
+			}
+
+			continue
+		}
+
+		// Bridge forwards are specific to a cluster member.
+		if n.Type() == "bridge" && s.ServerClustered && inst.Location() != s.ServerName {
+			if req.Device != "" {
+				return nil, "", api.StatusErrorf(http.StatusBadRequest, "Floating IPs on bridge networks can only be attached to instances on the cluster member holding them")
+			}
+
+			continue
+		}
+
+		targetAddress, err := network.FloatingIPTargetAddress(n, inst.LocalConfig()["volatile.uuid"], entry.Name, entry.Config, address)
+		if err != nil {
+			if req.Device != "" {
+				return nil, "", api.StatusErrorf(http.StatusBadRequest, "%w", err)
+			}
+
+			continue
+		}
+
+		req.Device = entry.Name
+
+		return n, targetAddress, nil
+	}
+
+	if req.Device != "" {
+		return nil, "", api.StatusErrorf(http.StatusNotFound, "Instance doesn't have a NIC device named %q", req.Device)
+	}
+
+	return nil, "", api.StatusErrorf(http.StatusBadRequest, "Instance %q doesn't have a NIC able to use floating IPs from %q", inst.Name(), poolName)
+}
+
+// swagger:operation POST /1.0/network-floating-ips network-floating-ips network_floating_ips_post
+//
+//	Add a floating IP
+//
+//	Allocates a new floating IP on a network, optionally attaching it to an instance NIC.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: target
+//	    description: Cluster member name
+//	    type: string
+//	    example: server01
+//	  - in: body
+//	    name: floating-ip
+//	    description: Floating IP
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/NetworkFloatingIPsPost"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFloatingIPsPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	resp := forwardedResponseIfTargetIsRemote(s, r)
+	if resp != nil {
+		return resp
+	}
+
+	reqProjectName := request.ProjectParam(r)
+	projectName, reqProject, err := project.NetworkProject(s.DB.Cluster, reqProjectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Parse the request into a record.
+	req := api.NetworkFloatingIPsPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	req.Normalise() // So we handle the request in normalised/canonical form.
+
+	if req.Network == "" {
+		return response.BadRequest(errors.New("A network is required"))
+	}
+
+	n, err := network.LoadByName(s, projectName, req.Network)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed loading network: %w", err))
+	}
+
+	// Check if project allows access to network.
+	if !project.NetworkAllowed(reqProject.Config, req.Network, n.IsManaged()) {
+		return response.SmartError(api.StatusErrorf(http.StatusNotFound, "Network not found"))
+	}
+
+	err = s.Authorizer.CheckPermission(r.Context(), r, auth.ObjectNetwork(projectName, n.Name()), auth.EntitlementCanEdit)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	unlock, err := network.FloatingIPPoolLock(n)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	defer unlock()
+
+	address, err := network.AllocateFloatingIP(s, n, req.Address)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Attach the floating IP right away if requested.
+	targetNet := n
+	targetAddress := ""
+	if req.Instance != "" {
+		targetNet, targetAddress, err = networkFloatingIPTarget(s, r, reqProjectName, n, address, &req.NetworkFloatingIPPut)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	} else {
+		req.Device = ""
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = network.CreateFloatingIP(targetNet, address, req.NetworkFloatingIPPut, reqProjectName, targetAddress, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed creating floating IP: %w", err))
+	}
+
+	lc := lifecycle.NetworkFloatingIPCreated.Event(projectName, address, request.CreateRequestor(r), nil)
+	s.Events.SendLifecycle(projectName, lc)
+
+	return response.SyncResponseLocation(true, nil, lc.Source)
+}
+
+// networkFloatingIPLoad loads the floating IP of the request along with the network holding it.
+// A response is returned if the request must be forwarded to the cluster member holding the floating IP.
+func networkFloatingIPLoad(s *state.State, r *http.Request, projectName string, entitlement auth.Entitlement) (network.Network, *api.NetworkFloatingIP, response.Response, error) {
+	address, err := url.PathUnescape(mux.Vars(r)["address"])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	n, floatingIP, err := network.LoadFloatingIP(s, projectName, address)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = s.Authorizer.CheckPermission(r.Context(), r, auth.ObjectNetwork(projectName, n.Name()), entitlement)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Bridge floating IPs are held by a specific cluster member.
+	if s.ServerClustered && floatingIP.Location != "" && floatingIP.Location != s.ServerName {
+		return nil, nil, forwardedResponseToNode(s, r, floatingIP.Location), nil
+	}
+
+	return n, floatingIP, nil, nil
+}
+
+// swagger:operation GET /1.0/network-floating-ips/{address} network-floating-ips network_floating_ip_get
+//
+//	Get the floating IP
+//
+//	Gets a specific floating IP.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: Floating IP
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/NetworkFloatingIP"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFloatingIPGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName, _, err := project.NetworkProject(s.DB.Cluster, request.ProjectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, floatingIP, resp, err := networkFloatingIPLoad(s, r, projectName, auth.EntitlementCanView)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	return response.SyncResponseETag(true, floatingIP, floatingIP.Etag())
+}
+
+// swagger:operation PATCH /1.0/network-floating-ips/{address} network-floating-ips network_floating_ip_patch
+//
+//  Partially update the floating IP
+//
+//  Updates a subset of the floating IP configuration.
+//  Setting the instance attaches the floating IP to it, clearing it detaches the floating IP.
+//
+//  ---
+//  consumes:
+//    - application/json
+//  produces:
+//    - application/json
+//  parameters:
+//    - in: query
+//      name: project
+//      description: Project name
+//      type: string
+//      example: default
+//    - in: body
+//      name: floating-ip
+//      description: Floating IP configuration
+//      required: true
+//      schema:
+//        $ref: "#/definitions/NetworkFloatingIPPut"
+//  responses:
+//    "200":
+//      $ref: "#/responses/EmptySyncResponse"
+//    "400":
+//      $ref: "#/responses/BadRequest"
+//    "403":
+//      $ref: "#/responses/Forbidden"
+//    "412":
+//      $ref: "#/responses/PreconditionFailed"
+//    "500":
+//      $ref: "#/responses/InternalServerError"
+
+// swagger:operation PUT /1.0/network-floating-ips/{address} network-floating-ips network_floating_ip_put
+//
+//	Update the floating IP
+//
+//	Updates the entire floating IP configuration.
+//	Setting the instance attaches the floating IP to it, clearing it detaches the floating IP.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: body
+//	    name: floating-ip
+//	    description: Floating IP configuration
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/NetworkFloatingIPPut"
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "412":
+//	    $ref: "#/responses/PreconditionFailed"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFloatingIPPut(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	reqProjectName := request.ProjectParam(r)
+	projectName, _, err := project.NetworkProject(s.DB.Cluster, reqProjectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, floatingIP, resp, err := networkFloatingIPLoad(s, r, projectName, auth.EntitlementCanEdit)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	err = localUtil.EtagCheck(r, floatingIP.Etag())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Decode the request, on top of the current values for PATCH.
+	req := api.NetworkFloatingIPPut{}
+	if r.Method == http.MethodPatch {
+		req = floatingIP.Writable()
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	req.Normalise()
+
+	var targetNet network.Network
+	targetAddress := ""
+	if req.Instance != "" {
+		targetNet, targetAddress, err = networkFloatingIPTarget(s, r, reqProjectName, n, floatingIP.Address, &req)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// Moving a bridge floating IP would require moving it between cluster members.
+		if targetNet.ID() != n.ID() && n.Type() == "bridge" && s.ServerClustered {
+			return response.BadRequest(errors.New("Floating IPs on bridge networks can't be moved to another network"))
+		}
+	} else {
+		req.Device = ""
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	// Moving the floating IP releases its address for a moment.
+	unlock, err := network.FloatingIPPoolLock(n)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	defer unlock()
+
+	err = network.UpdateFloatingIP(s, n, floatingIP, targetNet, req, reqProjectName, targetAddress, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed updating floating IP: %w", err))
+	}
+
+	s.Events.SendLifecycle(projectName, lifecycle.NetworkFloatingIPUpdated.Event(projectName, floatingIP.Address, request.CreateRequestor(r), map[string]any{"instance": req.Instance}))
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/network-floating-ips/{address} network-floating-ips network_floating_ip_delete
+//
+//	Delete the floating IP
+//
+//	Releases the floating IP, detaching it from its instance if needed.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    $ref: "#/responses/EmptySyncResponse"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func networkFloatingIPDelete(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName, _, err := project.NetworkProject(s.DB.Cluster, request.ProjectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, floatingIP, resp, err := networkFloatingIPLoad(s, r, projectName, auth.EntitlementCanEdit)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.ForwardDelete(floatingIP.Address, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed deleting floating IP: %w", err))
+	}
+
+	s.Events.SendLifecycle(projectName, lifecycle.NetworkFloatingIPDeleted.Event(projectName, floatingIP.Address, request.CreateRequestor(r), nil))
+
+	return response.EmptySyncResponse
+}
+
+// networkForwardIsFloatingIP returns whether the forward with the listen address backs a floating IP.
+// Such forwards can only be managed through the floating IP API.
+func networkForwardIsFloatingIP(s *state.State, n network.Network, listenAddress string) bool {
+	floatingNet, _, err := network.LoadFloatingIP(s, n.Project(), listenAddress)
+
+	return err == nil && floatingNet.ID() == n.ID()
+}
@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -22,6 +24,7 @@ import (
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
 )
 
 var networkForwardsCmd = APIEndpoint{
@@ -319,6 +322,12 @@ func networkForwardsPost(d *Daemon, r *http.Request) response.Response {
 
 	req.Normalise() // So we handle the request in normalised/canonical form.
 
+	for k := range req.Config {
+		if strings.HasPrefix(k, "volatile.") {
+			return response.BadRequest(fmt.Errorf("Volatile configuration key %q can't be set on network forwards", k))
+		}
+	}
+
 	networkName, err := url.PathUnescape(mux.Vars(r)["networkName"])
 	if err != nil {
 		return response.SmartError(err)
@@ -340,8 +349,32 @@ func networkForwardsPost(d *Daemon, r *http.Request) response.Response {
 
 	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
 
+	// Prevent a floating IP from being allocated the listen address while the forward is being created.
+	_, _, poolErr := network.FloatingIPPool(n)
+	if poolErr == nil {
+		unlock, err := network.FloatingIPPoolLock(n)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		defer unlock()
+	}
+
+	// Reserve the listen address in the IPAM of the network (only once for the whole cluster).
+	if clientType == clusterRequest.ClientTypeNormal {
+		req.ListenAddress, err = network.IPAMReserveForwardAddress(s, n, req.ListenAddress)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	err = n.ForwardCreate(req, clientType)
 	if err != nil {
+		// Don't release the reservation of an existing forward using the same listen address.
+		if clientType == clusterRequest.ClientTypeNormal && !api.StatusErrorCheck(err, http.StatusConflict) {
+			_ = network.IPAMReleaseForwardAddress(s, n, req.ListenAddress)
+		}
+
 		return response.SmartError(fmt.Errorf("Failed creating forward: %w", err))
 	}
 
@@ -412,6 +445,10 @@ func networkForwardDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	if networkForwardIsFloatingIP(s, n, listenAddress) {
+		return response.BadRequest(errors.New("Network forwards backing floating IPs must be managed through the floating IP API"))
+	}
+
 	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
 
 	err = n.ForwardDelete(listenAddress, clientType)
@@ -419,6 +456,13 @@ func networkForwardDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(fmt.Errorf("Failed deleting forward: %w", err))
 	}
 
+	if clientType == clusterRequest.ClientTypeNormal {
+		err = network.IPAMReleaseForwardAddress(s, n, listenAddress)
+		if err != nil {
+			logger.Warn("Failed releasing network forward listen address", logger.Ctx{"project": projectName, "network": networkName, "listenAddress": listenAddress, "err": err})
+		}
+	}
+
 	s.Events.SendLifecycle(projectName, lifecycle.NetworkForwardDeleted.Event(n, listenAddress, request.CreateRequestor(r), nil))
 
 	return response.EmptySyncResponse
@@ -654,6 +698,10 @@ func networkForwardPut(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	if networkForwardIsFloatingIP(s, n, listenAddress) {
+		return response.BadRequest(errors.New("Network forwards backing floating IPs must be managed through the floating IP API"))
+	}
+
 	// Decode the request.
 	req := api.NetworkForwardPut{}
 	err = json.NewDecoder(r.Body).Decode(&req)
@@ -724,6 +772,12 @@ func networkForwardPut(d *Daemon, r *http.Request) response.Response {
 
 	req.Normalise() // So we handle the request in normalised/canonical form.
 
+	for k := range req.Config {
+		if strings.HasPrefix(k, "volatile.") {
+			return response.BadRequest(fmt.Errorf("Volatile configuration key %q can't be set on network forwards", k))
+		}
+	}
+
 	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
 
 	err = n.ForwardUpdate(listenAddress, req, clientType)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/lxc/incus/v6/internal/filter"
 	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/bgp"
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/lifecycle"
@@ -855,11 +857,67 @@ func networkIntegrationPost(d *Daemon, r *http.Request) response.Response {
 
 // networkIntegrationValidate validates the configuration keys/values for network integration.
 func networkIntegrationValidate(integrationType string, inUse bool, oldConfig map[string]string, config map[string]string) error {
-	if integrationType != "ovn" {
+	var configKeys map[string]func(value string) error
+
+	switch integrationType {
+	case "ovn":
+		configKeys = networkIntegrationOVNRules()
+	case "bgp-evpn":
+		configKeys = networkIntegrationBGPEVPNRules()
+	case "wireguard":
+		configKeys = networkIntegrationWireGuardRules()
+	default:
 		return fmt.Errorf("Invalid integration type %q", integrationType)
 	}
 
-	configKeys := map[string]func(value string) error{
+	for k, v := range config {
+		// User keys are free for all.
+
+		// gendoc:generate(entity=network_integration, group=common, key=user.*)
+		// User keys can be used in search.
+		// ---
+		//  type: string
+		//  shortdesc: Free form user key/value storage
+		if strings.HasPrefix(k, "user.") {
+			continue
+		}
+
+		validator, ok := configKeys[k]
+		if !ok {
+			return fmt.Errorf("Invalid network integration configuration key %q", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return fmt.Errorf("Invalid network integration configuration key %q value", k)
+		}
+	}
+
+	if oldConfig == nil || !inUse {
+		return nil
+	}
+
+	if integrationType == "ovn" {
+		if oldConfig["ovn.transit.pattern"] != config["ovn.transit.pattern"] {
+			return errors.New("The OVN transit switch pattern cannot be changed while the integration is in use")
+		}
+
+		return nil
+	}
+
+	// BGP-EVPN and WireGuard integrations are applied when peering, don't allow changing them while in use.
+	for k := range configKeys {
+		if oldConfig[k] != config[k] {
+			return fmt.Errorf("The %q configuration key cannot be changed while the integration is in use", k)
+		}
+	}
+
+	return nil
+}
+
+// networkIntegrationOVNRules returns the configuration keys of OVN network integrations.
+func networkIntegrationOVNRules() map[string]func(value string) error {
+	return map[string]func(value string) error{
 		// gendoc:generate(entity=network_integration, group=ovn, key=ovn.northbound_connection)
 		//
 		// ---
@@ -912,32 +970,146 @@ func networkIntegrationValidate(integrationType string, inUse bool, oldConfig ma
 		//  shortdesc: Template for the transit switch name
 		"ovn.transit.pattern": validate.IsAny,
 	}
+}
 
-	for k, v := range config {
-		// User keys are free for all.
+// networkIntegrationBGPEVPNRules returns the configuration keys of BGP-EVPN network integrations.
+func networkIntegrationBGPEVPNRules() map[string]func(value string) error {
+	return map[string]func(value string) error{
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=bgp.peers)
+		// Comma-separated list of addresses of the BGP peers of the fabric (usually route reflectors).
+		// ---
+		//  type: string
+		//  shortdesc: Addresses of the fabric BGP peers
+		"bgp.peers": validate.Optional(validate.IsListOf(validate.IsNetworkAddress)),
 
-		// gendoc:generate(entity=network_integration, group=common, key=user.*)
-		// User keys can be used in search.
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=bgp.asn)
+		//
+		// ---
+		//  type: integer
+		//  shortdesc: ASN of the fabric BGP peers
+		"bgp.asn": validate.Optional(validate.IsInRange(1, 4294967294)),
+
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=bgp.password)
+		//
 		// ---
 		//  type: string
-		//  shortdesc: Free form user key/value storage
-		if strings.HasPrefix(k, "user.") {
-			continue
-		}
+		//  defaultdesc: (no password)
+		//  shortdesc: Password for the fabric BGP sessions
+		"bgp.password": validate.Optional(validate.IsAny),
 
-		validator, ok := configKeys[k]
-		if !ok {
-			return fmt.Errorf("Invalid network integration configuration key %q", k)
-		}
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=bgp.holdtime)
+		//
+		// ---
+		//  type: integer
+		//  defaultdesc: `180`
+		//  shortdesc: Hold time for the fabric BGP sessions (in seconds)
+		"bgp.holdtime": validate.Optional(validate.IsInRange(9, 65535)),
 
-		err := validator(v)
-		if err != nil {
-			return fmt.Errorf("Invalid network integration configuration key %q value", k)
-		}
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=evpn.vni)
+		// Layer 3 VNI of the fabric VRF the networks are advertised into.
+		// ---
+		//  type: integer
+		//  shortdesc: Layer 3 VNI
+		"evpn.vni": validate.Optional(validate.IsInRange(1, 16777215)),
+
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=evpn.route_target)
+		//
+		// ---
+		//  type: string
+		//  defaultdesc: `<core.bgp_asn>:<evpn.vni>`
+		//  shortdesc: Route target of the advertised routes (`ASN:NN`)
+		"evpn.route_target": validate.Optional(func(value string) error {
+			_, _, err := bgp.ParseRouteTarget(value)
+			return err
+		}),
+
+		// gendoc:generate(entity=network_integration, group=bgp-evpn, key=evpn.nexthop)
+		// Address of the VXLAN tunnel endpoint the fabric should send the traffic of the networks to.
+		// ---
+		//  type: string
+		//  defaultdesc: Uplink address of the network
+		//  shortdesc: Next hop of the advertised routes
+		"evpn.nexthop": validate.Optional(validate.IsNetworkAddress),
 	}
+}
+
+// networkIntegrationWireGuardRules returns the configuration keys of WireGuard network integrations.
+func networkIntegrationWireGuardRules() map[string]func(value string) error {
+	return map[string]func(value string) error{
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.private_key)
+		// Can be generated with `wg genkey`.
+		// ---
+		//  type: string
+		//  shortdesc: Private key of the local end of the tunnel
+		"wireguard.private_key": validate.Optional(networkIntegrationValidateWireGuardKey),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.listen_port)
+		//
+		// ---
+		//  type: integer
+		//  defaultdesc: `51820`
+		//  shortdesc: UDP port the tunnel listens on
+		"wireguard.listen_port": validate.Optional(validate.IsNetworkPort),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.address)
+		// Comma-separated list of addresses (in CIDR notation) of the local end of the tunnel.
+		// ---
+		//  type: string
+		//  shortdesc: Addresses of the local end of the tunnel
+		"wireguard.address": validate.Optional(validate.IsListOf(validate.IsNetworkAddressCIDR)),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.mtu)
+		//
+		// ---
+		//  type: integer
+		//  defaultdesc: `1420`
+		//  shortdesc: MTU of the tunnel interface
+		"wireguard.mtu": validate.Optional(validate.IsNetworkMTU),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.peer.public_key)
+		//
+		// ---
+		//  type: string
+		//  shortdesc: Public key of the remote site
+		"wireguard.peer.public_key": validate.Optional(networkIntegrationValidateWireGuardKey),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.peer.preshared_key)
+		//
+		// ---
+		//  type: string
+		//  defaultdesc: (no pre-shared key)
+		//  shortdesc: Pre-shared key of the tunnel
+		"wireguard.peer.preshared_key": validate.Optional(networkIntegrationValidateWireGuardKey),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.peer.endpoint)
+		// Leave empty to have the remote site initiate the tunnel.
+		// ---
+		//  type: string
+		//  shortdesc: Endpoint of the remote site (`<host>:<port>`)
+		"wireguard.peer.endpoint": validate.Optional(validate.IsListenAddress(true, false, true)),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.peer.subnets)
+		// Comma-separated list of subnets (in CIDR notation) reachable through the remote site.
+		// ---
+		//  type: string
+		//  shortdesc: Subnets of the remote site
+		"wireguard.peer.subnets": validate.Optional(validate.IsListOf(validate.IsNetwork)),
+
+		// gendoc:generate(entity=network_integration, group=wireguard, key=wireguard.peer.keepalive)
+		//
+		// ---
+		//  type: integer
+		//  defaultdesc: `0` (disabled)
+		//  shortdesc: Persistent keepalive interval (in seconds)
+		"wireguard.peer.keepalive": validate.Optional(validate.IsInRange(0, 65535)),
+	}
+}
 
-	if oldConfig != nil && oldConfig["ovn.transit.pattern"] != config["ovn.transit.pattern"] && inUse {
-		return errors.New("The OVN transit switch pattern cannot be changed while the integration is in use")
+// networkIntegrationValidateWireGuardKey validates a base64 encoded WireGuard key.
+func networkIntegrationValidateWireGuardKey(value string) error {
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return errors.New("Invalid WireGuard key")
 	}
 
 	return nil
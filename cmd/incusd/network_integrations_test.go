@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_networkIntegrationValidateWireGuardKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		err   bool
+	}{
+		{
+			name:  "Valid key",
+			value: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+		},
+		{
+			name:  "Too short",
+			value: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3f",
+			err:   true,
+		},
+		{
+			name:  "Too long",
+			value: "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmkAAAA=",
+			err:   true,
+		},
+		{
+			name:  "Not base64",
+			value: "not a WireGuard key",
+			err:   true,
+		},
+		{
+			name:  "Empty",
+			value: "",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := networkIntegrationValidateWireGuardKey(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
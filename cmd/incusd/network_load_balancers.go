@@ -213,6 +213,7 @@ func networkLoadBalancersGet(d *Daemon, r *http.Request) response.Response {
 					return err
 				}
 
+				network.LoadBalancerHideSecrets(apiLoadBalancer)
 				records[lb.ID] = apiLoadBalancer
 			}
 
@@ -540,6 +541,8 @@ func networkLoadBalancerGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	network.LoadBalancerHideSecrets(loadBalancer)
+
 	return response.SyncResponseETag(true, loadBalancer, loadBalancer.Etag())
 }
 
@@ -11,6 +11,7 @@ import (
 
 	"github.com/lxc/incus/v6/internal/filter"
 	"github.com/lxc/incus/v6/internal/server/auth"
+	clusterRequest "github.com/lxc/incus/v6/internal/server/cluster/request"
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/lifecycle"
@@ -347,7 +348,17 @@ func networkPeersPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Network driver %q does not support peering", n.Type()))
 	}
 
-	err = n.PeerCreate(req)
+	// Check if project allows peering with the target project.
+	if req.Type != "remote" && req.TargetProject != "" && req.TargetProject != projectName {
+		err = project.AllowNetworkPeering(reqProject, req.TargetProject)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.PeerCreate(req, clientType)
 	if err != nil {
 		return response.SmartError(fmt.Errorf("Failed creating peer: %w", err))
 	}
@@ -419,7 +430,9 @@ func networkPeerDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	err = n.PeerDelete(peerName)
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.PeerDelete(peerName, clientType)
 	if err != nil {
 		return response.SmartError(fmt.Errorf("Failed deleting peer: %w", err))
 	}
@@ -644,7 +657,9 @@ func networkPeerPut(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	err = n.PeerUpdate(peerName, req)
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.PeerUpdate(peerName, req, clientType)
 	if err != nil {
 		return response.SmartError(fmt.Errorf("Failed updating peer: %w", err))
 	}
@@ -442,7 +442,7 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		req.Config = map[string]string{}
 	}
 
-	netType, err := network.LoadByType(req.Type)
+	netType, err := network.LoadByType(s, req.Type, projectName, req.Name)
 	if err != nil {
 		return response.BadRequest(err)
 	}
@@ -638,10 +638,19 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 
 	// Populate default config.
 	if clientType != clusterRequest.ClientTypeJoiner {
+		reqConfig := maps.Clone(req.Config)
+
 		err = netType.FillConfig(req.Config)
 		if err != nil {
 			return response.SmartError(err)
 		}
+
+		reverter.Add(func() {
+			err := network.IPAMReleaseFilledSubnets(netType, reqConfig, req.Config)
+			if err != nil {
+				logger.Warn("Failed releasing network subnets", logger.Ctx{"project": projectName, "network": req.Name, "err": err})
+			}
+		})
 	}
 
 	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
@@ -726,6 +735,28 @@ func networksPostCluster(ctx context.Context, s *state.State, projectName string
 		}
 	}
 
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	// Add default values if we are inserting global config for first time.
+	// This is done outside of the transaction as it may involve querying an external IPAM.
+	if netInfo == nil || !networkPartiallyCreated(netInfo) {
+		reqConfig := maps.Clone(req.Config)
+
+		err := netType.FillConfig(req.Config)
+		if err != nil {
+			return err
+		}
+
+		// Release the subnets reserved in the external IPAM (if any) unless the global config gets stored.
+		reverter.Add(func() {
+			err := network.IPAMReleaseFilledSubnets(netType, reqConfig, req.Config)
+			if err != nil {
+				logger.Warn("Failed releasing network subnets", logger.Ctx{"project": projectName, "network": req.Name, "err": err})
+			}
+		})
+	}
+
 	// Check that the network is properly defined, get the node-specific configs and merge with global config.
 	var nodeConfigs map[string]map[string]string
 	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
@@ -751,12 +782,6 @@ func networksPostCluster(ctx context.Context, s *state.State, projectName string
 			return err
 		}
 
-		// Add default values if we are inserting global config for first time.
-		err = netType.FillConfig(req.Config)
-		if err != nil {
-			return err
-		}
-
 		// Insert the global config keys.
 		err = tx.CreateNetworkConfig(networkID, 0, req.Config)
 		if err != nil {
@@ -780,6 +805,8 @@ func networksPostCluster(ctx context.Context, s *state.State, projectName string
 		return err
 	}
 
+	reverter.Success()
+
 	// Create notifier for other nodes to create the network.
 	notifier, err := cluster.NewNotifier(s, s.Endpoints.NetworkCert(), s.ServerCert(), cluster.NotifyAll)
 	if err != nil {
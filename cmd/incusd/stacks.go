@@ -0,0 +1,763 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/internal/server/db/operationtype"
+	"github.com/lxc/incus/v6/internal/server/lifecycle"
+	"github.com/lxc/incus/v6/internal/server/locking"
+	"github.com/lxc/incus/v6/internal/server/operations"
+	"github.com/lxc/incus/v6/internal/server/request"
+	"github.com/lxc/incus/v6/internal/server/response"
+	"github.com/lxc/incus/v6/internal/server/state"
+	localUtil "github.com/lxc/incus/v6/internal/server/util"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/revert"
+	"github.com/lxc/incus/v6/shared/util"
+	"github.com/lxc/incus/v6/shared/validate"
+)
+
+// The resources of a stack are managed on behalf of the requestor, so managing a stack requires the permissions
+// needed to manage its resources.
+var stacksCmd = APIEndpoint{
+	Path: "stacks",
+
+	Get:  APIEndpointAction{Handler: stacksGet, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanView)},
+	Post: APIEndpointAction{Handler: stacksPost, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanEdit)},
+}
+
+var stackCmd = APIEndpoint{
+	Path: "stacks/{name}",
+
+	Delete: APIEndpointAction{Handler: stackDelete, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanEdit)},
+	Get:    APIEndpointAction{Handler: stackGet, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanView)},
+	Put:    APIEndpointAction{Handler: stackPut, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanEdit)},
+}
+
+var stackDiffCmd = APIEndpoint{
+	Path: "stacks/{name}/diff",
+
+	Post: APIEndpointAction{Handler: stackDiffPost, AccessHandler: allowPermission(auth.ObjectTypeProject, auth.EntitlementCanView)},
+}
+
+// swagger:operation GET /1.0/stacks stacks stacks_get
+//
+//	Get the stacks
+//
+//	Returns a list of stacks (URLs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of endpoints
+//	          items:
+//	            type: string
+//	          example: |-
+//	            [
+//	              "/1.0/stacks/webapp",
+//	              "/1.0/stacks/monitoring"
+//	            ]
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/stacks?recursion=1 stacks stacks_get_recursion1
+//
+//	Get the stacks
+//
+//	Returns a list of stacks (structs).
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: API endpoints
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          type: array
+//	          description: List of stacks
+//	          items:
+//	            $ref: "#/definitions/Stack"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stacksGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	recursion := localUtil.IsRecursionRequest(r)
+
+	var stacks []dbCluster.Stack
+	err := s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		stacks, err = dbCluster.GetStacks(ctx, tx.Tx(), dbCluster.StackFilter{Project: &projectName})
+
+		return err
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		urls := make([]string, 0, len(stacks))
+		for _, stack := range stacks {
+			urls = append(urls, api.NewURL().Path(version.APIVersion, "stacks", stack.Name).String())
+		}
+
+		return response.SyncResponse(true, urls)
+	}
+
+	results := make([]api.Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		info := stack.ToAPI()
+		info.UsedBy = stackUsedBy(projectName, info.Manifest)
+		results = append(results, *info)
+	}
+
+	return response.SyncResponse(true, results)
+}
+
+// swagger:operation POST /1.0/stacks stacks stacks_post
+//
+//	Add a stack
+//
+//	Creates a new stack and the resources of its manifest.
+//	Existing resources are refused unless they're adopted.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: adopt
+//	    description: Whether to adopt the existing resources of the manifest which aren't managed by the stack
+//	    type: boolean
+//	    example: true
+//	  - in: body
+//	    name: stack
+//	    description: Stack
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/StacksPost"
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stacksPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+
+	req := api.StacksPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = validate.IsAPIName(req.Name, false)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid stack name: %w", err))
+	}
+
+	err = stackValidateManifest(&req.Manifest)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = s.DB.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+		exists, err := dbCluster.StackExists(ctx, tx.Tx(), projectName, req.Name)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return api.StatusErrorf(http.StatusConflict, "The stack already exists")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	adopt := util.IsTrue(request.QueryParam(r, "adopt"))
+	requestor := request.CreateRequestor(r)
+	run := func(op *operations.Operation) error {
+		changes, err := stackApplyManifest(s, r, projectName, req.Name, nil, nil, req.Manifest, adopt, func(ctx context.Context, tx *db.ClusterTx, resources []api.StackResource) error {
+			_, err := dbCluster.CreateStack(ctx, tx.Tx(), dbCluster.Stack{
+				Project:     projectName,
+				Name:        req.Name,
+				Description: req.Description,
+				Manifest:    req.Manifest,
+				Resources:   resources,
+			})
+
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		s.Events.SendLifecycle(projectName, lifecycle.StackCreated.Event(req.Name, projectName, requestor, map[string]any{"changes": changes}))
+
+		return nil
+	}
+
+	resources := map[string][]api.URL{}
+	resources["stacks"] = []api.URL{*api.NewURL().Path(version.APIVersion, "stacks", req.Name)}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.StackApply, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation GET /1.0/stacks/{name} stacks stack_get
+//
+//	Get the stack
+//
+//	Gets a specific stack.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "200":
+//	    description: Stack
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/Stack"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "404":
+//	    $ref: "#/responses/NotFound"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stackGet(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	stack, err := stackLoad(r.Context(), s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	info := stack.ToAPI()
+	info.UsedBy = stackUsedBy(projectName, info.Manifest)
+
+	return response.SyncResponseETag(true, info, info.Writable())
+}
+
+// swagger:operation PUT /1.0/stacks/{name} stacks stack_put
+//
+//	Apply the stack
+//
+//	Updates the resources of the stack to match the new manifest.
+//	Resources which were removed from the manifest are deleted if the stack created them and released otherwise.
+//	Existing resources which aren't managed by the stack are refused unless they're adopted.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: adopt
+//	    description: Whether to adopt the existing resources of the manifest which aren't managed by the stack
+//	    type: boolean
+//	    example: true
+//	  - in: body
+//	    name: stack
+//	    description: Stack
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/StackPut"
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "412":
+//	    $ref: "#/responses/PreconditionFailed"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stackPut(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	stack, err := stackLoad(r.Context(), s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	err = localUtil.EtagCheck(r, stack.ToAPI().Writable())
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	req := api.StackPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = stackValidateManifest(&req.Manifest)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	adopt := util.IsTrue(request.QueryParam(r, "adopt"))
+	requestor := request.CreateRequestor(r)
+	run := func(op *operations.Operation) error {
+		changes, err := stackApplyManifest(s, r, projectName, name, &stack.Manifest, stack.Resources, req.Manifest, adopt, func(ctx context.Context, tx *db.ClusterTx, resources []api.StackResource) error {
+			stack.Description = req.Description
+			stack.Manifest = req.Manifest
+			stack.Resources = resources
+
+			return dbCluster.UpdateStack(ctx, tx.Tx(), projectName, name, *stack)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.Events.SendLifecycle(projectName, lifecycle.StackUpdated.Event(name, projectName, requestor, map[string]any{"changes": changes}))
+
+		return nil
+	}
+
+	resources := map[string][]api.URL{}
+	resources["stacks"] = []api.URL{*api.NewURL().Path(version.APIVersion, "stacks", name)}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.StackApply, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation DELETE /1.0/stacks/{name} stacks stack_delete
+//
+//	Delete the stack
+//
+//	Deletes the stack along with the resources it created.
+//	The resources it adopted are left alone.
+//
+//	---
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	responses:
+//	  "202":
+//	    $ref: "#/responses/Operation"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stackDelete(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, err = stackLoad(r.Context(), s, projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	requestor := request.CreateRequestor(r)
+	run := func(op *operations.Operation) error {
+		unlock, err := locking.Lock(context.TODO(), stackLockName(projectName, name))
+		if err != nil {
+			return err
+		}
+
+		defer unlock()
+
+		// Reload the stack now that nothing else can apply it.
+		stack, err := stackLoad(context.TODO(), s, projectName, name)
+		if err != nil {
+			return err
+		}
+
+		// Check that the requestor is allowed to delete the resources the stack created and to release the
+		// ones it adopted.
+		changes := []stackChange{}
+		for _, resource := range stack.Resources {
+			action := "release"
+			if resource.Created {
+				action = "delete"
+			}
+
+			changes = append(changes, stackChange{StackChange: api.StackChange{Action: action, Type: resource.Type, Name: resource.Name}})
+		}
+
+		err = stackAuthorize(context.TODO(), s, r, projectName, changes)
+		if err != nil {
+			return err
+		}
+
+		client, err := stackClient(s, r, projectName)
+		if err != nil {
+			return err
+		}
+
+		err = stackDeleteResources(client, projectName, stack.Manifest, stack.Resources)
+		if err != nil {
+			return err
+		}
+
+		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return dbCluster.DeleteStack(ctx, tx.Tx(), projectName, name)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.Events.SendLifecycle(projectName, lifecycle.StackDeleted.Event(name, projectName, requestor, nil))
+
+		return nil
+	}
+
+	resources := map[string][]api.URL{}
+	resources["stacks"] = []api.URL{*api.NewURL().Path(version.APIVersion, "stacks", name)}
+
+	op, err := operations.OperationCreate(s, projectName, operations.OperationClassTask, operationtype.StackDelete, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation POST /1.0/stacks/{name}/diff stacks stack_diff_post
+//
+//	Get the changes to apply a stack
+//
+//	Compares a stack manifest with the current state of its resources and returns the changes needed to apply it.
+//	The stack doesn't need to exist yet.
+//
+//	---
+//	consumes:
+//	  - application/json
+//	produces:
+//	  - application/json
+//	parameters:
+//	  - in: query
+//	    name: project
+//	    description: Project name
+//	    type: string
+//	    example: default
+//	  - in: query
+//	    name: adopt
+//	    description: Whether to adopt the existing resources of the manifest which aren't managed by the stack
+//	    type: boolean
+//	    example: true
+//	  - in: body
+//	    name: stack
+//	    description: Stack
+//	    required: true
+//	    schema:
+//	      $ref: "#/definitions/StackPut"
+//	responses:
+//	  "200":
+//	    description: Stack changes
+//	    schema:
+//	      type: object
+//	      description: Sync response
+//	      properties:
+//	        type:
+//	          type: string
+//	          description: Response type
+//	          example: sync
+//	        status:
+//	          type: string
+//	          description: Status description
+//	          example: Success
+//	        status_code:
+//	          type: integer
+//	          description: Status code
+//	          example: 200
+//	        metadata:
+//	          $ref: "#/definitions/StackDiff"
+//	  "400":
+//	    $ref: "#/responses/BadRequest"
+//	  "403":
+//	    $ref: "#/responses/Forbidden"
+//	  "500":
+//	    $ref: "#/responses/InternalServerError"
+func stackDiffPost(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	projectName := request.ProjectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.StackPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = stackValidateManifest(&req.Manifest)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Compare with the last applied manifest, if any, to find the resources to delete.
+	var current *api.StackManifest
+	var managed []api.StackResource
+	stack, err := stackLoad(r.Context(), s, projectName, name)
+	if err == nil {
+		current = &stack.Manifest
+		managed = stack.Resources
+	} else if !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return response.SmartError(err)
+	}
+
+	client, err := stackClient(s, r, projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	changes, err := stackDiff(client, projectName, current, managed, req.Manifest, util.IsTrue(request.QueryParam(r, "adopt")))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	diff := api.StackDiff{Changes: make([]api.StackChange, 0, len(changes))}
+	for _, change := range changes {
+		diff.Changes = append(diff.Changes, change.StackChange)
+	}
+
+	return response.SyncResponse(true, diff)
+}
+
+// stackLoad loads a stack from the database.
+func stackLoad(ctx context.Context, s *state.State, projectName string, name string) (*dbCluster.Stack, error) {
+	var stack *dbCluster.Stack
+	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		stack, err = dbCluster.GetStack(ctx, tx.Tx(), projectName, name)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stack, nil
+}
+
+// stackLockName returns the name of the lock preventing a stack from being applied concurrently.
+func stackLockName(projectName string, name string) string {
+	return fmt.Sprintf("stack_%s_%s", projectName, name)
+}
+
+// stackClient returns a client to the local server for the project of a stack. The requests made through it are
+// forwarded on behalf of the requestor, so they're authorized and attributed the same way as the requestor's.
+func stackClient(s *state.State, r *http.Request, projectName string) (incus.InstanceServer, error) {
+	requestor := request.CreateRequestor(r)
+
+	args := &incus.ConnectionArgs{
+		SkipGetServer: true,
+		UserAgent:     version.UserAgent,
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			req.Header.Add(request.HeaderForwardedUsername, requestor.Username)
+			req.Header.Add(request.HeaderForwardedProtocol, requestor.Protocol)
+			req.Header.Add(request.HeaderForwardedAddress, requestor.Address)
+
+			return nil, nil
+		},
+	}
+
+	client, err := incus.ConnectIncusUnix(s.OS.GetUnixSocket(), args)
+	if err != nil {
+		return nil, fmt.Errorf("Failed connecting to local server: %w", err)
+	}
+
+	return client.UseProject(projectName), nil
+}
+
+// stackApplyManifest applies a stack manifest on behalf of the requestor and records it in the database, along with
+// the resources the stack now manages, through the save function. If any step fails, the resources which were created
+// or updated are reverted. It returns the number of changes.
+func stackApplyManifest(s *state.State, r *http.Request, projectName string, name string, current *api.StackManifest, managed []api.StackResource, desired api.StackManifest, adopt bool, save func(ctx context.Context, tx *db.ClusterTx, resources []api.StackResource) error) (int, error) {
+	unlock, err := locking.Lock(context.TODO(), stackLockName(projectName, name))
+	if err != nil {
+		return 0, err
+	}
+
+	defer unlock()
+
+	client, err := stackClient(s, r, projectName)
+	if err != nil {
+		return 0, err
+	}
+
+	changes, err := stackDiff(client, projectName, current, managed, desired, adopt)
+	if err != nil {
+		return 0, err
+	}
+
+	err = stackAuthorize(context.TODO(), s, r, projectName, changes)
+	if err != nil {
+		return 0, err
+	}
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	err = stackApply(reverter, changes)
+	if err != nil {
+		return 0, err
+	}
+
+	resources := stackManaged(projectName, managed, desired, changes)
+
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return save(ctx, tx, resources)
+	})
+	if err != nil {
+		if errors.Is(err, dbCluster.ErrConflict) {
+			return 0, api.StatusErrorf(http.StatusConflict, "The stack already exists")
+		}
+
+		return 0, fmt.Errorf("Failed saving stack: %w", err)
+	}
+
+	reverter.Success()
+
+	return len(changes), nil
+}
@@ -0,0 +1,795 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/revert"
+)
+
+// Types of the resources of a stack, in the order they are created in.
+const (
+	stackTypeProfile        = "profile"
+	stackTypeNetwork        = "network"
+	stackTypeStorageVolume  = "storage-volume"
+	stackTypeInstance       = "instance"
+	stackTypeNetworkForward = "network-forward"
+)
+
+// stackResource is a resource of a stack manifest, along with the functions to manage it.
+type stackResource struct {
+	Type string
+	Name string
+	URL  *api.URL
+
+	// config is the config of the resource in the manifest.
+	config map[string]string
+
+	// diff returns whether the resource exists and the fields which differ from the manifest.
+	diff func() (bool, []string, error)
+
+	// create creates the resource as described in the manifest.
+	create func() error
+
+	// update updates the resource to match the manifest and returns a hook restoring its previous state.
+	update func() (revert.Hook, error)
+
+	// delete deletes the resource.
+	delete func() error
+}
+
+// stackNotFound returns whether an error means that a resource doesn't exist.
+func stackNotFound(err error) bool {
+	return api.StatusErrorCheck(err, http.StatusNotFound)
+}
+
+// stackConfigChanged returns whether applying the manifest changes the config of a resource, see stackConfigMerge.
+func stackConfigChanged(current map[string]string, previous map[string]string, desired map[string]string) bool {
+	return !maps.Equal(current, stackConfigMerge(current, previous, desired))
+}
+
+// stackConfigMerge returns the config of a resource with the manifest applied, the same way for all resource types.
+// The keys set in the manifest are applied and the keys which were removed from the manifest since it was last
+// applied are unset. Other keys, such as the ones the server fills in, are left alone.
+func stackConfigMerge(current map[string]string, previous map[string]string, desired map[string]string) map[string]string {
+	config := make(map[string]string, len(current)+len(desired))
+	maps.Copy(config, current)
+
+	for key := range previous {
+		_, ok := desired[key]
+		if !ok {
+			delete(config, key)
+		}
+	}
+
+	maps.Copy(config, desired)
+
+	return config
+}
+
+// stackDevicesEqual returns whether two sets of devices are identical.
+func stackDevicesEqual(current map[string]map[string]string, desired map[string]map[string]string) bool {
+	return maps.EqualFunc(current, desired, func(a map[string]string, b map[string]string) bool {
+		return maps.Equal(a, b)
+	})
+}
+
+// stackValidateManifest checks that the resources of a stack manifest are fully described and unique, and fills
+// in the default values.
+func stackValidateManifest(manifest *api.StackManifest) error {
+	seen := map[string]bool{}
+	checkName := func(resourceType string, name string) error {
+		if name == "" {
+			return fmt.Errorf("Missing name for %s", resourceType)
+		}
+
+		key := resourceType + "/" + name
+		if seen[key] {
+			return fmt.Errorf("Duplicate %s %q", resourceType, name)
+		}
+
+		seen[key] = true
+
+		return nil
+	}
+
+	for _, profile := range manifest.Profiles {
+		err := checkName(stackTypeProfile, profile.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, network := range manifest.Networks {
+		err := checkName(stackTypeNetwork, network.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range manifest.Volumes {
+		volume := &manifest.Volumes[i]
+		if volume.Type == "" {
+			volume.Type = "custom"
+		}
+
+		if volume.Type != "custom" {
+			return fmt.Errorf("Storage volume %q must be a custom volume", volume.Name)
+		}
+
+		if volume.Pool == "" {
+			return fmt.Errorf("Missing storage pool for storage volume %q", volume.Name)
+		}
+
+		err := checkName(stackTypeStorageVolume, volume.Pool+"/"+volume.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, inst := range manifest.Instances {
+		err := checkName(stackTypeInstance, inst.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, forward := range manifest.Forwards {
+		if forward.Network == "" {
+			return fmt.Errorf("Missing network for network forward %q", forward.ListenAddress)
+		}
+
+		if forward.ListenAddress == "" {
+			return fmt.Errorf("Missing listen address for network forward on %q", forward.Network)
+		}
+
+		err := checkName(stackTypeNetworkForward, forward.Network+"/"+forward.ListenAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stackResources returns the resources of a stack manifest, in the order to create them in. The previous manifest,
+// if any, is the last applied one and is used to find the config keys which were removed from the manifest.
+func stackResources(client incus.InstanceServer, projectName string, manifest api.StackManifest, previous *api.StackManifest) []stackResource {
+	resources := []stackResource{}
+
+	previousConfigs := map[string]map[string]string{}
+	if previous != nil {
+		for _, resource := range stackResources(nil, projectName, *previous, nil) {
+			previousConfigs[resource.Type+"/"+resource.Name] = resource.config
+		}
+	}
+
+	for _, profile := range manifest.Profiles {
+		previousConfig := previousConfigs[stackTypeProfile+"/"+profile.Name]
+
+		resources = append(resources, stackResource{
+			Type:   stackTypeProfile,
+			Name:   profile.Name,
+			URL:    api.NewURL().Path(version.APIVersion, "profiles", profile.Name).Project(projectName),
+			config: profile.Config,
+			diff: func() (bool, []string, error) {
+				current, _, err := client.GetProfile(profile.Name)
+				if err != nil {
+					if stackNotFound(err) {
+						return false, nil, nil
+					}
+
+					return false, nil, err
+				}
+
+				fields := []string{}
+				if current.Description != profile.Description {
+					fields = append(fields, "description")
+				}
+
+				if stackConfigChanged(current.Config, previousConfig, profile.Config) {
+					fields = append(fields, "config")
+				}
+
+				if !stackDevicesEqual(current.Devices, profile.Devices) {
+					fields = append(fields, "devices")
+				}
+
+				return true, fields, nil
+			},
+			create: func() error {
+				return client.CreateProfile(profile)
+			},
+			update: func() (revert.Hook, error) {
+				current, etag, err := client.GetProfile(profile.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				put := current.Writable()
+				put.Description = profile.Description
+				put.Config = stackConfigMerge(current.Config, previousConfig, profile.Config)
+				put.Devices = profile.Devices
+
+				err = client.UpdateProfile(profile.Name, put, etag)
+				if err != nil {
+					return nil, err
+				}
+
+				return func() { _ = client.UpdateProfile(profile.Name, current.Writable(), "") }, nil
+			},
+			delete: func() error {
+				return client.DeleteProfile(profile.Name)
+			},
+		})
+	}
+
+	for _, network := range manifest.Networks {
+		previousConfig := previousConfigs[stackTypeNetwork+"/"+network.Name]
+
+		resources = append(resources, stackResource{
+			Type:   stackTypeNetwork,
+			Name:   network.Name,
+			URL:    api.NewURL().Path(version.APIVersion, "networks", network.Name).Project(projectName),
+			config: network.Config,
+			diff: func() (bool, []string, error) {
+				current, _, err := client.GetNetwork(network.Name)
+				if err != nil {
+					if stackNotFound(err) {
+						return false, nil, nil
+					}
+
+					return false, nil, err
+				}
+
+				fields := []string{}
+				if current.Description != network.Description {
+					fields = append(fields, "description")
+				}
+
+				if stackConfigChanged(current.Config, previousConfig, network.Config) {
+					fields = append(fields, "config")
+				}
+
+				return true, fields, nil
+			},
+			create: func() error {
+				return client.CreateNetwork(network)
+			},
+			update: func() (revert.Hook, error) {
+				current, etag, err := client.GetNetwork(network.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				put := current.Writable()
+				put.Description = network.Description
+				put.Config = stackConfigMerge(current.Config, previousConfig, network.Config)
+
+				err = client.UpdateNetwork(network.Name, put, etag)
+				if err != nil {
+					return nil, err
+				}
+
+				return func() { _ = client.UpdateNetwork(network.Name, current.Writable(), "") }, nil
+			},
+			delete: func() error {
+				return client.DeleteNetwork(network.Name)
+			},
+		})
+	}
+
+	for _, volume := range manifest.Volumes {
+		previousConfig := previousConfigs[stackTypeStorageVolume+"/"+volume.Pool+"/"+volume.Name]
+
+		resources = append(resources, stackResource{
+			Type:   stackTypeStorageVolume,
+			Name:   volume.Pool + "/" + volume.Name,
+			URL:    api.NewURL().Path(version.APIVersion, "storage-pools", volume.Pool, "volumes", volume.Type, volume.Name).Project(projectName),
+			config: volume.Config,
+			diff: func() (bool, []string, error) {
+				current, _, err := client.GetStoragePoolVolume(volume.Pool, volume.Type, volume.Name)
+				if err != nil {
+					if stackNotFound(err) {
+						return false, nil, nil
+					}
+
+					return false, nil, err
+				}
+
+				fields := []string{}
+				if current.Description != volume.Description {
+					fields = append(fields, "description")
+				}
+
+				if stackConfigChanged(current.Config, previousConfig, volume.Config) {
+					fields = append(fields, "config")
+				}
+
+				return true, fields, nil
+			},
+			create: func() error {
+				return client.CreateStoragePoolVolume(volume.Pool, volume.StorageVolumesPost)
+			},
+			update: func() (revert.Hook, error) {
+				current, etag, err := client.GetStoragePoolVolume(volume.Pool, volume.Type, volume.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				put := current.Writable()
+				put.Description = volume.Description
+				put.Config = stackConfigMerge(current.Config, previousConfig, volume.Config)
+
+				err = client.UpdateStoragePoolVolume(volume.Pool, volume.Type, volume.Name, put, etag)
+				if err != nil {
+					return nil, err
+				}
+
+				return func() {
+					_ = client.UpdateStoragePoolVolume(volume.Pool, volume.Type, volume.Name, current.Writable(), "")
+				}, nil
+			},
+			delete: func() error {
+				return client.DeleteStoragePoolVolume(volume.Pool, volume.Type, volume.Name)
+			},
+		})
+	}
+
+	for _, inst := range manifest.Instances {
+		previousConfig := previousConfigs[stackTypeInstance+"/"+inst.Name]
+
+		// Instances get the default profile unless told otherwise.
+		profiles := inst.Profiles
+		if profiles == nil {
+			profiles = []string{"default"}
+		}
+
+		resources = append(resources, stackResource{
+			Type:   stackTypeInstance,
+			Name:   inst.Name,
+			URL:    api.NewURL().Path(version.APIVersion, "instances", inst.Name).Project(projectName),
+			config: inst.Config,
+			diff: func() (bool, []string, error) {
+				current, _, err := client.GetInstance(inst.Name)
+				if err != nil {
+					if stackNotFound(err) {
+						return false, nil, nil
+					}
+
+					return false, nil, err
+				}
+
+				fields := []string{}
+				if current.Description != inst.Description {
+					fields = append(fields, "description")
+				}
+
+				if stackConfigChanged(current.Config, previousConfig, inst.Config) {
+					fields = append(fields, "config")
+				}
+
+				if !stackDevicesEqual(current.Devices, inst.Devices) {
+					fields = append(fields, "devices")
+				}
+
+				if !slices.Equal(current.Profiles, profiles) {
+					fields = append(fields, "profiles")
+				}
+
+				return true, fields, nil
+			},
+			create: func() error {
+				op, err := client.CreateInstance(inst)
+				if err != nil {
+					return err
+				}
+
+				err = op.Wait()
+				if err != nil {
+					// Don't leave a half-created instance behind, for example if it failed to start.
+					_ = stackDeleteInstance(client, inst.Name)
+					return err
+				}
+
+				return nil
+			},
+			update: func() (revert.Hook, error) {
+				current, etag, err := client.GetInstance(inst.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				put := current.Writable()
+				put.Description = inst.Description
+				put.Config = stackConfigMerge(current.Config, previousConfig, inst.Config)
+				put.Devices = inst.Devices
+				put.Profiles = profiles
+
+				op, err := client.UpdateInstance(inst.Name, put, etag)
+				if err == nil {
+					err = op.Wait()
+				}
+
+				if err != nil {
+					return nil, err
+				}
+
+				return func() {
+					op, err := client.UpdateInstance(inst.Name, current.Writable(), "")
+					if err == nil {
+						_ = op.Wait()
+					}
+				}, nil
+			},
+			delete: func() error {
+				return stackDeleteInstance(client, inst.Name)
+			},
+		})
+	}
+
+	for _, forward := range manifest.Forwards {
+		previousConfig := previousConfigs[stackTypeNetworkForward+"/"+forward.Network+"/"+forward.ListenAddress]
+
+		resources = append(resources, stackResource{
+			Type:   stackTypeNetworkForward,
+			Name:   forward.Network + "/" + forward.ListenAddress,
+			URL:    api.NewURL().Path(version.APIVersion, "networks", forward.Network, "forwards", forward.ListenAddress).Project(projectName),
+			config: forward.Config,
+			diff: func() (bool, []string, error) {
+				current, _, err := client.GetNetworkForward(forward.Network, forward.ListenAddress)
+				if err != nil {
+					if stackNotFound(err) {
+						return false, nil, nil
+					}
+
+					return false, nil, err
+				}
+
+				fields := []string{}
+				if current.Description != forward.Description {
+					fields = append(fields, "description")
+				}
+
+				if stackConfigChanged(current.Config, previousConfig, forward.Config) {
+					fields = append(fields, "config")
+				}
+
+				if len(current.Ports) > 0 || len(forward.Ports) > 0 {
+					if !reflect.DeepEqual(current.Ports, forward.Ports) {
+						fields = append(fields, "ports")
+					}
+				}
+
+				return true, fields, nil
+			},
+			create: func() error {
+				return client.CreateNetworkForward(forward.Network, forward.NetworkForwardsPost)
+			},
+			update: func() (revert.Hook, error) {
+				current, etag, err := client.GetNetworkForward(forward.Network, forward.ListenAddress)
+				if err != nil {
+					return nil, err
+				}
+
+				put := current.Writable()
+				put.Description = forward.Description
+				put.Config = stackConfigMerge(current.Config, previousConfig, forward.Config)
+				put.Ports = forward.Ports
+
+				err = client.UpdateNetworkForward(forward.Network, forward.ListenAddress, put, etag)
+				if err != nil {
+					return nil, err
+				}
+
+				return func() {
+					_ = client.UpdateNetworkForward(forward.Network, forward.ListenAddress, current.Writable(), "")
+				}, nil
+			},
+			delete: func() error {
+				return client.DeleteNetworkForward(forward.Network, forward.ListenAddress)
+			},
+		})
+	}
+
+	return resources
+}
+
+// stackDeleteInstance stops an instance if needed and deletes it.
+func stackDeleteInstance(client incus.InstanceServer, name string) error {
+	current, _, err := client.GetInstance(name)
+	if err != nil {
+		return err
+	}
+
+	if current.StatusCode != api.Stopped {
+		op, err := client.UpdateInstanceState(name, api.InstanceStatePut{Action: "stop", Timeout: -1, Force: true}, "")
+		if err != nil {
+			return err
+		}
+
+		err = op.Wait()
+		if err != nil {
+			return err
+		}
+	}
+
+	op, err := client.DeleteInstance(name)
+	if err != nil {
+		return err
+	}
+
+	return op.Wait()
+}
+
+// stackChange is a change needed to apply a stack manifest, along with the resource it applies to.
+type stackChange struct {
+	api.StackChange
+
+	resource stackResource
+}
+
+// stackManagedResource returns the entry of a resource in the list of resources managed by a stack, if any.
+func stackManagedResource(managed []api.StackResource, resource stackResource) *api.StackResource {
+	for i := range managed {
+		if managed[i].Type == resource.Type && managed[i].Name == resource.Name {
+			return &managed[i]
+		}
+	}
+
+	return nil
+}
+
+// stackDiff returns the changes needed to go from the current state of the resources to the desired manifest.
+//
+// The managed resources are the ones the stack created or adopted when the current manifest was applied. Existing
+// resources which aren't managed by the stack are only adopted if requested. Managed resources which aren't in the
+// desired manifest are deleted if the stack created them and released otherwise.
+func stackDiff(client incus.InstanceServer, projectName string, current *api.StackManifest, managed []api.StackResource, desired api.StackManifest, adopt bool) ([]stackChange, error) {
+	changes := []stackChange{}
+
+	desiredResources := stackResources(client, projectName, desired, current)
+	for _, resource := range desiredResources {
+		exists, fields, err := resource.diff()
+		if err != nil {
+			return nil, fmt.Errorf("Failed checking %s %q: %w", resource.Type, resource.Name, err)
+		}
+
+		change := api.StackChange{Type: resource.Type, Name: resource.Name}
+		if !exists {
+			change.Action = "create"
+		} else if stackManagedResource(managed, resource) == nil {
+			if !adopt {
+				return nil, api.StatusErrorf(http.StatusConflict, "The %s %q already exists and isn't managed by the stack", resource.Type, resource.Name)
+			}
+
+			change.Action = "adopt"
+			change.Fields = fields
+		} else if len(fields) > 0 {
+			change.Action = "update"
+			change.Fields = fields
+		} else {
+			continue
+		}
+
+		changes = append(changes, stackChange{StackChange: change, resource: resource})
+	}
+
+	if current == nil {
+		return changes, nil
+	}
+
+	// Delete or release the resources which were removed from the manifest, in reverse order.
+	currentResources := stackResources(client, projectName, *current, nil)
+	for _, resource := range slices.Backward(currentResources) {
+		if slices.ContainsFunc(desiredResources, func(r stackResource) bool { return r.Type == resource.Type && r.Name == resource.Name }) {
+			continue
+		}
+
+		managedResource := stackManagedResource(managed, resource)
+		if managedResource == nil {
+			continue
+		}
+
+		change := api.StackChange{Action: "release", Type: resource.Type, Name: resource.Name}
+		if managedResource.Created {
+			exists, _, err := resource.diff()
+			if err != nil {
+				return nil, fmt.Errorf("Failed checking %s %q: %w", resource.Type, resource.Name, err)
+			}
+
+			if exists {
+				change.Action = "delete"
+			}
+		}
+
+		changes = append(changes, stackChange{StackChange: change, resource: resource})
+	}
+
+	return changes, nil
+}
+
+// stackManaged returns the resources managed by a stack once the changes needed to apply the desired manifest are
+// applied.
+func stackManaged(projectName string, managed []api.StackResource, desired api.StackManifest, changes []stackChange) []api.StackResource {
+	resources := []api.StackResource{}
+	for _, resource := range stackResources(nil, projectName, desired, nil) {
+		created := false
+
+		managedResource := stackManagedResource(managed, resource)
+		if managedResource != nil {
+			created = managedResource.Created
+		}
+
+		for _, change := range changes {
+			if change.Type == resource.Type && change.Name == resource.Name && change.Action == "create" {
+				created = true
+			}
+		}
+
+		resources = append(resources, api.StackResource{Type: resource.Type, Name: resource.Name, Created: created})
+	}
+
+	return resources
+}
+
+// stackApply applies the changes needed to go from the current manifest to the desired one. Creations and updates
+// are reverted through the reverter if a later change fails. Deletions are done last and can't be reverted.
+func stackApply(reverter *revert.Reverter, changes []stackChange) error {
+	for _, change := range changes {
+		resource := change.resource
+
+		var err error
+		switch change.Action {
+		case "create":
+			err = resource.create()
+			if err == nil {
+				reverter.Add(func() { _ = resource.delete() })
+			}
+
+		case "update", "adopt":
+			if len(change.Fields) == 0 {
+				continue
+			}
+
+			var hook revert.Hook
+			hook, err = resource.update()
+			if err == nil {
+				reverter.Add(hook)
+			}
+
+		case "delete":
+			err = resource.delete()
+			if stackNotFound(err) {
+				err = nil
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to %s %s %q: %w", change.Action, resource.Type, resource.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// stackDeleteResources deletes the resources of a stack manifest which were created by the stack, in reverse order.
+// The resources it adopted are left alone.
+func stackDeleteResources(client incus.InstanceServer, projectName string, manifest api.StackManifest, managed []api.StackResource) error {
+	var errs []error
+	for _, resource := range slices.Backward(stackResources(client, projectName, manifest, nil)) {
+		managedResource := stackManagedResource(managed, resource)
+		if managedResource == nil || !managedResource.Created {
+			continue
+		}
+
+		err := resource.delete()
+		if err != nil && !stackNotFound(err) {
+			errs = append(errs, fmt.Errorf("Failed deleting %s %q: %w", resource.Type, resource.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stackUsedBy returns the URLs of the resources of a stack manifest.
+func stackUsedBy(projectName string, manifest api.StackManifest) []string {
+	usedBy := []string{}
+	for _, resource := range stackResources(nil, projectName, manifest, nil) {
+		usedBy = append(usedBy, resource.URL.String())
+	}
+
+	return usedBy
+}
+
+// stackAuthorization returns the object and entitlement the requestor needs to make a change, if any.
+// Releasing a resource requires the same entitlement as editing it, as it hands it over from the stack.
+func stackAuthorization(p *api.Project, change stackChange) (auth.Object, auth.Entitlement) {
+	create := change.Action == "create"
+
+	switch change.Type {
+	case stackTypeProfile:
+		if create {
+			return auth.ObjectProject(p.Name), auth.EntitlementCanCreateProfiles
+		}
+
+		return auth.ObjectProfile(project.ProfileProjectFromRecord(p), change.Name), auth.EntitlementCanEdit
+
+	case stackTypeNetwork:
+		if create {
+			return auth.ObjectProject(p.Name), auth.EntitlementCanCreateNetworks
+		}
+
+		return auth.ObjectNetwork(project.NetworkProjectFromRecord(p), change.Name), auth.EntitlementCanEdit
+
+	case stackTypeStorageVolume:
+		if create {
+			return auth.ObjectProject(p.Name), auth.EntitlementCanCreateStorageVolumes
+		}
+
+		poolName, volumeName, _ := strings.Cut(change.Name, "/")
+
+		return auth.ObjectStorageVolume(project.StorageVolumeProjectFromRecord(p, db.StoragePoolVolumeTypeCustom), poolName, "custom", volumeName, ""), auth.EntitlementCanEdit
+
+	case stackTypeInstance:
+		if create {
+			return auth.ObjectProject(p.Name), auth.EntitlementCanCreateInstances
+		}
+
+		return auth.ObjectInstance(p.Name, change.Name), auth.EntitlementCanEdit
+
+	case stackTypeNetworkForward:
+		networkName, _, _ := strings.Cut(change.Name, "/")
+
+		return auth.ObjectNetwork(project.NetworkProjectFromRecord(p), networkName), auth.EntitlementCanEdit
+	}
+
+	return "", ""
+}
+
+// stackAuthorize checks that the requestor is allowed to make all the changes needed to apply a stack, so that
+// nothing is applied if one of them isn't allowed.
+func stackAuthorize(ctx context.Context, s *state.State, r *http.Request, projectName string, changes []stackChange) error {
+	var p *api.Project
+	err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		dbProject, err := dbCluster.GetProject(ctx, tx.Tx(), projectName)
+		if err != nil {
+			return err
+		}
+
+		p, err = dbProject.ToAPI(ctx, tx.Tx())
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		object, entitlement := stackAuthorization(p, change)
+		if object == "" {
+			continue
+		}
+
+		err := s.Authorizer.CheckPermission(ctx, r, object, entitlement)
+		if err != nil {
+			return fmt.Errorf("Not allowed to %s %s %q: %w", change.Action, change.Type, change.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/auth"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func Test_stackConfigMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  map[string]string
+		previous map[string]string
+		desired  map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "New keys are set",
+			current:  map[string]string{"volatile.uuid": "1234"},
+			desired:  map[string]string{"limits.cpu": "2"},
+			expected: map[string]string{"volatile.uuid": "1234", "limits.cpu": "2"},
+		},
+		{
+			name:     "Changed keys are updated",
+			current:  map[string]string{"limits.cpu": "2"},
+			previous: map[string]string{"limits.cpu": "2"},
+			desired:  map[string]string{"limits.cpu": "4"},
+			expected: map[string]string{"limits.cpu": "4"},
+		},
+		{
+			name:     "Keys removed from the manifest are unset",
+			current:  map[string]string{"limits.cpu": "2", "limits.memory": "1GiB", "volatile.uuid": "1234"},
+			previous: map[string]string{"limits.cpu": "2", "limits.memory": "1GiB"},
+			desired:  map[string]string{"limits.cpu": "2"},
+			expected: map[string]string{"limits.cpu": "2", "volatile.uuid": "1234"},
+		},
+		{
+			name:     "Keys never set in the manifest are left alone",
+			current:  map[string]string{"ipv4.address": "10.0.0.1/24"},
+			previous: map[string]string{},
+			desired:  map[string]string{},
+			expected: map[string]string{"ipv4.address": "10.0.0.1/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, stackConfigMerge(tt.current, tt.previous, tt.desired))
+			require.Equal(t, !maps.Equal(tt.current, tt.expected), stackConfigChanged(tt.current, tt.previous, tt.desired))
+		})
+	}
+}
+
+func Test_stackManaged(t *testing.T) {
+	desired := api.StackManifest{
+		Profiles:  []api.ProfilesPost{{Name: "web"}},
+		Instances: []api.InstancesPost{{Name: "web"}, {Name: "db"}, {Name: "cache"}},
+	}
+
+	managed := []api.StackResource{
+		{Type: stackTypeProfile, Name: "web", Created: true},
+		{Type: stackTypeInstance, Name: "db", Created: false},
+		{Type: stackTypeInstance, Name: "old", Created: true},
+	}
+
+	changes := []stackChange{
+		{StackChange: api.StackChange{Action: "create", Type: stackTypeInstance, Name: "web"}},
+		{StackChange: api.StackChange{Action: "adopt", Type: stackTypeInstance, Name: "cache"}},
+		{StackChange: api.StackChange{Action: "delete", Type: stackTypeInstance, Name: "old"}},
+	}
+
+	require.Equal(t, []api.StackResource{
+		{Type: stackTypeProfile, Name: "web", Created: true},
+		{Type: stackTypeInstance, Name: "web", Created: true},
+		{Type: stackTypeInstance, Name: "db", Created: false},
+		{Type: stackTypeInstance, Name: "cache", Created: false},
+	}, stackManaged("default", managed, desired, changes))
+}
+
+func Test_stackAuthorization(t *testing.T) {
+	p := &api.Project{Name: "default", ProjectPut: api.ProjectPut{Config: map[string]string{"features.profiles": "true", "features.networks": "true"}}}
+
+	tests := []struct {
+		name        string
+		change      api.StackChange
+		object      auth.Object
+		entitlement auth.Entitlement
+	}{
+		{
+			name:        "Create instance",
+			change:      api.StackChange{Action: "create", Type: stackTypeInstance, Name: "web"},
+			object:      auth.ObjectProject("default"),
+			entitlement: auth.EntitlementCanCreateInstances,
+		},
+		{
+			name:        "Update instance",
+			change:      api.StackChange{Action: "update", Type: stackTypeInstance, Name: "web"},
+			object:      auth.ObjectInstance("default", "web"),
+			entitlement: auth.EntitlementCanEdit,
+		},
+		{
+			name:        "Release instance",
+			change:      api.StackChange{Action: "release", Type: stackTypeInstance, Name: "web"},
+			object:      auth.ObjectInstance("default", "web"),
+			entitlement: auth.EntitlementCanEdit,
+		},
+		{
+			name:        "Release profile",
+			change:      api.StackChange{Action: "release", Type: stackTypeProfile, Name: "web"},
+			object:      auth.ObjectProfile("default", "web"),
+			entitlement: auth.EntitlementCanEdit,
+		},
+		{
+			name:        "Delete network",
+			change:      api.StackChange{Action: "delete", Type: stackTypeNetwork, Name: "net0"},
+			object:      auth.ObjectNetwork("default", "net0"),
+			entitlement: auth.EntitlementCanEdit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object, entitlement := stackAuthorization(p, stackChange{StackChange: tt.change})
+			require.Equal(t, tt.object, object)
+			require.Equal(t, tt.entitlement, entitlement)
+		})
+	}
+}
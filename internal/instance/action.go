@@ -5,9 +5,10 @@ type InstanceAction string
 
 // InstanceAction types.
 const (
-	Stop     InstanceAction = "stop"
-	Start    InstanceAction = "start"
-	Restart  InstanceAction = "restart"
-	Freeze   InstanceAction = "freeze"
-	Unfreeze InstanceAction = "unfreeze"
+	Stop      InstanceAction = "stop"
+	Start     InstanceAction = "start"
+	Restart   InstanceAction = "restart"
+	Freeze    InstanceAction = "freeze"
+	Unfreeze  InstanceAction = "unfreeze"
+	Hibernate InstanceAction = "hibernate"
 )
@@ -0,0 +1,155 @@
+package instance
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/units"
+)
+
+// autoscaleMemoryStep is the granularity of the memory limits set by the autoscaler.
+const autoscaleMemoryStep = 256 * 1024 * 1024
+
+// AutoscaleConfig represents the vertical autoscaling settings of an instance.
+type AutoscaleConfig struct {
+	// CPU bounds, CPU autoscaling is disabled when CPUMax is 0.
+	CPUMin int64
+	CPUMax int64
+
+	// Memory bounds in bytes, memory autoscaling is disabled when MemoryMax is 0.
+	MemoryMin int64
+	MemoryMax int64
+
+	// Usage percentages below and above which the limits are adjusted.
+	ThresholdLow  int64
+	ThresholdHigh int64
+
+	// Minimum time between two changes of the same limit.
+	Cooldown time.Duration
+}
+
+// ParseAutoscaleConfig returns the autoscaling settings from the instance configuration.
+func ParseAutoscaleConfig(config map[string]string) (*AutoscaleConfig, error) {
+	c := &AutoscaleConfig{
+		CPUMin:        1,
+		MemoryMin:     autoscaleMemoryStep,
+		ThresholdLow:  30,
+		ThresholdHigh: 80,
+		Cooldown:      5 * time.Minute,
+	}
+
+	parseInt := func(key string, target *int64) error {
+		if config[key] == "" {
+			return nil
+		}
+
+		value, err := strconv.ParseInt(config[key], 10, 64)
+		if err != nil {
+			return err
+		}
+
+		*target = value
+
+		return nil
+	}
+
+	parseSize := func(key string, target *int64) error {
+		if config[key] == "" {
+			return nil
+		}
+
+		value, err := units.ParseByteSizeString(config[key])
+		if err != nil {
+			return err
+		}
+
+		*target = value
+
+		return nil
+	}
+
+	for key, target := range map[string]*int64{
+		"autoscale.cpu.min":        &c.CPUMin,
+		"autoscale.cpu.max":        &c.CPUMax,
+		"autoscale.threshold.low":  &c.ThresholdLow,
+		"autoscale.threshold.high": &c.ThresholdHigh,
+	} {
+		err := parseInt(key, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, target := range map[string]*int64{
+		"autoscale.memory.min": &c.MemoryMin,
+		"autoscale.memory.max": &c.MemoryMax,
+	} {
+		err := parseSize(key, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cooldown int64
+	err := parseInt("autoscale.cooldown", &cooldown)
+	if err != nil {
+		return nil, err
+	}
+
+	if config["autoscale.cooldown"] != "" {
+		c.Cooldown = time.Duration(cooldown) * time.Second
+	}
+
+	if c.CPUMax > 0 && c.CPUMin > c.CPUMax {
+		return nil, errors.New("autoscale.cpu.min can't be greater than autoscale.cpu.max")
+	}
+
+	if c.MemoryMax > 0 && c.MemoryMin > c.MemoryMax {
+		return nil, errors.New("autoscale.memory.min can't be greater than autoscale.memory.max")
+	}
+
+	if c.ThresholdLow >= c.ThresholdHigh {
+		return nil, errors.New("autoscale.threshold.low must be lower than autoscale.threshold.high")
+	}
+
+	return c, nil
+}
+
+// target returns the limit which brings the usage in the middle of the thresholds, or the current limit if the
+// usage is already within the thresholds.
+func (c *AutoscaleConfig) target(current float64, used float64) float64 {
+	usage := used * 100 / current
+	if usage >= float64(c.ThresholdLow) && usage <= float64(c.ThresholdHigh) {
+		return current
+	}
+
+	return used * 200 / float64(c.ThresholdLow+c.ThresholdHigh)
+}
+
+// CPUTarget returns the number of CPUs to use given the current limit and the number of CPUs in use.
+func (c *AutoscaleConfig) CPUTarget(current int64, used float64) int64 {
+	if c.CPUMax == 0 || current <= 0 {
+		return current
+	}
+
+	target := int64(math.Ceil(c.target(float64(current), used)))
+
+	return min(max(target, c.CPUMin), c.CPUMax)
+}
+
+// MemoryTarget returns the memory limit to use given the current limit and the memory in use, in bytes.
+func (c *AutoscaleConfig) MemoryTarget(current int64, used int64) int64 {
+	if c.MemoryMax == 0 || current <= 0 {
+		return current
+	}
+
+	target := int64(c.target(float64(current), float64(used)))
+	if target != current {
+		// Round up to avoid changing the limit for minor usage variations.
+		target = (target + autoscaleMemoryStep - 1) / autoscaleMemoryStep * autoscaleMemoryStep
+	}
+
+	return min(max(target, c.MemoryMin), c.MemoryMax)
+}
@@ -0,0 +1,69 @@
+package instance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMiB = 1024 * 1024
+
+func TestParseAutoscaleConfig(t *testing.T) {
+	c, err := ParseAutoscaleConfig(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, &AutoscaleConfig{CPUMin: 1, MemoryMin: 256 * testMiB, ThresholdLow: 30, ThresholdHigh: 80, Cooldown: 5 * time.Minute}, c)
+
+	c, err = ParseAutoscaleConfig(map[string]string{
+		"autoscale.cpu.min":        "2",
+		"autoscale.cpu.max":        "8",
+		"autoscale.memory.max":     "4GiB",
+		"autoscale.threshold.low":  "20",
+		"autoscale.threshold.high": "60",
+		"autoscale.cooldown":       "0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &AutoscaleConfig{CPUMin: 2, CPUMax: 8, MemoryMin: 256 * testMiB, MemoryMax: 4096 * testMiB, ThresholdLow: 20, ThresholdHigh: 60}, c)
+
+	_, err = ParseAutoscaleConfig(map[string]string{"autoscale.cpu.min": "4", "autoscale.cpu.max": "2"})
+	assert.Error(t, err)
+
+	_, err = ParseAutoscaleConfig(map[string]string{"autoscale.threshold.low": "80", "autoscale.threshold.high": "50"})
+	assert.Error(t, err)
+}
+
+func TestAutoscaleCPUTarget(t *testing.T) {
+	c := &AutoscaleConfig{CPUMin: 1, CPUMax: 8, ThresholdLow: 30, ThresholdHigh: 80}
+
+	// Within the thresholds.
+	assert.Equal(t, int64(4), c.CPUTarget(4, 2))
+
+	// Scale up to bring the usage to 55%.
+	assert.Equal(t, int64(7), c.CPUTarget(4, 3.8))
+
+	// Scale up capped by the maximum.
+	assert.Equal(t, int64(8), c.CPUTarget(4, 4))
+
+	// Scale down capped by the minimum.
+	assert.Equal(t, int64(1), c.CPUTarget(4, 0.1))
+
+	// Disabled.
+	c.CPUMax = 0
+	assert.Equal(t, int64(4), c.CPUTarget(4, 4))
+}
+
+func TestAutoscaleMemoryTarget(t *testing.T) {
+	c := &AutoscaleConfig{MemoryMin: 512 * testMiB, MemoryMax: 8192 * testMiB, ThresholdLow: 30, ThresholdHigh: 80}
+
+	// Within the thresholds.
+	assert.Equal(t, int64(2048*testMiB), c.MemoryTarget(2048*testMiB, 1024*testMiB))
+
+	// Scale up, rounded to the next 256MiB.
+	assert.Equal(t, int64(3584*testMiB), c.MemoryTarget(2048*testMiB, 1900*testMiB))
+
+	// Scale down capped by the minimum.
+	assert.Equal(t, int64(512*testMiB), c.MemoryTarget(2048*testMiB, 100*testMiB))
+
+	// Scale up capped by the maximum.
+	assert.Equal(t, int64(8192*testMiB), c.MemoryTarget(8192*testMiB, 8000*testMiB))
+}
@@ -3,6 +3,7 @@ package instance
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +30,71 @@ var HugePageSizeSuffix = [...]string{"64KB", "1MB", "2MB", "1GB"}
 
 // InstanceConfigKeysAny is a map of config key to validator. (keys applying to containers AND virtual machines).
 var InstanceConfigKeysAny = map[string]func(value string) error{
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.cooldown)
+	// Minimum time between two changes of the same limit by the autoscaler.
+	// ---
+	//  type: integer
+	//  defaultdesc: `300`
+	//  liveupdate: yes
+	//  shortdesc: Time in seconds between two autoscaling changes of a limit
+	"autoscale.cooldown": validate.Optional(validate.IsInRange(0, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.cpu.max)
+	// Setting this key enables the autoscaling of `limits.cpu`.
+	// Virtual machines are only autoscaled while the agent is running.
+	// ---
+	//  type: integer
+	//  defaultdesc: empty
+	//  liveupdate: yes
+	//  shortdesc: Maximum number of CPUs set by the autoscaler
+	"autoscale.cpu.max": validate.Optional(validate.IsInRange(1, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.cpu.min)
+	//
+	// ---
+	//  type: integer
+	//  defaultdesc: `1`
+	//  liveupdate: yes
+	//  shortdesc: Minimum number of CPUs set by the autoscaler
+	"autoscale.cpu.min": validate.Optional(validate.IsInRange(1, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.memory.max)
+	// Setting this key enables the autoscaling of `limits.memory`.
+	// Virtual machines are only autoscaled while the agent is running.
+	// ---
+	//  type: string
+	//  defaultdesc: empty
+	//  liveupdate: yes
+	//  shortdesc: Maximum memory limit set by the autoscaler
+	"autoscale.memory.max": validate.Optional(validate.IsSize),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.memory.min)
+	//
+	// ---
+	//  type: string
+	//  defaultdesc: `256MiB`
+	//  liveupdate: yes
+	//  shortdesc: Minimum memory limit set by the autoscaler
+	"autoscale.memory.min": validate.Optional(validate.IsSize),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.threshold.high)
+	// When the usage of a resource goes above this percentage of its limit, the limit is raised.
+	// ---
+	//  type: integer
+	//  defaultdesc: `80`
+	//  liveupdate: yes
+	//  shortdesc: Usage percentage above which limits are raised
+	"autoscale.threshold.high": validate.Optional(validate.IsInRange(1, 100)),
+
+	// gendoc:generate(entity=instance, group=autoscale, key=autoscale.threshold.low)
+	// When the usage of a resource goes below this percentage of its limit, the limit is lowered.
+	// ---
+	//  type: integer
+	//  defaultdesc: `30`
+	//  liveupdate: yes
+	//  shortdesc: Usage percentage below which limits are lowered
+	"autoscale.threshold.low": validate.Optional(validate.IsInRange(0, 99)),
+
 	// gendoc:generate(entity=instance, group=boot, key=boot.autorestart)
 	// If set to `true` will attempt up to 10 restarts over a 1 minute period upon unexpected instance exit.
 	// ---
@@ -63,6 +129,25 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	//  shortdesc: What order to start the instances in
 	"boot.autostart.priority": validate.Optional(validate.IsInt64),
 
+	// gendoc:generate(entity=instance, group=boot, key=boot.depends_on)
+	// Comma-separated list of instances of the same project that this instance depends on.
+	// The dependencies are started before the instance and stopped after it.
+	// See {ref}`instance-options-dependencies` for more information.
+	// ---
+	//  type: string
+	//  liveupdate: yes
+	//  shortdesc: Instances to start before this one
+	"boot.depends_on": validate.Optional(validate.IsListOf(validate.IsHostname)),
+
+	// gendoc:generate(entity=instance, group=boot, key=boot.depends_on.timeout)
+	// Number of seconds to wait for the dependencies of the instance to be ready before starting it anyway.
+	// ---
+	//  type: integer
+	//  defaultdesc: 120
+	//  liveupdate: yes
+	//  shortdesc: How long to wait for the dependencies to be ready
+	"boot.depends_on.timeout": validate.Optional(validate.IsUint32),
+
 	// gendoc:generate(entity=instance, group=boot, key=boot.stop.priority)
 	// The instance with the highest value is shut down first.
 	// ---
@@ -177,6 +262,94 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	//  shortdesc: What to do when evacuating the instance
 	"cluster.evacuate": validate.Optional(validate.IsOneOf("auto", "migrate", "live-migrate", "stop", "stateful-stop", "force-stop")),
 
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.type)
+	// Possible values are:
+	//
+	//   -  `exec`: Run {config:option}`instance-healthcheck:healthcheck.command` in the instance (requires the agent for virtual machines)
+	//   -  `tcp`: Connect to {config:option}`instance-healthcheck:healthcheck.port` of the instance
+	//   -  `http`: Send an HTTP request to {config:option}`instance-healthcheck:healthcheck.port` of the instance and expect a 2xx or 3xx response
+	//
+	// See {ref}`instance-options-healthcheck` for more information.
+	// ---
+	//  type: string
+	//  liveupdate: yes
+	//  shortdesc: Type of health check to run
+	"healthcheck.type": validate.Optional(validate.IsOneOf("exec", "tcp", "http")),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.command)
+	// The check passes if the command exits with status 0.
+	// ---
+	//  type: string
+	//  liveupdate: yes
+	//  shortdesc: Command to run for `exec` health checks
+	"healthcheck.command": validate.IsAny,
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.address)
+	// If not set, the first global address of the instance is used.
+	// ---
+	//  type: string
+	//  liveupdate: yes
+	//  shortdesc: Address to probe for `tcp` and `http` health checks
+	"healthcheck.address": validate.Optional(validate.IsNetworkAddress),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.port)
+	//
+	// ---
+	//  type: integer
+	//  liveupdate: yes
+	//  shortdesc: Port to probe for `tcp` and `http` health checks
+	"healthcheck.port": validate.Optional(validate.IsNetworkPort),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.path)
+	//
+	// ---
+	//  type: string
+	//  defaultdesc: `/`
+	//  liveupdate: yes
+	//  shortdesc: Path to request for `http` health checks
+	"healthcheck.path": validate.IsAny,
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.interval)
+	//
+	// ---
+	//  type: integer
+	//  defaultdesc: `30`
+	//  liveupdate: yes
+	//  shortdesc: Number of seconds between health checks
+	"healthcheck.interval": validate.Optional(validate.IsInRange(10, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.timeout)
+	//
+	// ---
+	//  type: integer
+	//  defaultdesc: `5`
+	//  liveupdate: yes
+	//  shortdesc: Number of seconds before a health check is considered failed
+	"healthcheck.timeout": validate.Optional(validate.IsInRange(1, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.threshold)
+	//
+	// ---
+	//  type: integer
+	//  defaultdesc: `3`
+	//  liveupdate: yes
+	//  shortdesc: Number of consecutive failed health checks before the instance is unhealthy
+	"healthcheck.threshold": validate.Optional(validate.IsInRange(1, math.MaxInt32)),
+
+	// gendoc:generate(entity=instance, group=healthcheck, key=healthcheck.action)
+	// Possible values are:
+	//
+	//   -  `none`: Only report the instance as unhealthy
+	//   -  `restart`: Restart the instance
+	//   -  `rebuild`: Rebuild the instance from its image and start it again (its root disk is lost)
+	//   -  `evacuate`: Stop the instance, move it to another cluster member and start it there
+	// ---
+	//  type: string
+	//  defaultdesc: `none`
+	//  liveupdate: yes
+	//  shortdesc: What to do when the instance becomes unhealthy
+	"healthcheck.action": validate.Optional(validate.IsOneOf("none", "restart", "rebuild", "evacuate")),
+
 	// gendoc:generate(entity=instance, group=resource-limits, key=limits.cpu)
 	// A number or a specific range of CPUs to expose to the instance.
 	//
@@ -278,6 +451,36 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	//  shortdesc: Raw idmap configuration
 	"raw.idmap": validate.IsAny,
 
+	// gendoc:generate(entity=instance, group=schedule, key=schedule.restart)
+	// Specify either a cron expression (`<minute> <hour> <dom> <month> <dow>`) or a comma-and-space-separated list of schedule aliases (`@hourly`, `@daily`, `@midnight`, `@weekly`, `@monthly`, `@annually`, `@yearly`).
+	// The instance is only restarted if it's running.
+	// ---
+	//  type: string
+	//  defaultdesc: empty
+	//  liveupdate: yes
+	//  shortdesc: Schedule for restarting the instance
+	"schedule.restart": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+
+	// gendoc:generate(entity=instance, group=schedule, key=schedule.start)
+	// Specify either a cron expression (`<minute> <hour> <dom> <month> <dow>`) or a comma-and-space-separated list of schedule aliases (`@hourly`, `@daily`, `@midnight`, `@weekly`, `@monthly`, `@annually`, `@yearly`).
+	// The instance is only started if it's stopped.
+	// ---
+	//  type: string
+	//  defaultdesc: empty
+	//  liveupdate: yes
+	//  shortdesc: Schedule for starting the instance
+	"schedule.start": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+
+	// gendoc:generate(entity=instance, group=schedule, key=schedule.stop)
+	// Specify either a cron expression (`<minute> <hour> <dom> <month> <dow>`) or a comma-and-space-separated list of schedule aliases (`@hourly`, `@daily`, `@midnight`, `@weekly`, `@monthly`, `@annually`, `@yearly`).
+	// The instance is only stopped if it's running.
+	// ---
+	//  type: string
+	//  defaultdesc: empty
+	//  liveupdate: yes
+	//  shortdesc: Schedule for stopping the instance
+	"schedule.stop": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
+
 	// gendoc:generate(entity=instance, group=security, key=security.guestapi)
 	// See {ref}`dev-incus` for more information.
 	// ---
@@ -405,6 +608,13 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	//  shortdesc: Instance state as of last host shutdown
 	"volatile.last_state.power": validate.IsAny,
 
+	// gendoc:generate(entity=instance, group=volatile, key=volatile.last_state.hibernated)
+	//
+	// ---
+	//  type: bool
+	//  shortdesc: Whether the virtual machine was hibernated and should resume from its saved state
+	"volatile.last_state.hibernated": validate.IsBool,
+
 	// gendoc:generate(entity=instance, group=volatile, key=volatile.last_state.ready)
 	//
 	// ---
@@ -995,6 +1205,29 @@ var InstanceConfigKeysVM = map[string]func(value string) error{
 	//  shortdesc: Whether to back the instance using huge pages
 	"limits.memory.hugepages": validate.Optional(validate.IsBool),
 
+	// gendoc:generate(entity=instance, group=migration, key=migration.postcopy)
+	// In post-copy mode, the instance resumes on the target after a few pre-copy passes and fetches the rest
+	// of its memory from the source on demand. This bounds the migration time for busy instances, but
+	// a failure of the source or of the network after the switch leaves the instance stopped.
+	// It can also be enabled for a single migration with `incus move --live --postcopy`.
+	// ---
+	//  type: bool
+	//  defaultdesc: `false`
+	//  liveupdate: yes
+	//  condition: virtual machine
+	//  shortdesc: Whether to switch live migrations to post-copy mode
+	"migration.postcopy": validate.Optional(validate.IsBool),
+
+	// gendoc:generate(entity=instance, group=migration, key=migration.postcopy.passes)
+	//
+	// ---
+	//  type: integer
+	//  defaultdesc: `2`
+	//  liveupdate: yes
+	//  condition: virtual machine
+	//  shortdesc: Number of pre-copy passes over the memory before switching to post-copy mode
+	"migration.postcopy.passes": validate.Optional(validate.IsUint32),
+
 	// Caller is responsible for full validation of any raw.* value.
 
 	// gendoc:generate(entity=instance, group=raw, key=raw.qemu)
@@ -1131,6 +1364,17 @@ var InstanceConfigKeysVM = map[string]func(value string) error{
 	//  shortdesc: The guest owner's `base64`-encoded session blob
 	"security.sev.session.data": validate.Optional(validate.IsAny),
 
+	// gendoc:generate(entity=instance, group=security, key=security.tdx)
+	// This requires {config:option}`instance-security:security.secureboot` to be set to `false`.
+	// Memory hotplug, stateful migration and `virtiofs` shares are not available for Intel TDX guests.
+	// ---
+	//  type: bool
+	//  defaultdesc: `false`
+	//  liveupdate: no
+	//  condition: virtual machine
+	//  shortdesc: Whether Intel TDX (Trust Domain Extensions) is enabled for this VM
+	"security.tdx": validate.Optional(validate.IsBool),
+
 	// gendoc:generate(entity=instance, group=miscellaneous, key=agent.nic_config)
 	// For containers, the name and MTU of the default network interfaces is used for the instance devices.
 	// For virtual machines, set this option to `true` to set the name and MTU of the default network interfaces to be the same as the instance devices.
@@ -1241,6 +1485,24 @@ func ConfigKeyChecker(key string, instanceType api.InstanceType) (func(value str
 			return validate.IsAny, nil
 		}
 
+		// gendoc:generate(entity=instance, group=volatile, key=volatile.<name>.ipam.ipv4.address)
+		// The address is used when no `ipv4.address` property is set on the device itself.
+		// ---
+		//  type: string
+		//  shortdesc: IPv4 address reserved for the network device in an external IPAM
+		if strings.HasSuffix(key, ".ipam.ipv4.address") {
+			return validate.Optional(validate.IsNetworkAddressV4), nil
+		}
+
+		// gendoc:generate(entity=instance, group=volatile, key=volatile.<name>.ipam.ipv6.address)
+		// The address is used when no `ipv6.address` property is set on the device itself.
+		// ---
+		//  type: string
+		//  shortdesc: IPv6 address reserved for the network device in an external IPAM
+		if strings.HasSuffix(key, ".ipam.ipv6.address") {
+			return validate.Optional(validate.IsNetworkAddressV6), nil
+		}
+
 		// gendoc:generate(entity=instance, group=volatile, key=volatile.<name>.io.bus)
 		// The IO bus stores the actual IO bus being used, checked in case `io.bus=auto`.
 		// ---
@@ -0,0 +1,157 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Dependencies returns the names of the instances an instance depends on, as set in boot.depends_on.
+func Dependencies(config map[string]string) []string {
+	dependencies := []string{}
+
+	for _, name := range strings.Split(config["boot.depends_on"], ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(dependencies, name) {
+			continue
+		}
+
+		dependencies = append(dependencies, name)
+	}
+
+	return dependencies
+}
+
+// DependencyLevels returns the dependency level of each instance, given the dependencies of each instance.
+// Instances without dependencies are at level 0 and the other instances are one level above their deepest
+// dependency, so instances can be started level by level. Dependencies on instances which aren't in the map
+// are ignored. An error is returned if the dependencies contain a cycle.
+func DependencyLevels(dependencies map[string][]string) (map[string]int, error) {
+	levels := make(map[string]int, len(dependencies))
+	visiting := map[string]bool{}
+
+	var visit func(path []string, name string) (int, error)
+	visit = func(path []string, name string) (int, error) {
+		level, ok := levels[name]
+		if ok {
+			return level, nil
+		}
+
+		path = append(path, name)
+		if visiting[name] {
+			cycle := path[slices.Index(path, name):]
+			return -1, fmt.Errorf("Dependency cycle between instances: %s", strings.Join(cycle, " -> "))
+		}
+
+		visiting[name] = true
+
+		for _, dependency := range dependencies[name] {
+			_, ok := dependencies[dependency]
+			if !ok {
+				continue
+			}
+
+			dependencyLevel, err := visit(path, dependency)
+			if err != nil {
+				return -1, err
+			}
+
+			level = max(level, dependencyLevel+1)
+		}
+
+		visiting[name] = false
+		levels[name] = level
+
+		return level, nil
+	}
+
+	// Go through the instances in a stable order so the same cycle is always reported.
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		_, err := visit(nil, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return levels, nil
+}
+
+// CheckDependencies checks that the dependencies of an instance refer to other instances and don't introduce a
+// cycle, given the dependencies of the other instances of its project.
+func CheckDependencies(dependencies map[string][]string, instanceName string, instanceDependencies []string) error {
+	if slices.Contains(instanceDependencies, instanceName) {
+		return errors.New("Instance can't depend on itself")
+	}
+
+	for _, dependency := range instanceDependencies {
+		_, ok := dependencies[dependency]
+		if !ok {
+			return fmt.Errorf("Dependency %q doesn't exist", dependency)
+		}
+	}
+
+	updated := maps.Clone(dependencies)
+	updated[instanceName] = instanceDependencies
+
+	_, err := DependencyLevels(updated)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckDependenciesRename checks that renaming an instance doesn't break the dependencies of other instances or
+// introduce a cycle, given the dependencies of the instances of its project.
+func CheckDependenciesRename(dependencies map[string][]string, oldName string, newName string) error {
+	for name, instanceDependencies := range dependencies {
+		if name != oldName && slices.Contains(instanceDependencies, oldName) {
+			return fmt.Errorf("Instance %q depends on %q", name, oldName)
+		}
+	}
+
+	updated := maps.Clone(dependencies)
+	updated[newName] = updated[oldName]
+	delete(updated, oldName)
+
+	_, err := DependencyLevels(updated)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReverseDependencies returns the instances depending on each instance, given the dependencies of each instance.
+// Passing the result to DependencyLevels gives the levels to stop the instances in.
+func ReverseDependencies(dependencies map[string][]string) map[string][]string {
+	dependents := make(map[string][]string, len(dependencies))
+
+	for name, instanceDependencies := range dependencies {
+		_, ok := dependents[name]
+		if !ok {
+			dependents[name] = []string{}
+		}
+
+		for _, dependency := range instanceDependencies {
+			_, ok := dependencies[dependency]
+			if !ok {
+				continue
+			}
+
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	return dependents
+}
@@ -0,0 +1,149 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	assert.Equal(t, []string{}, Dependencies(map[string]string{}))
+	assert.Equal(t, []string{"db", "cache"}, Dependencies(map[string]string{"boot.depends_on": "db, cache,db,"}))
+}
+
+func TestDependencyLevels(t *testing.T) {
+	tests := []struct {
+		name           string
+		dependencies   map[string][]string
+		expectedLevels map[string]int
+		expectedErr    string
+	}{
+		{
+			name: "No dependencies",
+			dependencies: map[string][]string{
+				"a": {},
+				"b": {},
+			},
+			expectedLevels: map[string]int{"a": 0, "b": 0},
+		},
+		{
+			name: "Chain",
+			dependencies: map[string][]string{
+				"app":   {"cache", "db"},
+				"cache": {"db"},
+				"db":    {},
+				"web":   {"app"},
+			},
+			expectedLevels: map[string]int{"db": 0, "cache": 1, "app": 2, "web": 3},
+		},
+		{
+			name: "Unknown dependency",
+			dependencies: map[string][]string{
+				"app": {"db"},
+			},
+			expectedLevels: map[string]int{"app": 0},
+		},
+		{
+			name: "Cycle",
+			dependencies: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"a"},
+			},
+			expectedErr: "Dependency cycle between instances: a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := DependencyLevels(tt.dependencies)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedLevels, levels)
+		})
+	}
+}
+
+func TestReverseDependencies(t *testing.T) {
+	dependents := ReverseDependencies(map[string][]string{
+		"app": {"db", "other"},
+		"db":  {},
+	})
+
+	assert.Equal(t, map[string][]string{"app": {}, "db": {"app"}}, dependents)
+
+	levels, err := DependencyLevels(dependents)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"app": 0, "db": 1}, levels)
+}
+
+func TestCheckDependencies(t *testing.T) {
+	dependencies := map[string][]string{
+		"app": {"db"},
+		"db":  {},
+	}
+
+	tests := []struct {
+		name                 string
+		instanceName         string
+		instanceDependencies []string
+		expectedErr          string
+	}{
+		{
+			name:                 "New instance",
+			instanceName:         "web",
+			instanceDependencies: []string{"app", "db"},
+		},
+		{
+			name:                 "Self dependency",
+			instanceName:         "web",
+			instanceDependencies: []string{"web"},
+			expectedErr:          "Instance can't depend on itself",
+		},
+		{
+			name:                 "Missing dependency",
+			instanceName:         "web",
+			instanceDependencies: []string{"cache"},
+			expectedErr:          `Dependency "cache" doesn't exist`,
+		},
+		{
+			name:                 "Cycle",
+			instanceName:         "db",
+			instanceDependencies: []string{"app"},
+			expectedErr:          "Dependency cycle between instances: app -> db -> app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckDependencies(dependencies, tt.instanceName, tt.instanceDependencies)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.Equal(t, map[string][]string{"app": {"db"}, "db": {}}, dependencies)
+}
+
+func TestCheckDependenciesRename(t *testing.T) {
+	dependencies := map[string][]string{
+		"app":   {"db"},
+		"db":    {},
+		"other": {"web"},
+		"web":   {},
+	}
+
+	assert.NoError(t, CheckDependenciesRename(dependencies, "app", "app2"))
+	assert.EqualError(t, CheckDependenciesRename(dependencies, "db", "db2"), `Instance "app" depends on "db"`)
+
+	// Renaming an instance to the name of a missing dependency completes the dependency graph.
+	assert.EqualError(t, CheckDependenciesRename(map[string][]string{"a": {"b"}, "c": {"a"}}, "c", "b"), "Dependency cycle between instances: a -> b -> a")
+}
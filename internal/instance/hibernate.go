@@ -0,0 +1,20 @@
+package instance
+
+import (
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+// Hibernated returns whether an instance was hibernated and has a saved state to resume from.
+func Hibernated(config map[string]string) bool {
+	return util.IsTrue(config["volatile.last_state.hibernated"])
+}
+
+// RestoreState returns whether starting an instance should restore its saved state.
+// A hibernated instance is resumed unless a stateless start was explicitly requested.
+func RestoreState(config map[string]string, stateful bool, stateless bool) bool {
+	if stateless {
+		return false
+	}
+
+	return stateful || Hibernated(config)
+}
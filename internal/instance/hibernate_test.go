@@ -0,0 +1,61 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreState(t *testing.T) {
+	running := map[string]string{"volatile.last_state.power": "RUNNING"}
+	hibernated := map[string]string{"volatile.last_state.power": "STOPPED", "volatile.last_state.hibernated": "true"}
+	started := map[string]string{"volatile.last_state.power": "RUNNING", "volatile.last_state.hibernated": ""}
+
+	tests := []struct {
+		name      string
+		config    map[string]string
+		stateful  bool
+		stateless bool
+		expected  bool
+	}{
+		{
+			name:     "Plain start of a regular instance",
+			config:   running,
+			expected: false,
+		},
+		{
+			name:     "Stateful start of a regular instance",
+			config:   running,
+			stateful: true,
+			expected: true,
+		},
+		{
+			name:     "Plain start of a hibernated instance",
+			config:   hibernated,
+			expected: true,
+		},
+		{
+			name:     "Stateful start of a hibernated instance",
+			config:   hibernated,
+			stateful: true,
+			expected: true,
+		},
+		{
+			name:      "Stateless start of a hibernated instance",
+			config:    hibernated,
+			stateless: true,
+			expected:  false,
+		},
+		{
+			name:     "Plain start once the hibernated state was consumed",
+			config:   started,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RestoreState(tt.config, tt.stateful, tt.stateless))
+		})
+	}
+}
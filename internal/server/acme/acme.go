@@ -100,6 +100,16 @@ func UpdateCertificate(s *state.State, challengeType string, clustered bool, dom
 		return nil, nil
 	}
 
+	return IssueCertificate(s, challengeType, domain, email, caURL)
+}
+
+// CertificateNeedsUpdate returns true if the certificate doesn't match the domain or is valid for less than 30 days.
+func CertificateNeedsUpdate(domain string, cert *x509.Certificate) bool {
+	return certificateNeedsUpdate(domain, cert)
+}
+
+// IssueCertificate issues a new certificate for the domain.
+func IssueCertificate(s *state.State, challengeType string, domain string, email string, caURL string) (*CertKeyPair, error) {
 	tmpDir, err := os.MkdirTemp("", "lego")
 	if err != nil {
 		return nil, fmt.Errorf("Failed to create temporary directory: %w", err)
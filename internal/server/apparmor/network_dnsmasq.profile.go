@@ -27,7 +27,9 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
   network unix dgram,
 
   # Network-specific paths
+  {{ .varPath }}/networks/{{ .networkName }}/dhcp.hosts/{,*} r,
   {{ .varPath }}/networks/{{ .networkName }}/dnsmasq.hosts/{,*} r,
+  {{ .varPath }}/networks/{{ .networkName }}/dnsmasq.opts/{,*} r,
   {{ .varPath }}/networks/{{ .networkName }}/dnsmasq.leases rw,
   {{ .varPath }}/networks/{{ .networkName }}/dnsmasq.raw r,
 
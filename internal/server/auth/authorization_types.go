@@ -34,11 +34,12 @@ const (
 	EntitlementCanViewOperations           Entitlement = "can_view_operations"
 
 	// Instance entitlements.
-	EntitlementCanAccessConsole Entitlement = "can_access_console"
-	EntitlementCanAccessFiles   Entitlement = "can_access_files"
-	EntitlementCanConnectSFTP   Entitlement = "can_connect_sftp"
-	EntitlementCanExec          Entitlement = "can_exec"
-	EntitlementCanUpdateState   Entitlement = "can_update_state"
+	EntitlementCanAccessConsole  Entitlement = "can_access_console"
+	EntitlementCanAccessFiles    Entitlement = "can_access_files"
+	EntitlementCanCaptureTraffic Entitlement = "can_capture_traffic"
+	EntitlementCanConnectSFTP    Entitlement = "can_connect_sftp"
+	EntitlementCanExec           Entitlement = "can_exec"
+	EntitlementCanUpdateState    Entitlement = "can_update_state"
 
 	// Instance and storage volume entitlements.
 	EntitlementCanManageBackups   Entitlement = "can_manage_backups"
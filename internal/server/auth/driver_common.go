@@ -38,7 +38,7 @@ type requestDetails struct {
 }
 
 func (r *requestDetails) isInternalOrUnix() bool {
-	if r.Protocol == "unix" {
+	if r.Protocol == "unix" && (r.forwardedProtocol == "unix" || r.forwardedProtocol == "cluster" || r.forwardedProtocol == "") {
 		return true
 	}
 
@@ -50,7 +50,7 @@ func (r *requestDetails) isInternalOrUnix() bool {
 }
 
 func (r *requestDetails) username() string {
-	if r.Protocol == "cluster" && r.forwardedUsername != "" {
+	if (r.Protocol == "cluster" || r.Protocol == "unix") && r.forwardedUsername != "" {
 		return r.forwardedUsername
 	}
 
@@ -58,7 +58,7 @@ func (r *requestDetails) username() string {
 }
 
 func (r *requestDetails) authenticationProtocol() string {
-	if r.Protocol == "cluster" {
+	if r.Protocol == "cluster" || (r.Protocol == "unix" && r.forwardedProtocol != "") {
 		return r.forwardedProtocol
 	}
 
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/server/auth/common"
+)
+
+func Test_requestDetails(t *testing.T) {
+	tests := []struct {
+		name              string
+		protocol          string
+		username          string
+		forwardedUsername string
+		forwardedProtocol string
+		isInternalOrUnix  bool
+		actualUsername    string
+		actualProtocol    string
+	}{
+		{
+			name:             "Unix socket client",
+			protocol:         "unix",
+			username:         "root",
+			isInternalOrUnix: true,
+			actualUsername:   "root",
+			actualProtocol:   "unix",
+		},
+		{
+			name:              "Unix socket request forwarded by the daemon for a remote client",
+			protocol:          "unix",
+			username:          "root",
+			forwardedUsername: "alice",
+			forwardedProtocol: "tls",
+			isInternalOrUnix:  false,
+			actualUsername:    "alice",
+			actualProtocol:    "tls",
+		},
+		{
+			name:              "Unix socket request forwarded by the daemon for a unix socket client",
+			protocol:          "unix",
+			username:          "root",
+			forwardedUsername: "bob",
+			forwardedProtocol: "unix",
+			isInternalOrUnix:  true,
+			actualUsername:    "bob",
+			actualProtocol:    "unix",
+		},
+		{
+			name:              "Cluster request forwarded for a remote client",
+			protocol:          "cluster",
+			username:          "member",
+			forwardedUsername: "alice",
+			forwardedProtocol: "oidc",
+			isInternalOrUnix:  false,
+			actualUsername:    "alice",
+			actualProtocol:    "oidc",
+		},
+		{
+			name:             "Remote client",
+			protocol:         "tls",
+			username:         "alice",
+			isInternalOrUnix: false,
+			actualUsername:   "alice",
+			actualProtocol:   "tls",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := &requestDetails{
+				RequestDetails:    common.RequestDetails{Username: tt.username, Protocol: tt.protocol},
+				forwardedUsername: tt.forwardedUsername,
+				forwardedProtocol: tt.forwardedProtocol,
+			}
+
+			require.Equal(t, tt.isInternalOrUnix, details.isInternalOrUnix())
+
+			actual := details.actualDetails()
+			require.Equal(t, tt.actualUsername, actual.Username)
+			require.Equal(t, tt.actualProtocol, actual.Protocol)
+		})
+	}
+}
@@ -75,6 +75,11 @@ func (t *TLS) CheckPermission(ctx context.Context, r *http.Request, object Objec
 		return api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
 	}
 
+	// Don't allow capturing network traffic.
+	if entitlement == EntitlementCanCaptureTraffic {
+		return api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
+	}
+
 	// Don't allow project modifications.
 	if object.Type() == ObjectTypeProject && entitlement == EntitlementCanEdit {
 		return api.StatusErrorf(http.StatusForbidden, "Certificate is restricted")
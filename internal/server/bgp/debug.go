@@ -20,6 +20,7 @@ type DebugInfoPrefix struct {
 	Owner   string `json:"owner" yaml:"owner"`
 	Prefix  string `json:"prefix" yaml:"prefix"`
 	Nexthop string `json:"nexthop" yaml:"nexthop"`
+	VNI     uint32 `json:"vni,omitempty" yaml:"vni,omitempty"`
 }
 
 // DebugInfoPeer exposes details on a single BGP peer.
@@ -29,6 +30,7 @@ type DebugInfoPeer struct {
 	Password string `json:"password" yaml:"password"`
 	Count    int    `json:"count" yaml:"count"`
 	HoldTime uint64 `json:"holdtime" yaml:"holdtime"`
+	EVPN     bool   `json:"evpn" yaml:"evpn"`
 }
 
 // Debug returns a dump of the current configuration.
@@ -58,6 +60,7 @@ func (s *Server) debug() DebugInfo {
 		entry.Password = peer.password
 		entry.Count = peer.count
 		entry.HoldTime = peer.holdtime
+		entry.EVPN = peer.evpn
 
 		debug.Peers = append(debug.Peers, entry)
 	}
@@ -69,6 +72,9 @@ func (s *Server) debug() DebugInfo {
 		entry.Prefix = path.prefix.String()
 		entry.Owner = path.owner
 		entry.Nexthop = path.nexthop.String()
+		if path.evpn != nil {
+			entry.VNI = path.evpn.VNI
+		}
 
 		debug.Prefixes = append(debug.Prefixes, entry)
 	}
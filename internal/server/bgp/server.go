@@ -7,6 +7,7 @@ import (
 	"maps"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/google/uuid"
@@ -38,6 +39,7 @@ type path struct {
 	owner   string
 	prefix  net.IPNet
 	nexthop net.IP
+	evpn    *EVPNRoute
 }
 
 type peer struct {
@@ -45,9 +47,22 @@ type peer struct {
 	asn      uint32
 	password string
 	holdtime uint64
+	evpn     bool
 	count    int
 }
 
+// EVPNRoute represents the EVPN parameters of a prefix advertised as an EVPN IP prefix route (type 5).
+type EVPNRoute struct {
+	// VNI is the layer 3 VNI of the route.
+	VNI uint32
+
+	// RouteTarget is the route target of the route (ASN:NN), defaults to the local ASN and VNI.
+	RouteTarget string
+
+	// Gateway is the overlay gateway address of the route.
+	Gateway net.IP
+}
+
 // NewServer returns a new server instance.
 func NewServer() *Server {
 	// Setup new struct.
@@ -99,8 +114,8 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 		RouterId: routerID.String(),
 		Asn:      asn,
 
-		// Always setup for IPv4 and IPv6.
-		Families: []uint32{0, 1},
+		// Always setup for IPv4, IPv6 and EVPN.
+		Families: []uint32{0, 1, 9},
 
 		// Listen address.
 		ListenAddresses: []string{addrHost},
@@ -113,6 +128,11 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 		return err
 	}
 
+	// Record the address (EVPN paths rely on the ASN and router ID).
+	s.address = address
+	s.asn = asn
+	s.routerID = routerID
+
 	// Copy the path list
 	oldPaths := map[string]path{}
 	maps.Copy(oldPaths, s.paths)
@@ -120,7 +140,12 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 	// Add existing paths.
 	s.paths = map[string]path{}
 	for _, path := range oldPaths {
-		err := s.addPrefix(path.prefix, path.nexthop, path.owner)
+		if path.evpn != nil {
+			err = s.addEVPNPrefix(path.prefix, path.nexthop, *path.evpn, path.owner)
+		} else {
+			err = s.addPrefix(path.prefix, path.nexthop, path.owner)
+		}
+
 		if err != nil {
 			return err
 		}
@@ -133,17 +158,12 @@ func (s *Server) start(address string, asn uint32, routerID net.IP) error {
 	// Add existing peers.
 	s.peers = map[string]peer{}
 	for _, peer := range oldPeers {
-		err := s.addPeer(peer.address, peer.asn, peer.password, peer.holdtime)
+		err := s.addPeer(peer.address, peer.asn, peer.password, peer.holdtime, peer.evpn)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Record the address.
-	s.address = address
-	s.asn = asn
-	s.routerID = routerID
-
 	return nil
 }
 
@@ -238,7 +258,7 @@ func (s *Server) AddPrefix(subnet net.IPNet, nexthop net.IP, owner string) error
 func (s *Server) addPrefix(subnet net.IPNet, nexthop net.IP, owner string) error {
 	// Check for an existing entry.
 	for _, path := range s.paths {
-		if path.owner != owner || path.prefix.String() != subnet.String() || path.nexthop.String() != nexthop.String() {
+		if path.owner != owner || path.prefix.String() != subnet.String() || path.nexthop.String() != nexthop.String() || path.evpn != nil {
 			continue
 		}
 
@@ -320,6 +340,158 @@ func (s *Server) addPrefix(subnet net.IPNet, nexthop net.IP, owner string) error
 	return nil
 }
 
+// AddEVPNPrefix adds a new prefix to the BGP server, advertised as an EVPN IP prefix route.
+func (s *Server) AddEVPNPrefix(subnet net.IPNet, nexthop net.IP, route EVPNRoute, owner string) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addEVPNPrefix(subnet, nexthop, route, owner)
+}
+
+func (s *Server) addEVPNPrefix(subnet net.IPNet, nexthop net.IP, route EVPNRoute, owner string) error {
+	// Check for an existing entry.
+	for _, path := range s.paths {
+		if path.owner != owner || path.prefix.String() != subnet.String() || path.nexthop.String() != nexthop.String() || path.evpn == nil || path.evpn.VNI != route.VNI {
+			continue
+		}
+
+		return nil
+	}
+
+	// Add the prefix to the server.
+	var pathUUID string
+	if s.bgp != nil {
+		routeTarget := route.RouteTarget
+		if routeTarget == "" {
+			routeTarget = fmt.Sprintf("%d:%d", s.asn, route.VNI)
+		}
+
+		rtASN, rtValue, err := ParseRouteTarget(routeTarget)
+		if err != nil {
+			return err
+		}
+
+		// Prepare the prefix.
+		prefixLen, _ := subnet.Mask.Size()
+		rd, _ := anypb.New(&bgpAPI.RouteDistinguisherIPAddress{
+			Admin:    s.routerID.String(),
+			Assigned: route.VNI,
+		})
+
+		evpnRoute := &bgpAPI.EVPNIPPrefixRoute{
+			Rd:          rd,
+			Esi:         &bgpAPI.EthernetSegmentIdentifier{},
+			IpPrefix:    subnet.IP.String(),
+			IpPrefixLen: uint32(prefixLen),
+			Label:       route.VNI,
+		}
+
+		if route.Gateway != nil {
+			evpnRoute.GwAddress = route.Gateway.String()
+		} else if subnet.IP.To4() != nil {
+			evpnRoute.GwAddress = "0.0.0.0"
+		} else {
+			evpnRoute.GwAddress = "::"
+		}
+
+		nlri, _ := anypb.New(evpnRoute)
+
+		aOrigin, _ := anypb.New(&bgpAPI.OriginAttribute{
+			Origin: 0,
+		})
+
+		family := &bgpAPI.Family{
+			Afi:  bgpAPI.Family_AFI_L2VPN,
+			Safi: bgpAPI.Family_SAFI_EVPN,
+		}
+
+		aNextHop, _ := anypb.New(&bgpAPI.MpReachNLRIAttribute{
+			Family:   family,
+			NextHops: []string{nexthop.String()},
+			Nlris:    []*anypb.Any{nlri},
+		})
+
+		// Use the 4-octet form for route targets which don't fit the 2-octet one.
+		var rt *anypb.Any
+		if rtASN > 65535 {
+			rt, _ = anypb.New(&bgpAPI.FourOctetAsSpecificExtended{
+				IsTransitive: true,
+				SubType:      0x02,
+				Asn:          rtASN,
+				LocalAdmin:   rtValue,
+			})
+		} else {
+			rt, _ = anypb.New(&bgpAPI.TwoOctetAsSpecificExtended{
+				IsTransitive: true,
+				SubType:      0x02,
+				Asn:          rtASN,
+				LocalAdmin:   rtValue,
+			})
+		}
+
+		// VXLAN encapsulation (RFC 9012).
+		encap, _ := anypb.New(&bgpAPI.EncapExtended{
+			TunnelType: 8,
+		})
+
+		aCommunities, _ := anypb.New(&bgpAPI.ExtendedCommunitiesAttribute{
+			Communities: []*anypb.Any{rt, encap},
+		})
+
+		resp, err := s.bgp.AddPath(context.Background(), &bgpAPI.AddPathRequest{
+			Path: &bgpAPI.Path{
+				Family: family,
+				Nlri:   nlri,
+				Pattrs: []*anypb.Any{aOrigin, aNextHop, aCommunities},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		pathUUID = string(resp.Uuid)
+	} else {
+		// Generate a dummy UUID.
+		pathUUID = uuid.New().String()
+	}
+
+	// Add path to the map.
+	s.paths[pathUUID] = path{
+		prefix:  subnet,
+		nexthop: nexthop,
+		owner:   owner,
+		evpn:    &route,
+	}
+
+	return nil
+}
+
+// ParseRouteTarget parses a route target in the ASN:NN format.
+func ParseRouteTarget(value string) (uint32, uint32, error) {
+	asnStr, valueStr, found := strings.Cut(value, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("Invalid route target %q, expected ASN:NN", value)
+	}
+
+	asn, err := strconv.ParseUint(asnStr, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid route target ASN %q: %w", asnStr, err)
+	}
+
+	maxValue := uint64(4294967295)
+	if asn > 65535 {
+		maxValue = 65535
+	}
+
+	nn, err := strconv.ParseUint(valueStr, 10, 32)
+	if err != nil || nn > maxValue {
+		return 0, 0, fmt.Errorf("Invalid route target value %q", valueStr)
+	}
+
+	return uint32(asn), uint32(nn), nil
+}
+
 // RemovePrefixByOwner removes all prefixes for the provided owner.
 func (s *Server) RemovePrefixByOwner(owner string) error {
 	// Locking.
@@ -355,7 +527,7 @@ func (s *Server) RemovePrefix(subnet net.IPNet, nexthop net.IP) error {
 func (s *Server) removePrefix(subnet net.IPNet, nexthop net.IP) error {
 	found := false
 	for pathUUID, path := range s.paths {
-		if path.prefix.String() != subnet.String() || path.nexthop.String() != nexthop.String() {
+		if path.prefix.String() != subnet.String() || path.nexthop.String() != nexthop.String() || path.evpn != nil {
 			continue
 		}
 
@@ -396,10 +568,19 @@ func (s *Server) AddPeer(address net.IP, asn uint32, password string, holdTime u
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.addPeer(address, asn, password, holdTime)
+	return s.addPeer(address, asn, password, holdTime, false)
 }
 
-func (s *Server) addPeer(address net.IP, asn uint32, password string, holdTime uint64) error {
+// AddEVPNPeer adds a new BGP peer exchanging EVPN routes.
+func (s *Server) AddEVPNPeer(address net.IP, asn uint32, password string, holdTime uint64) error {
+	// Locking.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addPeer(address, asn, password, holdTime, true)
+}
+
+func (s *Server) addPeer(address net.IP, asn uint32, password string, holdTime uint64, evpn bool) error {
 	// Look for an existing peer.
 	bgpPeer, bgpPeerExists := s.peers[address.String()]
 	if bgpPeerExists {
@@ -411,6 +592,10 @@ func (s *Server) addPeer(address net.IP, asn uint32, password string, holdTime u
 			return fmt.Errorf("Peer %q already used but with a different password", address)
 		}
 
+		if bgpPeer.evpn != evpn {
+			return fmt.Errorf("Peer %q already used but with different address families", address)
+		}
+
 		// Reuse the existing entry.
 		bgpPeer.count++
 		s.peers[address.String()] = bgpPeer
@@ -448,9 +633,14 @@ func (s *Server) addPeer(address net.IP, asn uint32, password string, holdTime u
 		}
 	}
 
-	// Setup peer for dual-stack.
+	// Setup peer for dual-stack (or EVPN).
+	families := []string{"ipv4-unicast", "ipv6-unicast"}
+	if evpn {
+		families = []string{"l2vpn-evpn"}
+	}
+
 	n.AfiSafis = make([]*bgpAPI.AfiSafi, 0)
-	for _, f := range []string{"ipv4-unicast", "ipv6-unicast"} {
+	for _, f := range families {
 		rf, err := bgpPacket.GetRouteFamily(f)
 		if err != nil {
 			return err
@@ -490,6 +680,7 @@ func (s *Server) addPeer(address net.IP, asn uint32, password string, holdTime u
 			asn:      asn,
 			password: password,
 			holdtime: holdTime,
+			evpn:     evpn,
 			count:    1,
 		}
 	}
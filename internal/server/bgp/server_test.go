@@ -0,0 +1,80 @@
+package bgp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRouteTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		asn   uint32
+		nn    uint32
+		err   bool
+	}{
+		{
+			name:  "Two bytes ASN",
+			value: "65000:100",
+			asn:   65000,
+			nn:    100,
+		},
+		{
+			name:  "Two bytes ASN with four bytes value",
+			value: "65535:4294967295",
+			asn:   65535,
+			nn:    4294967295,
+		},
+		{
+			name:  "Four bytes ASN",
+			value: "4200000000:65535",
+			asn:   4200000000,
+			nn:    65535,
+		},
+		{
+			name:  "Four bytes ASN with four bytes value",
+			value: "4200000000:65536",
+			err:   true,
+		},
+		{
+			name:  "ASN out of range",
+			value: "4294967296:1",
+			err:   true,
+		},
+		{
+			name:  "Missing separator",
+			value: "65000",
+			err:   true,
+		},
+		{
+			name:  "Invalid ASN",
+			value: "foo:100",
+			err:   true,
+		},
+		{
+			name:  "Invalid value",
+			value: "65000:-1",
+			err:   true,
+		},
+		{
+			name:  "Empty",
+			value: "",
+			err:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asn, nn, err := ParseRouteTarget(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.asn, asn)
+			assert.Equal(t, tt.nn, nn)
+		})
+	}
+}
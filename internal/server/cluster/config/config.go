@@ -148,6 +148,16 @@ func (c *Config) ClusterRebalanceThreshold() int64 {
 	return c.m.GetInt64("cluster.rebalance.threshold")
 }
 
+// NetworkFirewallAutoRepair returns whether to re-apply the firewall rules of networks which changed outside of Incus.
+func (c *Config) NetworkFirewallAutoRepair() bool {
+	return c.m.GetBool("network.firewall.auto_repair")
+}
+
+// NetworkIPAMHTTP returns the URL and token of the external IPAM API used by the http IPAM driver.
+func (c *Config) NetworkIPAMHTTP() (string, string) {
+	return c.m.GetString("network.ipam.http.url"), c.m.GetString("network.ipam.http.token")
+}
+
 // NetworkOVNIntegrationBridge returns the integration OVS bridge to use for OVN networks.
 func (c *Config) NetworkOVNIntegrationBridge() string {
 	return c.m.GetString("network.ovn.integration_bridge")
@@ -968,6 +978,34 @@ var ConfigSchema = config.Schema{
 	//  shortdesc: OpenID Connect claim to use as the username
 	"oidc.claim": {},
 
+	// gendoc:generate(entity=server, group=miscellaneous, key=network.firewall.auto_repair)
+	// The firewall rules of bridge networks are periodically compared with the ones last applied by Incus.
+	// When this option is enabled, networks whose rules were changed or removed outside of Incus get them re-applied.
+	// ---
+	//  type: bool
+	//  scope: global
+	//  defaultdesc: `false`
+	//  shortdesc: Whether to re-apply firewall rules changed outside of Incus
+	"network.firewall.auto_repair": {Type: config.Bool, Default: "false"},
+
+	// External IPAM global keys.
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=network.ipam.http.url)
+	// Networks with `ipam.driver=http` reserve their subnets and addresses through this API.
+	// ---
+	//  type: string
+	//  scope: global
+	//  shortdesc: Base URL of the external IPAM API
+	"network.ipam.http.url": {Validator: validate.Optional(validate.IsRequestURL)},
+
+	// gendoc:generate(entity=server, group=miscellaneous, key=network.ipam.http.token)
+	//
+	// ---
+	//  type: string
+	//  scope: global
+	//  shortdesc: Bearer token used to authenticate with the external IPAM API
+	"network.ipam.http.token": {},
+
 	// OVN networking global keys.
 
 	// gendoc:generate(entity=server, group=miscellaneous, key=network.ovn.integration_bridge)
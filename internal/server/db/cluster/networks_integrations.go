@@ -35,11 +35,19 @@ import (
 const (
 	// NetworkIntegrationTypeOVN represents an OVN network integration.
 	NetworkIntegrationTypeOVN = iota
+
+	// NetworkIntegrationTypeBGPEVPN represents a BGP-EVPN network integration.
+	NetworkIntegrationTypeBGPEVPN
+
+	// NetworkIntegrationTypeWireGuard represents a WireGuard site network integration.
+	NetworkIntegrationTypeWireGuard
 )
 
 // NetworkIntegrationTypeNames is a map between DB type to their string representation.
 var NetworkIntegrationTypeNames = map[int]string{
-	NetworkIntegrationTypeOVN: "ovn",
+	NetworkIntegrationTypeOVN:       "ovn",
+	NetworkIntegrationTypeBGPEVPN:   "bgp-evpn",
+	NetworkIntegrationTypeWireGuard: "wireguard",
 }
 
 // NetworkIntegration is a value object holding db-related details about a network integration.
@@ -0,0 +1,66 @@
+//go:build linux && cgo && !agent
+
+package cluster
+
+import (
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// Code generation directives.
+//
+//generate-database:mapper target stacks.mapper.go
+//generate-database:mapper reset -i -b "//go:build linux && cgo && !agent"
+//
+//generate-database:mapper stmt -e stack objects table=stacks
+//generate-database:mapper stmt -e stack objects-by-ID table=stacks
+//generate-database:mapper stmt -e stack objects-by-Name table=stacks
+//generate-database:mapper stmt -e stack objects-by-Project table=stacks
+//generate-database:mapper stmt -e stack objects-by-Project-and-Name table=stacks
+//generate-database:mapper stmt -e stack id table=stacks
+//generate-database:mapper stmt -e stack create struct=Stack table=stacks
+//generate-database:mapper stmt -e stack update struct=Stack table=stacks
+//generate-database:mapper stmt -e stack delete-by-Project-and-Name table=stacks
+//
+//generate-database:mapper method -i -e stack ID struct=Stack table=stacks
+//generate-database:mapper method -i -e stack Exists struct=Stack table=stacks
+//generate-database:mapper method -i -e stack GetMany table=stacks
+//generate-database:mapper method -i -e stack GetOne struct=Stack table=stacks
+//generate-database:mapper method -i -e stack Create struct=Stack table=stacks
+//generate-database:mapper method -i -e stack Update struct=Stack table=stacks
+//generate-database:mapper method -i -e stack DeleteOne-by-Project-and-Name table=stacks
+
+// Stack is a value object holding db-related details about a stack.
+type Stack struct {
+	ID          int
+	ProjectID   int                 `db:"omit=create,update"`
+	Project     string              `db:"primary=yes&join=projects.name"`
+	Name        string              `db:"primary=yes"`
+	Description string              `db:"coalesce=''"`
+	Manifest    api.StackManifest   `db:"marshal=json"`
+	Resources   []api.StackResource `db:"marshal=json"`
+}
+
+// StackFilter specifies potential query parameter fields.
+type StackFilter struct {
+	ID      *int
+	Name    *string
+	Project *string
+}
+
+// ToAPI converts the DB record to an API record.
+func (s *Stack) ToAPI() *api.Stack {
+	resources := s.Resources
+	if resources == nil {
+		resources = []api.StackResource{}
+	}
+
+	return &api.Stack{
+		Name:    s.Name,
+		Project: s.Project,
+		StackPut: api.StackPut{
+			Description: s.Description,
+			Manifest:    s.Manifest,
+		},
+		Resources: resources,
+	}
+}
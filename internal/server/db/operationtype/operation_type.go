@@ -79,6 +79,11 @@ const (
 	BucketBackupRemove
 	BucketBackupRename
 	BucketBackupRestore
+	InstanceCapture
+	StackApply
+	StackDelete
+	InstanceHibernate
+	InstanceFork
 )
 
 // Description return a human-readable description of the operation type.
@@ -210,6 +215,16 @@ func (t Type) Description() string {
 		return "Renaming bucket backup"
 	case BucketBackupRestore:
 		return "Restoring bucket backup"
+	case InstanceCapture:
+		return "Capturing instance network traffic"
+	case StackApply:
+		return "Applying stack"
+	case StackDelete:
+		return "Deleting stack"
+	case InstanceHibernate:
+		return "Hibernating instance"
+	case InstanceFork:
+		return "Forking instance"
 	default:
 		return "Executing operation"
 	}
@@ -240,6 +255,8 @@ func (t Type) Permission() (auth.ObjectType, auth.Entitlement) {
 		return auth.ObjectTypeInstance, auth.EntitlementCanUpdateState
 	case CommandExec:
 		return auth.ObjectTypeInstance, auth.EntitlementCanExec
+	case InstanceCapture:
+		return auth.ObjectTypeInstance, auth.EntitlementCanCaptureTraffic
 	case SnapshotCreate:
 		return auth.ObjectTypeInstance, auth.EntitlementCanManageSnapshots
 	case SnapshotRename:
@@ -301,6 +318,16 @@ func (t Type) Permission() (auth.ObjectType, auth.Entitlement) {
 	case BucketBackupRestore:
 		return auth.ObjectTypeStorageVolume, auth.EntitlementCanEdit
 
+	case StackApply:
+		return auth.ObjectTypeProject, auth.EntitlementCanEdit
+	case StackDelete:
+		return auth.ObjectTypeProject, auth.EntitlementCanEdit
+
+	case InstanceHibernate:
+		return auth.ObjectTypeInstance, auth.EntitlementCanUpdateState
+	case InstanceFork:
+		return auth.ObjectTypeInstance, auth.EntitlementCanManageSnapshots
+
 	default:
 		return "", ""
 	}
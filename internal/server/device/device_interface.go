@@ -86,3 +86,14 @@ type device interface {
 type NICState interface {
 	State() (*api.InstanceStateNetwork, error)
 }
+
+// NICRename provides the ability to follow instance renames in the external IPAM and floating IPs of the NIC's network.
+type NICRename interface {
+	InstanceRename(oldInstanceName string) error
+}
+
+// NICPath provides the ability to inspect and trace the network path of a NIC.
+type NICPath interface {
+	NetworkPath() ([]api.InstanceNetworkPathHop, error)
+	NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error)
+}
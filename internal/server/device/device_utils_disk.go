@@ -328,6 +328,10 @@ func DiskVMVirtiofsdStart(execPath string, inst instance.Instance, socketPath st
 		return nil, nil, UnsupportedError{"SEV unsupported"}
 	}
 
+	if util.IsTrue(inst.ExpandedConfig()["security.tdx"]) {
+		return nil, nil, UnsupportedError{"TDX unsupported"}
+	}
+
 	// Trickery to handle paths > 107 chars.
 	socketFileDir, err := os.Open(filepath.Dir(socketPath))
 	if err != nil {
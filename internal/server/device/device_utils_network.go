@@ -24,6 +24,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/ip"
 	"github.com/lxc/incus/v6/internal/server/network"
 	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/revert"
 	"github.com/lxc/incus/v6/shared/units"
@@ -402,6 +403,119 @@ func networkVethFillFromVolatile(device deviceConfig.Device, volatile map[string
 	if device["hwaddr"] == "" {
 		device["hwaddr"] = volatile["hwaddr"]
 	}
+
+	// If not configured, check if volatile data contains addresses reserved in an external IPAM.
+	if device["ipv4.address"] == "" && volatile["ipam.ipv4.address"] != "" {
+		device["ipv4.address"] = volatile["ipam.ipv4.address"]
+	}
+
+	if device["ipv6.address"] == "" && volatile["ipam.ipv6.address"] != "" {
+		device["ipv6.address"] = volatile["ipam.ipv6.address"]
+	}
+}
+
+// networkNICIPAMReserve reserves addresses for a NIC without static addresses in the external IPAM of its network
+// (if any). The reserved addresses (and those reserved previously) are added to the volatile data to be saved.
+// The returned hook releases the newly reserved addresses, for use when the NIC fails to start.
+func networkNICIPAMReserve(s *state.State, n network.Network, inst instance.Instance, deviceName string, device deviceConfig.Device, volatile map[string]string, saveData map[string]string) (revert.Hook, error) {
+	if n == nil {
+		return func() {}, nil
+	}
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	for _, family := range []int{4, 6} {
+		key := fmt.Sprintf("ipv%d.address", family)
+		if device[key] != "" {
+			continue // Static address (or "none").
+		}
+
+		if volatile["ipam."+key] != "" {
+			saveData["ipam."+key] = volatile["ipam."+key]
+			continue
+		}
+
+		address, err := network.IPAMReserveNICAddress(s, n, inst.Project().Name, inst.Name(), deviceName, family)
+		if err != nil {
+			return nil, err
+		}
+
+		if address != "" {
+			saveData["ipam."+key] = address
+			reverter.Add(func() { _ = network.IPAMReleaseNICAddress(s, n, inst.Project().Name, inst.Name(), address) })
+		}
+	}
+
+	cleanup := reverter.Clone().Fail
+	reverter.Success()
+
+	return cleanup, nil
+}
+
+// networkNICIPAMRelease releases the addresses of a NIC reserved in the external IPAM of its network.
+func networkNICIPAMRelease(s *state.State, n network.Network, inst instance.Instance, volatile map[string]string) error {
+	if n == nil {
+		return nil
+	}
+
+	for _, key := range []string{"ipam.ipv4.address", "ipam.ipv6.address"} {
+		if volatile[key] == "" {
+			continue
+		}
+
+		err := network.IPAMReleaseNICAddress(s, n, inst.Project().Name, inst.Name(), volatile[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// networkNICIPAMRename moves the addresses of a NIC reserved in the external IPAM of its network to the new name of
+// its instance. Addresses which can't be moved are cleared from the volatile data so new ones get reserved on start.
+func networkNICIPAMRename(s *state.State, n network.Network, inst instance.Instance, oldName string, deviceName string, volatile map[string]string, volatileSet VolatileSetter) error {
+	if n == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range []string{"ipam.ipv4.address", "ipam.ipv6.address"} {
+		if volatile[key] == "" {
+			continue
+		}
+
+		err := network.IPAMRenameNICAddress(s, n, inst.Project().Name, oldName, inst.Name(), deviceName, volatile[key])
+		if err != nil {
+			errs = append(errs, err)
+
+			err = volatileSet(map[string]string{key: ""})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// networkNICFloatingIPsDetach detaches the floating IPs attached to a NIC, leaving them allocated to its network.
+func networkNICFloatingIPsDetach(s *state.State, n network.Network, inst instance.Instance, deviceName string) error {
+	if n == nil {
+		return nil
+	}
+
+	return network.DetachInstanceFloatingIPs(s, n, inst.Project().Name, inst.Name(), deviceName)
+}
+
+// networkNICFloatingIPsRename moves the floating IPs attached to a NIC to the new name of its instance.
+func networkNICFloatingIPsRename(s *state.State, n network.Network, inst instance.Instance, oldName string, deviceName string) error {
+	if n == nil {
+		return nil
+	}
+
+	return network.RenameInstanceFloatingIPs(s, n, inst.Project().Name, oldName, inst.Name(), deviceName)
 }
 
 // networkNICRouteAdd applies any static host-side routes configured for an instance NIC.
@@ -1190,3 +1304,42 @@ func pingOverIfaceByName(deadline time.Time, address net.IP, parentInterface str
 
 	return nil
 }
+
+// networkNICPathOpts returns the network path lookup options of a NIC along with the hops of the NIC itself.
+func networkNICPathOpts(inst instance.Instance, name string, config deviceConfig.Device, nicState NICState) (*network.InstanceNICPathOpts, []api.InstanceNetworkPathHop, error) {
+	state, err := nicState.State()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &network.InstanceNICPathOpts{
+		InstanceUUID: inst.LocalConfig()["volatile.uuid"],
+		DeviceName:   name,
+		DeviceConfig: config,
+	}
+
+	nicHop := api.InstanceNetworkPathHop{
+		Type:      "nic",
+		Name:      name,
+		Network:   config["network"],
+		Addresses: []string{},
+		ACLs:      util.SplitNTrimSpace(config["security.acls"], ",", -1, true),
+	}
+
+	for _, address := range state.Addresses {
+		ip := net.ParseIP(address.Address)
+		if ip == nil {
+			continue
+		}
+
+		opts.Addresses = append(opts.Addresses, ip)
+		nicHop.Addresses = append(nicHop.Addresses, address.Address)
+	}
+
+	hops := []api.InstanceNetworkPathHop{nicHop}
+	if config["host_name"] != "" {
+		hops = append(hops, api.InstanceNetworkPathHop{Type: "host-interface", Name: config["host_name"], Network: config["network"]})
+	}
+
+	return opts, hops, nil
+}
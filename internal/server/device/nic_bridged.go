@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"math/rand"
 	"net"
 	"net/http"
@@ -24,6 +25,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/db"
 	"github.com/lxc/incus/v6/internal/server/db/cluster"
 	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
 	firewallDrivers "github.com/lxc/incus/v6/internal/server/firewall/drivers"
@@ -583,6 +585,23 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		return validate.IsNetworkAddressV6(value)
 	}
 
+	// gendoc:generate(entity=devices, group=nic_bridged, key=ipv4.dhcp.options.NAME)
+	//
+	// ---
+	//  type: string
+	//  managed: no
+	//  shortdesc: DHCP option overriding the network's `ipv4.dhcp.options.NAME` for this NIC
+
+	// gendoc:generate(entity=devices, group=nic_bridged, key=ipv6.dhcp.options.NAME)
+	//
+	// ---
+	//  type: string
+	//  managed: no
+	//  shortdesc: DHCPv6 option overriding the network's `ipv6.dhcp.options.NAME` for this NIC
+
+	// Add the DHCP option validation rules.
+	maps.Copy(rules, dhcp.ValidationRules(d.config, false))
+
 	// Now run normal validation.
 	err := d.config.Validate(rules)
 	if err != nil {
@@ -688,12 +707,23 @@ func (d *nicBridged) validateEnvironment() error {
 // UpdatableFields returns a list of fields that can be updated without triggering a device remove & add.
 func (d *nicBridged) UpdatableFields(oldDevice Type) []string {
 	// Check old and new device types match.
-	_, match := oldDevice.(*nicBridged)
+	oldNIC, match := oldDevice.(*nicBridged)
 	if !match {
 		return []string{}
 	}
 
-	return []string{"limits.ingress", "limits.egress", "limits.max", "limits.priority", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "security.acls", "security.acls.default.egress.action", "security.acls.default.egress.logged", "security.acls.default.ingress.action", "security.acls.default.ingress.logged"}
+	fields := []string{"limits.ingress", "limits.egress", "limits.max", "limits.priority", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "security.acls", "security.acls.default.egress.action", "security.acls.default.egress.logged", "security.acls.default.ingress.action", "security.acls.default.ingress.logged"}
+
+	// DHCP options can be changed on a running instance.
+	for _, config := range []deviceConfig.Device{oldNIC.config, d.config} {
+		for k := range config {
+			if strings.HasPrefix(k, dhcp.IPv4OptionsPrefix) || strings.HasPrefix(k, dhcp.IPv6OptionsPrefix) {
+				fields = append(fields, k)
+			}
+		}
+	}
+
+	return fields
 }
 
 // Add is run when a device is added to a non-snapshot instance whether or not the instance is running.
@@ -766,6 +796,14 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 
 	reverter.Add(func() { _ = network.InterfaceRemove(saveData["host_name"]) })
 
+	// Reserve the addresses in the external IPAM of the network (if any).
+	ipamCleanup, err := networkNICIPAMReserve(d.state, d.network, d.inst, d.name, d.config, d.volatileGet(), saveData)
+	if err != nil {
+		return nil, err
+	}
+
+	reverter.Add(ipamCleanup)
+
 	// Populate device config with volatile fields if needed.
 	networkVethFillFromVolatile(d.config, saveData)
 
@@ -1157,7 +1195,23 @@ func (d *nicBridged) Remove() error {
 		}
 	}
 
-	return nil
+	// Detach the floating IPs attached to the NIC (if any).
+	err := networkNICFloatingIPsDetach(d.state, d.network, d.inst, d.name)
+	if err != nil {
+		return err
+	}
+
+	// Release the addresses reserved in the external IPAM of the network (if any).
+	return networkNICIPAMRelease(d.state, d.network, d.inst, d.volatileGet())
+}
+
+// InstanceRename moves the addresses reserved in the external IPAM of the network (if any) and the floating IPs
+// attached to the NIC to the new instance name.
+func (d *nicBridged) InstanceRename(oldInstanceName string) error {
+	return errors.Join(
+		networkNICIPAMRename(d.state, d.network, d.inst, oldInstanceName, d.name, d.volatileGet(), d.volatileSet),
+		networkNICFloatingIPsRename(d.state, d.network, d.inst, oldInstanceName, d.name),
+	)
 }
 
 // rebuildDnsmasqEntry rebuilds the dnsmasq host entry if connected to a managed network and reloads dnsmasq.
@@ -1201,7 +1255,23 @@ func (d *nicBridged) rebuildDnsmasqEntry() error {
 		}
 	}
 
-	err := dnsmasq.UpdateStaticEntry(d.config["parent"], d.inst.Project().Name, d.inst.Name(), d.Name(), d.network.Config(), d.config["hwaddr"], ipv4Address, ipv6Address)
+	// Write the DHCP options first so the host entry gets tagged with them.
+	options, err := dhcp.ParseOptions(d.config, false)
+	if err != nil {
+		return err
+	}
+
+	optionsV6, err := dhcp.ParseOptions(d.config, true)
+	if err != nil {
+		return err
+	}
+
+	err = dnsmasq.UpdateStaticOptions(d.config["parent"], d.inst.Project().Name, d.inst.Name(), d.Name(), append(options, optionsV6...))
+	if err != nil {
+		return err
+	}
+
+	err = dnsmasq.UpdateStaticEntry(d.config["parent"], d.inst.Project().Name, d.inst.Name(), d.Name(), d.network.Config(), d.config["hwaddr"], ipv4Address, ipv6Address)
 	if err != nil {
 		return err
 	}
@@ -2116,3 +2186,41 @@ func (d *nicBridged) Register() error {
 
 	return nil
 }
+
+// NetworkPath returns the elements of the network the traffic of the NIC goes through.
+func (d *nicBridged) NetworkPath() ([]api.InstanceNetworkPathHop, error) {
+	opts, hops, err := networkNICPathOpts(d.inst, d.name, d.config, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmanaged bridges only expose the bridge itself.
+	if d.network == nil {
+		return append(hops, api.InstanceNetworkPathHop{Type: "bridge", Name: d.config["parent"]}), nil
+	}
+
+	netHops, err := d.network.InstanceNICPath(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(hops, netHops...), nil
+}
+
+// NetworkTrace traces a flow originating from the NIC.
+func (d *nicBridged) NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	opts, _, err := networkNICPathOpts(d.inst, d.name, d.config, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.network == nil {
+		if d.config["host_name"] == "" {
+			return nil, fmt.Errorf("NIC %q doesn't have a host-side interface to trace on", d.name)
+		}
+
+		return network.TraceFirewallFlow(d.state, d.config["host_name"], req)
+	}
+
+	return d.network.InstanceNICTrace(opts, req)
+}
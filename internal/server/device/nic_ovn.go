@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"net"
 	"net/http"
 	"os"
@@ -20,6 +21,7 @@ import (
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
 	pcidev "github.com/lxc/incus/v6/internal/server/device/pci"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
 	"github.com/lxc/incus/v6/internal/server/instance"
 	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
@@ -35,6 +37,7 @@ import (
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/resources"
 	"github.com/lxc/incus/v6/shared/revert"
+	"github.com/lxc/incus/v6/shared/units"
 	"github.com/lxc/incus/v6/shared/util"
 	"github.com/lxc/incus/v6/shared/validate"
 )
@@ -60,6 +63,9 @@ type nicOVN struct {
 	ovnsb *ovn.SB
 }
 
+// nicOVNQoSKeys lists the QoS settings that can be changed while the instance is running.
+var nicOVNQoSKeys = []string{"limits.ingress", "limits.egress", "limits.max", "qos.dscp", "qos.min_rate"}
+
 // CanHotPlug returns whether the device can be managed whilst the instance is running.
 func (d *nicOVN) CanHotPlug() bool {
 	return true
@@ -78,7 +84,7 @@ func (d *nicOVN) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"security.acls"}
+	return append([]string{"security.acls"}, nicOVNQoSKeys...)
 }
 
 // validateConfig checks the supplied config for correctness.
@@ -259,6 +265,48 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 		//  shortdesc: Have OVN send unknown network traffic to this network interface (required for some nesting cases)
 		"security.promiscuous",
 
+		// gendoc:generate(entity=devices, group=nic_ovn, key=limits.ingress)
+		//
+		// ---
+		//  type: string
+		//  managed: no
+		//  shortdesc: I/O limit in bit/s for incoming traffic (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.ingress",
+
+		// gendoc:generate(entity=devices, group=nic_ovn, key=limits.egress)
+		//
+		// ---
+		//  type: string
+		//  managed: no
+		//  shortdesc: I/O limit in bit/s for outgoing traffic (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.egress",
+
+		// gendoc:generate(entity=devices, group=nic_ovn, key=limits.max)
+		//
+		// ---
+		//  type: string
+		//  managed: no
+		//  shortdesc: I/O limit in bit/s for both incoming and outgoing traffic (same as setting both `limits.ingress` and `limits.egress`)
+		"limits.max",
+
+		// gendoc:generate(entity=devices, group=nic_ovn, key=qos.dscp)
+		//
+		// ---
+		//  type: integer
+		//  managed: no
+		//  shortdesc: DSCP value (between 0 and 63) to mark the outgoing IP traffic with
+		"qos.dscp",
+
+		// gendoc:generate(entity=devices, group=nic_ovn, key=qos.min_rate)
+		//
+		// OVN only enforces the minimum rate on traffic that leaves the chassis through a `localnet` port (for example, an uplink network of type `physical`).
+		// It has no effect on traffic between instances or on traffic that goes through a tunnel.
+		// ---
+		//  type: string
+		//  managed: no
+		//  shortdesc: Minimum guaranteed rate in bit/s for outgoing traffic leaving through a `localnet` port (various suffixes supported, see {ref}`instances-limit-units`)
+		"qos.min_rate",
+
 		// gendoc:generate(entity=devices, group=nic_ovn, key=acceleration)
 		//
 		// ---
@@ -452,9 +500,36 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 		})
 	}
 
+	// Validate the QoS settings.
+	rules["limits.ingress"] = validate.Optional(network.ValidateOVNQoSRate)
+	rules["limits.egress"] = validate.Optional(network.ValidateOVNQoSRate)
+	rules["limits.max"] = validate.Optional(network.ValidateOVNQoSRate)
+	rules["qos.dscp"] = validate.Optional(validate.IsInRange(0, 63))
+	rules["qos.min_rate"] = validate.Optional(func(value string) error {
+		_, err := units.ParseBitSizeString(value)
+		return err
+	})
+
 	rules["ipv4.address.external"] = validate.Optional(validate.And(validate.IsNetworkAddressV4, isNetworkForward))
 	rules["ipv6.address.external"] = validate.Optional(validate.And(validate.IsNetworkAddressV6, isNetworkForward))
 
+	// gendoc:generate(entity=devices, group=nic_ovn, key=ipv4.dhcp.options.NAME)
+	//
+	// ---
+	//  type: string
+	//  managed: no
+	//  shortdesc: DHCP option overriding the network's `ipv4.dhcp.options.NAME` for this NIC
+
+	// gendoc:generate(entity=devices, group=nic_ovn, key=ipv6.dhcp.options.NAME)
+	//
+	// ---
+	//  type: string
+	//  managed: no
+	//  shortdesc: DHCPv6 option overriding the network's `ipv6.dhcp.options.NAME` for this NIC
+
+	// Add the DHCP option validation rules.
+	maps.Copy(rules, dhcp.ValidationRules(d.config, true))
+
 	// Now run normal validation.
 	err = d.config.Validate(rules)
 	if err != nil {
@@ -802,6 +877,14 @@ func (d *nicOVN) Start() (*deviceConfig.RunConfig, error) {
 		}
 	}
 
+	// Reserve the addresses in the external IPAM of the network (if any).
+	ipamCleanup, err := networkNICIPAMReserve(d.state, d.network, d.inst, d.name, d.config, d.volatileGet(), saveData)
+	if err != nil {
+		return nil, err
+	}
+
+	reverter.Add(ipamCleanup)
+
 	// Populate device config with volatile fields if needed.
 	networkVethFillFromVolatile(d.config, saveData)
 
@@ -956,6 +1039,43 @@ func (d *nicOVN) postStart() error {
 	return nil
 }
 
+// updatePort updates the OVN logical switch port of a running instance with the current device config.
+func (d *nicOVN) updatePort(removedACLs []string) error {
+	// Load uplink network config.
+	uplinkNetworkName := d.network.Config()["network"]
+	var uplink *api.Network
+	var uplinkConfig map[string]string
+
+	if uplinkNetworkName != "none" {
+		err := d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			var err error
+
+			_, uplink, _, err = tx.GetNetworkInAnyState(ctx, api.ProjectDefaultName, uplinkNetworkName)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to load uplink network %q: %w", uplinkNetworkName, err)
+		}
+
+		uplinkConfig = uplink.Config
+	}
+
+	// Update OVN logical switch port for instance.
+	_, _, err := d.network.InstanceDevicePortStart(&network.OVNInstanceNICSetupOpts{
+		InstanceUUID: d.inst.LocalConfig()["volatile.uuid"],
+		DNSName:      d.inst.Name(),
+		DeviceName:   d.name,
+		DeviceConfig: d.config,
+		UplinkConfig: uplinkConfig,
+	}, removedACLs)
+	if err != nil {
+		return fmt.Errorf("Failed updating OVN port: %w", err)
+	}
+
+	return nil
+}
+
 // Update applies configuration changes to a started device.
 func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 	oldConfig := oldDevices[d.name]
@@ -999,36 +1119,9 @@ func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 
 		// Setup the logical port with new ACLs if running.
 		if isRunning {
-			// Load uplink network config.
-			uplinkNetworkName := d.network.Config()["network"]
-			var uplink *api.Network
-			var uplinkConfig map[string]string
-
-			if uplinkNetworkName != "none" {
-				err := d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-					var err error
-
-					_, uplink, _, err = tx.GetNetworkInAnyState(ctx, api.ProjectDefaultName, uplinkNetworkName)
-
-					return err
-				})
-				if err != nil {
-					return fmt.Errorf("Failed to load uplink network %q: %w", uplinkNetworkName, err)
-				}
-
-				uplinkConfig = uplink.Config
-			}
-
-			// Update OVN logical switch port for instance.
-			_, _, err := d.network.InstanceDevicePortStart(&network.OVNInstanceNICSetupOpts{
-				InstanceUUID: d.inst.LocalConfig()["volatile.uuid"],
-				DNSName:      d.inst.Name(),
-				DeviceName:   d.name,
-				DeviceConfig: d.config,
-				UplinkConfig: uplinkConfig,
-			}, removedACLs)
+			err := d.updatePort(removedACLs)
 			if err != nil {
-				return fmt.Errorf("Failed updating OVN port: %w", err)
+				return err
 			}
 		}
 
@@ -1045,6 +1138,20 @@ func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		}
 	}
 
+	// Apply any changes to the QoS settings (already applied above if the ACLs changed).
+	if isRunning && d.config["security.acls"] == oldConfig["security.acls"] {
+		for _, key := range nicOVNQoSKeys {
+			if d.config[key] != oldConfig[key] {
+				err := d.updatePort(nil)
+				if err != nil {
+					return err
+				}
+
+				break
+			}
+		}
+	}
+
 	// If an external address changed, update the BGP advertisements.
 	err := bgpRemovePrefix(&d.deviceCommon, oldConfig)
 	if err != nil {
@@ -1247,7 +1354,28 @@ func (d *nicOVN) Remove() error {
 		}
 	}
 
-	return d.network.InstanceDevicePortRemove(d.inst.LocalConfig()["volatile.uuid"], d.name, d.config)
+	err := d.network.InstanceDevicePortRemove(d.inst.LocalConfig()["volatile.uuid"], d.name, d.config)
+	if err != nil {
+		return err
+	}
+
+	// Detach the floating IPs attached to the NIC (if any).
+	err = networkNICFloatingIPsDetach(d.state, d.network, d.inst, d.name)
+	if err != nil {
+		return err
+	}
+
+	// Release the addresses reserved in the external IPAM of the network (if any).
+	return networkNICIPAMRelease(d.state, d.network, d.inst, d.volatileGet())
+}
+
+// InstanceRename moves the addresses reserved in the external IPAM of the network (if any) and the floating IPs
+// attached to the NIC to the new instance name.
+func (d *nicOVN) InstanceRename(oldInstanceName string) error {
+	return errors.Join(
+		networkNICIPAMRename(d.state, d.network, d.inst, oldInstanceName, d.name, d.volatileGet(), d.volatileSet),
+		networkNICFloatingIPsRename(d.state, d.network, d.inst, oldInstanceName, d.name),
+	)
 }
 
 // State gets the state of an OVN NIC by querying the OVN Northbound logical switch port record.
@@ -1448,3 +1576,28 @@ func (d *nicOVN) setupHostNIC(hostName string, ovnPortName ovn.OVNSwitchPort) (r
 
 	return cleanup, err
 }
+
+// NetworkPath returns the elements of the network the traffic of the NIC goes through.
+func (d *nicOVN) NetworkPath() ([]api.InstanceNetworkPathHop, error) {
+	opts, hops, err := networkNICPathOpts(d.inst, d.name, d.config, d)
+	if err != nil {
+		return nil, err
+	}
+
+	netHops, err := d.network.InstanceNICPath(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(hops, netHops...), nil
+}
+
+// NetworkTrace simulates a flow originating from the NIC through the OVN network.
+func (d *nicOVN) NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	opts, _, err := networkNICPathOpts(d.inst, d.name, d.config, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.network.InstanceNICTrace(opts, req)
+}
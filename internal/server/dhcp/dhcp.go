@@ -0,0 +1,434 @@
+package dhcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/insomniacslk/dhcp/dhcpv6/server6"
+	"github.com/mdlayher/netx/eui64"
+
+	"github.com/lxc/incus/v6/internal/iprange"
+	internalUtil "github.com/lxc/incus/v6/internal/util"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Lease represents a dynamic lease handed out by the built-in DHCP server.
+type Lease struct {
+	Hwaddr   string    `json:"hwaddr"`
+	Address  string    `json:"address"`
+	Hostname string    `json:"hostname"`
+	ClientID string    `json:"client_id"`
+	Expiry   time.Time `json:"expiry"`
+}
+
+// StaticLease represents a static allocation for a host.
+type StaticLease struct {
+	Hwaddr   net.HardwareAddr
+	IPv4     net.IP
+	IPv6     net.IP
+	Hostname string
+
+	// Options overriding the network DHCP options for this client.
+	Options []Option
+}
+
+// Config represents the configuration of the built-in DHCP server for a network.
+type Config struct {
+	// Network name (also used as the interface name).
+	Network string
+
+	// IPv4 settings (DHCPv4 is disabled when IPv4Subnet is nil).
+	IPv4Address   net.IP
+	IPv4Subnet    *net.IPNet
+	IPv4Ranges    []iprange.Range
+	IPv4Gateway   net.IP
+	IPv4DNS       []net.IP
+	IPv4Routes    []*net.IPNet
+	IPv4RouteVia  []net.IP
+	IPv4LeaseTime time.Duration
+	IPv4Options   []Option
+
+	// IPv6 settings (router advertisements are disabled when IPv6Subnet is nil).
+	IPv6Address   net.IP
+	IPv6Subnet    *net.IPNet
+	IPv6DHCP      bool
+	IPv6Stateful  bool
+	IPv6Ranges    []iprange.Range
+	IPv6DNS       []net.IP
+	IPv6LeaseTime time.Duration
+	IPv6Options   []Option
+
+	// Common settings.
+	MTU           uint32
+	Domain        string
+	SearchDomains []string
+
+	// Hostnames controls whether the hosts file in HostsDir is kept updated with the leases.
+	Hostnames bool
+
+	// StaticLeases returns the current static allocations on the network.
+	StaticLeases func() ([]StaticLease, error)
+}
+
+// Server represents a built-in DHCP server for a network.
+type Server struct {
+	config Config
+	logger logger.Logger
+
+	v4 *server4.Server
+	v6 *server6.Server
+	ra *raServer
+
+	leases []Lease
+
+	mu sync.Mutex
+}
+
+var servers = map[string]*Server{}
+var serversMu sync.Mutex
+
+// LeasesPath returns the path to the lease file of a network.
+func LeasesPath(network string) string {
+	return internalUtil.VarPath("networks", network, "dhcp.leases")
+}
+
+// HostsDir returns the path to the directory holding the hosts file generated from the leases of a network.
+func HostsDir(network string) string {
+	return internalUtil.VarPath("networks", network, "dhcp.hosts")
+}
+
+// ParseLeaseTime parses a lease time in the dnsmasq format (a number of seconds or a number followed by s, m, h, d or w, or "infinite").
+func ParseLeaseTime(value string) (time.Duration, error) {
+	if value == "infinite" {
+		return time.Duration(math.MaxUint32) * time.Second, nil
+	}
+
+	units := map[byte]time.Duration{'s': time.Second, 'm': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+
+	count := value
+	unit := time.Second
+	if value != "" && units[value[len(value)-1]] != 0 {
+		count = value[:len(value)-1]
+		unit = units[value[len(value)-1]]
+	}
+
+	n, err := strconv.ParseUint(count, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid lease time %q", value)
+	}
+
+	return time.Duration(n) * unit, nil
+}
+
+// Start starts (or restarts) the built-in DHCP server for a network.
+func Start(config Config) error {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	// Stop any existing server.
+	existing := servers[config.Network]
+	if existing != nil {
+		existing.stop()
+		delete(servers, config.Network)
+	}
+
+	s := &Server{
+		config: config,
+		logger: logger.AddContext(logger.Ctx{"network": config.Network}),
+	}
+
+	err := s.start()
+	if err != nil {
+		s.stop()
+		return err
+	}
+
+	servers[config.Network] = s
+
+	return nil
+}
+
+// Stop stops the built-in DHCP server for a network (if running).
+func Stop(network string) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	s := servers[network]
+	if s == nil {
+		return
+	}
+
+	s.stop()
+	delete(servers, network)
+}
+
+// GetLeases returns the current dynamic leases for a network.
+func GetLeases(network string) ([]Lease, error) {
+	serversMu.Lock()
+	s := servers[network]
+	serversMu.Unlock()
+
+	// Use the in-memory state when available.
+	if s != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.expireLeases()
+
+		return slices.Clone(s.leases), nil
+	}
+
+	leases, err := loadLeases(LeasesPath(network))
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(leases, func(lease Lease) bool { return time.Now().After(lease.Expiry) }), nil
+}
+
+func (s *Server) start() error {
+	var err error
+
+	// Restore the existing leases.
+	s.leases, err = loadLeases(LeasesPath(s.config.Network))
+	if err != nil {
+		return err
+	}
+
+	s.expireLeases()
+
+	// Start DHCPv4.
+	if s.config.IPv4Subnet != nil {
+		s.v4, err = server4.NewServer(s.config.Network, &net.UDPAddr{IP: net.IPv4zero, Port: 67}, s.handleDHCPv4)
+		if err != nil {
+			return fmt.Errorf("Failed to start DHCPv4 server: %w", err)
+		}
+
+		go func() { _ = s.v4.Serve() }()
+	}
+
+	// Start router advertisements and DHCPv6.
+	if s.config.IPv6Subnet != nil {
+		s.ra, err = newRAServer(s.config)
+		if err != nil {
+			return fmt.Errorf("Failed to start router advertisements: %w", err)
+		}
+
+		if s.config.IPv6DHCP {
+			s.v6, err = server6.NewServer(s.config.Network, &net.UDPAddr{IP: net.IPv6unspecified, Port: 547}, s.handleDHCPv6)
+			if err != nil {
+				return fmt.Errorf("Failed to start DHCPv6 server: %w", err)
+			}
+
+			go func() { _ = s.v6.Serve() }()
+		}
+	}
+
+	// Write the lease file (also used to detect that the server is in use).
+	s.saveLeases()
+
+	return nil
+}
+
+func (s *Server) stop() {
+	if s.v4 != nil {
+		_ = s.v4.Close()
+	}
+
+	if s.v6 != nil {
+		_ = s.v6.Close()
+	}
+
+	if s.ra != nil {
+		s.ra.stop()
+	}
+}
+
+// expireLeases removes expired leases from the in-memory list.
+func (s *Server) expireLeases() {
+	s.leases = slices.DeleteFunc(s.leases, func(lease Lease) bool { return time.Now().After(lease.Expiry) })
+}
+
+// staticLeases returns the current static allocations.
+func (s *Server) staticLeases() []StaticLease {
+	if s.config.StaticLeases == nil {
+		return nil
+	}
+
+	leases, err := s.config.StaticLeases()
+	if err != nil {
+		s.logger.Warn("Failed to load static DHCP allocations", logger.Ctx{"err": err})
+		return nil
+	}
+
+	return leases
+}
+
+// clientOptions returns the DHCP options for a client (network options overridden by the static ones).
+func (s *Server) clientOptions(hwaddr net.HardwareAddr, ipv6 bool) []Option {
+	options := s.config.IPv4Options
+	if ipv6 {
+		options = s.config.IPv6Options
+	}
+
+	if hwaddr == nil {
+		return options
+	}
+
+	for _, entry := range s.staticLeases() {
+		if !bytes.Equal(entry.Hwaddr, hwaddr) {
+			continue
+		}
+
+		for _, option := range entry.Options {
+			if option.IPv6 != ipv6 {
+				continue
+			}
+
+			options = slices.DeleteFunc(slices.Clone(options), func(existing Option) bool { return existing.Code == option.Code })
+			options = append(options, option)
+		}
+	}
+
+	return options
+}
+
+// addLease records a lease (replacing any previous lease for the same client) and persists the lease list.
+func (s *Server) addLease(lease Lease) {
+	isV4 := net.ParseIP(lease.Address).To4() != nil
+
+	s.leases = slices.DeleteFunc(s.leases, func(existing Lease) bool {
+		if (net.ParseIP(existing.Address).To4() != nil) != isV4 {
+			return false
+		}
+
+		return existing.Address == lease.Address || (lease.ClientID != "" && existing.ClientID == lease.ClientID)
+	})
+
+	s.leases = append(s.leases, lease)
+	s.saveLeases()
+}
+
+// removeLease removes the lease for an address (if owned by the client) and persists the lease list.
+func (s *Server) removeLease(address string, clientID string) {
+	s.leases = slices.DeleteFunc(s.leases, func(existing Lease) bool {
+		return existing.Address == address && existing.ClientID == clientID
+	})
+
+	s.saveLeases()
+}
+
+// saveLeases writes the lease list to disk and refreshes the hosts file.
+func (s *Server) saveLeases() {
+	s.expireLeases()
+
+	data, err := json.Marshal(s.leases)
+	if err != nil {
+		s.logger.Warn("Failed to encode DHCP leases", logger.Ctx{"err": err})
+		return
+	}
+
+	path := LeasesPath(s.config.Network)
+	err = os.WriteFile(path+".tmp", data, 0o644)
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+
+	if err != nil {
+		s.logger.Warn("Failed to save DHCP leases", logger.Ctx{"err": err})
+	}
+
+	err = s.writeHosts()
+	if err != nil {
+		s.logger.Warn("Failed to update DHCP hosts file", logger.Ctx{"err": err})
+	}
+}
+
+// writeHosts writes the hosts file used by the DNS server to resolve the instances.
+func (s *Server) writeHosts() error {
+	if !s.config.Hostnames {
+		return nil
+	}
+
+	lines := []string{}
+	for _, lease := range s.leases {
+		if lease.Hostname == "" {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s %s", lease.Address, lease.Hostname))
+
+		// Add the SLAAC address for the IPv4 clients.
+		if s.config.IPv6Subnet != nil && !s.config.IPv6Stateful && net.ParseIP(lease.Address).To4() != nil {
+			hwaddr, err := net.ParseMAC(lease.Hwaddr)
+			if err != nil {
+				continue
+			}
+
+			address, err := eui64.ParseMAC(s.config.IPv6Subnet.IP, hwaddr)
+			if err != nil {
+				continue
+			}
+
+			lines = append(lines, fmt.Sprintf("%s %s", address.String(), lease.Hostname))
+		}
+	}
+
+	slices.Sort(lines)
+	content := strings.Join(lines, "\n") + "\n"
+
+	// Skip the write if nothing changed.
+	hostsPath := filepath.Join(HostsDir(s.config.Network), "leases")
+	current, err := os.ReadFile(hostsPath)
+	if err == nil && string(current) == content {
+		return nil
+	}
+
+	err = os.MkdirAll(HostsDir(s.config.Network), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// Write outside of the directory as the DNS server picks up any new file in it.
+	tmpPath := HostsDir(s.config.Network) + ".tmp"
+	err = os.WriteFile(tmpPath, []byte(content), 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, hostsPath)
+}
+
+// loadLeases reads a lease file.
+func loadLeases(path string) ([]Lease, error) {
+	leases := []Lease{}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return leases, nil
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &leases)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse lease file %q: %w", path, err)
+	}
+
+	return leases, nil
+}
@@ -0,0 +1,227 @@
+package dhcp
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+func Test_ParseLeaseTime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"3600":     time.Hour,
+		"45m":      45 * time.Minute,
+		"1h":       time.Hour,
+		"2d":       48 * time.Hour,
+		"1w":       7 * 24 * time.Hour,
+		"infinite": 4294967295 * time.Second,
+	}
+
+	for value, expected := range tests {
+		duration, err := ParseLeaseTime(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, duration, value)
+	}
+
+	for _, value := range []string{"", "h", "1y", "-1h", "1.5h"} {
+		_, err := ParseLeaseTime(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func newTestServer(t *testing.T) *Server {
+	dir := t.TempDir()
+	t.Setenv("INCUS_DIR", dir)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "networks", "test"), 0o755))
+
+	_, subnet, _ := net.ParseCIDR("10.0.0.0/24")
+
+	return &Server{
+		config: Config{
+			Network:       "test",
+			IPv4Address:   net.ParseIP("10.0.0.1").To4(),
+			IPv4Subnet:    subnet,
+			IPv4Ranges:    []iprange.Range{{Start: net.ParseIP("10.0.0.2"), End: net.ParseIP("10.0.0.4")}},
+			IPv4Gateway:   net.ParseIP("10.0.0.1"),
+			IPv4LeaseTime: time.Hour,
+			Hostnames:     true,
+			StaticLeases: func() ([]StaticLease, error) {
+				hwaddr, _ := net.ParseMAC("00:16:3e:00:00:01")
+				return []StaticLease{{Hwaddr: hwaddr, IPv4: net.ParseIP("10.0.0.3"), Hostname: "static"}}, nil
+			},
+		},
+		logger: logger.AddContext(logger.Ctx{"network": "test"}),
+	}
+}
+
+func newTestRequest(t *testing.T, hwaddr string, msgType dhcpv4.MessageType, modifiers ...dhcpv4.Modifier) *dhcpv4.DHCPv4 {
+	mac, err := net.ParseMAC(hwaddr)
+	require.NoError(t, err)
+
+	req, err := dhcpv4.New(append([]dhcpv4.Modifier{dhcpv4.WithHwAddr(mac), dhcpv4.WithMessageType(msgType)}, modifiers...)...)
+	require.NoError(t, err)
+
+	return req
+}
+
+func Test_replyDHCPv4(t *testing.T) {
+	s := newTestServer(t)
+
+	// Static allocation.
+	reply, err := s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:01", dhcpv4.MessageTypeDiscover))
+	require.NoError(t, err)
+	assert.Equal(t, dhcpv4.MessageTypeOffer, reply.MessageType())
+	assert.Equal(t, "10.0.0.3", reply.YourIPAddr.String())
+	assert.Equal(t, "10.0.0.1", reply.ServerIdentifier().String())
+
+	// Dynamic allocation skips the static address.
+	reply, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:02", dhcpv4.MessageTypeDiscover))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", reply.YourIPAddr.String())
+
+	reply, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:03", dhcpv4.MessageTypeDiscover))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.4", reply.YourIPAddr.String())
+
+	// The range is now exhausted.
+	_, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:04", dhcpv4.MessageTypeDiscover))
+	assert.Error(t, err)
+
+	// Requesting the offered address.
+	reply, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:02", dhcpv4.MessageTypeRequest, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(net.ParseIP("10.0.0.2"))), dhcpv4.WithOption(dhcpv4.OptHostName("dynamic"))))
+	require.NoError(t, err)
+	assert.Equal(t, dhcpv4.MessageTypeAck, reply.MessageType())
+	assert.Equal(t, "10.0.0.2", reply.YourIPAddr.String())
+	assert.Equal(t, time.Hour, reply.IPAddressLeaseTime(0))
+
+	// Requesting an address owned by another client.
+	reply, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:02", dhcpv4.MessageTypeRequest, dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(net.ParseIP("10.0.0.4")))))
+	require.NoError(t, err)
+	assert.Equal(t, dhcpv4.MessageTypeNak, reply.MessageType())
+
+	// Check the leases (including the pending offers) were persisted.
+	leases, err := loadLeases(LeasesPath("test"))
+	require.NoError(t, err)
+	assert.Len(t, leases, 3)
+
+	hosts, err := os.ReadFile(filepath.Join(HostsDir("test"), "leases"))
+	require.NoError(t, err)
+	assert.Contains(t, string(hosts), "10.0.0.2 dynamic\n")
+
+	// Releasing the address.
+	_, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:02", dhcpv4.MessageTypeRelease, dhcpv4.WithClientIP(net.ParseIP("10.0.0.2"))))
+	require.NoError(t, err)
+
+	leases, err = loadLeases(LeasesPath("test"))
+	require.NoError(t, err)
+	assert.Len(t, leases, 2)
+}
+
+func Test_ParseOptions(t *testing.T) {
+	options, err := ParseOptions(map[string]string{
+		"ipv4.address":                   "10.0.0.1/24",
+		"ipv4.dhcp.options.filename":     "pxelinux.0",
+		"ipv4.dhcp.options.next-server":  "10.0.0.10",
+		"ipv4.dhcp.options.ntp-server":   "10.0.0.11,10.0.0.12",
+		"ipv4.dhcp.options.224":          "01:02:0a",
+		"ipv4.dhcp.options.225":          "hello",
+		"ipv6.dhcp.options.bootfile-url": "tftp://[fd00::1]/boot.efi",
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, options, 5)
+
+	// Options are sorted by code.
+	assert.Equal(t, uint16(OptionNextServer), options[0].Code)
+	assert.Equal(t, uint16(42), options[1].Code)
+	assert.Equal(t, uint16(67), options[2].Code)
+
+	// Wire encoding.
+	assert.Equal(t, []byte{10, 0, 0, 11, 10, 0, 0, 12}, options[1].Bytes())
+	assert.Equal(t, []byte("pxelinux.0"), options[2].Bytes())
+	assert.Equal(t, []byte{1, 2, 10}, options[3].Bytes())
+	assert.Equal(t, []byte("hello"), options[4].Bytes())
+
+	// OVN rendering.
+	key, value := options[1].OVN()
+	assert.Equal(t, "ntp_server", key)
+	assert.Equal(t, "{10.0.0.11, 10.0.0.12}", value)
+
+	key, value = options[2].OVN()
+	assert.Equal(t, "bootfile_name", key)
+	assert.Equal(t, `"pxelinux.0"`, value)
+
+	key, _ = options[3].OVN()
+	assert.Empty(t, key)
+
+	// dnsmasq rendering round trip.
+	assert.Equal(t, "option:server-ip-address,10.0.0.10", options[0].Dnsmasq())
+	assert.Equal(t, `67,"pxelinux.0"`, options[2].Dnsmasq())
+
+	for _, option := range options {
+		parsed, err := ParseDnsmasqOption(option.Dnsmasq())
+		require.NoError(t, err)
+		assert.Equal(t, option.Code, parsed.Code)
+		assert.Equal(t, option.Bytes(), parsed.Bytes())
+	}
+
+	options, err = ParseOptions(map[string]string{"ipv6.dhcp.options.23": "fd00::1,fd00::2"}, true)
+	require.NoError(t, err)
+	require.Len(t, options, 1)
+	assert.Equal(t, "option6:23,[fd00::1],[fd00::2]", options[0].Dnsmasq())
+	assert.Len(t, options[0].Bytes(), 32)
+
+	// Invalid options.
+	for name, value := range map[string]string{
+		"unknown":     "foo",
+		"0":           "foo",
+		"255":         "foo",
+		"next-server": "fd00::1",
+		"filename":    `a"b`,
+		"ntp-server":  "10.0.0.1,foo",
+	} {
+		_, err := NewOption(name, value, false)
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_replyDHCPv4Options(t *testing.T) {
+	s := newTestServer(t)
+
+	var err error
+	s.config.IPv4Options, err = ParseOptions(map[string]string{
+		"ipv4.dhcp.options.next-server": "10.0.0.10",
+		"ipv4.dhcp.options.filename":    "pxelinux.0",
+	}, false)
+	require.NoError(t, err)
+
+	hwaddr, _ := net.ParseMAC("00:16:3e:00:00:01")
+	s.config.StaticLeases = func() ([]StaticLease, error) {
+		filename, err := NewOption("filename", "ipxe.efi", false)
+		if err != nil {
+			return nil, err
+		}
+
+		return []StaticLease{{Hwaddr: hwaddr, Options: []Option{*filename}}}, nil
+	}
+
+	// Network options.
+	reply, err := s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:02", dhcpv4.MessageTypeDiscover))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", reply.ServerIPAddr.String())
+	assert.Equal(t, "pxelinux.0", reply.BootFileName)
+	assert.Equal(t, "pxelinux.0", reply.BootFileNameOption())
+
+	// Per-client override.
+	reply, err = s.replyDHCPv4(newTestRequest(t, "00:16:3e:00:00:01", dhcpv4.MessageTypeDiscover))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.10", reply.ServerIPAddr.String())
+	assert.Equal(t, "ipxe.efi", reply.BootFileName)
+}
@@ -0,0 +1,286 @@
+package dhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+
+	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// offerLeaseTime is how long an offered address is held back for the client.
+const offerLeaseTime = 30 * time.Second
+
+// handleDHCPv4 handles a DHCPv4 request.
+func (s *Server) handleDHCPv4(conn net.PacketConn, peer net.Addr, req *dhcpv4.DHCPv4) {
+	if req.OpCode != dhcpv4.OpcodeBootRequest {
+		return
+	}
+
+	reply, err := s.replyDHCPv4(req)
+	if err != nil {
+		s.logger.Debug("Ignoring DHCPv4 request", logger.Ctx{"hwaddr": req.ClientHWAddr.String(), "type": req.MessageType().String(), "err": err})
+		return
+	}
+
+	if reply == nil {
+		return
+	}
+
+	// Send relayed replies back to the relay.
+	if !req.GatewayIPAddr.IsUnspecified() {
+		peer = &net.UDPAddr{IP: req.GatewayIPAddr, Port: dhcpv4.ServerPort}
+	}
+
+	_, err = conn.WriteTo(reply.ToBytes(), peer)
+	if err != nil {
+		s.logger.Warn("Failed to send DHCPv4 reply", logger.Ctx{"hwaddr": req.ClientHWAddr.String(), "err": err})
+	}
+}
+
+// replyDHCPv4 builds the reply to a DHCPv4 request (nil if no reply should be sent).
+func (s *Server) replyDHCPv4(req *dhcpv4.DHCPv4) (*dhcpv4.DHCPv4, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLeases()
+
+	hwaddr := req.ClientHWAddr.String()
+
+	switch req.MessageType() {
+	case dhcpv4.MessageTypeDiscover:
+		address, hostname := s.allocateIPv4(req.ClientHWAddr, req.RequestedIPAddress())
+		if address == nil {
+			return nil, errors.New("No free address available")
+		}
+
+		// Hold the address for the client until it requests it.
+		s.addLease(Lease{Hwaddr: hwaddr, ClientID: hwaddr, Address: address.String(), Hostname: s.hostname(hostname, req.HostName()), Expiry: time.Now().Add(offerLeaseTime)})
+
+		return s.newDHCPv4Reply(req, dhcpv4.MessageTypeOffer, address)
+
+	case dhcpv4.MessageTypeRequest:
+		requested := req.RequestedIPAddress()
+		if requested == nil || requested.IsUnspecified() {
+			requested = req.ClientIPAddr
+		}
+
+		// Ignore requests meant for another server.
+		serverID := req.ServerIdentifier()
+		if serverID != nil && !serverID.Equal(s.config.IPv4Address) {
+			return nil, nil
+		}
+
+		address, hostname := s.allocateIPv4(req.ClientHWAddr, requested)
+		if address == nil || !address.Equal(requested) {
+			return s.newDHCPv4Reply(req, dhcpv4.MessageTypeNak, nil)
+		}
+
+		s.addLease(Lease{Hwaddr: hwaddr, ClientID: hwaddr, Address: address.String(), Hostname: s.hostname(hostname, req.HostName()), Expiry: time.Now().Add(s.config.IPv4LeaseTime)})
+
+		return s.newDHCPv4Reply(req, dhcpv4.MessageTypeAck, address)
+
+	case dhcpv4.MessageTypeRelease, dhcpv4.MessageTypeDecline:
+		address := req.ClientIPAddr
+		if req.MessageType() == dhcpv4.MessageTypeDecline {
+			address = req.RequestedIPAddress()
+		}
+
+		if address != nil {
+			s.removeLease(address.String(), hwaddr)
+		}
+
+		return nil, nil
+
+	case dhcpv4.MessageTypeInform:
+		return s.newDHCPv4Reply(req, dhcpv4.MessageTypeAck, nil)
+	}
+
+	return nil, nil
+}
+
+// newDHCPv4Reply builds a reply with the network options.
+func (s *Server) newDHCPv4Reply(req *dhcpv4.DHCPv4, msgType dhcpv4.MessageType, address net.IP) (*dhcpv4.DHCPv4, error) {
+	modifiers := []dhcpv4.Modifier{
+		dhcpv4.WithMessageType(msgType),
+		dhcpv4.WithServerIP(s.config.IPv4Address),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(s.config.IPv4Address)),
+	}
+
+	if msgType == dhcpv4.MessageTypeNak {
+		return dhcpv4.NewReplyFromRequest(req, modifiers...)
+	}
+
+	if address != nil {
+		modifiers = append(modifiers,
+			dhcpv4.WithYourIP(address),
+			dhcpv4.WithLeaseTime(uint32(s.config.IPv4LeaseTime.Seconds())),
+		)
+	}
+
+	modifiers = append(modifiers, dhcpv4.WithNetmask(s.config.IPv4Subnet.Mask))
+
+	if s.config.IPv4Gateway != nil {
+		modifiers = append(modifiers, dhcpv4.WithRouter(s.config.IPv4Gateway))
+	}
+
+	if len(s.config.IPv4DNS) > 0 {
+		modifiers = append(modifiers, dhcpv4.WithDNS(s.config.IPv4DNS...))
+	}
+
+	if s.config.Domain != "" {
+		modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptDomainName(s.config.Domain)))
+	}
+
+	if len(s.config.SearchDomains) > 0 {
+		modifiers = append(modifiers, dhcpv4.WithDomainSearchList(s.config.SearchDomains...))
+	}
+
+	if s.config.MTU != 0 {
+		mtu := make([]byte, 2)
+		binary.BigEndian.PutUint16(mtu, uint16(s.config.MTU))
+		modifiers = append(modifiers, dhcpv4.WithGeneric(dhcpv4.OptionInterfaceMTU, mtu))
+	}
+
+	if len(s.config.IPv4Routes) > 0 {
+		routes := make([]*dhcpv4.Route, 0, len(s.config.IPv4Routes))
+		for i, route := range s.config.IPv4Routes {
+			routes = append(routes, &dhcpv4.Route{Dest: route, Router: s.config.IPv4RouteVia[i]})
+		}
+
+		modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptClasslessStaticRoute(routes...)))
+	}
+
+	// Apply the configured options last so they take precedence.
+	for _, option := range s.clientOptions(req.ClientHWAddr, false) {
+		switch option.Code {
+		case OptionNextServer:
+			modifiers = append(modifiers, dhcpv4.WithServerIP(net.ParseIP(option.Value)))
+		case uint16(dhcpv4.OptionBootfileName.Code()):
+			modifiers = append(modifiers, dhcpv4.WithGeneric(dhcpv4.OptionBootfileName, option.Bytes()), withBootFileName(option.Value))
+		default:
+			modifiers = append(modifiers, dhcpv4.WithGeneric(dhcpv4.GenericOptionCode(option.Code), option.Bytes()))
+		}
+	}
+
+	return dhcpv4.NewReplyFromRequest(req, modifiers...)
+}
+
+// withBootFileName sets the boot file name header field (used by PXE clients).
+func withBootFileName(name string) dhcpv4.Modifier {
+	return func(d *dhcpv4.DHCPv4) {
+		d.BootFileName = name
+	}
+}
+
+// hostname returns the hostname to record for a lease.
+func (s *Server) hostname(static string, requested string) string {
+	if !s.config.Hostnames {
+		return ""
+	}
+
+	if static != "" {
+		return static
+	}
+
+	return requested
+}
+
+// allocateIPv4 returns the address to use for a client (with the static hostname if any).
+// The static allocation is used first, then an existing lease, then the requested address and finally the first free address.
+func (s *Server) allocateIPv4(hwaddr net.HardwareAddr, requested net.IP) (net.IP, string) {
+	static := s.staticLeases()
+
+	// Check for a static allocation.
+	for _, entry := range static {
+		if bytes.Equal(entry.Hwaddr, hwaddr) && entry.IPv4 != nil {
+			return entry.IPv4.To4(), entry.Hostname
+		}
+	}
+
+	// Check whether an address is available to the client.
+	isFree := func(address net.IP) bool {
+		if address == nil || address.To4() == nil || !s.config.IPv4Subnet.Contains(address) || address.Equal(s.config.IPv4Address) {
+			return false
+		}
+
+		if !inRanges(s.config.IPv4Ranges, address) {
+			return false
+		}
+
+		if slices.ContainsFunc(static, func(entry StaticLease) bool { return entry.IPv4.Equal(address) }) {
+			return false
+		}
+
+		return !slices.ContainsFunc(s.leases, func(lease Lease) bool {
+			return lease.Address == address.String() && lease.Hwaddr != hwaddr.String()
+		})
+	}
+
+	// Check for an existing lease.
+	for _, lease := range s.leases {
+		address := net.ParseIP(lease.Address).To4()
+		if lease.Hwaddr == hwaddr.String() && address != nil && isFree(address) {
+			return address, ""
+		}
+	}
+
+	// Check the requested address.
+	if isFree(requested) {
+		return requested.To4(), ""
+	}
+
+	// Find a free address.
+	for _, r := range s.config.IPv4Ranges {
+		address := firstFree(r, isFree)
+		if address != nil {
+			return address.To4(), ""
+		}
+	}
+
+	return nil, ""
+}
+
+// inRanges checks whether an address is part of the ranges.
+func inRanges(ranges []iprange.Range, address net.IP) bool {
+	for _, r := range ranges {
+		// Normalize to the 16 bytes form as the range may mix both forms.
+		r = iprange.Range{Start: r.Start.To16(), End: r.End.To16()}
+		if r.ContainsIP(address.To16()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firstFree returns the first address of the range passing the check.
+func firstFree(r iprange.Range, isFree func(net.IP) bool) net.IP {
+	start, ok := netip.AddrFromSlice(r.Start)
+	if !ok {
+		return nil
+	}
+
+	end, ok := netip.AddrFromSlice(r.End)
+	if !ok {
+		return nil
+	}
+
+	start = start.Unmap()
+	end = end.Unmap()
+
+	for address := start; address.IsValid() && address.Compare(end) <= 0; address = address.Next() {
+		if isFree(address.AsSlice()) {
+			return address.AsSlice()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package dhcp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/mdlayher/netx/eui64"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// handleDHCPv6 handles a DHCPv6 request.
+func (s *Server) handleDHCPv6(conn net.PacketConn, peer net.Addr, req dhcpv6.DHCPv6) {
+	msg, err := req.GetInnerMessage()
+	if err != nil {
+		return
+	}
+
+	reply, err := s.replyDHCPv6(msg)
+	if err != nil {
+		s.logger.Debug("Ignoring DHCPv6 request", logger.Ctx{"type": msg.Type().String(), "err": err})
+		return
+	}
+
+	if reply == nil {
+		return
+	}
+
+	var resp dhcpv6.DHCPv6 = reply
+
+	// Wrap the reply for relays.
+	if req.IsRelay() {
+		resp, err = dhcpv6.NewRelayReplFromRelayForw(req.(*dhcpv6.RelayMessage), reply)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = conn.WriteTo(resp.ToBytes(), peer)
+	if err != nil {
+		s.logger.Warn("Failed to send DHCPv6 reply", logger.Ctx{"err": err})
+	}
+}
+
+// serverDUID returns the DUID used to identify the server.
+func (s *Server) serverDUID() dhcpv6.DUID {
+	duid := &dhcpv6.DUIDLL{HWType: iana.HWTypeEthernet}
+
+	iface, err := net.InterfaceByName(s.config.Network)
+	if err == nil {
+		duid.LinkLayerAddr = iface.HardwareAddr
+	}
+
+	return duid
+}
+
+// replyDHCPv6 builds the reply to a DHCPv6 request (nil if no reply should be sent).
+func (s *Server) replyDHCPv6(msg *dhcpv6.Message) (*dhcpv6.Message, error) {
+	clientDUID := msg.Options.ClientID()
+	if clientDUID == nil {
+		return nil, errors.New("Missing client identifier")
+	}
+
+	serverDUID := s.serverDUID()
+
+	// Ignore requests meant for another server.
+	requestedServer := msg.Options.ServerID()
+	if requestedServer != nil && !requestedServer.Equal(serverDUID) {
+		return nil, nil
+	}
+
+	modifiers := []dhcpv6.Modifier{dhcpv6.WithServerID(serverDUID)}
+
+	if len(s.config.IPv6DNS) > 0 {
+		modifiers = append(modifiers, dhcpv6.WithDNS(s.config.IPv6DNS...))
+	}
+
+	searchDomains := s.config.SearchDomains
+	if len(searchDomains) == 0 && s.config.Domain != "" {
+		searchDomains = []string{s.config.Domain}
+	}
+
+	if len(searchDomains) > 0 {
+		modifiers = append(modifiers, dhcpv6.WithDomainSearchList(searchDomains...))
+	}
+
+	hwaddr := duidHardwareAddr(clientDUID)
+	for _, option := range s.clientOptions(hwaddr, true) {
+		modifiers = append(modifiers, dhcpv6.WithOption(&dhcpv6.OptionGeneric{OptionCode: dhcpv6.OptionCode(option.Code), OptionData: option.Bytes()}))
+	}
+
+	// Stateless configuration.
+	if msg.Type() == dhcpv6.MessageTypeInformationRequest {
+		return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+	}
+
+	// Everything else requires stateful DHCPv6.
+	if !s.config.IPv6Stateful {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLeases()
+
+	clientID := hex.EncodeToString(clientDUID.ToBytes())
+
+	ia := msg.Options.OneIANA()
+	if ia == nil {
+		if msg.Type() == dhcpv6.MessageTypeRelease {
+			return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+		}
+
+		return nil, errors.New("Missing IA_NA option")
+	}
+
+	switch msg.Type() {
+	case dhcpv6.MessageTypeSolicit, dhcpv6.MessageTypeRequest, dhcpv6.MessageTypeRenew, dhcpv6.MessageTypeRebind, dhcpv6.MessageTypeConfirm:
+		var requested net.IP
+		addr := ia.Options.OneAddress()
+		if addr != nil {
+			requested = addr.IPv6Addr
+		}
+
+		address, hostname := s.allocateIPv6(clientID, hwaddr, requested)
+		if address == nil {
+			modifiers = append(modifiers, dhcpv6.WithOption(&dhcpv6.OptStatusCode{StatusCode: iana.StatusNoAddrsAvail, StatusMessage: "No free address available"}))
+			if msg.Type() == dhcpv6.MessageTypeSolicit {
+				return dhcpv6.NewAdvertiseFromSolicit(msg, modifiers...)
+			}
+
+			return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+		}
+
+		// Confirm only validates the existing address.
+		if msg.Type() == dhcpv6.MessageTypeConfirm {
+			if requested != nil && !address.Equal(requested) {
+				modifiers = append(modifiers, dhcpv6.WithOption(&dhcpv6.OptStatusCode{StatusCode: iana.StatusNotOnLink}))
+			}
+
+			return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+		}
+
+		lifetime := s.config.IPv6LeaseTime
+		modifiers = append(modifiers,
+			dhcpv6.WithIAID(ia.IaId),
+			dhcpv6.WithIANA(dhcpv6.OptIAAddress{IPv6Addr: address, PreferredLifetime: lifetime, ValidLifetime: lifetime}),
+		)
+
+		// Offered addresses are only held back for a short time.
+		expiry := time.Now().Add(lifetime)
+		if msg.Type() == dhcpv6.MessageTypeSolicit && msg.GetOneOption(dhcpv6.OptionRapidCommit) == nil {
+			expiry = time.Now().Add(offerLeaseTime)
+		}
+
+		var fqdn string
+		fqdnOpt := msg.Options.FQDN()
+		if fqdnOpt != nil && fqdnOpt.DomainName != nil && len(fqdnOpt.DomainName.Labels) > 0 {
+			fqdn = fqdnOpt.DomainName.Labels[0]
+		}
+
+		s.addLease(Lease{Hwaddr: hwaddrString(hwaddr), ClientID: clientID, Address: address.String(), Hostname: s.hostname(hostname, fqdn), Expiry: expiry})
+
+		if msg.Type() == dhcpv6.MessageTypeSolicit && msg.GetOneOption(dhcpv6.OptionRapidCommit) == nil {
+			return dhcpv6.NewAdvertiseFromSolicit(msg, modifiers...)
+		}
+
+		return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+
+	case dhcpv6.MessageTypeRelease, dhcpv6.MessageTypeDecline:
+		for _, addr := range ia.Options.Addresses() {
+			s.removeLease(addr.IPv6Addr.String(), clientID)
+		}
+
+		return dhcpv6.NewReplyFromMessage(msg, modifiers...)
+	}
+
+	return nil, nil
+}
+
+// allocateIPv6 returns the address to use for a client (with the static hostname if any).
+// The static allocation is used first, then an existing lease, then the EUI64 address when no ranges are set, then the requested address and finally the first free address.
+func (s *Server) allocateIPv6(clientID string, hwaddr net.HardwareAddr, requested net.IP) (net.IP, string) {
+	static := s.staticLeases()
+
+	// Check for a static allocation.
+	if hwaddr != nil {
+		for _, entry := range static {
+			if bytes.Equal(entry.Hwaddr, hwaddr) && entry.IPv6 != nil {
+				return entry.IPv6, entry.Hostname
+			}
+		}
+	}
+
+	// Check whether an address is available to the client.
+	isFree := func(address net.IP) bool {
+		if address == nil || address.To4() != nil || !s.config.IPv6Subnet.Contains(address) || address.Equal(s.config.IPv6Address) {
+			return false
+		}
+
+		if len(s.config.IPv6Ranges) > 0 && !inRanges(s.config.IPv6Ranges, address) {
+			return false
+		}
+
+		if slices.ContainsFunc(static, func(entry StaticLease) bool { return entry.IPv6.Equal(address) }) {
+			return false
+		}
+
+		return !slices.ContainsFunc(s.leases, func(lease Lease) bool {
+			return lease.Address == address.String() && lease.ClientID != clientID
+		})
+	}
+
+	// Check for an existing lease.
+	for _, lease := range s.leases {
+		address := net.ParseIP(lease.Address)
+		if lease.ClientID == clientID && address.To4() == nil && isFree(address) {
+			return address, ""
+		}
+	}
+
+	// Try the EUI64 address when no ranges are configured.
+	if hwaddr != nil && len(s.config.IPv6Ranges) == 0 {
+		address, err := eui64.ParseMAC(s.config.IPv6Subnet.IP, hwaddr)
+		if err == nil && isFree(address) {
+			return address, ""
+		}
+	}
+
+	// Check the requested address.
+	if isFree(requested) {
+		return requested, ""
+	}
+
+	// Find a free address.
+	for _, r := range s.config.IPv6Ranges {
+		address := firstFree(r, isFree)
+		if address != nil {
+			return address, ""
+		}
+	}
+
+	return nil, ""
+}
+
+// duidHardwareAddr extracts the MAC address from a DUID (if possible).
+func duidHardwareAddr(duid dhcpv6.DUID) net.HardwareAddr {
+	switch d := duid.(type) {
+	case *dhcpv6.DUIDLL:
+		if d.HWType == iana.HWTypeEthernet {
+			return d.LinkLayerAddr
+		}
+
+	case *dhcpv6.DUIDLLT:
+		if d.HWType == iana.HWTypeEthernet {
+			return d.LinkLayerAddr
+		}
+	}
+
+	return nil
+}
+
+// hwaddrString returns the string representation of a MAC address (empty if not set).
+func hwaddrString(hwaddr net.HardwareAddr) string {
+	if hwaddr == nil {
+		return ""
+	}
+
+	return hwaddr.String()
+}
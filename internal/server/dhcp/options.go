@@ -0,0 +1,403 @@
+package dhcp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IPv4OptionsPrefix is the configuration key prefix for DHCPv4 options.
+const IPv4OptionsPrefix = "ipv4.dhcp.options."
+
+// IPv6OptionsPrefix is the configuration key prefix for DHCPv6 options.
+const IPv6OptionsPrefix = "ipv6.dhcp.options."
+
+// OptionType represents the format of a DHCP option value.
+type OptionType int
+
+const (
+	// OptionTypeRaw is an option set by code whose value is an IP list, colon separated hex bytes or a string.
+	OptionTypeRaw OptionType = iota
+
+	// OptionTypeString is an option holding a string.
+	OptionTypeString
+
+	// OptionTypeIP is an option holding a single IP address.
+	OptionTypeIP
+
+	// OptionTypeIPList is an option holding a comma separated list of IP addresses.
+	OptionTypeIPList
+)
+
+// OptionNextServer is the pseudo option code used for the DHCPv4 next server (siaddr) header field.
+const OptionNextServer = 0
+
+// optionDefinition describes a named DHCP option.
+type optionDefinition struct {
+	name      string
+	code      uint16
+	valueType OptionType
+	ovnName   string
+}
+
+// ipv4OptionDefinitions lists the named DHCPv4 options.
+var ipv4OptionDefinitions = []optionDefinition{
+	{name: "next-server", code: OptionNextServer, valueType: OptionTypeIP, ovnName: "next_server"},
+	{name: "ntp-server", code: 42, valueType: OptionTypeIPList, ovnName: "ntp_server"},
+	{name: "tftp-server", code: 66, valueType: OptionTypeString, ovnName: "tftp_server"},
+	{name: "filename", code: 67, valueType: OptionTypeString, ovnName: "bootfile_name"},
+	{name: "tftp-server-address", code: 150, valueType: OptionTypeIPList, ovnName: "tftp_server_address"},
+	{name: "path-prefix", code: 210, valueType: OptionTypeString, ovnName: "path_prefix"},
+	{name: "wpad", code: 252, valueType: OptionTypeString, ovnName: "wpad"},
+}
+
+// ipv6OptionDefinitions lists the named DHCPv6 options.
+var ipv6OptionDefinitions = []optionDefinition{
+	{name: "ntp-server", code: 56, valueType: OptionTypeIPList},
+	{name: "bootfile-url", code: 59, valueType: OptionTypeString, ovnName: "bootfile_name"},
+}
+
+// Option is a DHCP option set through configuration.
+type Option struct {
+	Name  string
+	Code  uint16
+	Type  OptionType
+	Value string
+	IPv6  bool
+
+	ovnName string
+}
+
+// lookupOption returns the option definition for a name (named option or numeric code).
+func lookupOption(name string, ipv6 bool) (*optionDefinition, error) {
+	definitions := ipv4OptionDefinitions
+	maxCode := uint64(254)
+	if ipv6 {
+		definitions = ipv6OptionDefinitions
+		maxCode = 65535
+	}
+
+	for _, definition := range definitions {
+		if definition.name == name {
+			return &definition, nil
+		}
+	}
+
+	code, err := strconv.ParseUint(name, 10, 16)
+	if err != nil || code < 1 || code > maxCode {
+		return nil, fmt.Errorf("Unknown DHCP option %q", name)
+	}
+
+	// Prefer the named definition when the code matches one.
+	for _, definition := range definitions {
+		if uint64(definition.code) == code {
+			return &definition, nil
+		}
+	}
+
+	return &optionDefinition{name: name, code: uint16(code), valueType: OptionTypeRaw}, nil
+}
+
+// NewOption returns a DHCP option from its name (or numeric code) and value.
+func NewOption(name string, value string, ipv6 bool) (*Option, error) {
+	definition, err := lookupOption(name, ipv6)
+	if err != nil {
+		return nil, err
+	}
+
+	option := &Option{
+		Name:    name,
+		Code:    definition.code,
+		Type:    definition.valueType,
+		Value:   value,
+		IPv6:    ipv6,
+		ovnName: definition.ovnName,
+	}
+
+	err = option.validate()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid value for DHCP option %q: %w", name, err)
+	}
+
+	return option, nil
+}
+
+// ValidateOption returns a validator for the value of the named option.
+// When ovnOnly is set, only the options supported by OVN are accepted.
+func ValidateOption(name string, ipv6 bool, ovnOnly bool) func(value string) error {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		option, err := NewOption(name, value, ipv6)
+		if err != nil {
+			return err
+		}
+
+		if ovnOnly && option.ovnName == "" {
+			return fmt.Errorf("DHCP option %q isn't supported by OVN", name)
+		}
+
+		return nil
+	}
+}
+
+// ValidationRules returns validation rules for the DHCP option keys present in config.
+func ValidationRules(config map[string]string, ovnOnly bool) map[string]func(value string) error {
+	rules := map[string]func(value string) error{}
+	for k := range config {
+		name, found := strings.CutPrefix(k, IPv4OptionsPrefix)
+		if found {
+			rules[k] = ValidateOption(name, false, ovnOnly)
+			continue
+		}
+
+		name, found = strings.CutPrefix(k, IPv6OptionsPrefix)
+		if found {
+			rules[k] = ValidateOption(name, true, ovnOnly)
+		}
+	}
+
+	return rules
+}
+
+// ParseOptions returns the DHCP options set in a configuration map, sorted by code.
+func ParseOptions(config map[string]string, ipv6 bool) ([]Option, error) {
+	prefix := IPv4OptionsPrefix
+	if ipv6 {
+		prefix = IPv6OptionsPrefix
+	}
+
+	options := []Option{}
+	for key, value := range config {
+		name, found := strings.CutPrefix(key, prefix)
+		if !found || value == "" {
+			continue
+		}
+
+		option, err := NewOption(name, value, ipv6)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, *option)
+	}
+
+	slices.SortFunc(options, func(a Option, b Option) int { return int(a.Code) - int(b.Code) })
+
+	return options, nil
+}
+
+// validate checks the option value against its type.
+func (o *Option) validate() error {
+	if strings.ContainsAny(o.Value, "\"\n\r") {
+		return errors.New("Value cannot contain quotes or line breaks")
+	}
+
+	switch o.Type {
+	case OptionTypeString:
+		if len(o.Value) > 255 {
+			return errors.New("Value is too long")
+		}
+
+	case OptionTypeIP:
+		_, err := o.ips()
+		if err != nil {
+			return err
+		}
+
+		if strings.Contains(o.Value, ",") {
+			return errors.New("Only a single IP address is allowed")
+		}
+
+	case OptionTypeIPList:
+		_, err := o.ips()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ips parses the option value as a list of IP addresses of the option family.
+func (o *Option) ips() ([]net.IP, error) {
+	ips := []net.IP{}
+	for _, value := range strings.Split(o.Value, ",") {
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil || (ip.To4() == nil) != o.IPv6 {
+			return nil, fmt.Errorf("Invalid IP address %q", value)
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, nil
+}
+
+// hexBytes parses the option value as colon separated hex bytes.
+func (o *Option) hexBytes() ([]byte, bool) {
+	if !strings.Contains(o.Value, ":") {
+		return nil, false
+	}
+
+	data := []byte{}
+	for _, part := range strings.Split(o.Value, ":") {
+		if len(part) < 1 || len(part) > 2 {
+			return nil, false
+		}
+
+		b, err := hex.DecodeString(fmt.Sprintf("%02s", part))
+		if err != nil {
+			return nil, false
+		}
+
+		data = append(data, b...)
+	}
+
+	return data, true
+}
+
+// isIPList returns whether a raw option value should be handled as an IP list.
+func (o *Option) isIPList() bool {
+	if o.Type == OptionTypeIP || o.Type == OptionTypeIPList {
+		return true
+	}
+
+	if o.Type != OptionTypeRaw {
+		return false
+	}
+
+	_, err := o.ips()
+	return err == nil
+}
+
+// Bytes returns the wire encoding of the option value.
+func (o *Option) Bytes() []byte {
+	if o.isIPList() {
+		ips, _ := o.ips()
+
+		data := []byte{}
+		for _, ip := range ips {
+			if o.IPv6 {
+				data = append(data, ip.To16()...)
+			} else {
+				data = append(data, ip.To4()...)
+			}
+		}
+
+		return data
+	}
+
+	if o.Type == OptionTypeRaw {
+		data, ok := o.hexBytes()
+		if ok {
+			return data
+		}
+	}
+
+	return []byte(o.Value)
+}
+
+// OVN returns the OVN DHCP_Options key and value for the option.
+// An empty key is returned if the option isn't supported by OVN.
+func (o *Option) OVN() (string, string) {
+	if o.ovnName == "" {
+		return "", ""
+	}
+
+	switch o.Type {
+	case OptionTypeString:
+		return o.ovnName, strconv.Quote(o.Value)
+
+	case OptionTypeIPList:
+		ips, _ := o.ips()
+		values := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			values = append(values, ip.String())
+		}
+
+		if len(values) == 1 {
+			return o.ovnName, values[0]
+		}
+
+		return o.ovnName, "{" + strings.Join(values, ", ") + "}"
+	}
+
+	return o.ovnName, o.Value
+}
+
+// Dnsmasq returns the dnsmasq dhcp-option representation of the option (without any tag).
+func (o *Option) Dnsmasq() string {
+	var spec string
+	if o.IPv6 {
+		spec = fmt.Sprintf("option6:%d", o.Code)
+	} else if o.Code == OptionNextServer {
+		spec = "option:server-ip-address"
+	} else {
+		spec = strconv.Itoa(int(o.Code))
+	}
+
+	if o.isIPList() {
+		ips, _ := o.ips()
+		values := make([]string, 0, len(ips))
+		for _, ip := range ips {
+			if o.IPv6 {
+				values = append(values, "["+ip.String()+"]")
+			} else {
+				values = append(values, ip.String())
+			}
+		}
+
+		return spec + "," + strings.Join(values, ",")
+	}
+
+	if o.Type == OptionTypeRaw {
+		_, ok := o.hexBytes()
+		if ok {
+			return spec + "," + o.Value
+		}
+	}
+
+	return spec + "," + strconv.Quote(o.Value)
+}
+
+// ParseDnsmasqOption parses an option from its dnsmasq dhcp-option representation.
+func ParseDnsmasqOption(line string) (*Option, error) {
+	spec, value, found := strings.Cut(line, ",")
+	if !found {
+		return nil, fmt.Errorf("Invalid DHCP option %q", line)
+	}
+
+	ipv6 := false
+	code := spec
+	if spec == "option:server-ip-address" {
+		code = strconv.Itoa(OptionNextServer)
+	} else if strings.HasPrefix(spec, "option6:") {
+		ipv6 = true
+		code = strings.TrimPrefix(spec, "option6:")
+	}
+
+	if strings.HasPrefix(value, "\"") {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid DHCP option value %q: %w", value, err)
+		}
+
+		value = unquoted
+	} else if ipv6 {
+		value = strings.NewReplacer("[", "", "]", "").Replace(value)
+	}
+
+	// The next server pseudo option isn't a valid option code.
+	if code == strconv.Itoa(OptionNextServer) {
+		return NewOption(ipv4OptionDefinitions[0].name, value, false)
+	}
+
+	return NewOption(code, value, ipv6)
+}
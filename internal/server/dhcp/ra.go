@@ -0,0 +1,182 @@
+package dhcp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/mdlayher/ndp"
+	"golang.org/x/net/ipv6"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// raInterval is the interval between unsolicited router advertisements.
+const raInterval = 60 * time.Second
+
+// raServer sends IPv6 router advertisements on a network.
+type raServer struct {
+	config Config
+	logger logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newRAServer starts sending router advertisements for the network.
+func newRAServer(config Config) (*raServer, error) {
+	iface, err := net.InterfaceByName(config.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &raServer{
+		config: config,
+		logger: logger.AddContext(logger.Ctx{"network": config.Network}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	go s.run(iface)
+
+	return s, nil
+}
+
+// stop stops sending router advertisements.
+func (s *raServer) stop() {
+	s.cancel()
+}
+
+// run listens for router solicitations and sends the advertisements.
+func (s *raServer) run(iface *net.Interface) {
+	var conn *ndp.Conn
+	var err error
+
+	// The link-local address may not be ready yet (duplicate address detection).
+	for {
+		conn, _, err = ndp.Listen(iface, ndp.LinkLocal)
+		if err == nil {
+			break
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	go func() {
+		<-s.ctx.Done()
+		_ = conn.Close()
+	}()
+
+	// Only receive router solicitations.
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterSolicitation)
+
+	err = conn.SetICMPFilter(&filter)
+	if err != nil {
+		s.logger.Warn("Failed to set ICMPv6 filter", logger.Ctx{"err": err})
+	}
+
+	// Send periodic advertisements.
+	go func() {
+		ticker := time.NewTicker(raInterval)
+		defer ticker.Stop()
+
+		for {
+			s.send(conn, iface)
+
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	// Reply to solicitations.
+	for {
+		msg, _, _, err := conn.ReadFrom()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			continue
+		}
+
+		_, ok := msg.(*ndp.RouterSolicitation)
+		if !ok {
+			continue
+		}
+
+		s.send(conn, iface)
+	}
+}
+
+// send sends a router advertisement to all nodes.
+func (s *raServer) send(conn *ndp.Conn, iface *net.Interface) {
+	prefix, ok := netip.AddrFromSlice(s.config.IPv6Subnet.IP)
+	if !ok {
+		return
+	}
+
+	prefixLen, _ := s.config.IPv6Subnet.Mask.Size()
+
+	ra := &ndp.RouterAdvertisement{
+		CurrentHopLimit:      64,
+		ManagedConfiguration: s.config.IPv6Stateful,
+		OtherConfiguration:   s.config.IPv6DHCP,
+		RouterLifetime:       3 * raInterval,
+		Options: []ndp.Option{
+			&ndp.PrefixInformation{
+				PrefixLength:                   uint8(prefixLen),
+				OnLink:                         true,
+				AutonomousAddressConfiguration: !s.config.IPv6Stateful,
+				ValidLifetime:                  ndp.Infinity,
+				PreferredLifetime:              ndp.Infinity,
+				Prefix:                         prefix.Unmap(),
+			},
+			&ndp.LinkLayerAddress{
+				Direction: ndp.Source,
+				Addr:      iface.HardwareAddr,
+			},
+		},
+	}
+
+	if s.config.MTU != 0 {
+		ra.Options = append(ra.Options, ndp.NewMTU(s.config.MTU))
+	}
+
+	servers := make([]netip.Addr, 0, len(s.config.IPv6DNS))
+	for _, server := range s.config.IPv6DNS {
+		addr, ok := netip.AddrFromSlice(server)
+		if ok {
+			servers = append(servers, addr.Unmap())
+		}
+	}
+
+	if len(servers) > 0 {
+		ra.Options = append(ra.Options, &ndp.RecursiveDNSServer{Lifetime: 3 * raInterval, Servers: servers})
+	}
+
+	searchDomains := s.config.SearchDomains
+	if len(searchDomains) == 0 && s.config.Domain != "" {
+		searchDomains = []string{s.config.Domain}
+	}
+
+	if len(searchDomains) > 0 {
+		ra.Options = append(ra.Options, &ndp.DNSSearchList{Lifetime: 3 * raInterval, DomainNames: searchDomains})
+	}
+
+	err := conn.WriteTo(ra, nil, netip.IPv6LinkLocalAllNodes())
+	if err != nil && s.ctx.Err() == nil {
+		s.logger.Warn("Failed to send router advertisement", logger.Ctx{"err": err})
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
+	"github.com/lxc/incus/v6/shared/util"
 )
 
 type dnsHandler struct {
@@ -33,6 +34,12 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
+	// Dynamic updates (RFC 2136) are handled separately.
+	if r.Opcode == dns.OpcodeUpdate {
+		d.serveUpdate(w, r)
+		return
+	}
+
 	// Only allow a single request.
 	if len(r.Question) != 1 {
 		m := &dns.Msg{}
@@ -91,7 +98,7 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 
 	// Check access.
-	if !isAllowed(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil) {
+	if !isAllowed(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil, false) {
 		// On auth failure, return NXDOMAIN to avoid information leaks.
 		m := &dns.Msg{}
 		m.SetRcode(r, dns.RcodeNameError)
@@ -138,10 +145,11 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 }
 
-func isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool) bool {
+func isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool, update bool) bool {
 	type peer struct {
 		address string
 		key     string
+		update  bool
 	}
 
 	// Build a list of peers.
@@ -169,6 +177,8 @@ func isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool)
 			peers[peerName].address = v
 		case "key":
 			peers[peerName].key = v
+		case "update":
+			peers[peerName].update = util.IsTrue(v)
 		}
 	}
 
@@ -176,6 +186,11 @@ func isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool)
 	for peerName, peer := range peers {
 		peerKeyName := fmt.Sprintf("%s_%s.", zone.Name, peerName)
 
+		if update && (!peer.update || peer.key == "") {
+			// Dynamic updates are only allowed for peers with a TSIG key.
+			continue
+		}
+
 		if peer.address != "" && ip != peer.address {
 			// Bad IP address.
 			continue
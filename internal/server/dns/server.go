@@ -19,6 +19,9 @@ import (
 // ZoneRetriever is a function which fetches a DNS zone.
 type ZoneRetriever func(name string, full bool) (*Zone, error)
 
+// ZoneUpdater is a function which applies the update section of a dynamic update (RFC 2136) to a DNS zone.
+type ZoneUpdater func(name string, updates []dns.RR) error
+
 // Server represents a DNS server instance.
 type Server struct {
 	tcpDNS *dns.Server
@@ -27,6 +30,7 @@ type Server struct {
 	// External dependencies.
 	db            *db.Cluster
 	zoneRetriever ZoneRetriever
+	zoneUpdater   ZoneUpdater
 
 	// Internal state (to handle reconfiguration).
 	address string
@@ -53,9 +57,9 @@ type serverCmdInfo struct {
 }
 
 // NewServer returns a new server instance.
-func NewServer(db *db.Cluster, retriever ZoneRetriever) *Server {
+func NewServer(db *db.Cluster, retriever ZoneRetriever, updater ZoneUpdater) *Server {
 	// Setup new struct.
-	s := &Server{db: db, zoneRetriever: retriever}
+	s := &Server{db: db, zoneRetriever: retriever, zoneUpdater: updater}
 	return s
 }
 
@@ -178,7 +182,7 @@ func (s *Server) start(address string) error {
 	handler.server = s
 
 	// Spawn the DNS server.
-	s.tcpDNS = &dns.Server{Addr: address, Net: "tcp", Handler: handler}
+	s.tcpDNS = &dns.Server{Addr: address, Net: "tcp", Handler: handler, MsgAcceptFunc: acceptMsg}
 	go func() {
 		err := s.tcpDNS.ListenAndServe()
 		if err != nil {
@@ -186,7 +190,7 @@ func (s *Server) start(address string) error {
 		}
 	}()
 
-	s.udpDNS = &dns.Server{Addr: address, Net: "udp", Handler: handler}
+	s.udpDNS = &dns.Server{Addr: address, Net: "udp", Handler: handler, MsgAcceptFunc: acceptMsg}
 	go func() {
 		err := s.udpDNS.ListenAndServe()
 		if err != nil {
@@ -206,6 +210,21 @@ func (s *Server) start(address string) error {
 	return nil
 }
 
+// acceptMsg extends the default message filter to allow dynamic updates (RFC 2136).
+func acceptMsg(dh dns.Header) dns.MsgAcceptAction {
+	opcode := int(dh.Bits>>11) & 0xF
+	if opcode != dns.OpcodeUpdate || dh.Bits&(1<<15) != 0 {
+		return dns.DefaultMsgAcceptFunc(dh)
+	}
+
+	// Updates must have a single zone entry, other sections are variable length.
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+
+	return dns.MsgAccept
+}
+
 // Stop tears down the DNS listener.
 func (s *Server) Stop() error {
 	s.cmd <- serverCmdInfo{
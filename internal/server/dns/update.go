@@ -0,0 +1,269 @@
+package dns
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// serveUpdate handles dynamic update (RFC 2136) requests.
+func (d dnsHandler) serveUpdate(w dns.ResponseWriter, r *dns.Msg) {
+	reply := func(rcode int) {
+		m := &dns.Msg{}
+		m.SetRcode(r, rcode)
+
+		tsig := r.IsTsig()
+		if tsig != nil && w.TsigStatus() == nil {
+			m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
+		}
+
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
+		}
+	}
+
+	// Check if we're able to apply updates.
+	if d.server.zoneUpdater == nil {
+		reply(dns.RcodeNotImplemented)
+		return
+	}
+
+	// The zone section must contain a single SOA entry.
+	if len(r.Question) != 1 || r.Question[0].Qtype != dns.TypeSOA || r.Question[0].Qclass != dns.ClassINET {
+		reply(dns.RcodeFormatError)
+		return
+	}
+
+	// Extract the request information.
+	name := strings.TrimSuffix(r.Question[0].Name, ".")
+	ip, _, err := net.SplitHostPort(w.RemoteAddr().String())
+	if err != nil {
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	// Load the zone.
+	zone, err := d.server.zoneRetriever(name, true)
+	if err != nil {
+		// On failure, return NOTAUTH.
+		reply(dns.RcodeNotAuth)
+		return
+	}
+
+	// Check access.
+	if !isAllowed(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil, true) {
+		// On auth failure, return NOTAUTH to avoid information leaks.
+		reply(dns.RcodeNotAuth)
+		return
+	}
+
+	// Parse the current zone content.
+	zoneRRs, err := parseZone(zone.Content)
+	if err != nil {
+		logger.Errorf("Bad DNS record in zone %q: %v", name, err)
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	// Check the prerequisites against the current zone content.
+	rcode := checkPrerequisites(r.Question[0].Name, zoneRRs, r.Answer)
+	if rcode != dns.RcodeSuccess {
+		reply(rcode)
+		return
+	}
+
+	// Validate the update section.
+	rcode = checkUpdates(r.Question[0].Name, r.Ns)
+	if rcode != dns.RcodeSuccess {
+		reply(rcode)
+		return
+	}
+
+	// Apply the update.
+	err = d.server.zoneUpdater(name, r.Ns)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusBadRequest) {
+			reply(dns.RcodeRefused)
+			return
+		}
+
+		logger.Error("Failed to apply DNS update", logger.Ctx{"zone": name, "err": err})
+		reply(dns.RcodeServerFailure)
+		return
+	}
+
+	reply(dns.RcodeSuccess)
+}
+
+// parseZone parses the rendered zone content, skipping the trailing SOA record.
+func parseZone(content string) ([]dns.RR, error) {
+	rrs := []dns.RR{}
+
+	zoneRR := dns.NewZoneParser(strings.NewReader(content), "", "")
+	for {
+		rr, ok := zoneRR.Next()
+		if !ok {
+			err := zoneRR.Err()
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		if slices.ContainsFunc(rrs, func(existing dns.RR) bool { return dns.IsDuplicate(existing, rr) }) {
+			continue
+		}
+
+		rrs = append(rrs, rr)
+	}
+
+	return rrs, nil
+}
+
+// isMetaType returns whether the record type can't be used in the update section.
+func isMetaType(rrtype uint16) bool {
+	return slices.Contains([]uint16{dns.TypeANY, dns.TypeAXFR, dns.TypeIXFR, dns.TypeMAILA, dns.TypeMAILB}, rrtype)
+}
+
+// checkPrerequisites validates the prerequisite section of an update against the zone records (RFC 2136 section 3.2).
+func checkPrerequisites(zoneName string, zoneRRs []dns.RR, prereqs []dns.RR) int {
+	nameInUse := func(name string) bool {
+		return slices.ContainsFunc(zoneRRs, func(rr dns.RR) bool { return strings.EqualFold(rr.Header().Name, name) })
+	}
+
+	rrsetExists := func(name string, rrtype uint16) bool {
+		return slices.ContainsFunc(zoneRRs, func(rr dns.RR) bool {
+			return strings.EqualFold(rr.Header().Name, name) && rr.Header().Rrtype == rrtype
+		})
+	}
+
+	// Value dependent prerequisites are grouped by RRset.
+	type rrsetKey struct {
+		name   string
+		rrtype uint16
+	}
+
+	rrsets := map[rrsetKey][]dns.RR{}
+
+	for _, rr := range prereqs {
+		hdr := rr.Header()
+
+		if hdr.Ttl != 0 {
+			return dns.RcodeFormatError
+		}
+
+		if !dns.IsSubDomain(zoneName, hdr.Name) {
+			return dns.RcodeNotZone
+		}
+
+		switch hdr.Class {
+		case dns.ClassANY:
+			if hdr.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+
+			if hdr.Rrtype == dns.TypeANY {
+				// Name is in use.
+				if !nameInUse(hdr.Name) {
+					return dns.RcodeNameError
+				}
+			} else if !rrsetExists(hdr.Name, hdr.Rrtype) {
+				// RRset exists (value independent).
+				return dns.RcodeNXRrset
+			}
+
+		case dns.ClassNONE:
+			if hdr.Rdlength != 0 {
+				return dns.RcodeFormatError
+			}
+
+			if hdr.Rrtype == dns.TypeANY {
+				// Name is not in use.
+				if nameInUse(hdr.Name) {
+					return dns.RcodeYXDomain
+				}
+			} else if rrsetExists(hdr.Name, hdr.Rrtype) {
+				// RRset does not exist.
+				return dns.RcodeYXRrset
+			}
+
+		case dns.ClassINET:
+			// RRset exists (value dependent).
+			key := rrsetKey{name: strings.ToLower(hdr.Name), rrtype: hdr.Rrtype}
+			rrsets[key] = append(rrsets[key], rr)
+
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	for key, expected := range rrsets {
+		current := []dns.RR{}
+		for _, rr := range zoneRRs {
+			if strings.EqualFold(rr.Header().Name, key.name) && rr.Header().Rrtype == key.rrtype {
+				current = append(current, rr)
+			}
+		}
+
+		if len(current) == 0 {
+			return dns.RcodeNXRrset
+		}
+
+		// Both sets must contain the same records.
+		for _, rr := range expected {
+			if !slices.ContainsFunc(current, func(existing dns.RR) bool { return dns.IsDuplicate(existing, rr) }) {
+				return dns.RcodeNXRrset
+			}
+		}
+
+		for _, rr := range current {
+			if !slices.ContainsFunc(expected, func(other dns.RR) bool { return dns.IsDuplicate(other, rr) }) {
+				return dns.RcodeNXRrset
+			}
+		}
+	}
+
+	return dns.RcodeSuccess
+}
+
+// checkUpdates validates the update section of an update (RFC 2136 section 3.4.1).
+func checkUpdates(zoneName string, updates []dns.RR) int {
+	for _, rr := range updates {
+		hdr := rr.Header()
+
+		if !dns.IsSubDomain(zoneName, hdr.Name) {
+			return dns.RcodeNotZone
+		}
+
+		switch hdr.Class {
+		case dns.ClassINET:
+			if isMetaType(hdr.Rrtype) {
+				return dns.RcodeFormatError
+			}
+
+		case dns.ClassANY:
+			if hdr.Ttl != 0 || hdr.Rdlength != 0 || (hdr.Rrtype != dns.TypeANY && isMetaType(hdr.Rrtype)) {
+				return dns.RcodeFormatError
+			}
+
+		case dns.ClassNONE:
+			if hdr.Ttl != 0 || isMetaType(hdr.Rrtype) {
+				return dns.RcodeFormatError
+			}
+
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	return dns.RcodeSuccess
+}
@@ -0,0 +1,251 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+)
+
+const testZoneContent = `example.com. 300 IN SOA ns1.example.com. admin.example.com. 1 120 60 86400 30
+www.example.com. 300 IN A 10.0.0.1
+www.example.com. 300 IN A 10.0.0.2
+mail.example.com. 300 IN MX 10 mx.example.com.
+`
+
+// testUpdateMsg builds an update message for the example.com zone and runs it through the wire format.
+func testUpdateMsg(t *testing.T, build func(m *dns.Msg)) *dns.Msg {
+	m := &dns.Msg{}
+	m.SetUpdate("example.com.")
+	build(m)
+
+	packed, err := m.Pack()
+	require.NoError(t, err)
+
+	out := &dns.Msg{}
+	err = out.Unpack(packed)
+	require.NoError(t, err)
+
+	return out
+}
+
+func testRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	require.NoError(t, err)
+
+	return rr
+}
+
+// testRRWithClass returns the record with its class and TTL overridden.
+func testRRWithClass(t *testing.T, s string, class uint16, ttl uint32) dns.RR {
+	rr := testRR(t, s)
+	rr.Header().Class = class
+	rr.Header().Ttl = ttl
+
+	return rr
+}
+
+func Test_checkPrerequisites(t *testing.T) {
+	zoneRRs, err := parseZone(testZoneContent)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		build func(m *dns.Msg)
+		want  int
+	}{
+		{
+			"No prerequisites",
+			func(m *dns.Msg) {},
+			dns.RcodeSuccess,
+		},
+		{
+			"Name is in use",
+			func(m *dns.Msg) { m.NameUsed([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"Name is in use but doesn't exist",
+			func(m *dns.Msg) { m.NameUsed([]dns.RR{testRR(t, "missing.example.com. A")}) },
+			dns.RcodeNameError,
+		},
+		{
+			"Name is not in use",
+			func(m *dns.Msg) { m.NameNotUsed([]dns.RR{testRR(t, "missing.example.com. A")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"Name is not in use but exists",
+			func(m *dns.Msg) { m.NameNotUsed([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeYXDomain,
+		},
+		{
+			"RRset exists",
+			func(m *dns.Msg) { m.RRsetUsed([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"RRset exists but doesn't",
+			func(m *dns.Msg) { m.RRsetUsed([]dns.RR{testRR(t, "www.example.com. AAAA")}) },
+			dns.RcodeNXRrset,
+		},
+		{
+			"RRset doesn't exist",
+			func(m *dns.Msg) { m.RRsetNotUsed([]dns.RR{testRR(t, "www.example.com. AAAA")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"RRset doesn't exist but does",
+			func(m *dns.Msg) { m.RRsetNotUsed([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeYXRrset,
+		},
+		{
+			"RRset exists with matching values",
+			func(m *dns.Msg) {
+				m.Used([]dns.RR{testRR(t, "www.example.com. A 10.0.0.2"), testRR(t, "www.example.com. A 10.0.0.1")})
+			},
+			dns.RcodeSuccess,
+		},
+		{
+			"RRset exists with missing values",
+			func(m *dns.Msg) { m.Used([]dns.RR{testRR(t, "www.example.com. A 10.0.0.1")}) },
+			dns.RcodeNXRrset,
+		},
+		{
+			"RRset exists with extra values",
+			func(m *dns.Msg) {
+				m.Used([]dns.RR{testRR(t, "www.example.com. A 10.0.0.1"), testRR(t, "www.example.com. A 10.0.0.2"), testRR(t, "www.example.com. A 10.0.0.3")})
+			},
+			dns.RcodeNXRrset,
+		},
+		{
+			"RRset exists with values but doesn't",
+			func(m *dns.Msg) { m.Used([]dns.RR{testRR(t, "missing.example.com. A 10.0.0.1")}) },
+			dns.RcodeNXRrset,
+		},
+		{
+			"Prerequisite outside of the zone",
+			func(m *dns.Msg) { m.NameUsed([]dns.RR{testRR(t, "www.example.org. A")}) },
+			dns.RcodeNotZone,
+		},
+		{
+			"Prerequisite with a TTL",
+			func(m *dns.Msg) { m.Answer = append(m.Answer, testRR(t, "www.example.com. 300 IN A 10.0.0.1")) },
+			dns.RcodeFormatError,
+		},
+		{
+			"Name is in use with data",
+			func(m *dns.Msg) {
+				m.Answer = append(m.Answer, testRRWithClass(t, "www.example.com. A 10.0.0.1", dns.ClassANY, 0))
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"RRset doesn't exist with data",
+			func(m *dns.Msg) {
+				m.Answer = append(m.Answer, testRRWithClass(t, "www.example.com. A 10.0.0.1", dns.ClassNONE, 0))
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Multiple prerequisites with a failure",
+			func(m *dns.Msg) {
+				m.NameUsed([]dns.RR{testRR(t, "www.example.com. A")})
+				m.RRsetNotUsed([]dns.RR{testRR(t, "mail.example.com. MX")})
+			},
+			dns.RcodeYXRrset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testUpdateMsg(t, tt.build)
+			require.Equal(t, dns.RcodeToString[tt.want], dns.RcodeToString[checkPrerequisites("example.com.", zoneRRs, m.Answer)])
+		})
+	}
+}
+
+func Test_checkUpdates(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(m *dns.Msg)
+		want  int
+	}{
+		{
+			"Add records",
+			func(m *dns.Msg) {
+				m.Insert([]dns.RR{testRR(t, "www.example.com. 300 A 10.0.0.3"), testRR(t, "_acme-challenge.example.com. 60 TXT token")})
+			},
+			dns.RcodeSuccess,
+		},
+		{
+			"Delete an RRset",
+			func(m *dns.Msg) { m.RemoveRRset([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"Delete all RRsets from a name",
+			func(m *dns.Msg) { m.RemoveName([]dns.RR{testRR(t, "www.example.com. A")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"Delete a record",
+			func(m *dns.Msg) { m.Remove([]dns.RR{testRR(t, "www.example.com. A 10.0.0.1")}) },
+			dns.RcodeSuccess,
+		},
+		{
+			"Update outside of the zone",
+			func(m *dns.Msg) { m.Insert([]dns.RR{testRR(t, "www.example.org. 300 A 10.0.0.3")}) },
+			dns.RcodeNotZone,
+		},
+		{
+			"Add a meta type record",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, &dns.ANY{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeANY, Class: dns.ClassINET, Ttl: 300}})
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Delete an RRset with a TTL",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, &dns.ANY{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeA, Class: dns.ClassANY, Ttl: 300}})
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Delete an RRset with data",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, testRRWithClass(t, "www.example.com. A 10.0.0.1", dns.ClassANY, 0))
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Delete a record with a TTL",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, testRRWithClass(t, "www.example.com. A 10.0.0.1", dns.ClassNONE, 300))
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Delete a meta type record",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, &dns.ANY{Hdr: dns.RR_Header{Name: "www.example.com.", Rrtype: dns.TypeAXFR, Class: dns.ClassNONE}})
+			},
+			dns.RcodeFormatError,
+		},
+		{
+			"Unsupported class",
+			func(m *dns.Msg) {
+				m.Ns = append(m.Ns, testRRWithClass(t, "www.example.com. A 10.0.0.1", dns.ClassCHAOS, 300))
+			},
+			dns.RcodeFormatError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testUpdateMsg(t, tt.build)
+			require.Equal(t, dns.RcodeToString[tt.want], dns.RcodeToString[checkUpdates("example.com.", m.Ns)])
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Package dns64 implements a DNS64 resolver (RFC 6147) synthesizing IPv6 addresses for IPv4-only names.
+// It forwards the queries to the upstream resolvers of the host and is meant to be used as the upstream
+// server of the dnsmasq instance of a network.
+package dns64
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// WellKnownPrefix is the NAT64 well-known prefix (RFC 6052).
+var WellKnownPrefix = &net.IPNet{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)}
+
+// ResolvConf is the file the upstream resolvers are read from when none are configured.
+var ResolvConf = "/etc/resolv.conf"
+
+const upstreamTimeout = 5 * time.Second
+
+// Config represents the configuration of a DNS64 resolver.
+type Config struct {
+	Prefix    *net.IPNet // NAT64 prefix, must be a /96.
+	Upstreams []string   // Upstream resolvers (host:port). Read from ResolvConf if empty.
+}
+
+// Server represents a DNS64 resolver for a network.
+type Server struct {
+	config Config
+	logger logger.Logger
+
+	udp *dns.Server
+	tcp *dns.Server
+}
+
+var servers = map[string]*Server{}
+var serversMu sync.Mutex
+
+// Start starts (or restarts) the DNS64 resolver for a network.
+// It listens on a random loopback port and returns the address it's reachable on.
+func Start(network string, config Config) (*net.UDPAddr, error) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	// Stop any existing server.
+	existing := servers[network]
+	if existing != nil {
+		existing.stop()
+		delete(servers, network)
+	}
+
+	ones, bits := config.Prefix.Mask.Size()
+	if ones != 96 || bits != 128 {
+		return nil, fmt.Errorf("Unsupported DNS64 prefix %q, only /96 prefixes are supported", config.Prefix.String())
+	}
+
+	if len(config.Upstreams) == 0 {
+		clientConfig, err := dns.ClientConfigFromFile(ResolvConf)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading upstream resolvers: %w", err)
+		}
+
+		for _, server := range clientConfig.Servers {
+			config.Upstreams = append(config.Upstreams, net.JoinHostPort(server, clientConfig.Port))
+		}
+	}
+
+	if len(config.Upstreams) == 0 {
+		return nil, errors.New("No upstream resolvers available for DNS64")
+	}
+
+	s := &Server{
+		config: config,
+		logger: logger.AddContext(logger.Ctx{"network": network}),
+	}
+
+	address, err := s.listen()
+	if err != nil {
+		return nil, err
+	}
+
+	servers[network] = s
+
+	return address, nil
+}
+
+// Stop stops the DNS64 resolver for a network (if running).
+func Stop(network string) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	s := servers[network]
+	if s == nil {
+		return
+	}
+
+	s.stop()
+	delete(servers, network)
+}
+
+// listen starts serving UDP and TCP queries on the same random loopback port.
+func (s *Server) listen() (*net.UDPAddr, error) {
+	var err error
+
+	for range 5 {
+		var pc net.PacketConn
+		pc, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return nil, fmt.Errorf("Failed starting DNS64 resolver: %w", err)
+		}
+
+		address, _ := pc.LocalAddr().(*net.UDPAddr)
+
+		var l net.Listener
+		l, err = net.Listen("tcp", address.String())
+		if err != nil {
+			// The port is taken for TCP, try another one.
+			_ = pc.Close()
+			continue
+		}
+
+		// Wait for both servers to be ready so they can be stopped right away.
+		var started sync.WaitGroup
+		started.Add(2)
+
+		s.udp = &dns.Server{PacketConn: pc, Handler: s, NotifyStartedFunc: started.Done}
+		s.tcp = &dns.Server{Listener: l, Handler: s, NotifyStartedFunc: started.Done}
+
+		for _, server := range []*dns.Server{s.udp, s.tcp} {
+			go func(server *dns.Server) {
+				err := server.ActivateAndServe()
+				if err != nil {
+					s.logger.Error("DNS64 resolver stopped", logger.Ctx{"err": err})
+				}
+			}(server)
+		}
+
+		started.Wait()
+
+		return address, nil
+	}
+
+	return nil, fmt.Errorf("Failed starting DNS64 resolver: %w", err)
+}
+
+// stop stops the server.
+func (s *Server) stop() {
+	for _, server := range []*dns.Server{s.udp, s.tcp} {
+		if server != nil {
+			_ = server.Shutdown()
+		}
+	}
+}
+
+// ServeDNS answers a query, synthesizing AAAA records from the A records of names without IPv6 addresses.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	resp, err := s.exchange(r)
+	if err != nil {
+		s.logger.Debug("Failed forwarding DNS query", logger.Ctx{"err": err})
+
+		resp = new(dns.Msg)
+		resp.SetRcode(r, dns.RcodeServerFailure)
+		_ = w.WriteMsg(resp)
+
+		return
+	}
+
+	if len(r.Question) == 1 && r.Question[0].Qtype == dns.TypeAAAA && r.Question[0].Qclass == dns.ClassINET && resp.Rcode == dns.RcodeSuccess && !hasAnswer(resp, dns.TypeAAAA) {
+		synthesized, err := s.synthesize(r)
+		if err != nil {
+			s.logger.Debug("Failed synthesizing DNS64 answer", logger.Ctx{"err": err})
+		} else if synthesized != nil {
+			resp = synthesized
+		}
+	}
+
+	resp.Id = r.Id
+	_ = w.WriteMsg(resp)
+}
+
+// exchange forwards a query to the upstream resolvers, returning the first answer.
+func (s *Server) exchange(r *dns.Msg) (*dns.Msg, error) {
+	var err error
+
+	for _, upstream := range s.config.Upstreams {
+		var resp *dns.Msg
+
+		client := &dns.Client{Net: "udp", Timeout: upstreamTimeout}
+		resp, _, err = client.Exchange(r, upstream)
+		if err == nil && resp.Truncated {
+			client.Net = "tcp"
+			resp, _, err = client.Exchange(r, upstream)
+		}
+
+		if err == nil {
+			return resp, nil
+		}
+	}
+
+	return nil, err
+}
+
+// synthesize builds the AAAA answer of a query from the A records of the name.
+// It returns nil if no address could be synthesized.
+func (s *Server) synthesize(r *dns.Msg) (*dns.Msg, error) {
+	query := r.Copy()
+	query.Id = dns.Id()
+	query.Question[0].Qtype = dns.TypeA
+
+	resp, err := s.exchange(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, nil
+	}
+
+	answer := make([]dns.RR, 0, len(resp.Answer))
+	found := false
+
+	for _, rr := range resp.Answer {
+		a, ok := rr.(*dns.A)
+		if !ok {
+			// Keep the CNAME chain leading to the addresses.
+			answer = append(answer, rr)
+			continue
+		}
+
+		if !s.synthesizable(a.A) {
+			continue
+		}
+
+		answer = append(answer, &dns.AAAA{
+			Hdr: dns.RR_Header{
+				Name:   a.Hdr.Name,
+				Rrtype: dns.TypeAAAA,
+				Class:  dns.ClassINET,
+				Ttl:    a.Hdr.Ttl,
+			},
+			AAAA: Synthesize(s.config.Prefix, a.A),
+		})
+
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	msg.RecursionAvailable = resp.RecursionAvailable
+	msg.Answer = answer
+
+	return msg, nil
+}
+
+// synthesizable returns whether an IPv6 address can be synthesized for the IPv4 address.
+// The well-known prefix must not be used to represent non-global IPv4 addresses (RFC 6052).
+func (s *Server) synthesizable(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() {
+		return false
+	}
+
+	if s.config.Prefix.String() != WellKnownPrefix.String() {
+		return true
+	}
+
+	_, sharedSpace, _ := net.ParseCIDR("100.64.0.0/10")
+
+	return !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !sharedSpace.Contains(ip)
+}
+
+// Synthesize returns the IPv6 address representing an IPv4 address within a /96 NAT64 prefix.
+func Synthesize(prefix *net.IPNet, ip net.IP) net.IP {
+	address := make(net.IP, net.IPv6len)
+	copy(address, prefix.IP.To16()[:12])
+	copy(address[12:], ip.To4())
+
+	return address
+}
+
+// hasAnswer returns whether the message contains an answer of the given type.
+func hasAnswer(msg *dns.Msg, rrType uint16) bool {
+	for _, rr := range msg.Answer {
+		if rr.Header().Rrtype == rrType {
+			return true
+		}
+	}
+
+	return false
+}
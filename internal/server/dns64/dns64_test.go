@@ -0,0 +1,96 @@
+package dns64
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream starts a DNS server answering from the given records and returns its address.
+func upstream(t *testing.T, records map[string][]string) string {
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+
+		question := r.Question[0]
+		values, ok := records[question.Name]
+		if !ok {
+			msg.Rcode = dns.RcodeNameError
+		}
+
+		for _, value := range values {
+			rr, err := dns.NewRR(question.Name + " 300 IN " + value)
+			require.NoError(t, err)
+
+			if rr.Header().Rrtype == question.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+				msg.Answer = append(msg.Answer, rr)
+			}
+		}
+
+		_ = w.WriteMsg(msg)
+	})
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &dns.Server{PacketConn: pc, Handler: handler}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	return pc.LocalAddr().String()
+}
+
+func TestSynthesize(t *testing.T) {
+	assert.Equal(t, net.ParseIP("64:ff9b::c000:201"), Synthesize(WellKnownPrefix, net.ParseIP("192.0.2.1")))
+
+	_, prefix, err := net.ParseCIDR("2001:db8:64::/96")
+	require.NoError(t, err)
+	assert.Equal(t, net.ParseIP("2001:db8:64::a00:1"), Synthesize(prefix, net.ParseIP("10.0.0.1")))
+}
+
+func TestStart(t *testing.T) {
+	server := upstream(t, map[string][]string{
+		"v4only.example.net.":  {"A 192.0.2.1"},
+		"dual.example.net.":    {"A 192.0.2.2", "AAAA 2001:db8::2"},
+		"private.example.net.": {"A 10.0.0.1"},
+	})
+
+	address, err := Start("test", Config{Prefix: WellKnownPrefix, Upstreams: []string{server}})
+	require.NoError(t, err)
+	defer Stop("test")
+
+	query := func(name string, qtype uint16) *dns.Msg {
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, qtype)
+
+		resp, err := dns.Exchange(msg, address.String())
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	// IPv4-only names get a synthesized address.
+	resp := query("v4only.example.net.", dns.TypeAAAA)
+	require.Len(t, resp.Answer, 1)
+	assert.Equal(t, net.ParseIP("64:ff9b::c000:201"), resp.Answer[0].(*dns.AAAA).AAAA)
+
+	// Existing IPv6 addresses are returned as is.
+	resp = query("dual.example.net.", dns.TypeAAAA)
+	require.Len(t, resp.Answer, 1)
+	assert.Equal(t, net.ParseIP("2001:db8::2"), resp.Answer[0].(*dns.AAAA).AAAA)
+
+	// Other record types are forwarded.
+	resp = query("v4only.example.net.", dns.TypeA)
+	require.Len(t, resp.Answer, 1)
+	assert.Equal(t, net.ParseIP("192.0.2.1").To4(), resp.Answer[0].(*dns.A).A.To4())
+
+	// Private addresses can't be represented with the well-known prefix.
+	resp = query("private.example.net.", dns.TypeAAAA)
+	assert.Empty(t, resp.Answer)
+
+	resp = query("missing.example.net.", dns.TypeAAAA)
+	assert.Equal(t, dns.RcodeNameError, resp.Rcode)
+}
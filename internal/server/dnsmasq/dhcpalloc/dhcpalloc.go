@@ -12,6 +12,7 @@ import (
 	"github.com/mdlayher/netx/eui64"
 
 	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/shared/logger"
@@ -356,9 +357,9 @@ func AllocateTask(opts *Options, f func(*Transaction) error) error {
 	t.allocatedIPv4 = t.currentDHCPv4.IP
 	t.allocatedIPv6 = t.currentDHCPv6.IP
 
-	// Get all existing allocations in network if leases file exists. If not then we will detect this later
-	// due to the existing allocations maps being nil.
-	if util.PathExists(internalUtil.VarPath("networks", opts.Network.Name(), "dnsmasq.leases")) {
+	// Get all existing allocations in network if leases file exists (from dnsmasq or the built-in DHCP server).
+	// If not then we will detect this later due to the existing allocations maps being nil.
+	if util.PathExists(internalUtil.VarPath("networks", opts.Network.Name(), "dnsmasq.leases")) || util.PathExists(dhcp.LeasesPath(opts.Network.Name())) {
 		t.allocationsDHCPv4, t.allocationsDHCPv6, err = dnsmasq.DHCPAllAllocations(opts.Network.Name())
 		if err != nil {
 			return err
@@ -4,14 +4,17 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io/fs"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
 	"github.com/lxc/incus/v6/internal/server/project"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
@@ -26,6 +29,7 @@ type DHCPAllocation struct {
 	IP             net.IP
 	StaticFileName string
 	MAC            net.HardwareAddr
+	Hostname       string
 }
 
 // ConfigMutex used to coordinate access to the dnsmasq config files.
@@ -36,6 +40,12 @@ func UpdateStaticEntry(network string, projectName string, instanceName string,
 	hwaddr = strings.ToLower(hwaddr)
 	line := hwaddr
 
+	// Tag the host so its DHCP options apply.
+	deviceStaticFileName := StaticAllocationFileName(projectName, instanceName, deviceName)
+	if util.PathExists(DHCPStaticOptionsPath(network, deviceStaticFileName)) {
+		line += fmt.Sprintf(",set:%s", staticOptionsTag(deviceStaticFileName))
+	}
+
 	// Generate the dhcp-host line
 	if ipv4Address != "" {
 		line += fmt.Sprintf(",%s", ipv4Address)
@@ -53,7 +63,6 @@ func UpdateStaticEntry(network string, projectName string, instanceName string,
 		return nil
 	}
 
-	deviceStaticFileName := StaticAllocationFileName(projectName, instanceName, deviceName)
 	err := os.WriteFile(internalUtil.VarPath("networks", network, "dnsmasq.hosts", deviceStaticFileName), []byte(line+"\n"), 0o644)
 	if err != nil {
 		return err
@@ -70,9 +79,86 @@ func RemoveStaticEntry(network string, projectName string, instanceName string,
 		return err
 	}
 
+	err = os.Remove(DHCPStaticOptionsPath(network, deviceStaticFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
 	return nil
 }
 
+// UpdateStaticOptions writes the DHCP options for a network/instance combination (removing them if empty).
+// UpdateStaticEntry must be called afterwards to tag the host with the options.
+func UpdateStaticOptions(network string, projectName string, instanceName string, deviceName string, options []dhcp.Option) error {
+	deviceStaticFileName := StaticAllocationFileName(projectName, instanceName, deviceName)
+	optionsPath := DHCPStaticOptionsPath(network, deviceStaticFileName)
+
+	if len(options) == 0 {
+		err := os.Remove(optionsPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(optionsPath), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tag := staticOptionsTag(deviceStaticFileName)
+
+	var content strings.Builder
+	for _, option := range options {
+		content.WriteString(fmt.Sprintf("tag:%s,%s\n", tag, option.Dnsmasq()))
+	}
+
+	return os.WriteFile(optionsPath, []byte(content.String()), 0o644)
+}
+
+// DHCPStaticOptionsPath returns the path to the DHCP options file of an instance device.
+func DHCPStaticOptionsPath(network string, deviceStaticFileName string) string {
+	return internalUtil.VarPath("networks", network, "dnsmasq.opts", deviceStaticFileName)
+}
+
+// DHCPStaticOptions retrieves the DHCP options of an instance device static file.
+func DHCPStaticOptions(network string, deviceStaticFileName string) ([]dhcp.Option, error) {
+	content, err := os.ReadFile(DHCPStaticOptionsPath(network, deviceStaticFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	options := []dhcp.Option{}
+	for _, line := range strings.Split(string(content), "\n") {
+		_, spec, found := strings.Cut(line, ",")
+		if !found {
+			continue
+		}
+
+		option, err := dhcp.ParseDnsmasqOption(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, *option)
+	}
+
+	return options, nil
+}
+
+// staticOptionsTag returns the dnsmasq tag used to match the DHCP options of an instance device.
+func staticOptionsTag(deviceStaticFileName string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(deviceStaticFileName))
+
+	return fmt.Sprintf("incus-%08x", hash.Sum32())
+}
+
 // Kill kills dnsmasq for a particular network (or optionally reloads it).
 func Kill(name string, reload bool) error {
 	pidPath := internalUtil.VarPath("networks", name, "dnsmasq.pid")
@@ -128,6 +214,7 @@ func DHCPStaticAllocationPath(network string, deviceStaticFileName string) strin
 func DHCPStaticAllocation(network string, deviceStaticFileName string) (net.HardwareAddr, DHCPAllocation, DHCPAllocation, error) {
 	var IPv4, IPv6 DHCPAllocation
 	var mac net.HardwareAddr
+	var hostname string
 
 	file, err := os.Open(DHCPStaticAllocationPath(network, deviceStaticFileName))
 	if err != nil {
@@ -162,6 +249,11 @@ func DHCPStaticAllocation(network string, deviceStaticFileName string) (net.Hard
 				if err != nil {
 					return nil, IPv4, IPv6, fmt.Errorf("Error parsing MAC address %q", field)
 				}
+			} else if strings.HasPrefix(field, "set:") {
+				// Skip the DHCP options tag.
+				continue
+			} else if field != "" {
+				hostname = field
 			}
 		}
 	}
@@ -171,6 +263,15 @@ func DHCPStaticAllocation(network string, deviceStaticFileName string) (net.Hard
 		return nil, IPv4, IPv6, err
 	}
 
+	// The host name is expected to come last.
+	if IPv4.IP != nil {
+		IPv4.Hostname = hostname
+	}
+
+	if IPv6.IP != nil {
+		IPv6.Hostname = hostname
+	}
+
 	return mac, IPv4, IPv6, nil
 }
 
@@ -214,8 +315,70 @@ func DHCPAllAllocations(network string) (map[[4]byte]DHCPAllocation, map[[16]byt
 		}
 	}
 
-	// Next read all dynamic allocated IPs.
-	file, err := os.Open(internalUtil.VarPath("networks", network, "dnsmasq.leases"))
+	// addDynamic records a dynamic allocation.
+	addDynamic := func(address string, hwaddr string) error {
+		IP := net.ParseIP(address)
+		if IP == nil {
+			return fmt.Errorf("Error parsing IP address: %v", address)
+		}
+
+		// Handle IPv6 addresses.
+		if IP.To4() == nil {
+			var IPKey [16]byte
+			copy(IPKey[:], IP.To16())
+
+			// Don't replace IPs from static config as more reliable.
+			if IPv6s[IPKey].StaticFileName != "" {
+				return nil
+			}
+
+			IPv6s[IPKey] = DHCPAllocation{
+				IP: IP.To16(),
+			}
+		} else {
+			// MAC only available in IPv4 leases.
+			MAC, err := net.ParseMAC(hwaddr)
+			if err != nil {
+				return err
+			}
+
+			var IPKey [4]byte
+			copy(IPKey[:], IP.To4())
+
+			// Don't replace IPs from static config as more reliable.
+			if IPv4s[IPKey].StaticFileName != "" {
+				return nil
+			}
+
+			IPv4s[IPKey] = DHCPAllocation{
+				MAC: MAC,
+				IP:  IP.To4(),
+			}
+		}
+
+		return nil
+	}
+
+	// Next read all dynamic allocated IPs from the built-in DHCP server (if in use).
+	leasesPath := internalUtil.VarPath("networks", network, "dnsmasq.leases")
+	if !util.PathExists(leasesPath) && util.PathExists(dhcp.LeasesPath(network)) {
+		leases, err := dhcp.GetLeases(network)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, lease := range leases {
+			err = addDynamic(lease.Address, lease.Hwaddr)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		return IPv4s, IPv6s, nil
+	}
+
+	// Otherwise read them from the dnsmasq leases file.
+	file, err := os.Open(leasesPath)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -226,43 +389,9 @@ func DHCPAllAllocations(network string) (map[[4]byte]DHCPAllocation, map[[16]byt
 	for scanner.Scan() {
 		fields := strings.Fields(scanner.Text())
 		if len(fields) == 5 {
-			IP := net.ParseIP(fields[2])
-			if IP == nil {
-				return nil, nil, fmt.Errorf("Error parsing IP address: %v", fields[2])
-			}
-
-			// Handle IPv6 addresses.
-			if IP.To4() == nil {
-				var IPKey [16]byte
-				copy(IPKey[:], IP.To16())
-
-				// Don't replace IPs from static config as more reliable.
-				if IPv6s[IPKey].StaticFileName != "" {
-					continue
-				}
-
-				IPv6s[IPKey] = DHCPAllocation{
-					IP: IP.To16(),
-				}
-			} else {
-				// MAC only available in IPv4 leases.
-				MAC, err := net.ParseMAC(fields[1])
-				if err != nil {
-					return nil, nil, err
-				}
-
-				var IPKey [4]byte
-				copy(IPKey[:], IP.To4())
-
-				// Don't replace IPs from static config as more reliable.
-				if IPv4s[IPKey].StaticFileName != "" {
-					continue
-				}
-
-				IPv4s[IPKey] = DHCPAllocation{
-					MAC: MAC,
-					IP:  IP.To4(),
-				}
+			err = addDynamic(fields[2], fields[1])
+			if err != nil {
+				return nil, nil, err
 			}
 		}
 	}
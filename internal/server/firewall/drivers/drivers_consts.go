@@ -12,9 +12,10 @@ type FeatureOpts struct {
 
 // SNATOpts specify how SNAT rules are setup.
 type SNATOpts struct {
-	Append      bool       // Append rules (has no effect if driver doesn't support it).
-	Subnet      *net.IPNet // Subnet of source network used to identify candidate traffic.
-	SNATAddress net.IP     // SNAT IP address to use. If nil then MASQUERADE is used.
+	Append      bool         // Append rules (has no effect if driver doesn't support it).
+	Subnet      *net.IPNet   // Subnet of source network used to identify candidate traffic.
+	SNATAddress net.IP       // SNAT IP address to use. If nil then MASQUERADE is used.
+	Exclude     []*net.IPNet // Destination subnets to leave untranslated (such as those of peered networks).
 }
 
 // Opts for setting up the firewall.
@@ -23,6 +24,7 @@ type Opts struct {
 	FeaturesV6 *FeatureOpts // Enable IPv6 firewall with specified options. Off if not provided.
 	SNATV4     *SNATOpts    // Enable IPv4 SNAT with specified options. Off if not provided.
 	SNATV6     *SNATOpts    // Enable IPv6 SNAT with specified options. Off if not provided.
+	NAT64      *net.IPNet   // Enable NAT64 translation of the specified IPv6 prefix. Off if not provided.
 	ACL        bool         // Enable ACL during setup.
 	AddressSet bool         // Enable address sets, only for netfilter.
 }
@@ -40,6 +42,13 @@ type ACLRule struct {
 	DestinationPort string
 	ICMPType        string
 	ICMPCode        string
+	Counter         string // Optional, name of the counter tracking the matched packets.
+}
+
+// ACLCounter represents the hit counters of an ACL rule.
+type ACLCounter struct {
+	Packets uint64
+	Bytes   uint64
 }
 
 // AddressForward represents a NAT address forward.
@@ -52,6 +61,37 @@ type AddressForward struct {
 	SNAT          bool
 }
 
+// LoadBalancer represents a load balanced listen address and port.
+type LoadBalancer struct {
+	ListenAddress net.IP
+	Protocol      string
+	ListenPorts   []uint64
+	Backends      []LoadBalancerBackend
+}
+
+// LoadBalancerBackend represents a load balancer backend.
+type LoadBalancerBackend struct {
+	TargetAddress net.IP
+	TargetPorts   []uint64 // Empty to use the listen ports, single port or one port per listen port.
+}
+
+// TraceFlow represents a flow coming from an instance NIC to trace through the firewall.
+type TraceFlow struct {
+	HostName        string // Host-side interface of the NIC.
+	Protocol        string // Protocol of the flow (tcp, udp or icmp). Any protocol if empty.
+	Source          net.IP // Source address. Any source if nil.
+	Destination     net.IP
+	DestinationPort uint64 // Destination port (tcp and udp only). Any port if 0.
+}
+
+// NetworkRuleset represents the firewall rules of a network, both live and as last applied by Incus.
+type NetworkRuleset struct {
+	Rules      []string // Rules of the network currently in the kernel.
+	Tracked    bool     // Whether the rules were applied by Incus since startup (nothing to compare with otherwise).
+	Missing    []string // Rules applied by Incus which are no longer in the kernel.
+	Unexpected []string // Rules in the kernel which weren't applied by Incus.
+}
+
 // AddressSet represent an address set.
 type AddressSet struct {
 	Name      string
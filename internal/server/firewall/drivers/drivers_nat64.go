@@ -0,0 +1,107 @@
+package drivers
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"os/exec"
+	"slices"
+	"sync"
+
+	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// nat64Instance is the name of the Jool instance used for NAT64 translation.
+const nat64Instance = "incus"
+
+// nat64Networks tracks the prefix of the networks using the shared NAT64 instance.
+var nat64Networks = map[string]*net.IPNet{}
+var nat64Mu sync.Mutex
+
+// nat64FilterFunc restricts NAT64 translation of the prefix to the traffic coming from the given networks.
+// The filter is removed when no network is given.
+type nat64FilterFunc func(prefix *net.IPNet, networkNames []string) error
+
+// networkSetupNAT64 enables stateful NAT64 translation of the given prefix for a network.
+// Translation is performed by a single Jool instance (in netfilter mode) shared by all networks, so they
+// must all use the same prefix. The IPv4 side uses the addresses of the host.
+// As the netfilter mode translates any packet reaching the host for the prefix, the filter drops the
+// traffic for the prefix that doesn't come from one of the networks using NAT64 before it reaches Jool.
+func networkSetupNAT64(networkName string, prefix *net.IPNet, filter nat64FilterFunc) error {
+	nat64Mu.Lock()
+	defer nat64Mu.Unlock()
+
+	for otherName, otherPrefix := range nat64Networks {
+		if otherName != networkName && otherPrefix.String() != prefix.String() {
+			return fmt.Errorf("NAT64 prefix %q conflicts with prefix %q used by network %q", prefix.String(), otherPrefix.String(), otherName)
+		}
+	}
+
+	_, err := exec.LookPath("jool")
+	if err != nil {
+		return fmt.Errorf("NAT64 requires the jool command: %w", err)
+	}
+
+	// Apply the filter before the instance starts translating.
+	networkNames := slices.Sorted(maps.Keys(nat64Networks))
+	if !slices.Contains(networkNames, networkName) {
+		networkNames = append(networkNames, networkName)
+		slices.Sort(networkNames)
+	}
+
+	err = filter(prefix, networkNames)
+	if err != nil {
+		return fmt.Errorf("Failed applying NAT64 filter: %w", err)
+	}
+
+	// Create the instance unless left over by a previous run.
+	_, err = subprocess.RunCommand("jool", "--instance", nat64Instance, "global", "display")
+	if err != nil {
+		_ = linux.LoadModule("jool")
+
+		_, err = subprocess.RunCommand("jool", "instance", "add", nat64Instance, "--netfilter", "--pool6", prefix.String())
+		if err != nil {
+			return fmt.Errorf("Failed creating NAT64 instance: %w", err)
+		}
+	}
+
+	nat64Networks[networkName] = prefix
+
+	return nil
+}
+
+// networkClearNAT64 disables NAT64 translation for a network, removing the shared instance once unused.
+func networkClearNAT64(networkName string, filter nat64FilterFunc) error {
+	nat64Mu.Lock()
+	defer nat64Mu.Unlock()
+
+	prefix, ok := nat64Networks[networkName]
+	if !ok {
+		return nil
+	}
+
+	delete(nat64Networks, networkName)
+
+	if len(nat64Networks) > 0 {
+		err := filter(prefix, slices.Sorted(maps.Keys(nat64Networks)))
+		if err != nil {
+			return fmt.Errorf("Failed applying NAT64 filter: %w", err)
+		}
+
+		return nil
+	}
+
+	_, err := subprocess.RunCommand("jool", "instance", "remove", nat64Instance)
+	if err != nil {
+		return fmt.Errorf("Failed removing NAT64 instance: %w", err)
+	}
+
+	// Only remove the filter once nothing gets translated anymore.
+	err = filter(prefix, nil)
+	if err != nil {
+		return fmt.Errorf("Failed removing NAT64 filter: %w", err)
+	}
+
+	return nil
+}
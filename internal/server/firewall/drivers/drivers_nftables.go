@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"bufio"
 	"context"
 	"encoding/hex"
 	"encoding/json"
@@ -8,14 +9,17 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/lxc/incus/v6/internal/linux"
+	"github.com/lxc/incus/v6/internal/server/locking"
 	"github.com/lxc/incus/v6/internal/server/project"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/subprocess"
@@ -33,6 +37,19 @@ const (
 // to contain underscores (where as instance name is not).
 const nftablesChainSeparator = "."
 
+// nftablesNetworkChains lists the chains (without their network suffix) holding the rules of a network.
+var nftablesNetworkChains = []string{
+	"fwd", "pstrt", "in", "out", // Chains used for network operation rules.
+	"aclin", "aclout", "aclfwd", "acl", // Chains used by ACL rules.
+	"fwdprert", "fwdout", "fwdpstrt", // Chains used by Address Forward rules.
+	"lbprert", "lbout", "lbpstrt", // Chains used by Load Balancer rules.
+	"egress", // Chains added for limits.priority option
+}
+
+// nftablesNetworkFamilies lists the families of the tables the chains of a network can be in.
+// The ip and ip6 families are used by networks set up before we moved to the inet table.
+var nftablesNetworkFamilies = []string{"inet", "ip", "ip6", "netdev"}
+
 // nftablesMinVersion We need at least 0.9.1 as this was when the arp ether saddr filters were added.
 const nftablesMinVersion = "0.9.1"
 
@@ -190,6 +207,199 @@ func (d Nftables) nftParseRuleset() ([]nftGenericItem, error) {
 	return items, nil
 }
 
+// NetworkACLCounters returns the hit counters of the ACL rules, summed by counter name.
+func (d Nftables) NetworkACLCounters() (map[string]ACLCounter, error) {
+	// Dump ruleset as JSON. Use -nn flags to avoid doing DNS lookups of IPs mentioned in any rules.
+	output, err := subprocess.RunCommand("nft", "--json", "-nn", "list", "ruleset")
+	if err != nil {
+		return nil, err
+	}
+
+	return nftParseRuleCounters([]byte(output))
+}
+
+// nftParseRuleCounters extracts the counters of the commented rules from a JSON ruleset.
+func nftParseRuleCounters(ruleset []byte) (map[string]ACLCounter, error) {
+	v := &struct {
+		Nftables []struct {
+			Rule *struct {
+				Comment string `json:"comment"`
+				Expr    []struct {
+					Counter *struct {
+						Packets uint64 `json:"packets"`
+						Bytes   uint64 `json:"bytes"`
+					} `json:"counter"`
+				} `json:"expr"`
+			} `json:"rule"`
+		} `json:"nftables"`
+	}{}
+
+	err := json.Unmarshal(ruleset, v)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := map[string]ACLCounter{}
+	for _, item := range v.Nftables {
+		if item.Rule == nil || item.Rule.Comment == "" {
+			continue
+		}
+
+		for _, expr := range item.Rule.Expr {
+			if expr.Counter == nil {
+				continue
+			}
+
+			// The same ACL rule can result in multiple nftables rules (one per address family or network).
+			counter := counters[item.Rule.Comment]
+			counter.Packets += expr.Counter.Packets
+			counter.Bytes += expr.Counter.Bytes
+			counters[item.Rule.Comment] = counter
+		}
+	}
+
+	return counters, nil
+}
+
+// NetworkTraceFlow traces the packets of a flow coming from an instance device through the ruleset.
+// It waits up to timeout for a matching packet and returns the trace of the first one seen.
+func (d Nftables) NetworkTraceFlow(flow TraceFlow, timeout time.Duration) ([]string, error) {
+	ipFamily := "ip"
+	if flow.Destination.To4() == nil {
+		ipFamily = "ip6"
+	}
+
+	match := []string{fmt.Sprintf("%s daddr %s", ipFamily, flow.Destination.String())}
+
+	if flow.Source != nil {
+		if (flow.Source.To4() == nil) != (flow.Destination.To4() == nil) {
+			return nil, errors.New("Source and destination addresses must be of the same family")
+		}
+
+		match = append(match, fmt.Sprintf("%s saddr %s", ipFamily, flow.Source.String()))
+	}
+
+	switch flow.Protocol {
+	case "":
+	case "tcp", "udp":
+		if flow.DestinationPort > 0 {
+			match = append(match, fmt.Sprintf("%s dport %d", flow.Protocol, flow.DestinationPort))
+		} else {
+			match = append(match, fmt.Sprintf("meta l4proto %s", flow.Protocol))
+		}
+
+	case "icmp":
+		if ipFamily == "ip" {
+			match = append(match, "meta l4proto icmp")
+		} else {
+			match = append(match, "meta l4proto ipv6-icmp")
+		}
+
+	default:
+		return nil, fmt.Errorf("Unsupported protocol %q", flow.Protocol)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The trace chain is specific to the interface, so only one trace can run on it at a time.
+	unlock, err := locking.Lock(ctx, "firewall.trace."+flow.HostName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed waiting for other traces of interface %q: %w", flow.HostName, err)
+	}
+
+	defer unlock()
+
+	// Start monitoring before enabling tracing so no packet is missed.
+	cmd := exec.CommandContext(ctx, "nft", "monitor", "trace")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("Failed starting nftables trace monitor: %w", err)
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		cancel()
+		_ = cmd.Wait()
+	}()
+
+	// Tag the trace rule so that the packets it traces can be told apart from those of other traces.
+	tag := "trace-" + uuid.New().String()
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"family":         "netdev",
+		"chainSeparator": nftablesChainSeparator,
+		"hostName":       flow.HostName,
+		"match":          strings.Join(match, " "),
+		"tag":            tag,
+	}
+
+	err = d.applyNftConfig(nftablesInstanceTrace, tplFields)
+	if err != nil {
+		return nil, fmt.Errorf("Failed adding trace rule for interface %q: %w", flow.HostName, err)
+	}
+
+	defer func() { _ = d.removeChains([]string{"netdev"}, flow.HostName, "trace") }()
+
+	// Collect the trace of the first packet traced by the rule, giving it a moment to go through all the hooks.
+	// The lines of a packet are buffered until the one showing the tagged rule identifies it.
+	pending := map[string][]string{}
+	var traceID string
+	var settled <-chan time.Time
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return pending[traceID], nil
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) < 3 || fields[0] != "trace" || fields[1] != "id" {
+				continue
+			}
+
+			id := fields[2]
+			if traceID != "" && id != traceID {
+				continue
+			}
+
+			pending[id] = append(pending[id], line)
+
+			if traceID == "" && strings.Contains(line, tag) {
+				traceID = id
+				pending = map[string][]string{id: pending[id]}
+				settled = time.After(time.Second)
+			}
+
+		case <-settled:
+			return pending[traceID], nil
+
+		case <-ctx.Done():
+			return pending[traceID], nil
+		}
+	}
+}
+
 // GetVersion returns the version of nftables.
 func (d Nftables) hostVersion() (*version.DottedVersion, error) {
 	output, err := subprocess.RunCommandCLocale("nft", "--version")
@@ -320,8 +530,43 @@ func (d Nftables) networkSetupACLChainAndJumpRules(networkName string) error {
 	return nil
 }
 
+// networkSetupNAT64Filter drops the traffic for the NAT64 prefix that doesn't come from the specified networks.
+// The filter chain is removed when no network is specified.
+func (d Nftables) networkSetupNAT64Filter(prefix *net.IPNet, networkNames []string) error {
+	if len(networkNames) == 0 {
+		return d.removeChains([]string{"inet"}, "", "nat64")
+	}
+
+	tplFields := map[string]any{
+		"namespace":    nftablesNamespace,
+		"family":       "inet",
+		"prefix":       prefix.String(),
+		"networkNames": networkNames,
+	}
+
+	config := &strings.Builder{}
+	err := nftablesNetNAT64Filter.Execute(config, tplFields)
+	if err != nil {
+		return fmt.Errorf("Failed running %q template: %w", nftablesNetNAT64Filter.Name(), err)
+	}
+
+	err = subprocess.RunCommandWithFds(context.TODO(), strings.NewReader(config.String()), nil, "nft", "-f", "-")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // NetworkSetup configure network firewall.
 func (d Nftables) NetworkSetup(networkName string, opts Opts) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkSetup(networkName, opts)
+	})
+}
+
+// networkSetup is the implementation of NetworkSetup.
+func (d Nftables) networkSetup(networkName string, opts Opts) error {
 	// Do this first before adding other network rules, so jump to ACL rules come first.
 	if opts.ACL {
 		err := d.networkSetupACLChainAndJumpRules(networkName)
@@ -337,6 +582,13 @@ func (d Nftables) NetworkSetup(networkName string, opts Opts) error {
 		}
 	}
 
+	if opts.NAT64 != nil {
+		err := networkSetupNAT64(networkName, opts.NAT64, d.networkSetupNAT64Filter)
+		if err != nil {
+			return err
+		}
+	}
+
 	dhcpDNSAccess := []uint{}
 	var ip4ForwardingAllow, ip6ForwardingAllow *bool
 
@@ -374,16 +626,18 @@ func (d Nftables) NetworkSetup(networkName string, opts Opts) error {
 // NetworkClear removes the Incus network related chains and address sets.
 // The delete and ipeVersions arguments have no effect for nftables driver.
 func (d Nftables) NetworkClear(networkName string, _ bool, _ []uint) error {
-	removeChains := []string{
-		"fwd", "pstrt", "in", "out", // Chains used for network operation rules.
-		"aclin", "aclout", "aclfwd", "acl", // Chains used by ACL rules.
-		"fwdprert", "fwdout", "fwdpstrt", // Chains used by Address Forward rules.
-		"egress", // Chains added for limits.priority option
+	unlock, err := networkRulesetLock(networkName)
+	if err != nil {
+		return err
 	}
 
+	defer unlock()
+
+	networkRulesetForget(networkName)
+
 	// Remove chains created by network rules.
 	// Remove from ip and ip6 tables to ensure cleanup for instances started before we moved to inet table
-	err := d.removeChains([]string{"inet", "ip", "ip6", "netdev"}, networkName, removeChains...)
+	err = d.removeChains(nftablesNetworkFamilies, networkName, nftablesNetworkChains...)
 	if err != nil {
 		return fmt.Errorf("Failed clearing nftables rules for network %q: %w", networkName, err)
 	}
@@ -392,9 +646,68 @@ func (d Nftables) NetworkClear(networkName string, _ bool, _ []uint) error {
 	// This will fail so long as there are still rules referencing them (other networks).
 	_ = d.RemoveIncusAddressSets("bridge")
 
+	err = networkClearNAT64(networkName, d.networkSetupNAT64Filter)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// NetworkRuleset returns the rules of the network in the kernel, compared with the ones last applied by Incus.
+func (d Nftables) NetworkRuleset(networkName string) (*NetworkRuleset, error) {
+	return networkRulesetCompare(networkName, d.networkRules)
+}
+
+// networkRules returns the rules of the chains of a network, one per line prefixed with their family and chain.
+// Counter values are stripped from the rules so that only changes to the rules themselves are compared.
+func (d Nftables) networkRules(networkName string) ([]string, error) {
+	ruleset, err := d.nftParseRuleset()
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make([]string, 0, len(nftablesNetworkChains))
+	for _, chain := range nftablesNetworkChains {
+		chains = append(chains, fmt.Sprintf("%s%s%s", chain, nftablesChainSeparator, networkName))
+	}
+
+	rules := []string{}
+	for _, item := range ruleset {
+		if item.ItemType != "chain" || item.Table != nftablesNamespace || !slices.Contains(nftablesNetworkFamilies, item.Family) || !slices.Contains(chains, item.Name) {
+			continue
+		}
+
+		output, err := subprocess.RunCommand("nft", "-nn", "list", "chain", item.Family, nftablesNamespace, item.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, nftChainRules(item.Family, item.Name, output)...)
+	}
+
+	return rules, nil
+}
+
+// nftCounterValues matches the values of anonymous counters in nft listings.
+var nftCounterValues = regexp.MustCompile(`counter packets [0-9]+ bytes [0-9]+`)
+
+// nftChainRules parses the listing of a chain and returns its rules prefixed with the chain family and name.
+func nftChainRules(family string, chain string, output string) []string {
+	rules := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "}" || strings.HasPrefix(line, "table ") || strings.HasPrefix(line, "chain ") {
+			continue
+		}
+
+		line = nftCounterValues.ReplaceAllString(line, "counter")
+		rules = append(rules, fmt.Sprintf("%s %s: %s", family, chain, line))
+	}
+
+	return rules
+}
+
 // instanceDeviceLabel returns the unique label used for instance device chains.
 func (d Nftables) instanceDeviceLabel(projectName, instanceName, deviceName string) string {
 	return fmt.Sprintf("%s%s%s", project.Instance(projectName, instanceName), nftablesChainSeparator, deviceName)
@@ -935,6 +1248,13 @@ func (d Nftables) InstanceClearNetPrio(projectName string, instanceName string,
 
 // NetworkApplyACLRules applies ACL rules to the existing firewall chains.
 func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkApplyACLRules(networkName, rules)
+	})
+}
+
+// networkApplyACLRules is the implementation of NetworkApplyACLRules.
+func (d Nftables) networkApplyACLRules(networkName string, rules []ACLRule) error {
 	completeNftRules := make([]string, 0)
 	for _, rule := range rules {
 		// First try generating rules with IPv4 or IP agnostic criteria.
@@ -1027,11 +1347,16 @@ func (d Nftables) buildRemainingRuleParts(rule *ACLRule, ipVersion uint) (string
 	if rule.Log {
 		args = append(args, "log")
 		if rule.LogName != "" {
-			// Append a trailing space for readability in logs.
-			args = append(args, "prefix", fmt.Sprintf(`"%s "`, rule.LogName))
+			// Include the verdict for the network-acl events and append a trailing space for readability in logs.
+			args = append(args, "prefix", fmt.Sprintf(`"%s verdict=%s "`, rule.LogName, rule.Action))
 		}
 	}
 
+	// Count matched packets, the counter is identified by the rule comment.
+	if rule.Counter != "" {
+		args = append(args, "counter")
+	}
+
 	// Handle action.
 	action := rule.Action
 	if action == "allow" {
@@ -1040,6 +1365,10 @@ func (d Nftables) buildRemainingRuleParts(rule *ACLRule, ipVersion uint) (string
 
 	args = append(args, action)
 
+	if rule.Counter != "" {
+		args = append(args, "comment", fmt.Sprintf(`"%s"`, rule.Counter))
+	}
+
 	return strings.Join(args, " "), nil
 }
 
@@ -1310,6 +1639,13 @@ func (d Nftables) aclRulePortToACLMatch(direction string, portCriteria ...string
 
 // NetworkApplyForwards apply network address forward rules to firewall.
 func (d Nftables) NetworkApplyForwards(networkName string, rules []AddressForward) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkApplyForwards(networkName, rules)
+	})
+}
+
+// networkApplyForwards is the implementation of NetworkApplyForwards.
+func (d Nftables) networkApplyForwards(networkName string, rules []AddressForward) error {
 	var dnatRules []map[string]any
 	var snatRules []map[string]any
 
@@ -1459,6 +1795,120 @@ func (d Nftables) NetworkApplyForwards(networkName string, rules []AddressForwar
 	return nil
 }
 
+// NetworkApplyLoadBalancers applies the load balancer rules to the network, spreading the connections across
+// the backends.
+func (d Nftables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkApplyLoadBalancers(networkName, rules)
+	})
+}
+
+// networkApplyLoadBalancers is the implementation of NetworkApplyLoadBalancers.
+func (d Nftables) networkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	dnatRules, snatRules, err := nftLoadBalancerRules(rules)
+	if err != nil {
+		return err
+	}
+
+	// Remove chains if no rules generated.
+	if len(dnatRules) == 0 {
+		err := d.removeChains([]string{"inet"}, networkName, "lbprert", "lbout", "lbpstrt")
+		if err != nil {
+			return fmt.Errorf("Failed clearing nftables load balancer rules for network %q: %w", networkName, err)
+		}
+
+		return nil
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"family":         "inet",
+		"label":          networkName,
+		"dnatRules":      dnatRules,
+		"snatRules":      snatRules,
+	}
+
+	config := &strings.Builder{}
+	err = nftablesNetLoadBalancer.Execute(config, tplFields)
+	if err != nil {
+		return fmt.Errorf("Failed running %q template: %w", nftablesNetLoadBalancer.Name(), err)
+	}
+
+	return subprocess.RunCommandWithFds(context.TODO(), strings.NewReader(config.String()), nil, "nft", "-f", "-")
+}
+
+// nftLoadBalancerRules returns the DNAT and hairpin SNAT template fields for the load balancer rules.
+func nftLoadBalancerRules(rules []LoadBalancer) ([]map[string]any, []map[string]any, error) {
+	var dnatRules []map[string]any
+	var snatRules []map[string]any
+
+	for ruleIndex, rule := range rules {
+		if rule.ListenAddress == nil {
+			return nil, nil, fmt.Errorf("Invalid rule %d, listen address is required", ruleIndex)
+		}
+
+		if rule.Protocol == "" || len(rule.ListenPorts) == 0 {
+			return nil, nil, fmt.Errorf("Invalid rule %d, protocol and listen ports are required", ruleIndex)
+		}
+
+		// Skip rules without any available backend.
+		if len(rule.Backends) == 0 {
+			continue
+		}
+
+		ipFamily := "ip"
+		if rule.ListenAddress.To4() == nil {
+			ipFamily = "ip6"
+		}
+
+		for portIndex, listenPort := range rule.ListenPorts {
+			targets := make([]string, 0, len(rule.Backends))
+
+			for backendIndex, backend := range rule.Backends {
+				if backend.TargetAddress == nil {
+					return nil, nil, fmt.Errorf("Invalid rule %d, target address is required for backend %d", ruleIndex, backendIndex)
+				}
+
+				var targetPort uint64
+				switch len(backend.TargetPorts) {
+				case 0:
+					// No target ports specified, use listen port.
+					targetPort = listenPort
+				case 1:
+					// Single target port specified.
+					targetPort = backend.TargetPorts[0]
+				case len(rule.ListenPorts):
+					// One-to-one match with listen ports.
+					targetPort = backend.TargetPorts[portIndex]
+				default:
+					return nil, nil, fmt.Errorf("Invalid rule %d, mismatch between listen port(s) and target port(s) count for backend %d", ruleIndex, backendIndex)
+				}
+
+				targets = append(targets, fmt.Sprintf("%d : %s . %d", backendIndex, backend.TargetAddress.String(), targetPort))
+
+				snatRules = append(snatRules, map[string]any{
+					"ipFamily":   ipFamily,
+					"protocol":   rule.Protocol,
+					"targetHost": backend.TargetAddress.String(),
+					"targetPort": targetPort,
+				})
+			}
+
+			dnatRules = append(dnatRules, map[string]any{
+				"ipFamily":      ipFamily,
+				"protocol":      rule.Protocol,
+				"listenAddress": rule.ListenAddress.String(),
+				"listenPort":    listenPort,
+				"backendCount":  len(rule.Backends),
+				"targets":       strings.Join(targets, ", "),
+			})
+		}
+	}
+
+	return dnatRules, snatRules, nil
+}
+
 // NetworkApplyAddressSets creates or updates named nft sets for all address sets.
 func (d Nftables) NetworkApplyAddressSets(sets []AddressSet, nftTable string) error {
 	_, err := subprocess.RunCommand("nft", "create", "table", nftTable, nftablesNamespace)
@@ -31,6 +31,9 @@ chain pstrt{{.chainSeparator}}{{.networkName}} {
 	type nat hook postrouting priority 100; policy accept;
 
 	{{ range $ipFamily, $config := .rules }}
+	{{ range $config.Exclude }}
+	{{$ipFamily}} saddr {{$config.Subnet}} {{$ipFamily}} daddr {{.}} return
+	{{ end }}
 	{{ if $config.SNATAddress }}
 	{{$ipFamily}} saddr {{$config.Subnet}} {{$ipFamily}} daddr != {{$config.Subnet}} snat {{$config.SNATAddress}}
 	{{ else }}
@@ -115,6 +118,39 @@ table {{.family}} {{.namespace}} {
 }
 `))
 
+var nftablesNetLoadBalancer = template.Must(template.New("nftablesNetLoadBalancer").Parse(`
+add table {{.family}} {{.namespace}}
+add chain {{.family}} {{.namespace}} lbprert{{.chainSeparator}}{{.label}} {type nat hook prerouting priority -100; policy accept;}
+add chain {{.family}} {{.namespace}} lbout{{.chainSeparator}}{{.label}} {type nat hook output priority -100; policy accept;}
+add chain {{.family}} {{.namespace}} lbpstrt{{.chainSeparator}}{{.label}} {type nat hook postrouting priority 100; policy accept;}
+flush chain {{.family}} {{.namespace}} lbprert{{.chainSeparator}}{{.label}}
+flush chain {{.family}} {{.namespace}} lbout{{.chainSeparator}}{{.label}}
+flush chain {{.family}} {{.namespace}} lbpstrt{{.chainSeparator}}{{.label}}
+
+table {{.family}} {{.namespace}} {
+	chain lbprert{{.chainSeparator}}{{.label}} {
+		type nat hook prerouting priority -100; policy accept;
+		{{ range .dnatRules }}
+		{{.ipFamily}} daddr {{.listenAddress}} {{.protocol}} dport {{.listenPort}} dnat {{.ipFamily}} to numgen random mod {{.backendCount}} map { {{.targets}} }
+		{{ end }}
+	}
+
+	chain lbout{{.chainSeparator}}{{.label}} {
+		type nat hook output priority -100; policy accept;
+		{{ range .dnatRules }}
+		{{.ipFamily}} daddr {{.listenAddress}} {{.protocol}} dport {{.listenPort}} dnat {{.ipFamily}} to numgen random mod {{.backendCount}} map { {{.targets}} }
+		{{ end }}
+	}
+
+	chain lbpstrt{{.chainSeparator}}{{.label}} {
+		type nat hook postrouting priority 100; policy accept;
+		{{ range .snatRules }}
+		{{.ipFamily}} saddr {{.targetHost}} {{.ipFamily}} daddr {{.targetHost}} {{.protocol}} dport {{.targetPort}} masquerade
+		{{ end }}
+	}
+}
+`))
+
 var nftablesNetACLSetup = template.Must(template.New("nftablesNetACLSetup").Parse(`
 add table {{.family}} {{.namespace}}
 add chain {{.family}} {{.namespace}} acl{{.chainSeparator}}{{.networkName}}
@@ -166,6 +202,19 @@ table {{.family}} {{.namespace}} {
 }
 `))
 
+var nftablesNetNAT64Filter = template.Must(template.New("nftablesNetNAT64Filter").Parse(`
+add table {{.family}} {{.namespace}}
+add chain {{.family}} {{.namespace}} nat64 {type filter hook prerouting priority raw; policy accept;}
+flush chain {{.family}} {{.namespace}} nat64
+
+table {{.family}} {{.namespace}} {
+	chain nat64 {
+		# Only translate the traffic coming from the networks using NAT64.
+		ip6 daddr {{.prefix}} iifname != { {{range $i, $name := .networkNames}}{{if $i}}, {{end}}"{{$name}}"{{end}} } drop
+	}
+}
+`))
+
 var nftablesNetACLRules = template.Must(template.New("nftablesNetACLRules").Parse(`
 flush chain {{.family}} {{.namespace}} acl{{.chainSeparator}}{{.networkName}}
 
@@ -374,6 +423,14 @@ chain prert{{.chainSeparator}}{{.deviceLabel}} {
 }
 `))
 
+// nftablesInstanceTrace defines the rule enabling tracing of a flow coming from an instance device.
+var nftablesInstanceTrace = template.Must(template.New("nftablesInstanceTrace").Parse(`
+chain trace{{.chainSeparator}}{{.hostName}} {
+	type filter hook ingress device "{{.hostName}}" priority -500;
+	{{.match}} meta nftrace set 1 comment "{{.tag}}"
+}
+`))
+
 // nftablesInstanceNetPrio defines the rules to perform setting of skb->priority.
 var nftablesInstanceNetPrio = template.Must(template.New("nftablesInstanceNetPrio").Parse(`
 chain egress{{.chainSeparator}}netprio{{.chainSeparator}}{{.deviceLabel}} {
@@ -0,0 +1,162 @@
+package drivers
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_nftParseRuleCounters(t *testing.T) {
+	ruleset := `{"nftables": [
+		{"metainfo": {"version": "1.0.9", "json_schema_version": 1}},
+		{"table": {"family": "inet", "name": "incus", "handle": 1}},
+		{"chain": {"family": "inet", "table": "incus", "name": "aclin.incusbr0", "handle": 2}},
+		{"rule": {"family": "inet", "table": "incus", "chain": "aclin.incusbr0", "handle": 3, "comment": "incus_acl1-ingress-0",
+			"expr": [{"match": {"op": "==", "left": {"meta": {"key": "l4proto"}}, "right": "tcp"}}, {"counter": {"packets": 10, "bytes": 1200}}, {"accept": null}]}},
+		{"rule": {"family": "inet", "table": "incus", "chain": "aclin.incusbr0", "handle": 4, "comment": "incus_acl1-ingress-0",
+			"expr": [{"counter": {"packets": 5, "bytes": 300}}, {"accept": null}]}},
+		{"rule": {"family": "inet", "table": "incus", "chain": "aclout.incusbr0", "handle": 5, "comment": "incus_acl1-egress-2",
+			"expr": [{"counter": {"packets": 0, "bytes": 0}}, {"drop": null}]}},
+		{"rule": {"family": "inet", "table": "incus", "chain": "aclout.incusbr0", "handle": 6,
+			"expr": [{"counter": {"packets": 7, "bytes": 700}}, {"drop": null}]}}
+	]}`
+
+	counters, err := nftParseRuleCounters([]byte(ruleset))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]ACLCounter{
+		"incus_acl1-ingress-0": {Packets: 15, Bytes: 1500},
+		"incus_acl1-egress-2":  {Packets: 0, Bytes: 0},
+	}, counters)
+}
+
+func Test_nftablesNetNAT64Filter(t *testing.T) {
+	config := &strings.Builder{}
+	err := nftablesNetNAT64Filter.Execute(config, map[string]any{
+		"namespace":    nftablesNamespace,
+		"family":       "inet",
+		"prefix":       "64:ff9b::/96",
+		"networkNames": []string{"incusbr0", "incusbr1"},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, config.String(), `ip6 daddr 64:ff9b::/96 iifname != { "incusbr0", "incusbr1" } drop`)
+}
+
+func Test_nftablesNetLoadBalancer(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []LoadBalancer
+		expected []string
+		excluded []string
+		err      string
+	}{
+		{
+			name: "IPv4 with the listen port on all backends",
+			rules: []LoadBalancer{{
+				ListenAddress: net.ParseIP("192.0.2.1"),
+				Protocol:      "tcp",
+				ListenPorts:   []uint64{80},
+				Backends: []LoadBalancerBackend{
+					{TargetAddress: net.ParseIP("10.0.0.2")},
+					{TargetAddress: net.ParseIP("10.0.0.3")},
+				},
+			}},
+			expected: []string{
+				`ip daddr 192.0.2.1 tcp dport 80 dnat ip to numgen random mod 2 map { 0 : 10.0.0.2 . 80, 1 : 10.0.0.3 . 80 }`,
+				`ip saddr 10.0.0.2 ip daddr 10.0.0.2 tcp dport 80 masquerade`,
+				`ip saddr 10.0.0.3 ip daddr 10.0.0.3 tcp dport 80 masquerade`,
+			},
+		},
+		{
+			name: "IPv6 with a single target port and one target port per listen port",
+			rules: []LoadBalancer{{
+				ListenAddress: net.ParseIP("2001:db8::1"),
+				Protocol:      "udp",
+				ListenPorts:   []uint64{53, 5353},
+				Backends: []LoadBalancerBackend{
+					{TargetAddress: net.ParseIP("fd42::2"), TargetPorts: []uint64{1053}},
+					{TargetAddress: net.ParseIP("fd42::3"), TargetPorts: []uint64{2053, 25353}},
+				},
+			}},
+			expected: []string{
+				`ip6 daddr 2001:db8::1 udp dport 53 dnat ip6 to numgen random mod 2 map { 0 : fd42::2 . 1053, 1 : fd42::3 . 2053 }`,
+				`ip6 daddr 2001:db8::1 udp dport 5353 dnat ip6 to numgen random mod 2 map { 0 : fd42::2 . 1053, 1 : fd42::3 . 25353 }`,
+				`ip6 saddr fd42::2 ip6 daddr fd42::2 udp dport 1053 masquerade`,
+				`ip6 saddr fd42::3 ip6 daddr fd42::3 udp dport 25353 masquerade`,
+			},
+		},
+		{
+			name: "Rule without backends",
+			rules: []LoadBalancer{
+				{
+					ListenAddress: net.ParseIP("192.0.2.1"),
+					Protocol:      "tcp",
+					ListenPorts:   []uint64{443},
+				},
+				{
+					ListenAddress: net.ParseIP("192.0.2.1"),
+					Protocol:      "tcp",
+					ListenPorts:   []uint64{22},
+					Backends:      []LoadBalancerBackend{{TargetAddress: net.ParseIP("10.0.0.2")}},
+				},
+			},
+			expected: []string{
+				`ip daddr 192.0.2.1 tcp dport 22 dnat ip to numgen random mod 1 map { 0 : 10.0.0.2 . 22 }`,
+			},
+			excluded: []string{"dport 443"},
+		},
+		{
+			name: "Mismatched target ports",
+			rules: []LoadBalancer{{
+				ListenAddress: net.ParseIP("192.0.2.1"),
+				Protocol:      "tcp",
+				ListenPorts:   []uint64{80, 443, 8080},
+				Backends:      []LoadBalancerBackend{{TargetAddress: net.ParseIP("10.0.0.2"), TargetPorts: []uint64{80, 443}}},
+			}},
+			err: "Invalid rule 0, mismatch between listen port(s) and target port(s) count for backend 0",
+		},
+		{
+			name: "Missing listen address",
+			rules: []LoadBalancer{{
+				Protocol:    "tcp",
+				ListenPorts: []uint64{80},
+			}},
+			err: "Invalid rule 0, listen address is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dnatRules, snatRules, err := nftLoadBalancerRules(tt.rules)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			config := &strings.Builder{}
+			err = nftablesNetLoadBalancer.Execute(config, map[string]any{
+				"namespace":      nftablesNamespace,
+				"chainSeparator": nftablesChainSeparator,
+				"family":         "inet",
+				"label":          "incusbr0",
+				"dnatRules":      dnatRules,
+				"snatRules":      snatRules,
+			})
+			require.NoError(t, err)
+
+			for _, rule := range tt.expected {
+				assert.Contains(t, config.String(), rule)
+			}
+
+			for _, rule := range tt.excluded {
+				assert.NotContains(t, config.String(), rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/lxc/incus/v6/internal/server/locking"
+)
+
+// networkRulesets holds the rules of each network as they were right after Incus last applied them.
+// Note that any access to this map must be done while holding networkRulesetsMutex.
+var networkRulesets = map[string][]string{}
+
+// networkRulesetsMutex is used to access networkRulesets safely.
+var networkRulesetsMutex sync.Mutex
+
+// networkRulesetLock locks the rules of a network, so they aren't compared while being changed.
+func networkRulesetLock(networkName string) (locking.UnlockFunc, error) {
+	return locking.Lock(context.TODO(), fmt.Sprintf("firewall_network_%s", networkName))
+}
+
+// networkRulesetUpdate runs f, which changes the rules of a network, and records the resulting rules as the
+// expected ones. The listRules function is used to get the rules of the network from the kernel.
+func networkRulesetUpdate(networkName string, listRules func(networkName string) ([]string, error), f func() error) error {
+	unlock, err := networkRulesetLock(networkName)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	err = f()
+	if err != nil {
+		// The rules are in an unknown state, stop comparing them until they are next applied.
+		networkRulesetForget(networkName)
+		return err
+	}
+
+	rules, err := listRules(networkName)
+	if err != nil {
+		networkRulesetForget(networkName)
+		return nil
+	}
+
+	networkRulesetsMutex.Lock()
+	networkRulesets[networkName] = rules
+	networkRulesetsMutex.Unlock()
+
+	return nil
+}
+
+// networkRulesetForget stops tracking the rules of a network.
+func networkRulesetForget(networkName string) {
+	networkRulesetsMutex.Lock()
+	delete(networkRulesets, networkName)
+	networkRulesetsMutex.Unlock()
+}
+
+// networkRulesetCompare returns the rules of a network in the kernel, compared with the ones last applied by Incus.
+func networkRulesetCompare(networkName string, listRules func(networkName string) ([]string, error)) (*NetworkRuleset, error) {
+	unlock, err := networkRulesetLock(networkName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	rules, err := listRules(networkName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing firewall rules of network %q: %w", networkName, err)
+	}
+
+	ruleset := &NetworkRuleset{Rules: rules}
+
+	networkRulesetsMutex.Lock()
+	expected, tracked := networkRulesets[networkName]
+	networkRulesetsMutex.Unlock()
+
+	if tracked {
+		ruleset.Tracked = true
+		ruleset.Missing, ruleset.Unexpected = rulesetDiff(expected, rules)
+	}
+
+	return ruleset, nil
+}
+
+// rulesetDiff returns the rules of expected which aren't in live and the rules of live which aren't in expected.
+// Identical rules are counted, so a rule applied twice but found once is reported missing.
+func rulesetDiff(expected []string, live []string) ([]string, []string) {
+	remaining := append([]string{}, live...)
+	missing := []string{}
+
+	for _, rule := range expected {
+		idx := slices.Index(remaining, rule)
+		if idx < 0 {
+			missing = append(missing, rule)
+			continue
+		}
+
+		remaining = slices.Delete(remaining, idx, idx+1)
+	}
+
+	return missing, remaining
+}
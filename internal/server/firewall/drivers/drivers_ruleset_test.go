@@ -0,0 +1,90 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rulesetDiff(t *testing.T) {
+	tests := []struct {
+		name               string
+		expected           []string
+		live               []string
+		expectedMissing    []string
+		expectedUnexpected []string
+	}{
+		{
+			name:               "Identical",
+			expected:           []string{"a", "b"},
+			live:               []string{"a", "b"},
+			expectedMissing:    []string{},
+			expectedUnexpected: []string{},
+		},
+		{
+			name:               "Flushed",
+			expected:           []string{"a", "b"},
+			live:               nil,
+			expectedMissing:    []string{"a", "b"},
+			expectedUnexpected: []string{},
+		},
+		{
+			name:               "Changed",
+			expected:           []string{"a", "b"},
+			live:               []string{"a", "c"},
+			expectedMissing:    []string{"b"},
+			expectedUnexpected: []string{"c"},
+		},
+		{
+			name:               "Duplicate rule removed",
+			expected:           []string{"a", "a", "b"},
+			live:               []string{"b", "a"},
+			expectedMissing:    []string{"a"},
+			expectedUnexpected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, unexpected := rulesetDiff(tt.expected, tt.live)
+			assert.Equal(t, tt.expectedMissing, missing)
+			assert.Equal(t, tt.expectedUnexpected, unexpected)
+		})
+	}
+}
+
+func Test_nftChainRules(t *testing.T) {
+	output := `table inet incus {
+	chain pstrt.incusbr0 {
+		type nat hook postrouting priority 100; policy accept;
+		ip saddr 10.0.0.0/24 ip daddr != 10.0.0.0/24 counter packets 12 bytes 1024 masquerade
+	}
+}
+`
+
+	assert.Equal(t, []string{
+		"inet pstrt.incusbr0: type nat hook postrouting priority 100; policy accept;",
+		"inet pstrt.incusbr0: ip saddr 10.0.0.0/24 ip daddr != 10.0.0.0/24 counter masquerade",
+	}, nftChainRules("inet", "pstrt.incusbr0", output))
+}
+
+func Test_iptablesSaveRules(t *testing.T) {
+	output := `# Generated by iptables-save
+*nat
+:POSTROUTING ACCEPT [0:0]
+-A POSTROUTING -s 10.0.0.0/24 ! -d 10.0.0.0/24 -m comment --comment "generated for Incus network incusbr0" -j MASQUERADE
+-A POSTROUTING -s 10.1.0.0/24 ! -d 10.1.0.0/24 -m comment --comment "generated for Incus network incusbr01" -j MASQUERADE
+COMMIT
+*filter
+:incus_acl_incusbr0 - [0:0]
+-A incus_acl_incusbr0 -j REJECT
+-A PREROUTING -d 192.0.2.1/32 -m comment --comment "generated for Incus network-forward incusbr0" -j DNAT --to-destination 10.0.0.2
+COMMIT
+`
+
+	assert.Equal(t, []string{
+		`ipv4 nat: -A POSTROUTING -s 10.0.0.0/24 ! -d 10.0.0.0/24 -m comment --comment "generated for Incus network incusbr0" -j MASQUERADE`,
+		`ipv4 filter: -A incus_acl_incusbr0 -j REJECT`,
+		`ipv4 filter: -A PREROUTING -d 192.0.2.1/32 -m comment --comment "generated for Incus network-forward incusbr0" -j DNAT --to-destination 10.0.0.2`,
+	}, iptablesSaveRules(4, output, "incus_acl_incusbr0", "Incus network incusbr0", "Incus network-forward incusbr0"))
+}
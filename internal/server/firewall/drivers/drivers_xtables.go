@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lxc/incus/v6/internal/server/project"
 	"github.com/lxc/incus/v6/shared/logger"
@@ -339,7 +340,8 @@ func (d Xtables) networkSetupForwardingPolicy(networkName string, ipVersion uint
 
 // networkSetupOutboundNAT configures outbound NAT.
 // If srcIP is non-nil then SNAT is used with the specified address, otherwise MASQUERADE mode is used.
-func (d Xtables) networkSetupOutboundNAT(networkName string, subnet *net.IPNet, srcIP net.IP, appendRule bool) error {
+// Traffic towards any of the excluded subnets is left untranslated.
+func (d Xtables) networkSetupOutboundNAT(networkName string, subnet *net.IPNet, srcIP net.IP, exclude []*net.IPNet, appendRule bool) error {
 	family := uint(4)
 	if subnet.IP.To4() == nil {
 		family = 6
@@ -360,6 +362,13 @@ func (d Xtables) networkSetupOutboundNAT(networkName string, subnet *net.IPNet,
 	comment := d.networkIPTablesComment(networkName)
 
 	if appendRule {
+		for _, excludeSubnet := range exclude {
+			err := d.iptablesAppend(family, comment, "nat", "POSTROUTING", "-s", subnet.String(), "-d", excludeSubnet.String(), "-j", "RETURN")
+			if err != nil {
+				return err
+			}
+		}
+
 		err := d.iptablesAppend(family, comment, "nat", "POSTROUTING", args...)
 		if err != nil {
 			return err
@@ -369,6 +378,14 @@ func (d Xtables) networkSetupOutboundNAT(networkName string, subnet *net.IPNet,
 		if err != nil {
 			return err
 		}
+
+		// Prepend the exclusions last so they end up ahead of the NAT rule.
+		for _, excludeSubnet := range exclude {
+			err := d.iptablesPrepend(family, comment, "nat", "POSTROUTING", "-s", subnet.String(), "-d", excludeSubnet.String(), "-j", "RETURN")
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -438,17 +455,55 @@ func (d Xtables) networkSetupDHCPv4Checksum(networkName string) error {
 	return d.iptablesPrepend(4, comment, "mangle", "POSTROUTING", "-o", networkName, "-p", "udp", "--dport", "68", "-j", "CHECKSUM", "--checksum-fill")
 }
 
+// networkSetupNAT64Filter drops the traffic for the NAT64 prefix that doesn't come from the specified networks.
+// The filter rules are removed when no network is specified.
+func (d Xtables) networkSetupNAT64Filter(prefix *net.IPNet, networkNames []string) error {
+	comment := "Incus NAT64"
+
+	err := d.iptablesClear(6, []string{comment}, "raw")
+	if err != nil {
+		return err
+	}
+
+	if len(networkNames) == 0 {
+		return nil
+	}
+
+	for _, networkName := range networkNames {
+		err = d.iptablesAppend(6, comment, "raw", "PREROUTING", "-i", networkName, "-d", prefix.String(), "-j", "ACCEPT")
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.iptablesAppend(6, comment, "raw", "PREROUTING", "-d", prefix.String(), "-j", "DROP")
+}
+
 // NetworkSetup configure network firewall.
 func (d Xtables) NetworkSetup(networkName string, opts Opts) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkSetup(networkName, opts)
+	})
+}
+
+// networkSetup is the implementation of NetworkSetup.
+func (d Xtables) networkSetup(networkName string, opts Opts) error {
 	if opts.SNATV4 != nil {
-		err := d.networkSetupOutboundNAT(networkName, opts.SNATV4.Subnet, opts.SNATV4.SNATAddress, opts.SNATV4.Append)
+		err := d.networkSetupOutboundNAT(networkName, opts.SNATV4.Subnet, opts.SNATV4.SNATAddress, opts.SNATV4.Exclude, opts.SNATV4.Append)
 		if err != nil {
 			return err
 		}
 	}
 
 	if opts.SNATV6 != nil {
-		err := d.networkSetupOutboundNAT(networkName, opts.SNATV6.Subnet, opts.SNATV6.SNATAddress, opts.SNATV6.Append)
+		err := d.networkSetupOutboundNAT(networkName, opts.SNATV6.Subnet, opts.SNATV6.SNATAddress, opts.SNATV6.Exclude, opts.SNATV6.Append)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.NAT64 != nil {
+		err := networkSetupNAT64(networkName, opts.NAT64, d.networkSetupNAT64Filter)
 		if err != nil {
 			return err
 		}
@@ -510,6 +565,13 @@ func (d Xtables) NetworkSetup(networkName string, opts Opts) error {
 
 // NetworkApplyACLRules applies ACL rules to the existing firewall chains.
 func (d Xtables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkApplyACLRules(networkName, rules)
+	})
+}
+
+// networkApplyACLRules is the implementation of NetworkApplyACLRules.
+func (d Xtables) networkApplyACLRules(networkName string, rules []ACLRule) error {
 	chain := fmt.Sprintf("%s_%s", iptablesChainACLFilterPrefix, networkName)
 
 	// Parse rules for both IP families before applying either family of rules.
@@ -750,6 +812,15 @@ func (d Xtables) aclRulePortToACLMatch(direction string, portCriteria ...string)
 // NetworkClear removes network rules from filter, mangle and nat tables.
 // If delete is true then network-specific chains are also removed.
 func (d Xtables) NetworkClear(networkName string, delete bool, ipVersions []uint) error {
+	unlock, err := networkRulesetLock(networkName)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	networkRulesetForget(networkName)
+
 	comments := []string{
 		d.networkIPTablesComment(networkName),
 		d.networkForwardIPTablesComment(networkName),
@@ -792,6 +863,13 @@ func (d Xtables) NetworkClear(networkName string, delete bool, ipVersions []uint
 				}
 			}
 		}
+
+		if ipVersion == 6 {
+			err = networkClearNAT64(networkName, d.networkSetupNAT64Filter)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -1507,6 +1585,13 @@ func (d Xtables) iptablesChainDelete(ipVersion uint, table string, chain string,
 
 // NetworkApplyForwards apply network address forward rules to firewall.
 func (d Xtables) NetworkApplyForwards(networkName string, rules []AddressForward) error {
+	return networkRulesetUpdate(networkName, d.networkRules, func() error {
+		return d.networkApplyForwards(networkName, rules)
+	})
+}
+
+// networkApplyForwards is the implementation of NetworkApplyForwards.
+func (d Xtables) networkApplyForwards(networkName string, rules []AddressForward) error {
 	// Validate all rules first.
 	for i, rule := range rules {
 		if rule.ListenAddress == nil {
@@ -1668,3 +1753,99 @@ func (d Xtables) NetworkApplyAddressSets(sets []AddressSet, nftTable string) err
 func (d Xtables) NetworkDeleteAddressSetsIfUnused(nftTable string) error {
 	return errors.New("Address sets aren't supported by xtables firewalling")
 }
+
+// NetworkACLCounters isn't supported under xtables.
+func (d Xtables) NetworkACLCounters() (map[string]ACLCounter, error) {
+	return nil, errors.New("ACL rule counters aren't supported by xtables firewalling")
+}
+
+// NetworkTraceFlow isn't supported under xtables.
+func (d Xtables) NetworkTraceFlow(flow TraceFlow, timeout time.Duration) ([]string, error) {
+	return nil, errors.New("Flow tracing isn't supported by xtables firewalling")
+}
+
+// NetworkRuleset returns the rules of the network in the kernel, compared with the ones last applied by Incus.
+func (d Xtables) NetworkRuleset(networkName string) (*NetworkRuleset, error) {
+	return networkRulesetCompare(networkName, d.networkRules)
+}
+
+// networkRules returns the rules of a network and of its address forwards, one per line prefixed with their
+// IP version and table.
+func (d Xtables) networkRules(networkName string) ([]string, error) {
+	comments := []string{
+		d.networkIPTablesComment(networkName),
+		d.networkForwardIPTablesComment(networkName),
+	}
+
+	aclFilterChain := fmt.Sprintf("%s_%s", iptablesChainACLFilterPrefix, networkName)
+
+	rules := []string{}
+	for _, ipVersion := range []uint{4, 6} {
+		cmd := "iptables-save"
+		if ipVersion == 6 {
+			// Detect kernels that lack IPv6 support.
+			if !util.PathExists("/proc/sys/net/ipv6") {
+				continue
+			}
+
+			cmd = "ip6tables-save"
+		}
+
+		// Check command exists.
+		_, err := exec.LookPath(cmd)
+		if err != nil {
+			continue
+		}
+
+		output, err := subprocess.RunCommand(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list IPv%d rules: %w", ipVersion, err)
+		}
+
+		rules = append(rules, iptablesSaveRules(ipVersion, output, aclFilterChain, comments...)...)
+	}
+
+	return rules, nil
+}
+
+// iptablesSaveRules parses the output of iptables-save and returns the rules of the given chain and the rules
+// having one of the given comments, prefixed with their IP version and table.
+func iptablesSaveRules(ipVersion uint, output string, chain string, comments ...string) []string {
+	rules := []string{}
+	table := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		if strings.HasPrefix(line, "*") {
+			table = strings.TrimPrefix(line, "*")
+			continue
+		}
+
+		if !strings.HasPrefix(line, "-A ") {
+			continue
+		}
+
+		found := strings.HasPrefix(line, fmt.Sprintf("-A %s ", chain))
+		for _, comment := range comments {
+			if strings.Contains(line, fmt.Sprintf("--comment \"%s %s\"", iptablesCommentPrefix, comment)) {
+				found = true
+				break
+			}
+		}
+
+		if found {
+			rules = append(rules, fmt.Sprintf("ipv%d %s: %s", ipVersion, table, line))
+		}
+	}
+
+	return rules
+}
+
+// NetworkApplyLoadBalancers isn't supported under xtables.
+func (d Xtables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	return errors.New("Load balancers aren't supported by xtables firewalling")
+}
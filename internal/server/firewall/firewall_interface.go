@@ -2,6 +2,7 @@ package firewall
 
 import (
 	"net"
+	"time"
 
 	"github.com/lxc/incus/v6/internal/server/firewall/drivers"
 )
@@ -24,8 +25,12 @@ type Firewall interface {
 	NetworkClear(networkName string, delete bool, ipVersions []uint) error
 	NetworkApplyACLRules(networkName string, rules []drivers.ACLRule) error
 	NetworkApplyForwards(networkName string, rules []drivers.AddressForward) error
+	NetworkApplyLoadBalancers(networkName string, rules []drivers.LoadBalancer) error
 	NetworkApplyAddressSets(sets []drivers.AddressSet, nftTable string) error
 	NetworkDeleteAddressSetsIfUnused(nftTable string) error
+	NetworkACLCounters() (map[string]drivers.ACLCounter, error)
+	NetworkTraceFlow(flow drivers.TraceFlow, timeout time.Duration) ([]string, error)
+	NetworkRuleset(networkName string) (*drivers.NetworkRuleset, error)
 
 	InstanceSetupBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4Nets []*net.IPNet, IPv6Nets []*net.IPNet, IPv4DNS []string, IPv6DNS []string, parentManaged bool, macFiltering bool, aclRules []drivers.ACLRule) error
 	InstanceClearBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4Nets []*net.IPNet, IPv6Nets []*net.IPNet) error
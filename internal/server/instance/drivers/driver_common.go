@@ -50,6 +50,12 @@ var (
 	muInstancesLastRestart sync.Mutex
 )
 
+// Track health check status of an instance.
+var (
+	instancesHealth   = map[int]api.InstanceStateHealth{}
+	muInstancesHealth sync.Mutex
+)
+
 // ErrExecCommandNotFound indicates the command is not found.
 var ErrExecCommandNotFound = api.StatusErrorf(http.StatusBadRequest, "Command not found")
 
@@ -153,6 +159,37 @@ func (d *common) ExpiryDate() time.Time {
 	return time.Time{}
 }
 
+// SetInstanceHealth records the health check status of an instance, to be included in its state.
+// A nil status clears it.
+func SetInstanceHealth(instanceID int, health *api.InstanceStateHealth) {
+	muInstancesHealth.Lock()
+	defer muInstancesHealth.Unlock()
+
+	if health == nil {
+		delete(instancesHealth, instanceID)
+		return
+	}
+
+	instancesHealth[instanceID] = *health
+}
+
+// health returns the health check status of the instance, or nil if it has no health check.
+func (d *common) health() *api.InstanceStateHealth {
+	if d.expandedConfig["healthcheck.type"] == "" {
+		return nil
+	}
+
+	muInstancesHealth.Lock()
+	defer muInstancesHealth.Unlock()
+
+	health, ok := instancesHealth[d.id]
+	if !ok {
+		return &api.InstanceStateHealth{Status: "unknown"}
+	}
+
+	return &health
+}
+
 func (d *common) shouldAutoRestart() bool {
 	if !util.IsTrue(d.expandedConfig["boot.autorestart"]) {
 		return false
@@ -1252,6 +1289,32 @@ func (d *common) deviceLoad(inst instance.Instance, deviceName string, rawConfig
 	return dev, err
 }
 
+// devicesNICRename moves the addresses reserved by the NICs in external IPAMs and the floating IPs attached to
+// them to the new name of the instance.
+// Failures are only logged as the affected NICs get new addresses on next start.
+func (d *common) devicesNICRename(inst instance.Instance, oldName string) {
+	for _, entry := range d.expandedDevices.Sorted() {
+		if entry.Config["type"] != "nic" {
+			continue
+		}
+
+		dev, err := d.deviceLoad(inst, entry.Name, entry.Config)
+		if err != nil {
+			continue
+		}
+
+		nic, ok := dev.(device.NICRename)
+		if !ok {
+			continue
+		}
+
+		err = nic.InstanceRename(oldName)
+		if err != nil {
+			d.logger.Warn("Failed moving NIC addresses to new instance name", logger.Ctx{"device": entry.Name, "err": err})
+		}
+	}
+}
+
 // deviceAdd loads a new device and calls its Add() function.
 func (d *common) deviceAdd(dev device.Device, instanceRunning bool) error {
 	l := d.logger.AddContext(logger.Ctx{"device": dev.Name(), "type": dev.Config()["type"]})
@@ -1692,3 +1755,70 @@ func (d *common) ClearLimitsCPUNodes(changedConfig []string) {
 
 	d.localConfig["volatile.cpu.nodes"] = ""
 }
+
+// networkPath returns the network path of the instance NICs, optionally limited to a single NIC.
+func (d *common) networkPath(inst instance.Instance, deviceName string) ([]api.InstanceNetworkPath, error) {
+	paths := []api.InstanceNetworkPath{}
+
+	if deviceName != "" {
+		m, ok := d.expandedDevices[deviceName]
+		if !ok || m["type"] != "nic" {
+			return nil, api.StatusErrorf(http.StatusNotFound, "Instance doesn't have a NIC device named %q", deviceName)
+		}
+	}
+
+	for _, entry := range d.expandedDevices.Sorted() {
+		if entry.Config["type"] != "nic" || (deviceName != "" && entry.Name != deviceName) {
+			continue
+		}
+
+		dev, err := d.deviceLoad(inst, entry.Name, entry.Config)
+		if err != nil {
+			if errors.Is(err, device.ErrUnsupportedDevType) {
+				continue // Skip unsupported device (allows for mixed instance type profiles).
+			}
+
+			return nil, fmt.Errorf("Failed loading device %q: %w", entry.Name, err)
+		}
+
+		path := api.InstanceNetworkPath{Device: entry.Name}
+
+		nic, ok := dev.(device.NICPath)
+		if ok {
+			path.Hops, err = nic.NetworkPath()
+			if err != nil {
+				return nil, fmt.Errorf("Failed getting network path for %q: %w", entry.Name, err)
+			}
+		} else {
+			// Fallback to the NIC and its parent for NIC types without path support.
+			path.Hops = []api.InstanceNetworkPathHop{{Type: "nic", Name: entry.Name, Network: entry.Config["network"]}}
+			if entry.Config["parent"] != "" {
+				path.Hops = append(path.Hops, api.InstanceNetworkPathHop{Type: "parent", Name: entry.Config["parent"]})
+			}
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// networkTrace traces a flow originating from one of the instance NICs.
+func (d *common) networkTrace(inst instance.Instance, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	m, ok := d.expandedDevices[req.Device]
+	if !ok || m["type"] != "nic" {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Instance doesn't have a NIC device named %q", req.Device)
+	}
+
+	dev, err := d.deviceLoad(inst, req.Device, m)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading device %q: %w", req.Device, err)
+	}
+
+	nic, ok := dev.(device.NICPath)
+	if !ok {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "NIC %q doesn't support flow tracing", req.Device)
+	}
+
+	return nic.NetworkTrace(req)
+}
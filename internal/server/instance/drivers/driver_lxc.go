@@ -3842,6 +3842,8 @@ func (d *lxc) renderState(statusCode api.StatusCode, hostInterfaces []net.Interf
 		if err != nil {
 			return nil, err
 		}
+
+		status.Health = d.health()
 	}
 
 	status.Disk = d.diskState()
@@ -3851,6 +3853,16 @@ func (d *lxc) renderState(statusCode api.StatusCode, hostInterfaces []net.Interf
 	return &status, nil
 }
 
+// NetworkPath returns the network path of the instance NICs.
+func (d *lxc) NetworkPath(deviceName string) ([]api.InstanceNetworkPath, error) {
+	return d.networkPath(d, deviceName)
+}
+
+// NetworkTrace traces a flow originating from one of the instance NICs.
+func (d *lxc) NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	return d.networkTrace(d, req)
+}
+
 // RenderState renders just the running state of the instance.
 func (d *lxc) RenderState(hostInterfaces []net.Interface) (*api.InstanceState, error) {
 	return d.renderState(d.statusCode(), hostInterfaces)
@@ -4334,6 +4346,13 @@ func (d *lxc) Rename(newName string, applyTemplateTrigger bool) error {
 		return errors.New("Renaming of running instance not allowed")
 	}
 
+	if !d.IsSnapshot() {
+		err = instance.ValidDependenciesRename(d.state, d.project.Name, oldName, newName)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Clean things up.
 	d.cleanup()
 
@@ -4476,6 +4495,9 @@ func (d *lxc) Rename(newName string, applyTemplateTrigger bool) error {
 		if err != nil {
 			return err
 		}
+
+		// Follow the rename in the external IPAMs and floating IPs.
+		d.devicesNICRename(d, oldName)
 	}
 
 	// Update the backup file.
@@ -4529,7 +4551,7 @@ func (d *lxc) CGroupSet(key string, value string) error {
 // Update applies updated config.
 func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation
-	op, err := operationlock.CreateWaitGet(d.Project().Name, d.Name(), d.op, operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionCreate, operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project().Name, d.Name(), d.op, operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionCreate, operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionAutoscale}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
@@ -4778,6 +4800,14 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 			return fmt.Errorf("Invalid expanded config: %w", err)
 		}
 
+		// Validate the instance dependencies.
+		if d.expandedConfig["boot.depends_on"] != oldExpandedConfig["boot.depends_on"] {
+			err = instance.ValidDependencies(d.state, d.project.Name, d.name, d.expandedConfig)
+			if err != nil {
+				return fmt.Errorf("Invalid dependencies: %w", err)
+			}
+		}
+
 		// Do full expanded validation of the devices diff.
 		err = instance.ValidDevices(d.state, d.project, d.Type(), d.localDevices, d.expandedDevices)
 		if err != nil {
@@ -8986,6 +9016,76 @@ func (d *lxc) Metrics(hostInterfaces []net.Interface) (*metrics.MetricSet, error
 	return out, nil
 }
 
+// UsageMetrics returns the current resource usage of the instance.
+func (d *lxc) UsageMetrics() (*metrics.Metrics, error) {
+	if !d.IsRunning() {
+		return nil, ErrInstanceIsStopped
+	}
+
+	cc, err := d.initLXC(false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load cgroup abstraction
+	cg, err := d.cgroup(cc, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &metrics.Metrics{}
+
+	// Get CPU stats.
+	usage, err := cg.GetCPUAcctUsageAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for cpu, stats := range usage {
+		out.CPU = append(out.CPU, metrics.CPUMetrics{
+			CPU:           strconv.Itoa(int(cpu)),
+			SecondsUser:   float64(stats.User) / 1000000000,
+			SecondsSystem: float64(stats.System) / 1000000000,
+		})
+	}
+
+	CPUs, err := cg.GetEffectiveCPUs()
+	if err != nil {
+		return nil, err
+	}
+
+	out.CPUs = CPUs
+
+	// Get memory stats.
+	memoryLimit, err := cg.GetEffectiveMemoryLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	memoryUsage, err := cg.GetMemoryUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	memoryCached := int64(0)
+
+	memStats, err := cg.GetMemoryStats()
+	if err != nil {
+		d.logger.Warn("Failed to get memory stats", logger.Ctx{"err": err})
+	} else {
+		memoryCached = int64(memStats["cache"])
+	}
+
+	if memoryLimit > 0 {
+		out.Memory.CachedBytes = uint64(memoryCached)
+		out.Memory.MemTotalBytes = uint64(memoryLimit)
+		out.Memory.MemAvailableBytes = uint64(max(memoryLimit-memoryUsage+memoryCached, 0))
+		out.Memory.MemFreeBytes = uint64(max(memoryLimit-memoryUsage, 0))
+	}
+
+	return out, nil
+}
+
 func (d *lxc) getFSStats() (*metrics.MetricSet, error) {
 	type mountInfo struct {
 		Mountpoint string
@@ -11,6 +11,7 @@ import (
 	"database/sql"
 	"embed"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -348,6 +350,9 @@ type qemu struct {
 	// Stateful migration streams.
 	migrationReceiveStateful map[string]io.ReadWriteCloser
 
+	// Result of an incoming post-copy migration, set when the migration source agreed to use post-copy.
+	migrationReceivePostcopy chan error
+
 	// Keep a reference to the console socket when switching backends, so we can properly cleanup when switching back to a ring buffer.
 	consoleSocket     *net.UnixListener
 	consoleSocketFile *os.File
@@ -935,6 +940,46 @@ func (d *qemu) restoreStateHandle(ctx context.Context, monitor *qmp.Monitor, f *
 	return nil
 }
 
+// restoreStatePostcopy receives the VM state from a migration source which may switch to post-copy mode.
+// It returns as soon as the VM can be resumed, with the remaining memory pages being fetched from the source in the
+// background. The final result of the migration is then sent to d.migrationReceivePostcopy.
+func (d *qemu) restoreStatePostcopy(monitor *qmp.Monitor, stateConn io.ReadWriteCloser) error {
+	err := monitor.MigrateSetCapabilities(map[string]bool{"postcopy-ram": true})
+	if err != nil {
+		return fmt.Errorf("Failed setting migration capabilities: %w", err)
+	}
+
+	stateFile, err := d.migrationStateSocket(stateConn)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = stateFile.Close() }()
+
+	err = monitor.SendFile("migration", stateFile)
+	if err != nil {
+		return err
+	}
+
+	err = monitor.MigrateIncomingPostcopy(context.Background(), "migration")
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := monitor.MigrateWait("completed")
+		if err != nil {
+			d.logger.Error("Post-copy migration failed", logger.Ctx{"err": err})
+		} else {
+			d.logger.Debug("Post-copy migration completed")
+		}
+
+		d.migrationReceivePostcopy <- err
+	}()
+
+	return nil
+}
+
 // restoreState restores VM state from state file or from migration source if d.migrationReceiveStateful set.
 func (d *qemu) restoreState(monitor *qmp.Monitor) error {
 	if d.migrationReceiveStateful != nil {
@@ -979,6 +1024,18 @@ func (d *qemu) restoreState(monitor *qmp.Monitor) error {
 
 		// Receive checkpoint from QEMU process on source.
 		d.logger.Debug("Stateful migration checkpoint receive starting")
+
+		if d.migrationReceivePostcopy != nil {
+			err := d.restoreStatePostcopy(monitor, stateConn)
+			if err != nil {
+				return fmt.Errorf("Failed restoring checkpoint from source: %w", err)
+			}
+
+			d.logger.Debug("Stateful migration checkpoint receive switched to post-copy")
+
+			return nil
+		}
+
 		pipeRead, pipeWrite, err := os.Pipe()
 		if err != nil {
 			return err
@@ -1059,6 +1116,44 @@ func (d *qemu) saveStateHandle(monitor *qmp.Monitor, f *os.File) error {
 	return nil
 }
 
+// migrationStateSocket returns one end of a socket pair to pass to QEMU as the migration stream, with the other end
+// proxied to the migration state connection. Unlike a pipe this works in both directions, which post-copy migration
+// needs for the target to request the memory pages it's missing from the source.
+func (d *qemu) migrationStateSocket(stateConn io.ReadWriteCloser) (*os.File, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating migration socket pair: %w", err)
+	}
+
+	qemuFile := os.NewFile(uintptr(fds[0]), "migration")
+	proxyFile := os.NewFile(uintptr(fds[1]), "migration-proxy")
+
+	proxyConn, err := net.FileConn(proxyFile)
+	_ = proxyFile.Close()
+	if err != nil {
+		_ = qemuFile.Close()
+		return nil, fmt.Errorf("Failed setting up migration socket: %w", err)
+	}
+
+	go func() {
+		_, _ = io.Copy(stateConn, proxyConn)
+		_ = proxyConn.Close()
+	}()
+
+	go func() {
+		_, _ = io.Copy(proxyConn, stateConn)
+		_ = proxyConn.Close()
+	}()
+
+	return qemuFile, nil
+}
+
+// migrationPostcopySupported checks whether the kernel supports userfaultfd, which QEMU uses on the target of a
+// post-copy migration to catch guest accesses to the memory pages not yet received.
+func migrationPostcopySupported() bool {
+	return util.PathExists("/proc/sys/vm/unprivileged_userfaultfd") || util.PathExists("/dev/userfaultfd")
+}
+
 // saveState dumps the current VM state to the state file.
 // Once dumped, the VM is in a paused state and it's up to the caller to resume or kill it.
 func (d *qemu) saveState(monitor *qmp.Monitor) error {
@@ -1137,6 +1232,25 @@ func (d *qemu) validateStartup(stateful bool, statusCode api.StatusCode) error {
 		return errors.New("Secure boot can't be enabled while CSM is turned on. Please set security.secureboot=false on the instance")
 	}
 
+	// Ensure the configuration is compatible with Intel TDX.
+	if util.IsTrue(d.expandedConfig["security.tdx"]) {
+		if util.IsTrueOrEmpty(d.expandedConfig["security.secureboot"]) {
+			return errors.New("Secure boot can't be enabled with Intel TDX. Please set security.secureboot=false on the instance")
+		}
+
+		if util.IsTrue(d.expandedConfig["security.csm"]) {
+			return errors.New("CSM can't be enabled with Intel TDX")
+		}
+
+		if util.IsTrue(d.expandedConfig["security.sev"]) {
+			return errors.New("AMD SEV and Intel TDX can't be enabled at the same time")
+		}
+
+		if util.IsTrue(d.expandedConfig["migration.stateful"]) {
+			return errors.New("Stateful migration isn't supported with Intel TDX")
+		}
+	}
+
 	// gendoc:generate(entity=image, group=requirements, key=requirements.cdrom_agent)
 	//
 	// ---
@@ -2107,6 +2221,15 @@ func (d *qemu) start(stateful bool, op *operationlock.InstanceOperation) error {
 		return err
 	}
 
+	// The VM isn't hibernated anymore, whether its state was restored or discarded.
+	if internalInstance.Hibernated(d.localConfig) {
+		err = d.VolatileSet(map[string]string{"volatile.last_state.hibernated": ""})
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	reverter.Success()
 
 	// Post-start startup hook
@@ -2227,6 +2350,34 @@ func (d *qemu) setupSEV(fdFiles *[]*os.File) (*qemuSevOpts, error) {
 	return sevOpts, nil
 }
 
+// setupTDX checks that Intel TDX can be used and returns the path to the TDX firmware.
+func (d *qemu) setupTDX() (string, error) {
+	if d.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+		return "", errors.New("Intel TDX support is only available on x86_64 systems")
+	}
+
+	// Get the QEMU features to check if Intel TDX is supported.
+	info := DriverStatuses()[instancetype.VM].Info
+	_, tdxFound := info.Features["tdx"]
+	if !tdxFound {
+		return "", errors.New("Intel TDX is not supported by the host")
+	}
+
+	// TDX guests can't use pflash, so boot a stateless firmware instead.
+	firmwares, err := edk2.GetArchitectureFirmwarePairsForUsage(d.architecture, edk2.TDX)
+	if err != nil {
+		return "", err
+	}
+
+	for _, firmware := range firmwares {
+		if util.PathExists(firmware.Code) {
+			return firmware.Code, nil
+		}
+	}
+
+	return "", fmt.Errorf("Unable to locate the Intel TDX firmware: %+v", firmwares)
+}
+
 // getAgentConnectionInfo returns the connection info the agent needs to connect to the server.
 func (d *qemu) getAgentConnectionInfo() (*agentAPI.API10Put, error) {
 	addr := d.state.Endpoints.VsockAddress()
@@ -2300,6 +2451,17 @@ func (d *qemu) AgentCertificate() *x509.Certificate {
 	return cert
 }
 
+// AgentRunning returns whether the agent is running inside of the VM.
+func (d *qemu) AgentRunning() bool {
+	if !d.IsRunning() {
+		return false
+	}
+
+	_, err := d.getAgentClient()
+
+	return err == nil
+}
+
 func (d *qemu) architectureSupportsUEFI(arch int) bool {
 	return slices.Contains([]int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}, arch)
 }
@@ -2366,6 +2528,23 @@ func (d *qemu) setupNvram() error {
 		return err
 	}
 
+	// Enroll the project's default Secure Boot keys.
+	if !util.IsTrue(d.expandedConfig["security.csm"]) && util.IsTrueOrEmpty(d.expandedConfig["security.secureboot"]) {
+		keys := edk2.SecureBootKeys{
+			PK:  d.project.Config["secureboot.pk"],
+			KEK: d.project.Config["secureboot.kek"],
+			DB:  d.project.Config["secureboot.db"],
+			DBX: d.project.Config["secureboot.dbx"],
+		}
+
+		if keys != (edk2.SecureBootKeys{}) {
+			err = d.enrollUEFIKeys(filepath.Join(d.Path(), efiVarsName), keys)
+			if err != nil {
+				return fmt.Errorf("Failed enrolling the project's Secure Boot keys: %w", err)
+			}
+		}
+	}
+
 	nvramPath := d.nvramPath()
 
 	// Handle the case where the firmware vars filename matches our internal one.
@@ -3526,7 +3705,7 @@ func (d *qemu) generateQemuConfig(machineDefinition string, cpuType string, cpuI
 	// Allow disabling the UEFI firmware.
 	if slices.Contains(rawOptions, "-bios") || slices.Contains(rawOptions, "-kernel") {
 		d.logger.Warn("Starting VM without default firmware (-bios or -kernel in raw.qemu)")
-	} else if d.architectureSupportsUEFI(d.architecture) {
+	} else if d.architectureSupportsUEFI(d.architecture) && !util.IsTrue(d.expandedConfig["security.tdx"]) {
 		// Open the UEFI NVRAM file and pass it via file descriptor to QEMU.
 		// This is so the QEMU process can still read/write the file after it has dropped its user privs.
 		nvRAMFile, err := os.Open(d.nvramPath())
@@ -3771,6 +3950,29 @@ func (d *qemu) generateQemuConfig(machineDefinition string, cpuType string, cpuI
 		}
 	}
 
+	// If user has requested Intel TDX, check if supported and add to QEMU config.
+	if util.IsTrue(d.expandedConfig["security.tdx"]) {
+		tdxFirmware, err := d.setupTDX()
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range conf {
+			if conf[i].Name == "machine" {
+				conf[i].Entries["confidential-guest-support"] = "tdx0"
+				conf[i].Entries["kernel-irqchip"] = "split"
+
+				if !slices.Contains(rawOptions, "-bios") {
+					conf[i].Entries["firmware"] = tdxFirmware
+				}
+
+				break
+			}
+		}
+
+		conf = append(conf, qemuTDX()...)
+	}
+
 	if util.IsTrue(d.expandedConfig["security.csm"]) {
 		// Allocate a regular entry to keep things aligned normally (avoid NICs getting a different name).
 		_, _, _ = bus.allocate(busFunctionGroupNone)
@@ -4124,7 +4326,9 @@ func (d *qemu) addCPUMemoryConfig(conf *[]cfg.Section, cpuType string, cpuInfo *
 	cpuPhysBits := uint64(39)
 
 	limitsMemoryHotplug := d.expandedConfig["limits.memory.hotplug"]
-	memoryHotplugEnabled := !util.IsFalse(limitsMemoryHotplug)
+
+	// Intel TDX guest memory can't be hotplugged.
+	memoryHotplugEnabled := !util.IsFalse(limitsMemoryHotplug) && !util.IsTrue(d.expandedConfig["security.tdx"])
 
 	if (cpuType == "host" || cpuType == "kvm64") && memoryHotplugEnabled {
 		if !util.IsTrueOrEmpty(limitsMemoryHotplug) {
@@ -5512,6 +5716,269 @@ func (d *qemu) Stop(stateful bool) error {
 	return nil
 }
 
+// Hibernate saves the VM memory and device state to the instance volume and stops the VM, freeing its host
+// resources. The VM then resumes from the saved state on its next stateful start.
+func (d *qemu) Hibernate() error {
+	if !d.IsRunning() {
+		return ErrInstanceIsStopped
+	}
+
+	if !d.CanLiveMigrate() {
+		return errors.New("Hibernation requires migration.stateful to be set to true")
+	}
+
+	err := d.Stop(true)
+	if err != nil {
+		return err
+	}
+
+	// Record the hibernation so the VM gets resumed on host boot.
+	err = d.VolatileSet(map[string]string{"volatile.last_state.hibernated": "true"})
+	if err != nil {
+		return fmt.Errorf("Failed recording hibernation: %w", err)
+	}
+
+	d.state.Events.SendLifecycle(d.project.Name, lifecycle.InstanceHibernated.Event(d, nil))
+
+	return nil
+}
+
+// RefreshIdentity has the agent of a VM restored from another instance's state update the guest hostname,
+// machine-id and MAC addresses (keyed by their previous value). It returns once the agent has applied the change.
+func (d *qemu) RefreshIdentity(hwaddrs map[string]string) error {
+	// Give the agent a chance to reconnect after the restore.
+	for range 30 {
+		if d.AgentRunning() {
+			break
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	if !d.AgentRunning() {
+		return errQemuAgentOffline
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agentArgs := &incus.ConnectionArgs{
+		SkipGetEvents: true,
+		SkipGetServer: true,
+	}
+
+	agent, err := incus.ConnectIncusHTTP(agentArgs, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to the agent", logger.Ctx{"err": err})
+		return errors.New("Failed to connect to the agent")
+	}
+
+	defer agent.Disconnect()
+
+	_, _, err = agent.RawQuery("POST", "/1.0/identity", agentAPI.IdentityPost{Hostname: d.Name(), HWAddrs: hwaddrs}, "")
+	if err != nil {
+		return fmt.Errorf("Failed refreshing the guest identity: %w", err)
+	}
+
+	return nil
+}
+
+// AttestationReport retrieves an attestation report from a confidential VM through its agent.
+func (d *qemu) AttestationReport(reportData []byte) (*api.InstanceAttestation, error) {
+	if !util.IsTrue(d.expandedConfig["security.tdx"]) && !util.IsTrue(d.expandedConfig["security.sev"]) {
+		return nil, errors.New("Attestation reports are only available for confidential virtual machines")
+	}
+
+	if !d.IsRunning() {
+		return nil, ErrInstanceIsStopped
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		return nil, err
+	}
+
+	agentArgs := &incus.ConnectionArgs{
+		SkipGetEvents: true,
+		SkipGetServer: true,
+	}
+
+	agent, err := incus.ConnectIncusHTTP(agentArgs, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to the agent", logger.Ctx{"err": err})
+		return nil, errors.New("Failed to connect to the agent")
+	}
+
+	defer agent.Disconnect()
+
+	resp, _, err := agent.RawQuery("POST", "/1.0/attestation", api.InstanceAttestationPost{ReportData: base64.StdEncoding.EncodeToString(reportData)}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	attestation := api.InstanceAttestation{}
+	err = resp.MetadataAsStruct(&attestation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attestation, nil
+}
+
+// uefiVarsAccess mounts the instance's config volume and returns the path to the UEFI variable store,
+// generating it if the instance was never started.
+func (d *qemu) uefiVarsAccess() (string, func(), error) {
+	if !d.architectureSupportsUEFI(d.architecture) {
+		return "", nil, errors.New("UEFI isn't supported on this architecture")
+	}
+
+	if util.IsTrue(d.expandedConfig["security.tdx"]) {
+		return "", nil, errors.New("UEFI variables aren't persisted for Intel TDX virtual machines")
+	}
+
+	cleanup := func() {}
+
+	// The config volume is already mounted while the instance is running.
+	if !d.IsRunning() {
+		_, err := d.mount()
+		if err != nil {
+			return "", nil, err
+		}
+
+		cleanup = func() { _ = d.unmount() }
+	}
+
+	if !util.PathExists(d.nvramPath()) {
+		err := d.setupNvram()
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+
+	return d.nvramPath(), cleanup, nil
+}
+
+// enrollUEFIKeys enrolls Secure Boot keys in the UEFI variable store at the provided path.
+func (d *qemu) enrollUEFIKeys(path string, keys edk2.SecureBootKeys) error {
+	vars, err := edk2.ReadVariables(path)
+	if err != nil {
+		return err
+	}
+
+	vars, err = edk2.EnrollSecureBootKeys(vars, keys)
+	if err != nil {
+		return err
+	}
+
+	return edk2.WriteVariables(path, vars)
+}
+
+// UEFIVariables returns the content of the UEFI variable store.
+func (d *qemu) UEFIVariables() (*api.InstanceUEFIVars, error) {
+	path, cleanup, err := d.uefiVarsAccess()
+	if err != nil {
+		return nil, err
+	}
+
+	defer cleanup()
+
+	vars, err := edk2.ReadVariables(path)
+	if err != nil {
+		return nil, err
+	}
+
+	uefiVars := api.InstanceUEFIVars{Variables: make(map[string]api.InstanceUEFIVariable, len(vars))}
+	for _, variable := range vars {
+		uefiVars.Variables[variable.Key()] = api.InstanceUEFIVariable{
+			Data:       hex.EncodeToString(variable.Data),
+			Attributes: variable.Attributes,
+			Timestamp:  variable.Timestamp,
+		}
+	}
+
+	return &uefiVars, nil
+}
+
+// UEFIVariablesUpdate replaces the content of the UEFI variable store.
+func (d *qemu) UEFIVariablesUpdate(uefiVars api.InstanceUEFIVars) error {
+	if d.IsRunning() {
+		return errors.New("UEFI variables can only be changed while the instance is stopped")
+	}
+
+	newVars := make([]edk2.Variable, 0, len(uefiVars.Variables))
+	for key, value := range uefiVars.Variables {
+		name, vendorGUID, err := edk2.ParseVariableKey(key)
+		if err != nil {
+			return err
+		}
+
+		data, err := hex.DecodeString(value.Data)
+		if err != nil {
+			return fmt.Errorf("Invalid data for variable %q: %w", key, err)
+		}
+
+		newVars = append(newVars, edk2.Variable{
+			Name:       name,
+			VendorGUID: vendorGUID,
+			Attributes: value.Attributes,
+			Timestamp:  value.Timestamp,
+			Data:       data,
+		})
+	}
+
+	path, cleanup, err := d.uefiVarsAccess()
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	oldVars, err := edk2.ReadVariables(path)
+	if err != nil {
+		return err
+	}
+
+	// Keep the existing variables in their current order and append the new ones.
+	vars := make([]edk2.Variable, 0, len(newVars))
+	for _, oldVar := range oldVars {
+		idx := slices.IndexFunc(newVars, func(v edk2.Variable) bool { return v.Key() == oldVar.Key() })
+		if idx < 0 {
+			continue
+		}
+
+		vars = append(vars, newVars[idx])
+		newVars = slices.Delete(newVars, idx, idx+1)
+	}
+
+	slices.SortFunc(newVars, func(a edk2.Variable, b edk2.Variable) int { return strings.Compare(a.Key(), b.Key()) })
+	vars = append(vars, newVars...)
+
+	return edk2.WriteVariables(path, vars)
+}
+
+// UEFIKeysEnroll enrolls custom Secure Boot keys in the UEFI variable store.
+func (d *qemu) UEFIKeysEnroll(keys api.InstanceUEFIKeysPost) error {
+	if d.IsRunning() {
+		return errors.New("Secure Boot keys can only be enrolled while the instance is stopped")
+	}
+
+	if util.IsTrue(d.expandedConfig["security.csm"]) || util.IsFalse(d.expandedConfig["security.secureboot"]) {
+		return errors.New("Secure Boot keys can only be enrolled when Secure Boot is enabled")
+	}
+
+	path, cleanup, err := d.uefiVarsAccess()
+	if err != nil {
+		return err
+	}
+
+	defer cleanup()
+
+	return d.enrollUEFIKeys(path, edk2.SecureBootKeys{PK: keys.PK, KEK: keys.KEK, DB: keys.DB, DBX: keys.DBX})
+}
+
 // Unfreeze restores the instance to running.
 func (d *qemu) Unfreeze() error {
 	// Connect to the monitor.
@@ -5742,6 +6209,13 @@ func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 		return errors.New("Renaming of running instance not allowed")
 	}
 
+	if !d.IsSnapshot() {
+		err = instance.ValidDependenciesRename(d.state, d.project.Name, oldName, newName)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Clean things up.
 	d.cleanup()
 
@@ -5878,6 +6352,9 @@ func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 		if err != nil {
 			return err
 		}
+
+		// Follow the rename in the external IPAMs and floating IPs.
+		d.devicesNICRename(d, oldName)
 	}
 
 	// Update the backup file.
@@ -5968,7 +6445,7 @@ func (d *qemu) detachDisk(name string) error {
 // Update the instance config.
 func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation.
-	op, err := operationlock.CreateWaitGet(d.Project().Name, d.Name(), d.op, operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project().Name, d.Name(), d.op, operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionAutoscale}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
@@ -6192,6 +6669,14 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 			return fmt.Errorf("Invalid expanded config: %w", err)
 		}
 
+		// Validate the instance dependencies.
+		if d.expandedConfig["boot.depends_on"] != oldExpandedConfig["boot.depends_on"] {
+			err = instance.ValidDependencies(d.state, d.project.Name, d.name, d.expandedConfig)
+			if err != nil {
+				return fmt.Errorf("Invalid dependencies: %w", err)
+			}
+		}
+
 		// Do full expanded validation of the devices diff.
 		err = instance.ValidDevices(d.state, d.project, d.Type(), d.localDevices, d.expandedDevices)
 		if err != nil {
@@ -6245,10 +6730,13 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		liveUpdateKeyPrefixes := []string{
+			"autoscale.",
 			"boot.",
 			"cloud-init.",
 			"environment.",
+			"healthcheck.",
 			"image.",
+			"schedule.",
 			"snapshots.",
 			"user.",
 			"volatile.",
@@ -6534,7 +7022,7 @@ func (d *qemu) updateMemoryLimit(newLimit string) error {
 	if curSizeMB == newSizeMB {
 		return nil
 	} else if baseSizeMB < newSizeMB {
-		if util.IsFalse(d.expandedConfig["limits.memory.hotplug"]) {
+		if util.IsFalse(d.expandedConfig["limits.memory.hotplug"]) || util.IsTrue(d.expandedConfig["security.tdx"]) {
 			return fmt.Errorf("Memory hotplug feature is disabled")
 		}
 
@@ -7255,6 +7743,11 @@ func (d *qemu) MigrateSend(args instance.MigrateSendArgs) error {
 	// fulfil the "live" part of the request, albeit with longer pause of the instance during the process.
 	if args.Live {
 		offerHeader.Criu = migration.CRIUType_VM_QEMU.Enum()
+
+		// Offer switching to post-copy mode if requested for this migration or by the instance.
+		if args.Postcopy || util.IsTrue(d.expandedConfig["migration.postcopy"]) {
+			offerHeader.Postcopy = proto.Bool(true)
+		}
 	}
 
 	// Send offer to target.
@@ -7317,12 +7810,18 @@ func (d *qemu) MigrateSend(args instance.MigrateSendArgs) error {
 	// Detect whether the far side has chosen to use QEMU to QEMU live state transfer mode, and if so then
 	// wait for the connection to be established.
 	var stateConn io.ReadWriteCloser
+	var postcopy bool
 	if args.Live && respHeader.Criu != nil && *respHeader.Criu == migration.CRIUType_VM_QEMU {
 		stateConn, err = args.StateConn(connectionsCtx)
 		if err != nil {
 			op.Done(err)
 			return err
 		}
+
+		postcopy = respHeader.GetPostcopy()
+		if offerHeader.GetPostcopy() && !postcopy {
+			d.logger.Warn("Migration target doesn't support post-copy, falling back to pre-copy")
+		}
 	}
 
 	g, ctx := errgroup.WithContext(context.Background())
@@ -7389,7 +7888,7 @@ func (d *qemu) MigrateSend(args instance.MigrateSendArgs) error {
 				defer instanceRefClear(d)
 			}
 
-			err = d.migrateSendLive(pool, args.ClusterMoveSourceName, args.StoragePool, blockSize, filesystemConn, stateConn, volSourceArgs)
+			err = d.migrateSendLive(pool, args.ClusterMoveSourceName, args.StoragePool, blockSize, filesystemConn, stateConn, volSourceArgs, postcopy)
 			if err != nil {
 				return err
 			}
@@ -7428,7 +7927,7 @@ func (d *qemu) MigrateSend(args instance.MigrateSendArgs) error {
 }
 
 // migrateSendLive performs live migration send process.
-func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName string, storagePool string, rootDiskSize int64, filesystemConn io.ReadWriteCloser, stateConn io.ReadWriteCloser, volSourceArgs *localMigration.VolumeSourceArgs) error {
+func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName string, storagePool string, rootDiskSize int64, filesystemConn io.ReadWriteCloser, stateConn io.ReadWriteCloser, volSourceArgs *localMigration.VolumeSourceArgs, postcopy bool) error {
 	monitor, err := d.qmpConnect()
 	if err != nil {
 		return err
@@ -7444,6 +7943,20 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 
 	reverter := revert.New()
 
+	// Once the guest has resumed on the target in post-copy mode, the source no longer has its latest state.
+	// Added first so that it runs after the other revert steps, which merge back the storage snapshot.
+	var postcopySwitched atomic.Bool
+	reverter.Add(func() {
+		if !postcopySwitched.Load() {
+			return
+		}
+
+		err := d.Stop(false)
+		if err != nil && !errors.Is(err, ErrInstanceIsStopped) {
+			d.logger.Error("Failed stopping instance after post-copy migration failure", logger.Ctx{"err": err})
+		}
+	})
+
 	// Non-shared storage snapshot setup.
 	if !sameSharedStorage {
 		// Setup migration capabilities.
@@ -7461,6 +7974,10 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 			"zero-blocks": true,
 		}
 
+		if postcopy {
+			capabilities["postcopy-ram"] = true
+		}
+
 		err = monitor.MigrateSetCapabilities(capabilities)
 		if err != nil {
 			return fmt.Errorf("Failed setting migration capabilities: %w", err)
@@ -7562,12 +8079,18 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 
 		d.logger.Debug("Setup temporary migration storage snapshot")
 	} else {
+		defer reverter.Fail()
+
 		// Still set some options for shared storage.
 		capabilities := map[string]bool{
 			// Automatically throttle down the guest to speed up convergence of RAM migration.
 			"auto-converge": true,
 		}
 
+		if postcopy {
+			capabilities["postcopy-ram"] = true
+		}
+
 		err = monitor.MigrateSetCapabilities(capabilities)
 		if err != nil {
 			return fmt.Errorf("Failed setting migration capabilities: %w", err)
@@ -7709,23 +8232,108 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 	d.logger.Debug("Stateful migration checkpoint send starting")
 
 	// Send checkpoint to QEMU process on target. This will pause the guest OS (if not already paused).
-	pipeRead, pipeWrite, err := os.Pipe()
-	if err != nil {
-		return err
-	}
+	var stateFile *os.File
+	if postcopy {
+		stateFile, err = d.migrationStateSocket(stateConn)
+		if err != nil {
+			return err
+		}
+	} else {
+		pipeRead, pipeWrite, err := os.Pipe()
+		if err != nil {
+			return err
+		}
 
-	defer func() {
-		_ = pipeRead.Close()
-		_ = pipeWrite.Close()
-	}()
+		defer func() { _ = pipeRead.Close() }()
 
-	go func() { _, _ = io.Copy(stateConn, pipeRead) }()
+		go func() { _, _ = io.Copy(stateConn, pipeRead) }()
 
-	err = d.saveStateHandle(monitor, pipeWrite)
+		stateFile = pipeWrite
+	}
+
+	defer func() { _ = stateFile.Close() }()
+
+	err = d.saveStateHandle(monitor, stateFile)
 	if err != nil {
 		return fmt.Errorf("Failed starting state transfer to target: %w", err)
 	}
 
+	// Switch to post-copy mode once the configured number of pre-copy passes is done.
+	postcopyStarted := make(chan struct{})
+	if postcopy {
+		passes := int64(2)
+		if d.expandedConfig["migration.postcopy.passes"] != "" {
+			passes, err = strconv.ParseInt(d.expandedConfig["migration.postcopy.passes"], 10, 64)
+			if err != nil {
+				return fmt.Errorf("Invalid migration.postcopy.passes: %w", err)
+			}
+		}
+
+		chPostcopy := make(chan struct{})
+		defer close(chPostcopy)
+
+		go func() {
+			for {
+				select {
+				case <-chPostcopy:
+					return
+
+				case <-time.After(time.Second):
+				}
+
+				progress, err := monitor.QueryMigrate()
+				if err != nil {
+					return
+				}
+
+				// Stop once the migration has ended or converged on its own.
+				if progress.Status != "active" {
+					if !slices.Contains([]string{"setup", "wait-unplug"}, progress.Status) {
+						return
+					}
+
+					continue
+				}
+
+				if progress.RAM.DirtySyncCount <= passes {
+					continue
+				}
+
+				err = monitor.MigrateStartPostcopy()
+				if err != nil {
+					d.logger.Warn("Failed switching migration to post-copy", logger.Ctx{"err": err})
+					return
+				}
+
+				d.logger.Info("Switched migration to post-copy", logger.Ctx{"passes": progress.RAM.DirtySyncCount})
+				close(postcopyStarted)
+				return
+			}
+		}()
+	}
+
+	// isPostcopyStarted returns whether the switch to post-copy mode was requested.
+	isPostcopyStarted := func() bool {
+		select {
+		case <-postcopyStarted:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// Once in post-copy mode, the guest resumes on the target as soon as the device state is sent.
+	// A failure past that point means the latest memory state of the guest is lost.
+	postcopyFailed := func(err error) error {
+		if !isPostcopyStarted() {
+			return err
+		}
+
+		postcopySwitched.Store(true)
+
+		return fmt.Errorf("%w (post-copy migration failed after switch-over, the memory state of the instance was lost and it was stopped)", err)
+	}
+
 	// Start monitoring the migration progress.
 	chMonitor := make(chan bool, 1)
 
@@ -7759,7 +8367,11 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 					"speed":     strconv.FormatInt(speed, 10),
 				}
 
-				metadata["live_migrate_instance_progress"] = fmt.Sprintf("Live migration: %s remaining (%s/s) (%d%% CPU throttle)", units.GetByteSizeString(progress.RAM.Remaining, 2), units.GetByteSizeString(speed, 2), progress.CPUThrottlePercentage)
+				if strings.HasPrefix(progress.Status, "postcopy-") {
+					metadata["live_migrate_instance_progress"] = fmt.Sprintf("Live migration (post-copy): %s remaining (%s/s)", units.GetByteSizeString(progress.RAM.Remaining, 2), units.GetByteSizeString(speed, 2))
+				} else {
+					metadata["live_migrate_instance_progress"] = fmt.Sprintf("Live migration: %s remaining (%s/s) (%d%% CPU throttle)", units.GetByteSizeString(progress.RAM.Remaining, 2), units.GetByteSizeString(speed, 2), progress.CPUThrottlePercentage)
+				}
 				_ = d.op.UpdateMetadata(metadata)
 			}
 		}()
@@ -7787,7 +8399,7 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 		// Finalise the migration state transfer (the guest OS will remain paused).
 		err = monitor.MigrateContinue("pre-switchover")
 		if err != nil {
-			return fmt.Errorf("Failed continuing state transfer: %w", err)
+			return postcopyFailed(fmt.Errorf("Failed continuing state transfer: %w", err))
 		}
 
 		d.logger.Debug("Stateful migration checkpoint send continuing")
@@ -7796,7 +8408,7 @@ func (d *qemu) migrateSendLive(pool storagePools.Pool, clusterMoveSourceName str
 	// Wait until the migration state transfer has completed (the guest OS will remain paused).
 	err = monitor.MigrateWait("completed")
 	if err != nil {
-		return fmt.Errorf("Failed waiting for state transfer to reach completed stage: %w", err)
+		return postcopyFailed(fmt.Errorf("Failed waiting for state transfer to reach completed stage: %w", err))
 	}
 
 	close(chMonitor)
@@ -7984,6 +8596,15 @@ func (d *qemu) MigrateReceive(args instance.MigrateReceiveArgs) error {
 	if args.Live && offerHeader.Criu != nil && *offerHeader.Criu == migration.CRIUType_VM_QEMU {
 		respHeader.Criu = migration.CRIUType_VM_QEMU.Enum()
 		useStateConn = true
+
+		// Accept switching to post-copy mode if the kernel can serve the missing pages to QEMU.
+		if offerHeader.GetPostcopy() {
+			if migrationPostcopySupported() {
+				respHeader.Postcopy = proto.Bool(true)
+			} else {
+				d.logger.Warn("Post-copy migration requested but userfaultfd isn't supported, using pre-copy")
+			}
+		}
 	}
 
 	// Send response to source.
@@ -8241,6 +8862,10 @@ func (d *qemu) MigrateReceive(args instance.MigrateReceiveArgs) error {
 					api.SecretNameState: stateConn,
 				}
 
+				if respHeader.GetPostcopy() {
+					d.migrationReceivePostcopy = make(chan error, 1)
+				}
+
 				// Populate the filesystem connection handle if doing non-shared storage migration.
 				sameSharedStorage := args.ClusterMoveSourceName != "" && poolInfo.Remote && args.StoragePool == ""
 				if !sameSharedStorage {
@@ -8256,6 +8881,16 @@ func (d *qemu) MigrateReceive(args instance.MigrateReceiveArgs) error {
 			if err != nil {
 				return err
 			}
+
+			// The guest is running but may still be fetching memory from the source in post-copy mode,
+			// so keep the migration connections open until it's done.
+			if d.migrationReceivePostcopy != nil {
+				err = <-d.migrationReceivePostcopy
+				if err != nil {
+					_ = d.forceStop()
+					return fmt.Errorf("Failed receiving memory in post-copy mode, the memory state of the instance was lost: %w", err)
+				}
+			}
 		}
 
 		return nil
@@ -8778,6 +9413,8 @@ func (d *qemu) renderState(statusCode api.StatusCode) (*api.InstanceState, error
 		if err != nil {
 			return status, err
 		}
+
+		status.Health = d.health()
 	}
 
 	status.Status = statusCode.String()
@@ -8790,6 +9427,16 @@ func (d *qemu) renderState(statusCode api.StatusCode) (*api.InstanceState, error
 	return status, nil
 }
 
+// NetworkPath returns the network path of the instance NICs.
+func (d *qemu) NetworkPath(deviceName string) ([]api.InstanceNetworkPath, error) {
+	return d.networkPath(d, deviceName)
+}
+
+// NetworkTrace traces a flow originating from one of the instance NICs.
+func (d *qemu) NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	return d.networkTrace(d, req)
+}
+
 // RenderState returns just state info about the instance.
 func (d *qemu) RenderState(hostInterfaces []net.Interface) (*api.InstanceState, error) {
 	return d.renderState(d.statusCode())
@@ -9689,6 +10336,24 @@ func (d *qemu) checkFeatures(hostArch int, qemuPath string) (map[string]any, err
 		}
 	}
 
+	// Check Intel TDX feature (only for x86 architecture).
+	if hostArch == osarch.ARCH_64BIT_INTEL_X86 {
+		tdx, err := tdxHostEnabled(tdxKVMParameter)
+		if err != nil {
+			return nil, err
+		}
+
+		if tdx {
+			// Host supports TDX, check if QEMU supports it as well.
+			qomTypes, err := monitor.QOMListTypes("confidential-guest-support")
+			if err != nil {
+				logger.Debug("Failed querying TDX capability during VM feature check", logger.Ctx{"err": err})
+			} else if tdxQEMUSupported(qomTypes) {
+				features["tdx"] = struct{}{}
+			}
+		}
+	}
+
 	// Check if vhost-net accelerator (for NIC CPU offloading) is available.
 	if util.PathExists("/dev/vhost-net") {
 		features["vhost_net"] = struct{}{}
@@ -9769,7 +10434,45 @@ func (d *qemu) Metrics(hostInterfaces []net.Interface) (*metrics.MetricSet, erro
 	return d.getQemuMetrics()
 }
 
+// UsageMetrics returns the current resource usage of the instance.
+// The usage of the guest is only available through the agent, without it no usage is returned.
+// The memory of the QEMU process isn't used as a fallback as it doesn't shrink when the guest frees memory.
+func (d *qemu) UsageMetrics() (*metrics.Metrics, error) {
+	if !d.IsRunning() {
+		return nil, ErrInstanceIsStopped
+	}
+
+	if !d.agentMetricsEnabled() {
+		return &metrics.Metrics{}, nil
+	}
+
+	m, err := d.getAgentRawMetrics()
+	if err != nil {
+		if !errors.Is(err, errQemuAgentOffline) {
+			d.logger.Warn("Could not get VM metrics from agent", logger.Ctx{"err": err})
+		}
+
+		return &metrics.Metrics{}, nil
+	}
+
+	return m, nil
+}
+
 func (d *qemu) getAgentMetrics() (*metrics.MetricSet, error) {
+	m, err := d.getAgentRawMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	metricSet, err := metrics.MetricSetFromAPI(m, map[string]string{"project": d.project.Name, "name": d.name, "type": instancetype.VM.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	return metricSet, nil
+}
+
+func (d *qemu) getAgentRawMetrics() (*metrics.Metrics, error) {
 	client, err := d.getAgentClient()
 	if err != nil {
 		return nil, err
@@ -9800,12 +10503,7 @@ func (d *qemu) getAgentMetrics() (*metrics.MetricSet, error) {
 		return nil, err
 	}
 
-	metricSet, err := metrics.MetricSetFromAPI(&m, map[string]string{"project": d.project.Name, "name": d.name, "type": instancetype.VM.String()})
-	if err != nil {
-		return nil, err
-	}
-
-	return metricSet, nil
+	return &m, nil
 }
 
 func (d *qemu) getNetworkState() (map[string]api.InstanceStateNetwork, error) {
@@ -314,6 +314,14 @@ func TestQemuConfigTemplates(t *testing.T) {
 		}
 	})
 
+	t.Run("qemu_tdx", func(t *testing.T) {
+		runTest(`# Intel Trust Domain Extensions
+			[object "tdx0"]
+			qom-type = "tdx-guest"
+			sept-ve-disable = "on"
+			`, qemuTDX())
+	})
+
 	t.Run("qemu_gpu", func(t *testing.T) {
 		testCases := []struct {
 			opts     qemuGpuOpts
@@ -417,6 +417,17 @@ func qemuSEV(opts *qemuSevOpts) []cfg.Section {
 	}}
 }
 
+func qemuTDX() []cfg.Section {
+	return []cfg.Section{{
+		Name:    `object "tdx0"`,
+		Comment: "Intel Trust Domain Extensions",
+		Entries: map[string]string{
+			"qom-type":        "tdx-guest",
+			"sept-ve-disable": "on",
+		},
+	}}
+}
+
 type qemuVsockOpts struct {
 	dev     qemuDevOpts
 	vsockFD int
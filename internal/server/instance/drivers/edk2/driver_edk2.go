@@ -33,6 +33,9 @@ const (
 
 	// CSM is a firmware with the UEFI Compatibility Support Module enabled to boot BIOS-only operating systems.
 	CSM
+
+	// TDX is a stateless firmware built to boot Intel TDX confidential guests.
+	TDX
 )
 
 var architectureInstallations = map[int][]Installation{
@@ -67,6 +70,16 @@ var architectureInstallations = map[int][]Installation{
 				{Code: "OVMF_CODE.CSM.fd", Vars: "OVMF_VARS.CSM.fd"},
 				{Code: "OVMF_CODE.csm.fd", Vars: "OVMF_VARS.fd"},
 			},
+			TDX: {
+				{Code: "OVMF.inteltdx.fd", Vars: "OVMF.inteltdx.fd"},
+			},
+		},
+	}, {
+		Path: "/usr/share/ovmf",
+		Usage: map[FirmwareUsage][]FirmwarePair{
+			TDX: {
+				{Code: "OVMF.inteltdx.fd", Vars: "OVMF.inteltdx.fd"},
+			},
 		},
 	}, {
 		Path: "/usr/share/qemu",
@@ -105,6 +118,13 @@ var architectureInstallations = map[int][]Installation{
 				{Code: "OVMF_CODE.secure.fd", Vars: "OVMF_VARS.fd"},
 			},
 		},
+	}, {
+		Path: "/usr/share/edk2/ovmf",
+		Usage: map[FirmwareUsage][]FirmwarePair{
+			TDX: {
+				{Code: "OVMF.inteltdx.fd", Vars: "OVMF.inteltdx.fd"},
+			},
+		},
 	}, {
 		Path: "/usr/share/OVMF/x64",
 		Usage: map[FirmwareUsage][]FirmwarePair{
@@ -167,7 +187,7 @@ func GetArchitectureInstallations(hostArch int) []Installation {
 func GetArchitectureFirmwarePairs(hostArch int) ([]FirmwarePair, error) {
 	firmwares := make([]FirmwarePair, 0)
 
-	for _, usage := range []FirmwareUsage{GENERIC, SECUREBOOT, CSM} {
+	for _, usage := range []FirmwareUsage{GENERIC, SECUREBOOT, CSM, TDX} {
 		firmware, err := GetArchitectureFirmwarePairsForUsage(hostArch, usage)
 		if err != nil {
 			return nil, err
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/lxc/incus/v6/internal/server/util"
+	"github.com/lxc/incus/v6/shared/osarch"
 )
 
 func tSetup(t *testing.T) func() {
@@ -24,7 +25,7 @@ func tSetup(t *testing.T) func() {
 		t.Fatal(err)
 	}
 
-	for _, fn := range []string{"OVMF_CODE.fd", "OVMF.fd", "OVMF_VARS.fd"} {
+	for _, fn := range []string{"OVMF_CODE.fd", "OVMF.fd", "OVMF_VARS.fd", "OVMF.inteltdx.fd"} {
 		f, err := os.Create(filepath.Join(incusEdk2Path, fn))
 		if err != nil {
 			t.Fatal(err)
@@ -92,3 +93,30 @@ func TestArchitectureFirmwarePairs(t *testing.T) {
 		t.Fatal(pair1)
 	}
 }
+
+func TestArchitectureFirmwarePairsForUsageTDX(t *testing.T) {
+	rb := tSetup(t)
+	defer rb()
+
+	pairs, err := GetArchitectureFirmwarePairsForUsage(osarch.ARCH_64BIT_INTEL_X86, TDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pairs) == 0 {
+		t.Fatal(pairs)
+	}
+
+	if !strings.HasSuffix(pairs[0].Code, "ovmf/OVMF.inteltdx.fd") || pairs[0].Code != pairs[0].Vars {
+		t.Fatal(pairs[0])
+	}
+
+	pairs, err = GetArchitectureFirmwarePairsForUsage(osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN, TDX)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pairs) != 0 {
+		t.Fatal(pairs)
+	}
+}
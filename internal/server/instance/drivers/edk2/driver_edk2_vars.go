@@ -0,0 +1,512 @@
+package edk2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+)
+
+// GUID represents an EFI GUID in its binary (mixed-endian) layout.
+type GUID [16]byte
+
+// String returns the textual representation of the GUID.
+func (g GUID) String() string {
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x", binary.LittleEndian.Uint32(g[0:4]), binary.LittleEndian.Uint16(g[4:6]), binary.LittleEndian.Uint16(g[6:8]), g[8:10], g[10:16])
+}
+
+// ParseGUID parses the textual representation of a GUID.
+func ParseGUID(value string) (GUID, error) {
+	u, err := uuid.Parse(value)
+	if err != nil {
+		return GUID{}, fmt.Errorf("Invalid GUID %q: %w", value, err)
+	}
+
+	var g GUID
+	binary.LittleEndian.PutUint32(g[0:4], binary.BigEndian.Uint32(u[0:4]))
+	binary.LittleEndian.PutUint16(g[4:6], binary.BigEndian.Uint16(u[4:6]))
+	binary.LittleEndian.PutUint16(g[6:8], binary.BigEndian.Uint16(u[6:8]))
+	copy(g[8:], u[8:])
+
+	return g, nil
+}
+
+func mustParseGUID(value string) GUID {
+	g, err := ParseGUID(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return g
+}
+
+var (
+	// GlobalVariableGUID is the vendor GUID of the EFI global variables (PK, KEK, BootOrder, Boot####, ...).
+	GlobalVariableGUID = mustParseGUID("8be4dfcb-90b5-11d2-aa0d-00e098032b8c")
+
+	// ImageSecurityDatabaseGUID is the vendor GUID of the signature databases (db and dbx).
+	ImageSecurityDatabaseGUID = mustParseGUID("d719b2cb-3d3a-4596-a3bc-dad00e67656f")
+
+	// SignatureOwnerGUID is the owner of the signatures enrolled by Incus.
+	SignatureOwnerGUID = mustParseGUID("3d1b4e5a-7c2f-4b8e-9f61-0a2d5c8e4b17")
+
+	nvDataFvGUID              = mustParseGUID("fff12b8d-7696-4c8b-a985-2747075b4f50")
+	authenticatedVariableGUID = mustParseGUID("aaf32c78-947b-439a-a180-2e144ec37792")
+	variableGUID              = mustParseGUID("ddcf3616-3275-4164-98b6-fe85707ffe7d")
+	secureBootEnableGUID      = mustParseGUID("f0a30bc7-af08-4556-99c4-001009c93a44")
+	certX509GUID              = mustParseGUID("a5c059a1-94e4-4aa7-87b5-ab155c2bf072")
+	certSHA256GUID            = mustParseGUID("c1c41626-504c-4092-aca9-41f936934328")
+)
+
+// EFI variable attributes.
+const (
+	VariableNonVolatile                       uint32 = 0x01
+	VariableBootServiceAccess                 uint32 = 0x02
+	VariableRuntimeAccess                     uint32 = 0x04
+	VariableTimeBasedAuthenticatedWriteAccess uint32 = 0x20
+)
+
+const (
+	fvHeaderMinSize         = 0x48
+	varStoreHeaderSize      = 28
+	varHeaderSize           = 32
+	authVarHeaderSize       = 60
+	varStartID              = 0x55aa
+	varStateAdded           = 0x3f
+	varStateAddedInDeletion = 0x3e
+)
+
+// Variable represents an EFI variable in an EDK2 variable store.
+type Variable struct {
+	Name       string
+	VendorGUID GUID
+	Attributes uint32
+	Timestamp  time.Time
+	Data       []byte
+}
+
+// Key returns the unique identifier of the variable, made of its name and vendor GUID.
+func (v Variable) Key() string {
+	return v.Name + "-" + v.VendorGUID.String()
+}
+
+// ParseVariableKey splits a variable identifier into its name and vendor GUID.
+func ParseVariableKey(key string) (string, GUID, error) {
+	// The GUID is 36 characters long and separated from the name by a dash.
+	if len(key) < 38 || key[len(key)-37] != '-' {
+		return "", GUID{}, fmt.Errorf("Invalid variable %q, expected <name>-<GUID>", key)
+	}
+
+	guid, err := ParseGUID(key[len(key)-36:])
+	if err != nil {
+		return "", GUID{}, err
+	}
+
+	return key[:len(key)-37], guid, nil
+}
+
+// varStore describes the location of the variable store within an EDK2 vars file.
+type varStore struct {
+	start         int
+	end           int
+	authenticated bool
+}
+
+func findVarStore(data []byte) (*varStore, error) {
+	if len(data) < fvHeaderMinSize || !bytes.Equal(data[16:32], nvDataFvGUID[:]) || string(data[40:44]) != "_FVH" {
+		return nil, errors.New("Not an EDK2 variable store")
+	}
+
+	headerLength := int(binary.LittleEndian.Uint16(data[48:50]))
+	if headerLength+varStoreHeaderSize > len(data) {
+		return nil, errors.New("Invalid firmware volume header length")
+	}
+
+	var signature GUID
+	copy(signature[:], data[headerLength:headerLength+16])
+
+	store := &varStore{start: headerLength + varStoreHeaderSize}
+	switch signature {
+	case authenticatedVariableGUID:
+		store.authenticated = true
+	case variableGUID:
+	default:
+		return nil, fmt.Errorf("Unsupported variable store format %q", signature.String())
+	}
+
+	store.end = headerLength + int(binary.LittleEndian.Uint32(data[headerLength+16:headerLength+20]))
+	if store.end > len(data) || store.end < store.start {
+		return nil, errors.New("Invalid variable store size")
+	}
+
+	return store, nil
+}
+
+func (s *varStore) headerSize() int {
+	if s.authenticated {
+		return authVarHeaderSize
+	}
+
+	return varHeaderSize
+}
+
+func align4(value int) int {
+	return (value + 3) &^ 3
+}
+
+func decodeTime(data []byte) time.Time {
+	year := int(binary.LittleEndian.Uint16(data[0:2]))
+	if year == 0 {
+		return time.Time{}
+	}
+
+	return time.Date(year, time.Month(data[2]), int(data[3]), int(data[4]), int(data[5]), int(data[6]), int(binary.LittleEndian.Uint32(data[8:12])), time.UTC)
+}
+
+func encodeTime(t time.Time) []byte {
+	data := make([]byte, 16)
+	if t.IsZero() {
+		return data
+	}
+
+	t = t.UTC()
+	binary.LittleEndian.PutUint16(data[0:2], uint16(t.Year()))
+	data[2] = byte(t.Month())
+	data[3] = byte(t.Day())
+	data[4] = byte(t.Hour())
+	data[5] = byte(t.Minute())
+	data[6] = byte(t.Second())
+
+	return data
+}
+
+func decodeName(data []byte) string {
+	chars := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		char := binary.LittleEndian.Uint16(data[i : i+2])
+		if char == 0 {
+			break
+		}
+
+		chars = append(chars, char)
+	}
+
+	return string(utf16.Decode(chars))
+}
+
+func encodeName(name string) []byte {
+	chars := append(utf16.Encode([]rune(name)), 0)
+	data := make([]byte, len(chars)*2)
+	for i, char := range chars {
+		binary.LittleEndian.PutUint16(data[i*2:], char)
+	}
+
+	return data
+}
+
+// ParseVariables returns the variables from the content of an EDK2 vars file.
+func ParseVariables(data []byte) ([]Variable, error) {
+	store, err := findVarStore(data)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []Variable{}
+	deleting := []Variable{}
+	headerSize := store.headerSize()
+
+	for offset := store.start; offset+headerSize <= store.end; {
+		header := data[offset : offset+headerSize]
+		if binary.LittleEndian.Uint16(header[0:2]) != varStartID {
+			break
+		}
+
+		state := header[2]
+		variable := Variable{Attributes: binary.LittleEndian.Uint32(header[4:8])}
+
+		var nameSize, dataSize int
+		if store.authenticated {
+			variable.Timestamp = decodeTime(header[16:32])
+			nameSize = int(binary.LittleEndian.Uint32(header[36:40]))
+			dataSize = int(binary.LittleEndian.Uint32(header[40:44]))
+			copy(variable.VendorGUID[:], header[44:60])
+		} else {
+			nameSize = int(binary.LittleEndian.Uint32(header[8:12]))
+			dataSize = int(binary.LittleEndian.Uint32(header[12:16]))
+			copy(variable.VendorGUID[:], header[16:32])
+		}
+
+		nameStart := offset + headerSize
+		dataStart := nameStart + nameSize
+		if nameSize < 0 || dataSize < 0 || dataStart+dataSize > store.end {
+			return nil, fmt.Errorf("Invalid variable at offset %d", offset)
+		}
+
+		variable.Name = decodeName(data[nameStart:dataStart])
+		variable.Data = slices.Clone(data[dataStart : dataStart+dataSize])
+
+		switch state {
+		case varStateAdded:
+			vars = append(vars, variable)
+		case varStateAddedInDeletion:
+			deleting = append(deleting, variable)
+		}
+
+		offset = align4(dataStart + dataSize)
+	}
+
+	// Variables being replaced are only valid if the update didn't complete.
+	for _, variable := range deleting {
+		if !slices.ContainsFunc(vars, func(v Variable) bool { return v.Key() == variable.Key() }) {
+			vars = append(vars, variable)
+		}
+	}
+
+	return vars, nil
+}
+
+// ReadVariables returns the variables stored in an EDK2 vars file.
+func ReadVariables(path string) ([]Variable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseVariables(data)
+}
+
+// EncodeVariables replaces the variables in the content of an EDK2 vars file.
+func EncodeVariables(data []byte, vars []Variable) ([]byte, error) {
+	store, err := findVarStore(data)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	for _, variable := range vars {
+		name := encodeName(variable.Name)
+
+		header := make([]byte, store.headerSize())
+		binary.LittleEndian.PutUint16(header[0:2], varStartID)
+		header[2] = varStateAdded
+		binary.LittleEndian.PutUint32(header[4:8], variable.Attributes)
+
+		if store.authenticated {
+			copy(header[16:32], encodeTime(variable.Timestamp))
+			binary.LittleEndian.PutUint32(header[36:40], uint32(len(name)))
+			binary.LittleEndian.PutUint32(header[40:44], uint32(len(variable.Data)))
+			copy(header[44:60], variable.VendorGUID[:])
+		} else {
+			binary.LittleEndian.PutUint32(header[8:12], uint32(len(name)))
+			binary.LittleEndian.PutUint32(header[12:16], uint32(len(variable.Data)))
+			copy(header[16:32], variable.VendorGUID[:])
+		}
+
+		buf.Write(header)
+		buf.Write(name)
+		buf.Write(variable.Data)
+
+		// Pad to the next variable with the erased flash value.
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0xff)
+		}
+	}
+
+	if store.start+buf.Len() > store.end {
+		return nil, fmt.Errorf("Variables don't fit in the variable store (%d bytes over)", store.start+buf.Len()-store.end)
+	}
+
+	out := slices.Clone(data)
+	copy(out[store.start:], buf.Bytes())
+	for i := store.start + buf.Len(); i < store.end; i++ {
+		out[i] = 0xff
+	}
+
+	return out, nil
+}
+
+// WriteVariables replaces the variables stored in an EDK2 vars file.
+func WriteVariables(path string, vars []Variable) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	data, err = EncodeVariables(data, vars)
+	if err != nil {
+		return err
+	}
+
+	// Write in place to keep the ownership and permissions of the file.
+	return os.WriteFile(path, data, 0o600)
+}
+
+// SetVariable adds a variable or replaces the existing one with the same name and vendor GUID.
+func SetVariable(vars []Variable, variable Variable) []Variable {
+	for i := range vars {
+		if vars[i].Key() == variable.Key() {
+			vars[i] = variable
+			return vars
+		}
+	}
+
+	return append(vars, variable)
+}
+
+// signatureList builds an EFI_SIGNATURE_LIST of same-sized signatures owned by Incus.
+func signatureList(signatureType GUID, signatures ...[]byte) []byte {
+	signatureSize := 16 + len(signatures[0])
+	listSize := 28 + signatureSize*len(signatures)
+
+	list := make([]byte, 28, listSize)
+	copy(list[0:16], signatureType[:])
+	binary.LittleEndian.PutUint32(list[16:20], uint32(listSize))
+	binary.LittleEndian.PutUint32(list[24:28], uint32(signatureSize))
+
+	for _, signature := range signatures {
+		list = append(list, SignatureOwnerGUID[:]...)
+		list = append(list, signature...)
+	}
+
+	return list
+}
+
+// parseSignatures parses PEM encoded certificates and hex encoded SHA-256 hashes.
+func parseSignatures(value string) ([][]byte, [][]byte, error) {
+	var certs [][]byte
+	var hashes [][]byte
+
+	rest := []byte(value)
+	for len(bytes.TrimSpace(rest)) > 0 {
+		block, remaining := pem.Decode(rest)
+
+		// Anything before the next certificate is a list of hashes.
+		text := rest
+		if block != nil {
+			text = rest[:bytes.Index(rest, []byte("-----BEGIN"))]
+		}
+
+		for _, field := range strings.Fields(string(text)) {
+			hash, err := hex.DecodeString(field)
+			if err != nil || len(hash) != sha256.Size {
+				return nil, nil, fmt.Errorf("Invalid SHA-256 hash %q", field)
+			}
+
+			hashes = append(hashes, hash)
+		}
+
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return nil, nil, fmt.Errorf("Unexpected PEM block %q", block.Type)
+		}
+
+		_, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid certificate: %w", err)
+		}
+
+		certs = append(certs, block.Bytes)
+		rest = remaining
+	}
+
+	if len(certs) == 0 && len(hashes) == 0 {
+		return nil, nil, errors.New("No certificate or hash provided")
+	}
+
+	return certs, hashes, nil
+}
+
+// SecureBootKeys represents the keys to enroll in the Secure Boot variables.
+// Each field holds PEM encoded certificates, and for db and dbx hex encoded SHA-256 hashes too.
+type SecureBootKeys struct {
+	PK  string
+	KEK string
+	DB  string
+	DBX string
+}
+
+// EnrollSecureBootKeys replaces the Secure Boot variables for which keys are provided and enables Secure Boot
+// when a Platform Key is enrolled.
+func EnrollSecureBootKeys(vars []Variable, keys SecureBootKeys) ([]Variable, error) {
+	now := time.Now().UTC()
+
+	for _, entry := range []struct {
+		name         string
+		vendorGUID   GUID
+		value        string
+		maxCerts     int
+		allowsHashes bool
+	}{
+		{name: "PK", vendorGUID: GlobalVariableGUID, value: keys.PK, maxCerts: 1},
+		{name: "KEK", vendorGUID: GlobalVariableGUID, value: keys.KEK},
+		{name: "db", vendorGUID: ImageSecurityDatabaseGUID, value: keys.DB, allowsHashes: true},
+		{name: "dbx", vendorGUID: ImageSecurityDatabaseGUID, value: keys.DBX, allowsHashes: true},
+	} {
+		if entry.value == "" {
+			continue
+		}
+
+		certs, hashes, err := parseSignatures(entry.value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s: %w", entry.name, err)
+		}
+
+		if len(hashes) > 0 && !entry.allowsHashes {
+			return nil, fmt.Errorf("Invalid %s: Only certificates are supported", entry.name)
+		}
+
+		if entry.maxCerts > 0 && len(certs) > entry.maxCerts {
+			return nil, fmt.Errorf("Invalid %s: Only a single certificate is supported", entry.name)
+		}
+
+		// Certificates have different sizes so each gets its own list.
+		var data []byte
+		for _, cert := range certs {
+			data = append(data, signatureList(certX509GUID, cert)...)
+		}
+
+		if len(hashes) > 0 {
+			data = append(data, signatureList(certSHA256GUID, hashes...)...)
+		}
+
+		vars = SetVariable(vars, Variable{
+			Name:       entry.name,
+			VendorGUID: entry.vendorGUID,
+			Attributes: VariableNonVolatile | VariableBootServiceAccess | VariableRuntimeAccess | VariableTimeBasedAuthenticatedWriteAccess,
+			Timestamp:  now,
+			Data:       data,
+		})
+	}
+
+	if keys.PK != "" {
+		vars = SetVariable(vars, Variable{
+			Name:       "SecureBootEnable",
+			VendorGUID: secureBootEnableGUID,
+			Attributes: VariableNonVolatile | VariableBootServiceAccess,
+			Data:       []byte{1},
+		})
+	}
+
+	return vars, nil
+}
+
+// ValidateSecureBootKeys checks that the provided keys can be enrolled.
+func ValidateSecureBootKeys(keys SecureBootKeys) error {
+	_, err := EnrollSecureBootKeys(nil, keys)
+
+	return err
+}
@@ -0,0 +1,172 @@
+package edk2
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tVarStore builds an empty authenticated variable store of the given size.
+func tVarStore(size int) []byte {
+	data := bytes.Repeat([]byte{0xff}, size)
+
+	// Firmware volume header.
+	copy(data[0:16], make([]byte, 16))
+	copy(data[16:32], nvDataFvGUID[:])
+	binary.LittleEndian.PutUint64(data[32:40], uint64(size))
+	copy(data[40:44], "_FVH")
+	binary.LittleEndian.PutUint16(data[48:50], fvHeaderMinSize)
+
+	// Variable store header.
+	copy(data[fvHeaderMinSize:fvHeaderMinSize+16], authenticatedVariableGUID[:])
+	binary.LittleEndian.PutUint32(data[fvHeaderMinSize+16:fvHeaderMinSize+20], uint32(size-fvHeaderMinSize))
+
+	return data
+}
+
+func tCertificate(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestGUID(t *testing.T) {
+	guid, err := ParseGUID("8be4dfcb-90b5-11d2-aa0d-00e098032b8c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if guid[0] != 0xcb || guid[4] != 0xb5 || guid[8] != 0xaa {
+		t.Fatal(guid)
+	}
+
+	if guid.String() != "8be4dfcb-90b5-11d2-aa0d-00e098032b8c" {
+		t.Fatal(guid.String())
+	}
+
+	name, vendorGUID, err := ParseVariableKey("Boot0001-" + guid.String())
+	if err != nil || name != "Boot0001" || vendorGUID != guid {
+		t.Fatal(name, vendorGUID, err)
+	}
+
+	_, _, err = ParseVariableKey("Boot0001")
+	if err == nil {
+		t.Fatal("Expected an error for a key without GUID")
+	}
+}
+
+func TestVariablesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "OVMF_VARS.fd")
+	err := os.WriteFile(path, tVarStore(4096), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := ReadVariables(path)
+	if err != nil || len(vars) != 0 {
+		t.Fatal(vars, err)
+	}
+
+	timestamp := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	vars = SetVariable(vars, Variable{Name: "BootOrder", VendorGUID: GlobalVariableGUID, Attributes: 0x7, Data: []byte{1, 0, 2}})
+	vars = SetVariable(vars, Variable{Name: "db", VendorGUID: ImageSecurityDatabaseGUID, Attributes: 0x27, Timestamp: timestamp, Data: []byte{1, 2, 3, 4}})
+	vars = SetVariable(vars, Variable{Name: "BootOrder", VendorGUID: GlobalVariableGUID, Attributes: 0x7, Data: []byte{2, 0}})
+
+	err = WriteVariables(path, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err = ReadVariables(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vars) != 2 {
+		t.Fatal(vars)
+	}
+
+	if vars[0].Name != "BootOrder" || !bytes.Equal(vars[0].Data, []byte{2, 0}) || vars[0].Attributes != 0x7 {
+		t.Fatal(vars[0])
+	}
+
+	if vars[1].Key() != "db-d719b2cb-3d3a-4596-a3bc-dad00e67656f" || !vars[1].Timestamp.Equal(timestamp) {
+		t.Fatal(vars[1])
+	}
+
+	// Variables that don't fit are rejected.
+	err = WriteVariables(path, []Variable{{Name: "big", VendorGUID: GlobalVariableGUID, Data: make([]byte, 8192)}})
+	if err == nil {
+		t.Fatal("Expected an error for an oversized variable")
+	}
+}
+
+func TestEnrollSecureBootKeys(t *testing.T) {
+	cert := tCertificate(t)
+	hash := strings.Repeat("ab", 32)
+
+	vars, err := EnrollSecureBootKeys(nil, SecureBootKeys{PK: cert, KEK: cert, DB: cert + hash, DBX: hash + "\n" + strings.Repeat("cd", 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(vars) != 5 {
+		t.Fatal(vars)
+	}
+
+	for _, variable := range vars[:4] {
+		if variable.Attributes != 0x27 || variable.Timestamp.IsZero() {
+			t.Fatal(variable)
+		}
+	}
+
+	// The dbx holds a single SHA-256 list with both hashes.
+	dbx := vars[3]
+	if dbx.Name != "dbx" || len(dbx.Data) != 28+2*(16+32) {
+		t.Fatal(dbx)
+	}
+
+	if vars[4].Name != "SecureBootEnable" || !bytes.Equal(vars[4].Data, []byte{1}) {
+		t.Fatal(vars[4])
+	}
+
+	for _, keys := range []SecureBootKeys{
+		{PK: cert + cert},
+		{KEK: hash},
+		{DB: "not-a-hash"},
+		{DBX: "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n"},
+	} {
+		err = ValidateSecureBootKeys(keys)
+		if err == nil {
+			t.Fatal("Expected an error for", keys)
+		}
+	}
+}
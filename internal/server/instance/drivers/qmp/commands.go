@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -114,9 +115,9 @@ type MigrationStatus struct {
 		Duplicate               int64   `json:"duplicate"`
 		Normal                  int64   `json:"normal"`
 		NormalBytes             int64   `json:"normal-bytes"`
-		DirtyPagesRate          int64   `json:"diry-pages-rate"`
+		DirtyPagesRate          int64   `json:"dirty-pages-rate"`
 		MBps                    float64 `json:"mbps"`
-		DirtySyncCount          int64   `json:"diry-sync-count"`
+		DirtySyncCount          int64   `json:"dirty-sync-count"`
 		PostcopyRequests        int64   `json:"postcopy-requests"`
 		PageSize                int64   `json:"page-size"`
 		MultiFDBytes            int64   `json:"multifd-bytes"`
@@ -124,7 +125,7 @@ type MigrationStatus struct {
 		PrecopyBytes            int64   `json:"precopy-bytes"`
 		DowntimeBytes           int64   `json:"downtime-bytes"`
 		PostcopyBytes           int64   `json:"postcopy-bytes"`
-		DirtySyncMissedZeroCopy int64   `json:"diry-sync-missed-zero-copy"`
+		DirtySyncMissedZeroCopy int64   `json:"dirty-sync-missed-zero-copy"`
 	} `json:"ram"`
 	TotalTime                      int64   `json:"total-time"`
 	DownTime                       int64   `json:"down-time"`
@@ -475,6 +476,10 @@ func (m *Monitor) MigrateWait(state string) error {
 			return errors.New("Migrate call failed")
 		}
 
+		if resp.Return.Status == "postcopy-paused" {
+			return errors.New("Post-copy migration interrupted")
+		}
+
 		if resp.Return.Status == state {
 			return nil
 		}
@@ -483,6 +488,17 @@ func (m *Monitor) MigrateWait(state string) error {
 	}
 }
 
+// MigrateStartPostcopy switches an ongoing migration to post-copy mode.
+// The migration must have been started with the postcopy-ram capability enabled.
+func (m *Monitor) MigrateStartPostcopy() error {
+	err := m.Run("migrate-start-postcopy", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // MigrateContinue continues a migration stream.
 func (m *Monitor) MigrateContinue(fromState string) error {
 	var args struct {
@@ -501,6 +517,17 @@ func (m *Monitor) MigrateContinue(fromState string) error {
 
 // MigrateIncoming starts the receiver of a migration stream.
 func (m *Monitor) MigrateIncoming(ctx context.Context, name string) error {
+	return m.migrateIncoming(ctx, name, "completed")
+}
+
+// MigrateIncomingPostcopy starts the receiver of a migration stream which may switch to post-copy mode.
+// Returns once the migration is completed or in post-copy mode, at which point the guest can be resumed while the
+// remaining memory is fetched from the source.
+func (m *Monitor) MigrateIncomingPostcopy(ctx context.Context, name string) error {
+	return m.migrateIncoming(ctx, name, "completed", "postcopy-active")
+}
+
+func (m *Monitor) migrateIncoming(ctx context.Context, name string, states ...string) error {
 	type migrateArgsChannel struct {
 		ChannelType string            `json:"channel-type"`
 		Address     map[string]string `json:"addr"`
@@ -544,7 +571,11 @@ func (m *Monitor) MigrateIncoming(ctx context.Context, name string) error {
 			return errors.New("Migrate incoming call failed")
 		}
 
-		if resp.Return.Status == "completed" {
+		if resp.Return.Status == "postcopy-paused" {
+			return errors.New("Post-copy migration interrupted")
+		}
+
+		if slices.Contains(states, resp.Return.Status) {
 			return nil
 		}
 
@@ -1058,6 +1089,36 @@ func (m *Monitor) SEVCapabilities() (AMDSEVCapabilities, error) {
 	return resp.Return, nil
 }
 
+// QOMListTypes returns the names of the non-abstract QOM types implementing the given type.
+func (m *Monitor) QOMListTypes(implements string) ([]string, error) {
+	// Prepare the request.
+	var req struct {
+		Implements string `json:"implements"`
+		Abstract   bool   `json:"abstract"`
+	}
+
+	req.Implements = implements
+
+	// Prepare the response.
+	var resp struct {
+		Return []struct {
+			Name string `json:"name"`
+		} `json:"return"`
+	}
+
+	err := m.Run("qom-list-types", req, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing QOM types: %w", err)
+	}
+
+	types := make([]string, 0, len(resp.Return))
+	for _, qomType := range resp.Return {
+		types = append(types, qomType.Name)
+	}
+
+	return types, nil
+}
+
 // NBDServerStart starts internal NBD server and returns a connection to it.
 func (m *Monitor) NBDServerStart() (net.Conn, error) {
 	var args struct {
@@ -123,6 +123,30 @@ func ParseMemoryStr(memory string) (valueInt int64, err error) {
 	return valueInt, err
 }
 
+// tdxKVMParameter is the KVM module parameter reporting whether Intel TDX is enabled on the host.
+var tdxKVMParameter = "/sys/module/kvm_intel/parameters/tdx"
+
+// tdxHostEnabled checks whether KVM has Intel TDX enabled, based on the provided module parameter file.
+func tdxHostEnabled(parameterPath string) (bool, error) {
+	value, err := os.ReadFile(parameterPath)
+	if err != nil {
+		// The parameter is missing on kernels without TDX support or without kvm_intel loaded.
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return slices.Contains([]string{"Y", "1"}, strings.TrimSpace(string(value))), nil
+}
+
+// tdxQEMUSupported checks whether QEMU provides the Intel TDX guest object, based on the list of the
+// confidential guest support types it implements.
+func tdxQEMUSupported(confidentialGuestTypes []string) bool {
+	return slices.Contains(confidentialGuestTypes, "tdx-guest")
+}
+
 func qemuEscapeCmdline(value string) string {
 	return strings.ReplaceAll(value, ",", ",,")
 }
@@ -1,6 +1,8 @@
 package drivers
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,3 +66,28 @@ func TestExtractTrailingNumber(t *testing.T) {
 		t.Errorf("unexpected error message: got %q, want %q", err.Error(), expectedErr)
 	}
 }
+
+// Test tdxHostEnabled.
+func TestTDXHostEnabled(t *testing.T) {
+	parameterPath := filepath.Join(t.TempDir(), "tdx")
+
+	enabled, err := tdxHostEnabled(parameterPath)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	for value, expected := range map[string]bool{"Y\n": true, "1\n": true, "N\n": false, "0\n": false} {
+		err = os.WriteFile(parameterPath, []byte(value), 0o644)
+		assert.NoError(t, err)
+
+		enabled, err = tdxHostEnabled(parameterPath)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, enabled, value)
+	}
+}
+
+// Test tdxQEMUSupported.
+func TestTDXQEMUSupported(t *testing.T) {
+	assert.True(t, tdxQEMUSupported([]string{"sev-guest", "tdx-guest"}))
+	assert.False(t, tdxQEMUSupported([]string{"sev-guest", "sev-snp-guest"}))
+	assert.False(t, tdxQEMUSupported(nil))
+}
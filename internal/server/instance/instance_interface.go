@@ -123,6 +123,8 @@ type Instance interface {
 	RenderWithUsage() (any, any, error)
 	RenderFull(hostInterfaces []net.Interface) (*api.InstanceFull, any, error)
 	RenderState(hostInterfaces []net.Interface) (*api.InstanceState, error)
+	NetworkPath(deviceName string) ([]api.InstanceNetworkPath, error)
+	NetworkTrace(req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error)
 	IsRunning() bool
 	IsFrozen() bool
 	IsEphemeral() bool
@@ -176,6 +178,7 @@ type Instance interface {
 	DeferTemplateApply(trigger TemplateTrigger) error
 
 	Metrics(hostInterfaces []net.Interface) (*metrics.MetricSet, error)
+	UsageMetrics() (*metrics.Metrics, error)
 }
 
 // Container interface is for container specific functions.
@@ -196,9 +199,16 @@ type VM interface {
 	Instance
 
 	AgentCertificate() *x509.Certificate
+	AgentRunning() bool
 	ConsoleLog() (string, error)
 	ConsoleScreenshot(screenshotFile *os.File) error
 	DumpGuestMemory(w *os.File, format string) error
+	Hibernate() error
+	RefreshIdentity(hwaddrs map[string]string) error
+	AttestationReport(reportData []byte) (*api.InstanceAttestation, error)
+	UEFIVariables() (*api.InstanceUEFIVars, error)
+	UEFIVariablesUpdate(uefiVars api.InstanceUEFIVars) error
+	UEFIKeysEnroll(keys api.InstanceUEFIKeysPost) error
 }
 
 // CriuMigrationArgs arguments for CRIU migration.
@@ -241,6 +251,7 @@ type MigrateSendArgs struct {
 	MigrateArgs
 
 	AllowInconsistent bool
+	Postcopy          bool
 }
 
 // MigrateReceiveArgs represent arguments for instance migration receive.
@@ -135,6 +135,13 @@ func ValidConfig(sysOS *sys.OS, config map[string]string, expanded bool, instanc
 		return err
 	}
 
+	if expanded {
+		_, err = instance.ParseAutoscaleConfig(config)
+		if err != nil {
+			return err
+		}
+	}
+
 	if instanceType == instancetype.Container && expanded && util.IsFalseOrEmpty(config["security.privileged"]) && sysOS.IdmapSet == nil {
 		return errors.New("No uid/gid allocation configured. In this mode, only privileged containers are supported")
 	}
@@ -253,6 +260,51 @@ func AllowedUnprivilegedOnlyMap(rawIdmap string) error {
 	return nil
 }
 
+// ValidDependencies checks that the boot.depends_on setting of an instance's expanded config refers to other
+// instances of its project and doesn't introduce a dependency cycle.
+func ValidDependencies(s *state.State, projectName string, instanceName string, expandedConfig map[string]string) error {
+	instanceDependencies := instance.Dependencies(expandedConfig)
+	if len(instanceDependencies) == 0 {
+		return nil
+	}
+
+	dependencies, err := loadDependencies(s, projectName)
+	if err != nil {
+		return err
+	}
+
+	delete(dependencies, instanceName)
+
+	return instance.CheckDependencies(dependencies, instanceName, instanceDependencies)
+}
+
+// ValidDependenciesRename checks that renaming an instance doesn't break the dependencies of the other instances
+// of its project or introduce a dependency cycle.
+func ValidDependenciesRename(s *state.State, projectName string, oldName string, newName string) error {
+	dependencies, err := loadDependencies(s, projectName)
+	if err != nil {
+		return err
+	}
+
+	return instance.CheckDependenciesRename(dependencies, oldName, newName)
+}
+
+// loadDependencies returns the dependencies of each instance of a project.
+func loadDependencies(s *state.State, projectName string) (map[string][]string, error) {
+	dependencies := map[string][]string{}
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.InstanceList(ctx, func(dbInst db.InstanceArgs, p api.Project) error {
+			dependencies[dbInst.Name] = instance.Dependencies(db.ExpandInstanceConfig(dbInst.Config, dbInst.Profiles))
+			return nil
+		}, cluster.InstanceFilter{Project: &projectName})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading instance dependencies: %w", err)
+	}
+
+	return dependencies, nil
+}
+
 // LoadByID loads an instance by ID.
 func LoadByID(s *state.State, id int) (Instance, error) {
 	var project string
@@ -816,6 +868,14 @@ func CreateInternal(s *state.State, args db.InstanceArgs, op *operations.Operati
 		checkedProfiles[profile.Name] = true
 	}
 
+	// Validate dependencies.
+	if !args.Snapshot {
+		err = ValidDependencies(s, args.Project, args.Name, db.ExpandInstanceConfig(args.Config, args.Profiles))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
 	if args.CreationDate.IsZero() {
 		args.CreationDate = time.Now().UTC()
 	}
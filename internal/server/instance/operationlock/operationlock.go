@@ -39,6 +39,9 @@ const ActionDelete Action = "delete"
 // ActionMigrate for migrating an instance.
 const ActionMigrate Action = "migrate"
 
+// ActionAutoscale for autoscaling the limits of an instance.
+const ActionAutoscale Action = "autoscale"
+
 // ActionConsoleRetrieve for retrieving and saving a VM's console history.
 const ActionConsoleRetrieve Action = "console_retrieve"
 
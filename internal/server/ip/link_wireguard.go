@@ -0,0 +1,32 @@
+package ip
+
+import (
+	"context"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/lxc/incus/v6/shared/subprocess"
+)
+
+// Wireguard represents arguments for link device of type wireguard.
+type Wireguard struct {
+	Link
+}
+
+// Add adds new virtual link.
+func (wg *Wireguard) Add() error {
+	attrs, err := wg.netlinkAttrs()
+	if err != nil {
+		return err
+	}
+
+	return wg.addLink(&netlink.Wireguard{
+		LinkAttrs: attrs,
+	})
+}
+
+// SetConfig replaces the configuration (keys and peers) of the link with the given WireGuard configuration.
+func (wg *Wireguard) SetConfig(config string) error {
+	return subprocess.RunCommandWithFds(context.TODO(), strings.NewReader(config), nil, "wg", "setconf", wg.Name, "/dev/stdin")
+}
@@ -18,6 +18,7 @@ type InstanceAction string
 
 // All supported lifecycle events for instances.
 const (
+	InstanceAutoscaled       = InstanceAction(api.EventLifecycleInstanceAutoscaled)
 	InstanceConsole          = InstanceAction(api.EventLifecycleInstanceConsole)
 	InstanceConsoleReset     = InstanceAction(api.EventLifecycleInstanceConsoleReset)
 	InstanceConsoleRetrieved = InstanceAction(api.EventLifecycleInstanceConsoleRetrieved)
@@ -27,6 +28,8 @@ const (
 	InstanceFileDeleted      = InstanceAction(api.EventLifecycleInstanceFileDeleted)
 	InstanceFilePushed       = InstanceAction(api.EventLifecycleInstanceFilePushed)
 	InstanceFileRetrieved    = InstanceAction(api.EventLifecycleInstanceFileRetrieved)
+	InstanceHealthy          = InstanceAction(api.EventLifecycleInstanceHealthy)
+	InstanceHibernated       = InstanceAction(api.EventLifecycleInstanceHibernated)
 	InstanceMigrated         = InstanceAction(api.EventLifecycleInstanceMigrated)
 	InstancePaused           = InstanceAction(api.EventLifecycleInstancePaused)
 	InstanceReady            = InstanceAction(api.EventLifecycleInstanceReady)
@@ -37,6 +40,7 @@ const (
 	InstanceShutdown         = InstanceAction(api.EventLifecycleInstanceShutdown)
 	InstanceStarted          = InstanceAction(api.EventLifecycleInstanceStarted)
 	InstanceStopped          = InstanceAction(api.EventLifecycleInstanceStopped)
+	InstanceUnhealthy        = InstanceAction(api.EventLifecycleInstanceUnhealthy)
 	InstanceUpdated          = InstanceAction(api.EventLifecycleInstanceUpdated)
 )
 
@@ -0,0 +1,28 @@
+package lifecycle
+
+import (
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// NetworkFloatingIPAction represents a lifecycle event action for floating IPs.
+type NetworkFloatingIPAction string
+
+// All supported lifecycle events for floating IPs.
+const (
+	NetworkFloatingIPCreated = NetworkFloatingIPAction(api.EventLifecycleNetworkFloatingIPCreated)
+	NetworkFloatingIPDeleted = NetworkFloatingIPAction(api.EventLifecycleNetworkFloatingIPDeleted)
+	NetworkFloatingIPUpdated = NetworkFloatingIPAction(api.EventLifecycleNetworkFloatingIPUpdated)
+)
+
+// Event creates the lifecycle event for an action on a floating IP.
+func (a NetworkFloatingIPAction) Event(projectName string, address string, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "network-floating-ips", address).Project(projectName)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -0,0 +1,28 @@
+package lifecycle
+
+import (
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// StackAction represents a lifecycle event action for stacks.
+type StackAction string
+
+// All supported lifecycle events for stacks.
+const (
+	StackCreated = StackAction(api.EventLifecycleStackCreated)
+	StackDeleted = StackAction(api.EventLifecycleStackDeleted)
+	StackUpdated = StackAction(api.EventLifecycleStackUpdated)
+)
+
+// Event creates the lifecycle event for an action on a stack.
+func (a StackAction) Event(name string, projectName string, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	u := api.NewURL().Path(version.APIVersion, "stacks", name).Project(projectName)
+
+	return api.EventLifecycle{
+		Action:    string(a),
+		Source:    u.String(),
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
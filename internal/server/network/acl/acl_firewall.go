@@ -3,6 +3,8 @@ package acl
 import (
 	"context"
 	"fmt"
+	"net"
+	"strings"
 
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
@@ -31,28 +33,40 @@ func FirewallACLRules(s *state.State, aclDeviceName string, aclProjectName strin
 	var allowStatelessRules []firewallDrivers.ACLRule
 
 	// convertACLRules converts the ACL rules to Firewall ACL rules.
-	convertACLRules := func(direction string, logPrefix string, rules ...api.NetworkACLRule) error {
+	convertACLRules := func(aclID int64, direction string, rules ...api.NetworkACLRule) error {
 		for ruleIndex, rule := range rules {
 			if rule.State == "disabled" {
 				continue
 			}
 
+			// Resolve network peer subjects into the subnets of the peered networks.
+			source, err := firewallPeerSubjects(s, aclProjectName, rule.Source)
+			if err != nil {
+				return err
+			}
+
+			destination, err := firewallPeerSubjects(s, aclProjectName, rule.Destination)
+			if err != nil {
+				return err
+			}
+
 			firewallACLRule := firewallDrivers.ACLRule{
 				Direction:       direction,
 				Action:          rule.Action,
-				Source:          rule.Source,
-				Destination:     rule.Destination,
+				Source:          source,
+				Destination:     destination,
 				Protocol:        rule.Protocol,
 				SourcePort:      rule.SourcePort,
 				DestinationPort: rule.DestinationPort,
 				ICMPType:        rule.ICMPType,
 				ICMPCode:        rule.ICMPCode,
+				Counter:         ruleCounterName(aclID, direction, ruleIndex),
 			}
 
 			if rule.State == "logged" {
 				firewallACLRule.Log = true
-				// Max 29 chars.
-				firewallACLRule.LogName = fmt.Sprintf("%s-%s-%d", logPrefix, direction, ruleIndex)
+				// Same name as used by OVN. Max 29 chars.
+				firewallACLRule.LogName = ruleCounterName(aclID, direction, ruleIndex)
 			}
 
 			switch {
@@ -76,12 +90,13 @@ func FirewallACLRules(s *state.State, aclDeviceName string, aclProjectName strin
 
 	// Load ACLs specified by network.
 	for _, aclName := range util.SplitNTrimSpace(config["security.acls"], ",", -1, true) {
+		var aclID int
 		var aclInfo *api.NetworkACL
 
 		err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 			var err error
 
-			_, aclInfo, err = dbCluster.GetNetworkACLAPI(ctx, tx.Tx(), aclProjectName, aclName)
+			aclID, aclInfo, err = dbCluster.GetNetworkACLAPI(ctx, tx.Tx(), aclProjectName, aclName)
 
 			return err
 		})
@@ -89,12 +104,12 @@ func FirewallACLRules(s *state.State, aclDeviceName string, aclProjectName strin
 			return nil, fmt.Errorf("Failed loading ACL %q for network %q: %w", aclName, aclDeviceName, err)
 		}
 
-		err = convertACLRules("ingress", logPrefix, aclInfo.Ingress...)
+		err = convertACLRules(int64(aclID), "ingress", aclInfo.Ingress...)
 		if err != nil {
 			return nil, fmt.Errorf("Failed converting ACL %q ingress rules for network %q: %w", aclInfo.Name, aclDeviceName, err)
 		}
 
-		err = convertACLRules("egress", logPrefix, aclInfo.Egress...)
+		err = convertACLRules(int64(aclID), "egress", aclInfo.Egress...)
 		if err != nil {
 			return nil, fmt.Errorf("Failed converting ACL %q egress rules for network %q: %w", aclInfo.Name, aclDeviceName, err)
 		}
@@ -127,6 +142,78 @@ func FirewallACLRules(s *state.State, aclDeviceName string, aclProjectName strin
 	return rules, nil
 }
 
+// firewallPeerSubjects replaces any network peer subjects (in the form "@<network>/<peer>") in the
+// comma-separated subject list with the subnets of the peered network.
+func firewallPeerSubjects(s *state.State, projectName string, subjects string) (string, error) {
+	if !strings.Contains(subjects, "@") {
+		return subjects, nil
+	}
+
+	criteria := util.SplitNTrimSpace(subjects, ",", -1, false)
+	resolved := make([]string, 0, len(criteria))
+
+	for _, subject := range criteria {
+		after, ok := strings.CutPrefix(subject, "@")
+		peerParts := strings.SplitN(after, "/", 2)
+		if !ok || len(peerParts) != 2 {
+			resolved = append(resolved, subject)
+			continue
+		}
+
+		var targetConfig map[string]string
+
+		err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			networkID, err := tx.GetNetworkID(ctx, projectName, peerParts[0])
+			if err != nil {
+				return err
+			}
+
+			dbPeer, err := dbCluster.GetNetworkPeer(ctx, tx.Tx(), networkID, peerParts[1])
+			if err != nil {
+				return err
+			}
+
+			if !dbPeer.TargetNetworkID.Valid {
+				return fmt.Errorf("Network peer %q isn't linked to a mutual peer", subject)
+			}
+
+			targetName, targetProject, err := tx.GetNetworkNameAndProjectWithID(ctx, int(dbPeer.TargetNetworkID.Int64))
+			if err != nil {
+				return err
+			}
+
+			_, targetNet, _, err := tx.GetNetworkInAnyState(ctx, targetProject, targetName)
+			if err != nil {
+				return err
+			}
+
+			targetConfig = targetNet.Config
+
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("Failed resolving network peer subject %q: %w", subject, err)
+		}
+
+		found := false
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			_, subnet, err := net.ParseCIDR(targetConfig[key])
+			if err != nil {
+				continue // Address is unset or "none".
+			}
+
+			resolved = append(resolved, subnet.String())
+			found = true
+		}
+
+		if !found {
+			return "", fmt.Errorf("Network peer %q has no subnets", subject)
+		}
+	}
+
+	return strings.Join(resolved, ","), nil
+}
+
 // firewallACLDefaults returns the action and logging mode to use for the specified direction's default rule.
 // If the security.acls.default.{in,e}gress.action or security.acls.default.{in,e}gress.logged settings are not
 // specified in the network config, then it returns "reject" and false respectively.
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -511,6 +512,7 @@ func ovnApplyToPortGroup(s *state.State, l logger.Logger, client *ovn.NB, aclInf
 	revEgressPGRules := make([]ovn.OVNACLRule, 0)
 	allPGRules := make([]ovn.OVNACLRule, 0)
 	networkRules := make([]ovn.OVNACLRule, 0)
+	qosRules := make([]ovn.OVNQoSRule, 0)
 	networkPeersNeeded := make([]cluster.NetworkPeerConnection, 0)
 	// First gather used address sets
 	addressSetNamesSet := make(map[string]struct{})
@@ -577,6 +579,18 @@ func ovnApplyToPortGroup(s *state.State, l logger.Logger, client *ovn.NB, aclInf
 				ovnACLRule.LogName = fmt.Sprintf("%s-%s-%d", portGroupName, direction, ruleIndex)
 			}
 
+			ovnACLRule.Counter = ruleCounterName(aclNameIDs[aclInfo.Name], direction, ruleIndex)
+
+			// Mark or shape the matching traffic if requested.
+			if rule.DSCP != "" || rule.RateLimit != "" {
+				qosRule, err := ovnACLRuleToOVNQoSRule(ovnACLRule, &rule)
+				if err != nil {
+					return err
+				}
+
+				qosRules = append(qosRules, qosRule)
+			}
+
 			if networkSpecific {
 				networkRules = append(networkRules, ovnACLRule)
 			} else if isAllRule {
@@ -673,11 +687,46 @@ func ovnApplyToPortGroup(s *state.State, l logger.Logger, client *ovn.NB, aclInf
 		if err != nil {
 			return fmt.Errorf("Failed applying ACL %q rules to port group %q for network %q: %w", aclInfo.Name, netPortGroupName, aclNet.Name, err)
 		}
+
+		// Apply the QoS rules to the network's switch (the rules are removed along with the network port group).
+		err = client.UpdateLogicalSwitchQoSRules(context.TODO(), OVNIntSwitchName(aclNet.ID), string(netPortGroupName), matchReplace, qosRules...)
+		if err != nil {
+			return fmt.Errorf("Failed applying ACL %q QoS rules for network %q: %w", aclInfo.Name, aclNet.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// ovnACLRuleToOVNQoSRule returns the QoS rule marking or shaping the traffic matched by an OVN ACL rule.
+func ovnACLRuleToOVNQoSRule(aclRule ovn.OVNACLRule, rule *api.NetworkACLRule) (ovn.OVNQoSRule, error) {
+	qosRule := ovn.OVNQoSRule{
+		Direction: aclRule.Direction,
+		Match:     aclRule.Match,
+		Priority:  ovn.OVNQoSPriorityACL,
+	}
+
+	if rule.DSCP != "" {
+		dscp, err := strconv.Atoi(rule.DSCP)
+		if err != nil {
+			return ovn.OVNQoSRule{}, fmt.Errorf("Invalid DSCP %q: %w", rule.DSCP, err)
+		}
+
+		qosRule.DSCP = &dscp
+	}
+
+	if rule.RateLimit != "" {
+		rate, err := ovn.ParseQoSRate(rule.RateLimit)
+		if err != nil {
+			return ovn.OVNQoSRule{}, fmt.Errorf("Invalid rate limit %q: %w", rule.RateLimit, err)
+		}
+
+		qosRule.Rate = rate
+	}
+
+	return qosRule, nil
+}
+
 // ovnRuleCriteriaToOVNACLRule converts an ACL rule into an OVNACLRule for an OVN port group or network.
 // Returns a bool indicating if any of the rule subjects are network specific.
 func ovnRuleCriteriaToOVNACLRule(s *state.State, direction string, rule *api.NetworkACLRule, portGroupName ovn.OVNPortGroup, aclNameIDs map[string]int64, peerTargetNetIDs map[cluster.NetworkPeerConnection]int64, reversed bool) (ovn.OVNACLRule, bool, bool, []cluster.NetworkPeerConnection, error) {
@@ -0,0 +1,66 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/incus/v6/internal/server/network/ovn"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func Test_ovnACLRuleToOVNQoSRule(t *testing.T) {
+	dscp := 34
+	aclRule := ovn.OVNACLRule{
+		Direction: "to-lport",
+		Action:    "allow-related",
+		Match:     "inport == @incus_acl1 && ip4.dst == 192.0.2.1",
+		Priority:  PortGroupActionPriority("allow", false),
+	}
+
+	tests := []struct {
+		name    string
+		rule    api.NetworkACLRule
+		qosRule ovn.OVNQoSRule
+		err     bool
+	}{
+		{
+			name:    "DSCP only",
+			rule:    api.NetworkACLRule{Action: "allow", DSCP: "34"},
+			qosRule: ovn.OVNQoSRule{Direction: aclRule.Direction, Match: aclRule.Match, Priority: ovn.OVNQoSPriorityACL, DSCP: &dscp},
+		},
+		{
+			name:    "Rate limit only",
+			rule:    api.NetworkACLRule{Action: "allow", RateLimit: "10Mbit"},
+			qosRule: ovn.OVNQoSRule{Direction: aclRule.Direction, Match: aclRule.Match, Priority: ovn.OVNQoSPriorityACL, Rate: 10000},
+		},
+		{
+			name:    "DSCP and rate limit",
+			rule:    api.NetworkACLRule{Action: "allow", DSCP: "34", RateLimit: "1Gbit"},
+			qosRule: ovn.OVNQoSRule{Direction: aclRule.Direction, Match: aclRule.Match, Priority: ovn.OVNQoSPriorityACL, DSCP: &dscp, Rate: 1000000},
+		},
+		{
+			name: "Invalid DSCP",
+			rule: api.NetworkACLRule{Action: "allow", DSCP: "ef"},
+			err:  true,
+		},
+		{
+			name: "Rate limit below 1kbit",
+			rule: api.NetworkACLRule{Action: "allow", RateLimit: "100bit"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qosRule, err := ovnACLRuleToOVNQoSRule(aclRule, &tt.rule)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.qosRule, qosRule)
+		})
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
 	"os"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -20,6 +22,8 @@ import (
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	addressset "github.com/lxc/incus/v6/internal/server/network/address-set"
+	"github.com/lxc/incus/v6/internal/server/network/ovn"
+	"github.com/lxc/incus/v6/internal/server/network/ovs"
 	"github.com/lxc/incus/v6/internal/server/state"
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
 	"github.com/lxc/incus/v6/internal/version"
@@ -369,6 +373,25 @@ func (d *common) validateRule(direction ruleDirection, rule api.NetworkACLRule)
 		}
 	}
 
+	// Validate QoS fields (only applied on OVN networks).
+	if (rule.DSCP != "" || rule.RateLimit != "") && !slices.Contains([]string{"allow", "allow-stateless"}, rule.Action) {
+		return errors.New("DSCP marking and rate limiting can only be used with allow rules")
+	}
+
+	if rule.DSCP != "" {
+		err := validate.IsInRange(0, 63)(rule.DSCP)
+		if err != nil {
+			return fmt.Errorf("Invalid DSCP: %w", err)
+		}
+	}
+
+	if rule.RateLimit != "" {
+		_, err := ovn.ParseQoSRate(rule.RateLimit)
+		if err != nil {
+			return fmt.Errorf("Invalid rate limit: %w", err)
+		}
+	}
+
 	// Validate Protocol field.
 	if rule.Protocol != "" {
 		validProtocols := []string{"icmp4", "icmp6", "tcp", "udp"}
@@ -926,3 +949,173 @@ func (d *common) GetLog(clientType request.ClientType) (string, error) {
 
 	return strings.Join(logEntries, "\n") + "\n", nil
 }
+
+// ruleCounterName returns the name of the counter tracking the hits of an ACL rule.
+func ruleCounterName(aclID int64, direction string, ruleIndex int) string {
+	return fmt.Sprintf("%s-%s-%d", OVNACLPortGroupNamePrefix(aclID), direction, ruleIndex)
+}
+
+// GetState gets the hit counters of the ACL rules.
+func (d *common) GetState(clientType request.ClientType) (*api.NetworkACLState, error) {
+	aclState := &api.NetworkACLState{
+		Ingress: make([]api.NetworkACLRuleCounters, len(d.info.Ingress)),
+		Egress:  make([]api.NetworkACLRuleCounters, len(d.info.Egress)),
+	}
+
+	// addCounters adds the hits recorded by one of the counters to the matching rule.
+	addCounters := func(name string, packets uint64, bytes uint64) {
+		var counters []api.NetworkACLRuleCounters
+		var ruleIndex int
+
+		for _, direction := range []string{"ingress", "egress"} {
+			suffix, found := strings.CutPrefix(name, fmt.Sprintf("%s-%s-", OVNACLPortGroupNamePrefix(d.id), direction))
+			if !found {
+				continue
+			}
+
+			idx, err := strconv.Atoi(suffix)
+			if err != nil {
+				return
+			}
+
+			ruleIndex = idx
+			counters = aclState.Ingress
+			if direction == "egress" {
+				counters = aclState.Egress
+			}
+		}
+
+		// Skip counters of rules that no longer exist.
+		if ruleIndex < 0 || ruleIndex >= len(counters) {
+			return
+		}
+
+		counters[ruleIndex].Packets += packets
+		counters[ruleIndex].Bytes += bytes
+	}
+
+	// Get a list of networks that are using this ACL (either directly or indirectly via a NIC).
+	aclNets := map[string]NetworkACLUsage{}
+	err := NetworkUsage(d.state, d.projectName, []string{d.info.Name}, aclNets)
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting ACL network usage: %w", err)
+	}
+
+	hasBridge := false
+	hasOVN := false
+	for _, aclNet := range aclNets {
+		switch aclNet.Type {
+		case "bridge":
+			hasBridge = true
+		case "ovn":
+			hasOVN = true
+		}
+	}
+
+	// Get the counters of the firewall rules on this member.
+	if hasBridge {
+		counters, err := d.state.Firewall.NetworkACLCounters()
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting firewall ACL counters: %w", err)
+		}
+
+		for name, counter := range counters {
+			addCounters(name, counter.Packets, counter.Bytes)
+		}
+	}
+
+	// Get the counters of the OVN flows handled by this member.
+	if hasOVN {
+		counters, err := d.ovnCounters()
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting OVN ACL counters: %w", err)
+		}
+
+		for name, counter := range counters {
+			addCounters(name, counter.Packets, counter.Bytes)
+		}
+	}
+
+	// Aggregates the counters from the rest of the cluster.
+	if clientType == request.ClientTypeNormal {
+		// Setup notifier to reach the rest of the cluster.
+		notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), d.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return nil, err
+		}
+
+		mu := sync.Mutex{}
+		err = notifier(func(client incus.InstanceServer) error {
+			memberState, err := client.UseProject(d.projectName).GetNetworkACLState(d.info.Name)
+			if err != nil {
+				return err
+			}
+
+			// Prevent concurrent writes to the counters.
+			mu.Lock()
+			defer mu.Unlock()
+
+			for i := range min(len(memberState.Ingress), len(aclState.Ingress)) {
+				aclState.Ingress[i].Packets += memberState.Ingress[i].Packets
+				aclState.Ingress[i].Bytes += memberState.Ingress[i].Bytes
+			}
+
+			for i := range min(len(memberState.Egress), len(aclState.Egress)) {
+				aclState.Egress[i].Packets += memberState.Egress[i].Packets
+				aclState.Egress[i].Bytes += memberState.Egress[i].Bytes
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return aclState, nil
+}
+
+// ovnCounters returns the hit counters of the OVN flows of the ACL rules handled by this member.
+func (d *common) ovnCounters() (map[string]ovs.OVSFlowCounters, error) {
+	ovnnb, ovnsb, err := d.state.OVN()
+	if err != nil {
+		return nil, err
+	}
+
+	vswitch, err := d.state.OVS()
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the ACL rule stage hints to the OpenFlow cookies of the resulting logical flows.
+	hints, err := ovnnb.GetACLRuleCounterHints(context.TODO(), fmt.Sprintf("%s-", OVNACLPortGroupNamePrefix(d.id)))
+	if err != nil {
+		return nil, err
+	}
+
+	cookies, err := ovnsb.GetLogicalFlowCookies(context.TODO(), slices.Collect(maps.Keys(hints)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Sum the counters of the local flows.
+	flows, err := vswitch.GetBridgeFlowCounters(context.TODO(), d.state.GlobalConfig.NetworkOVNIntegrationBridge())
+	if err != nil {
+		return nil, err
+	}
+
+	counters := map[string]ovs.OVSFlowCounters{}
+	for cookie, flow := range flows {
+		hint, ok := cookies[cookie]
+		if !ok {
+			continue
+		}
+
+		counter := counters[hints[hint]]
+		counter.Packets += flow.Packets
+		counter.Bytes += flow.Bytes
+		counters[hints[hint]] = counter
+	}
+
+	return counters, nil
+}
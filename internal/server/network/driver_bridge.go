@@ -2,7 +2,10 @@ package network
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -11,14 +14,18 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mdlayher/netx/eui64"
 
 	incus "github.com/lxc/incus/v6/client"
+	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/internal/server/acme"
 	"github.com/lxc/incus/v6/internal/server/apparmor"
 	"github.com/lxc/incus/v6/internal/server/cluster"
 	"github.com/lxc/incus/v6/internal/server/cluster/request"
@@ -26,13 +33,19 @@ import (
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	"github.com/lxc/incus/v6/internal/server/db/warningtype"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
+	"github.com/lxc/incus/v6/internal/server/dns64"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
 	firewallDrivers "github.com/lxc/incus/v6/internal/server/firewall/drivers"
 	"github.com/lxc/incus/v6/internal/server/ip"
 	"github.com/lxc/incus/v6/internal/server/network/acl"
 	addressset "github.com/lxc/incus/v6/internal/server/network/address-set"
+	"github.com/lxc/incus/v6/internal/server/network/healthcheck"
+	"github.com/lxc/incus/v6/internal/server/network/ipam"
+	"github.com/lxc/incus/v6/internal/server/network/lbproxy"
 	"github.com/lxc/incus/v6/internal/server/project"
+	"github.com/lxc/incus/v6/internal/server/tftp"
 	localUtil "github.com/lxc/incus/v6/internal/server/util"
 	"github.com/lxc/incus/v6/internal/server/warnings"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
@@ -62,6 +75,8 @@ func (n *bridge) DBType() db.NetworkType {
 func (n *bridge) Info() Info {
 	info := n.common.Info()
 	info.AddressForwards = true
+	info.LoadBalancers = true
+	info.Peering = true
 
 	return info
 }
@@ -133,27 +148,43 @@ func (n *bridge) populateAutoConfig(config map[string]string) error {
 
 	// Now populate "auto" values where needed.
 	if config["ipv4.address"] == "auto" {
-		subnet, err := randomSubnetV4()
+		// Have an external IPAM pick the subnet if configured.
+		subnet, err := n.ipamSubnet(config, 4)
 		if err != nil {
 			return err
 		}
 
+		if subnet == "" {
+			subnet, err = randomSubnetV4()
+			if err != nil {
+				return err
+			}
+		}
+
 		config["ipv4.address"] = subnet
 		changedConfig = true
 	}
 
 	if config["ipv6.address"] == "auto" {
-		subnet, err := randomSubnetV6()
+		// Have an external IPAM pick the subnet if configured.
+		subnet, err := n.ipamSubnet(config, 6)
 		if err != nil {
 			return err
 		}
 
+		if subnet == "" {
+			subnet, err = randomSubnetV6()
+			if err != nil {
+				return err
+			}
+		}
+
 		config["ipv6.address"] = subnet
 		changedConfig = true
 	}
 
 	// Re-validate config if changed.
-	if changedConfig && n.state != nil {
+	if changedConfig && n.id > -1 {
 		return n.Validate(config, request.ClientTypeNormal)
 	}
 
@@ -352,6 +383,15 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 		//  shortdesc: Comma-separated list of IPv4 ranges to use for child OVN network routers (FIRST-LAST format)
 		"ipv4.ovn.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV4)),
 
+		// gendoc:generate(entity=network_bridge, group=common, key=ipv4.floating.ranges)
+		//
+		// ---
+		//  type: string
+		//  condition: -
+		//  default: -
+		//  shortdesc: Comma-separated list of IPv4 ranges to allocate floating IPs from (FIRST-LAST format)
+		"ipv4.floating.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV4)),
+
 		// gendoc:generate(entity=network_bridge, group=common, key=ipv6.address)
 		//
 		// ---
@@ -403,6 +443,16 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 		//  shortdesc: The source address used for outbound traffic from the bridge
 		"ipv6.nat.address": validate.Optional(validate.IsNetworkAddressV6),
 
+		// gendoc:generate(entity=network_bridge, group=common, key=ipv6.nat64)
+		// When enabled, traffic towards the `64:ff9b::/96` prefix is translated to IPv4 using the addresses of the host and the DNS server of the network synthesizes IPv6 addresses for names that only have IPv4 addresses.
+		// This requires Jool to be installed on the host.
+		// ---
+		//  type: bool
+		//  condition: IPv6 address
+		//  default: `false`
+		//  shortdesc: Whether to provide NAT64 and DNS64 to the network
+		"ipv6.nat64": validate.Optional(validate.IsBool),
+
 		// gendoc:generate(entity=network_bridge, group=common, key=ipv6.dhcp)
 		//
 		// ---
@@ -466,6 +516,42 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 		//  shortdesc: Comma-separated list of IPv6 ranges to use for child OVN network routers (FIRST-LAST format)
 		"ipv6.ovn.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV6)),
 
+		// gendoc:generate(entity=network_bridge, group=common, key=ipv6.floating.ranges)
+		//
+		// ---
+		//  type: string
+		//  condition: -
+		//  default: -
+		//  shortdesc: Comma-separated list of IPv6 ranges to allocate floating IPs from (FIRST-LAST format)
+		"ipv6.floating.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV6)),
+
+		// gendoc:generate(entity=network_bridge, group=common, key=ipam.driver)
+		// With the `http` driver, the subnets picked for `auto` addresses, the addresses of instance NICs without a static address and the listen addresses of network forwards are reserved in an external IPAM.
+		// ---
+		//  type: string
+		//  condition: -
+		//  default: `builtin`
+		//  shortdesc: IPAM driver to use (`builtin` or `http`)
+		"ipam.driver": validate.Optional(ipam.ValidDriver),
+
+		// gendoc:generate(entity=network_bridge, group=common, key=dhcp.backend)
+		//
+		// ---
+		//  type: string
+		//  condition: -
+		//  default: `dnsmasq`
+		//  shortdesc: DHCP and router advertisement implementation to use (`dnsmasq` or `builtin`)
+		"dhcp.backend": validate.Optional(validate.IsOneOf("dnsmasq", "builtin")),
+
+		// gendoc:generate(entity=network_bridge, group=common, key=tftp.root)
+		//
+		// ---
+		//  type: string
+		//  condition: IPv4 address
+		//  default: -
+		//  shortdesc: Absolute path to a host directory to serve over TFTP on the bridge IPv4 address
+		"tftp.root": validate.Optional(validate.IsAbsFilePath),
+
 		// gendoc:generate(entity=network_bridge, group=common, key=dns.nameservers)
 		//
 		// ---
@@ -717,6 +803,25 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 
 	maps.Copy(rules, bgpRules)
 
+	// gendoc:generate(entity=network_bridge, group=common, key=ipv4.dhcp.options.NAME)
+	// `NAME` is either a named option (`next-server`, `filename`, `tftp-server`, `tftp-server-address`, `ntp-server`, `path-prefix` or `wpad`) or a numeric option code.
+	// ---
+	//  type: string
+	//  condition: IPv4 DHCP
+	//  default: -
+	//  shortdesc: Value of a DHCP option to send to clients
+
+	// gendoc:generate(entity=network_bridge, group=common, key=ipv6.dhcp.options.NAME)
+	// `NAME` is either a named option (`bootfile-url` or `ntp-server`) or a numeric option code.
+	// ---
+	//  type: string
+	//  condition: IPv6 DHCP
+	//  default: -
+	//  shortdesc: Value of a DHCPv6 option to send to clients
+
+	// Add the DHCP option validation rules.
+	maps.Copy(rules, dhcp.ValidationRules(config, false))
+
 	// gendoc:generate(entity=network_bridge, group=common, key=user.*)
 	//
 	// ---
@@ -739,6 +844,21 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 		return err
 	}
 
+	// Check the IPAM driver can be loaded with this configuration.
+	_, err = ipamLoad(n.state, config)
+	if err != nil {
+		return err
+	}
+
+	// The TFTP server listens on the bridge IPv4 address.
+	if config["tftp.root"] != "" && util.IsNoneOrEmpty(config["ipv4.address"]) {
+		return errors.New("tftp.root requires ipv4.address to be set")
+	}
+
+	if util.IsTrue(config["ipv6.nat64"]) && util.IsNoneOrEmpty(config["ipv6.address"]) {
+		return errors.New("ipv6.nat64 requires ipv6.address to be set")
+	}
+
 	for k, v := range config {
 		key := k
 		// MTU checks
@@ -836,6 +956,19 @@ func (n *bridge) Validate(config map[string]string, clientType request.ClientTyp
 		}
 	}
 
+	// Check floating IP ranges don't overlap with the DHCP and OVN ranges.
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		dhcpRanges, err := bridgeDHCPRanges(config, keyPrefix)
+		if err != nil {
+			return err
+		}
+
+		err = floatingIPRangesValidate(config, keyPrefix, map[string][]*iprange.Range{keyPrefix + ".dhcp.ranges": dhcpRanges})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check Security ACLs are supported and exist.
 	if config["security.acls"] != "" {
 		err = acl.Exists(n.state, n.Project(), util.SplitNTrimSpace(config["security.acls"], ",", -1, true)...)
@@ -1380,7 +1513,7 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 
 		// Update the dnsmasq config.
 		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", ipAddress.String()))
-		if n.DHCPv4Subnet() != nil {
+		if n.DHCPv4Subnet() != nil && !n.hasBuiltinDHCP() {
 			if !slices.Contains(dnsmasqCmd, "--dhcp-no-override") {
 				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-no-override", "--dhcp-authoritative", fmt.Sprintf("--dhcp-leasefile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.leases")), fmt.Sprintf("--dhcp-hostsfile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.hosts"))}...)
 			}
@@ -1410,6 +1543,15 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=121,%s", strings.ReplaceAll(n.config["ipv4.dhcp.routes"], " ", "")))
 			}
 
+			options, err := dhcp.ParseOptions(n.config, false)
+			if err != nil {
+				return err
+			}
+
+			for _, option := range options {
+				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=%s", option.Dnsmasq()))
+			}
+
 			expiry := "1h"
 			if n.config["ipv4.dhcp.expiry"] != "" {
 				expiry = n.config["ipv4.dhcp.expiry"]
@@ -1448,11 +1590,23 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				srcIP = net.ParseIP(n.config["ipv4.nat.address"])
 			}
 
+			// Leave traffic towards peered networks untranslated.
+			peerSubnets, err := n.peerTargetSubnets()
+			if err != nil {
+				return fmt.Errorf("Failed loading network peers: %w", err)
+			}
+
 			fwOpts.SNATV4 = &firewallDrivers.SNATOpts{
 				SNATAddress: srcIP,
 				Subnet:      subnet,
 			}
 
+			for _, peerSubnet := range peerSubnets {
+				if peerSubnet.IP.To4() != nil {
+					fwOpts.SNATV4.Exclude = append(fwOpts.SNATV4.Exclude, peerSubnet)
+				}
+			}
+
 			if n.config["ipv4.nat.order"] == "after" {
 				fwOpts.SNATV4.Append = true
 			}
@@ -1559,36 +1713,50 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 
 		// Update the dnsmasq config.
-		dnsmasqCmd = append(dnsmasqCmd, []string{fmt.Sprintf("--listen-address=%s", ipAddress.String()), "--enable-ra"}...)
-		if n.DHCPv6Subnet() != nil {
-			if n.hasIPv6Firewall() {
-				fwOpts.FeaturesV6.ICMPDHCPDNSAccess = true
-			}
+		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", ipAddress.String()))
+		if n.DHCPv6Subnet() != nil && n.hasIPv6Firewall() {
+			fwOpts.FeaturesV6.ICMPDHCPDNSAccess = true
+		}
 
-			// Build DHCP configuration.
-			if !slices.Contains(dnsmasqCmd, "--dhcp-no-override") {
-				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-no-override", "--dhcp-authoritative", fmt.Sprintf("--dhcp-leasefile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.leases")), fmt.Sprintf("--dhcp-hostsfile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.hosts"))}...)
-			}
+		// Router advertisements and DHCPv6 are handled by the built-in DHCP server when in use.
+		if !n.hasBuiltinDHCP() {
+			dnsmasqCmd = append(dnsmasqCmd, "--enable-ra")
 
-			expiry := "1h"
-			if n.config["ipv6.dhcp.expiry"] != "" {
-				expiry = n.config["ipv6.dhcp.expiry"]
-			}
+			if n.DHCPv6Subnet() != nil {
+				// Build DHCP configuration.
+				if !slices.Contains(dnsmasqCmd, "--dhcp-no-override") {
+					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-no-override", "--dhcp-authoritative", fmt.Sprintf("--dhcp-leasefile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.leases")), fmt.Sprintf("--dhcp-hostsfile=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.hosts"))}...)
+				}
+
+				options, err := dhcp.ParseOptions(n.config, true)
+				if err != nil {
+					return err
+				}
+
+				for _, option := range options {
+					dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-option-force=%s", option.Dnsmasq()))
+				}
+
+				expiry := "1h"
+				if n.config["ipv6.dhcp.expiry"] != "" {
+					expiry = n.config["ipv6.dhcp.expiry"]
+				}
 
-			if util.IsTrue(n.config["ipv6.dhcp.stateful"]) {
-				if n.config["ipv6.dhcp.ranges"] != "" {
-					for _, dhcpRange := range strings.Split(n.config["ipv6.dhcp.ranges"], ",") {
-						dhcpRange = strings.TrimSpace(dhcpRange)
-						dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%d,%s", strings.ReplaceAll(dhcpRange, "-", ","), subnetSize, expiry)}...)
+				if util.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+					if n.config["ipv6.dhcp.ranges"] != "" {
+						for _, dhcpRange := range strings.Split(n.config["ipv6.dhcp.ranges"], ",") {
+							dhcpRange = strings.TrimSpace(dhcpRange)
+							dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%d,%s", strings.ReplaceAll(dhcpRange, "-", ","), subnetSize, expiry)}...)
+						}
+					} else {
+						dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%d,%s", dhcpalloc.GetIP(subnet, 2), dhcpalloc.GetIP(subnet, -1), subnetSize, expiry)}...)
 					}
 				} else {
-					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%d,%s", dhcpalloc.GetIP(subnet, 2), dhcpalloc.GetIP(subnet, -1), subnetSize, expiry)}...)
+					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-stateless,ra-names", n.name)}...)
 				}
 			} else {
-				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-stateless,ra-names", n.name)}...)
+				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-only", n.name)}...)
 			}
-		} else {
-			dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-only", n.name)}...)
 		}
 
 		if n.config["dns.nameservers"] != "" {
@@ -1666,16 +1834,33 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				srcIP = net.ParseIP(n.config["ipv6.nat.address"])
 			}
 
+			// Leave traffic towards peered networks untranslated.
+			peerSubnets, err := n.peerTargetSubnets()
+			if err != nil {
+				return fmt.Errorf("Failed loading network peers: %w", err)
+			}
+
 			fwOpts.SNATV6 = &firewallDrivers.SNATOpts{
 				SNATAddress: srcIP,
 				Subnet:      subnet,
 			}
 
+			for _, peerSubnet := range peerSubnets {
+				if peerSubnet.IP.To4() == nil {
+					fwOpts.SNATV6.Exclude = append(fwOpts.SNATV6.Exclude, peerSubnet)
+				}
+			}
+
 			if n.config["ipv6.nat.order"] == "after" {
 				fwOpts.SNATV6.Append = true
 			}
 		}
 
+		// Configure NAT64.
+		if util.IsTrue(n.config["ipv6.nat64"]) {
+			fwOpts.NAT64 = dns64.WellKnownPrefix
+		}
+
 		// Add additional routes.
 		if n.config["ipv6.routes"] != "" {
 			for _, route := range strings.Split(n.config["ipv6.routes"], ",") {
@@ -1829,6 +2014,10 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
+	// Stop any existing built-in DHCP server and DNS64 resolver for this network.
+	dhcp.Stop(n.name)
+	dns64.Stop(n.name)
+
 	// Kill any existing dnsmasq daemon for this network.
 	err = dnsmasq.Kill(n.name, false)
 	if err != nil {
@@ -1847,6 +2036,26 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			dnsmasqCmd = append(dnsmasqCmd, "-s", dnsDomain)
 			dnsmasqCmd = append(dnsmasqCmd, "--interface-name", fmt.Sprintf("_gateway.%s,%s", dnsDomain, n.name))
 			dnsmasqCmd = append(dnsmasqCmd, "-S", fmt.Sprintf("/%s/", dnsDomain))
+
+			// Forward the other queries to the built-in DNS64 resolver.
+			if util.IsTrue(n.config["ipv6.nat64"]) {
+				dns64Address, err := dns64.Start(n.name, dns64.Config{Prefix: dns64.WellKnownPrefix})
+				if err != nil {
+					return err
+				}
+
+				dnsmasqCmd = append(dnsmasqCmd, "--no-resolv", fmt.Sprintf("--server=%s#%d", dns64Address.IP.String(), dns64Address.Port))
+			}
+
+			// Resolve the leases handed out by the built-in DHCP server.
+			if n.hasBuiltinDHCP() {
+				err = os.MkdirAll(dhcp.HostsDir(n.name), 0o755)
+				if err != nil {
+					return err
+				}
+
+				dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--hostsdir=%s", dhcp.HostsDir(n.name)), "--expand-hosts")
+			}
 		}
 
 		// Create a config file to contain additional config (and to prevent dnsmasq from reading /etc/dnsmasq.conf)
@@ -1874,6 +2083,16 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			}
 		}
 
+		// Create DHCP options directory (used for the per-instance DHCP options).
+		err = os.MkdirAll(internalUtil.VarPath("networks", n.name, "dnsmasq.opts"), 0o755)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(dnsmasqCmd, "--dhcp-no-override") {
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--dhcp-optsdir=%s", internalUtil.VarPath("networks", n.name, "dnsmasq.opts")))
+		}
+
 		// Check for dnsmasq.
 		_, err := exec.LookPath("dnsmasq")
 		if err != nil {
@@ -1960,6 +2179,56 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Configure the built-in DHCP server.
+	if n.hasBuiltinDHCP() {
+		// Clean up the dnsmasq leases as dnsmasq isn't handing out leases anymore.
+		leasesPath := internalUtil.VarPath("networks", n.name, "dnsmasq.leases")
+		if util.PathExists(leasesPath) {
+			err := os.Remove(leasesPath)
+			if err != nil {
+				return fmt.Errorf("Failed to remove old dnsmasq leases file %q: %w", leasesPath, err)
+			}
+		}
+
+		dhcpConfig, err := n.builtinDHCPConfig(bridge.MTU)
+		if err != nil {
+			return err
+		}
+
+		if dhcpConfig.IPv4Subnet != nil || dhcpConfig.IPv6Subnet != nil {
+			err = dhcp.Start(*dhcpConfig)
+			if err != nil {
+				return fmt.Errorf("Failed to start the built-in DHCP server: %w", err)
+			}
+		}
+	} else {
+		// Clean up old built-in DHCP server state.
+		err = os.RemoveAll(dhcp.LeasesPath(n.name))
+		if err != nil {
+			return fmt.Errorf("Failed to remove old DHCP leases file: %w", err)
+		}
+
+		err = os.RemoveAll(dhcp.HostsDir(n.name))
+		if err != nil {
+			return fmt.Errorf("Failed to remove old DHCP hosts directory: %w", err)
+		}
+	}
+
+	// Configure the built-in TFTP server.
+	tftp.Stop(n.name)
+
+	if n.config["tftp.root"] != "" {
+		ipAddress, _, err := net.ParseCIDR(n.config["ipv4.address"])
+		if err != nil {
+			return fmt.Errorf("Failed parsing ipv4.address: %w", err)
+		}
+
+		err = tftp.Start(n.name, n.config["tftp.root"], ipAddress)
+		if err != nil {
+			return fmt.Errorf("Failed to start the TFTP server: %w", err)
+		}
+	}
+
 	// Setup firewall.
 	n.logger.Debug("Setting up firewall")
 
@@ -2005,12 +2274,24 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
+	// Setup network load balancers.
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
 	// Setup BGP.
 	err = n.bgpSetup(oldConfig)
 	if err != nil {
 		return err
 	}
 
+	// Setup WireGuard tunnels.
+	err = n.wireguardSetup()
+	if err != nil {
+		return err
+	}
+
 	reverter.Success()
 
 	return nil
@@ -2030,6 +2311,12 @@ func (n *bridge) Stop() error {
 		return err
 	}
 
+	// Clear WireGuard tunnels.
+	err = n.wireguardClear()
+	if err != nil {
+		return err
+	}
+
 	err = n.deleteChildren()
 	if err != nil {
 		return fmt.Errorf("Failed to delete bridge children interfaces: %w", err)
@@ -2073,6 +2360,14 @@ func (n *bridge) Stop() error {
 		}
 	}
 
+	// Stop the built-in DHCP, TFTP and DNS64 servers.
+	dhcp.Stop(n.name)
+	tftp.Stop(n.name)
+	dns64.Stop(n.name)
+
+	// Stop the load balancer health checks and proxies.
+	n.loadBalancerStop()
+
 	// Kill any existing dnsmasq daemon for this network
 	err = dnsmasq.Kill(n.name, false)
 	if err != nil {
@@ -2300,87 +2595,316 @@ func (n *bridge) hasDHCPv6() bool {
 	return util.IsTrueOrEmpty(n.config["ipv6.dhcp"])
 }
 
-// DHCPv4Subnet returns the DHCPv4 subnet (if DHCP is enabled on network).
-func (n *bridge) DHCPv4Subnet() *net.IPNet {
-	// DHCP is disabled on this network.
-	if !n.hasDHCPv4() {
-		return nil
+// hasBuiltinDHCP indicates whether the network uses the built-in DHCP server rather than dnsmasq.
+func (n *bridge) hasBuiltinDHCP() bool {
+	return n.config["dhcp.backend"] == "builtin"
+}
+
+// builtinDHCPConfig returns the configuration of the built-in DHCP server for the network.
+func (n *bridge) builtinDHCPConfig(mtu uint32) (*dhcp.Config, error) {
+	config := &dhcp.Config{
+		Network:   n.name,
+		Hostnames: n.config["dns.mode"] != "none",
 	}
 
-	// Return configured bridge subnet directly.
-	_, subnet, err := net.ParseCIDR(n.config["ipv4.address"])
-	if err != nil {
-		return nil
+	if mtu != bridgeMTUDefault {
+		config.MTU = mtu
 	}
 
-	return subnet
-}
+	if config.Hostnames {
+		config.Domain = n.config["dns.domain"]
+		if config.Domain == "" {
+			config.Domain = "incus"
+		}
+	}
 
-// DHCPv6Subnet returns the DHCPv6 subnet (if DHCP or SLAAC is enabled on network).
-func (n *bridge) DHCPv6Subnet() *net.IPNet {
-	// DHCP is disabled on this network.
-	if !n.hasDHCPv6() {
-		return nil
+	if n.config["dns.search"] != "" {
+		config.SearchDomains = util.SplitNTrimSpace(n.config["dns.search"], ",", -1, true)
 	}
 
-	_, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
-	if err != nil {
-		return nil
+	var dnsIPv4 []net.IP
+	var dnsIPv6 []net.IP
+	for _, s := range util.SplitNTrimSpace(n.config["dns.nameservers"], ",", -1, true) {
+		address := net.ParseIP(s)
+		if address.To4() != nil {
+			dnsIPv4 = append(dnsIPv4, address)
+		} else if address != nil {
+			dnsIPv6 = append(dnsIPv6, address)
+		}
 	}
 
-	return subnet
-}
+	// Configure DHCPv4.
+	subnet := n.DHCPv4Subnet()
+	if subnet != nil {
+		ipAddress, _, err := net.ParseCIDR(n.config["ipv4.address"])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing ipv4.address: %w", err)
+		}
 
-// forwardConvertToFirewallForward converts forwards into format compatible with the firewall package.
-func (n *bridge) forwardConvertToFirewallForwards(listenAddress net.IP, defaultTargetAddress net.IP, portMaps []*forwardPortMap) []firewallDrivers.AddressForward {
-	var vips []firewallDrivers.AddressForward
+		config.IPv4Address = ipAddress
+		config.IPv4Subnet = subnet
+		config.IPv4Gateway = ipAddress
+		config.IPv4DNS = []net.IP{ipAddress}
 
-	if defaultTargetAddress != nil {
-		vips = append(vips, firewallDrivers.AddressForward{
-			ListenAddress: listenAddress,
-			TargetAddress: defaultTargetAddress,
-		})
-	}
+		if n.config["ipv4.dhcp.gateway"] != "" {
+			config.IPv4Gateway = net.ParseIP(n.config["ipv4.dhcp.gateway"])
+		}
 
-	for _, portMap := range portMaps {
-		vips = append(vips, firewallDrivers.AddressForward{
-			ListenAddress: listenAddress,
-			Protocol:      portMap.protocol,
-			TargetAddress: portMap.target.address,
-			ListenPorts:   portMap.listenPorts,
-			TargetPorts:   portMap.target.ports,
-			SNAT:          portMap.snat,
-		})
-	}
+		if n.config["dns.nameservers"] != "" {
+			config.IPv4DNS = dnsIPv4
+		}
 
-	return vips
-}
+		if n.config["ipv4.dhcp.ranges"] != "" {
+			ranges, err := parseIPRanges(n.config["ipv4.dhcp.ranges"], subnet)
+			if err != nil {
+				return nil, fmt.Errorf("Failed parsing ipv4.dhcp.ranges: %w", err)
+			}
 
-// bridgeProjectNetworks takes a map of all networks in all projects and returns a filtered map of bridge networks.
-func (n *bridge) bridgeProjectNetworks(projectNetworks map[string]map[int64]api.Network) map[string][]*api.Network {
-	bridgeProjectNetworks := make(map[string][]*api.Network)
-	for netProject, networks := range projectNetworks {
-		for _, ni := range networks {
-			network := ni // Local var creating pointer to rather than iterator.
+			for _, r := range ranges {
+				config.IPv4Ranges = append(config.IPv4Ranges, *r)
+			}
+		} else {
+			config.IPv4Ranges = []iprange.Range{{Start: dhcpalloc.GetIP(subnet, 2), End: dhcpalloc.GetIP(subnet, -2)}}
+		}
 
-			// Skip non-bridge networks.
-			if network.Type != "bridge" {
-				continue
+		routes := util.SplitNTrimSpace(n.config["ipv4.dhcp.routes"], ",", -1, true)
+		for i := 0; i+1 < len(routes); i += 2 {
+			_, route, err := net.ParseCIDR(routes[i])
+			if err != nil {
+				return nil, fmt.Errorf("Failed parsing ipv4.dhcp.routes: %w", err)
 			}
 
-			if bridgeProjectNetworks[netProject] == nil {
-				bridgeProjectNetworks[netProject] = []*api.Network{&network}
-			} else {
-				bridgeProjectNetworks[netProject] = append(bridgeProjectNetworks[netProject], &network)
+			config.IPv4Routes = append(config.IPv4Routes, route)
+			config.IPv4RouteVia = append(config.IPv4RouteVia, net.ParseIP(routes[i+1]))
+		}
+
+		config.IPv4LeaseTime = time.Hour
+		if n.config["ipv4.dhcp.expiry"] != "" {
+			config.IPv4LeaseTime, err = dhcp.ParseLeaseTime(n.config["ipv4.dhcp.expiry"])
+			if err != nil {
+				return nil, fmt.Errorf("Failed parsing ipv4.dhcp.expiry: %w", err)
 			}
 		}
 	}
 
-	return bridgeProjectNetworks
-}
+	// Configure router advertisements and DHCPv6.
+	if !util.IsNoneOrEmpty(n.config["ipv6.address"]) {
+		ipAddress, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing ipv6.address: %w", err)
+		}
 
-// bridgeNetworkExternalSubnets returns a list of external subnets used by bridge networks. Networks are considered
-// to be using external subnets for their ipv4.address and/or ipv6.address if they have NAT disabled, and/or if
+		// Only global addresses are advertised.
+		if !ipAddress.IsLinkLocalUnicast() {
+			// Resolve the generated host address.
+			if ipAddress.Equal(subnet.IP) {
+				iface, err := net.InterfaceByName(n.name)
+				if err != nil {
+					return nil, err
+				}
+
+				ipAddress, err = eui64.ParseMAC(subnet.IP, iface.HardwareAddr)
+				if err != nil {
+					return nil, fmt.Errorf("Failed generating EUI64 value for ipv6.address: %w", err)
+				}
+			}
+
+			config.IPv6Address = ipAddress
+			config.IPv6Subnet = subnet
+			config.IPv6DHCP = n.DHCPv6Subnet() != nil
+			config.IPv6Stateful = config.IPv6DHCP && util.IsTrue(n.config["ipv6.dhcp.stateful"])
+			config.IPv6DNS = []net.IP{ipAddress}
+
+			if n.config["dns.nameservers"] != "" {
+				config.IPv6DNS = dnsIPv6
+			}
+
+			if n.config["ipv6.dhcp.ranges"] != "" {
+				ranges, err := parseIPRanges(n.config["ipv6.dhcp.ranges"], subnet)
+				if err != nil {
+					return nil, fmt.Errorf("Failed parsing ipv6.dhcp.ranges: %w", err)
+				}
+
+				for _, r := range ranges {
+					config.IPv6Ranges = append(config.IPv6Ranges, *r)
+				}
+			} else if config.IPv6Stateful {
+				config.IPv6Ranges = []iprange.Range{{Start: dhcpalloc.GetIP(subnet, 2), End: dhcpalloc.GetIP(subnet, -1)}}
+			}
+
+			config.IPv6LeaseTime = time.Hour
+			if n.config["ipv6.dhcp.expiry"] != "" {
+				config.IPv6LeaseTime, err = dhcp.ParseLeaseTime(n.config["ipv6.dhcp.expiry"])
+				if err != nil {
+					return nil, fmt.Errorf("Failed parsing ipv6.dhcp.expiry: %w", err)
+				}
+			}
+		}
+	}
+
+	var err error
+	config.IPv4Options, err = dhcp.ParseOptions(n.config, false)
+	if err != nil {
+		return nil, err
+	}
+
+	config.IPv6Options, err = dhcp.ParseOptions(n.config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Static allocations come from the instance NICs.
+	config.StaticLeases = func() ([]dhcp.StaticLease, error) {
+		entries, err := os.ReadDir(internalUtil.VarPath("networks", n.name, "dnsmasq.hosts"))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		leases := make([]dhcp.StaticLease, 0, len(entries))
+		for _, entry := range entries {
+			mac, IPv4, IPv6, err := dnsmasq.DHCPStaticAllocation(n.name, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+
+			lease := dhcp.StaticLease{Hwaddr: mac, IPv4: IPv4.IP, IPv6: IPv6.IP, Hostname: IPv4.Hostname}
+			if lease.Hostname == "" {
+				lease.Hostname = IPv6.Hostname
+			}
+
+			lease.Options, err = dnsmasq.DHCPStaticOptions(n.name, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+
+			leases = append(leases, lease)
+		}
+
+		return leases, nil
+	}
+
+	return config, nil
+}
+
+// bridgeDHCPRanges returns the ranges the DHCP server of a bridge network hands out addresses from for the
+// address family of keyPrefix. No ranges are returned when addresses aren't allocated from predefined ranges.
+func bridgeDHCPRanges(config map[string]string, keyPrefix string) ([]*iprange.Range, error) {
+	if util.IsNoneOrEmpty(config[keyPrefix+".address"]) || !util.IsTrueOrEmpty(config[keyPrefix+".dhcp"]) {
+		return nil, nil
+	}
+
+	// Stateless DHCPv6 relies on SLAAC to generate the client addresses.
+	if keyPrefix == "ipv6" && util.IsFalseOrEmpty(config["ipv6.dhcp.stateful"]) {
+		return nil, nil
+	}
+
+	_, subnet, err := net.ParseCIDR(config[keyPrefix+".address"])
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing %s.address: %w", keyPrefix, err)
+	}
+
+	if config[keyPrefix+".dhcp.ranges"] != "" {
+		ranges, err := parseIPRanges(config[keyPrefix+".dhcp.ranges"], subnet)
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing %s.dhcp.ranges: %w", keyPrefix, err)
+		}
+
+		return ranges, nil
+	}
+
+	// Same default ranges as used by the DHCP server.
+	if keyPrefix == "ipv6" {
+		return []*iprange.Range{{Start: dhcpalloc.GetIP(subnet, 2), End: dhcpalloc.GetIP(subnet, -1)}}, nil
+	}
+
+	return []*iprange.Range{{Start: dhcpalloc.GetIP(subnet, 2), End: dhcpalloc.GetIP(subnet, -2)}}, nil
+}
+
+// DHCPv4Subnet returns the DHCPv4 subnet (if DHCP is enabled on network).
+func (n *bridge) DHCPv4Subnet() *net.IPNet {
+	// DHCP is disabled on this network.
+	if !n.hasDHCPv4() {
+		return nil
+	}
+
+	// Return configured bridge subnet directly.
+	_, subnet, err := net.ParseCIDR(n.config["ipv4.address"])
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// DHCPv6Subnet returns the DHCPv6 subnet (if DHCP or SLAAC is enabled on network).
+func (n *bridge) DHCPv6Subnet() *net.IPNet {
+	// DHCP is disabled on this network.
+	if !n.hasDHCPv6() {
+		return nil
+	}
+
+	_, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// forwardConvertToFirewallForward converts forwards into format compatible with the firewall package.
+func (n *bridge) forwardConvertToFirewallForwards(listenAddress net.IP, defaultTargetAddress net.IP, portMaps []*forwardPortMap) []firewallDrivers.AddressForward {
+	var vips []firewallDrivers.AddressForward
+
+	if defaultTargetAddress != nil {
+		vips = append(vips, firewallDrivers.AddressForward{
+			ListenAddress: listenAddress,
+			TargetAddress: defaultTargetAddress,
+		})
+	}
+
+	for _, portMap := range portMaps {
+		vips = append(vips, firewallDrivers.AddressForward{
+			ListenAddress: listenAddress,
+			Protocol:      portMap.protocol,
+			TargetAddress: portMap.target.address,
+			ListenPorts:   portMap.listenPorts,
+			TargetPorts:   portMap.target.ports,
+			SNAT:          portMap.snat,
+		})
+	}
+
+	return vips
+}
+
+// bridgeProjectNetworks takes a map of all networks in all projects and returns a filtered map of bridge networks.
+func (n *bridge) bridgeProjectNetworks(projectNetworks map[string]map[int64]api.Network) map[string][]*api.Network {
+	bridgeProjectNetworks := make(map[string][]*api.Network)
+	for netProject, networks := range projectNetworks {
+		for _, ni := range networks {
+			network := ni // Local var creating pointer to rather than iterator.
+
+			// Skip non-bridge networks.
+			if network.Type != "bridge" {
+				continue
+			}
+
+			if bridgeProjectNetworks[netProject] == nil {
+				bridgeProjectNetworks[netProject] = []*api.Network{&network}
+			} else {
+				bridgeProjectNetworks[netProject] = append(bridgeProjectNetworks[netProject], &network)
+			}
+		}
+	}
+
+	return bridgeProjectNetworks
+}
+
+// bridgeNetworkExternalSubnets returns a list of external subnets used by bridge networks. Networks are considered
+// to be using external subnets for their ipv4.address and/or ipv6.address if they have NAT disabled, and/or if
 // they have external NAT addresses specified.
 func (n *bridge) bridgeNetworkExternalSubnets(bridgeProjectNetworks map[string][]*api.Network) ([]externalSubnetUsage, error) {
 	externalSubnets := make([]externalSubnetUsage, 0)
@@ -3213,96 +3737,1568 @@ func (n *bridge) forwardSetupFirewall() error {
 	return nil
 }
 
-// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
-// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
-func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
-	var err error
-	var projectMacs []string
-	leases := []api.NetworkLease{}
+// LoadBalancerCreate creates a network load balancer.
+func (n *bridge) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	reverter := revert.New()
+	defer reverter.Fail()
 
-	// Get all static leases.
 	if clientType == request.ClientTypeNormal {
-		// If requested project matches network's project then include gateway and downstream uplink IPs.
-		if projectName == n.project {
-			// Add our own gateway IPs.
-			for _, addr := range []string{n.config["ipv4.address"], n.config["ipv6.address"]} {
-				ip, _, _ := net.ParseCIDR(addr)
-				if ip != nil {
-					leases = append(leases, api.NetworkLease{
-						Hostname: fmt.Sprintf("%s.gw", n.Name()),
-						Address:  ip.String(),
-						Type:     "gateway",
-					})
-				}
-			}
-
-			// Include downstream OVN routers using the network as an uplink.
-			var projectNetworks map[string]map[int64]api.Network
-			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-				projectNetworks, err = tx.GetCreatedNetworks(ctx)
-				return err
-			})
+		err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			// Check if there is an existing load balancer using the same listen address.
+			_, err := dbCluster.GetNetworkLoadBalancer(ctx, tx.Tx(), n.ID(), loadBalancer.ListenAddress)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			// Look for networks using the current network as an uplink.
-			for projectName, networks := range projectNetworks {
-				for _, network := range networks {
-					if network.Config["network"] != n.name {
-						continue
-					}
+			return nil
+		})
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+		}
 
-					// Found a network, add leases.
-					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
-						v := network.Config[k]
-						if v != "" {
-							leases = append(leases, api.NetworkLease{
-								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
-								Address:  v,
-								Type:     "uplink",
-							})
-						}
-					}
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		_, err = n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+
+		externalSubnetsInUse, err := n.getExternalSubnetInUse()
+		if err != nil {
+			return err
+		}
+
+		// Check the listen address subnet doesn't fall within any existing network external subnets.
+		for _, externalSubnetUser := range externalSubnetsInUse {
+			// Check if usage is from our own network.
+			if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+				// Skip checking conflict with our own network's subnet or SNAT address.
+				// But do not allow other conflict with other usage types within our own network.
+				if externalSubnetUser.usageType == subnetUsageNetwork || externalSubnetUser.usageType == subnetUsageNetworkSNAT {
+					continue
 				}
 			}
-		}
 
-		// Get all the instances in the requested project that are connected to this network.
-		filter := dbCluster.InstanceFilter{Project: &projectName}
-		err = UsedByInstanceDevices(n.state, n.Project(), n.Name(), n.Type(), func(inst db.InstanceArgs, nicName string, nicConfig map[string]string) error {
-			// Fill in the hwaddr from volatile.
-			if nicConfig["hwaddr"] == "" {
-				nicConfig["hwaddr"] = inst.Config[fmt.Sprintf("volatile.%s.hwaddr", nicName)]
+			if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+				// This error is purposefully vague so that it doesn't reveal any names of
+				// resources potentially outside of the network.
+				return fmt.Errorf("Load balancer listen address %q overlaps with another network or NIC", listenAddressNet.String())
 			}
+		}
 
-			// Record the MAC.
-			hwAddr, _ := net.ParseMAC(nicConfig["hwaddr"])
-			if hwAddr != nil {
-				projectMacs = append(projectMacs, hwAddr.String())
+		var loadBalancerID int64
+
+		err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			// Create load balancer DB record.
+			lb := dbCluster.NetworkLoadBalancer{
+				NetworkID:     n.ID(),
+				ListenAddress: loadBalancer.ListenAddress,
+				Description:   loadBalancer.Description,
+				Backends:      loadBalancer.Backends,
+				Ports:         loadBalancer.Ports,
 			}
 
-			// Add the lease.
-			nicIP4 := net.ParseIP(nicConfig["ipv4.address"])
-			if nicIP4 != nil {
-				leases = append(leases, api.NetworkLease{
-					Hostname: inst.Name,
-					Address:  nicIP4.String(),
-					Hwaddr:   hwAddr.String(),
-					Type:     "static",
-					Location: inst.Node,
-				})
+			loadBalancerID, err = dbCluster.CreateNetworkLoadBalancer(ctx, tx.Tx(), lb)
+			if err != nil {
+				return err
 			}
 
-			nicIP6 := net.ParseIP(nicConfig["ipv6.address"])
-			if nicIP6 != nil {
-				leases = append(leases, api.NetworkLease{
-					Hostname: inst.Name,
-					Address:  nicIP6.String(),
-					Hwaddr:   hwAddr.String(),
-					Type:     "static",
-					Location: inst.Node,
-				})
+			// Save the load balancer configuration.
+			err = dbCluster.CreateNetworkLoadBalancerConfig(ctx, tx.Tx(), loadBalancerID, loadBalancer.Config)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		reverter.Add(func() {
+			_ = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				return dbCluster.DeleteNetworkLoadBalancer(ctx, tx.Tx(), n.ID(), loadBalancerID)
+			})
+
+			healthcheck.Stop(n.loadBalancerKey(loadBalancer.ListenAddress))
+			n.loadBalancerStopProxy(loadBalancer.ListenAddress)
+			_ = n.loadBalancerSetupFirewall()
+		})
+	}
+
+	err := n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	if clientType == request.ClientTypeNormal {
+		// Notify all other members to apply the load balancer.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client incus.InstanceServer) error {
+			return client.UseProject(n.project).CreateNetworkLoadBalancer(n.name, loadBalancer)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	reverter.Success()
+	return nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *bridge) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		var curLoadBalancer *api.NetworkLoadBalancer
+		var curLoadBalancerID int64
+
+		err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			networkID := n.ID()
+
+			// Get the load balancer.
+			dbLoadBalancers, err := dbCluster.GetNetworkLoadBalancers(ctx, tx.Tx(), dbCluster.NetworkLoadBalancerFilter{
+				NetworkID:     &networkID,
+				ListenAddress: &listenAddress,
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(dbLoadBalancers) != 1 {
+				return api.StatusErrorf(http.StatusNotFound, "Network load balancer not found")
+			}
+
+			// Get the API struct.
+			curLoadBalancer, err = dbLoadBalancers[0].ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			curLoadBalancerID = dbLoadBalancers[0].ID
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		loadBalancerKeepSecrets(curLoadBalancer.Config, &req)
+
+		_, err = n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req)
+		if err != nil {
+			return err
+		}
+
+		curEtagHash, err := localUtil.EtagHash(curLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		newLoadBalancer := api.NetworkLoadBalancer{
+			ListenAddress:          curLoadBalancer.ListenAddress,
+			NetworkLoadBalancerPut: req,
+		}
+
+		newLoadBalancerEtagHash, err := localUtil.EtagHash(newLoadBalancer.Etag())
+		if err != nil {
+			return err
+		}
+
+		if curEtagHash == newLoadBalancerEtagHash {
+			return nil // Nothing has changed.
+		}
+
+		err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			lb := dbCluster.NetworkLoadBalancer{
+				NetworkID:     n.ID(),
+				ListenAddress: listenAddress,
+				Description:   newLoadBalancer.Description,
+				Backends:      newLoadBalancer.Backends,
+				Ports:         newLoadBalancer.Ports,
+			}
+
+			err = dbCluster.UpdateNetworkLoadBalancer(ctx, tx.Tx(), n.ID(), listenAddress, lb)
+			if err != nil {
+				return err
+			}
+
+			err = dbCluster.UpdateNetworkLoadBalancerConfig(ctx, tx.Tx(), curLoadBalancerID, newLoadBalancer.Config)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		reverter.Add(func() {
+			_ = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				lb := dbCluster.NetworkLoadBalancer{
+					NetworkID:     n.ID(),
+					ListenAddress: listenAddress,
+					Description:   curLoadBalancer.Description,
+					Backends:      curLoadBalancer.Backends,
+					Ports:         curLoadBalancer.Ports,
+				}
+
+				err = dbCluster.UpdateNetworkLoadBalancer(ctx, tx.Tx(), n.ID(), listenAddress, lb)
+				if err != nil {
+					return err
+				}
+
+				err = dbCluster.UpdateNetworkLoadBalancerConfig(ctx, tx.Tx(), curLoadBalancerID, curLoadBalancer.Config)
+				if err != nil {
+					return err
+				}
+
+				return nil
+			})
+
+			_ = n.loadBalancerSetupFirewall()
+		})
+	}
+
+	err := n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	if clientType == request.ClientTypeNormal {
+		// Notify all other members to apply the updated load balancer.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client incus.InstanceServer) error {
+			return client.UseProject(n.project).UpdateNetworkLoadBalancer(n.name, listenAddress, req, "")
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	reverter.Success()
+	return nil
+}
+
+// LoadBalancerState returns the current state of the load balancer as seen from the local member.
+func (n *bridge) LoadBalancerState(lb api.NetworkLoadBalancer) (*api.NetworkLoadBalancerState, error) {
+	lbState := &api.NetworkLoadBalancerState{}
+
+	key := n.loadBalancerKey(lb.ListenAddress)
+	healthChecked := util.IsTrue(lb.Config["healthcheck"])
+	proxyMetrics := lbproxy.BackendMetrics(n.loadBalancerPath(lb.ListenAddress))
+
+	if healthChecked {
+		lbState.BackendHealth = map[string]api.NetworkLoadBalancerStateBackendHealth{}
+	}
+
+	if len(proxyMetrics) > 0 {
+		lbState.BackendMetrics = map[string]api.NetworkLoadBalancerStateBackendMetrics{}
+	}
+
+	for _, backend := range lb.Backends {
+		backendHealth := api.NetworkLoadBalancerStateBackendHealth{}
+		backendHealth.Address = backend.TargetAddress
+		backendHealth.Ports = []api.NetworkLoadBalancerStateBackendHealthPort{}
+
+		backendMetrics := api.NetworkLoadBalancerStateBackendMetrics{}
+		seenTargets := map[string]struct{}{}
+
+		var targetPorts []uint64
+		for _, pr := range util.SplitNTrimSpace(backend.TargetPort, ",", -1, true) {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port in backend %q: %w", backend.Name, err)
+			}
+
+			for i := range portRange {
+				targetPorts = append(targetPorts, uint64(portFirst+i))
+			}
+		}
+
+		for _, lbPort := range lb.Ports {
+			if !slices.Contains(lbPort.TargetBackend, backend.Name) {
+				continue
+			}
+
+			var listenPorts []uint64
+			for _, pr := range util.SplitNTrimSpace(lbPort.ListenPort, ",", -1, true) {
+				portFirst, portRange, err := ParsePortRange(pr)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid listen port in port specification %q: %w", lbPort.ListenPort, err)
+				}
+
+				for i := range portRange {
+					listenPorts = append(listenPorts, uint64(portFirst+i))
+				}
+			}
+
+			checkProtocol := loadBalancerCheckProtocol(lbPort.Protocol)
+
+			for i := range listenPorts {
+				port := loadBalancerTargetPort(targetPorts, listenPorts, i)
+
+				target := net.JoinHostPort(backend.TargetAddress, strconv.FormatUint(port, 10))
+				_, seen := seenTargets[checkProtocol+"/"+target]
+				if seen {
+					continue
+				}
+
+				seenTargets[checkProtocol+"/"+target] = struct{}{}
+
+				if healthChecked {
+					portHealth := api.NetworkLoadBalancerStateBackendHealthPort{
+						Protocol: checkProtocol,
+						Port:     int(port),
+						Status:   healthcheck.Status(key, healthcheck.Target{Protocol: checkProtocol, Address: backend.TargetAddress, Port: port}),
+					}
+
+					backendHealth.Ports = append(backendHealth.Ports, portHealth)
+				}
+
+				metrics, found := proxyMetrics[target]
+				if found {
+					backendMetrics.Requests += metrics.Requests
+					backendMetrics.Failures += metrics.Failures
+				}
+			}
+		}
+
+		if healthChecked {
+			lbState.BackendHealth[backend.Name] = backendHealth
+		}
+
+		if lbState.BackendMetrics != nil {
+			lbState.BackendMetrics[backend.Name] = backendMetrics
+		}
+	}
+
+	return lbState, nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *bridge) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	if clientType == request.ClientTypeNormal {
+		var lb *api.NetworkLoadBalancer
+		var lbID int64
+
+		err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			networkID := n.ID()
+
+			dbLoadBalancers, err := dbCluster.GetNetworkLoadBalancers(ctx, tx.Tx(), dbCluster.NetworkLoadBalancerFilter{
+				NetworkID:     &networkID,
+				ListenAddress: &listenAddress,
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(dbLoadBalancers) != 1 {
+				return api.StatusErrorf(http.StatusNotFound, "Network load balancer not found")
+			}
+
+			lbID = dbLoadBalancers[0].ID
+			lb, err = dbLoadBalancers[0].ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Delete the database records.
+		err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return dbCluster.DeleteNetworkLoadBalancer(ctx, tx.Tx(), n.ID(), lbID)
+		})
+		if err != nil {
+			return err
+		}
+
+		reverter.Add(func() {
+			_ = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				dbRecord := dbCluster.NetworkLoadBalancer{
+					NetworkID:     n.ID(),
+					ListenAddress: lb.ListenAddress,
+					Description:   lb.Description,
+					Backends:      lb.Backends,
+					Ports:         lb.Ports,
+				}
+
+				lbID, err = dbCluster.CreateNetworkLoadBalancer(ctx, tx.Tx(), dbRecord)
+				if err != nil {
+					return err
+				}
+
+				return dbCluster.CreateNetworkLoadBalancerConfig(ctx, tx.Tx(), lbID, lb.Config)
+			})
+
+			_ = n.loadBalancerSetupFirewall()
+		})
+	}
+
+	healthcheck.Stop(n.loadBalancerKey(listenAddress))
+	n.loadBalancerStopProxy(listenAddress)
+
+	err := n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	if clientType == request.ClientTypeNormal {
+		// Notify all other members to remove the load balancer.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client incus.InstanceServer) error {
+			return client.UseProject(n.project).DeleteNetworkLoadBalancer(n.name, listenAddress)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	reverter.Success()
+	return nil
+}
+
+// loadBalancerKey returns the name used for the health checks of a load balancer.
+func (n *bridge) loadBalancerKey(listenAddress string) string {
+	return fmt.Sprintf("%s/%s", n.name, listenAddress)
+}
+
+// loadBalancerPath returns the directory holding the proxy process files and the ACME certificates of a load balancer.
+func (n *bridge) loadBalancerPath(listenAddress string) string {
+	return internalUtil.VarPath("networks", n.name, "load-balancers", listenAddress)
+}
+
+// loadBalancerTargetPort returns the target port for the listen port at the given index.
+// An empty list of target ports maps to the listen port and a single target port is used for all listen ports.
+func loadBalancerTargetPort(targetPorts []uint64, listenPorts []uint64, index int) uint64 {
+	switch len(targetPorts) {
+	case 0:
+		return listenPorts[index]
+	case 1:
+		return targetPorts[0]
+	default:
+		return targetPorts[index]
+	}
+}
+
+// loadBalancerCheckProtocol returns the transport protocol used to reach the backends of a port.
+func loadBalancerCheckProtocol(protocol string) string {
+	if protocol == "udp" {
+		return "udp"
+	}
+
+	return "tcp"
+}
+
+// loadBalancerLoad returns the load balancers defined for this network.
+func (n *bridge) loadBalancerLoad() ([]*api.NetworkLoadBalancer, error) {
+	var loadBalancers []*api.NetworkLoadBalancer
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		networkID := n.ID()
+
+		dbLoadBalancers, err := dbCluster.GetNetworkLoadBalancers(ctx, tx.Tx(), dbCluster.NetworkLoadBalancerFilter{
+			NetworkID: &networkID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, dbLoadBalancer := range dbLoadBalancers {
+			lb, err := dbLoadBalancer.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			loadBalancers = append(loadBalancers, lb)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	return loadBalancers, nil
+}
+
+// loadBalancerStop stops the health checks and proxies of all network load balancers.
+func (n *bridge) loadBalancerStop() {
+	loadBalancers, err := n.loadBalancerLoad()
+	if err != nil {
+		return
+	}
+
+	for _, lb := range loadBalancers {
+		healthcheck.Stop(n.loadBalancerKey(lb.ListenAddress))
+		n.loadBalancerStopProxy(lb.ListenAddress)
+	}
+}
+
+// loadBalancerSetupFirewall starts the health checks and applies the firewall rules of all network load balancers.
+func (n *bridge) loadBalancerSetupFirewall() error {
+	loadBalancers, err := n.loadBalancerLoad()
+	if err != nil {
+		return err
+	}
+
+	for _, lb := range loadBalancers {
+		key := n.loadBalancerKey(lb.ListenAddress)
+
+		if !util.IsTrue(lb.Config["healthcheck"]) {
+			healthcheck.Stop(key)
+			continue
+		}
+
+		portMaps, err := n.loadBalancerValidate(net.ParseIP(lb.ListenAddress), &lb.NetworkLoadBalancerPut)
+		if err != nil {
+			return fmt.Errorf("Failed validating load balancer %q: %w", lb.ListenAddress, err)
+		}
+
+		hcConfig := healthcheck.Config{
+			Interval:     time.Duration(loadBalancerHealthCheckValue(lb.Config, "healthcheck.interval", 10)) * time.Second,
+			Timeout:      time.Duration(loadBalancerHealthCheckValue(lb.Config, "healthcheck.timeout", 30)) * time.Second,
+			SuccessCount: loadBalancerHealthCheckValue(lb.Config, "healthcheck.success_count", 3),
+			FailureCount: loadBalancerHealthCheckValue(lb.Config, "healthcheck.failure_count", 3),
+			OnChange: func() {
+				err := n.loadBalancerApplyFirewall()
+				if err != nil {
+					n.logger.Error("Failed applying load balancer firewall rules", logger.Ctx{"err": err})
+				}
+			},
+		}
+
+		for _, portMap := range portMaps {
+			for _, target := range portMap.targets {
+				for i := range portMap.listenPorts {
+					hcTarget := healthcheck.Target{
+						Protocol: loadBalancerCheckProtocol(portMap.protocol),
+						Address:  target.address.String(),
+						Port:     loadBalancerTargetPort(target.ports, portMap.listenPorts, i),
+					}
+
+					if !slices.Contains(hcConfig.Targets, hcTarget) {
+						hcConfig.Targets = append(hcConfig.Targets, hcTarget)
+					}
+				}
+			}
+		}
+
+		healthcheck.Start(key, hcConfig)
+	}
+
+	return n.loadBalancerApplyFirewall()
+}
+
+// loadBalancerApplyFirewall applies the firewall rules and layer 7 proxies of all network load balancers,
+// leaving out offline backends.
+func (n *bridge) loadBalancerApplyFirewall() error {
+	loadBalancers, err := n.loadBalancerLoad()
+	if err != nil {
+		return err
+	}
+
+	var fwLoadBalancers []firewallDrivers.LoadBalancer
+
+	for _, lb := range loadBalancers {
+		listenAddress := net.ParseIP(lb.ListenAddress)
+
+		portMaps, err := n.loadBalancerValidate(listenAddress, &lb.NetworkLoadBalancerPut)
+		if err != nil {
+			return fmt.Errorf("Failed validating load balancer %q: %w", lb.ListenAddress, err)
+		}
+
+		key := n.loadBalancerKey(lb.ListenAddress)
+		healthChecked := util.IsTrue(lb.Config["healthcheck"])
+
+		var frontends []lbproxy.Frontend
+		frontendIndex := map[uint64]int{}
+
+		for _, portMap := range portMaps {
+			var backends []firewallDrivers.LoadBalancerBackend
+
+			for _, target := range portMap.targets {
+				if healthChecked && n.loadBalancerTargetOffline(key, portMap, target) {
+					continue
+				}
+
+				backends = append(backends, firewallDrivers.LoadBalancerBackend{
+					TargetAddress: target.address,
+					TargetPorts:   target.ports,
+				})
+			}
+
+			if !portMap.isL7() {
+				fwLoadBalancers = append(fwLoadBalancers, firewallDrivers.LoadBalancer{
+					ListenAddress: listenAddress,
+					Protocol:      portMap.protocol,
+					ListenPorts:   portMap.listenPorts,
+					Backends:      backends,
+				})
+
+				continue
+			}
+
+			// Layer 7 ports are routed by the proxy, grouping the routes sharing a listen port.
+			for i, listenPort := range portMap.listenPorts {
+				route := lbproxy.Route{
+					Host: portMap.host,
+					Path: portMap.path,
+				}
+
+				for _, backend := range backends {
+					targetPort := loadBalancerTargetPort(backend.TargetPorts, portMap.listenPorts, i)
+					route.Backends = append(route.Backends, net.JoinHostPort(backend.TargetAddress.String(), strconv.FormatUint(targetPort, 10)))
+				}
+
+				idx, found := frontendIndex[listenPort]
+				if !found {
+					idx = len(frontends)
+					frontendIndex[listenPort] = idx
+					frontends = append(frontends, lbproxy.Frontend{Protocol: portMap.protocol, ListenPort: listenPort})
+				}
+
+				frontends[idx].Routes = append(frontends[idx].Routes, route)
+			}
+		}
+
+		if len(frontends) == 0 {
+			n.loadBalancerStopProxy(lb.ListenAddress)
+			continue
+		}
+
+		proxyRules, err := n.loadBalancerStartProxy(lb, frontends)
+		if err != nil {
+			return fmt.Errorf("Failed starting proxy of load balancer %q: %w", lb.ListenAddress, err)
+		}
+
+		fwLoadBalancers = append(fwLoadBalancers, proxyRules...)
+	}
+
+	err = n.state.Firewall.NetworkApplyLoadBalancers(n.name, fwLoadBalancers)
+	if err != nil {
+		return fmt.Errorf("Failed applying firewall load balancers: %w", err)
+	}
+
+	return nil
+}
+
+// loadBalancerStartProxy starts the layer 7 proxy of a load balancer on the network's gateway address.
+// It returns the firewall rules redirecting the listen ports to the proxy.
+func (n *bridge) loadBalancerStartProxy(lb *api.NetworkLoadBalancer, frontends []lbproxy.Frontend) ([]firewallDrivers.LoadBalancer, error) {
+	listenAddress := net.ParseIP(lb.ListenAddress)
+
+	netIPKey := "ipv4.address"
+	if listenAddress.To4() == nil {
+		netIPKey = "ipv6.address"
+	}
+
+	proxyAddress, _, err := net.ParseCIDR(n.config[netIPKey])
+	if err != nil {
+		return nil, fmt.Errorf("Layer 7 load balancing requires %q to be set on the network", netIPKey)
+	}
+
+	path := n.loadBalancerPath(lb.ListenAddress)
+
+	config := lbproxy.Config{
+		Address:   proxyAddress,
+		Frontends: frontends,
+	}
+
+	if lb.Config["tls.certificate"] != "" {
+		config.Certificate = lb.Config["tls.certificate"]
+		config.Key = lb.Config["tls.key"]
+
+		n.loadBalancerStopRenewal(path)
+	} else if util.IsTrue(lb.Config["tls.acme"]) {
+		for _, port := range lb.Ports {
+			domain := strings.ToLower(port.Host)
+			if port.Protocol == "https" && !slices.Contains(config.CertificateDomains, domain) {
+				config.CertificateDomains = append(config.CertificateDomains, domain)
+			}
+		}
+
+		// The proxy process loads the certificates from disk as they get issued.
+		config.CertificatesPath = path
+
+		// Let the HTTP-01 challenges reach the ACME client.
+		config.ACMEChallenge = n.state.GlobalConfig.ACMEHTTP()
+		if strings.HasPrefix(config.ACMEChallenge, ":") {
+			config.ACMEChallenge = "127.0.0.1" + config.ACMEChallenge
+		}
+
+		// Get the certificates ready before the first request and keep them renewed.
+		for _, domain := range config.CertificateDomains {
+			_, _ = n.loadBalancerACMECertificate(lb.ListenAddress, domain)
+		}
+
+		n.loadBalancerStartRenewal(lb.ListenAddress, config.CertificateDomains)
+	} else {
+		n.loadBalancerStopRenewal(path)
+	}
+
+	ports, err := lbproxy.Start(n.state.OS.ExecPath, path, config)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]firewallDrivers.LoadBalancer, 0, len(frontends))
+	for _, frontend := range frontends {
+		rules = append(rules, firewallDrivers.LoadBalancer{
+			ListenAddress: listenAddress,
+			Protocol:      "tcp",
+			ListenPorts:   []uint64{frontend.ListenPort},
+			Backends: []firewallDrivers.LoadBalancerBackend{{
+				TargetAddress: proxyAddress,
+				TargetPorts:   []uint64{ports[frontend.ListenPort]},
+			}},
+		})
+	}
+
+	return rules, nil
+}
+
+// loadBalancerStopProxy stops the layer 7 proxy of a load balancer along with the renewal of its certificates.
+func (n *bridge) loadBalancerStopProxy(listenAddress string) {
+	path := n.loadBalancerPath(listenAddress)
+
+	n.loadBalancerStopRenewal(path)
+	lbproxy.Stop(path)
+}
+
+// loadBalancerRenewals holds the functions stopping the renewal of the ACME certificates of load balancers by path.
+var loadBalancerRenewals = map[string]context.CancelFunc{}
+var loadBalancerRenewalsMu sync.Mutex
+
+// loadBalancerStartRenewal periodically checks the ACME certificates of a load balancer, issuing the missing or
+// expiring ones. The proxy process picks them up from disk.
+func (n *bridge) loadBalancerStartRenewal(listenAddress string, domains []string) {
+	path := n.loadBalancerPath(listenAddress)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	loadBalancerRenewalsMu.Lock()
+	stopRenewal := loadBalancerRenewals[path]
+	loadBalancerRenewals[path] = cancel
+	loadBalancerRenewalsMu.Unlock()
+
+	if stopRenewal != nil {
+		stopRenewal()
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, domain := range domains {
+					_, _ = n.loadBalancerACMECertificate(listenAddress, domain)
+				}
+			}
+		}
+	}()
+}
+
+// loadBalancerStopRenewal stops the renewal of the ACME certificates of a load balancer (if running).
+func (n *bridge) loadBalancerStopRenewal(path string) {
+	loadBalancerRenewalsMu.Lock()
+	stopRenewal := loadBalancerRenewals[path]
+	delete(loadBalancerRenewals, path)
+	loadBalancerRenewalsMu.Unlock()
+
+	if stopRenewal != nil {
+		stopRenewal()
+	}
+}
+
+// loadBalancerCertificates caches the ACME certificates of load balancers by path.
+var loadBalancerCertificates = map[string]*tls.Certificate{}
+var loadBalancerCertificatesIssuing = map[string]bool{}
+var loadBalancerCertificatesMu sync.Mutex
+
+// loadBalancerACMECertificate returns the ACME certificate of a load balancer domain.
+// Missing or expiring certificates are issued in the background, the current certificate being used meanwhile.
+func (n *bridge) loadBalancerACMECertificate(listenAddress string, domain string) (*tls.Certificate, error) {
+	path := filepath.Join(n.loadBalancerPath(listenAddress), domain)
+
+	loadBalancerCertificatesMu.Lock()
+	defer loadBalancerCertificatesMu.Unlock()
+
+	cert := loadBalancerCertificates[path]
+	if cert == nil {
+		keyPair, err := tls.LoadX509KeyPair(path+".crt", path+".key")
+		if err == nil {
+			cert = &keyPair
+			loadBalancerCertificates[path] = cert
+		}
+	}
+
+	if (cert == nil || acme.CertificateNeedsUpdate(domain, cert.Leaf)) && !loadBalancerCertificatesIssuing[path] {
+		loadBalancerCertificatesIssuing[path] = true
+
+		go func() {
+			defer func() {
+				loadBalancerCertificatesMu.Lock()
+				delete(loadBalancerCertificatesIssuing, path)
+				loadBalancerCertificatesMu.Unlock()
+			}()
+
+			err := n.loadBalancerIssueCertificate(path, domain)
+			if err != nil {
+				n.logger.Error("Failed issuing load balancer certificate", logger.Ctx{"domain": domain, "err": err})
+			}
+		}()
+	}
+
+	if cert == nil {
+		return nil, fmt.Errorf("Certificate for %q isn't available yet", domain)
+	}
+
+	return cert, nil
+}
+
+// loadBalancerIssueCertificate issues a certificate for the domain through ACME and stores it at path.
+func (n *bridge) loadBalancerIssueCertificate(path string, domain string) error {
+	_, email, caURL, agreeToS, challengeType := n.state.GlobalConfig.ACME()
+	if email == "" || !agreeToS {
+		return errors.New("The acme.email and acme.agree_tos server options are required")
+	}
+
+	if challengeType == "" {
+		challengeType = "HTTP-01"
+	}
+
+	newCert, err := acme.IssueCertificate(n.state, challengeType, domain, email, caURL)
+	if err != nil {
+		return err
+	}
+
+	keyPair, err := tls.X509KeyPair(newCert.Certificate, newCert.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".key", newCert.PrivateKey, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".crt", newCert.Certificate, 0o644)
+	if err != nil {
+		return err
+	}
+
+	loadBalancerCertificatesMu.Lock()
+	loadBalancerCertificates[path] = &keyPair
+	loadBalancerCertificatesMu.Unlock()
+
+	n.logger.Info("Issued load balancer certificate", logger.Ctx{"domain": domain})
+
+	return nil
+}
+
+// loadBalancerTargetOffline returns whether any of the target ports of a backend is considered offline.
+// Backends with an unknown status are kept until the health checks complete.
+func (n *bridge) loadBalancerTargetOffline(key string, portMap *loadBalancerPortMap, target forwardTarget) bool {
+	for i := range portMap.listenPorts {
+		hcTarget := healthcheck.Target{
+			Protocol: loadBalancerCheckProtocol(portMap.protocol),
+			Address:  target.address.String(),
+			Port:     loadBalancerTargetPort(target.ports, portMap.listenPorts, i),
+		}
+
+		if healthcheck.Status(key, hcTarget) == healthcheck.StatusOffline {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadBalancerHealthCheckValue returns the integer value of a health check option or its default.
+func loadBalancerHealthCheckValue(config map[string]string, key string, defaultValue int) int {
+	value, err := strconv.Atoi(config[key])
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// PeerCreate creates a network peering with another local bridge network.
+func (n *bridge) PeerCreate(peer api.NetworkPeersPost, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		// The DB records are already in place, just refresh the peered networks on this member.
+		return n.peerRefresh(peer.TargetProject, peer.TargetNetwork)
+	}
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	// Default type is local.
+	if peer.Type == "" {
+		peer.Type = "local"
+	}
+
+	if peer.Type == "remote" {
+		return n.remotePeerCreate(peer)
+	}
+
+	if peer.Type != "local" {
+		return api.StatusErrorf(http.StatusBadRequest, "Invalid peer type %q", peer.Type)
+	}
+
+	// Default to network's project if target project not specified.
+	if peer.TargetProject == "" {
+		peer.TargetProject = n.Project()
+	}
+
+	// Target network name is required.
+	if peer.TargetNetwork == "" {
+		return api.StatusErrorf(http.StatusBadRequest, "Target network is required")
+	}
+
+	if peer.TargetProject == n.Project() && peer.TargetNetwork == n.Name() {
+		return api.StatusErrorf(http.StatusBadRequest, "A network cannot be peered with itself")
+	}
+
+	// If the target network already exists, check it can be peered with.
+	targetNet, err := LoadByName(n.state, peer.TargetProject, peer.TargetNetwork)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("Failed loading target network: %w", err)
+	}
+
+	if targetNet != nil {
+		targetBridge, ok := targetNet.(*bridge)
+		if !ok {
+			return api.StatusErrorf(http.StatusBadRequest, "Target network %q isn't a bridge network", peer.TargetNetwork)
+		}
+
+		for _, subnet := range bridgePeerSubnets(n.config) {
+			for _, targetSubnet := range bridgePeerSubnets(targetBridge.config) {
+				if SubnetContains(subnet, targetSubnet) || SubnetContains(targetSubnet, subnet) {
+					return api.StatusErrorf(http.StatusBadRequest, "Target network subnet %q overlaps with local subnet %q", targetSubnet.String(), subnet.String())
+				}
+			}
+		}
+	}
+
+	peerID, mutualExists, err := n.peerCreateRecord(peer)
+	if err != nil {
+		return err
+	}
+
+	reverter.Add(func() { _ = n.peerDeleteRecord(peerID, peer.Type) })
+
+	// Apply the peering once both sides have been created.
+	if mutualExists {
+		err = n.peerRefresh(peer.TargetProject, peer.TargetNetwork)
+		if err != nil {
+			return err
+		}
+
+		reverter.Add(func() { _ = n.peerRefresh(peer.TargetProject, peer.TargetNetwork) })
+
+		// Notify all other members to apply the peering.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client incus.InstanceServer) error {
+			return client.UseProject(n.project).CreateNetworkPeer(n.name, peer)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	reverter.Success()
+	return nil
+}
+
+// PeerUpdate updates a network peering.
+func (n *bridge) PeerUpdate(peerName string, req api.NetworkPeerPut, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		// Used to signal that the peering of this network changed on another member.
+		return n.peerRefresh("", "")
+	}
+
+	return n.peerUpdateRecord(peerName, req)
+}
+
+// PeerDelete deletes a network peering.
+func (n *bridge) PeerDelete(peerName string, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		// The DB records are already gone, just refresh the network on this member.
+		return n.peerRefresh("", "")
+	}
+
+	var peerID int64
+	var peer *api.NetworkPeer
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		dbPeer, err := dbCluster.GetNetworkPeer(ctx, tx.Tx(), n.id, peerName)
+		if err != nil {
+			return fmt.Errorf("Failed getting network peer DB object: %w", err)
+		}
+
+		peerID = dbPeer.ID
+		peer, err = dbPeer.ToAPI(ctx, tx.Tx())
+		if err != nil {
+			return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	isUsed, err := n.peerIsUsed(peer.Name)
+	if err != nil {
+		return err
+	}
+
+	if isUsed {
+		return errors.New("Cannot delete a peer that is in use")
+	}
+
+	// Find the mutual peer on the target network so other members can be told to refresh it too.
+	var mutualPeer *api.NetworkPeer
+	if peer.Status == api.NetworkStatusCreated && peer.Type == "local" {
+		err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			targetID, err := tx.GetNetworkID(ctx, peer.TargetProject, peer.TargetNetwork)
+			if err != nil {
+				return err
+			}
+
+			dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{NetworkID: &targetID, TargetNetworkID: &n.id})
+			if err != nil {
+				return err
+			}
+
+			if len(dbPeers) == 1 {
+				mutualPeer, err = dbPeers[0].ToAPI(ctx, tx.Tx())
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("Failed loading mutual network peer: %w", err)
+		}
+	}
+
+	err = n.peerDeleteRecord(peerID, peer.Type)
+	if err != nil {
+		return err
+	}
+
+	if peer.Status != api.NetworkStatusCreated {
+		return nil
+	}
+
+	err = n.peerRefresh(peer.TargetProject, peer.TargetNetwork)
+	if err != nil {
+		return err
+	}
+
+	// Notify all other members to remove the peering from both networks.
+	notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	err = notifier(func(client incus.InstanceServer) error {
+		err := client.UseProject(n.project).DeleteNetworkPeer(n.name, peerName)
+		if err != nil {
+			return err
+		}
+
+		if mutualPeer == nil {
+			return nil
+		}
+
+		return client.UseProject(peer.TargetProject).UpdateNetworkPeer(peer.TargetNetwork, mutualPeer.Name, mutualPeer.Writable(), "")
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// remotePeerCreate creates a network peering with a WireGuard site integration.
+func (n *bridge) remotePeerCreate(peer api.NetworkPeersPost) error {
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	// Target integration name is required.
+	if peer.TargetIntegration == "" {
+		return api.StatusErrorf(http.StatusBadRequest, "Target integration is required")
+	}
+
+	// Every cluster member has its own bridge, while the remote site only expects a single tunnel endpoint.
+	if n.state.ServerClustered {
+		return api.StatusErrorf(http.StatusBadRequest, "WireGuard peers aren't supported on clustered bridge networks")
+	}
+
+	err := n.peerIntegrationAllowed(peer.TargetIntegration)
+	if err != nil {
+		return err
+	}
+
+	integration, err := n.peerLoadIntegration(peer.TargetIntegration)
+	if err != nil {
+		return err
+	}
+
+	if integration.Type != "wireguard" {
+		return api.StatusErrorf(http.StatusBadRequest, "Only WireGuard integrations can be used with bridge networks")
+	}
+
+	err = bridgeWireguardValidate(integration)
+	if err != nil {
+		return err
+	}
+
+	// The tunnel uses a fixed key and listen port, so the integration can't be shared between networks.
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		integrationID, err := dbCluster.GetNetworkIntegrationID(ctx, tx.Tx(), integration.Name)
+		if err != nil {
+			return err
+		}
+
+		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{TargetNetworkIntegrationID: &integrationID})
+		if err != nil {
+			return err
+		}
+
+		if len(dbPeers) > 0 {
+			return api.StatusErrorf(http.StatusConflict, "WireGuard integration %q is already in use", integration.Name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	peerID, _, err := n.peerCreateRecord(peer)
+	if err != nil {
+		return err
+	}
+
+	reverter.Add(func() {
+		_ = n.peerDeleteRecord(peerID, peer.Type)
+		_ = n.peerRefresh("", "")
+	})
+
+	err = n.peerRefresh("", "")
+	if err != nil {
+		return err
+	}
+
+	reverter.Success()
+	return nil
+}
+
+// bridgeWireguardValidate checks that a WireGuard integration has all the configuration needed to set up a tunnel.
+func bridgeWireguardValidate(integration *api.NetworkIntegration) error {
+	for _, key := range []string{"wireguard.private_key", "wireguard.peer.public_key", "wireguard.peer.subnets"} {
+		if integration.Config[key] == "" {
+			return api.StatusErrorf(http.StatusBadRequest, "Network integration %q is missing the %q configuration key", integration.Name, key)
+		}
+	}
+
+	return nil
+}
+
+// wireguardNameHash returns a fixed-length hash of an ID for use in WireGuard interface names.
+func wireguardNameHash(id int64) string {
+	hash := sha256.Sum256([]byte(strconv.FormatInt(id, 10)))
+
+	return hex.EncodeToString(hash[:])[:6]
+}
+
+// wireguardInterfacePrefix returns the prefix of the WireGuard interfaces of the network.
+func (n *bridge) wireguardInterfacePrefix() string {
+	return "iwg" + wireguardNameHash(n.id)
+}
+
+// wireguardInterfaceName returns the name of the WireGuard interface of an integration the network is peered with.
+// Both IDs are hashed so that the name is always 15 characters long, the maximum allowed by the kernel.
+func (n *bridge) wireguardInterfaceName(integrationID int64) string {
+	return n.wireguardInterfacePrefix() + wireguardNameHash(integrationID)
+}
+
+// wireguardSetup sets up the WireGuard tunnels to the sites the network is peered with and removes stale ones.
+func (n *bridge) wireguardSetup() error {
+	integrations, err := n.peerIntegrations("wireguard")
+	if err != nil {
+		return err
+	}
+
+	tunnels := make([]string, 0, len(integrations))
+	for integrationID, integration := range integrations {
+		name := n.wireguardInterfaceName(integrationID)
+		tunnels = append(tunnels, name)
+
+		err = n.wireguardSetupTunnel(name, integration)
+		if err != nil {
+			return fmt.Errorf("Failed setting up WireGuard tunnel for integration %q: %w", integration.Name, err)
+		}
+	}
+
+	return n.wireguardClear(tunnels...)
+}
+
+// wireguardSetupTunnel creates or updates the WireGuard tunnel interface of an integration.
+func (n *bridge) wireguardSetupTunnel(name string, integration *api.NetworkIntegration) error {
+	config := integration.Config
+
+	err := bridgeWireguardValidate(integration)
+	if err != nil {
+		return err
+	}
+
+	mtu := uint32(1420)
+	if config["wireguard.mtu"] != "" {
+		value, err := strconv.ParseUint(config["wireguard.mtu"], 10, 32)
+		if err != nil {
+			return err
+		}
+
+		mtu = uint32(value)
+	}
+
+	link := &ip.Wireguard{Link: ip.Link{Name: name, MTU: mtu}}
+	if !InterfaceExists(name) {
+		err = link.Add()
+		if err != nil {
+			return err
+		}
+	} else {
+		err = link.SetMTU(mtu)
+		if err != nil {
+			return err
+		}
+	}
+
+	listenPort := config["wireguard.listen_port"]
+	if listenPort == "" {
+		listenPort = "51820"
+	}
+
+	// Apply the keys and peer.
+	var wgConfig strings.Builder
+	fmt.Fprintf(&wgConfig, "[Interface]\nPrivateKey = %s\nListenPort = %s\n\n", config["wireguard.private_key"], listenPort)
+	fmt.Fprintf(&wgConfig, "[Peer]\nPublicKey = %s\nAllowedIPs = %s\n", config["wireguard.peer.public_key"], strings.Join(util.SplitNTrimSpace(config["wireguard.peer.subnets"], ",", -1, true), ", "))
+
+	if config["wireguard.peer.preshared_key"] != "" {
+		fmt.Fprintf(&wgConfig, "PresharedKey = %s\n", config["wireguard.peer.preshared_key"])
+	}
+
+	if config["wireguard.peer.endpoint"] != "" {
+		fmt.Fprintf(&wgConfig, "Endpoint = %s\n", config["wireguard.peer.endpoint"])
+	}
+
+	if config["wireguard.peer.keepalive"] != "" {
+		fmt.Fprintf(&wgConfig, "PersistentKeepalive = %s\n", config["wireguard.peer.keepalive"])
+	}
+
+	err = link.SetConfig(wgConfig.String())
+	if err != nil {
+		return err
+	}
+
+	// Apply the tunnel addresses.
+	addr := &ip.Addr{DevName: name}
+	err = addr.Flush()
+	if err != nil {
+		return err
+	}
+
+	for _, address := range util.SplitNTrimSpace(config["wireguard.address"], ",", -1, true) {
+		ipAddr, ipNet, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+
+		ipNet.IP = ipAddr
+		addr := &ip.Addr{DevName: name, Address: ipNet}
+		err = addr.Add()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = link.SetUp()
+	if err != nil {
+		return err
+	}
+
+	// Route the subnets of the remote site through the tunnel.
+	for _, subnet := range util.SplitNTrimSpace(config["wireguard.peer.subnets"], ",", -1, true) {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return err
+		}
+
+		family := ip.FamilyV4
+		if ipNet.IP.To4() == nil {
+			family = ip.FamilyV6
+		}
+
+		route := &ip.Route{DevName: name, Route: ipNet, Proto: "static", Family: family}
+		err = route.Replace()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// wireguardClear removes the WireGuard tunnels of the network, except for the ones listed in keep.
+func (n *bridge) wireguardClear(keep ...string) error {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+
+	for _, iface := range ifaces {
+		if !strings.HasPrefix(iface.Name, n.wireguardInterfacePrefix()) || slices.Contains(keep, iface.Name) {
+			continue
+		}
+
+		link := &ip.Link{Name: iface.Name}
+		err = link.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// peerRefresh re-applies the firewall configuration of this network and of the given target network on the
+// local member so that the peering changes take effect.
+func (n *bridge) peerRefresh(targetProject string, targetNetwork string) error {
+	if n.isRunning() {
+		err := n.setup(n.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	if targetNetwork == "" {
+		return nil
+	}
+
+	targetNet, err := LoadByName(n.state, targetProject, targetNetwork)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return nil
+		}
+
+		return fmt.Errorf("Failed loading target network: %w", err)
+	}
+
+	targetBridge, ok := targetNet.(*bridge)
+	if !ok || !targetBridge.isRunning() {
+		return nil
+	}
+
+	return targetBridge.setup(targetBridge.config)
+}
+
+// peerTargetSubnets returns the subnets of all the bridge networks this network is peered with.
+func (n *bridge) peerTargetSubnets() ([]*net.IPNet, error) {
+	var targetConfigs []map[string]string
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		netID := n.ID()
+		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{NetworkID: &netID})
+		if err != nil {
+			return fmt.Errorf("Failed loading network peer DB objects: %w", err)
+		}
+
+		for _, dbPeer := range dbPeers {
+			// Skip peers which aren't linked to a mutual peer.
+			if !dbPeer.TargetNetworkID.Valid {
+				continue
+			}
+
+			targetName, targetProject, err := tx.GetNetworkNameAndProjectWithID(ctx, int(dbPeer.TargetNetworkID.Int64))
+			if err != nil {
+				return err
+			}
+
+			_, targetNet, _, err := tx.GetNetworkInAnyState(ctx, targetProject, targetName)
+			if err != nil {
+				return err
+			}
+
+			targetConfigs = append(targetConfigs, targetNet.Config)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []*net.IPNet
+	for _, config := range targetConfigs {
+		subnets = append(subnets, bridgePeerSubnets(config)...)
+	}
+
+	return subnets, nil
+}
+
+// bridgePeerSubnets returns the IPv4 and IPv6 subnets of a bridge network config.
+func bridgePeerSubnets(config map[string]string) []*net.IPNet {
+	var subnets []*net.IPNet
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		_, subnet, err := net.ParseCIDR(config[key])
+		if err != nil {
+			continue // Address is unset or "none".
+		}
+
+		subnets = append(subnets, subnet)
+	}
+
+	return subnets
+}
+
+// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
+// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
+func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
+	var err error
+	var projectMacs []string
+	leases := []api.NetworkLease{}
+
+	// Get all static leases.
+	if clientType == request.ClientTypeNormal {
+		// If requested project matches network's project then include gateway and downstream uplink IPs.
+		if projectName == n.project {
+			// Add our own gateway IPs.
+			for _, addr := range []string{n.config["ipv4.address"], n.config["ipv6.address"]} {
+				ip, _, _ := net.ParseCIDR(addr)
+				if ip != nil {
+					leases = append(leases, api.NetworkLease{
+						Hostname: fmt.Sprintf("%s.gw", n.Name()),
+						Address:  ip.String(),
+						Type:     "gateway",
+					})
+				}
+			}
+
+			// Include downstream OVN routers using the network as an uplink.
+			var projectNetworks map[string]map[int64]api.Network
+			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				projectNetworks, err = tx.GetCreatedNetworks(ctx)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Look for networks using the current network as an uplink.
+			for projectName, networks := range projectNetworks {
+				for _, network := range networks {
+					if network.Config["network"] != n.name {
+						continue
+					}
+
+					// Found a network, add leases.
+					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
+						v := network.Config[k]
+						if v != "" {
+							leases = append(leases, api.NetworkLease{
+								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
+								Address:  v,
+								Type:     "uplink",
+							})
+						}
+					}
+				}
+			}
+		}
+
+		// Get all the instances in the requested project that are connected to this network.
+		filter := dbCluster.InstanceFilter{Project: &projectName}
+		err = UsedByInstanceDevices(n.state, n.Project(), n.Name(), n.Type(), func(inst db.InstanceArgs, nicName string, nicConfig map[string]string) error {
+			// Fill in the hwaddr from volatile.
+			if nicConfig["hwaddr"] == "" {
+				nicConfig["hwaddr"] = inst.Config[fmt.Sprintf("volatile.%s.hwaddr", nicName)]
+			}
+
+			// Record the MAC.
+			hwAddr, _ := net.ParseMAC(nicConfig["hwaddr"])
+			if hwAddr != nil {
+				projectMacs = append(projectMacs, hwAddr.String())
+			}
+
+			// Add the lease.
+			nicIP4 := net.ParseIP(nicConfig["ipv4.address"])
+			if nicIP4 != nil {
+				leases = append(leases, api.NetworkLease{
+					Hostname: inst.Name,
+					Address:  nicIP4.String(),
+					Hwaddr:   hwAddr.String(),
+					Type:     "static",
+					Location: inst.Node,
+				})
+			}
+
+			nicIP6 := net.ParseIP(nicConfig["ipv6.address"])
+			if nicIP6 != nil {
+				leases = append(leases, api.NetworkLease{
+					Hostname: inst.Name,
+					Address:  nicIP6.String(),
+					Hwaddr:   hwAddr.String(),
+					Type:     "static",
+					Location: inst.Node,
+				})
 			}
 
 			// Add EUI64 records.
@@ -3327,62 +5323,78 @@ func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]ap
 		}
 	}
 
-	// Get dynamic leases.
-	leaseFile := internalUtil.VarPath("networks", n.name, "dnsmasq.leases")
-	if !util.PathExists(leaseFile) {
-		return leases, nil
-	}
+	// addDynamicLease adds a dynamic lease to the list (unless already present as a static lease).
+	addDynamicLease := func(hostname string, address string, macStr string) {
+		// Look for an existing static entry.
+		found := false
+		for _, entry := range leases {
+			if entry.Hwaddr == macStr && entry.Address == address {
+				found = true
+				break
+			}
+		}
 
-	content, err := os.ReadFile(leaseFile)
-	if err != nil {
-		return nil, err
+		if found {
+			return
+		}
+
+		// DHCPv6 leases can't be tracked down to a MAC so clear the field.
+		// This means that instance project filtering will not work on IPv6 leases.
+		if strings.Contains(address, ":") {
+			macStr = ""
+		}
+
+		// Skip leases that don't match any of the instance MACs from the project (only when we
+		// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
+		// leases and they will be filtered on the server handling the end user request.
+		if clientType == request.ClientTypeNormal && macStr != "" && !slices.Contains(projectMacs, macStr) {
+			return
+		}
+
+		// Add the lease to the list.
+		leases = append(leases, api.NetworkLease{
+			Hostname: hostname,
+			Address:  address,
+			Hwaddr:   macStr,
+			Type:     "dynamic",
+			Location: n.state.ServerName,
+		})
 	}
 
-	for _, lease := range strings.Split(string(content), "\n") {
-		fields := strings.Fields(lease)
-		if len(fields) >= 5 {
-			// Parse the MAC.
-			mac := GetMACSlice(fields[1])
-			macStr := strings.Join(mac, ":")
+	// Get dynamic leases.
+	if n.hasBuiltinDHCP() {
+		dhcpLeases, err := dhcp.GetLeases(n.name)
+		if err != nil {
+			return nil, err
+		}
 
-			if len(macStr) < 17 && fields[4] != "" {
-				macStr = fields[4][len(fields[4])-17:]
-			}
+		for _, lease := range dhcpLeases {
+			addDynamicLease(lease.Hostname, lease.Address, lease.Hwaddr)
+		}
+	} else {
+		leaseFile := internalUtil.VarPath("networks", n.name, "dnsmasq.leases")
+		if !util.PathExists(leaseFile) {
+			return leases, nil
+		}
 
-			// Look for an existing static entry.
-			found := false
-			for _, entry := range leases {
-				if entry.Hwaddr == macStr && entry.Address == fields[2] {
-					found = true
-					break
-				}
-			}
+		content, err := os.ReadFile(leaseFile)
+		if err != nil {
+			return nil, err
+		}
 
-			if found {
-				continue
-			}
+		for _, lease := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(lease)
+			if len(fields) >= 5 {
+				// Parse the MAC.
+				mac := GetMACSlice(fields[1])
+				macStr := strings.Join(mac, ":")
 
-			// DHCPv6 leases can't be tracked down to a MAC so clear the field.
-			// This means that instance project filtering will not work on IPv6 leases.
-			if strings.Contains(fields[2], ":") {
-				macStr = ""
-			}
+				if len(macStr) < 17 && fields[4] != "" {
+					macStr = fields[4][len(fields[4])-17:]
+				}
 
-			// Skip leases that don't match any of the instance MACs from the project (only when we
-			// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
-			// leases and they will be filtered on the server handling the end user request.
-			if clientType == request.ClientTypeNormal && macStr != "" && !slices.Contains(projectMacs, macStr) {
-				continue
+				addDynamicLease(fields[3], fields[2], macStr)
 			}
-
-			// Add the lease to the list.
-			leases = append(leases, api.NetworkLease{
-				Hostname: fields[3],
-				Address:  fields[2],
-				Hwaddr:   macStr,
-				Type:     "dynamic",
-				Location: n.state.ServerName,
-			})
 		}
 	}
 
@@ -3489,3 +5501,53 @@ func (n *bridge) deleteChildren() error {
 
 	return nil
 }
+
+// InstanceNICPath returns the bridge, its services and the host the traffic of an instance NIC goes through.
+func (n *bridge) InstanceNICPath(opts *InstanceNICPathOpts) ([]api.InstanceNetworkPathHop, error) {
+	forwards, loadBalancers, peers, err := n.instanceNICPathServices(opts.Addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeHop := api.InstanceNetworkPathHop{
+		Type:          "bridge",
+		Name:          n.name,
+		Network:       n.name,
+		Addresses:     []string{},
+		ACLs:          util.SplitNTrimSpace(n.config["security.acls"], ",", -1, true),
+		Forwards:      forwards,
+		LoadBalancers: loadBalancers,
+		Peers:         peers,
+	}
+
+	hostHop := api.InstanceNetworkPathHop{
+		Type:      "host",
+		Name:      n.state.ServerName,
+		Network:   n.name,
+		Addresses: []string{},
+	}
+
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		address := n.config[keyPrefix+".address"]
+		if address != "" && address != "none" {
+			bridgeHop.Addresses = append(bridgeHop.Addresses, address)
+		}
+
+		natAddress := n.config[keyPrefix+".nat.address"]
+		if util.IsTrue(n.config[keyPrefix+".nat"]) && natAddress != "" {
+			hostHop.Addresses = append(hostHop.Addresses, natAddress)
+		}
+	}
+
+	return []api.InstanceNetworkPathHop{bridgeHop, hostHop}, nil
+}
+
+// InstanceNICTrace traces a flow from an instance NIC through the host firewall.
+func (n *bridge) InstanceNICTrace(opts *InstanceNICPathOpts, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	hostName := opts.DeviceConfig["host_name"]
+	if hostName == "" {
+		return nil, fmt.Errorf("NIC %q doesn't have a host-side interface to trace on", opts.DeviceName)
+	}
+
+	return TraceFirewallFlow(n.state, hostName, req)
+}
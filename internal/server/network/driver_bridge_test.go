@@ -0,0 +1,75 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func Test_bridgeWireguardValidate(t *testing.T) {
+	validConfig := func() map[string]string {
+		return map[string]string{
+			"wireguard.private_key":     "yAnz5TF+lXXJte14tji3zlMNq+hd2rYUIgJBgB3fBmk=",
+			"wireguard.peer.public_key": "xTIBA5rboUvnH4htodjb6e697QjLERt1NAB4mZqp8Dg=",
+			"wireguard.peer.subnets":    "10.0.0.0/24",
+		}
+	}
+
+	tests := []struct {
+		name      string
+		removeKey string
+		err       bool
+	}{
+		{
+			name: "Complete config",
+		},
+		{
+			name:      "Missing private key",
+			removeKey: "wireguard.private_key",
+			err:       true,
+		},
+		{
+			name:      "Missing peer public key",
+			removeKey: "wireguard.peer.public_key",
+			err:       true,
+		},
+		{
+			name:      "Missing peer subnets",
+			removeKey: "wireguard.peer.subnets",
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			delete(config, tt.removeKey)
+
+			err := bridgeWireguardValidate(&api.NetworkIntegration{Name: "site2", NetworkIntegrationPut: api.NetworkIntegrationPut{Config: config}})
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_bridgeWireguardInterfaceName(t *testing.T) {
+	n := &bridge{common: common{id: 1234567890}}
+
+	name := n.wireguardInterfaceName(9876543210)
+	assert.Len(t, name, 15)
+	assert.Contains(t, name, n.wireguardInterfacePrefix())
+
+	// Names are stable and distinct between integrations and networks.
+	assert.Equal(t, name, n.wireguardInterfaceName(9876543210))
+	assert.NotEqual(t, name, n.wireguardInterfaceName(1))
+
+	other := &bridge{common: common{id: 1}}
+	assert.NotEqual(t, name, other.wireguardInterfaceName(9876543210))
+	assert.Len(t, other.wireguardInterfaceName(1), 15)
+}
@@ -2,10 +2,13 @@ package network
 
 import (
 	"context"
+	"crypto/tls"
+	"database/sql"
 	"errors"
 	"fmt"
 	"maps"
 	"net"
+	"net/http"
 	"os"
 	"slices"
 	"strconv"
@@ -20,8 +23,11 @@ import (
 	"github.com/lxc/incus/v6/internal/server/cluster/request"
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
 	"github.com/lxc/incus/v6/internal/server/network/acl"
+	"github.com/lxc/incus/v6/internal/server/project"
 	"github.com/lxc/incus/v6/internal/server/state"
+	localUtil "github.com/lxc/incus/v6/internal/server/util"
 	internalUtil "github.com/lxc/incus/v6/internal/util"
 	"github.com/lxc/incus/v6/internal/version"
 	"github.com/lxc/incus/v6/shared/api"
@@ -58,6 +64,13 @@ type loadBalancerPortMap struct {
 	listenPorts []uint64
 	protocol    string
 	targets     []forwardTarget
+	host        string
+	path        string
+}
+
+// isL7 returns whether the port map is handled by the layer 7 proxy rather than forwarded as-is.
+func (p *loadBalancerPortMap) isL7() bool {
+	return slices.Contains([]string{"http", "https", "tls"}, p.protocol)
 }
 
 // subnetUsageType indicates the type of use for a subnet.
@@ -377,6 +390,7 @@ func (n *common) DHCPv6Ranges() []iprange.Range {
 func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clientType request.ClientType) error {
 	// Update internal config before database has been updated (so that if update is a notification we apply
 	// the config being supplied and not that in the database).
+	oldConfig := n.config
 	n.description = applyNetwork.Description
 	n.config = applyNetwork.Config
 
@@ -411,6 +425,18 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clientTy
 		if err != nil {
 			return err
 		}
+
+		// Release replaced subnets from the external IPAM (if any).
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			if oldConfig[key] == applyNetwork.Config[key] {
+				continue
+			}
+
+			err = n.ipamReleaseSubnet(oldConfig, oldConfig[key])
+			if err != nil {
+				n.logger.Warn("Failed releasing replaced subnet", logger.Ctx{"err": err})
+			}
+		}
 	}
 
 	return nil
@@ -513,6 +539,16 @@ func (n *common) delete(clientType request.ClientType) error {
 		return err
 	}
 
+	// Release the subnets from the external IPAM (if any), only once for the whole cluster.
+	if clientType == request.ClientTypeNormal {
+		for _, key := range []string{"ipv4.address", "ipv6.address"} {
+			err = n.ipamReleaseSubnet(n.config, n.config[key])
+			if err != nil {
+				n.logger.Warn("Failed releasing subnet", logger.Ctx{"err": err})
+			}
+		}
+	}
+
 	// Cleanup storage.
 	if util.PathExists(internalUtil.VarPath("networks", n.name)) {
 		_ = os.RemoveAll(internalUtil.VarPath("networks", n.name))
@@ -890,6 +926,33 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 			continue
 		}
 
+		// gendoc:generate(entity=network_forward, group=common, key=volatile.floating_ip)
+		//
+		// ---
+		//  type: bool
+		//  shortdesc: Whether the forward backs a floating IP (set by the floating IP API)
+
+		// gendoc:generate(entity=network_forward, group=common, key=volatile.floating_ip.instance)
+		//
+		// ---
+		//  type: string
+		//  shortdesc: Instance the floating IP is attached to (set by the floating IP API)
+
+		// gendoc:generate(entity=network_forward, group=common, key=volatile.floating_ip.device)
+		//
+		// ---
+		//  type: string
+		//  shortdesc: Instance NIC the floating IP is attached to (set by the floating IP API)
+
+		// gendoc:generate(entity=network_forward, group=common, key=volatile.floating_ip.project)
+		//
+		// ---
+		//  type: string
+		//  shortdesc: Project of the instance the floating IP is attached to (set by the floating IP API)
+		if slices.Contains([]string{floatingIPKey, floatingIPInstanceKey, floatingIPDeviceKey, floatingIPProjectKey}, k) {
+			continue
+		}
+
 		// User keys are not validated.
 
 		// gendoc:generate(entity=network_forward, group=common, key=user.*)
@@ -1330,6 +1393,28 @@ func (n *common) loadBalancerValidate(listenAddress net.IP, forward *api.Network
 		//  shortdesc: Test timeout
 		//  defaultdesc: `30`
 		"healthcheck.timeout": validate.IsUint32,
+
+		// gendoc:generate(entity=network_load_balancer, group=common, key=tls.acme)
+		// Certificates are issued for the host of each `https` port, using the server's ACME configuration.
+		// ---
+		//  type: bool
+		//  defaultdesc: `false`
+		//  shortdesc: Whether to obtain the certificates of `https` ports through ACME
+		"tls.acme": validate.Optional(validate.IsBool),
+
+		// gendoc:generate(entity=network_load_balancer, group=common, key=tls.certificate)
+		//
+		// ---
+		//  type: string
+		//  shortdesc: PEM encoded certificate used by `https` ports (when not using ACME)
+		"tls.certificate": validate.IsAny,
+
+		// gendoc:generate(entity=network_load_balancer, group=common, key=tls.key)
+		// The key is never returned through the API. When updating the load balancer, it can be left out to keep the current key.
+		// ---
+		//  type: string
+		//  shortdesc: PEM encoded private key of `tls.certificate`
+		"tls.key": validate.IsAny,
 	}
 
 	for k, v := range forward.Config {
@@ -1357,15 +1442,34 @@ func (n *common) loadBalancerValidate(listenAddress net.IP, forward *api.Network
 		return nil, fmt.Errorf("Invalid option %q", k)
 	}
 
+	if (forward.Config["tls.certificate"] == "") != (forward.Config["tls.key"] == "") {
+		return nil, errors.New("Both tls.certificate and tls.key must be set")
+	}
+
+	if forward.Config["tls.certificate"] != "" {
+		if util.IsTrue(forward.Config["tls.acme"]) {
+			return nil, errors.New("tls.certificate can't be used along with tls.acme")
+		}
+
+		_, err := tls.X509KeyPair([]byte(forward.Config["tls.certificate"]), []byte(forward.Config["tls.key"]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid TLS certificate or key: %w", err)
+		}
+	}
+
 	// Validate port rules.
-	validPortProcols := []string{"tcp", "udp"}
+	validPortProcols := []string{"tcp", "udp", "http", "https", "tls"}
 
 	// Used to ensure that each listen port is only used once.
+	// The layer 7 protocols (which run over TCP) can share a listen port between different hosts and paths.
 	listenPorts := map[string]map[int64]struct{}{
 		"tcp": make(map[int64]struct{}),
 		"udp": make(map[int64]struct{}),
 	}
 
+	l7ListenPorts := map[int64]string{}
+	l7Routes := map[string]struct{}{}
+
 	// Check backends config and store the parsed target by backend name.
 	backendsByName := make(map[string]*forwardTarget, len(forward.Backends))
 	for backendSpecID, backendSpec := range forward.Backends {
@@ -1432,6 +1536,13 @@ func (n *common) loadBalancerValidate(listenAddress net.IP, forward *api.Network
 			listenPorts: make([]uint64, 0),
 			protocol:    portSpec.Protocol,
 			targets:     make([]forwardTarget, 0, len(portSpec.TargetBackend)),
+			host:        portSpec.Host,
+			path:        portSpec.Path,
+		}
+
+		err := loadBalancerValidateRoute(&portMap, forward.Config)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid port specification %d: %w", portSpecID, err)
 		}
 
 		for _, pr := range listenPortRanges {
@@ -1442,8 +1553,28 @@ func (n *common) loadBalancerValidate(listenAddress net.IP, forward *api.Network
 
 			for i := range portRange {
 				port := portFirst + i
+
+				if portMap.isL7() {
+					_, found := listenPorts["tcp"][port]
+					if found || (l7ListenPorts[port] != "" && l7ListenPorts[port] != portSpec.Protocol) {
+						return nil, fmt.Errorf("Listen port %d is used with different protocols in port specification %d", port, portSpecID)
+					}
+
+					routeKey := fmt.Sprintf("%d/%s%s", port, portSpec.Host, portSpec.Path)
+					_, found = l7Routes[routeKey]
+					if found {
+						return nil, fmt.Errorf("Duplicate listen port %d for host %q and path %q in port specification %d", port, portSpec.Host, portSpec.Path, portSpecID)
+					}
+
+					l7ListenPorts[port] = portSpec.Protocol
+					l7Routes[routeKey] = struct{}{}
+					portMap.listenPorts = append(portMap.listenPorts, uint64(port))
+
+					continue
+				}
+
 				_, found := listenPorts[portSpec.Protocol][port]
-				if found {
+				if found || (portSpec.Protocol == "tcp" && l7ListenPorts[port] != "") {
 					return nil, fmt.Errorf("Duplicate listen port %d for protocol %q in port specification %d", port, portSpec.Protocol, portSpecID)
 				}
 
@@ -1477,6 +1608,52 @@ func (n *common) loadBalancerValidate(listenAddress net.IP, forward *api.Network
 	return portMaps, err
 }
 
+// loadBalancerValidateRoute validates the host and path of a load balancer port.
+func loadBalancerValidateRoute(portMap *loadBalancerPortMap, config map[string]string) error {
+	if !portMap.isL7() {
+		if portMap.host != "" || portMap.path != "" {
+			return fmt.Errorf("Host and path can't be used with protocol %q", portMap.protocol)
+		}
+
+		return nil
+	}
+
+	if portMap.path != "" {
+		if portMap.protocol == "tls" {
+			return errors.New(`Path can't be used with protocol "tls"`)
+		}
+
+		if !strings.HasPrefix(portMap.path, "/") {
+			return errors.New(`Path must start with "/"`)
+		}
+	}
+
+	if portMap.host != "" {
+		for i, label := range strings.Split(portMap.host, ".") {
+			if i == 0 && label == "*" {
+				continue
+			}
+
+			err := validate.IsHostname(label)
+			if err != nil {
+				return fmt.Errorf("Invalid host %q: %w", portMap.host, err)
+			}
+		}
+	}
+
+	if portMap.protocol == "https" {
+		if util.IsTrue(config["tls.acme"]) {
+			if portMap.host == "" || strings.HasPrefix(portMap.host, "*.") {
+				return errors.New("A host name is required to obtain the certificate through ACME")
+			}
+		} else if config["tls.certificate"] == "" {
+			return errors.New(`Either tls.acme or tls.certificate is required with protocol "https"`)
+		}
+	}
+
+	return nil
+}
+
 // LoadBalancerCreate returns ErrNotImplemented for drivers that do not support load balancers.
 func (n *common) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
 	return ErrNotImplemented
@@ -1503,17 +1680,17 @@ func (n *common) Leases(projectName string, clientType request.ClientType) ([]ap
 }
 
 // PeerCrete returns ErrNotImplemented for drivers that do not support forwards.
-func (n *common) PeerCreate(forward api.NetworkPeersPost) error {
+func (n *common) PeerCreate(forward api.NetworkPeersPost, clientType request.ClientType) error {
 	return ErrNotImplemented
 }
 
 // PeerUpdate returns ErrNotImplemented for drivers that do not support forwards.
-func (n *common) PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error {
+func (n *common) PeerUpdate(peerName string, newPeer api.NetworkPeerPut, clientType request.ClientType) error {
 	return ErrNotImplemented
 }
 
 // PeerDelete returns ErrNotImplemented for drivers that do not support forwards.
-func (n *common) PeerDelete(peerName string) error {
+func (n *common) PeerDelete(peerName string, clientType request.ClientType) error {
 	return ErrNotImplemented
 }
 
@@ -1545,6 +1722,368 @@ func (n *common) peerValidate(peerName string, peer *api.NetworkPeerPut) error {
 	return nil
 }
 
+// peerCreateRecord validates the peer request and creates its DB record.
+// If the target network already has a pending peer for this network, both peers are linked together and
+// mutualExists is returned as true.
+func (n *common) peerCreateRecord(peer api.NetworkPeersPost) (int64, bool, error) {
+	// Look for an existing entry.
+	var peers map[int64]*api.NetworkPeer
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		// Use generated function to get peers.
+		netID := n.ID()
+		filter := dbCluster.NetworkPeerFilter{NetworkID: &netID}
+		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), filter)
+		if err != nil {
+			return fmt.Errorf("Failed loading network peer DB objects: %w", err)
+		}
+
+		// Convert DB objects to API objects and build the map.
+		peers = make(map[int64]*api.NetworkPeer, len(dbPeers))
+		for _, dbPeer := range dbPeers {
+			peer, err := dbPeer.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
+			}
+
+			peers[dbPeer.ID] = peer
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, false, err
+	}
+
+	for _, existingPeer := range peers {
+		if peer.Name == existingPeer.Name {
+			return -1, false, api.StatusErrorf(http.StatusConflict, "A peer for that name already exists")
+		}
+
+		if peer.Type == "local" && peer.TargetProject == existingPeer.TargetProject && peer.TargetNetwork == existingPeer.TargetNetwork {
+			return -1, false, api.StatusErrorf(http.StatusConflict, "A peer for that target network already exists")
+		}
+	}
+
+	// Perform general (create and update) validation.
+	err = n.peerValidate(peer.Name, &peer.NetworkPeerPut)
+	if err != nil {
+		return -1, false, err
+	}
+
+	var peerID int64
+	var mutualExists bool
+
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error { // Create peer DB record.
+		record := dbCluster.NetworkPeer{
+			NetworkID:   n.ID(),
+			Name:        peer.Name,
+			Description: peer.Description,
+			Type:        dbCluster.NetworkPeerTypes[peer.Type],
+		}
+
+		switch peer.Type {
+		case "remote":
+			integrationID, err := dbCluster.GetNetworkIntegrationID(ctx, tx.Tx(), peer.TargetIntegration)
+			if err != nil {
+				return err
+			}
+
+			id := sql.NullInt64{}
+			err = id.Scan(integrationID)
+			if err != nil {
+				return err
+			}
+
+			record.TargetNetworkIntegrationID = id
+
+		case "local":
+			// Check if target peer already exists.
+			peers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{
+				Type:                 &record.Type,
+				TargetNetworkProject: &n.project,
+				TargetNetworkName:    &n.name,
+			})
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			if len(peers) == 1 {
+				// Update the target peer.
+				peer := peers[0]
+
+				empty := sql.NullString{}
+				peer.TargetNetworkProject = empty
+				peer.TargetNetworkName = empty
+
+				targetID := sql.NullInt64{}
+				err = targetID.Scan(n.id)
+				if err != nil {
+					return err
+				}
+
+				peer.TargetNetworkID = targetID
+
+				err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), peer.NetworkID, peer.Name, peer)
+				if err != nil {
+					return err
+				}
+
+				// Set our target network ID to match.
+				id := sql.NullInt64{}
+				err = id.Scan(peer.NetworkID)
+				if err != nil {
+					return err
+				}
+
+				record.TargetNetworkID = id
+
+				mutualExists = true
+			} else if len(peers) == 0 {
+				networkProjectName := sql.NullString{}
+				err = networkProjectName.Scan(peer.TargetProject)
+				if err != nil {
+					return err
+				}
+
+				networkName := sql.NullString{}
+				err = networkName.Scan(peer.TargetNetwork)
+				if err != nil {
+					return err
+				}
+
+				record.TargetNetworkProject = networkProjectName
+				record.TargetNetworkName = networkName
+			} else {
+				return errors.New("More than one matching network peer was found")
+			}
+		}
+
+		peerID, err = dbCluster.CreateNetworkPeer(ctx, tx.Tx(), record)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, false, err
+	}
+
+	return peerID, mutualExists, nil
+}
+
+// peerDeleteRecord deletes the peer DB record and deactivates the mutual peer on the target network.
+func (n *common) peerDeleteRecord(peerID int64, peerType string) error {
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		// Deactivate any existing peer.
+		if peerType == "local" {
+			filter := dbCluster.NetworkPeerFilter{TargetNetworkID: &n.id}
+
+			// Only consider the mutual peer of the target network when the peering is established.
+			records, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{ID: &peerID})
+			if err != nil {
+				return err
+			}
+
+			if len(records) == 1 && records[0].TargetNetworkID.Valid {
+				filter.NetworkID = &records[0].TargetNetworkID.Int64
+			}
+
+			peers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), filter)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			for _, peer := range peers {
+				peer.TargetNetworkID = sql.NullInt64{}
+
+				err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), peer.NetworkID, peer.Name, peer)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		// Delete the peer.
+		err := dbCluster.DeleteNetworkPeer(ctx, tx.Tx(), n.id, peerID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// peerUpdateRecord validates the peer request and updates its DB record.
+func (n *common) peerUpdateRecord(peerName string, req api.NetworkPeerPut) error {
+	var curPeer *api.NetworkPeer
+	var dbCurPeer *dbCluster.NetworkPeer
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		dbCurPeer, err = dbCluster.GetNetworkPeer(ctx, tx.Tx(), n.id, peerName)
+		if err != nil {
+			return fmt.Errorf("Failed getting network peer DB object: %w", err)
+		}
+
+		curPeer, err = dbCurPeer.ToAPI(ctx, tx.Tx())
+		if err != nil {
+			return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = n.peerValidate(peerName, &req)
+	if err != nil {
+		return err
+	}
+
+	curPeerEtagHash, err := localUtil.EtagHash(curPeer.Etag())
+	if err != nil {
+		return err
+	}
+
+	newPeer := api.NetworkPeer{
+		Name:           curPeer.Name,
+		NetworkPeerPut: req,
+	}
+
+	newPeerEtagHash, err := localUtil.EtagHash(newPeer.Etag())
+	if err != nil {
+		return err
+	}
+
+	if curPeerEtagHash == newPeerEtagHash {
+		return nil // Nothing has changed.
+	}
+
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		// Update the description field from the input.
+		dbCurPeer.Description = newPeer.Description
+
+		// Update the main peer object.
+		err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), n.id, dbCurPeer.Name, *dbCurPeer)
+		if err != nil {
+			return fmt.Errorf("Failed to update network peer: %w", err)
+		}
+
+		// Update the peer configuration.
+		err = dbCluster.UpdateNetworkPeerConfig(ctx, tx.Tx(), dbCurPeer.ID, newPeer.Config)
+		if err != nil {
+			return fmt.Errorf("Failed to update network peer config: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// peerLoadIntegration loads a network integration.
+func (n *common) peerLoadIntegration(name string) (*api.NetworkIntegration, error) {
+	var integration *api.NetworkIntegration
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		entry, err := dbCluster.GetNetworkIntegration(ctx, tx.Tx(), name)
+		if err != nil {
+			return err
+		}
+
+		integration, err = entry.ToAPI(ctx, tx.Tx())
+
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load network integration %q: %w", name, err)
+	}
+
+	return integration, nil
+}
+
+// peerIntegrationAllowed checks that the project of the network is allowed to use the network integration.
+func (n *common) peerIntegrationAllowed(name string) error {
+	var p *api.Project
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		dbProject, err := dbCluster.GetProject(ctx, tx.Tx(), n.project)
+		if err != nil {
+			return err
+		}
+
+		p, err = dbProject.ToAPI(ctx, tx.Tx())
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to load network restrictions from project %q: %w", n.project, err)
+	}
+
+	if !project.NetworkIntegrationAllowed(p.Config, name) {
+		return api.StatusErrorf(http.StatusForbidden, "Project isn't allowed to use this network integration")
+	}
+
+	return nil
+}
+
+// peerIntegrations returns the network integrations of the given type the network is peered with, indexed by ID.
+func (n *common) peerIntegrations(integrationType string) (map[int64]*api.NetworkIntegration, error) {
+	integrations := map[int64]*api.NetworkIntegration{}
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		netID := n.ID()
+		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{NetworkID: &netID})
+		if err != nil {
+			return fmt.Errorf("Failed loading network peer DB objects: %w", err)
+		}
+
+		for _, dbPeer := range dbPeers {
+			if !dbPeer.TargetNetworkIntegrationID.Valid {
+				continue
+			}
+
+			integrationID := int(dbPeer.TargetNetworkIntegrationID.Int64)
+			entries, err := dbCluster.GetNetworkIntegrations(ctx, tx.Tx(), dbCluster.NetworkIntegrationFilter{ID: &integrationID})
+			if err != nil {
+				return err
+			}
+
+			if len(entries) != 1 || dbCluster.NetworkIntegrationTypeNames[entries[0].Type] != integrationType {
+				continue
+			}
+
+			integration, err := entries[0].ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			integrations[dbPeer.TargetNetworkIntegrationID.Int64] = integration
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return integrations, nil
+}
+
 // PeerUsedBy returns a list of API endpoints referencing this peer.
 func (n *common) PeerUsedBy(peerName string) ([]string, error) {
 	return n.peerUsedBy(peerName, false)
@@ -1665,3 +2204,98 @@ func (n *common) setAvailable() {
 	delete(unavailableNetworks, pn)
 	unavailableNetworksMu.Unlock()
 }
+
+// InstanceNICPathOpts describes the instance NIC whose network path is looked up or traced.
+type InstanceNICPathOpts struct {
+	InstanceUUID string
+	DeviceName   string
+	DeviceConfig deviceConfig.Device
+	Addresses    []net.IP // Current addresses of the NIC.
+}
+
+// InstanceNICPath returns the elements of the network the traffic of an instance NIC goes through.
+func (n *common) InstanceNICPath(opts *InstanceNICPathOpts) ([]api.InstanceNetworkPathHop, error) {
+	return []api.InstanceNetworkPathHop{{Type: n.netType, Name: n.name, Network: n.name}}, nil
+}
+
+// InstanceNICTrace returns ErrNotImplemented for drivers that don't support flow tracing.
+func (n *common) InstanceNICTrace(opts *InstanceNICPathOpts, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	return nil, ErrNotImplemented
+}
+
+// instanceNICPathServices returns the listen addresses of the forwards and load balancers leading to any of
+// the addresses, along with the names of the network's peers.
+func (n *common) instanceNICPathServices(addresses []net.IP) ([]string, []string, []string, error) {
+	forwards := []string{}
+	loadBalancers := []string{}
+	peers := []string{}
+
+	isTarget := func(address string) bool {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return false
+		}
+
+		return slices.ContainsFunc(addresses, ip.Equal)
+	}
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		networkID := n.ID()
+
+		dbForwards, err := dbCluster.GetNetworkForwards(ctx, tx.Tx(), dbCluster.NetworkForwardFilter{NetworkID: &networkID})
+		if err != nil {
+			return err
+		}
+
+		for _, dbForward := range dbForwards {
+			forward, err := dbForward.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			targeted := isTarget(forward.Config["target_address"])
+			for _, port := range forward.Ports {
+				targeted = targeted || isTarget(port.TargetAddress)
+			}
+
+			if targeted {
+				forwards = append(forwards, forward.ListenAddress)
+			}
+		}
+
+		dbLoadBalancers, err := dbCluster.GetNetworkLoadBalancers(ctx, tx.Tx(), dbCluster.NetworkLoadBalancerFilter{NetworkID: &networkID})
+		if err != nil {
+			return err
+		}
+
+		for _, dbLoadBalancer := range dbLoadBalancers {
+			loadBalancer, err := dbLoadBalancer.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			for _, backend := range loadBalancer.Backends {
+				if isTarget(backend.TargetAddress) {
+					loadBalancers = append(loadBalancers, loadBalancer.ListenAddress)
+					break
+				}
+			}
+		}
+
+		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{NetworkID: &networkID})
+		if err != nil {
+			return err
+		}
+
+		for _, dbPeer := range dbPeers {
+			peers = append(peers, dbPeer.Name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Failed loading network services: %w", err)
+	}
+
+	return forwards, loadBalancers, peers, nil
+}
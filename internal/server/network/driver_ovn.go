@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/netip"
 	"os"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -25,17 +26,20 @@ import (
 
 	incus "github.com/lxc/incus/v6/client"
 	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/internal/server/bgp"
 	"github.com/lxc/incus/v6/internal/server/cluster"
 	"github.com/lxc/incus/v6/internal/server/cluster/request"
 	"github.com/lxc/incus/v6/internal/server/db"
 	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
 	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+	"github.com/lxc/incus/v6/internal/server/dhcp"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
 	"github.com/lxc/incus/v6/internal/server/instance"
 	"github.com/lxc/incus/v6/internal/server/ip"
 	"github.com/lxc/incus/v6/internal/server/locking"
 	"github.com/lxc/incus/v6/internal/server/network/acl"
 	addressset "github.com/lxc/incus/v6/internal/server/network/address-set"
+	"github.com/lxc/incus/v6/internal/server/network/ipam"
 	networkOVN "github.com/lxc/incus/v6/internal/server/network/ovn"
 	ovnSB "github.com/lxc/incus/v6/internal/server/network/ovn/schema/ovn-sb"
 	"github.com/lxc/incus/v6/internal/server/network/ovs"
@@ -46,6 +50,7 @@ import (
 	"github.com/lxc/incus/v6/shared/api"
 	"github.com/lxc/incus/v6/shared/logger"
 	"github.com/lxc/incus/v6/shared/revert"
+	"github.com/lxc/incus/v6/shared/units"
 	"github.com/lxc/incus/v6/shared/util"
 	"github.com/lxc/incus/v6/shared/validate"
 )
@@ -544,6 +549,16 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		//  shortdesc: The source address used for outbound traffic from the network (requires uplink `ovn.ingress_mode=routed`)
 		"ipv6.nat.address": validate.Optional(validate.IsNetworkAddressV6),
 
+		// gendoc:generate(entity=network_ovn, group=common, key=ipv6.nat64)
+		// Traffic towards the `64:ff9b::/96` prefix is routed through the uplink network which must perform the translation to IPv4.
+		// When the uplink is a bridge network, it must have `ipv6.nat64` enabled and its DNS server is then used to synthesize IPv6 addresses.
+		// ---
+		//  type: bool
+		//  condition: IPv6 address
+		//  default: `false`
+		//  shortdesc: Whether to use NAT64 and DNS64 provided by the uplink network
+		"ipv6.nat64": validate.Optional(validate.IsBool),
+
 		// gendoc:generate(entity=network_ovn, group=common, key=ipv4.l3only)
 		//
 		// ---
@@ -606,6 +621,27 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		//  shortdesc: DNS zone name for IPv6 reverse DNS records
 		"dns.zone.reverse.ipv6": validate.IsAny,
 
+		// gendoc:generate(entity=network_ovn, group=common, key=limits.ingress)
+		// The limit applies to the traffic entering the network from its uplink and is enforced separately on each chassis.
+		// ---
+		//  type: string
+		//  shortdesc: Aggregate bandwidth limit in bit/s for traffic coming into the network (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.ingress": validate.Optional(ValidateOVNQoSRate),
+
+		// gendoc:generate(entity=network_ovn, group=common, key=limits.egress)
+		// The limit applies to the traffic leaving the network through its uplink and is enforced separately on each chassis.
+		// ---
+		//  type: string
+		//  shortdesc: Aggregate bandwidth limit in bit/s for traffic going out of the network (various suffixes supported, see {ref}`instances-limit-units`)
+		"limits.egress": validate.Optional(ValidateOVNQoSRate),
+
+		// gendoc:generate(entity=network_ovn, group=common, key=qos.dscp)
+		//
+		// ---
+		//  type: integer
+		//  shortdesc: DSCP value (between 0 and 63) to mark the IP traffic originating from the network with
+		"qos.dscp": validate.Optional(validate.IsInRange(0, 63)),
+
 		// gendoc:generate(entity=network_ovn, group=common, key=security.acls)
 		//
 		// ---
@@ -649,6 +685,15 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		//  condition: `security.acls`
 		"security.acls.default.egress.logged": validate.Optional(validate.IsBool),
 
+		// gendoc:generate(entity=network_ovn, group=common, key=ipam.driver)
+		// With the `http` driver, the subnets picked for `auto` addresses, the addresses of instance NICs without a static address and the listen addresses of network forwards are reserved in an external IPAM.
+		// ---
+		//  type: string
+		//  condition: -
+		//  default: `builtin`
+		//  shortdesc: IPAM driver to use (`builtin` or `http`)
+		"ipam.driver": validate.Optional(ipam.ValidDriver),
+
 		// gendoc:generate(entity=network_ovn, group=common, key=user.*)
 		//
 		// ---
@@ -660,6 +705,23 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		ovnVolatileUplinkIPv6: validate.Optional(validate.IsNetworkAddressV6),
 	}
 
+	// gendoc:generate(entity=network_ovn, group=common, key=ipv4.dhcp.options.NAME)
+	// `NAME` is one of `next-server`, `filename`, `tftp-server`, `tftp-server-address`, `ntp-server`, `path-prefix` or `wpad`.
+	// ---
+	//  type: string
+	//  condition: IPv4 DHCP
+	//  shortdesc: Value of a DHCP option to send to clients
+
+	// gendoc:generate(entity=network_ovn, group=common, key=ipv6.dhcp.options.NAME)
+	// `NAME` can only be `bootfile-url`.
+	// ---
+	//  type: string
+	//  condition: IPv6 DHCP
+	//  shortdesc: Value of a DHCPv6 option to send to clients
+
+	// Add the DHCP option validation rules.
+	maps.Copy(rules, dhcp.ValidationRules(config, true))
+
 	err := n.validate(config, rules)
 	if err != nil {
 		return err
@@ -673,6 +735,12 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		return err
 	}
 
+	// Check the IPAM driver can be loaded with this configuration.
+	_, err = ipamLoad(n.state, config)
+	if err != nil {
+		return err
+	}
+
 	if config["ipv4.address"] != "" {
 		ipv4Addr, ipv4Net, _ := net.ParseCIDR(config["ipv4.address"])
 		if ipv4Net != nil {
@@ -773,11 +841,20 @@ func (n *ovn) Validate(config map[string]string, clientType request.ClientType)
 		return errors.New("The ipv6.dhcp.stateful setting must be enabled when using ipv6.l3only mode with ipv6.dhcp enabled")
 	}
 
+	if util.IsTrue(config["ipv6.nat64"]) && util.IsNoneOrEmpty(config["ipv6.address"]) {
+		return errors.New("ipv6.nat64 requires ipv6.address to be set")
+	}
+
 	// All tests below are related to the uplink network, skip if we don't have one.
 	if uplink == nil {
 		return nil
 	}
 
+	// Check that a bridge uplink provides NAT64 (other uplinks are expected to provide it externally).
+	if util.IsTrue(config["ipv6.nat64"]) && uplink.Type == "bridge" && util.IsFalseOrEmpty(uplink.Config["ipv6.nat64"]) {
+		return fmt.Errorf("ipv6.nat64 requires the uplink network %q to have ipv6.nat64 enabled", uplink.Name)
+	}
+
 	// If NAT disabled, parse the external subnets that are being requested.
 	var externalSubnets []*net.IPNet // Subnets to check for conflicts with other networks/NICs.
 	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
@@ -2126,11 +2203,19 @@ func (n *ovn) populateAutoConfig(config map[string]string) error {
 	changedConfig := false
 
 	if config["ipv4.address"] == "auto" {
-		subnet, err := randomSubnetV4()
+		// Have an external IPAM pick the subnet if configured.
+		subnet, err := n.ipamSubnet(config, 4)
 		if err != nil {
 			return err
 		}
 
+		if subnet == "" {
+			subnet, err = randomSubnetV4()
+			if err != nil {
+				return err
+			}
+		}
+
 		config["ipv4.address"] = subnet
 
 		if config["ipv4.nat"] == "" {
@@ -2141,11 +2226,19 @@ func (n *ovn) populateAutoConfig(config map[string]string) error {
 	}
 
 	if config["ipv6.address"] == "auto" {
-		subnet, err := randomSubnetV6()
+		// Have an external IPAM pick the subnet if configured.
+		subnet, err := n.ipamSubnet(config, 6)
 		if err != nil {
 			return err
 		}
 
+		if subnet == "" {
+			subnet, err = randomSubnetV6()
+			if err != nil {
+				return err
+			}
+		}
+
 		config["ipv6.address"] = subnet
 
 		if config["ipv6.nat"] == "" {
@@ -2156,7 +2249,7 @@ func (n *ovn) populateAutoConfig(config map[string]string) error {
 	}
 
 	// Re-validate config if changed.
-	if changedConfig && n.state != nil {
+	if changedConfig && n.id > -1 {
 		return n.Validate(config, request.ClientTypeNormal)
 	}
 
@@ -3000,6 +3093,11 @@ func (n *ovn) setup(update bool) error {
 			RecursiveDNSServer: dnsIPv4,
 		}
 
+		opts.Options, err = ovnDHCPOptions(n.config, false)
+		if err != nil {
+			return err
+		}
+
 		err = n.ovnnb.UpdateLogicalSwitchDHCPv4Options(context.TODO(), n.getIntSwitchName(), dhcpv4UUID, dhcpV4Subnet, opts)
 		if err != nil {
 			return fmt.Errorf("Failed adding DHCPv4 settings for internal switch: %w", err)
@@ -3019,6 +3117,11 @@ func (n *ovn) setup(update bool) error {
 			DHCPv6Stateless:    util.IsFalseOrEmpty(n.config["ipv6.dhcp.stateful"]),
 		}
 
+		opts.Options, err = ovnDHCPOptions(n.config, true)
+		if err != nil {
+			return err
+		}
+
 		err = n.ovnnb.UpdateLogicalSwitchDHCPv6Options(context.TODO(), n.getIntSwitchName(), dhcpv6UUID, dhcpV6Subnet, opts)
 		if err != nil {
 			return fmt.Errorf("Failed adding DHCPv6 settings for internal switch: %w", err)
@@ -3122,6 +3225,12 @@ func (n *ovn) setup(update bool) error {
 		return fmt.Errorf("Failed applying baseline ACL rules to internal switch: %w", err)
 	}
 
+	// Apply network level QoS rules to internal logical switch.
+	err = n.qosSetup()
+	if err != nil {
+		return fmt.Errorf("Failed applying QoS rules to internal switch: %w", err)
+	}
+
 	// Create network port group if needed.
 	err = n.ensureNetworkPortGroup(projectID)
 	if err != nil {
@@ -3624,6 +3733,12 @@ func (n *ovn) Start() error {
 		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
 	}
 
+	// Advertise the network to the BGP-EVPN fabrics it is peered with.
+	err = n.evpnSetup(false)
+	if err != nil {
+		return err
+	}
+
 	// Setup event handler for monitored services.
 	handler := networkOVN.EventHandler{
 		Tables: []string{"Service_Monitor"},
@@ -3755,6 +3870,12 @@ func (n *ovn) Stop() error {
 		return err
 	}
 
+	// Withdraw the network from the BGP-EVPN fabrics it is peered with.
+	err = n.evpnClear()
+	if err != nil {
+		return err
+	}
+
 	// Clear event handler for monitored services.
 	err = networkOVN.RemoveOVNSBHandler(fmt.Sprintf("network_%d", n.id))
 	if err != nil {
@@ -3806,7 +3927,7 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clientType re
 			return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
 		}
 
-		return nil
+		return n.evpnSetup(true)
 	}
 
 	dbUpdateNeeded, changedKeys, oldNetwork, err := n.common.configChanged(newNetwork)
@@ -4152,6 +4273,11 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clientType re
 		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
 	}
 
+	err = n.evpnSetup(true)
+	if err != nil {
+		return err
+	}
+
 	// Delete any address set that is unused
 	err = addressset.OVNAddressSetsDeleteIfUnused(n.state, n.logger, n.ovnnb, n.Project())
 	if err != nil {
@@ -4480,6 +4606,34 @@ func (n *ovn) InstanceDevicePortStart(opts *OVNInstanceNICSetupOpts, securityACL
 		nestedPortVLAN = uint16(nestedPortVLANInt64)
 	}
 
+	// Apply the NIC specific DHCP options on top of the network ones.
+	for _, ipv6 := range []bool{false, true} {
+		dhcpUUID := &dhcpV4UUID
+		if ipv6 {
+			dhcpUUID = &dhcpV6UUID
+		}
+
+		if *dhcpUUID == "" {
+			continue
+		}
+
+		nicOptions, err := ovnDHCPOptions(opts.DeviceConfig, ipv6)
+		if err != nil {
+			return "", nil, err
+		}
+
+		*dhcpUUID, err = n.ovnnb.UpdateLogicalSwitchPortDHCPOptions(context.TODO(), instancePortName, *dhcpUUID, nicOptions)
+		if err != nil {
+			return "", nil, fmt.Errorf("Failed setting up DHCP options for instance port: %w", err)
+		}
+	}
+
+	// Get the NIC QoS settings.
+	qosRules, qosMinRate, err := ovnPortQoSRules(instancePortName, opts.DeviceConfig)
+	if err != nil {
+		return "", nil, err
+	}
+
 	// Add port with mayExist set to true, so that if instance port exists, we don't fail and continue below
 	// to configure the port as needed. This is required in case the OVN northbound database was unavailable
 	// when the instance NIC was stopped and was unable to remove the port on last stop, which would otherwise
@@ -4494,6 +4648,7 @@ func (n *ovn) InstanceDevicePortStart(opts *OVNInstanceNICSetupOpts, securityACL
 		VLAN:         nestedPortVLAN,
 		Location:     n.state.ServerName,
 		Promiscuous:  util.IsTrue(opts.DeviceConfig["security.promiscuous"]),
+		QoSMinRate:   qosMinRate,
 	}, true)
 	if err != nil {
 		return "", nil, err
@@ -4503,6 +4658,12 @@ func (n *ovn) InstanceDevicePortStart(opts *OVNInstanceNICSetupOpts, securityACL
 		_ = n.ovnnb.DeleteLogicalSwitchPort(context.TODO(), n.getIntSwitchName(), instancePortName)
 	})
 
+	// Apply the NIC QoS rules (replacing any existing ones).
+	err = n.ovnnb.UpdateLogicalSwitchQoSRules(context.TODO(), n.getIntSwitchName(), string(instancePortName), nil, qosRules...)
+	if err != nil {
+		return "", nil, fmt.Errorf("Failed applying QoS rules to instance port: %w", err)
+	}
+
 	// Add DNS records for port's IPs, and retrieve the IP addresses used.
 	var dnsIPv4, dnsIPv6 net.IP
 	dnsIPs := make([]net.IP, 0, 2)
@@ -6024,6 +6185,10 @@ func (n *ovn) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clie
 			return err
 		}
 
+		if slices.ContainsFunc(portMaps, (*loadBalancerPortMap).isL7) {
+			return errors.New("Layer 7 load balancing isn't supported on OVN networks")
+		}
+
 		// Load the project to get uplink network restrictions.
 		var p *api.Project
 		var uplink *api.Network
@@ -6232,11 +6397,17 @@ func (n *ovn) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalanc
 			return err
 		}
 
+		loadBalancerKeepSecrets(curLoadBalancer.Config, &req)
+
 		portMaps, err := n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req)
 		if err != nil {
 			return err
 		}
 
+		if slices.ContainsFunc(portMaps, (*loadBalancerPortMap).isL7) {
+			return errors.New("Layer 7 load balancing isn't supported on OVN networks")
+		}
+
 		curEtagHash, err := localUtil.EtagHash(curLoadBalancer.Etag())
 		if err != nil {
 			return err
@@ -6891,10 +7062,22 @@ func (n *ovn) remotePeerCreate(peer api.NetworkPeersPost) error {
 }
 
 // PeerCreate creates a network peering.
-func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
+func (n *ovn) PeerCreate(peer api.NetworkPeersPost, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		// Only BGP-EVPN peerings get applied on all members.
+		integration, err := n.peerLoadIntegration(peer.TargetIntegration)
+		if err != nil {
+			return err
+		}
+
+		return n.evpnSetupIntegration(integration, false)
+	}
+
 	reverter := revert.New()
 	defer reverter.Fail()
 
+	var integration *api.NetworkIntegration
+
 	// Default type is local.
 	if peer.Type == "" {
 		peer.Type = "local"
@@ -6916,150 +7099,32 @@ func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
 		if peer.TargetIntegration == "" {
 			return api.StatusErrorf(http.StatusBadRequest, "Target integration is required")
 		}
-	}
-
-	// Look for an existing entry.
-	var peers map[int64]*api.NetworkPeer
 
-	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		var err error
-
-		// Use generated function to get peers.
-		netID := n.ID()
-		filter := dbCluster.NetworkPeerFilter{NetworkID: &netID}
-		dbPeers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), filter)
+		integration, err = n.peerLoadIntegration(peer.TargetIntegration)
 		if err != nil {
-			return fmt.Errorf("Failed loading network peer DB objects: %w", err)
-		}
-
-		// Convert DB objects to API objects and build the map.
-		peers = make(map[int64]*api.NetworkPeer, len(dbPeers))
-		for _, dbPeer := range dbPeers {
-			peer, err := dbPeer.ToAPI(ctx, tx.Tx())
-			if err != nil {
-				return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
-			}
-
-			peers[dbPeer.ID] = peer
-		}
-
-		return nil
-	})
-	if err != nil {
-		return err
-	}
-
-	for _, existingPeer := range peers {
-		if peer.Name == existingPeer.Name {
-			return api.StatusErrorf(http.StatusConflict, "A peer for that name already exists")
-		}
-
-		if peer.Type == "local" && peer.TargetProject == existingPeer.TargetProject && peer.TargetNetwork == existingPeer.TargetNetwork {
-			return api.StatusErrorf(http.StatusConflict, "A peer for that target network already exists")
-		}
-	}
-
-	// Perform general (create and update) validation.
-	err = n.peerValidate(peer.Name, &peer.NetworkPeerPut)
-	if err != nil {
-		return err
-	}
-
-	var peerID int64
-	var mutualExists bool
-
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error { // Create peer DB record.
-		record := dbCluster.NetworkPeer{
-			NetworkID:   n.ID(),
-			Name:        peer.Name,
-			Description: peer.Description,
-			Type:        dbCluster.NetworkPeerTypes[peer.Type],
+			return err
 		}
 
-		switch peer.Type {
-		case "remote":
-			integrationID, err := dbCluster.GetNetworkIntegrationID(ctx, tx.Tx(), peer.TargetIntegration)
+		switch integration.Type {
+		case "ovn":
+		case "bgp-evpn":
+			err = n.peerIntegrationAllowed(integration.Name)
 			if err != nil {
 				return err
 			}
 
-			id := sql.NullInt64{}
-			err = id.Scan(integrationID)
+			err = ovnEVPNValidate(integration)
 			if err != nil {
 				return err
 			}
 
-			record.TargetNetworkIntegrationID = id
-
-		case "local":
-			// Check if target peer already exists.
-			peers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{
-				Type:                 &record.Type,
-				TargetNetworkProject: &n.project,
-				TargetNetworkName:    &n.name,
-			})
-			if err != nil && !errors.Is(err, sql.ErrNoRows) {
-				return err
-			}
-
-			if len(peers) == 1 {
-				// Update the target peer.
-				peer := peers[0]
-
-				empty := sql.NullString{}
-				peer.TargetNetworkProject = empty
-				peer.TargetNetworkName = empty
-
-				targetID := sql.NullInt64{}
-				err = targetID.Scan(n.id)
-				if err != nil {
-					return err
-				}
-
-				peer.TargetNetworkID = targetID
-
-				err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), peer.NetworkID, peer.Name, peer)
-				if err != nil {
-					return err
-				}
-
-				// Set our target network ID to match.
-				id := sql.NullInt64{}
-				err = id.Scan(peer.NetworkID)
-				if err != nil {
-					return err
-				}
-
-				record.TargetNetworkID = id
-
-				mutualExists = true
-			} else if len(peers) == 0 {
-				networkProjectName := sql.NullString{}
-				err = networkProjectName.Scan(peer.TargetProject)
-				if err != nil {
-					return err
-				}
-
-				networkName := sql.NullString{}
-				err = networkName.Scan(peer.TargetNetwork)
-				if err != nil {
-					return err
-				}
-
-				record.TargetNetworkProject = networkProjectName
-				record.TargetNetworkName = networkName
-			} else {
-				return errors.New("More than one matching network peer was found")
-			}
-		}
-
-		peerID, err = dbCluster.CreateNetworkPeer(ctx, tx.Tx(), record)
-		if err != nil {
-			return err
+		default:
+			return api.StatusErrorf(http.StatusBadRequest, "Network integrations of type %q can't be used with OVN networks", integration.Type)
 		}
+	}
 
-		return nil
-	})
+	peerID, mutualExists, err := n.peerCreateRecord(peer)
 	if err != nil {
 		return err
 	}
@@ -7081,6 +7146,26 @@ func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
 		if err != nil {
 			return err
 		}
+	} else if peer.Type == "remote" && integration.Type == "bgp-evpn" {
+		err := n.evpnSetupIntegration(integration, false)
+		if err != nil {
+			return err
+		}
+
+		reverter.Add(func() { _ = n.evpnClearIntegration(integration) })
+
+		// Notify all other members to advertise the network too.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client incus.InstanceServer) error {
+			return client.UseProject(n.project).CreateNetworkPeer(n.name, peer)
+		})
+		if err != nil {
+			return err
+		}
 	} else if peer.Type == "remote" {
 		err := n.remotePeerCreate(peer)
 		if err != nil {
@@ -7225,80 +7310,8 @@ func (n *ovn) peerSetup(ovnnb *networkOVN.NB, targetOVNNet *ovn, opts networkOVN
 }
 
 // PeerUpdate updates a network peering.
-func (n *ovn) PeerUpdate(peerName string, req api.NetworkPeerPut) error {
-	reverter := revert.New()
-	defer reverter.Fail()
-
-	var curPeer *api.NetworkPeer
-	var dbCurPeer *dbCluster.NetworkPeer
-
-	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		var err error
-
-		dbCurPeer, err = dbCluster.GetNetworkPeer(ctx, tx.Tx(), n.id, peerName)
-		if err != nil {
-			return fmt.Errorf("Failed getting network peer DB object: %w", err)
-		}
-
-		curPeer, err = dbCurPeer.ToAPI(ctx, tx.Tx())
-		if err != nil {
-			return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
-		}
-
-		return nil
-	})
-	if err != nil {
-		return err
-	}
-
-	err = n.peerValidate(peerName, &req)
-	if err != nil {
-		return err
-	}
-
-	curPeerEtagHash, err := localUtil.EtagHash(curPeer.Etag())
-	if err != nil {
-		return err
-	}
-
-	newPeer := api.NetworkPeer{
-		Name:           curPeer.Name,
-		NetworkPeerPut: req,
-	}
-
-	newPeerEtagHash, err := localUtil.EtagHash(newPeer.Etag())
-	if err != nil {
-		return err
-	}
-
-	if curPeerEtagHash == newPeerEtagHash {
-		return nil // Nothing has changed.
-	}
-
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		// Update the description field from the input.
-		dbCurPeer.Description = newPeer.Description
-
-		// Update the main peer object.
-		err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), n.id, dbCurPeer.Name, *dbCurPeer)
-		if err != nil {
-			return fmt.Errorf("Failed to update network peer: %w", err)
-		}
-
-		// Update the peer configuration.
-		err = dbCluster.UpdateNetworkPeerConfig(ctx, tx.Tx(), dbCurPeer.ID, newPeer.Config)
-		if err != nil {
-			return fmt.Errorf("Failed to update network peer config: %w", err)
-		}
-
-		return nil
-	})
-	if err != nil {
-		return err
-	}
-
-	reverter.Success()
-	return nil
+func (n *ovn) PeerUpdate(peerName string, req api.NetworkPeerPut, clientType request.ClientType) error {
+	return n.peerUpdateRecord(peerName, req)
 }
 
 // localPeerDelete deletes a network peering with another local network.
@@ -7440,75 +7453,174 @@ func (n *ovn) remotePeerDelete(peer *api.NetworkPeer) error {
 	return nil
 }
 
-// PeerDelete deletes a network peering.
-func (n *ovn) PeerDelete(peerName string) error {
-	var peerID int64
-	var peer *api.NetworkPeer
-
-	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		dbPeer, err := dbCluster.GetNetworkPeer(ctx, tx.Tx(), n.id, peerName)
-		if err != nil {
-			return fmt.Errorf("Failed getting network peer DB object: %w", err)
+// ovnEVPNValidate checks that a BGP-EVPN integration has all the configuration needed to advertise networks.
+func ovnEVPNValidate(integration *api.NetworkIntegration) error {
+	for _, key := range []string{"bgp.peers", "bgp.asn", "evpn.vni"} {
+		if integration.Config[key] == "" {
+			return api.StatusErrorf(http.StatusBadRequest, "Network integration %q is missing the %q configuration key", integration.Name, key)
 		}
+	}
 
-		peerID = dbPeer.ID
-		peer, err = dbPeer.ToAPI(ctx, tx.Tx())
-		if err != nil {
-			return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
+	return nil
+}
+
+// evpnOwner returns the BGP owner of the EVPN routes of the network for an integration.
+func (n *ovn) evpnOwner(integration *api.NetworkIntegration) string {
+	return fmt.Sprintf("network_%d_evpn_%s", n.id, integration.Name)
+}
+
+// evpnSetup advertises the network to all the BGP-EVPN fabrics it is peered with.
+// When prefixesOnly is set, only the advertised prefixes are refreshed (the BGP peers are left as is).
+func (n *ovn) evpnSetup(prefixesOnly bool) error {
+	integrations, err := n.peerIntegrations("bgp-evpn")
+	if err != nil {
+		return err
+	}
+
+	for _, integration := range integrations {
+		err = n.evpnSetupIntegration(integration, prefixesOnly)
+		if err != nil {
+			return err
 		}
+	}
 
-		return nil
-	})
+	return nil
+}
+
+// evpnClear withdraws the network from all the BGP-EVPN fabrics it is peered with.
+func (n *ovn) evpnClear() error {
+	integrations, err := n.peerIntegrations("bgp-evpn")
 	if err != nil {
 		return err
 	}
 
-	isUsed, err := n.peerIsUsed(peer.Name)
+	for _, integration := range integrations {
+		err = n.evpnClearIntegration(integration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evpnSetupIntegration advertises the subnets of the network as EVPN IP prefix routes to the fabric of an integration.
+func (n *ovn) evpnSetupIntegration(integration *api.NetworkIntegration, prefixesOnly bool) error {
+	config := integration.Config
+
+	err := ovnEVPNValidate(integration)
 	if err != nil {
 		return err
 	}
 
-	if isUsed {
-		return errors.New("Cannot delete a peer that is in use")
+	vni, err := strconv.ParseUint(config["evpn.vni"], 10, 32)
+	if err != nil {
+		return err
 	}
 
-	if peer.Status == api.NetworkStatusCreated {
-		if peer.Type == "local" {
-			err := n.localPeerDelete(peer)
+	if !prefixesOnly {
+		asn, err := strconv.ParseUint(config["bgp.asn"], 10, 32)
+		if err != nil {
+			return err
+		}
+
+		var holdTime uint64
+		if config["bgp.holdtime"] != "" {
+			holdTime, err = strconv.ParseUint(config["bgp.holdtime"], 10, 32)
 			if err != nil {
 				return err
 			}
-		} else if peer.Type == "remote" {
-			err := n.remotePeerDelete(peer)
+		}
+
+		for _, address := range util.SplitNTrimSpace(config["bgp.peers"], ",", -1, true) {
+			err = n.state.BGP.AddEVPNPeer(net.ParseIP(address), uint32(asn), config["bgp.password"], holdTime)
 			if err != nil {
-				return err
+				return fmt.Errorf("Failed adding EVPN peer %q: %w", address, err)
 			}
 		}
 	}
 
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		// Deactivate any existing peer.
-		if peer.Type == "local" {
-			peers, err := dbCluster.GetNetworkPeers(ctx, tx.Tx(), dbCluster.NetworkPeerFilter{TargetNetworkID: &n.id})
-			if err != nil && !errors.Is(err, sql.ErrNoRows) {
-				return err
-			}
+	// Clear existing prefixes.
+	owner := n.evpnOwner(integration)
+	err = n.state.BGP.RemovePrefixByOwner(owner)
+	if err != nil {
+		return err
+	}
 
-			for _, peer := range peers {
-				peer.TargetNetworkID = sql.NullInt64{}
+	// Traffic is sent to the VTEP in front of the network, defaulting to the uplink address of the network.
+	nextHop := net.ParseIP(config["evpn.nexthop"])
+	if nextHop == nil {
+		nextHop = net.ParseIP(n.config["volatile.network.ipv4.address"])
+		if nextHop == nil {
+			nextHop = net.ParseIP(n.config["volatile.network.ipv6.address"])
+		}
+	}
 
-				err = dbCluster.UpdateNetworkPeer(ctx, tx.Tx(), peer.NetworkID, peer.Name, peer)
-				if err != nil {
-					return err
-				}
-			}
+	if nextHop == nil {
+		return errors.New("No next hop address available for EVPN routes")
+	}
+
+	// Advertise the subnets of the network, only routed (non-NAT) subnets are reachable from the fabric.
+	for _, ipVersion := range []uint{4, 6} {
+		netAddress := n.config[fmt.Sprintf("ipv%d.address", ipVersion)]
+		if slices.Contains([]string{"", "none"}, netAddress) || util.IsTrue(n.config[fmt.Sprintf("ipv%d.nat", ipVersion)]) {
+			continue
 		}
 
-		// Delete the peer.
-		err := dbCluster.DeleteNetworkPeer(ctx, tx.Tx(), n.id, peerID)
+		_, subnet, err := net.ParseCIDR(netAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing network address %q: %w", netAddress, err)
+		}
+
+		route := bgp.EVPNRoute{
+			VNI:         uint32(vni),
+			RouteTarget: config["evpn.route_target"],
+			Gateway:     net.ParseIP(n.config[fmt.Sprintf("volatile.network.ipv%d.address", ipVersion)]),
+		}
+
+		err = n.state.BGP.AddEVPNPrefix(*subnet, nextHop, route, owner)
 		if err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// evpnClearIntegration withdraws the network from the fabric of a BGP-EVPN integration.
+func (n *ovn) evpnClearIntegration(integration *api.NetworkIntegration) error {
+	err := n.state.BGP.RemovePrefixByOwner(n.evpnOwner(integration))
+	if err != nil {
+		return err
+	}
+
+	for _, address := range util.SplitNTrimSpace(integration.Config["bgp.peers"], ",", -1, true) {
+		err = n.state.BGP.RemovePeer(net.ParseIP(address))
+		if err != nil && !errors.Is(err, bgp.ErrPeerNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PeerDelete deletes a network peering.
+func (n *ovn) PeerDelete(peerName string, clientType request.ClientType) error {
+	var peerID int64
+	var peer *api.NetworkPeer
+	var integration *api.NetworkIntegration
+
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		dbPeer, err := dbCluster.GetNetworkPeer(ctx, tx.Tx(), n.id, peerName)
+		if err != nil {
+			return fmt.Errorf("Failed getting network peer DB object: %w", err)
+		}
+
+		peerID = dbPeer.ID
+		peer, err = dbPeer.ToAPI(ctx, tx.Tx())
+		if err != nil {
+			return fmt.Errorf("Failed converting network peer DB object to API object: %w", err)
+		}
 
 		return nil
 	})
@@ -7516,7 +7628,61 @@ func (n *ovn) PeerDelete(peerName string) error {
 		return err
 	}
 
-	return nil
+	isUsed, err := n.peerIsUsed(peer.Name)
+	if err != nil {
+		return err
+	}
+
+	if isUsed {
+		return errors.New("Cannot delete a peer that is in use")
+	}
+
+	if peer.Type == "remote" {
+		integration, err = n.peerLoadIntegration(peer.TargetIntegration)
+		if err != nil {
+			return err
+		}
+	}
+
+	if integration != nil && integration.Type == "bgp-evpn" {
+		// Notify all other members to withdraw the network (before the peer record goes away).
+		if clientType == request.ClientTypeNormal {
+			notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+			if err != nil {
+				return err
+			}
+
+			err = notifier(func(client incus.InstanceServer) error {
+				return client.UseProject(n.project).DeleteNetworkPeer(n.name, peerName)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = n.evpnClearIntegration(integration)
+		if err != nil {
+			return err
+		}
+
+		if clientType == request.ClientTypeNotifier {
+			return nil
+		}
+	} else if peer.Status == api.NetworkStatusCreated {
+		if peer.Type == "local" {
+			err := n.localPeerDelete(peer)
+			if err != nil {
+				return err
+			}
+		} else if peer.Type == "remote" {
+			err := n.remotePeerDelete(peer)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return n.peerDeleteRecord(peerID, peer.Type)
 }
 
 // forPeers runs f for each target peer network that this network is connected to.
@@ -7685,3 +7851,320 @@ func (n *ovn) loadBalancerBGPSetupPrefixes() error {
 
 	return nil
 }
+
+// ovnDHCPOptions returns the OVN DHCP options matching the DHCP option keys in config.
+func ovnDHCPOptions(config map[string]string, ipv6 bool) (map[string]string, error) {
+	options, err := dhcp.ParseOptions(config, ipv6)
+	if err != nil {
+		return nil, err
+	}
+
+	ovnOptions := make(map[string]string, len(options))
+	for _, option := range options {
+		key, value := option.OVN()
+		if key == "" {
+			return nil, fmt.Errorf("DHCP option %q isn't supported by OVN", option.Name)
+		}
+
+		ovnOptions[key] = value
+	}
+
+	return ovnOptions, nil
+}
+
+// qosSetup applies the network level QoS rules to the internal logical switch.
+// Any existing network level rules are replaced.
+func (n *ovn) qosSetup() error {
+	qosRules, err := ovnNetworkQoSRules(n.getIntSwitchRouterPortName(), n.config)
+	if err != nil {
+		return err
+	}
+
+	return n.ovnnb.UpdateLogicalSwitchQoSRules(context.TODO(), n.getIntSwitchName(), string(n.getIntSwitchName()), nil, qosRules...)
+}
+
+// ovnNetworkQoSRules returns the network level QoS rules for the traffic going through the router port.
+func ovnNetworkQoSRules(routerPortName networkOVN.OVNSwitchPort, config map[string]string) ([]networkOVN.OVNQoSRule, error) {
+	qosRules := []networkOVN.OVNQoSRule{}
+
+	// Traffic coming from the uplink through the router.
+	if config["limits.ingress"] != "" {
+		rate, err := networkOVN.ParseQoSRate(config["limits.ingress"])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid limits.ingress: %w", err)
+		}
+
+		qosRules = append(qosRules, networkOVN.OVNQoSRule{
+			Direction: "from-lport",
+			Match:     fmt.Sprintf(`inport == "%s"`, routerPortName),
+			Priority:  networkOVN.OVNQoSPriorityNetwork,
+			Rate:      rate,
+		})
+	}
+
+	// Traffic going to the uplink through the router.
+	if config["limits.egress"] != "" {
+		rate, err := networkOVN.ParseQoSRate(config["limits.egress"])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid limits.egress: %w", err)
+		}
+
+		qosRules = append(qosRules, networkOVN.OVNQoSRule{
+			Direction: "to-lport",
+			Match:     fmt.Sprintf(`outport == "%s"`, routerPortName),
+			Priority:  networkOVN.OVNQoSPriorityNetwork,
+			Rate:      rate,
+		})
+	}
+
+	// Traffic originating from the network itself.
+	if config["qos.dscp"] != "" {
+		dscp, err := strconv.Atoi(config["qos.dscp"])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid qos.dscp: %w", err)
+		}
+
+		qosRules = append(qosRules, networkOVN.OVNQoSRule{
+			Direction: "from-lport",
+			Match:     fmt.Sprintf(`inport != "%s"`, routerPortName),
+			Priority:  networkOVN.OVNQoSPriorityNetwork,
+			DSCP:      &dscp,
+		})
+	}
+
+	return qosRules, nil
+}
+
+// ovnPortQoSRules returns the QoS rules and the minimum guaranteed rate (in bit/s) for an instance NIC.
+func ovnPortQoSRules(portName networkOVN.OVNSwitchPort, config map[string]string) ([]networkOVN.OVNQoSRule, uint64, error) {
+	qosRules := []networkOVN.OVNQoSRule{}
+
+	ingress := config["limits.ingress"]
+	egress := config["limits.egress"]
+	if config["limits.max"] != "" {
+		ingress = config["limits.max"]
+		egress = config["limits.max"]
+	}
+
+	// Traffic going to the instance.
+	if ingress != "" {
+		rate, err := networkOVN.ParseQoSRate(ingress)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid ingress limit: %w", err)
+		}
+
+		qosRules = append(qosRules, networkOVN.OVNQoSRule{
+			Direction: "to-lport",
+			Match:     fmt.Sprintf(`outport == "%s"`, portName),
+			Priority:  networkOVN.OVNQoSPriorityPort,
+			Rate:      rate,
+		})
+	}
+
+	// Traffic coming from the instance.
+	egressRule := networkOVN.OVNQoSRule{
+		Direction: "from-lport",
+		Match:     fmt.Sprintf(`inport == "%s"`, portName),
+		Priority:  networkOVN.OVNQoSPriorityPort,
+	}
+
+	if egress != "" {
+		rate, err := networkOVN.ParseQoSRate(egress)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid egress limit: %w", err)
+		}
+
+		egressRule.Rate = rate
+	}
+
+	if config["qos.dscp"] != "" {
+		dscp, err := strconv.Atoi(config["qos.dscp"])
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid qos.dscp: %w", err)
+		}
+
+		egressRule.DSCP = &dscp
+	}
+
+	if egressRule.Rate > 0 || egressRule.DSCP != nil {
+		qosRules = append(qosRules, egressRule)
+	}
+
+	var minRate uint64
+	if config["qos.min_rate"] != "" {
+		rate, err := units.ParseBitSizeString(config["qos.min_rate"])
+		if err != nil {
+			return nil, 0, fmt.Errorf("Invalid qos.min_rate: %w", err)
+		}
+
+		minRate = uint64(rate)
+	}
+
+	return qosRules, minRate, nil
+}
+
+// ValidateOVNQoSRate validates a bit/s limit used for OVN QoS rules.
+func ValidateOVNQoSRate(value string) error {
+	_, err := networkOVN.ParseQoSRate(value)
+	return err
+}
+
+// InstanceNICPath returns the logical switch, router and uplink the traffic of an instance NIC goes through.
+func (n *ovn) InstanceNICPath(opts *InstanceNICPathOpts) ([]api.InstanceNetworkPathHop, error) {
+	forwards, loadBalancers, peers, err := n.instanceNICPathServices(opts.Addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	switchHop := api.InstanceNetworkPathHop{
+		Type:      "ovn-switch",
+		Name:      string(n.getIntSwitchName()),
+		Network:   n.name,
+		Addresses: []string{},
+		ACLs:      util.SplitNTrimSpace(n.config["security.acls"], ",", -1, true),
+	}
+
+	routerHop := api.InstanceNetworkPathHop{
+		Type:          "ovn-router",
+		Name:          string(n.getRouterName()),
+		Network:       n.name,
+		Addresses:     []string{},
+		Forwards:      forwards,
+		LoadBalancers: loadBalancers,
+		Peers:         peers,
+	}
+
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		address := n.config[keyPrefix+".address"]
+		if address != "" && address != "none" {
+			switchHop.Addresses = append(switchHop.Addresses, address)
+		}
+	}
+
+	for _, key := range []string{ovnVolatileUplinkIPv4, ovnVolatileUplinkIPv6} {
+		if n.config[key] != "" {
+			routerHop.Addresses = append(routerHop.Addresses, n.config[key])
+		}
+	}
+
+	hops := []api.InstanceNetworkPathHop{switchHop, routerHop}
+
+	uplink := n.config["network"]
+	if uplink != "" && uplink != "none" {
+		hops = append(hops, api.InstanceNetworkPathHop{Type: "uplink", Name: uplink, Network: uplink})
+	}
+
+	return hops, nil
+}
+
+// InstanceNICTrace simulates a flow from an instance NIC through the OVN logical network using ovn-trace.
+func (n *ovn) InstanceNICTrace(opts *InstanceNICPathOpts, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	dst, src, err := parseTraceFlow(req)
+	if err != nil {
+		return nil, err
+	}
+
+	isIPv4 := dst.To4() != nil
+
+	// Default to the first NIC address of the same family.
+	if src == nil {
+		for _, address := range opts.Addresses {
+			if (address.To4() != nil) == isIPv4 {
+				src = address
+				break
+			}
+		}
+
+		if src == nil {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "NIC %q doesn't have an address to trace from", opts.DeviceName)
+		}
+	}
+
+	mac := opts.DeviceConfig["hwaddr"]
+	if mac == "" {
+		return nil, fmt.Errorf("NIC %q doesn't have a MAC address", opts.DeviceName)
+	}
+
+	routerMAC, err := n.getRouterMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	ipFamily := "ip6"
+	icmpProtocol := "icmp6"
+	if isIPv4 {
+		ipFamily = "ip4"
+		icmpProtocol = "icmp4"
+	}
+
+	portName := n.getInstanceDevicePortName(opts.InstanceUUID, opts.DeviceName)
+	microflow := []string{
+		fmt.Sprintf("inport == %q", portName),
+		fmt.Sprintf("eth.src == %s", mac),
+		fmt.Sprintf("eth.dst == %s", routerMAC.String()),
+		fmt.Sprintf("%s.src == %s", ipFamily, src.String()),
+		fmt.Sprintf("%s.dst == %s", ipFamily, dst.String()),
+		"ip.ttl == 64",
+	}
+
+	switch req.Protocol {
+	case "tcp", "udp":
+		microflow = append(microflow, req.Protocol)
+		if req.DestinationPort > 0 {
+			microflow = append(microflow, fmt.Sprintf("%s.dst == %d", req.Protocol, req.DestinationPort))
+		}
+
+	case "icmp":
+		microflow = append(microflow, icmpProtocol)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	output, err := n.ovnsb.Trace(ctx, n.getIntSwitchName(), strings.Join(microflow, " && "))
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &api.InstanceNetworkTrace{
+		Verdict: ovnTraceVerdict(output),
+		Output:  strings.Split(strings.TrimSpace(output), "\n"),
+	}
+
+	return trace, nil
+}
+
+// ovnTraceStage matches the lines of the ovn-trace output introducing a logical flow table, such as:
+// " 9. ls_out_port_sec_l2 (northd.c:4786): outport == "sw0-port2", priority 50, uuid 6e28d3a1".
+var ovnTraceStage = regexp.MustCompile(`^\s*[0-9]+\. [a-z_]+`)
+
+// ovnTraceVerdict returns the verdict of a flow from the output of ovn-trace.
+// Earlier stages may output the packet to the next logical datapath, so only the actions of the last stage
+// the packet went through are considered.
+func ovnTraceVerdict(output string) string {
+	lines := strings.Split(output, "\n")
+
+	last := -1
+	for i, line := range lines {
+		if ovnTraceStage.MatchString(line) {
+			last = i
+		}
+	}
+
+	if last < 0 {
+		return "unknown"
+	}
+
+	for _, line := range lines[last:] {
+		action := strings.TrimSpace(line)
+
+		switch {
+		case action == "drop;", strings.HasPrefix(action, "reject"), strings.Contains(action, "implicit drop"), strings.HasPrefix(action, "/* omitting output"):
+			return "drop"
+		case strings.HasPrefix(action, `/* output to "`):
+			return "allow"
+		}
+	}
+
+	return "unknown"
+}
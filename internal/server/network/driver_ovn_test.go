@@ -0,0 +1,202 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkOVN "github.com/lxc/incus/v6/internal/server/network/ovn"
+)
+
+func Test_ovnNetworkQoSRules(t *testing.T) {
+	dscp := 46
+
+	tests := []struct {
+		name   string
+		config map[string]string
+		rules  []networkOVN.OVNQoSRule
+		err    bool
+	}{
+		{
+			name:   "No limits",
+			config: map[string]string{},
+			rules:  []networkOVN.OVNQoSRule{},
+		},
+		{
+			name:   "Ingress and egress limits",
+			config: map[string]string{"limits.ingress": "100Mbit", "limits.egress": "50Mbit"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "from-lport", Match: `inport == "net-ls-int-lsp-router"`, Priority: networkOVN.OVNQoSPriorityNetwork, Rate: 100000},
+				{Direction: "to-lport", Match: `outport == "net-ls-int-lsp-router"`, Priority: networkOVN.OVNQoSPriorityNetwork, Rate: 50000},
+			},
+		},
+		{
+			name:   "DSCP only",
+			config: map[string]string{"qos.dscp": "46"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "from-lport", Match: `inport != "net-ls-int-lsp-router"`, Priority: networkOVN.OVNQoSPriorityNetwork, DSCP: &dscp},
+			},
+		},
+		{
+			name:   "Limit below 1kbit",
+			config: map[string]string{"limits.egress": "500bit"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ovnNetworkQoSRules("net-ls-int-lsp-router", tt.config)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
+
+func Test_ovnPortQoSRules(t *testing.T) {
+	dscp := 10
+
+	tests := []struct {
+		name    string
+		config  map[string]string
+		rules   []networkOVN.OVNQoSRule
+		minRate uint64
+		err     bool
+	}{
+		{
+			name:   "No limits",
+			config: map[string]string{},
+			rules:  []networkOVN.OVNQoSRule{},
+		},
+		{
+			name:   "Ingress and egress limits",
+			config: map[string]string{"limits.ingress": "20Mbit", "limits.egress": "10Mbit"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "to-lport", Match: `outport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, Rate: 20000},
+				{Direction: "from-lport", Match: `inport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, Rate: 10000},
+			},
+		},
+		{
+			name:   "Maximum limit overrides ingress and egress",
+			config: map[string]string{"limits.ingress": "20Mbit", "limits.egress": "10Mbit", "limits.max": "1Gbit"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "to-lport", Match: `outport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, Rate: 1000000},
+				{Direction: "from-lport", Match: `inport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, Rate: 1000000},
+			},
+		},
+		{
+			name:   "DSCP only",
+			config: map[string]string{"qos.dscp": "10"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "from-lport", Match: `inport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, DSCP: &dscp},
+			},
+		},
+		{
+			name:   "DSCP combined with the egress limit",
+			config: map[string]string{"limits.egress": "5Mbit", "qos.dscp": "10"},
+			rules: []networkOVN.OVNQoSRule{
+				{Direction: "from-lport", Match: `inport == "inst-eth0"`, Priority: networkOVN.OVNQoSPriorityPort, Rate: 5000, DSCP: &dscp},
+			},
+		},
+		{
+			name:    "Minimum rate",
+			config:  map[string]string{"qos.min_rate": "100Mbit"},
+			rules:   []networkOVN.OVNQoSRule{},
+			minRate: 100000000,
+		},
+		{
+			name:   "Limit below 1kbit",
+			config: map[string]string{"limits.ingress": "999bit"},
+			err:    true,
+		},
+		{
+			name:   "Invalid DSCP",
+			config: map[string]string{"qos.dscp": "af41"},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, minRate, err := ovnPortQoSRules("inst-eth0", tt.config)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rules, rules)
+			assert.Equal(t, tt.minRate, minRate)
+		})
+	}
+}
+
+func Test_ovnTraceVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		verdict string
+	}{
+		{
+			name: "Delivered to the destination port",
+			output: `# ip,reg14=0x1,vlan_tci=0x0000,dl_src=00:16:3e:00:00:01,dl_dst=00:16:3e:00:00:02,nw_src=10.0.0.2,nw_dst=10.0.0.3,nw_ttl=64
+ingress(dp="net-ls-int", inport="net-c1-eth0")
+----------------------------------------------
+ 0. ls_in_port_sec_l2 (northd.c:4829): inport == "net-c1-eth0", priority 50, uuid 6e28d3a1
+    next;
+22. ls_in_l2_lkup (northd.c:7519): eth.dst == 00:16:3e:00:00:02, priority 50, uuid 1f4bc2a7
+    outport = "net-c2-eth0";
+    output;
+
+egress(dp="net-ls-int", inport="net-c1-eth0", outport="net-c2-eth0")
+--------------------------------------------------------------------
+ 9. ls_out_port_sec_l2 (northd.c:4786): outport == "net-c2-eth0", priority 50, uuid 4b1d7e0c
+    output;
+    /* output to "net-c2-eth0", type "" */`,
+			verdict: "allow",
+		},
+		{
+			name: "Dropped by an ACL after being output to the router",
+			output: `ingress(dp="net-ls-int", inport="net-c1-eth0")
+----------------------------------------------
+22. ls_in_l2_lkup (northd.c:7519): eth.dst == 00:16:3e:00:00:ff, priority 50, uuid 1f4bc2a7
+    outport = "net-ls-int-lsp-router";
+    output;
+
+egress(dp="net-ls-int", inport="net-c1-eth0", outport="net-ls-int-lsp-router")
+------------------------------------------------------------------------------
+ 9. ls_out_port_sec_l2 (northd.c:4786): outport == "net-ls-int-lsp-router", priority 50, uuid 4b1d7e0c
+    output;
+    /* output to "net-ls-int-lsp-router", type "patch" */
+
+ingress(dp="net-lr", inport="net-lr-lrp-int")
+--------------------------------------------
+ 4. lr_in_acl (northd.c:5000): ip4.dst == 192.0.2.10, priority 2000, uuid 9c3a5e11
+    drop;`,
+			verdict: "drop",
+		},
+		{
+			name: "No matching flow",
+			output: `ingress(dp="net-ls-int", inport="net-c1-eth0")
+----------------------------------------------
+ 0. ls_in_port_sec_l2: no match (implicit drop)`,
+			verdict: "drop",
+		},
+		{
+			name:    "Unexpected output",
+			output:  "ovn-trace: unknown datapath",
+			verdict: "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.verdict, ovnTraceVerdict(tt.output))
+		})
+	}
+}
@@ -104,6 +104,14 @@ func (n *physical) Validate(config map[string]string, clientType request.ClientT
 		// shortdesc: Comma-separated list of IPv4 ranges to use for child OVN network routers (FIRST-LAST format)
 		"ipv4.ovn.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV4)),
 
+		// gendoc:generate(entity=network_physical, group=ipv4, key=ipv4.floating.ranges)
+		//
+		// ---
+		// type: string
+		// condition: -
+		// shortdesc: Comma-separated list of IPv4 ranges to allocate floating IPs of child OVN networks from (FIRST-LAST format)
+		"ipv4.floating.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV4)),
+
 		// gendoc:generate(entity=network_physical, group=ipv6, key=ipv6.ovn.ranges)
 		//
 		// ---
@@ -112,6 +120,14 @@ func (n *physical) Validate(config map[string]string, clientType request.ClientT
 		// shortdesc: Comma-separated list of IPv6 ranges to use for child OVN network routers (FIRST-LAST format)
 		"ipv6.ovn.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV6)),
 
+		// gendoc:generate(entity=network_physical, group=ipv6, key=ipv6.floating.ranges)
+		//
+		// ---
+		// type: string
+		// condition: -
+		// shortdesc: Comma-separated list of IPv6 ranges to allocate floating IPs of child OVN networks from (FIRST-LAST format)
+		"ipv6.floating.ranges": validate.Optional(validate.IsListOf(validate.IsNetworkRangeV6)),
+
 		// gendoc:generate(entity=network_physical, group=ipv4, key=ipv4.routes)
 		//
 		// ---
@@ -212,6 +228,14 @@ func (n *physical) Validate(config map[string]string, clientType request.ClientT
 		return err
 	}
 
+	// Check the floating IP ranges don't overlap with the OVN ranges.
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		err = floatingIPRangesValidate(config, keyPrefix, nil)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
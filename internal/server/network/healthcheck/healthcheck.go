@@ -0,0 +1,228 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Backend statuses.
+const (
+	StatusUnknown = "unknown"
+	StatusOnline  = "online"
+	StatusOffline = "offline"
+)
+
+// Target represents a backend address and port to check.
+type Target struct {
+	Protocol string
+	Address  string
+	Port     uint64
+}
+
+// String returns the target in the protocol:address:port form.
+func (t Target) String() string {
+	return fmt.Sprintf("%s:%s", t.Protocol, net.JoinHostPort(t.Address, strconv.FormatUint(t.Port, 10)))
+}
+
+// Config represents the health checks of a set of targets.
+type Config struct {
+	Targets      []Target
+	Interval     time.Duration
+	Timeout      time.Duration
+	SuccessCount int
+	FailureCount int
+
+	// OnChange is called (from a separate go routine) whenever the status of a target changes.
+	OnChange func()
+}
+
+// Checker represents the running health checks of a set of targets.
+type Checker struct {
+	config Config
+	cancel context.CancelFunc
+
+	status map[Target]string
+	mu     sync.Mutex
+}
+
+var checkers = map[string]*Checker{}
+var checkersMu sync.Mutex
+
+// Start starts (or restarts) the health checks identified by name.
+// The last known status of the targets that are still checked is kept.
+func Start(name string, config Config) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+
+	status := map[Target]string{}
+
+	existing := checkers[name]
+	if existing != nil {
+		existing.cancel()
+
+		existing.mu.Lock()
+		for _, target := range config.Targets {
+			s, ok := existing.status[target]
+			if ok {
+				status[target] = s
+			}
+		}
+
+		existing.mu.Unlock()
+	}
+
+	if len(config.Targets) == 0 {
+		delete(checkers, name)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Checker{
+		config: config,
+		cancel: cancel,
+		status: status,
+	}
+
+	for _, target := range config.Targets {
+		go c.run(ctx, name, target)
+	}
+
+	checkers[name] = c
+}
+
+// Stop stops the health checks identified by name (if running).
+func Stop(name string) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+
+	c := checkers[name]
+	if c == nil {
+		return
+	}
+
+	c.cancel()
+	delete(checkers, name)
+}
+
+// Status returns the current status of a target.
+func Status(name string, target Target) string {
+	checkersMu.Lock()
+	c := checkers[name]
+	checkersMu.Unlock()
+
+	if c == nil {
+		return StatusUnknown
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.status[target]
+	if !ok {
+		return StatusUnknown
+	}
+
+	return status
+}
+
+// run checks the target until the context is cancelled.
+func (c *Checker) run(ctx context.Context, name string, target Target) {
+	l := logger.AddContext(logger.Ctx{"checker": name, "target": target.String()})
+
+	successes := 0
+	failures := 0
+
+	for {
+		err := Check(ctx, target, c.config.Timeout)
+		if ctx.Err() != nil {
+			return
+		}
+
+		newStatus := ""
+		if err == nil {
+			successes++
+			failures = 0
+
+			if successes >= c.config.SuccessCount {
+				newStatus = StatusOnline
+			}
+		} else {
+			failures++
+			successes = 0
+
+			if failures >= c.config.FailureCount {
+				newStatus = StatusOffline
+			}
+		}
+
+		if newStatus != "" {
+			c.mu.Lock()
+			oldStatus := c.status[target]
+			c.status[target] = newStatus
+			c.mu.Unlock()
+
+			if oldStatus != newStatus {
+				l.Info("Backend status changed", logger.Ctx{"status": newStatus, "err": err})
+
+				if c.config.OnChange != nil {
+					go c.config.OnChange()
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.config.Interval):
+		}
+	}
+}
+
+// Check performs a single check of the target.
+// TCP targets must accept the connection, UDP targets must not reject a datagram with an ICMP error.
+func Check(ctx context.Context, target Target, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	address := net.JoinHostPort(target.Address, strconv.FormatUint(target.Port, 10))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, target.Protocol, address)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	if target.Protocol != "udp" {
+		return nil
+	}
+
+	// Without a reply, a UDP backend is considered online unless an ICMP port unreachable is received.
+	// Such errors come back quickly, so don't wait for the whole timeout.
+	err = conn.SetDeadline(time.Now().Add(min(timeout, time.Second)))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write([]byte{})
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Read(make([]byte, 1))
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+
+	return nil
+}
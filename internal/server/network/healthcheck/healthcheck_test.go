@@ -0,0 +1,90 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freePort returns a local port nothing is listening on.
+func freePort(t *testing.T, protocol string) uint64 {
+	var addr net.Addr
+
+	if protocol == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr = conn.LocalAddr()
+		_ = conn.Close()
+	} else {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr = listener.Addr()
+		_ = listener.Close()
+	}
+
+	_, port, err := net.SplitHostPort(addr.String())
+	require.NoError(t, err)
+
+	portInt, err := strconv.ParseUint(port, 10, 64)
+	require.NoError(t, err)
+
+	return portInt
+}
+
+func TestCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = udpConn.Close() }()
+
+	tcpPort := uint64(listener.Addr().(*net.TCPAddr).Port)
+	udpPort := uint64(udpConn.LocalAddr().(*net.UDPAddr).Port)
+
+	ctx := context.Background()
+
+	assert.NoError(t, Check(ctx, Target{Protocol: "tcp", Address: "127.0.0.1", Port: tcpPort}, time.Second))
+	assert.Error(t, Check(ctx, Target{Protocol: "tcp", Address: "127.0.0.1", Port: freePort(t, "tcp")}, time.Second))
+	assert.NoError(t, Check(ctx, Target{Protocol: "udp", Address: "127.0.0.1", Port: udpPort}, time.Second))
+	assert.Error(t, Check(ctx, Target{Protocol: "udp", Address: "127.0.0.1", Port: freePort(t, "udp")}, time.Second))
+}
+
+func TestStartStatus(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	online := Target{Protocol: "tcp", Address: "127.0.0.1", Port: uint64(listener.Addr().(*net.TCPAddr).Port)}
+	offline := Target{Protocol: "tcp", Address: "127.0.0.1", Port: freePort(t, "tcp")}
+
+	changes := make(chan struct{}, 10)
+
+	Start("test", Config{
+		Targets:      []Target{online, offline},
+		Interval:     10 * time.Millisecond,
+		Timeout:      time.Second,
+		SuccessCount: 2,
+		FailureCount: 2,
+		OnChange:     func() { changes <- struct{}{} },
+	})
+	defer Stop("test")
+
+	assert.Eventually(t, func() bool {
+		return Status("test", online) == StatusOnline && Status("test", offline) == StatusOffline
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Len(t, changes, 2)
+
+	// Unknown targets and checkers.
+	assert.Equal(t, StatusUnknown, Status("test", Target{Protocol: "udp", Address: "127.0.0.1", Port: 1}))
+
+	Stop("test")
+	assert.Equal(t, StatusUnknown, Status("test", online))
+}
@@ -0,0 +1,27 @@
+package ipam
+
+import (
+	"context"
+)
+
+// builtin is the default driver, Incus itself keeps track of the addresses in use.
+type builtin struct{}
+
+func (d *builtin) init(serverConfig ServerConfig) error {
+	return nil
+}
+
+// Name returns the name of the driver.
+func (d *builtin) Name() string {
+	return "builtin"
+}
+
+// Reserve returns the requested address as is, leaving the allocation to Incus.
+func (d *builtin) Reserve(ctx context.Context, req Request) (string, error) {
+	return req.Address, nil
+}
+
+// Release is a no-op as nothing is tracked outside of Incus.
+func (d *builtin) Release(ctx context.Context, req Request) error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package ipam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// httpDriver reserves addresses in an external IPAM through a simple REST API:
+//
+//	POST   <url>/reservations                     reserve (or pick) an address
+//	DELETE <url>/reservations/<address>?owner=... release an address
+type httpDriver struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+type httpReservation struct {
+	Address string `json:"address"`
+}
+
+type httpError struct {
+	Error string `json:"error"`
+}
+
+func (d *httpDriver) init(serverConfig ServerConfig) error {
+	d.url = strings.TrimSuffix(serverConfig.HTTPURL, "/")
+	if d.url == "" {
+		return errors.New("The http IPAM driver requires the network.ipam.http.url server option to be set")
+	}
+
+	d.token = serverConfig.HTTPToken
+	d.client = &http.Client{Timeout: 10 * time.Second}
+
+	return nil
+}
+
+// Name returns the name of the driver.
+func (d *httpDriver) Name() string {
+	return "http"
+}
+
+// Reserve reserves the requested address in the external IPAM, or has it pick one.
+func (d *httpDriver) Reserve(ctx context.Context, req Request) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	var reservation httpReservation
+
+	err = d.query(ctx, http.MethodPost, d.url+"/reservations", bytes.NewReader(data), &reservation)
+	if err != nil {
+		return "", err
+	}
+
+	if reservation.Address == "" {
+		return "", errors.New("IPAM didn't return any address")
+	}
+
+	return reservation.Address, nil
+}
+
+// Release releases an address held by the owner of the request.
+// Addresses which aren't (or no longer) reserved by the owner are ignored.
+func (d *httpDriver) Release(ctx context.Context, req Request) error {
+	values := url.Values{}
+	values.Set("owner", req.Owner)
+
+	err := d.query(ctx, http.MethodDelete, fmt.Sprintf("%s/reservations/%s?%s", d.url, url.PathEscape(req.Address), values.Encode()), nil, nil)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// query sends a request to the IPAM and decodes the response into target (if not nil).
+func (d *httpDriver) query(ctx context.Context, method string, url string, body io.Reader, target any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed contacting IPAM: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var ipamErr httpError
+
+		_ = json.NewDecoder(resp.Body).Decode(&ipamErr)
+		if ipamErr.Error == "" {
+			ipamErr.Error = http.StatusText(resp.StatusCode)
+		}
+
+		// Pass request related errors through, report everything else (including authentication) as a server side failure.
+		status := resp.StatusCode
+		if !slices.Contains([]int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusServiceUnavailable}, status) {
+			status = http.StatusInternalServerError
+		}
+
+		return api.StatusErrorf(status, "IPAM error: %s", ipamErr.Error)
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(target)
+	if err != nil {
+		return fmt.Errorf("Failed parsing IPAM response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// mockIPAM is a minimal external IPAM handing out addresses from a fixed list.
+type mockIPAM struct {
+	token string
+	free  []string
+
+	reservations map[string]Request
+	mu           sync.Mutex
+}
+
+func (m *mockIPAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeError := func(status int, msg string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+m.token {
+		writeError(http.StatusUnauthorized, "Bad token")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/reservations":
+		var req Request
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeError(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.Address == "" {
+			if len(m.free) == 0 {
+				writeError(http.StatusServiceUnavailable, "Pool exhausted")
+				return
+			}
+
+			req.Address = m.free[0]
+			m.free = m.free[1:]
+		}
+
+		existing, ok := m.reservations[req.Address]
+		if ok && existing.Owner != req.Owner {
+			writeError(http.StatusConflict, fmt.Sprintf("Address %s is reserved by %s", req.Address, existing.Owner))
+			return
+		}
+
+		m.reservations[req.Address] = req
+		_ = json.NewEncoder(w).Encode(map[string]string{"address": req.Address})
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/reservations/"):
+		address := strings.TrimPrefix(r.URL.Path, "/reservations/")
+
+		existing, ok := m.reservations[address]
+		if !ok || existing.Owner != r.URL.Query().Get("owner") {
+			writeError(http.StatusNotFound, "Reservation not found")
+			return
+		}
+
+		delete(m.reservations, address)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(http.StatusNotFound, "Not found")
+	}
+}
+
+func newMockIPAM(t *testing.T, free ...string) (*mockIPAM, Driver) {
+	mock := &mockIPAM{token: "secret", free: free, reservations: map[string]Request{}}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	d, err := Load(map[string]string{"ipam.driver": "http"}, ServerConfig{HTTPURL: server.URL + "/", HTTPToken: "secret"})
+	require.NoError(t, err)
+
+	return mock, d
+}
+
+func TestLoad(t *testing.T) {
+	d, err := Load(map[string]string{}, ServerConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "builtin", d.Name())
+
+	_, err = Load(map[string]string{"ipam.driver": "http"}, ServerConfig{})
+	assert.Error(t, err)
+
+	_, err = Load(map[string]string{"ipam.driver": "foo"}, ServerConfig{HTTPURL: "https://ipam.example.net"})
+	assert.Error(t, err)
+
+	assert.False(t, IsExternal(map[string]string{"ipam.driver": "builtin"}))
+	assert.True(t, IsExternal(map[string]string{"ipam.driver": "http"}))
+}
+
+func TestBuiltin(t *testing.T) {
+	d, err := Load(map[string]string{"ipam.driver": "builtin"}, ServerConfig{})
+	require.NoError(t, err)
+
+	address, err := d.Reserve(context.Background(), Request{Type: TypeAddress, Family: 4})
+	require.NoError(t, err)
+	assert.Empty(t, address)
+
+	address, err = d.Reserve(context.Background(), Request{Type: TypeAddress, Family: 4, Address: "192.0.2.10"})
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.10", address)
+
+	assert.NoError(t, d.Release(context.Background(), Request{Address: "192.0.2.10"}))
+}
+
+func TestHTTPReserveRelease(t *testing.T) {
+	mock, d := newMockIPAM(t, "10.0.0.0/24", "10.0.1.0/24")
+	ctx := context.Background()
+
+	// Have the IPAM pick a subnet.
+	req := Request{Type: TypeSubnet, Family: 4, Project: "default", Network: "br0", Owner: "/1.0/networks/br0?project=default", PrefixLength: 24}
+	subnet, err := d.Reserve(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/24", subnet)
+	assert.Equal(t, "br0", mock.reservations[subnet].Network)
+	assert.Equal(t, 24, mock.reservations[subnet].PrefixLength)
+
+	// Reserving it again for the same owner is fine, for another one isn't.
+	req.Address = subnet
+	_, err = d.Reserve(ctx, req)
+	require.NoError(t, err)
+
+	other := req
+	other.Owner = "/1.0/networks/br1?project=default"
+	_, err = d.Reserve(ctx, other)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusConflict))
+
+	// Releasing as another owner is ignored.
+	require.NoError(t, d.Release(ctx, other))
+	assert.Contains(t, mock.reservations, subnet)
+
+	require.NoError(t, d.Release(ctx, req))
+	assert.NotContains(t, mock.reservations, subnet)
+
+	// Exhausting the pool.
+	_, err = d.Reserve(ctx, Request{Type: TypeSubnet, Family: 4, Owner: "a"})
+	require.NoError(t, err)
+
+	_, err = d.Reserve(ctx, Request{Type: TypeSubnet, Family: 4, Owner: "b"})
+	assert.True(t, api.StatusErrorCheck(err, http.StatusServiceUnavailable))
+}
+
+func TestHTTPAuthentication(t *testing.T) {
+	mock, _ := newMockIPAM(t, "192.0.2.10")
+
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	d, err := Load(map[string]string{"ipam.driver": "http"}, ServerConfig{HTTPURL: server.URL, HTTPToken: "wrong"})
+	require.NoError(t, err)
+
+	_, err = d.Reserve(context.Background(), Request{Type: TypeAddress, Family: 4, Owner: "a"})
+	require.Error(t, err)
+	assert.True(t, api.StatusErrorCheck(err, http.StatusInternalServerError))
+	assert.Contains(t, err.Error(), "Bad token")
+}
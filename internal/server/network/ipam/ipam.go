@@ -0,0 +1,114 @@
+package ipam
+
+import (
+	"context"
+	"fmt"
+)
+
+// Reservation types.
+const (
+	// TypeSubnet is used for the subnets of networks.
+	TypeSubnet = "subnet"
+
+	// TypeAddress is used for the addresses of instance NICs and network forwards.
+	TypeAddress = "address"
+)
+
+// Request represents a reservation request sent to an IPAM driver.
+type Request struct {
+	// Type of reservation (TypeSubnet or TypeAddress).
+	Type string `json:"type"`
+
+	// Address family (4 or 6).
+	Family int `json:"family"`
+
+	// Project and name of the network the reservation is made for.
+	Project string `json:"project"`
+	Network string `json:"network"`
+
+	// URL of the entity holding the reservation.
+	Owner string `json:"owner"`
+
+	// Human readable description of the reservation.
+	Description string `json:"description,omitempty"`
+
+	// Reserved address (subnet in CIDR notation for TypeSubnet).
+	// Left empty in reservation requests to have the IPAM pick one.
+	Address string `json:"address,omitempty"`
+
+	// Subnet (in CIDR notation) the address must be picked from (TypeAddress only).
+	Subnet string `json:"subnet,omitempty"`
+
+	// Prefix length of the subnet to pick (TypeSubnet only).
+	PrefixLength int `json:"prefix_length,omitempty"`
+}
+
+// Driver represents an IPAM driver.
+type Driver interface {
+	// Name returns the name of the driver.
+	Name() string
+
+	// Reserve reserves the requested address, or picks and reserves one if none is requested.
+	// An empty address is returned when the driver leaves the allocation to Incus.
+	Reserve(ctx context.Context, req Request) (string, error)
+
+	// Release releases an address reserved by the owner of the request.
+	Release(ctx context.Context, req Request) error
+}
+
+var drivers = map[string]func() driver{
+	"builtin": func() driver { return &builtin{} },
+	"http":    func() driver { return &httpDriver{} },
+}
+
+type driver interface {
+	Driver
+
+	init(serverConfig ServerConfig) error
+}
+
+// ServerConfig holds the server wide settings of the IPAM drivers.
+// The endpoint and credentials of an external IPAM are only configurable by the server administrator.
+type ServerConfig struct {
+	// Base URL of the external IPAM API used by the http driver.
+	HTTPURL string
+
+	// Bearer token used to authenticate with the external IPAM API.
+	HTTPToken string
+}
+
+// Load returns the IPAM driver selected by the "ipam.driver" key of a network config.
+func Load(config map[string]string, serverConfig ServerConfig) (Driver, error) {
+	name := config["ipam.driver"]
+	if name == "" {
+		name = "builtin"
+	}
+
+	driverFunc, ok := drivers[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown IPAM driver %q", name)
+	}
+
+	d := driverFunc()
+	err := d.init(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// IsExternal returns whether the "ipam.*" keys of a network config select an external IPAM.
+func IsExternal(config map[string]string) bool {
+	return config["ipam.driver"] != "" && config["ipam.driver"] != "builtin"
+}
+
+// ValidDriver validates an IPAM driver name.
+func ValidDriver(value string) error {
+	_, ok := drivers[value]
+	if !ok {
+		return fmt.Errorf("Unknown IPAM driver %q", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,564 @@
+// Package lbproxy implements the layer 7 proxy used by network load balancers.
+// It routes HTTP requests by host and path, optionally terminating TLS, and TLS connections by server name.
+// Each proxy runs in its own process, configured by the daemon through a control socket.
+package lbproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Frontend protocols.
+const (
+	ProtocolHTTP  = "http"
+	ProtocolHTTPS = "https"
+	ProtocolTLS   = "tls"
+)
+
+// acmeChallengePrefix is the path prefix of ACME HTTP-01 challenges.
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// dialTimeout is the timeout used when connecting to a backend.
+const dialTimeout = 10 * time.Second
+
+// Route represents a set of backends serving the requests matching a host and path.
+type Route struct {
+	// Host is either a host name, a wildcard (*.example.net) or empty to match any host.
+	Host string `json:"host"`
+
+	// Path is a path prefix (HTTP only), empty to match any path.
+	Path string `json:"path"`
+
+	// Backends is the list of backend addresses (host:port) to spread the requests across.
+	Backends []string `json:"backends"`
+}
+
+// Frontend represents a listen port of the load balancer handled by the proxy.
+type Frontend struct {
+	Protocol   string  `json:"protocol"`
+	ListenPort uint64  `json:"listen_port"`
+	Routes     []Route `json:"routes"`
+}
+
+// Config represents the proxy configuration of a load balancer.
+type Config struct {
+	// Address is the local address the proxy listens on, the traffic being redirected to it by the firewall.
+	Address net.IP `json:"address"`
+
+	Frontends []Frontend `json:"frontends"`
+
+	// Certificate and Key are the PEM encoded certificate and key used for terminating TLS on https frontends.
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+
+	// CertificatesPath is the directory holding the certificate of each of CertificateDomains (<domain>.crt and
+	// <domain>.key), used when Certificate isn't set. The files are reloaded when they change.
+	CertificatesPath   string   `json:"certificates_path"`
+	CertificateDomains []string `json:"certificate_domains"`
+
+	// ACMEChallenge is the address of the ACME HTTP-01 challenge listener, if any.
+	ACMEChallenge string `json:"acme_challenge"`
+}
+
+// Metrics represents the requests handled by a backend.
+// For TLS frontends, each connection counts as a request.
+type Metrics struct {
+	Requests uint64 `json:"requests"`
+	Failures uint64 `json:"failures"`
+}
+
+// proxy represents the running proxy of a load balancer.
+type proxy struct {
+	config      atomic.Pointer[Config]
+	certificate atomic.Pointer[tls.Certificate]
+	listeners   map[uint64]*listener
+
+	metrics   map[string]*metrics
+	metricsMu sync.Mutex
+
+	certificateFiles   map[string]*certificateFile
+	certificateFilesMu sync.Mutex
+}
+
+type listener struct {
+	protocol string
+	address  string
+	ln       net.Listener
+	server   *http.Server
+
+	routes atomic.Pointer[[]Route]
+	next   atomic.Uint64
+}
+
+type metrics struct {
+	requests atomic.Uint64
+	failures atomic.Uint64
+}
+
+// certificateFile is a certificate loaded from disk along with the modification time of its file.
+type certificateFile struct {
+	modTime     time.Time
+	certificate *tls.Certificate
+}
+
+// newProxy returns a proxy without any listener.
+func newProxy() *proxy {
+	return &proxy{
+		listeners:        map[uint64]*listener{},
+		metrics:          map[string]*metrics{},
+		certificateFiles: map[string]*certificateFile{},
+	}
+}
+
+// apply applies the configuration to the proxy.
+// Listeners of frontends whose protocol is unchanged are kept, so existing connections aren't interrupted.
+// It returns the local port used for each listen port.
+func (p *proxy) apply(config Config) (map[uint64]uint64, error) {
+	if config.Certificate != "" {
+		cert, err := tls.X509KeyPair([]byte(config.Certificate), []byte(config.Key))
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing certificate: %w", err)
+		}
+
+		p.certificate.Store(&cert)
+	} else {
+		p.certificate.Store(nil)
+	}
+
+	p.config.Store(&config)
+
+	ports := make(map[uint64]uint64, len(config.Frontends))
+	wanted := make(map[uint64]struct{}, len(config.Frontends))
+
+	for _, frontend := range config.Frontends {
+		wanted[frontend.ListenPort] = struct{}{}
+
+		address := net.JoinHostPort(config.Address.String(), "0")
+
+		l := p.listeners[frontend.ListenPort]
+		if l != nil && (l.protocol != frontend.Protocol || l.address != address) {
+			l.close()
+			l = nil
+		}
+
+		if l == nil {
+			var err error
+
+			l, err = p.listen(frontend.Protocol, address)
+			if err != nil {
+				return nil, err
+			}
+
+			p.listeners[frontend.ListenPort] = l
+		}
+
+		routes := frontend.Routes
+		l.routes.Store(&routes)
+
+		ports[frontend.ListenPort] = uint64(l.ln.Addr().(*net.TCPAddr).Port)
+	}
+
+	p.stopUnlisted(wanted)
+
+	return ports, nil
+}
+
+// close stops all the listeners of the proxy.
+func (p *proxy) close() {
+	p.stopUnlisted(nil)
+}
+
+// backendMetrics returns the metrics of each backend address.
+func (p *proxy) backendMetrics() map[string]Metrics {
+	p.metricsMu.Lock()
+	defer p.metricsMu.Unlock()
+
+	result := make(map[string]Metrics, len(p.metrics))
+	for backend, m := range p.metrics {
+		result[backend] = Metrics{
+			Requests: m.requests.Load(),
+			Failures: m.failures.Load(),
+		}
+	}
+
+	return result
+}
+
+// stopUnlisted closes the listeners whose listen port isn't in wanted.
+func (p *proxy) stopUnlisted(wanted map[uint64]struct{}) {
+	for port, l := range p.listeners {
+		_, found := wanted[port]
+		if found {
+			continue
+		}
+
+		l.close()
+		delete(p.listeners, port)
+	}
+}
+
+// listen starts a listener for the protocol on the address.
+func (p *proxy) listen(protocol string, address string) (*listener, error) {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		protocol: protocol,
+		address:  address,
+		ln:       ln,
+	}
+
+	switch protocol {
+	case ProtocolTLS:
+		go p.acceptTLS(l)
+	case ProtocolHTTP, ProtocolHTTPS:
+		l.server = &http.Server{
+			Handler:           http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { p.serveHTTP(l, w, r) }),
+			ReadHeaderTimeout: 30 * time.Second,
+			ErrorLog:          log.New(io.Discard, "", 0),
+		}
+
+		if protocol == ProtocolHTTPS {
+			ln = tls.NewListener(ln, &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: p.getCertificate,
+			})
+		}
+
+		go func() { _ = l.server.Serve(ln) }()
+	default:
+		_ = ln.Close()
+		return nil, errors.New("Unsupported protocol " + strconv.Quote(protocol))
+	}
+
+	return l, nil
+}
+
+// close stops the listener.
+func (l *listener) close() {
+	if l.server != nil {
+		_ = l.server.Close()
+		return
+	}
+
+	_ = l.ln.Close()
+}
+
+// pick returns the route and backend to use for the host and path.
+func (l *listener) pick(host string, path string) (*Route, string) {
+	route := matchRoute(*l.routes.Load(), host, path)
+	if route == nil || len(route.Backends) == 0 {
+		return route, ""
+	}
+
+	return route, route.Backends[l.next.Add(1)%uint64(len(route.Backends))]
+}
+
+// getCertificate returns the certificate for TLS termination.
+func (p *proxy) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := p.certificate.Load()
+	if cert != nil {
+		return cert, nil
+	}
+
+	config := p.config.Load()
+	domain := strings.ToLower(hello.ServerName)
+	if config.CertificatesPath == "" || !slices.Contains(config.CertificateDomains, domain) {
+		return nil, fmt.Errorf("No certificate for %q", domain)
+	}
+
+	return p.loadCertificate(filepath.Join(config.CertificatesPath, domain))
+}
+
+// loadCertificate returns the certificate stored at path (.crt and .key), reloading it when its file changes.
+func (p *proxy) loadCertificate(path string) (*tls.Certificate, error) {
+	info, err := os.Stat(path + ".crt")
+	if err != nil {
+		return nil, fmt.Errorf("Certificate %q isn't available: %w", filepath.Base(path), err)
+	}
+
+	p.certificateFilesMu.Lock()
+	defer p.certificateFilesMu.Unlock()
+
+	file := p.certificateFiles[path]
+	if file != nil && file.modTime.Equal(info.ModTime()) {
+		return file.certificate, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(path+".crt", path+".key")
+	if err != nil {
+		return nil, err
+	}
+
+	p.certificateFiles[path] = &certificateFile{modTime: info.ModTime(), certificate: &cert}
+
+	return &cert, nil
+}
+
+// backendMetric returns the metrics of a backend address.
+func (p *proxy) backendMetric(backend string) *metrics {
+	p.metricsMu.Lock()
+	defer p.metricsMu.Unlock()
+
+	m := p.metrics[backend]
+	if m == nil {
+		m = &metrics{}
+		p.metrics[backend] = m
+	}
+
+	return m
+}
+
+// serveHTTP proxies a HTTP request to the backend of the matching route.
+func (p *proxy) serveHTTP(l *listener, w http.ResponseWriter, r *http.Request) {
+	config := p.config.Load()
+
+	// Let the ACME challenges through so certificates can be issued for the load balancer.
+	if l.protocol == ProtocolHTTP && config.ACMEChallenge != "" && strings.HasPrefix(r.URL.Path, acmeChallengePrefix) {
+		p.proxyHTTP(w, r, config.ACMEChallenge, nil)
+		return
+	}
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+
+	route, backend := l.pick(host, r.URL.Path)
+	if route == nil {
+		http.Error(w, "No matching route", http.StatusNotFound)
+		return
+	}
+
+	if backend == "" {
+		http.Error(w, "No backend available", http.StatusServiceUnavailable)
+		return
+	}
+
+	p.proxyHTTP(w, r, backend, p.backendMetric(backend))
+}
+
+// proxyHTTP forwards the request to the backend, keeping the original host header.
+func (p *proxy) proxyHTTP(w http.ResponseWriter, r *http.Request, backend string, m *metrics) {
+	if m != nil {
+		m.requests.Add(1)
+	}
+
+	rp := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(&url.URL{Scheme: "http", Host: backend})
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			if m != nil && resp.StatusCode >= http.StatusInternalServerError {
+				m.failures.Add(1)
+			}
+
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if m != nil {
+				m.failures.Add(1)
+			}
+
+			logger.Debug("Failed proxying load balancer request", logger.Ctx{"backend": backend, "err": err})
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	rp.ServeHTTP(w, r)
+}
+
+// acceptTLS accepts the connections of a TLS frontend.
+func (p *proxy) acceptTLS(l *listener) {
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			continue
+		}
+
+		go p.serveTLS(l, conn)
+	}
+}
+
+// serveTLS forwards a TLS connection to the backend of the route matching its server name.
+func (p *proxy) serveTLS(l *listener, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(dialTimeout))
+
+	serverName, hello, err := peekServerName(conn)
+	if err != nil {
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	_, backend := l.pick(serverName, "")
+	if backend == "" {
+		return
+	}
+
+	m := p.backendMetric(backend)
+	m.requests.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	backendConn, err := dialer.DialContext(ctx, "tcp", backend)
+	if err != nil {
+		m.failures.Add(1)
+		logger.Debug("Failed connecting to load balancer backend", logger.Ctx{"backend": backend, "err": err})
+		return
+	}
+
+	defer func() { _ = backendConn.Close() }()
+
+	_, err = backendConn.Write(hello)
+	if err != nil {
+		m.failures.Add(1)
+		return
+	}
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(backendConn, conn)
+		closeWrite(backendConn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(conn, backendConn)
+		closeWrite(conn)
+		done <- struct{}{}
+	}()
+
+	<-done
+	<-done
+}
+
+// closeWrite shuts down the writing side of a TCP connection.
+func closeWrite(conn net.Conn) {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if ok {
+		_ = tcpConn.CloseWrite()
+	}
+}
+
+// readOnlyConn is a net.Conn only allowing reads, used to parse the TLS client hello.
+type readOnlyConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c readOnlyConn) Read(p []byte) (int, error)  { return c.reader.Read(p) }
+func (c readOnlyConn) Write(p []byte) (int, error) { return 0, io.ErrClosedPipe }
+
+// peekServerName reads the TLS client hello from the connection.
+// It returns the requested server name along with the bytes read, to be replayed to the backend.
+func peekServerName(conn net.Conn) (string, []byte, error) {
+	var buf bytes.Buffer
+	var hello *tls.ClientHelloInfo
+
+	errDone := errors.New("Client hello read")
+
+	err := tls.Server(readOnlyConn{Conn: conn, reader: io.TeeReader(conn, &buf)}, &tls.Config{
+		GetConfigForClient: func(h *tls.ClientHelloInfo) (*tls.Config, error) {
+			hello = h
+			return nil, errDone
+		},
+	}).Handshake()
+	if hello == nil {
+		return "", nil, err
+	}
+
+	return strings.ToLower(hello.ServerName), buf.Bytes(), nil
+}
+
+// matchRoute returns the most specific route matching the host and path.
+// Exact host matches are preferred over wildcards, which are preferred over routes without host.
+// Among those, the longest matching path prefix wins.
+func matchRoute(routes []Route, host string, path string) *Route {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	var best *Route
+	bestHostScore := -1
+	bestPathLen := -1
+
+	for i := range routes {
+		route := &routes[i]
+
+		hostScore := matchHost(route.Host, host)
+		if hostScore < 0 || !matchPath(route.Path, path) {
+			continue
+		}
+
+		if hostScore > bestHostScore || (hostScore == bestHostScore && len(route.Path) > bestPathLen) {
+			best = route
+			bestHostScore = hostScore
+			bestPathLen = len(route.Path)
+		}
+	}
+
+	return best
+}
+
+// matchHost returns how specific the route host matches the host (-1 for no match).
+func matchHost(routeHost string, host string) int {
+	routeHost = strings.ToLower(routeHost)
+
+	switch {
+	case routeHost == "":
+		return 0
+	case routeHost == host:
+		return 2
+	case strings.HasPrefix(routeHost, "*."):
+		suffix := routeHost[1:]
+		if strings.HasSuffix(host, suffix) && !strings.Contains(strings.TrimSuffix(host, suffix), ".") && len(host) > len(suffix) {
+			return 1
+		}
+	}
+
+	return -1
+}
+
+// matchPath returns whether the path falls under the route path prefix.
+func matchPath(routePath string, path string) bool {
+	if routePath == "" || routePath == "/" {
+		return true
+	}
+
+	if !strings.HasPrefix(path, routePath) {
+		return false
+	}
+
+	return len(path) == len(routePath) || strings.HasSuffix(routePath, "/") || path[len(routePath)] == '/'
+}
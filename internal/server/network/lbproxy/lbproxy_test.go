@@ -0,0 +1,262 @@
+package lbproxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	localtls "github.com/lxc/incus/v6/shared/tls"
+)
+
+func TestMatchRoute(t *testing.T) {
+	routes := []Route{
+		{Host: "", Path: ""},
+		{Host: "*.example.net", Path: ""},
+		{Host: "www.example.net", Path: ""},
+		{Host: "www.example.net", Path: "/api"},
+		{Host: "", Path: "/static/"},
+	}
+
+	tests := []struct {
+		host string
+		path string
+		want int
+	}{
+		{host: "www.example.net", path: "/", want: 2},
+		{host: "WWW.example.net.", path: "/", want: 2},
+		{host: "www.example.net", path: "/api", want: 3},
+		{host: "www.example.net", path: "/api/v1", want: 3},
+		{host: "www.example.net", path: "/apiv1", want: 2},
+		{host: "foo.example.net", path: "/api", want: 1},
+		{host: "a.foo.example.net", path: "/", want: 0},
+		{host: "example.net", path: "/", want: 0},
+		{host: "other.org", path: "/static/file", want: 4},
+		{host: "foo.example.net", path: "/static/file", want: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s%s", test.host, test.path), func(t *testing.T) {
+			route := matchRoute(routes, test.host, test.path)
+			require.NotNil(t, route)
+			assert.Same(t, &routes[test.want], route)
+		})
+	}
+
+	assert.Nil(t, matchRoute([]Route{{Host: "www.example.net"}}, "other.org", "/"))
+}
+
+// backend returns a HTTP backend replying with its name and the request host.
+func backend(t *testing.T, name string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s %s", name, r.Host)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestProxyHTTP(t *testing.T) {
+	web1 := backend(t, "web1")
+	web2 := backend(t, "web2")
+	api := backend(t, "api")
+
+	config := Config{
+		Address: net.ParseIP("127.0.0.1"),
+		Frontends: []Frontend{{
+			Protocol:   ProtocolHTTP,
+			ListenPort: 80,
+			Routes: []Route{
+				{Host: "www.example.net", Backends: []string{web1, web2}},
+				{Host: "www.example.net", Path: "/api", Backends: []string{api}},
+				{Host: "down.example.net"},
+			},
+		}},
+	}
+
+	p := newProxy()
+	defer p.close()
+
+	ports, err := p.apply(config)
+	require.NoError(t, err)
+
+	get := func(host string, path string) (int, string) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("http://127.0.0.1:%d%s", ports[80], path), nil)
+		require.NoError(t, err)
+		req.Host = host
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	seen := map[string]bool{}
+	for range 4 {
+		status, body := get("www.example.net", "/")
+		assert.Equal(t, http.StatusOK, status)
+		seen[strings.Fields(body)[0]] = true
+	}
+
+	assert.Equal(t, map[string]bool{"web1": true, "web2": true}, seen)
+
+	status, body := get("www.example.net", "/api/items")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "api www.example.net", body)
+
+	status, _ = get("down.example.net", "/")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	status, _ = get("other.org", "/")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	metrics := p.backendMetrics()
+	assert.Equal(t, uint64(4), metrics[web1].Requests+metrics[web2].Requests)
+	assert.Equal(t, Metrics{Requests: 1}, metrics[api])
+
+	// Updating the routes keeps the listener.
+	config.Frontends[0].Routes = []Route{{Backends: []string{api}}}
+	newPorts, err := p.apply(config)
+	require.NoError(t, err)
+	assert.Equal(t, ports, newPorts)
+
+	status, body = get("other.org", "/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "api other.org", body)
+}
+
+func TestProxyTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "secure")
+	}))
+
+	defer server.Close()
+
+	backendAddress := strings.TrimPrefix(server.URL, "https://")
+
+	p := newProxy()
+	defer p.close()
+
+	ports, err := p.apply(Config{
+		Address: net.ParseIP("127.0.0.1"),
+		Frontends: []Frontend{{
+			Protocol:   ProtocolTLS,
+			ListenPort: 443,
+			Routes:     []Route{{Host: "secure.example.net", Backends: []string{backendAddress}}},
+		}},
+	})
+	require.NoError(t, err)
+
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.ServerName = "secure.example.net"
+	transport.TLSClientConfig.InsecureSkipVerify = true
+
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(fmt.Sprintf("https://127.0.0.1:%d/", ports[443]))
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "secure", string(body))
+	assert.Equal(t, Metrics{Requests: 1}, p.backendMetrics()[backendAddress])
+
+	// Unknown server names are rejected.
+	conn, err := tls.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[443]), &tls.Config{ServerName: "other.org", InsecureSkipVerify: true})
+	if err == nil {
+		_ = conn.Close()
+	}
+
+	assert.Error(t, err)
+}
+
+func TestServe(t *testing.T) {
+	web := backend(t, "web")
+
+	path := t.TempDir()
+	socketPath := filepath.Join(path, "proxy.socket")
+
+	go func() { _ = Serve(socketPath) }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			return false
+		}
+
+		_ = conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ports, err := applyConfig(socketPath, Config{
+		Address: net.ParseIP("127.0.0.1"),
+		Frontends: []Frontend{{
+			Protocol:   ProtocolHTTP,
+			ListenPort: 80,
+			Routes:     []Route{{Backends: []string{web}}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Contains(t, ports, uint64(80))
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", ports[80]))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, map[string]Metrics{web: {Requests: 1}}, BackendMetrics(path))
+
+	// Invalid configurations are reported back.
+	_, err = applyConfig(socketPath, Config{Address: net.ParseIP("127.0.0.1"), Certificate: "invalid"})
+	assert.ErrorContains(t, err, "Failed parsing certificate")
+}
+
+func TestProxyCertificates(t *testing.T) {
+	cert, key, err := localtls.GenerateMemCert(false, false)
+	require.NoError(t, err)
+
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "www.example.net.crt"), cert, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "www.example.net.key"), key, 0o600))
+
+	p := newProxy()
+	defer p.close()
+
+	_, err = p.apply(Config{
+		Address:            net.ParseIP("127.0.0.1"),
+		CertificatesPath:   path,
+		CertificateDomains: []string{"www.example.net"},
+	})
+	require.NoError(t, err)
+
+	loaded, err := p.getCertificate(&tls.ClientHelloInfo{ServerName: "WWW.example.net"})
+	require.NoError(t, err)
+	assert.NotNil(t, loaded)
+
+	// Only the listed domains are loaded.
+	_, err = p.getCertificate(&tls.ClientHelloInfo{ServerName: "other.org"})
+	assert.Error(t, err)
+
+	// The certificate set in the configuration takes precedence.
+	_, err = p.apply(Config{Address: net.ParseIP("127.0.0.1"), Certificate: string(cert), Key: string(key)})
+	require.NoError(t, err)
+
+	loaded, err = p.getCertificate(&tls.ClientHelloInfo{ServerName: "other.org"})
+	require.NoError(t, err)
+	assert.NotNil(t, loaded)
+}
@@ -0,0 +1,236 @@
+package lbproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/subprocess"
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+// processesMu serializes the management of the proxy processes.
+var processesMu sync.Mutex
+
+// Start starts (or updates) the proxy process whose files (control socket, pid and log) are kept in path.
+// A running process is reconfigured in place, so existing connections aren't interrupted.
+// It returns the local port used for each listen port.
+func Start(execPath string, path string, config Config) (map[uint64]uint64, error) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	socketPath := filepath.Join(path, "proxy.socket")
+
+	conn, err := net.Dial("unix", socketPath)
+	if err == nil {
+		_ = conn.Close()
+	} else {
+		err = startProcess(execPath, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return applyConfig(socketPath, config)
+}
+
+// Stop stops the proxy process whose files are kept in path (if running).
+func Stop(path string) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	stopProcess(path)
+}
+
+// BackendMetrics returns the metrics of each backend address of the proxy process whose files are kept in path.
+func BackendMetrics(path string) map[string]Metrics {
+	result := map[string]Metrics{}
+
+	resp, err := controlClient(filepath.Join(path, "proxy.socket")).Get("http://lbproxy/metrics")
+	if err != nil {
+		return result
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return result
+	}
+
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	return result
+}
+
+// startProcess spawns a new proxy process, replacing any leftover one.
+func startProcess(execPath string, path string) error {
+	stopProcess(path)
+
+	err := os.MkdirAll(path, 0o700)
+	if err != nil {
+		return err
+	}
+
+	socketPath := filepath.Join(path, "proxy.socket")
+	logPath := filepath.Join(path, "proxy.log")
+
+	p, err := subprocess.NewProcess(execPath, []string{"forklbproxy", socketPath}, logPath, logPath)
+	if err != nil {
+		return fmt.Errorf("Failed creating proxy process: %w", err)
+	}
+
+	err = p.Start(context.Background())
+	if err != nil {
+		return fmt.Errorf("Failed starting proxy process: %w", err)
+	}
+
+	err = p.Save(filepath.Join(path, "proxy.pid"))
+	if err != nil {
+		_ = p.Stop()
+		return fmt.Errorf("Failed saving proxy process details: %w", err)
+	}
+
+	// Wait for the control socket to be ready.
+	for range 50 {
+		conn, err := net.Dial("unix", socketPath)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	stopProcess(path)
+
+	return fmt.Errorf("Proxy process didn't start, please look in %s", logPath)
+}
+
+// stopProcess stops the proxy process whose files are kept in path and removes its control socket and pid file.
+func stopProcess(path string) {
+	pidPath := filepath.Join(path, "proxy.pid")
+
+	if util.PathExists(pidPath) {
+		p, err := subprocess.ImportProcess(pidPath)
+		if err == nil {
+			_ = p.Stop()
+		}
+
+		_ = os.Remove(pidPath)
+	}
+
+	_ = os.Remove(filepath.Join(path, "proxy.socket"))
+}
+
+// controlClient returns a HTTP client connecting to the control socket of a proxy process.
+func controlClient(socketPath string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: 10 * time.Second,
+	}
+}
+
+// applyConfig sends the configuration to the proxy process listening on the control socket.
+func applyConfig(socketPath string, config Config) (map[uint64]uint64, error) {
+	body, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, "http://lbproxy/config", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := controlClient(socketPath).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed configuring proxy process: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(strings.TrimSpace(string(msg)))
+	}
+
+	ports := map[uint64]uint64{}
+
+	err = json.NewDecoder(resp.Body).Decode(&ports)
+	if err != nil {
+		return nil, err
+	}
+
+	return ports, nil
+}
+
+// Serve runs a proxy configured through the control socket at socketPath.
+// It is run by the proxy process and only returns on failure.
+func Serve(socketPath string) error {
+	_ = os.Remove(socketPath)
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(socketPath, 0o600)
+	if err != nil {
+		_ = ln.Close()
+		return err
+	}
+
+	p := newProxy()
+	defer p.close()
+
+	// Configuration changes are applied one at a time.
+	var configMu sync.Mutex
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("PUT /config", func(w http.ResponseWriter, r *http.Request) {
+		var config Config
+
+		err := json.NewDecoder(r.Body).Decode(&config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		configMu.Lock()
+		ports, err := p.apply(config)
+		configMu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(ports)
+	})
+
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(p.backendMetrics())
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server.Serve(ln)
+}
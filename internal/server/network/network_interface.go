@@ -70,8 +70,12 @@ type Network interface {
 	LoadBalancerDelete(listenAddress string, clientType request.ClientType) error
 
 	// Peerings.
-	PeerCreate(forward api.NetworkPeersPost) error
-	PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error
-	PeerDelete(peerName string) error
+	PeerCreate(forward api.NetworkPeersPost, clientType request.ClientType) error
+	PeerUpdate(peerName string, newPeer api.NetworkPeerPut, clientType request.ClientType) error
+	PeerDelete(peerName string, clientType request.ClientType) error
 	PeerUsedBy(peerName string) ([]string, error)
+
+	// Introspection.
+	InstanceNICPath(opts *InstanceNICPathOpts) ([]api.InstanceNetworkPathHop, error)
+	InstanceNICTrace(opts *InstanceNICPathOpts, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error)
 }
@@ -29,15 +29,15 @@ var (
 	unavailableNetworksMu = sync.Mutex{}
 )
 
-// LoadByType loads a network by driver type.
-func LoadByType(driverType string) (Type, error) {
+// LoadByType loads a network by driver type for the network about to be created with the given project and name.
+func LoadByType(s *state.State, driverType string, projectName string, name string) (Type, error) {
 	driverFunc, ok := drivers[driverType]
 	if !ok {
 		return nil, ErrUnknownDriver
 	}
 
 	n := driverFunc()
-	err := n.init(nil, -1, "", &api.Network{Type: driverType}, nil)
+	err := n.init(s, -1, projectName, &api.Network{Name: name, Type: driverType}, nil)
 	if err != nil {
 		return nil, err
 	}
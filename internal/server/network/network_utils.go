@@ -12,8 +12,10 @@ import (
 	"math/big"
 	"math/rand"
 	"net"
+	"net/http"
 	"net/netip"
 	"os"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -27,6 +29,7 @@ import (
 	"github.com/lxc/incus/v6/internal/server/device/nictype"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq"
 	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
+	firewallDrivers "github.com/lxc/incus/v6/internal/server/firewall/drivers"
 	"github.com/lxc/incus/v6/internal/server/instance"
 	"github.com/lxc/incus/v6/internal/server/instance/instancetype"
 	"github.com/lxc/incus/v6/internal/server/ip"
@@ -955,7 +958,7 @@ func usesIPv6Firewall(netConfig map[string]string) bool {
 		return true
 	}
 
-	if util.IsTrue(netConfig["ipv6.nat"]) {
+	if util.IsTrue(netConfig["ipv6.nat"]) || util.IsTrue(netConfig["ipv6.nat64"]) {
 		return true
 	}
 
@@ -1564,3 +1567,115 @@ func ipInRanges(ipAddr net.IP, ipRanges []iprange.Range) bool {
 
 	return false
 }
+
+// parseTraceFlow validates a flow trace request and returns its destination and source (if any) addresses.
+func parseTraceFlow(req api.InstanceNetworkTracePost) (net.IP, net.IP, error) {
+	dst := net.ParseIP(req.Destination)
+	if dst == nil {
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Invalid destination address %q", req.Destination)
+	}
+
+	var src net.IP
+	if req.Source != "" {
+		src = net.ParseIP(req.Source)
+		if src == nil {
+			return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Invalid source address %q", req.Source)
+		}
+
+		if (src.To4() == nil) != (dst.To4() == nil) {
+			return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Source and destination addresses must be of the same family")
+		}
+	}
+
+	if !slices.Contains([]string{"", "tcp", "udp", "icmp"}, req.Protocol) {
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Unsupported protocol %q", req.Protocol)
+	}
+
+	if req.DestinationPort < 0 || req.DestinationPort > 65535 {
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Invalid destination port %d", req.DestinationPort)
+	}
+
+	if req.DestinationPort > 0 && !slices.Contains([]string{"tcp", "udp"}, req.Protocol) {
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "A destination port requires the tcp or udp protocol")
+	}
+
+	return dst, src, nil
+}
+
+// TraceFirewallFlow traces a flow coming from the host-side interface of an instance NIC through the firewall.
+// The trace relies on live traffic and waits for a matching packet up to the timeout of the request.
+func TraceFirewallFlow(s *state.State, hostName string, req api.InstanceNetworkTracePost) (*api.InstanceNetworkTrace, error) {
+	dst, src, err := parseTraceFlow(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Timeout == 0 {
+		req.Timeout = 10
+	}
+
+	if req.Timeout < 0 || req.Timeout > 60 {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Trace timeout must be between 1 and 60 seconds")
+	}
+
+	flow := firewallDrivers.TraceFlow{
+		HostName:        hostName,
+		Protocol:        req.Protocol,
+		Source:          src,
+		Destination:     dst,
+		DestinationPort: uint64(req.DestinationPort),
+	}
+
+	output, err := s.Firewall.NetworkTraceFlow(flow, time.Duration(req.Timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := &api.InstanceNetworkTrace{
+		Verdict: firewallTraceVerdict(output),
+		Output:  output,
+	}
+
+	return trace, nil
+}
+
+// nftTraceVerdict matches the verdict of a rule or the policy of a chain in a line of nftables trace output.
+var nftTraceVerdict = regexp.MustCompile(`(?:verdict|policy) (\w+)\)?$`)
+
+// firewallTraceVerdict returns the verdict of a flow from its nftables trace.
+// A packet accepted in one hook goes on to the next ones, so the verdict comes from the last rule or chain
+// policy which accepted or dropped the packet.
+func firewallTraceVerdict(output []string) string {
+	for _, line := range slices.Backward(output) {
+		match := nftTraceVerdict.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		switch match[1] {
+		case "accept":
+			return "allow"
+		case "drop", "reject":
+			return "drop"
+		}
+	}
+
+	return "unknown"
+}
+
+// LoadBalancerHideSecrets removes the private key of the TLS certificate from a load balancer returned through the API.
+func LoadBalancerHideSecrets(lb *api.NetworkLoadBalancer) {
+	delete(lb.Config, "tls.key")
+}
+
+// loadBalancerKeepSecrets fills the private key of the TLS certificate with its current value when left out of
+// the update, so that a load balancer retrieved through the API can be sent back as is.
+func loadBalancerKeepSecrets(curConfig map[string]string, req *api.NetworkLoadBalancerPut) {
+	if req.Config["tls.certificate"] == "" || req.Config["tls.key"] != "" {
+		return
+	}
+
+	if curConfig["tls.key"] != "" {
+		req.Config["tls.key"] = curConfig["tls.key"]
+	}
+}
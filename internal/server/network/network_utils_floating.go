@@ -0,0 +1,576 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/internal/server/cluster/request"
+	"github.com/lxc/incus/v6/internal/server/db"
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+	"github.com/lxc/incus/v6/internal/server/locking"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/shared/api"
+	"github.com/lxc/incus/v6/shared/revert"
+	"github.com/lxc/incus/v6/shared/util"
+)
+
+// Floating IPs are backed by network forwards carrying these configuration keys.
+const (
+	floatingIPKey         = "volatile.floating_ip"
+	floatingIPInstanceKey = "volatile.floating_ip.instance"
+	floatingIPDeviceKey   = "volatile.floating_ip.device"
+	floatingIPProjectKey  = "volatile.floating_ip.project"
+)
+
+// IsFloatingIPForward returns whether a network forward backs a floating IP.
+func IsFloatingIPForward(forward *api.NetworkForward) bool {
+	return util.IsTrue(forward.Config[floatingIPKey])
+}
+
+// FloatingIPPool returns the project and name of the network the floating IPs of a network are allocated from.
+// Bridge networks allocate from their own ranges while OVN networks allocate from their uplink's.
+func FloatingIPPool(n Network) (string, string, error) {
+	switch n.Type() {
+	case "bridge":
+		return n.Project(), n.Name(), nil
+	case "ovn":
+		uplink := n.Config()["network"]
+		if uplink == "" || uplink == "none" {
+			return "", "", fmt.Errorf("Network %q doesn't have an uplink to allocate floating IPs from", n.Name())
+		}
+
+		return api.ProjectDefaultName, uplink, nil
+	}
+
+	return "", "", fmt.Errorf("Network type %q doesn't support floating IPs", n.Type())
+}
+
+// FloatingIPPoolLock locks the floating IP pool of a network.
+// It must be held from picking an address until the forward using it exists, so that an address can't be
+// handed out twice by concurrent requests or by networks sharing the pool.
+func FloatingIPPoolLock(n Network) (locking.UnlockFunc, error) {
+	poolProject, poolName, err := FloatingIPPool(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return locking.Lock(context.TODO(), fmt.Sprintf("network.floating_ip.%s.%s", poolProject, poolName))
+}
+
+// FloatingIPFromForward converts a network forward backing a floating IP into a floating IP.
+func FloatingIPFromForward(n Network, forward *api.NetworkForward) *api.NetworkFloatingIP {
+	_, poolName, _ := FloatingIPPool(n)
+
+	return &api.NetworkFloatingIP{
+		NetworkFloatingIPPut: api.NetworkFloatingIPPut{
+			Description: forward.Description,
+			Instance:    forward.Config[floatingIPInstanceKey],
+			Device:      forward.Config[floatingIPDeviceKey],
+		},
+		Address:       forward.ListenAddress,
+		Network:       n.Name(),
+		Pool:          poolName,
+		TargetAddress: forward.Config["target_address"],
+		Location:      forward.Location,
+	}
+}
+
+// GetFloatingIPs returns the floating IPs held by a network.
+func GetFloatingIPs(s *state.State, n Network) ([]api.NetworkFloatingIP, error) {
+	forwards, err := floatingIPForwards(s, n, func(forward *api.NetworkForward) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+
+	floatingIPs := make([]api.NetworkFloatingIP, 0, len(forwards))
+	for _, forward := range forwards {
+		floatingIPs = append(floatingIPs, *FloatingIPFromForward(n, &forward))
+	}
+
+	return floatingIPs, nil
+}
+
+// LoadFloatingIP returns a floating IP of a project along with the network currently holding it.
+func LoadFloatingIP(s *state.State, projectName string, address string) (Network, *api.NetworkFloatingIP, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, nil, api.StatusErrorf(http.StatusBadRequest, "Invalid floating IP address %q", address)
+	}
+
+	address = ip.String()
+
+	var networkName string
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		networks, err := tx.GetCreatedNetworksByProject(ctx, projectName)
+		if err != nil {
+			return err
+		}
+
+		for networkID, netInfo := range networks {
+			if !slices.Contains([]string{"bridge", "ovn"}, netInfo.Type) {
+				continue
+			}
+
+			dbRecords, err := dbCluster.GetNetworkForwards(ctx, tx.Tx(), dbCluster.NetworkForwardFilter{NetworkID: &networkID, ListenAddress: &address})
+			if err != nil {
+				return err
+			}
+
+			for _, dbRecord := range dbRecords {
+				forward, err := dbRecord.ToAPI(ctx, tx.Tx())
+				if err != nil {
+					return err
+				}
+
+				if IsFloatingIPForward(forward) {
+					networkName = netInfo.Name
+					return nil
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed loading floating IP: %w", err)
+	}
+
+	if networkName == "" {
+		return nil, nil, api.StatusErrorf(http.StatusNotFound, "Floating IP not found")
+	}
+
+	n, err := LoadByName(s, projectName, networkName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed loading network %q: %w", networkName, err)
+	}
+
+	floatingIPs, err := GetFloatingIPs(s, n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, floatingIP := range floatingIPs {
+		if floatingIP.Address == address {
+			return n, &floatingIP, nil
+		}
+	}
+
+	return nil, nil, api.StatusErrorf(http.StatusNotFound, "Floating IP not found")
+}
+
+// floatingIPPoolAddresses returns the listen addresses of the forwards and load balancers of all the networks
+// sharing a floating IP pool.
+func floatingIPPoolAddresses(s *state.State, poolProject string, poolName string) ([]net.IP, error) {
+	addresses := []net.IP{}
+
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		projectNetworks, err := tx.GetCreatedNetworks(ctx)
+		if err != nil {
+			return err
+		}
+
+		for projectName, networks := range projectNetworks {
+			for networkID, netInfo := range networks {
+				isPool := projectName == poolProject && netInfo.Name == poolName
+				usesPool := poolProject == api.ProjectDefaultName && netInfo.Type == "ovn" && netInfo.Config["network"] == poolName
+				if !isPool && !usesPool {
+					continue
+				}
+
+				forwards, err := dbCluster.GetNetworkForwards(ctx, tx.Tx(), dbCluster.NetworkForwardFilter{NetworkID: &networkID})
+				if err != nil {
+					return err
+				}
+
+				for _, forward := range forwards {
+					addresses = append(addresses, net.ParseIP(forward.ListenAddress))
+				}
+
+				loadBalancers, err := dbCluster.GetNetworkLoadBalancers(ctx, tx.Tx(), dbCluster.NetworkLoadBalancerFilter{NetworkID: &networkID})
+				if err != nil {
+					return err
+				}
+
+				for _, loadBalancer := range loadBalancers {
+					addresses = append(addresses, net.ParseIP(loadBalancer.ListenAddress))
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading addresses in use: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// AllocateFloatingIP returns a free address from the floating IP ranges of the pool of a network.
+// If an address is provided, it's checked to be within the ranges and free instead.
+// The pool must be locked with FloatingIPPoolLock until the address is used.
+func AllocateFloatingIP(s *state.State, n Network, address string) (string, error) {
+	poolProject, poolName, err := FloatingIPPool(n)
+	if err != nil {
+		return "", err
+	}
+
+	pool, err := LoadByName(s, poolProject, poolName)
+	if err != nil {
+		return "", fmt.Errorf("Failed loading network %q: %w", poolName, err)
+	}
+
+	ipRanges, err := floatingIPRanges(poolName, pool.Config())
+	if err != nil {
+		return "", err
+	}
+
+	usedAddresses, err := floatingIPPoolAddresses(s, poolProject, poolName)
+	if err != nil {
+		return "", err
+	}
+
+	return floatingIPAllocate(poolName, ipRanges, usedAddresses, address)
+}
+
+// floatingIPRanges returns the floating IP ranges of a pool network, IPv4 ranges first.
+func floatingIPRanges(poolName string, poolConfig map[string]string) ([]*iprange.Range, error) {
+	ipRanges := []*iprange.Range{}
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		key := keyPrefix + ".floating.ranges"
+		if poolConfig[key] == "" {
+			continue
+		}
+
+		keyRanges, err := parseIPRanges(poolConfig[key])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing %s of network %q: %w", key, poolName, err)
+		}
+
+		ipRanges = append(ipRanges, keyRanges...)
+	}
+
+	if len(ipRanges) == 0 {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Network %q doesn't have any floating IP ranges", poolName)
+	}
+
+	return ipRanges, nil
+}
+
+// floatingIPRangesValidate checks that the floating IP ranges of a network don't overlap with its OVN ranges nor
+// with the other ranges provided, which are keyed by the configuration key they come from.
+func floatingIPRangesValidate(config map[string]string, keyPrefix string, otherRanges map[string][]*iprange.Range) error {
+	key := keyPrefix + ".floating.ranges"
+	if config[key] == "" {
+		return nil
+	}
+
+	floatingRanges, err := parseIPRanges(config[key])
+	if err != nil {
+		return fmt.Errorf("Failed parsing %s: %w", key, err)
+	}
+
+	ovnKey := keyPrefix + ".ovn.ranges"
+	if config[ovnKey] != "" {
+		ovnRanges, err := parseIPRanges(config[ovnKey])
+		if err != nil {
+			return fmt.Errorf("Failed parsing %s: %w", ovnKey, err)
+		}
+
+		otherRanges = maps.Clone(otherRanges)
+		if otherRanges == nil {
+			otherRanges = map[string][]*iprange.Range{}
+		}
+
+		otherRanges[ovnKey] = ovnRanges
+	}
+
+	// Compare the addresses in their 16 bytes form as ranges may come in either form.
+	to16 := func(r *iprange.Range) *iprange.Range {
+		return &iprange.Range{Start: r.Start.To16(), End: r.End.To16()}
+	}
+
+	for _, otherKey := range slices.Sorted(maps.Keys(otherRanges)) {
+		for _, floatingRange := range floatingRanges {
+			for _, otherRange := range otherRanges[otherKey] {
+				r1, r2 := to16(floatingRange), to16(otherRange)
+				if IPRangesOverlap(r1, r2) || IPRangesOverlap(r2, r1) {
+					return fmt.Errorf("The range specified in %q (%q) cannot overlap with %q", key, floatingRange, otherKey)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// floatingIPAllocate returns the requested address if it's within the ranges and free.
+// Otherwise, when no address is requested, the first free address of the ranges is returned.
+func floatingIPAllocate(poolName string, ipRanges []*iprange.Range, usedAddresses []net.IP, address string) (string, error) {
+	isUsed := func(ip net.IP) bool {
+		return slices.ContainsFunc(usedAddresses, ip.Equal)
+	}
+
+	// Check the requested address.
+	if address != "" {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return "", api.StatusErrorf(http.StatusBadRequest, "Invalid floating IP address %q", address)
+		}
+
+		if !slices.ContainsFunc(ipRanges, func(ipRange *iprange.Range) bool { return ipRange.ContainsIP(ip) }) {
+			return "", api.StatusErrorf(http.StatusBadRequest, "Address %q isn't within the floating IP ranges of network %q", address, poolName)
+		}
+
+		if isUsed(ip) {
+			return "", api.StatusErrorf(http.StatusConflict, "Address %q is already in use", address)
+		}
+
+		return ip.String(), nil
+	}
+
+	// Allocate the first free address, in the order of the ranges.
+	for _, ipRange := range ipRanges {
+		start, ok := netip.AddrFromSlice(ipRange.Start)
+		if !ok {
+			continue
+		}
+
+		end, ok := netip.AddrFromSlice(ipRange.End)
+		if !ok {
+			continue
+		}
+
+		start = start.Unmap()
+		end = end.Unmap()
+
+		for addr := start; addr.IsValid() && addr.Compare(end) <= 0; addr = addr.Next() {
+			ip := net.IP(addr.AsSlice())
+			if !isUsed(ip) {
+				return ip.String(), nil
+			}
+		}
+	}
+
+	return "", api.StatusErrorf(http.StatusServiceUnavailable, "No free address in the floating IP ranges of network %q", poolName)
+}
+
+// FloatingIPTargetAddress returns the address of an instance NIC connected to a network that a floating IP
+// forwards its traffic to. Static NIC addresses are used first, followed by the dynamic addresses of OVN NICs.
+func FloatingIPTargetAddress(n Network, instanceUUID string, deviceName string, nicConfig deviceConfig.Device, floatingIP string) (string, error) {
+	ip := net.ParseIP(floatingIP)
+	if ip == nil {
+		return "", fmt.Errorf("Invalid floating IP address %q", floatingIP)
+	}
+
+	isIPv4 := ip.To4() != nil
+
+	keyPrefix := "ipv6"
+	if isIPv4 {
+		keyPrefix = "ipv4"
+	}
+
+	targetIP := net.ParseIP(nicConfig[keyPrefix+".address"])
+	if targetIP != nil {
+		return targetIP.String(), nil
+	}
+
+	ovnNet, ok := n.(*ovn)
+	if ok {
+		nicIPs, err := ovnNet.InstanceDevicePortIPs(instanceUUID, deviceName)
+		if err != nil {
+			return "", err
+		}
+
+		for _, nicIP := range nicIPs {
+			if (nicIP.To4() != nil) == isIPv4 && nicIP.IsGlobalUnicast() {
+				return nicIP.String(), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("NIC %q doesn't have an %s address to forward the floating IP to", deviceName, keyPrefix)
+}
+
+// floatingIPForward returns the network forward backing a floating IP.
+// The instance of the request is in instanceProject, which may differ from the project of the network.
+func floatingIPForward(req api.NetworkFloatingIPPut, instanceProject string, targetAddress string) api.NetworkForwardPut {
+	forward := api.NetworkForwardPut{
+		Description: req.Description,
+		Config:      map[string]string{floatingIPKey: "true"},
+	}
+
+	if targetAddress != "" {
+		forward.Config["target_address"] = targetAddress
+		forward.Config[floatingIPInstanceKey] = req.Instance
+		forward.Config[floatingIPDeviceKey] = req.Device
+		forward.Config[floatingIPProjectKey] = instanceProject
+	}
+
+	return forward
+}
+
+// CreateFloatingIP creates the network forward backing a new floating IP.
+func CreateFloatingIP(n Network, address string, req api.NetworkFloatingIPPut, instanceProject string, targetAddress string, clientType request.ClientType) error {
+	forward := api.NetworkForwardsPost{
+		NetworkForwardPut: floatingIPForward(req, instanceProject, targetAddress),
+		ListenAddress:     address,
+	}
+
+	return n.ForwardCreate(forward, clientType)
+}
+
+// UpdateFloatingIP attaches or detaches a floating IP held by curNet, moving it to targetNet if different.
+func UpdateFloatingIP(s *state.State, curNet Network, floatingIP *api.NetworkFloatingIP, targetNet Network, req api.NetworkFloatingIPPut, instanceProject string, targetAddress string, clientType request.ClientType) error {
+	if targetNet == nil || targetNet.ID() == curNet.ID() {
+		return curNet.ForwardUpdate(floatingIP.Address, floatingIPForward(req, instanceProject, targetAddress), clientType)
+	}
+
+	curForwards, err := floatingIPForwards(s, curNet, func(forward *api.NetworkForward) bool {
+		return forward.ListenAddress == floatingIP.Address
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(curForwards) != 1 {
+		return api.StatusErrorf(http.StatusNotFound, "Floating IP not found")
+	}
+
+	curForward := curForwards[0]
+
+	reverter := revert.New()
+	defer reverter.Fail()
+
+	// The address can only be used by a single network, so release it before moving it.
+	err = curNet.ForwardDelete(floatingIP.Address, clientType)
+	if err != nil {
+		return err
+	}
+
+	reverter.Add(func() {
+		_ = curNet.ForwardCreate(api.NetworkForwardsPost{NetworkForwardPut: curForward.Writable(), ListenAddress: curForward.ListenAddress}, clientType)
+	})
+
+	err = CreateFloatingIP(targetNet, floatingIP.Address, req, instanceProject, targetAddress, clientType)
+	if err != nil {
+		return err
+	}
+
+	reverter.Success()
+
+	return nil
+}
+
+// floatingIPForwards returns the forwards of a network backing floating IPs which match the filter.
+func floatingIPForwards(s *state.State, n Network, filter func(forward *api.NetworkForward) bool) ([]api.NetworkForward, error) {
+	forwards := []api.NetworkForward{}
+
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		networkID := n.ID()
+		dbRecords, err := dbCluster.GetNetworkForwards(ctx, tx.Tx(), dbCluster.NetworkForwardFilter{NetworkID: &networkID})
+		if err != nil {
+			return err
+		}
+
+		for _, dbRecord := range dbRecords {
+			forward, err := dbRecord.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return err
+			}
+
+			if IsFloatingIPForward(forward) && filter(forward) {
+				forwards = append(forwards, *forward)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading floating IPs of network %q: %w", n.Name(), err)
+	}
+
+	return forwards, nil
+}
+
+// instanceFloatingIPForwards returns the forwards of a network backing floating IPs attached to an instance NIC.
+// Only the forwards of the local cluster member are returned for networks with member specific forwards.
+func instanceFloatingIPForwards(s *state.State, n Network, projectName string, instanceName string, deviceName string) ([]api.NetworkForward, error) {
+	return floatingIPForwards(s, n, func(forward *api.NetworkForward) bool {
+		if forward.Location != "" && forward.Location != s.ServerName {
+			return false
+		}
+
+		return forward.Config[floatingIPProjectKey] == projectName && forward.Config[floatingIPInstanceKey] == instanceName && forward.Config[floatingIPDeviceKey] == deviceName
+	})
+}
+
+// DetachInstanceFloatingIPs detaches the floating IPs attached to an instance NIC connected to a network.
+// The floating IPs stay allocated to the network but no longer forward any traffic.
+func DetachInstanceFloatingIPs(s *state.State, n Network, projectName string, instanceName string, deviceName string) error {
+	_, _, err := FloatingIPPool(n)
+	if err != nil {
+		return nil // The network can't hold floating IPs.
+	}
+
+	unlock, err := FloatingIPPoolLock(n)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	forwards, err := instanceFloatingIPForwards(s, n, projectName, instanceName, deviceName)
+	if err != nil {
+		return err
+	}
+
+	for _, forward := range forwards {
+		err = n.ForwardUpdate(forward.ListenAddress, floatingIPForward(api.NetworkFloatingIPPut{Description: forward.Description}, "", ""), request.ClientTypeNormal)
+		if err != nil {
+			return fmt.Errorf("Failed detaching floating IP %q: %w", forward.ListenAddress, err)
+		}
+	}
+
+	return nil
+}
+
+// RenameInstanceFloatingIPs updates the floating IPs attached to an instance NIC connected to a network to
+// the new name of the instance.
+func RenameInstanceFloatingIPs(s *state.State, n Network, projectName string, oldInstanceName string, newInstanceName string, deviceName string) error {
+	_, _, err := FloatingIPPool(n)
+	if err != nil {
+		return nil // The network can't hold floating IPs.
+	}
+
+	unlock, err := FloatingIPPoolLock(n)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	forwards, err := instanceFloatingIPForwards(s, n, projectName, oldInstanceName, deviceName)
+	if err != nil {
+		return err
+	}
+
+	for _, forward := range forwards {
+		req := forward.Writable()
+		req.Config[floatingIPInstanceKey] = newInstanceName
+
+		err = n.ForwardUpdate(forward.ListenAddress, req, request.ClientTypeNormal)
+		if err != nil {
+			return fmt.Errorf("Failed renaming instance of floating IP %q: %w", forward.ListenAddress, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package network
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/internal/iprange"
+	deviceConfig "github.com/lxc/incus/v6/internal/server/device/config"
+)
+
+func Test_FloatingIPPool(t *testing.T) {
+	tests := []struct {
+		name        string
+		network     Network
+		poolProject string
+		poolName    string
+		err         bool
+	}{
+		{
+			name:        "Bridge network",
+			network:     &bridge{common{project: "foo", name: "br0", netType: "bridge"}},
+			poolProject: "foo",
+			poolName:    "br0",
+		},
+		{
+			name:        "OVN network",
+			network:     &ovn{common: common{project: "foo", name: "ovn0", netType: "ovn", config: map[string]string{"network": "uplink"}}},
+			poolProject: "default",
+			poolName:    "uplink",
+		},
+		{
+			name:    "OVN network without uplink",
+			network: &ovn{common: common{project: "foo", name: "ovn0", netType: "ovn", config: map[string]string{"network": "none"}}},
+			err:     true,
+		},
+		{
+			name:    "Unsupported network type",
+			network: &macvlan{common{project: "default", name: "mv0", netType: "macvlan"}},
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolProject, poolName, err := FloatingIPPool(tt.network)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.poolProject, poolProject)
+			assert.Equal(t, tt.poolName, poolName)
+		})
+	}
+}
+
+func Test_floatingIPAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]string
+		used    []string
+		address string
+		result  string
+		err     bool
+	}{
+		{
+			name:   "First address of the range",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			result: "192.0.2.10",
+		},
+		{
+			name:   "Used addresses are skipped",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			used:   []string{"192.0.2.10", "192.0.2.11"},
+			result: "192.0.2.12",
+		},
+		{
+			name:   "IPv4 tried before IPv6",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12", "ipv6.floating.ranges": "2001:db8::10-2001:db8::12"},
+			result: "192.0.2.10",
+		},
+		{
+			name:   "IPv6 used once IPv4 is full",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.11", "ipv6.floating.ranges": "2001:db8::10-2001:db8::12"},
+			used:   []string{"192.0.2.10", "192.0.2.11", "2001:db8::10"},
+			result: "2001:db8::11",
+		},
+		{
+			name:   "Next range once the first is full",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.10,198.51.100.5-198.51.100.6"},
+			used:   []string{"192.0.2.10"},
+			result: "198.51.100.5",
+		},
+		{
+			name:   "Full range",
+			config: map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.11"},
+			used:   []string{"192.0.2.11", "192.0.2.10"},
+			err:    true,
+		},
+		{
+			name:    "Explicit address",
+			config:  map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			used:    []string{"192.0.2.10"},
+			address: "192.0.2.12",
+			result:  "192.0.2.12",
+		},
+		{
+			name:    "Explicit IPv6 address is normalised",
+			config:  map[string]string{"ipv6.floating.ranges": "2001:db8::10-2001:db8::12"},
+			address: "2001:0db8::0011",
+			result:  "2001:db8::11",
+		},
+		{
+			name:    "Explicit address in use",
+			config:  map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			used:    []string{"192.0.2.11"},
+			address: "192.0.2.11",
+			err:     true,
+		},
+		{
+			name:    "Explicit address outside of the ranges",
+			config:  map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			address: "192.0.2.13",
+			err:     true,
+		},
+		{
+			name:    "Invalid explicit address",
+			config:  map[string]string{"ipv4.floating.ranges": "192.0.2.10-192.0.2.12"},
+			address: "192.0.2",
+			err:     true,
+		},
+		{
+			name:   "No ranges",
+			config: map[string]string{},
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used := []net.IP{}
+			for _, address := range tt.used {
+				used = append(used, net.ParseIP(address))
+			}
+
+			var result string
+			ipRanges, err := floatingIPRanges("uplink", tt.config)
+			if err == nil {
+				result, err = floatingIPAllocate("uplink", ipRanges, used, tt.address)
+			}
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func Test_FloatingIPTargetAddress(t *testing.T) {
+	n := &bridge{common{project: "default", name: "br0", netType: "bridge"}}
+
+	tests := []struct {
+		name       string
+		nicConfig  deviceConfig.Device
+		floatingIP string
+		result     string
+		err        bool
+	}{
+		{
+			name:       "Static IPv4 address",
+			nicConfig:  deviceConfig.Device{"ipv4.address": "10.0.0.5", "ipv6.address": "fd42::5"},
+			floatingIP: "192.0.2.10",
+			result:     "10.0.0.5",
+		},
+		{
+			name:       "Static IPv6 address",
+			nicConfig:  deviceConfig.Device{"ipv4.address": "10.0.0.5", "ipv6.address": "fd42::5"},
+			floatingIP: "2001:db8::10",
+			result:     "fd42::5",
+		},
+		{
+			name:       "No address of the same family",
+			nicConfig:  deviceConfig.Device{"ipv4.address": "10.0.0.5"},
+			floatingIP: "2001:db8::10",
+			err:        true,
+		},
+		{
+			name:       "Invalid floating IP",
+			nicConfig:  deviceConfig.Device{"ipv4.address": "10.0.0.5"},
+			floatingIP: "not-an-ip",
+			err:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FloatingIPTargetAddress(n, "uuid", "eth0", tt.nicConfig, tt.floatingIP)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
+
+func Test_floatingIPRangesValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+		err    bool
+	}{
+		{
+			name:   "Outside of the bridge subnet",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.floating.ranges": "192.0.2.10-192.0.2.20"},
+		},
+		{
+			name:   "Within the default DHCP range",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.floating.ranges": "10.0.0.100-10.0.0.110"},
+			err:    true,
+		},
+		{
+			name:   "Containing the DHCP ranges",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.dhcp.ranges": "10.0.0.100-10.0.0.110", "ipv4.floating.ranges": "10.0.0.50-10.0.0.200"},
+			err:    true,
+		},
+		{
+			name:   "Outside of the DHCP ranges",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.dhcp.ranges": "10.0.0.100-10.0.0.110", "ipv4.floating.ranges": "10.0.0.200-10.0.0.210"},
+		},
+		{
+			name:   "DHCP disabled",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.dhcp": "false", "ipv4.floating.ranges": "10.0.0.100-10.0.0.110"},
+		},
+		{
+			name:   "Overlapping the OVN ranges",
+			config: map[string]string{"ipv4.address": "10.0.0.1/24", "ipv4.dhcp.ranges": "10.0.0.10-10.0.0.20", "ipv4.ovn.ranges": "10.0.0.100-10.0.0.110", "ipv4.floating.ranges": "10.0.0.105-10.0.0.120"},
+			err:    true,
+		},
+		{
+			name:   "Within the stateful DHCPv6 range",
+			config: map[string]string{"ipv6.address": "fd42::1/64", "ipv6.dhcp.stateful": "true", "ipv6.floating.ranges": "fd42::100-fd42::110"},
+			err:    true,
+		},
+		{
+			name:   "Within the SLAAC subnet",
+			config: map[string]string{"ipv6.address": "fd42::1/64", "ipv6.floating.ranges": "fd42::100-fd42::110"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+				dhcpRanges, rangesErr := bridgeDHCPRanges(tt.config, keyPrefix)
+				require.NoError(t, rangesErr)
+
+				err = errors.Join(err, floatingIPRangesValidate(tt.config, keyPrefix, map[string][]*iprange.Range{keyPrefix + ".dhcp.ranges": dhcpRanges}))
+			}
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
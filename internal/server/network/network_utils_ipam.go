@@ -0,0 +1,320 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/lxc/incus/v6/internal/server/dnsmasq/dhcpalloc"
+	"github.com/lxc/incus/v6/internal/server/network/ipam"
+	"github.com/lxc/incus/v6/internal/server/state"
+	"github.com/lxc/incus/v6/internal/version"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// ipamNetworkOwner returns the URL of a network, used as the owner of its IPAM reservations.
+func ipamNetworkOwner(projectName string, networkName string) string {
+	return api.NewURL().Path(version.APIVersion, "networks", networkName).Project(projectName).String()
+}
+
+// ipamLoad returns the IPAM driver selected by a network config, set up with the server wide IPAM settings.
+func ipamLoad(s *state.State, config map[string]string) (ipam.Driver, error) {
+	var serverConfig ipam.ServerConfig
+	if s != nil && s.GlobalConfig != nil {
+		serverConfig.HTTPURL, serverConfig.HTTPToken = s.GlobalConfig.NetworkIPAMHTTP()
+	}
+
+	return ipam.Load(config, serverConfig)
+}
+
+// ipamFamily returns the address family of an IP.
+func ipamFamily(ip net.IP) int {
+	if ip.To4() != nil {
+		return 4
+	}
+
+	return 6
+}
+
+// ipamSubnet has the IPAM configured in config pick a subnet of the given family for the network.
+// The subnet is returned in the form used by the ipvX.address keys (first address and prefix length).
+// An empty subnet is returned when the allocation is left to Incus.
+func (n *common) ipamSubnet(config map[string]string, family int) (string, error) {
+	driver, err := ipamLoad(n.state, config)
+	if err != nil {
+		return "", err
+	}
+
+	prefixLength := 24
+	if family == 6 {
+		prefixLength = 64
+	}
+
+	subnet, err := driver.Reserve(context.TODO(), ipam.Request{
+		Type:         ipam.TypeSubnet,
+		Family:       family,
+		Project:      n.project,
+		Network:      n.name,
+		Owner:        ipamNetworkOwner(n.project, n.name),
+		Description:  fmt.Sprintf("Subnet of network %q", n.name),
+		PrefixLength: prefixLength,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed reserving IPv%d subnet: %w", family, err)
+	}
+
+	if subnet == "" {
+		return "", nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil || ipamFamily(ipNet.IP) != family {
+		return "", fmt.Errorf("IPAM returned an invalid IPv%d subnet %q", family, subnet)
+	}
+
+	ones, _ := ipNet.Mask.Size()
+
+	return fmt.Sprintf("%s/%d", dhcpalloc.GetIP(ipNet, 1).String(), ones), nil
+}
+
+// ipamReleaseSubnet releases a subnet of the network (in the form used by the ipvX.address keys) from the IPAM
+// configured in config.
+func (n *common) ipamReleaseSubnet(config map[string]string, address string) error {
+	if !ipam.IsExternal(config) {
+		return nil
+	}
+
+	ip, ipNet, err := net.ParseCIDR(address)
+	if err != nil {
+		return nil // Not a subnet ("none" or empty).
+	}
+
+	driver, err := ipamLoad(n.state, config)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Release(context.TODO(), ipam.Request{
+		Type:    ipam.TypeSubnet,
+		Family:  ipamFamily(ip),
+		Project: n.project,
+		Network: n.name,
+		Owner:   ipamNetworkOwner(n.project, n.name),
+		Address: ipNet.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed releasing subnet %q: %w", ipNet.String(), err)
+	}
+
+	return nil
+}
+
+// IPAMReleaseFilledSubnets releases the subnets picked by FillConfig (those differing between the requested config
+// and the filled config) from the external IPAM of the network (if any).
+// This is used to undo the reservations when the network then fails to be created.
+func IPAMReleaseFilledSubnets(n Type, reqConfig map[string]string, config map[string]string) error {
+	c, ok := n.(interface {
+		ipamReleaseSubnet(config map[string]string, address string) error
+	})
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		if reqConfig[key] == config[key] {
+			continue
+		}
+
+		err := c.ipamReleaseSubnet(config, config[key])
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// IPAMReserveNICAddress has the external IPAM of a network pick an address of the given family for an instance NIC.
+// An empty address is returned when the network doesn't use an external IPAM or has no subnet of that family.
+func IPAMReserveNICAddress(s *state.State, n Network, projectName string, instanceName string, deviceName string, family int) (string, error) {
+	config := n.Config()
+	if !ipam.IsExternal(config) {
+		return "", nil
+	}
+
+	_, subnet, err := net.ParseCIDR(config[fmt.Sprintf("ipv%d.address", family)])
+	if err != nil {
+		return "", nil // No subnet to allocate from.
+	}
+
+	driver, err := ipamLoad(s, config)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := driver.Reserve(context.TODO(), ipam.Request{
+		Type:        ipam.TypeAddress,
+		Family:      family,
+		Project:     n.Project(),
+		Network:     n.Name(),
+		Owner:       api.NewURL().Path(version.APIVersion, "instances", instanceName).Project(projectName).String(),
+		Description: fmt.Sprintf("Device %q of instance %q", deviceName, instanceName),
+		Subnet:      subnet.String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed reserving IPv%d address: %w", family, err)
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil || !subnet.Contains(ip) {
+		return "", fmt.Errorf("IPAM returned address %q which isn't within subnet %q", address, subnet.String())
+	}
+
+	return ip.String(), nil
+}
+
+// IPAMReleaseNICAddress releases an instance NIC address reserved through IPAMReserveNICAddress.
+func IPAMReleaseNICAddress(s *state.State, n Network, projectName string, instanceName string, address string) error {
+	config := n.Config()
+	ip := net.ParseIP(address)
+	if !ipam.IsExternal(config) || ip == nil {
+		return nil
+	}
+
+	driver, err := ipamLoad(s, config)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Release(context.TODO(), ipam.Request{
+		Type:    ipam.TypeAddress,
+		Family:  ipamFamily(ip),
+		Project: n.Project(),
+		Network: n.Name(),
+		Owner:   api.NewURL().Path(version.APIVersion, "instances", instanceName).Project(projectName).String(),
+		Address: ip.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed releasing address %q: %w", ip.String(), err)
+	}
+
+	return nil
+}
+
+// IPAMRenameNICAddress moves an instance NIC address reserved through IPAMReserveNICAddress to the new name
+// of the instance, as the owner of the reservation is derived from it.
+func IPAMRenameNICAddress(s *state.State, n Network, projectName string, oldInstanceName string, newInstanceName string, deviceName string, address string) error {
+	config := n.Config()
+	ip := net.ParseIP(address)
+	if !ipam.IsExternal(config) || ip == nil {
+		return nil
+	}
+
+	driver, err := ipamLoad(s, config)
+	if err != nil {
+		return err
+	}
+
+	req := ipam.Request{
+		Type:    ipam.TypeAddress,
+		Family:  ipamFamily(ip),
+		Project: n.Project(),
+		Network: n.Name(),
+		Owner:   api.NewURL().Path(version.APIVersion, "instances", oldInstanceName).Project(projectName).String(),
+		Address: ip.String(),
+	}
+
+	err = driver.Release(context.TODO(), req)
+	if err != nil {
+		return fmt.Errorf("Failed releasing address %q: %w", ip.String(), err)
+	}
+
+	req.Owner = api.NewURL().Path(version.APIVersion, "instances", newInstanceName).Project(projectName).String()
+	req.Description = fmt.Sprintf("Device %q of instance %q", deviceName, newInstanceName)
+
+	_, err = driver.Reserve(context.TODO(), req)
+	if err != nil {
+		return fmt.Errorf("Failed reserving address %q: %w", ip.String(), err)
+	}
+
+	return nil
+}
+
+// IPAMReserveForwardAddress reserves the listen address of a new network forward in the IPAM of the network.
+// A wildcard listen address (0.0.0.0 or ::) has the IPAM pick the listen address, which is returned.
+func IPAMReserveForwardAddress(s *state.State, n Network, listenAddress string) (string, error) {
+	ip := net.ParseIP(listenAddress)
+	if ip == nil {
+		return "", api.StatusErrorf(http.StatusBadRequest, "Invalid listen address %q", listenAddress)
+	}
+
+	config := n.Config()
+	if !ipam.IsExternal(config) {
+		if ip.IsUnspecified() {
+			return "", api.StatusErrorf(http.StatusBadRequest, "Allocating listen addresses requires the network to use an external IPAM")
+		}
+
+		return listenAddress, nil
+	}
+
+	driver, err := ipamLoad(s, config)
+	if err != nil {
+		return "", err
+	}
+
+	req := ipam.Request{
+		Type:        ipam.TypeAddress,
+		Family:      ipamFamily(ip),
+		Project:     n.Project(),
+		Network:     n.Name(),
+		Owner:       ipamNetworkOwner(n.Project(), n.Name()),
+		Description: fmt.Sprintf("Listen address of a forward of network %q", n.Name()),
+	}
+
+	if !ip.IsUnspecified() {
+		req.Address = ip.String()
+	}
+
+	address, err := driver.Reserve(context.TODO(), req)
+	if err != nil {
+		return "", fmt.Errorf("Failed reserving listen address: %w", err)
+	}
+
+	reserved := net.ParseIP(address)
+	if reserved == nil || ipamFamily(reserved) != req.Family {
+		return "", fmt.Errorf("IPAM returned an invalid IPv%d address %q", req.Family, address)
+	}
+
+	return reserved.String(), nil
+}
+
+// IPAMReleaseForwardAddress releases the listen address of a deleted network forward from the IPAM of the network.
+func IPAMReleaseForwardAddress(s *state.State, n Network, listenAddress string) error {
+	config := n.Config()
+	ip := net.ParseIP(listenAddress)
+	if !ipam.IsExternal(config) || ip == nil {
+		return nil
+	}
+
+	driver, err := ipamLoad(s, config)
+	if err != nil {
+		return err
+	}
+
+	err = driver.Release(context.TODO(), ipam.Request{
+		Type:    ipam.TypeAddress,
+		Family:  ipamFamily(ip),
+		Project: n.Project(),
+		Network: n.Name(),
+		Owner:   ipamNetworkOwner(n.Project(), n.Name()),
+		Address: ip.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed releasing listen address %q: %w", ip.String(), err)
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/lxc/incus/v6/internal/iprange"
+	"github.com/lxc/incus/v6/shared/api"
 )
 
 func Example_parseIPRange() {
@@ -180,3 +181,94 @@ func Example_complementRanges() {
 	// Range2: 10.1.1.1-10.1.1.9, 10.1.1.101-10.1.1.199, 10.1.1.231-10.1.1.254
 	// Range3: 10.1.1.1-10.1.1.9, 10.1.1.26-10.1.1.254
 }
+
+func Example_parseTraceFlow() {
+	requests := []api.InstanceNetworkTracePost{
+		{Destination: "192.0.2.10"},
+		{Destination: "192.0.2.10", Source: "10.0.0.2", Protocol: "tcp", DestinationPort: 443},
+		{Destination: "2001:db8::10", Protocol: "icmp"},
+		{Destination: "not-an-ip"},
+		{Destination: "192.0.2.10", Source: "fd42::2"},
+		{Destination: "192.0.2.10", Protocol: "sctp"},
+		{Destination: "192.0.2.10", Protocol: "icmp", DestinationPort: 80},
+		{Destination: "192.0.2.10", Protocol: "udp", DestinationPort: 70000},
+	}
+
+	for _, req := range requests {
+		dst, src, err := parseTraceFlow(req)
+		if err != nil {
+			fmt.Printf("Err: %v\n", err)
+			continue
+		}
+
+		fmt.Printf("%v from %v\n", dst, src)
+	}
+
+	// Output: 192.0.2.10 from <nil>
+	// 192.0.2.10 from 10.0.0.2
+	// 2001:db8::10 from <nil>
+	// Err: Invalid destination address "not-an-ip"
+	// Err: Source and destination addresses must be of the same family
+	// Err: Unsupported protocol "sctp"
+	// Err: A destination port requires the tcp or udp protocol
+	// Err: Invalid destination port 70000
+}
+
+func Example_firewallTraceVerdict() {
+	traces := [][]string{
+		{
+			`trace id 1a2b netdev incus trace.veth1 packet: iif "veth1" ether saddr 00:16:3e:00:00:01 ip saddr 10.0.0.2 ip daddr 192.0.2.10`,
+			`trace id 1a2b netdev incus trace.veth1 rule ip daddr 192.0.2.10 meta nftrace set 1 comment "trace-1" (verdict continue)`,
+			`trace id 1a2b netdev incus trace.veth1 policy accept`,
+			`trace id 1a2b inet incus fwd.incusbr0 rule iifname "incusbr0" accept (verdict accept)`,
+			`trace id 1a2b inet incus aclfwd.incusbr0 verdict continue`,
+		},
+		{
+			`trace id 3c4d netdev incus trace.veth1 policy accept`,
+			`trace id 3c4d inet incus aclfwd.incusbr0 rule ip daddr 192.0.2.10 counter drop comment "incus_acl1-egress-0" (verdict drop)`,
+		},
+		{
+			`trace id 5e6f netdev incus trace.veth1 policy accept`,
+			`trace id 5e6f inet incus fwd.incusbr0 policy drop`,
+		},
+		{
+			`trace id 7a8b netdev incus trace.veth1 verdict continue`,
+		},
+		{},
+	}
+
+	for _, output := range traces {
+		fmt.Println(firewallTraceVerdict(output))
+	}
+
+	// Output: allow
+	// drop
+	// drop
+	// unknown
+	// unknown
+}
+
+func Example_loadBalancerKeepSecrets() {
+	curConfig := map[string]string{"tls.certificate": "cert", "tls.key": "key"}
+
+	requests := []map[string]string{
+		{"tls.certificate": "cert"},
+		{"tls.certificate": "new-cert", "tls.key": "new-key"},
+		{},
+	}
+
+	for _, config := range requests {
+		req := api.NetworkLoadBalancerPut{Config: config}
+		loadBalancerKeepSecrets(curConfig, &req)
+		fmt.Printf("%q\n", req.Config["tls.key"])
+	}
+
+	lb := api.NetworkLoadBalancer{NetworkLoadBalancerPut: api.NetworkLoadBalancerPut{Config: curConfig}}
+	LoadBalancerHideSecrets(&lb)
+	fmt.Println(lb.Config)
+
+	// Output: "key"
+	// "new-key"
+	// ""
+	// map[tls.certificate:cert]
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/lxc/incus/v6/internal/iprange"
 	ovnNB "github.com/lxc/incus/v6/internal/server/network/ovn/schema/ovn-nb"
 	ovnSB "github.com/lxc/incus/v6/internal/server/network/ovn/schema/ovn-sb"
+	"github.com/lxc/incus/v6/shared/units"
 	"github.com/lxc/incus/v6/shared/util"
 )
 
@@ -82,6 +83,10 @@ const (
 	ovnExtIDIncusProjectID  = "incus_project_id"
 	ovnExtIDIncusPortGroup  = "incus_port_group"
 	ovnExtIDIncusLocation   = "incus_location"
+	ovnExtIDIncusDHCPParent = "incus_dhcp_parent"
+	ovnExtIDIncusDHCPExtra  = "incus_dhcp_options"
+	ovnExtIDIncusQoSOwner   = "incus_qos_owner"
+	ovnExtIDIncusACLRule    = "incus_acl_rule"
 )
 
 // OVNIPv6RAOpts IPv6 router advertisements options that can be applied to a router.
@@ -113,6 +118,7 @@ type OVNDHCPv4Opts struct {
 	Netmask            string
 	DNSSearchList      []string
 	StaticRoutes       string
+	Options            map[string]string // Additional OVN DHCP options (keyed by OVN option name).
 }
 
 // OVNDHCPv6Opts IPv6 DHCP option set that can be created (and then applied to a switch port by resulting ID).
@@ -121,6 +127,7 @@ type OVNDHCPv6Opts struct {
 	RecursiveDNSServer []net.IP
 	DNSSearchList      []string
 	DHCPv6Stateless    bool
+	Options            map[string]string // Additional OVN DHCP options (keyed by OVN option name).
 }
 
 // OVNSwitchPortOpts options that can be applied to a switch port.
@@ -135,6 +142,7 @@ type OVNSwitchPortOpts struct {
 	Location     string             // Optional, use to indicate the name of the server this port is bound to.
 	RouterPort   OVNRouterPort      // Optional, the name of the associated logical router port.
 	Promiscuous  bool               // Optional, controls whether to allow unknown traffic on the port.
+	QoSMinRate   uint64             // Optional, minimum guaranteed rate in bit/s for traffic sent from the port.
 }
 
 // OVNACLRule represents an ACL rule that can be added to a logical switch or port group.
@@ -145,6 +153,38 @@ type OVNACLRule struct {
 	Priority  int    // Priority (between 0 and 32767, inclusive). Higher values take precedence.
 	Log       bool   // Whether or not to log matched packets.
 	LogName   string // Log label name (requires Log be true).
+	Counter   string // Optional, name of the Incus ACL rule counter the hits are accounted to.
+}
+
+// OVNQoSRule represents a QoS rule applied to a logical switch.
+type OVNQoSRule struct {
+	Direction string // Either "from-lport" or "to-lport".
+	Match     string // Match criteria. See OVN Southbound database's Logical_Flow table match column usage.
+	Priority  int    // Priority (between 0 and 32767, inclusive). Higher values take precedence.
+	DSCP      *int   // DSCP value to mark matching packets with (between 0 and 63, inclusive).
+	Rate      int    // Rate limit in kbps (0 for no limit).
+	Burst     int    // Burst size in kbits (0 for the OVN default).
+}
+
+// QoS rule priorities, the most specific rules take precedence.
+const (
+	OVNQoSPriorityNetwork = 1000 // Network level rules.
+	OVNQoSPriorityACL     = 2000 // Network ACL rules.
+	OVNQoSPriorityPort    = 3000 // Instance NIC rules.
+)
+
+// ParseQoSRate parses a bit/s limit into the kbps rate used by QoS rules.
+func ParseQoSRate(value string) (int, error) {
+	rate, err := units.ParseBitSizeString(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if rate < 1000 {
+		return 0, errors.New("Rate must be at least 1kbit")
+	}
+
+	return int(rate / 1000), nil
 }
 
 // OVNLoadBalancerTarget represents an OVN load balancer Virtual IP target.
@@ -1419,24 +1459,12 @@ func (o *NB) UpdateLogicalSwitchDHCPv4Options(ctx context.Context, switchName OV
 		delete(dhcpOption.Options, "classless_static_route")
 	}
 
-	// Prepare the changes.
-	operations := []ovsdb.Operation{}
-	if dhcpOption.UUID == "" {
-		// Create a new record.
-		createOps, err := o.client.Create(&dhcpOption)
-		if err != nil {
-			return err
-		}
-
-		operations = append(operations, createOps...)
-	} else {
-		// Update the record.
-		updateOps, err := o.client.Where(&dhcpOption).Update(&dhcpOption)
-		if err != nil {
-			return err
-		}
+	applyDHCPExtraOptions(&dhcpOption, opts.Options)
 
-		operations = append(operations, updateOps...)
+	// Prepare the changes.
+	operations, err := o.dhcpOptionsUpdateOperations(ctx, &dhcpOption)
+	if err != nil {
+		return err
 	}
 
 	// Apply the database changes.
@@ -1507,38 +1535,182 @@ func (o *NB) UpdateLogicalSwitchDHCPv6Options(ctx context.Context, switchName OV
 		delete(dhcpOption.Options, "dns_server")
 	}
 
+	applyDHCPExtraOptions(&dhcpOption, opts.Options)
+
 	// Prepare the changes.
+	operations, err := o.dhcpOptionsUpdateOperations(ctx, &dhcpOption)
+	if err != nil {
+		return err
+	}
+
+	// Apply the database changes.
+	resp, err := o.client.Transact(ctx, operations...)
+	if err != nil {
+		return err
+	}
+
+	_, err = ovsdb.CheckOperationResults(resp, operations)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyDHCPExtraOptions replaces the additional options previously applied to a DHCP options set.
+// The applied option names are recorded in the external IDs so they can be removed later on.
+func applyDHCPExtraOptions(dhcpOption *ovnNB.DHCPOptions, options map[string]string) {
+	for _, key := range util.SplitNTrimSpace(dhcpOption.ExternalIDs[ovnExtIDIncusDHCPExtra], ",", -1, true) {
+		delete(dhcpOption.Options, key)
+	}
+
+	keys := slices.Sorted(maps.Keys(options))
+	if len(keys) == 0 {
+		delete(dhcpOption.ExternalIDs, ovnExtIDIncusDHCPExtra)
+		return
+	}
+
+	maps.Copy(dhcpOption.Options, options)
+	dhcpOption.ExternalIDs[ovnExtIDIncusDHCPExtra] = strings.Join(keys, ",")
+}
+
+// dhcpOptionsUpdateOperations returns the operations needed to create or update a DHCP options set.
+// When updating an existing set, the port specific copies of it are also refreshed (keeping their own options).
+func (o *NB) dhcpOptionsUpdateOperations(ctx context.Context, dhcpOption *ovnNB.DHCPOptions) ([]ovsdb.Operation, error) {
 	operations := []ovsdb.Operation{}
 	if dhcpOption.UUID == "" {
 		// Create a new record.
-		createOps, err := o.client.Create(&dhcpOption)
+		createOps, err := o.client.Create(dhcpOption)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		operations = append(operations, createOps...)
-	} else {
-		// Update the record.
-		updateOps, err := o.client.Where(&dhcpOption).Update(&dhcpOption)
+		return append(operations, createOps...), nil
+	}
+
+	// Update the record.
+	updateOps, err := o.client.Where(dhcpOption).Update(dhcpOption)
+	if err != nil {
+		return nil, err
+	}
+
+	operations = append(operations, updateOps...)
+
+	// Refresh the port specific copies.
+	portOptions := []ovnNB.DHCPOptions{}
+	err = o.client.WhereCache(func(do *ovnNB.DHCPOptions) bool {
+		return do.ExternalIDs != nil && do.ExternalIDs[ovnExtIDIncusDHCPParent] == dhcpOption.UUID
+	}).List(ctx, &portOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, portOption := range portOptions {
+		options := maps.Clone(dhcpOption.Options)
+		for _, key := range util.SplitNTrimSpace(portOption.ExternalIDs[ovnExtIDIncusDHCPExtra], ",", -1, true) {
+			options[key] = portOption.Options[key]
+		}
+
+		portOption.Cidr = dhcpOption.Cidr
+		portOption.Options = options
+
+		updateOps, err := o.client.Where(&portOption).Update(&portOption)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		operations = append(operations, updateOps...)
 	}
 
+	return operations, nil
+}
+
+// UpdateLogicalSwitchPortDHCPOptions creates or updates a port specific copy of the DHCP options set uuid with
+// the additional options applied on top of it and returns the UUID of the copy.
+// If no options are provided, any existing copy is removed and uuid is returned.
+func (o *NB) UpdateLogicalSwitchPortDHCPOptions(ctx context.Context, portName OVNSwitchPort, uuid OVNDHCPOptionsUUID, options map[string]string) (OVNDHCPOptionsUUID, error) {
+	// Get the existing copies.
+	portOptions := []ovnNB.DHCPOptions{}
+	err := o.client.WhereCache(func(do *ovnNB.DHCPOptions) bool {
+		return do.ExternalIDs != nil && do.ExternalIDs[ovnExtIDIncusSwitchPort] == string(portName) && do.ExternalIDs[ovnExtIDIncusDHCPParent] == string(uuid)
+	}).List(ctx, &portOptions)
+	if err != nil {
+		return "", err
+	}
+
+	operations := []ovsdb.Operation{}
+	if len(options) == 0 {
+		for _, portOption := range portOptions {
+			deleteOps, err := o.client.Where(&portOption).Delete()
+			if err != nil {
+				return "", err
+			}
+
+			operations = append(operations, deleteOps...)
+		}
+	} else {
+		// Get the DHCP options set to copy.
+		dhcpOption := ovnNB.DHCPOptions{UUID: string(uuid)}
+		err = o.get(ctx, &dhcpOption)
+		if err != nil {
+			return "", err
+		}
+
+		portOption := ovnNB.DHCPOptions{UUID: "dhcp"}
+		if len(portOptions) > 0 {
+			portOption = portOptions[0]
+		}
+
+		portOption.Cidr = dhcpOption.Cidr
+		portOption.Options = maps.Clone(dhcpOption.Options)
+		portOption.ExternalIDs = map[string]string{
+			ovnExtIDIncusSwitch:     dhcpOption.ExternalIDs[ovnExtIDIncusSwitch],
+			ovnExtIDIncusSwitchPort: string(portName),
+			ovnExtIDIncusDHCPParent: string(uuid),
+		}
+
+		applyDHCPExtraOptions(&portOption, options)
+
+		if portOption.UUID == "dhcp" {
+			createOps, err := o.client.Create(&portOption)
+			if err != nil {
+				return "", err
+			}
+
+			operations = append(operations, createOps...)
+		} else {
+			updateOps, err := o.client.Where(&portOption).Update(&portOption)
+			if err != nil {
+				return "", err
+			}
+
+			operations = append(operations, updateOps...)
+		}
+
+		uuid = OVNDHCPOptionsUUID(portOption.UUID)
+	}
+
+	// Check if there's anything to do.
+	if len(operations) == 0 {
+		return uuid, nil
+	}
+
 	// Apply the database changes.
 	resp, err := o.client.Transact(ctx, operations...)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	_, err = ovsdb.CheckOperationResults(resp, operations)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	if uuid == "dhcp" {
+		uuid = OVNDHCPOptionsUUID(resp[0].UUID.GoUUID)
+	}
+
+	return uuid, nil
 }
 
 // GetLogicalSwitchDHCPOptions retrieves the existing DHCP options defined for a logical switch.
@@ -1546,7 +1718,8 @@ func (o *NB) GetLogicalSwitchDHCPOptions(ctx context.Context, switchName OVNSwit
 	// Get the matching DHCP options.
 	dhcpOptions := []ovnNB.DHCPOptions{}
 	err := o.client.WhereCache(func(do *ovnNB.DHCPOptions) bool {
-		return do.ExternalIDs != nil && do.ExternalIDs[ovnExtIDIncusSwitch] == string(switchName)
+		// Skip the port specific copies.
+		return do.ExternalIDs != nil && do.ExternalIDs[ovnExtIDIncusSwitch] == string(switchName) && do.ExternalIDs[ovnExtIDIncusDHCPParent] == ""
 	}).List(ctx, &dhcpOptions)
 	if err != nil {
 		return nil, err
@@ -1655,6 +1828,139 @@ func (o *NB) UpdateLogicalSwitchACLRules(ctx context.Context, switchName OVNSwit
 	return nil
 }
 
+// UpdateLogicalSwitchQoSRules applies a set of QoS rules to the specified logical switch.
+// Any existing rules on any logical switch for the same owner are removed.
+func (o *NB) UpdateLogicalSwitchQoSRules(ctx context.Context, switchName OVNSwitch, owner string, matchReplace map[string]string, qosRules ...OVNQoSRule) error {
+	// Remove any existing rules for the owner.
+	operations, err := o.qosRuleDeleteOperations(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	// Add new rules.
+	ls := ovnNB.LogicalSwitch{
+		Name: string(switchName),
+	}
+
+	for i, rule := range qosRules {
+		// Perform any replacements requested on the Match string.
+		for find, replace := range matchReplace {
+			rule.Match = strings.ReplaceAll(rule.Match, find, replace)
+		}
+
+		qos := ovnNB.QoS{
+			UUID:      fmt.Sprintf("qos%d", i),
+			Direction: rule.Direction,
+			Match:     rule.Match,
+			Priority:  rule.Priority,
+			ExternalIDs: map[string]string{
+				ovnExtIDIncusSwitch:   string(switchName),
+				ovnExtIDIncusQoSOwner: owner,
+			},
+		}
+
+		if rule.DSCP != nil {
+			qos.Action = map[string]int{ovnNB.QoSActionDSCP: *rule.DSCP}
+		}
+
+		if rule.Rate > 0 {
+			qos.Bandwidth = map[string]int{ovnNB.QoSBandwidthRate: rule.Rate}
+
+			if rule.Burst > 0 {
+				qos.Bandwidth[ovnNB.QoSBandwidthBurst] = rule.Burst
+			}
+		}
+
+		createOps, err := o.client.Create(&qos)
+		if err != nil {
+			return err
+		}
+
+		operations = append(operations, createOps...)
+
+		updateOps, err := o.client.Where(&ls).Mutate(&ls, ovsModel.Mutation{
+			Field:   &ls.QOSRules,
+			Mutator: ovsdb.MutateOperationInsert,
+			Value:   []string{qos.UUID},
+		})
+		if err != nil {
+			return err
+		}
+
+		operations = append(operations, updateOps...)
+	}
+
+	// Check if we have anything to do.
+	if len(operations) == 0 {
+		return nil
+	}
+
+	// Apply the database changes.
+	resp, err := o.client.Transact(ctx, operations...)
+	if err != nil {
+		return err
+	}
+
+	_, err = ovsdb.CheckOperationResults(resp, operations)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// qosRuleDeleteOperations returns the operations that remove the QoS rules of an owner from all logical switches.
+// The QoS rows themselves are garbage collected by OVN once no longer referenced.
+func (o *NB) qosRuleDeleteOperations(ctx context.Context, owner string) ([]ovsdb.Operation, error) {
+	operations := []ovsdb.Operation{}
+
+	qosRules := []ovnNB.QoS{}
+	err := o.client.WhereCache(func(qos *ovnNB.QoS) bool {
+		return qos.ExternalIDs != nil && qos.ExternalIDs[ovnExtIDIncusQoSOwner] == owner
+	}).List(ctx, &qosRules)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(qosRules) == 0 {
+		return operations, nil
+	}
+
+	qosRuleUUIDs := make([]string, 0, len(qosRules))
+	for _, qos := range qosRules {
+		qosRuleUUIDs = append(qosRuleUUIDs, qos.UUID)
+	}
+
+	switches := []ovnNB.LogicalSwitch{}
+	err = o.client.WhereCache(func(ls *ovnNB.LogicalSwitch) bool {
+		for _, qosUUID := range ls.QOSRules {
+			if slices.Contains(qosRuleUUIDs, qosUUID) {
+				return true
+			}
+		}
+
+		return false
+	}).List(ctx, &switches)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ls := range switches {
+		updateOps, err := o.client.Where(&ls).Mutate(&ls, ovsModel.Mutation{
+			Field:   &ls.QOSRules,
+			Mutator: ovsdb.MutateOperationDelete,
+			Value:   qosRuleUUIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, updateOps...)
+	}
+
+	return operations, nil
+}
+
 // logicalSwitchPortACLRules returns the ACL rule UUIDs belonging to a logical switch port.
 func (o *NB) logicalSwitchPortACLRules(ctx context.Context, portName OVNSwitchPort) ([]string, error) {
 	acls := []ovnNB.ACL{}
@@ -1837,6 +2143,16 @@ func (o *NB) CreateLogicalSwitchPort(ctx context.Context, switchName OVNSwitch,
 			}
 
 			logicalSwitchPort.Addresses = addresses
+
+			if opts.QoSMinRate > 0 {
+				if logicalSwitchPort.Options == nil {
+					logicalSwitchPort.Options = map[string]string{}
+				}
+
+				logicalSwitchPort.Options["qos_min_rate"] = fmt.Sprintf("%d", opts.QoSMinRate)
+			} else {
+				delete(logicalSwitchPort.Options, "qos_min_rate")
+			}
 		}
 
 		if opts.Location != "" {
@@ -2327,6 +2643,32 @@ func (o *NB) logicalSwitchPortDeleteOperations(ctx context.Context, switchName O
 
 	operations = append(operations, deleteOps...)
 
+	// Delete the port specific DHCP options.
+	dhcpOptions := []ovnNB.DHCPOptions{}
+	err = o.client.WhereCache(func(do *ovnNB.DHCPOptions) bool {
+		return do.ExternalIDs != nil && do.ExternalIDs[ovnExtIDIncusSwitchPort] == string(portName)
+	}).List(ctx, &dhcpOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, do := range dhcpOptions {
+		deleteOps, err := o.client.Where(&do).Delete()
+		if err != nil {
+			return nil, err
+		}
+
+		operations = append(operations, deleteOps...)
+	}
+
+	// Remove the port specific QoS rules.
+	qosOps, err := o.qosRuleDeleteOperations(ctx, string(portName))
+	if err != nil {
+		return nil, err
+	}
+
+	operations = append(operations, qosOps...)
+
 	return operations, nil
 }
 
@@ -2748,16 +3090,24 @@ func (o *NB) CreatePortGroup(ctx context.Context, projectID int64, portGroupName
 	return nil
 }
 
-// DeletePortGroup deletes port groups along with their ACL rules.
+// DeletePortGroup deletes port groups along with their ACL and QoS rules.
 func (o *NB) DeletePortGroup(ctx context.Context, portGroupNames ...OVNPortGroup) error {
 	operations := []ovsdb.Operation{}
 
 	for _, portGroupName := range portGroupNames {
+		// Remove the QoS rules applied on behalf of the port group.
+		qosOps, err := o.qosRuleDeleteOperations(ctx, string(portGroupName))
+		if err != nil {
+			return err
+		}
+
+		operations = append(operations, qosOps...)
+
 		pg := ovnNB.PortGroup{
 			Name: string(portGroupName),
 		}
 
-		err := o.get(ctx, &pg)
+		err = o.get(ctx, &pg)
 		if err != nil {
 			if errors.Is(err, ErrNotFound) {
 				// Already gone.
@@ -2957,6 +3307,10 @@ func (o *NB) aclRuleAddOperations(ctx context.Context, entityTable string, entit
 
 		maps.Copy(acl.ExternalIDs, externalIDs)
 
+		if rule.Counter != "" {
+			acl.ExternalIDs[ovnExtIDIncusACLRule] = rule.Counter
+		}
+
 		createOps, err := o.client.Create(&acl)
 		if err != nil {
 			return nil, err
@@ -3003,6 +3357,31 @@ func (o *NB) aclRuleAddOperations(ctx context.Context, entityTable string, entit
 	return operations, nil
 }
 
+// GetACLRuleCounterHints returns the stage hints of the ACLs whose counter name starts with the prefix.
+// The result maps the stage hint (used by OVN to tag the resulting logical flows) to the counter name.
+func (o *NB) GetACLRuleCounterHints(ctx context.Context, prefix string) (map[string]string, error) {
+	acls := []ovnNB.ACL{}
+
+	err := o.client.WhereCache(func(acl *ovnNB.ACL) bool {
+		return acl.ExternalIDs != nil && strings.HasPrefix(acl.ExternalIDs[ovnExtIDIncusACLRule], prefix)
+	}).List(ctx, &acls)
+	if err != nil {
+		return nil, err
+	}
+
+	hints := make(map[string]string, len(acls))
+	for _, acl := range acls {
+		// OVN uses the first 8 characters of the ACL UUID as the stage hint.
+		if len(acl.UUID) < 8 {
+			continue
+		}
+
+		hints[acl.UUID[:8]] = acl.ExternalIDs[ovnExtIDIncusACLRule]
+	}
+
+	return hints, nil
+}
+
 // aclRuleDeleteOperations returns the operations that delete the provided ACL rules from the specified OVN entity.
 func (o *NB) aclRuleDeleteOperations(ctx context.Context, entityTable string, entityName string, aclRuleUUIDs []string) ([]ovsdb.Operation, error) {
 	operations := []ovsdb.Operation{}
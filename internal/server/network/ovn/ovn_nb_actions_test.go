@@ -0,0 +1,40 @@
+package ovn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseQoSRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		rate  int
+		err   bool
+	}{
+		{name: "Kilobits", value: "1kbit", rate: 1},
+		{name: "Megabits", value: "10Mbit", rate: 10000},
+		{name: "Gigabits", value: "2Gbit", rate: 2000000},
+		{name: "Bits rounded down", value: "1500bit", rate: 1},
+		{name: "Bits without suffix", value: "64000", rate: 64},
+		{name: "Below 1kbit", value: "999bit", err: true},
+		{name: "Zero", value: "0", err: true},
+		{name: "Empty", value: "", err: true},
+		{name: "Invalid suffix", value: "10MB", err: true},
+		{name: "Not a number", value: "fast", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := ParseQoSRate(tt.value)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.rate, rate)
+		})
+	}
+}
@@ -24,6 +24,12 @@ import (
 type SB struct {
 	client ovsdbClient.Client
 	cookie ovsdbClient.MonitorCookie
+
+	// For external tools.
+	dbAddr        string
+	sslCACert     string
+	sslClientCert string
+	sslClientKey  string
 }
 
 // NewSB initializes new OVN client for Southbound operations.
@@ -191,8 +197,12 @@ func NewSB(dbAddr string, sslCACert string, sslClientCert string, sslClientKey s
 
 	// Create the SB struct.
 	client := &SB{
-		client: ovn,
-		cookie: monitorCookie,
+		client:        ovn,
+		cookie:        monitorCookie,
+		dbAddr:        dbAddr,
+		sslCACert:     sslCACert,
+		sslClientCert: sslClientCert,
+		sslClientKey:  sslClientKey,
 	}
 
 	// Set finalizer to stop the monitor.
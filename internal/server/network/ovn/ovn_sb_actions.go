@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/ovn-org/libovsdb/ovsdb"
+
 	ovnNB "github.com/lxc/incus/v6/internal/server/network/ovn/schema/ovn-nb"
 	ovnSB "github.com/lxc/incus/v6/internal/server/network/ovn/schema/ovn-sb"
+	"github.com/lxc/incus/v6/shared/subprocess"
 )
 
 // GetLogicalRouterPortActiveChassisHostname gets the hostname of the chassis managing the logical router port.
@@ -96,3 +101,99 @@ func (o *SB) CheckLoadBalancerOnline(ctx context.Context, lb ovnNB.LoadBalancer)
 
 	return false, nil
 }
+
+// GetLogicalFlowCookies returns the OpenFlow cookies of the logical flows generated from the provided stage hints.
+// The result maps the cookie (first 32 bits of the logical flow UUID) to the stage hint.
+func (o *SB) GetLogicalFlowCookies(ctx context.Context, stageHints []string) (map[uint64]string, error) {
+	cookies := map[uint64]string{}
+	if len(stageHints) == 0 {
+		return cookies, nil
+	}
+
+	// The Logical_Flow table isn't cached, so query it directly.
+	operations := make([]ovsdb.Operation, 0, len(stageHints))
+	for _, stageHint := range stageHints {
+		operations = append(operations, ovsdb.Operation{
+			Op:    ovsdb.OperationSelect,
+			Table: ovnSB.LogicalFlowTable,
+			Where: []ovsdb.Condition{{
+				Column:   "external_ids",
+				Function: ovsdb.ConditionIncludes,
+				Value:    ovsdb.OvsMap{GoMap: map[any]any{"stage-hint": stageHint}},
+			}},
+			Columns: []string{"_uuid"},
+		})
+	}
+
+	resp, err := o.client.Transact(ctx, operations...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ovsdb.CheckOperationResults(resp, operations)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range resp {
+		for _, row := range result.Rows {
+			uuid, ok := row["_uuid"].(ovsdb.UUID)
+			if !ok || len(uuid.GoUUID) < 8 {
+				continue
+			}
+
+			cookie, err := strconv.ParseUint(uuid.GoUUID[:8], 16, 32)
+			if err != nil {
+				continue
+			}
+
+			cookies[cookie] = stageHints[i]
+		}
+	}
+
+	return cookies, nil
+}
+
+// Trace simulates a packet matching the microflow entering the logical datapath using ovn-trace and returns the output.
+func (o *SB) Trace(ctx context.Context, datapath OVNSwitch, microflow string) (string, error) {
+	args := []string{"--db", o.dbAddr}
+
+	// Pass the SSL credentials to ovn-trace.
+	if strings.Contains(o.dbAddr, "ssl:") {
+		tmpDir, err := os.MkdirTemp("", "incus_ovn_trace_")
+		if err != nil {
+			return "", err
+		}
+
+		defer func() { _ = os.RemoveAll(tmpDir) }()
+
+		files := map[string]string{"key": o.sslClientKey, "cert": o.sslClientCert, "ca": o.sslCACert}
+		for name, content := range files {
+			if content == "" {
+				continue
+			}
+
+			err = os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		args = append(args, "-p", filepath.Join(tmpDir, "key"), "-c", filepath.Join(tmpDir, "cert"))
+
+		if o.sslCACert != "" {
+			args = append(args, "-C", filepath.Join(tmpDir, "ca"))
+		} else {
+			args = append(args, "-C", "none")
+		}
+	}
+
+	args = append(args, string(datapath), microflow)
+
+	output, err := subprocess.RunCommandContext(ctx, "ovn-trace", args...)
+	if err != nil {
+		return "", fmt.Errorf("Failed running ovn-trace: %w", err)
+	}
+
+	return output, nil
+}
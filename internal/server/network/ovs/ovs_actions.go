@@ -16,6 +16,7 @@ import (
 
 	"github.com/lxc/incus/v6/internal/server/ip"
 	ovsSwitch "github.com/lxc/incus/v6/internal/server/network/ovs/schema/ovs"
+	"github.com/lxc/incus/v6/shared/subprocess"
 	"github.com/lxc/incus/v6/shared/util"
 )
 
@@ -728,3 +729,19 @@ func (o *VSwitch) GetOVNSouthboundDBRemoteAddress(ctx context.Context) (string,
 
 	return val, nil
 }
+
+// OVSFlowCounters represents the hit counters of OpenFlow flows.
+type OVSFlowCounters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// GetBridgeFlowCounters returns the hit counters of the flows on a bridge, summed by flow cookie.
+func (o *VSwitch) GetBridgeFlowCounters(ctx context.Context, bridgeName string) (map[uint64]OVSFlowCounters, error) {
+	output, err := subprocess.RunCommandContext(ctx, "ovs-ofctl", "dump-flows", bridgeName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed dumping flows of bridge %q: %w", bridgeName, err)
+	}
+
+	return parseFlowCounters(output), nil
+}
@@ -14,3 +14,42 @@ func unquote(s string) (string, error) {
 
 	return s, nil
 }
+
+// parseFlowCounters parses the output of ovs-ofctl dump-flows and returns the flow counters summed by cookie.
+func parseFlowCounters(output string) map[uint64]OVSFlowCounters {
+	counters := map[uint64]OVSFlowCounters{}
+
+	for _, line := range strings.Split(output, "\n") {
+		var cookie, packets, bytes uint64
+		var err error
+
+		hasCookie := false
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' }) {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			switch key {
+			case "cookie":
+				cookie, err = strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 64)
+				hasCookie = err == nil
+			case "n_packets":
+				packets, _ = strconv.ParseUint(value, 10, 64)
+			case "n_bytes":
+				bytes, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+
+		if !hasCookie {
+			continue
+		}
+
+		counter := counters[cookie]
+		counter.Packets += packets
+		counter.Bytes += bytes
+		counters[cookie] = counter
+	}
+
+	return counters
+}
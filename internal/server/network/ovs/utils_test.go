@@ -0,0 +1,20 @@
+package ovs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseFlowCounters(t *testing.T) {
+	output := `NXST_FLOW reply (xid=0x4):
+ cookie=0x5f1a2b3c, duration=12.345s, table=44, n_packets=10, n_bytes=980, idle_age=3, priority=2002,ip,reg0=0x80/0x80,metadata=0x1 actions=load:0x1->NXM_NX_XXREG0[97],resubmit(,45)
+ cookie=0x5f1a2b3c, duration=12.345s, table=44, n_packets=2, n_bytes=20, idle_age=3, priority=2002,ipv6,metadata=0x1 actions=resubmit(,45)
+ cookie=0xa1b2, duration=1.000s, table=10, n_packets=0, n_bytes=0, priority=100 actions=drop
+`
+
+	assert.Equal(t, map[uint64]OVSFlowCounters{
+		0x5f1a2b3c: {Packets: 12, Bytes: 1000},
+		0xa1b2:     {Packets: 0, Bytes: 0},
+	}, parseFlowCounters(output))
+}
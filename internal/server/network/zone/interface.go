@@ -3,6 +3,8 @@ package zone
 import (
 	"strings"
 
+	"github.com/miekg/dns"
+
 	"github.com/lxc/incus/v6/internal/server/cluster/request"
 	"github.com/lxc/incus/v6/internal/server/state"
 	"github.com/lxc/incus/v6/shared/api"
@@ -28,6 +30,7 @@ type NetworkZone interface {
 	GetRecord(name string) (*api.NetworkZoneRecord, error)
 	UpdateRecord(name string, req api.NetworkZoneRecordPut, clientType request.ClientType) error
 	DeleteRecord(name string) error
+	ApplyUpdate(updates []dns.RR) error
 
 	// Internal validation.
 	validateName(name string) error
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"strings"
 
 	"github.com/miekg/dns"
 
@@ -16,7 +17,7 @@ import (
 
 func (d *zone) AddRecord(req api.NetworkZoneRecordsPost) error {
 	// Validate.
-	err := d.validateName(req.Name)
+	err := d.validateRecordName(req.Name)
 	if err != nil {
 		return err
 	}
@@ -224,6 +225,184 @@ func (d *zone) DeleteRecord(name string) error {
 	return nil
 }
 
+// ApplyUpdate applies the update section of a dynamic update (RFC 2136) to the zone records.
+// Records generated from the networks can't be modified this way, only the custom records are affected.
+func (d *zone) ApplyUpdate(updates []dns.RR) error {
+	return d.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		zoneID := int(d.id)
+		filter := dbCluster.NetworkZoneRecordFilter{
+			NetworkZoneID: &zoneID,
+		}
+
+		dbRecords, err := dbCluster.GetNetworkZoneRecords(ctx, tx.Tx(), filter)
+		if err != nil {
+			return err
+		}
+
+		records := map[string]*dbCluster.NetworkZoneRecord{}
+		for i := range dbRecords {
+			records[strings.ToLower(dbRecords[i].Name)] = &dbRecords[i]
+		}
+
+		modified, err := d.applyUpdates(records, updates)
+		if err != nil {
+			return err
+		}
+
+		// Store the modified records.
+		for _, name := range modified {
+			record := records[name]
+
+			if record.ID == 0 {
+				if len(record.Entries) == 0 {
+					continue
+				}
+
+				id, err := dbCluster.CreateNetworkZoneRecord(ctx, tx.Tx(), *record)
+				if err != nil {
+					return err
+				}
+
+				err = dbCluster.CreateNetworkZoneRecordConfig(ctx, tx.Tx(), id, nil)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if len(record.Entries) == 0 {
+				err = dbCluster.DeleteNetworkZoneRecord(ctx, tx.Tx(), zoneID, record.ID)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			err = dbCluster.UpdateNetworkZoneRecord(ctx, tx.Tx(), zoneID, record.Name, *record)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// applyUpdates applies the update section of a dynamic update to the zone records, indexed by lowercase name.
+// New records are added to the map and the sorted names of the modified records are returned.
+func (d *zone) applyUpdates(records map[string]*dbCluster.NetworkZoneRecord, updates []dns.RR) ([]string, error) {
+	zoneSuffix := "." + d.info.Name + "."
+	modified := []string{}
+
+	for _, rr := range updates {
+		hdr := rr.Header()
+
+		// Get the record name relative to the zone.
+		fqdn := strings.ToLower(hdr.Name)
+		if fqdn == d.info.Name+"." {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "Updates to the zone apex aren't supported")
+		}
+
+		name := strings.TrimSuffix(fqdn, zoneSuffix)
+		if name == fqdn {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "Record %q isn't part of the zone", hdr.Name)
+		}
+
+		record := records[name]
+
+		switch hdr.Class {
+		case dns.ClassINET:
+			// Add to an RRset.
+			if record == nil {
+				err := d.validateRecordName(name)
+				if err != nil {
+					return nil, api.StatusErrorf(http.StatusBadRequest, "Invalid record name %q: %v", name, err)
+				}
+
+				record = &dbCluster.NetworkZoneRecord{NetworkZoneID: int(d.id), Name: name}
+				records[name] = record
+			}
+
+			// An existing RR is replaced by the update RR, only its TTL can differ (RFC 2136 section 3.4.2.2).
+			idx := slices.IndexFunc(record.Entries, func(entry api.NetworkZoneRecordEntry) bool { return entryMatches(entry, rr) })
+			if idx >= 0 {
+				if record.Entries[idx].TTL == uint64(hdr.Ttl) {
+					continue
+				}
+
+				record.Entries[idx].TTL = uint64(hdr.Ttl)
+			} else {
+				record.Entries = append(record.Entries, api.NetworkZoneRecordEntry{
+					Type:  dns.TypeToString[hdr.Rrtype],
+					TTL:   uint64(hdr.Ttl),
+					Value: strings.TrimPrefix(rr.String(), hdr.String()),
+				})
+			}
+
+		case dns.ClassANY:
+			// Delete an RRset or all RRsets from a name.
+			if record == nil {
+				continue
+			}
+
+			count := len(record.Entries)
+			record.Entries = slices.DeleteFunc(record.Entries, func(entry api.NetworkZoneRecordEntry) bool {
+				return hdr.Rrtype == dns.TypeANY || entry.Type == dns.TypeToString[hdr.Rrtype]
+			})
+
+			if len(record.Entries) == count {
+				continue
+			}
+
+		case dns.ClassNONE:
+			// Delete an RR from an RRset.
+			if record == nil {
+				continue
+			}
+
+			count := len(record.Entries)
+			record.Entries = slices.DeleteFunc(record.Entries, func(entry api.NetworkZoneRecordEntry) bool {
+				return entryMatches(entry, rr)
+			})
+
+			if len(record.Entries) == count {
+				continue
+			}
+		}
+
+		if !slices.Contains(modified, name) {
+			modified = append(modified, name)
+		}
+	}
+
+	slices.Sort(modified)
+
+	for _, name := range modified {
+		err := d.validateEntries(api.NetworkZoneRecordPut{Entries: records[name].Entries})
+		if err != nil {
+			return nil, api.StatusErrorf(http.StatusBadRequest, "Invalid record %q: %v", name, err)
+		}
+	}
+
+	return modified, nil
+}
+
+// entryMatches checks whether a zone record entry has the same type and data as the DNS record.
+func entryMatches(entry api.NetworkZoneRecordEntry, rr dns.RR) bool {
+	entryRR, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", rr.Header().Name, entry.TTL, entry.Type, entry.Value))
+	if err != nil || entryRR == nil {
+		return false
+	}
+
+	// Compare the record data, ignoring the class of deletion requests.
+	rrCopy := dns.Copy(rr)
+	rrCopy.Header().Class = dns.ClassINET
+
+	return dns.IsDuplicate(entryRR, rrCopy)
+}
+
 // validateRecordConfig checks the config and rules are valid.
 func (d *zone) validateRecordConfig(info api.NetworkZoneRecordPut) error {
 	rules := map[string]func(value string) error{}
@@ -0,0 +1,178 @@
+package zone
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+
+	dbCluster "github.com/lxc/incus/v6/internal/server/db/cluster"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+func Test_applyUpdates(t *testing.T) {
+	newRR := func(s string, class uint16) dns.RR {
+		rr, err := dns.NewRR(s)
+		require.NoError(t, err)
+
+		rr.Header().Class = class
+		if class != dns.ClassINET {
+			rr.Header().Ttl = 0
+		}
+
+		return rr
+	}
+
+	newRRset := func(name string, rrtype uint16, class uint16) dns.RR {
+		return &dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: rrtype, Class: class}}
+	}
+
+	testRecords := func() map[string]*dbCluster.NetworkZoneRecord {
+		return map[string]*dbCluster.NetworkZoneRecord{
+			"www": {ID: 1, Name: "www", Entries: []api.NetworkZoneRecordEntry{
+				{Type: "A", TTL: 300, Value: "10.0.0.1"},
+				{Type: "A", TTL: 300, Value: "10.0.0.2"},
+				{Type: "AAAA", TTL: 300, Value: "fd00::1"},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		updates  []dns.RR
+		modified []string
+		entries  map[string][]string
+		ttls     map[string][]uint64
+		err      bool
+	}{
+		{
+			name:     "Add a record to an existing name",
+			updates:  []dns.RR{newRR("www.example.com. 300 IN A 10.0.0.3", dns.ClassINET)},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "A 10.0.0.2", "AAAA fd00::1", "A 10.0.0.3"}},
+		},
+		{
+			name:     "Add an existing record",
+			updates:  []dns.RR{newRR("WWW.example.com. 300 IN A 10.0.0.1", dns.ClassINET)},
+			modified: []string{},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "A 10.0.0.2", "AAAA fd00::1"}},
+		},
+		{
+			name:     "Add an existing record with a new TTL",
+			updates:  []dns.RR{newRR("www.example.com. 60 IN A 10.0.0.2", dns.ClassINET)},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "A 10.0.0.2", "AAAA fd00::1"}},
+			ttls:     map[string][]uint64{"www": {300, 60, 300}},
+		},
+		{
+			name:     "Add a record to a new underscored name",
+			updates:  []dns.RR{newRR("_acme-challenge.example.com. 60 IN TXT token", dns.ClassINET)},
+			modified: []string{"_acme-challenge"},
+			entries:  map[string][]string{"_acme-challenge": {"TXT \"token\""}},
+		},
+		{
+			name:     "Add a record to a new SRV name",
+			updates:  []dns.RR{newRR("_sip._tcp.example.com. 60 IN SRV 10 5 5060 sip.example.com.", dns.ClassINET)},
+			modified: []string{"_sip._tcp"},
+			entries:  map[string][]string{"_sip._tcp": {"SRV 10 5 5060 sip.example.com."}},
+		},
+		{
+			name:    "Add a record with an empty label",
+			updates: []dns.RR{newRR("_.example.com. 300 IN A 10.0.0.1", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:     "Delete an RRset (class ANY)",
+			updates:  []dns.RR{newRRset("www.example.com.", dns.TypeA, dns.ClassANY)},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {"AAAA fd00::1"}},
+		},
+		{
+			name:     "Delete all RRsets of a name (class ANY, type ANY)",
+			updates:  []dns.RR{newRRset("www.example.com.", dns.TypeANY, dns.ClassANY)},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {}},
+		},
+		{
+			name:     "Delete a record (class NONE)",
+			updates:  []dns.RR{newRR("www.example.com. A 10.0.0.2", dns.ClassNONE)},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "AAAA fd00::1"}},
+		},
+		{
+			name:     "Delete a missing record (class NONE)",
+			updates:  []dns.RR{newRR("www.example.com. A 10.0.0.9", dns.ClassNONE)},
+			modified: []string{},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "A 10.0.0.2", "AAAA fd00::1"}},
+		},
+		{
+			name:     "Delete a missing RRset (class ANY)",
+			updates:  []dns.RR{newRRset("www.example.com.", dns.TypeTXT, dns.ClassANY)},
+			modified: []string{},
+			entries:  map[string][]string{"www": {"A 10.0.0.1", "A 10.0.0.2", "AAAA fd00::1"}},
+		},
+		{
+			name:     "Delete from a missing name",
+			updates:  []dns.RR{newRRset("missing.example.com.", dns.TypeANY, dns.ClassANY), newRR("missing.example.com. A 10.0.0.1", dns.ClassNONE)},
+			modified: []string{},
+		},
+		{
+			name: "Replace an RRset",
+			updates: []dns.RR{
+				newRRset("www.example.com.", dns.TypeA, dns.ClassANY),
+				newRR("www.example.com. 60 IN A 10.0.0.5", dns.ClassINET),
+			},
+			modified: []string{"www"},
+			entries:  map[string][]string{"www": {"AAAA fd00::1", "A 10.0.0.5"}},
+		},
+		{
+			name:    "Update the zone apex",
+			updates: []dns.RR{newRR("example.com. 300 IN A 10.0.0.1", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:    "Update outside of the zone",
+			updates: []dns.RR{newRR("www.example.org. 300 IN A 10.0.0.1", dns.ClassINET)},
+			err:     true,
+		},
+		{
+			name:    "Add a record with an invalid name",
+			updates: []dns.RR{newRR("-bad.example.com. 300 IN A 10.0.0.1", dns.ClassINET)},
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &zone{info: &api.NetworkZone{Name: "example.com"}}
+			records := testRecords()
+
+			modified, err := d.applyUpdates(records, tt.updates)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.modified, modified)
+
+			for name, expected := range tt.entries {
+				entries := []string{}
+				for _, entry := range records[name].Entries {
+					entries = append(entries, entry.Type+" "+entry.Value)
+				}
+
+				require.Equal(t, expected, entries)
+			}
+
+			for name, expected := range tt.ttls {
+				ttls := []uint64{}
+				for _, entry := range records[name].Entries {
+					ttls = append(ttls, entry.TTL)
+				}
+
+				require.Equal(t, expected, ttls)
+			}
+		})
+	}
+}
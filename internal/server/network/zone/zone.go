@@ -154,6 +154,20 @@ func (d *zone) validateName(name string) error {
 	return validate.IsAPIName(name, false)
 }
 
+// validateRecordName checks a record name is valid.
+// Underscored labels (RFC 8552) are allowed as used for ACME challenges or SRV records.
+func (d *zone) validateRecordName(name string) error {
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		labels[i] = strings.TrimPrefix(label, "_")
+		if labels[i] == "" {
+			return errors.New("Name cannot contain empty labels")
+		}
+	}
+
+	return validate.IsAPIName(strings.Join(labels, "."), false)
+}
+
 // validateConfig checks the config and rules are valid.
 func (d *zone) validateConfig(info *api.NetworkZonePut) error {
 	rules := map[string]func(value string) error{}
@@ -209,6 +223,19 @@ func (d *zone) validateConfig(info *api.NetworkZonePut) error {
 			//  required: no
 			//  shortdesc: TSIG key for the server
 			rules[k] = validate.Optional(validate.IsAny)
+		case "update":
+			// gendoc:generate(entity=network_zone, group=common, key=peers.NAME.update)
+			//
+			// ---
+			//  type: bool
+			//  required: no
+			//  defaultdesc: `false`
+			//  shortdesc: Whether the server may send dynamic updates (RFC 2136), requires `peers.NAME.key`
+			rules[k] = validate.Optional(validate.IsBool)
+
+			if util.IsTrue(info.Config[k]) && info.Config[strings.TrimSuffix(k, "update")+"key"] == "" {
+				return fmt.Errorf("Dynamic updates for peer %q require a TSIG key", fields[1])
+			}
 		}
 	}
 
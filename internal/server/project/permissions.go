@@ -1648,6 +1648,22 @@ func AllowSnapshotCreation(p *api.Project) error {
 	return nil
 }
 
+// AllowNetworkPeering returns an error if the given project isn't allowed to peer its networks
+// with networks in the target project.
+func AllowNetworkPeering(p *api.Project, targetProject string) error {
+	// Peering within the same project is always allowed.
+	if p.Name == targetProject || util.IsFalseOrEmpty(p.Config["restricted"]) {
+		return nil
+	}
+
+	allowedProjects := util.SplitNTrimSpace(p.Config["restricted.networks.peers"], ",", -1, true)
+	if !slices.Contains(allowedProjects, targetProject) {
+		return api.StatusErrorf(http.StatusForbidden, "Project %q isn't allowed to peer with networks in project %q", p.Name, targetProject)
+	}
+
+	return nil
+}
+
 // GetRestrictedClusterGroups returns a slice of restricted cluster groups for the given project.
 func GetRestrictedClusterGroups(p *api.Project) []string {
 	return util.SplitNTrimSpace(p.Config["restricted.cluster.groups"], ",", -1, true)
@@ -249,3 +249,24 @@ func TestCheckClusterTargetRestriction_RestrictedFalse(t *testing.T) {
 	err = project.CheckClusterTargetRestriction(authorizer, req, p, "n1")
 	assert.NoError(t, err)
 }
+
+// Cross-project peering is only allowed with the projects listed in restricted.networks.peers.
+func TestAllowNetworkPeering(t *testing.T) {
+	p := &api.Project{
+		Name: "p1",
+		ProjectPut: api.ProjectPut{
+			Config: map[string]string{"restricted": "true"},
+		},
+	}
+
+	assert.NoError(t, project.AllowNetworkPeering(p, "p1"))
+	assert.EqualError(t, project.AllowNetworkPeering(p, "p2"), `Project "p1" isn't allowed to peer with networks in project "p2"`)
+
+	p.Config["restricted.networks.peers"] = "p2, p3"
+	assert.NoError(t, project.AllowNetworkPeering(p, "p2"))
+	assert.NoError(t, project.AllowNetworkPeering(p, "p3"))
+	assert.Error(t, project.AllowNetworkPeering(p, "p4"))
+
+	p.Config["restricted"] = "false"
+	assert.NoError(t, project.AllowNetworkPeering(p, "p4"))
+}
@@ -0,0 +1,163 @@
+package syslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/incus/v6/internal/server/events"
+	"github.com/lxc/incus/v6/shared/api"
+)
+
+// kernelACLLogName matches the log prefix of the network ACL rules of bridge networks.
+var kernelACLLogName = regexp.MustCompile(`^\S+-(ingress|egress)(-[0-9]+)?$`)
+
+// ListenKernel starts the kernel log monitor.
+// The entries logged by the network ACL rules of bridge networks are sent as network-acl events.
+func ListenKernel(ctx context.Context, eventServer *events.Server) error {
+	kmsg, err := os.Open("/dev/kmsg")
+	if err != nil {
+		return fmt.Errorf("Failed opening kernel log: %w", err)
+	}
+
+	// Only follow the new entries.
+	_, err = kmsg.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = kmsg.Close()
+		return fmt.Errorf("Failed seeking to the end of the kernel log: %w", err)
+	}
+
+	// This goroutine waits for the context to be cancelled and then closes the kernel log causing `Read` to return an error and exit the goroutine below.
+	go func() {
+		<-ctx.Done()
+		_ = kmsg.Close()
+	}()
+
+	go func() {
+		// Each read returns a single entry.
+		buf := make([]byte, 8192)
+
+		for {
+			n, err := kmsg.Read(buf)
+			if err != nil {
+				// Entries were overwritten before being read, carry on with the next ones.
+				if errors.Is(err, unix.EPIPE) {
+					continue
+				}
+
+				return
+			}
+
+			event, ok := parseKernelACLLogEntry(string(buf[:n]))
+			if !ok {
+				continue
+			}
+
+			err = eventServer.Send("", api.EventTypeNetworkACL, event)
+			if err != nil {
+				continue
+			}
+		}
+	}()
+
+	return nil
+}
+
+// parseKernelACLLogEntry converts a kernel log entry written by a network ACL rule of a bridge network into an event.
+//
+// The entry looks like:
+// 4,1234,5678901234,-;incus_acl1-ingress-0 verdict=drop IN=incusbr0 OUT=veth1234 MAC=... SRC=10.0.0.2 DST=10.0.0.3 LEN=60 ... PROTO=TCP SPT=4242 DPT=22 ...
+func parseKernelACLLogEntry(entry string) (api.EventLogging, bool) {
+	header, message, found := strings.Cut(entry, ";")
+	if !found {
+		return api.EventLogging{}, false
+	}
+
+	// Only keep the first line, the others hold the device metadata.
+	message, _, _ = strings.Cut(message, "\n")
+
+	headerFields := strings.Split(header, ",")
+	if len(headerFields) < 2 {
+		return api.EventLogging{}, false
+	}
+
+	priority, err := strconv.Atoi(headerFields[0])
+	if err != nil {
+		return api.EventLogging{}, false
+	}
+
+	fields := strings.Fields(message)
+	if len(fields) == 0 {
+		return api.EventLogging{}, false
+	}
+
+	nameMatch := kernelACLLogName.FindStringSubmatch(fields[0])
+	if nameMatch == nil {
+		return api.EventLogging{}, false
+	}
+
+	values := map[string]string{}
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(field, "=")
+		if found {
+			values[key] = value
+		}
+	}
+
+	// Netfilter log entries always include the input interface.
+	_, found = values["IN"]
+	if !found {
+		return api.EventLogging{}, false
+	}
+
+	// Use the same protocol names as OVN.
+	protocol := strings.ToLower(values["PROTO"])
+	if protocol == "icmpv6" {
+		protocol = "icmp6"
+	}
+
+	eventContext := map[string]string{
+		"name":             fields[0],
+		"verdict":          values["verdict"],
+		"direction":        nameMatch[1],
+		"protocol":         protocol,
+		"source":           values["SRC"],
+		"destination":      values["DST"],
+		"source_port":      values["SPT"],
+		"destination_port": values["DPT"],
+		"icmp_type":        values["TYPE"],
+		"icmp_code":        values["CODE"],
+	}
+
+	// Only keep the fields that are set.
+	maps.DeleteFunc(eventContext, func(_ string, value string) bool { return value == "" })
+
+	eventContext["sequence"] = headerFields[1]
+	eventContext["application"] = "kernel"
+
+	// This maps the kernel log levels to logrus log levels.
+	level := logrus.ErrorLevel
+	switch priority & 7 {
+	case 4:
+		level = logrus.WarnLevel
+	case 5, 6:
+		level = logrus.InfoLevel
+	case 7:
+		level = logrus.DebugLevel
+	}
+
+	return api.EventLogging{
+		Level:   level.String(),
+		Message: message,
+		Context: eventContext,
+	}, true
+}
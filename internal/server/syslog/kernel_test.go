@@ -0,0 +1,73 @@
+package syslog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseKernelACLLogEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		level    string
+		expected map[string]string
+	}{
+		{
+			name:  "TCP over IPv4",
+			entry: "4,1234,5678901234,-;incus_acl1-ingress-0 verdict=drop IN=incusbr0 OUT=veth1234 MAC=00:16:3e:00:00:01:00:16:3e:00:00:02:08:00 SRC=10.0.0.2 DST=10.0.0.3 LEN=60 TOS=0x00 PREC=0x00 TTL=64 ID=1234 DF PROTO=TCP SPT=4242 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0 \n SUBSYSTEM=net\n",
+			level: "warning",
+			expected: map[string]string{
+				"name":             "incus_acl1-ingress-0",
+				"verdict":          "drop",
+				"direction":        "ingress",
+				"protocol":         "tcp",
+				"source":           "10.0.0.2",
+				"destination":      "10.0.0.3",
+				"source_port":      "4242",
+				"destination_port": "22",
+				"sequence":         "1234",
+				"application":      "kernel",
+			},
+		},
+		{
+			name:  "ICMPv6 default rule",
+			entry: "6,1235,5678901235,-;incusbr0-egress verdict=reject IN=incusbr0 OUT=eth0 SRC=fd42::2 DST=fd42::3 LEN=104 TC=0 HOPLIMIT=64 FLOWLBL=0 PROTO=ICMPv6 TYPE=128 CODE=0 ID=1 SEQ=1 \n",
+			level: "info",
+			expected: map[string]string{
+				"name":        "incusbr0-egress",
+				"verdict":     "reject",
+				"direction":   "egress",
+				"protocol":    "icmp6",
+				"source":      "fd42::2",
+				"destination": "fd42::3",
+				"icmp_type":   "128",
+				"icmp_code":   "0",
+				"sequence":    "1235",
+				"application": "kernel",
+			},
+		},
+		{
+			name:  "Unrelated message",
+			entry: "6,1236,5678901236,-;incusbr0: port 1(veth1234) entered forwarding state\n",
+		},
+		{
+			name:  "Other netfilter log",
+			entry: "4,1237,5678901237,-;IN=eth0 OUT= SRC=192.0.2.1 DST=192.0.2.2 PROTO=TCP SPT=1 DPT=2 \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok := parseKernelACLLogEntry(tt.entry)
+			if tt.expected == nil {
+				assert.False(t, ok)
+				return
+			}
+
+			assert.True(t, ok)
+			assert.Equal(t, tt.level, event.Level)
+			assert.Equal(t, tt.expected, event.Context)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package syslog
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"strings"
@@ -133,6 +134,9 @@ func Listen(ctx context.Context, eventServer *events.Server) error {
 				event.Context["application"] = applicationName
 			}
 
+			// Add the flow details as structured fields.
+			maps.Copy(event.Context, parseACLLogMessage(message))
+
 			err = eventServer.Send("", api.EventTypeNetworkACL, event)
 			if err != nil {
 				continue
@@ -144,3 +148,49 @@ func Listen(ctx context.Context, eventServer *events.Server) error {
 
 	return nil
 }
+
+// parseACLLogMessage extracts the flow details from an OVN ACL log message.
+//
+// The message looks like:
+// name="incus_acl1-ingress-0", verdict=allow, severity=info, direction=to-lport: tcp,vlan_tci=0x0000,...,nw_src=10.0.0.2,nw_dst=10.0.0.3,...,tp_src=4242,tp_dst=22,...
+func parseACLLogMessage(message string) map[string]string {
+	entry := map[string]string{}
+	for _, field := range util.SplitNTrimSpace(message, ",", -1, true) {
+		key, value, found := strings.Cut(field, "=")
+		if !found {
+			continue
+		}
+
+		entry[strings.Trim(key, "\"")] = strings.Trim(strings.TrimSpace(value), "\"")
+	}
+
+	// The direction field also holds the protocol.
+	direction, protocol, _ := strings.Cut(entry["direction"], ": ")
+
+	// Map the OVN fields to the event context.
+	fields := map[string]string{
+		"name":             entry["name"],
+		"verdict":          entry["verdict"],
+		"direction":        direction,
+		"protocol":         protocol,
+		"source":           entry["nw_src"],
+		"destination":      entry["nw_dst"],
+		"source_port":      entry["tp_src"],
+		"destination_port": entry["tp_dst"],
+		"icmp_type":        entry["icmp_type"],
+		"icmp_code":        entry["icmp_code"],
+	}
+
+	if fields["source"] == "" {
+		fields["source"] = entry["ipv6_src"]
+	}
+
+	if fields["destination"] == "" {
+		fields["destination"] = entry["ipv6_dst"]
+	}
+
+	// Only keep the fields that are set.
+	maps.DeleteFunc(fields, func(_ string, value string) bool { return value == "" })
+
+	return fields
+}
@@ -0,0 +1,55 @@
+package syslog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseACLLogMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected map[string]string
+	}{
+		{
+			name:    "TCP over IPv4",
+			message: `name="incus_acl1-ingress-0", verdict=allow, severity=info, direction=to-lport: tcp,vlan_tci=0x0000,dl_src=00:16:3e:00:00:01,dl_dst=00:16:3e:00:00:02,nw_src=10.0.0.2,nw_dst=10.0.0.3,nw_tos=0,nw_ecn=0,nw_ttl=64,tp_src=4242,tp_dst=22,tcp_flags=syn`,
+			expected: map[string]string{
+				"name":             "incus_acl1-ingress-0",
+				"verdict":          "allow",
+				"direction":        "to-lport",
+				"protocol":         "tcp",
+				"source":           "10.0.0.2",
+				"destination":      "10.0.0.3",
+				"source_port":      "4242",
+				"destination_port": "22",
+			},
+		},
+		{
+			name:    "ICMPv6",
+			message: `name="incus_acl1-egress-3", verdict=drop, severity=info, direction=from-lport: icmp6,vlan_tci=0x0000,dl_src=00:16:3e:00:00:01,dl_dst=00:16:3e:00:00:02,ipv6_src=fd42::2,ipv6_dst=fd42::3,ipv6_label=0x00000,nw_tos=0,nw_ecn=0,nw_ttl=64,icmp_type=128,icmp_code=0`,
+			expected: map[string]string{
+				"name":        "incus_acl1-egress-3",
+				"verdict":     "drop",
+				"direction":   "from-lport",
+				"protocol":    "icmp6",
+				"source":      "fd42::2",
+				"destination": "fd42::3",
+				"icmp_type":   "128",
+				"icmp_code":   "0",
+			},
+		},
+		{
+			name:     "Unrelated message",
+			message:  "unix:/run/openvswitch/br-int.mgmt: connected",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseACLLogMessage(tt.message))
+		})
+	}
+}
@@ -0,0 +1,452 @@
+// Package tftp implements a minimal read-only TFTP server (RFC 1350) used for network booting.
+// The block size, timeout and transfer size options (RFC 2347, 2348 and 2349) are supported.
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// Port is the standard TFTP port.
+const Port = 69
+
+// TFTP opcodes.
+const (
+	opRRQ   = 1
+	opWRQ   = 2
+	opDATA  = 3
+	opACK   = 4
+	opERROR = 5
+	opOACK  = 6
+)
+
+// TFTP error codes.
+const (
+	errNotDefined       = 0
+	errFileNotFound     = 1
+	errAccessViolation  = 2
+	errIllegalOperation = 4
+	errUnknownTID       = 5
+)
+
+const (
+	defaultBlockSize = 512
+	minBlockSize     = 8
+	maxBlockSize     = 65464
+	defaultTimeout   = 2 * time.Second
+	maxRetries       = 5
+)
+
+// Server represents a built-in TFTP server for a network.
+type Server struct {
+	root   string
+	logger logger.Logger
+
+	conns []*net.UDPConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var servers = map[string]*Server{}
+var serversMu sync.Mutex
+
+// Start starts (or restarts) the TFTP server for a network, serving the files in root on the given addresses.
+func Start(network string, root string, addresses ...net.IP) error {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	// Stop any existing server.
+	existing := servers[network]
+	if existing != nil {
+		existing.stop()
+		delete(servers, network)
+	}
+
+	listenAddresses := make([]*net.UDPAddr, 0, len(addresses))
+	for _, address := range addresses {
+		listenAddresses = append(listenAddresses, &net.UDPAddr{IP: address, Port: Port})
+	}
+
+	s, err := newServer(root, logger.AddContext(logger.Ctx{"network": network}), listenAddresses...)
+	if err != nil {
+		return err
+	}
+
+	servers[network] = s
+
+	return nil
+}
+
+// Stop stops the TFTP server for a network (if running).
+func Stop(network string) {
+	serversMu.Lock()
+	defer serversMu.Unlock()
+
+	s := servers[network]
+	if s == nil {
+		return
+	}
+
+	s.stop()
+	delete(servers, network)
+}
+
+// newServer starts a server listening on the given addresses.
+func newServer(root string, l logger.Logger, addresses ...*net.UDPAddr) (*Server, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("Failed accessing TFTP root %q: %w", root, err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("TFTP root %q isn't a directory", root)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Server{
+		root:   root,
+		logger: l,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for _, address := range addresses {
+		conn, err := net.ListenUDP("udp", address)
+		if err != nil {
+			s.stop()
+			return nil, fmt.Errorf("Failed starting TFTP server on %q: %w", address.String(), err)
+		}
+
+		s.conns = append(s.conns, conn)
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+
+	return s, nil
+}
+
+// stop stops the server and waits for the transfers to end.
+func (s *Server) stop() {
+	s.cancel()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	s.wg.Wait()
+}
+
+// serve handles the requests received on a listening socket.
+func (s *Server) serve(conn *net.UDPConn) {
+	defer s.wg.Done()
+
+	localAddr, _ := conn.LocalAddr().(*net.UDPAddr)
+	buf := make([]byte, 1500)
+
+	for {
+		n, peer, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			continue
+		}
+
+		packet := bytes.Clone(buf[:n])
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			err := s.handleRequest(localAddr.IP, peer, packet)
+			if err != nil {
+				s.logger.Debug("Failed TFTP transfer", logger.Ctx{"peer": peer.String(), "err": err})
+			}
+		}()
+	}
+}
+
+// request represents a parsed TFTP request.
+type request struct {
+	opcode   uint16
+	filename string
+	mode     string
+	options  map[string]string
+}
+
+// parseRequest parses a read or write request packet.
+func parseRequest(packet []byte) (*request, error) {
+	if len(packet) < 4 {
+		return nil, errors.New("Packet too short")
+	}
+
+	req := &request{
+		opcode:  binary.BigEndian.Uint16(packet),
+		options: map[string]string{},
+	}
+
+	fields := strings.Split(string(packet[2:]), "\x00")
+	if len(fields) < 3 || fields[len(fields)-1] != "" {
+		return nil, errors.New("Malformed request")
+	}
+
+	fields = fields[:len(fields)-1]
+	req.filename = fields[0]
+	req.mode = strings.ToLower(fields[1])
+
+	for i := 2; i+1 < len(fields); i += 2 {
+		req.options[strings.ToLower(fields[i])] = fields[i+1]
+	}
+
+	return req, nil
+}
+
+// handleRequest handles a single transfer from a dedicated socket (the transfer identifier).
+func (s *Server) handleRequest(localIP net.IP, peer *net.UDPAddr, packet []byte) error {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: localIP})
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	// Interrupt the transfer when the server stops.
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-s.ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	req, err := parseRequest(packet)
+	if err != nil {
+		sendError(conn, peer, errIllegalOperation, err.Error())
+		return err
+	}
+
+	if req.opcode == opWRQ {
+		sendError(conn, peer, errAccessViolation, "Server is read-only")
+		return errors.New("Write request refused")
+	}
+
+	if req.opcode != opRRQ {
+		sendError(conn, peer, errIllegalOperation, "Unexpected request")
+		return fmt.Errorf("Unexpected opcode %d", req.opcode)
+	}
+
+	if req.mode != "octet" && req.mode != "netascii" {
+		sendError(conn, peer, errIllegalOperation, "Unsupported transfer mode")
+		return fmt.Errorf("Unsupported transfer mode %q", req.mode)
+	}
+
+	file, size, err := s.open(req.filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			sendError(conn, peer, errFileNotFound, "File not found")
+		} else {
+			sendError(conn, peer, errAccessViolation, "Access denied")
+		}
+
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	s.logger.Debug("Sending file over TFTP", logger.Ctx{"peer": peer.String(), "file": req.filename})
+
+	t := &transfer{conn: conn, peer: peer, blockSize: defaultBlockSize, timeout: defaultTimeout}
+
+	// Negotiate the options.
+	oack := t.negotiate(req.options, size)
+	if len(oack) > 0 {
+		packet := []byte{0, opOACK}
+		for _, option := range []string{"blksize", "timeout", "tsize"} {
+			value, ok := oack[option]
+			if ok {
+				packet = append(packet, []byte(option+"\x00"+value+"\x00")...)
+			}
+		}
+
+		err = t.send(packet, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Send the file content.
+	buf := make([]byte, t.blockSize)
+	block := uint16(1)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			sendError(conn, peer, errNotDefined, "Read error")
+			return err
+		}
+
+		packet := make([]byte, 4, 4+n)
+		binary.BigEndian.PutUint16(packet, opDATA)
+		binary.BigEndian.PutUint16(packet[2:], block)
+		packet = append(packet, buf[:n]...)
+
+		err = t.send(packet, block)
+		if err != nil {
+			return err
+		}
+
+		// A short block ends the transfer.
+		if n < t.blockSize {
+			return nil
+		}
+
+		block++
+	}
+}
+
+// open opens a file from the root directory (without following paths outside of it).
+func (s *Server) open(filename string) (*os.File, int64, error) {
+	// Some clients use Windows style paths.
+	filename = strings.TrimLeft(strings.ReplaceAll(filename, "\\", "/"), "/")
+	if filename == "" {
+		return nil, 0, fs.ErrNotExist
+	}
+
+	file, err := os.OpenInRoot(s.root, filename)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, err
+	}
+
+	if !info.Mode().IsRegular() {
+		_ = file.Close()
+		return nil, 0, fs.ErrPermission
+	}
+
+	return file, info.Size(), nil
+}
+
+// transfer tracks the state of a file transfer.
+type transfer struct {
+	conn      *net.UDPConn
+	peer      *net.UDPAddr
+	blockSize int
+	timeout   time.Duration
+}
+
+// negotiate applies the requested options and returns those acknowledged.
+func (t *transfer) negotiate(options map[string]string, size int64) map[string]string {
+	oack := map[string]string{}
+
+	value, ok := options["blksize"]
+	if ok {
+		blockSize, err := strconv.Atoi(value)
+		if err == nil && blockSize >= minBlockSize {
+			t.blockSize = min(blockSize, maxBlockSize)
+			oack["blksize"] = strconv.Itoa(t.blockSize)
+		}
+	}
+
+	value, ok = options["timeout"]
+	if ok {
+		timeout, err := strconv.Atoi(value)
+		if err == nil && timeout >= 1 && timeout <= 255 {
+			t.timeout = time.Duration(timeout) * time.Second
+			oack["timeout"] = value
+		}
+	}
+
+	_, ok = options["tsize"]
+	if ok {
+		oack["tsize"] = strconv.FormatInt(size, 10)
+	}
+
+	return oack
+}
+
+// send sends a packet and waits for its acknowledgement, retransmitting it on timeout.
+func (t *transfer) send(packet []byte, block uint16) error {
+	buf := make([]byte, 1500)
+
+	for range maxRetries {
+		_, err := t.conn.WriteToUDP(packet, t.peer)
+		if err != nil {
+			return err
+		}
+
+		err = t.conn.SetReadDeadline(time.Now().Add(t.timeout))
+		if err != nil {
+			return err
+		}
+
+		for {
+			n, peer, err := t.conn.ReadFromUDP(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break
+				}
+
+				return err
+			}
+
+			// Reject packets from other transfers.
+			if !peer.IP.Equal(t.peer.IP) || peer.Port != t.peer.Port {
+				sendError(t.conn, peer, errUnknownTID, "Unknown transfer ID")
+				continue
+			}
+
+			if n < 4 {
+				continue
+			}
+
+			switch binary.BigEndian.Uint16(buf) {
+			case opACK:
+				// Ignore duplicate acknowledgements of the previous block.
+				if binary.BigEndian.Uint16(buf[2:]) == block {
+					return nil
+				}
+
+			case opERROR:
+				return fmt.Errorf("Transfer aborted by client: %s", strings.TrimRight(string(buf[4:n]), "\x00"))
+			}
+		}
+	}
+
+	return errors.New("Transfer timed out")
+}
+
+// sendError sends an error packet.
+func sendError(conn *net.UDPConn, peer *net.UDPAddr, code uint16, message string) {
+	packet := make([]byte, 4, 5+len(message))
+	binary.BigEndian.PutUint16(packet, opERROR)
+	binary.BigEndian.PutUint16(packet[2:], code)
+	packet = append(packet, []byte(message+"\x00")...)
+
+	_, _ = conn.WriteToUDP(packet, peer)
+}
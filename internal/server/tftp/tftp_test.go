@@ -0,0 +1,118 @@
+package tftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/incus/v6/shared/logger"
+)
+
+// download fetches a file from the server, returning its content (or the error message sent by the server).
+func download(t *testing.T, server *net.UDPAddr, filename string, options ...string) ([]byte, string) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	defer func() { _ = conn.Close() }()
+
+	packet := []byte{0, opRRQ}
+	packet = append(packet, []byte(strings.Join(append([]string{filename, "octet"}, options...), "\x00")+"\x00")...)
+
+	_, err = conn.WriteToUDP(packet, server)
+	require.NoError(t, err)
+
+	content := []byte{}
+	buf := make([]byte, maxBlockSize+4)
+	blockSize := defaultBlockSize
+
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		n, peer, err := conn.ReadFromUDP(buf)
+		require.NoError(t, err)
+
+		ack := make([]byte, 4)
+		binary.BigEndian.PutUint16(ack, opACK)
+
+		switch binary.BigEndian.Uint16(buf) {
+		case opERROR:
+			return nil, strings.TrimRight(string(buf[4:n]), "\x00")
+
+		case opOACK:
+			fields := strings.Split(string(buf[2:n]), "\x00")
+			for i := 0; i+1 < len(fields); i += 2 {
+				if fields[i] == "blksize" {
+					blockSize, _ = strconv.Atoi(fields[i+1])
+				}
+			}
+
+		case opDATA:
+			copy(ack[2:], buf[2:4])
+			content = append(content, buf[4:n]...)
+
+			if n-4 < blockSize {
+				_, err = conn.WriteToUDP(ack, peer)
+				require.NoError(t, err)
+
+				return content, ""
+			}
+		}
+
+		_, err = conn.WriteToUDP(ack, peer)
+		require.NoError(t, err)
+	}
+}
+
+func Test_Server(t *testing.T) {
+	root := t.TempDir()
+	content := bytes.Repeat([]byte("incus"), 1000)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "pxelinux.cfg"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pxelinux.0"), content, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pxelinux.cfg", "default"), []byte("DEFAULT linux\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(t.TempDir(), "secret"), []byte("secret"), 0o644))
+
+	s, err := newServer(root, logger.AddContext(logger.Ctx{}), &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+
+	defer s.stop()
+
+	addr, ok := s.conns[0].LocalAddr().(*net.UDPAddr)
+	require.True(t, ok)
+
+	// Default block size.
+	data, errMsg := download(t, addr, "pxelinux.0")
+	assert.Empty(t, errMsg)
+	assert.Equal(t, content, data)
+
+	// Negotiated block size with a Windows style path.
+	data, errMsg = download(t, addr, "\\pxelinux.0", "blksize", "1428", "tsize", "0")
+	assert.Empty(t, errMsg)
+	assert.Equal(t, content, data)
+
+	// Sub-directory.
+	data, errMsg = download(t, addr, "/pxelinux.cfg/default")
+	assert.Empty(t, errMsg)
+	assert.Equal(t, "DEFAULT linux\n", string(data))
+
+	// Missing file.
+	_, errMsg = download(t, addr, "missing")
+	assert.Equal(t, "File not found", errMsg)
+
+	// Paths outside of the root.
+	_, errMsg = download(t, addr, "../secret")
+	assert.NotEmpty(t, errMsg)
+
+	// Directories.
+	_, errMsg = download(t, addr, "pxelinux.cfg")
+	assert.Equal(t, "Access denied", errMsg)
+}
@@ -495,6 +495,31 @@ var APIExtensions = []string{
 	"server_logging_webhook",
 	"storage_driver_truenas",
 	"container_disk_tmpfs",
+	"network_zones_dns_update",
+	"network_bridge_dhcp_backend",
+	"network_dhcp_options",
+	"network_ovn_qos",
+	"network_acl_state",
+	"network_load_balancer_bridge",
+	"network_load_balancer_l7",
+	"network_peer_bridge",
+	"network_nat64",
+	"instance_nic_capture",
+	"instance_network_path",
+	"network_floating_ips",
+	"network_ipam",
+	"network_integrations_evpn_wireguard",
+	"network_firewall_drift",
+	"migration_postcopy",
+	"instance_health",
+	"instance_dependencies",
+	"stacks",
+	"instance_hibernate",
+	"instance_fork",
+	"instance_tdx",
+	"instance_uefi_vars",
+	"instance_schedule",
+	"instance_autoscale",
 }
 
 // APIExtensionsCount returns the number of available API extensions.
@@ -0,0 +1,12 @@
+package api
+
+// IdentityPost contains the new identity of a virtual machine restored from another instance's state.
+type IdentityPost struct {
+	// New hostname of the guest
+	// Example: vm2
+	Hostname string `json:"hostname" yaml:"hostname"`
+
+	// New MAC addresses of the guest NICs keyed by their previous value
+	// Example: {"10:66:6a:aa:bb:cc": "10:66:6a:dd:ee:ff"}
+	HWAddrs map[string]string `json:"hwaddrs" yaml:"hwaddrs"`
+}
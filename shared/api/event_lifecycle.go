@@ -31,6 +31,7 @@ const (
 	EventLifecycleImageRetrieved                    = "image-retrieved"
 	EventLifecycleImageSecretCreated                = "image-secret-created"
 	EventLifecycleImageUpdated                      = "image-updated"
+	EventLifecycleInstanceAutoscaled                = "instance-autoscaled"
 	EventLifecycleInstanceBackupCreated             = "instance-backup-created"
 	EventLifecycleInstanceBackupDeleted             = "instance-backup-deleted"
 	EventLifecycleInstanceBackupRenamed             = "instance-backup-renamed"
@@ -44,6 +45,8 @@ const (
 	EventLifecycleInstanceFileDeleted               = "instance-file-deleted"
 	EventLifecycleInstanceFilePushed                = "instance-file-pushed"
 	EventLifecycleInstanceFileRetrieved             = "instance-file-retrieved"
+	EventLifecycleInstanceHealthy                   = "instance-healthy"
+	EventLifecycleInstanceHibernated                = "instance-hibernated"
 	EventLifecycleInstanceLogDeleted                = "instance-log-deleted"
 	EventLifecycleInstanceLogRetrieved              = "instance-log-retrieved"
 	EventLifecycleInstanceMetadataRetrieved         = "instance-metadata-retrieved"
@@ -65,6 +68,7 @@ const (
 	EventLifecycleInstanceSnapshotUpdated           = "instance-snapshot-updated"
 	EventLifecycleInstanceStarted                   = "instance-started"
 	EventLifecycleInstanceStopped                   = "instance-stopped"
+	EventLifecycleInstanceUnhealthy                 = "instance-unhealthy"
 	EventLifecycleInstanceUpdated                   = "instance-updated"
 	EventLifecycleNetworkACLCreated                 = "network-acl-created"
 	EventLifecycleNetworkACLDeleted                 = "network-acl-deleted"
@@ -76,6 +80,9 @@ const (
 	EventLifecycleNetworkAddressSetUpdated          = "network-address-set-updated"
 	EventLifecycleNetworkCreated                    = "network-created"
 	EventLifecycleNetworkDeleted                    = "network-deleted"
+	EventLifecycleNetworkFloatingIPCreated          = "network-floating-ip-created"
+	EventLifecycleNetworkFloatingIPDeleted          = "network-floating-ip-deleted"
+	EventLifecycleNetworkFloatingIPUpdated          = "network-floating-ip-updated"
 	EventLifecycleNetworkForwardCreated             = "network-forward-created"
 	EventLifecycleNetworkForwardDeleted             = "network-forward-deleted"
 	EventLifecycleNetworkForwardUpdated             = "network-forward-updated"
@@ -106,6 +113,9 @@ const (
 	EventLifecycleProjectDeleted                    = "project-deleted"
 	EventLifecycleProjectRenamed                    = "project-renamed"
 	EventLifecycleProjectUpdated                    = "project-updated"
+	EventLifecycleStackCreated                      = "stack-created"
+	EventLifecycleStackDeleted                      = "stack-deleted"
+	EventLifecycleStackUpdated                      = "stack-updated"
 	EventLifecycleStorageBucketBackupCreated        = "storage-bucket-backup-created"
 	EventLifecycleStorageBucketBackupDeleted        = "storage-bucket-backup-deleted"
 	EventLifecycleStorageBucketBackupRenamed        = "storage-bucket-backup-renamed"
@@ -110,6 +110,12 @@ type InstancePost struct {
 	// API extension: instance_allow_inconsistent_copy
 	AllowInconsistent bool `json:"allow_inconsistent" yaml:"allow_inconsistent"`
 
+	// Whether to switch a live migration to post-copy mode (migration only)
+	// Example: false
+	//
+	// API extension: migration_postcopy
+	Postcopy bool `json:"postcopy" yaml:"postcopy"`
+
 	// Instance configuration file.
 	// Example: {"security.nesting": "true"}
 	//
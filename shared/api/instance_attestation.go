@@ -0,0 +1,27 @@
+package api
+
+// InstanceAttestationPost represents a request for an attestation report from a confidential virtual machine.
+//
+// swagger:model
+//
+// API extension: instance_tdx.
+type InstanceAttestationPost struct {
+	// Base64 encoded data to bind into the report, such as a nonce (up to 64 bytes)
+	// Example: ZXhhbXBsZQ==
+	ReportData string `json:"report_data" yaml:"report_data"`
+}
+
+// InstanceAttestation represents an attestation report generated by a confidential virtual machine.
+//
+// swagger:model
+//
+// API extension: instance_tdx.
+type InstanceAttestation struct {
+	// Name of the attestation provider in the guest kernel
+	// Example: tdx_guest
+	Provider string `json:"provider" yaml:"provider"`
+
+	// Base64 encoded attestation report
+	// Example: BAACAIEAAAAAAAAAk5pyM/ecTKmUCg2zlX8GB...
+	Report string `json:"report" yaml:"report"`
+}
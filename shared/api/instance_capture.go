@@ -0,0 +1,28 @@
+package api
+
+// InstanceCapturePost represents a packet capture request on an instance NIC.
+//
+// swagger:model
+//
+// API extension: instance_nic_capture.
+type InstanceCapturePost struct {
+	// Name of the NIC device to capture traffic on
+	// Example: eth0
+	Device string `json:"device" yaml:"device"`
+
+	// Capture filter (pcap-filter syntax)
+	// Example: tcp port 80
+	Filter string `json:"filter" yaml:"filter"`
+
+	// Maximum duration of the capture in seconds (defaults to 60)
+	// Example: 30
+	Duration int `json:"duration" yaml:"duration"`
+
+	// Maximum number of packets to capture (0 for no limit)
+	// Example: 1000
+	Packets int `json:"packets" yaml:"packets"`
+
+	// Number of bytes captured per packet (0 for the whole packet)
+	// Example: 128
+	Snaplen int `json:"snaplen" yaml:"snaplen"`
+}
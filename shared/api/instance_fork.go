@@ -0,0 +1,16 @@
+package api
+
+// InstanceForkPost represents a request to fork a running virtual machine into new instances.
+//
+// swagger:model
+//
+// API extension: instance_fork.
+type InstanceForkPost struct {
+	// Names of the new instances
+	// Example: ["ci-1", "ci-2"]
+	Names []string `json:"names" yaml:"names"`
+
+	// Whether the new instances are ephemeral
+	// Example: true
+	Ephemeral bool `json:"ephemeral" yaml:"ephemeral"`
+}
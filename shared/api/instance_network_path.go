@@ -0,0 +1,100 @@
+package api
+
+// InstanceNetworkPath represents the path taken by the traffic of an instance NIC.
+//
+// swagger:model
+//
+// API extension: instance_network_path.
+type InstanceNetworkPath struct {
+	// Name of the NIC device
+	// Example: eth0
+	Device string `json:"device" yaml:"device"`
+
+	// Elements the traffic goes through, starting from the instance
+	Hops []InstanceNetworkPathHop `json:"hops" yaml:"hops"`
+}
+
+// InstanceNetworkPathHop represents an element on the path of an instance NIC.
+//
+// swagger:model
+//
+// API extension: instance_network_path.
+type InstanceNetworkPathHop struct {
+	// Type of element (nic, host-interface, parent, bridge, host, ovn-switch, ovn-router or uplink)
+	// Example: bridge
+	Type string `json:"type" yaml:"type"`
+
+	// Name of the element
+	// Example: incusbr0
+	Name string `json:"name" yaml:"name"`
+
+	// Name of the managed network the element belongs to
+	// Example: incusbr0
+	Network string `json:"network" yaml:"network"`
+
+	// Addresses of the element
+	// Example: ["10.0.0.1/24"]
+	Addresses []string `json:"addresses" yaml:"addresses"`
+
+	// Network ACLs applied on the element
+	// Example: ["web"]
+	ACLs []string `json:"acls" yaml:"acls"`
+
+	// Listen addresses of the network forwards leading to the NIC
+	// Example: ["192.0.2.1"]
+	Forwards []string `json:"forwards" yaml:"forwards"`
+
+	// Listen addresses of the network load balancers with the NIC as a backend
+	// Example: ["192.0.2.2"]
+	LoadBalancers []string `json:"load_balancers" yaml:"load_balancers"`
+
+	// Names of the network peers reachable from the element
+	// Example: ["backend"]
+	Peers []string `json:"peers" yaml:"peers"`
+}
+
+// InstanceNetworkTracePost represents a flow from an instance NIC to trace.
+//
+// swagger:model
+//
+// API extension: instance_network_path.
+type InstanceNetworkTracePost struct {
+	// Name of the NIC device the flow originates from
+	// Example: eth0
+	Device string `json:"device" yaml:"device"`
+
+	// Protocol of the flow (tcp, udp or icmp, any if empty)
+	// Example: tcp
+	Protocol string `json:"protocol" yaml:"protocol"`
+
+	// Source address (defaults to the address of the NIC)
+	// Example: 10.0.0.2
+	Source string `json:"source" yaml:"source"`
+
+	// Destination address
+	// Example: 192.0.2.10
+	Destination string `json:"destination" yaml:"destination"`
+
+	// Destination port (tcp and udp only)
+	// Example: 443
+	DestinationPort int `json:"destination_port" yaml:"destination_port"`
+
+	// How long to wait for matching traffic in seconds when tracing live traffic (defaults to 10)
+	// Example: 30
+	Timeout int `json:"timeout" yaml:"timeout"`
+}
+
+// InstanceNetworkTrace represents the result of a flow trace.
+//
+// swagger:model
+//
+// API extension: instance_network_path.
+type InstanceNetworkTrace struct {
+	// Fate of the flow (allow, drop or unknown)
+	// Example: drop
+	Verdict string `json:"verdict" yaml:"verdict"`
+
+	// Trace output explaining the verdict
+	// Example: ["trace id 1a2b3c4d inet incus fwd.incusbr0 rule ... (verdict drop)"]
+	Output []string `json:"output" yaml:"output"`
+}
@@ -10,7 +10,7 @@ import (
 //
 // API extension: instances.
 type InstanceStatePut struct {
-	// State change action (start, stop, restart, freeze, unfreeze)
+	// State change action (start, stop, restart, freeze, unfreeze, hibernate)
 	// Example: start
 	Action string `json:"action" yaml:"action"`
 
@@ -25,6 +25,12 @@ type InstanceStatePut struct {
 	// Whether to store the runtime state (for stop)
 	// Example: false
 	Stateful bool `json:"stateful" yaml:"stateful"`
+
+	// Whether to discard the saved state of a hibernated virtual machine (for start)
+	// Example: false
+	//
+	// API extension: instance_hibernate
+	Stateless bool `json:"stateless" yaml:"stateless"`
 }
 
 // InstanceState represents an instance's state.
@@ -70,6 +76,11 @@ type InstanceState struct {
 	//
 	// API extension: instances_state_os_info.
 	OSInfo *InstanceStateOSInfo `json:"os_info" yaml:"os_info"`
+
+	// Health check status (nil if no health check is configured).
+	//
+	// API extension: instance_health.
+	Health *InstanceStateHealth `json:"health" yaml:"health"`
 }
 
 // InstanceStateDisk represents the disk information section of an instance's state.
@@ -258,3 +269,26 @@ type InstanceStateOSInfo struct {
 	// Example: myhost.mydomain.local
 	FQDN string `json:"fqdn" yaml:"fqdn"`
 }
+
+// InstanceStateHealth represents the health check section of an instance's state.
+//
+// swagger:model
+//
+// API extension: instance_health.
+type InstanceStateHealth struct {
+	// Health status (unknown, healthy or unhealthy)
+	// Example: healthy
+	Status string `json:"status" yaml:"status"`
+
+	// Number of consecutive failed checks
+	// Example: 0
+	Failures int64 `json:"failures" yaml:"failures"`
+
+	// When the last check ran
+	// Example: 2021-03-23T20:00:00-04:00
+	LastCheck time.Time `json:"last_check" yaml:"last_check"`
+
+	// Error of the last failed check
+	// Example: Command returned exit status 1
+	LastError string `json:"last_error" yaml:"last_error"`
+}
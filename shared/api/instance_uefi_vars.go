@@ -0,0 +1,58 @@
+package api
+
+import (
+	"time"
+)
+
+// InstanceUEFIVars represents the UEFI variable store of a virtual machine.
+//
+// swagger:model
+//
+// API extension: instance_uefi_vars.
+type InstanceUEFIVars struct {
+	// UEFI variables, keyed by name and vendor GUID
+	// Example: {"BootOrder-8be4dfcb-90b5-11d2-aa0d-00e098032b8c": {"data": "01000000", "attributes": 7}}
+	Variables map[string]InstanceUEFIVariable `json:"variables" yaml:"variables"`
+}
+
+// InstanceUEFIVariable represents a single UEFI variable.
+//
+// swagger:model
+//
+// API extension: instance_uefi_vars.
+type InstanceUEFIVariable struct {
+	// Hex encoded value of the variable
+	// Example: 01000000
+	Data string `json:"data" yaml:"data"`
+
+	// Attributes of the variable (EFI_VARIABLE_* flags)
+	// Example: 7
+	Attributes uint32 `json:"attributes" yaml:"attributes"`
+
+	// Timestamp of time based authenticated variables
+	// Example: 2021-03-23T20:00:00-04:00
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// InstanceUEFIKeysPost represents the Secure Boot keys to enroll in a virtual machine.
+//
+// swagger:model
+//
+// API extension: instance_uefi_vars.
+type InstanceUEFIKeysPost struct {
+	// PEM encoded Platform Key certificate
+	// Example: -----BEGIN CERTIFICATE-----...
+	PK string `json:"pk" yaml:"pk"`
+
+	// PEM encoded Key Exchange Key certificates
+	// Example: -----BEGIN CERTIFICATE-----...
+	KEK string `json:"kek" yaml:"kek"`
+
+	// PEM encoded certificates and hex encoded SHA-256 hashes allowed to boot
+	// Example: -----BEGIN CERTIFICATE-----...
+	DB string `json:"db" yaml:"db"`
+
+	// PEM encoded certificates and hex encoded SHA-256 hashes forbidden to boot
+	// Example: 80b4d96931bf0d02fd91a61e19d14f1da452e66db2408ca8604d411f92659f0a
+	DBX string `json:"dbx" yaml:"dbx"`
+}
@@ -347,3 +347,30 @@ type NetworkStateOVN struct {
 	// API extension: network_ovn_state_addresses
 	UplinkIPv6 string `json:"uplink_ipv6" yaml:"uplink_ipv6"`
 }
+
+// NetworkFirewall represents the firewall rules of a network on a server
+//
+// swagger:model
+//
+// API extension: network_firewall_drift.
+type NetworkFirewall struct {
+	// Firewall driver in use
+	// Example: nftables
+	Driver string `json:"driver" yaml:"driver"`
+
+	// Whether the rules were changed outside of the server since they were last applied
+	// Example: true
+	Drift bool `json:"drift" yaml:"drift"`
+
+	// Rules of the network currently in the kernel
+	// Example: ["inet pstrt.incusbr0: ip saddr 10.0.0.0/24 ip daddr != 10.0.0.0/24 masquerade"]
+	Rules []string `json:"rules" yaml:"rules"`
+
+	// Rules applied by the server which are no longer in the kernel
+	// Example: ["inet fwd.incusbr0: ip version 4 accept"]
+	Missing []string `json:"missing" yaml:"missing"`
+
+	// Rules in the kernel which weren't applied by the server
+	// Example: []
+	Unexpected []string `json:"unexpected" yaml:"unexpected"`
+}
@@ -50,6 +50,18 @@ type NetworkACLRule struct {
 	// State of the rule
 	// Example: enabled
 	State string `json:"state" yaml:"state"`
+
+	// DSCP value to set on matching traffic (OVN only)
+	// Example: 46
+	//
+	// API extension: network_ovn_qos
+	DSCP string `json:"dscp,omitempty" yaml:"dscp,omitempty"`
+
+	// Rate limit to apply to matching traffic (OVN only)
+	// Example: 10Mbit
+	//
+	// API extension: network_ovn_qos
+	RateLimit string `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
 }
 
 // Normalise normalises the fields in the rule so that they are comparable with ones stored.
@@ -60,6 +72,8 @@ func (r *NetworkACLRule) Normalise() {
 	r.ICMPCode = strings.TrimSpace(r.ICMPCode)
 	r.Description = strings.TrimSpace(r.Description)
 	r.State = strings.TrimSpace(r.State)
+	r.DSCP = strings.TrimSpace(r.DSCP)
+	r.RateLimit = strings.TrimSpace(r.RateLimit)
 
 	// Remove space from Source subject list.
 	subjects := strings.Split(r.Source, ",")
@@ -166,3 +180,31 @@ type NetworkACLsPost struct {
 	NetworkACLPost `yaml:",inline"`
 	NetworkACLPut  `yaml:",inline"`
 }
+
+// NetworkACLState represents the state of a network ACL.
+//
+// swagger:model
+//
+// API extension: network_acl_state.
+type NetworkACLState struct {
+	// Hit counters of the ingress rules (in rule order)
+	Ingress []NetworkACLRuleCounters `json:"ingress" yaml:"ingress"`
+
+	// Hit counters of the egress rules (in rule order)
+	Egress []NetworkACLRuleCounters `json:"egress" yaml:"egress"`
+}
+
+// NetworkACLRuleCounters represents the hit counters of a network ACL rule.
+//
+// swagger:model
+//
+// API extension: network_acl_state.
+type NetworkACLRuleCounters struct {
+	// Number of packets matched by the rule
+	// Example: 1024
+	Packets uint64 `json:"packets" yaml:"packets"`
+
+	// Number of bytes matched by the rule
+	// Example: 98304
+	Bytes uint64 `json:"bytes" yaml:"bytes"`
+}
@@ -11,6 +11,12 @@ type NetworkAllocations struct {
 	// Example: 192.0.2.1/24
 	Address string `json:"addresses" yaml:"addresses"`
 
+	// Name of the IPAM driver managing the network address
+	// Example: http
+	//
+	// API extension: network_ipam
+	IPAM string `json:"ipam" yaml:"ipam"`
+
 	// Hwaddr is the MAC address of the entity consuming the network address
 	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
 
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net"
+	"strings"
+)
+
+// NetworkFloatingIPsPost represents the fields of a new floating IP
+//
+// swagger:model
+//
+// API extension: network_floating_ips.
+type NetworkFloatingIPsPost struct {
+	NetworkFloatingIPPut `yaml:",inline"`
+
+	// Name of the network (bridge or OVN) the floating IP is created on
+	// Example: ovn0
+	Network string `json:"network" yaml:"network"`
+
+	// Floating IP address (allocated from the network's floating IP ranges if empty)
+	// Example: 192.0.2.50
+	Address string `json:"address" yaml:"address"`
+}
+
+// Normalise normalises the fields of the floating IP so that they are comparable with ones stored.
+func (f *NetworkFloatingIPsPost) Normalise() {
+	ip := net.ParseIP(f.Address)
+	if ip != nil {
+		f.Address = ip.String() // Replace with canonical form if specified.
+	}
+
+	f.NetworkFloatingIPPut.Normalise()
+}
+
+// NetworkFloatingIPPut represents the modifiable fields of a floating IP
+//
+// swagger:model
+//
+// API extension: network_floating_ips.
+type NetworkFloatingIPPut struct {
+	// Description of the floating IP
+	// Example: My web server address
+	Description string `json:"description" yaml:"description"`
+
+	// Name of the instance the floating IP is attached to (empty when detached)
+	// Example: c1
+	Instance string `json:"instance" yaml:"instance"`
+
+	// Name of the instance NIC the floating IP is attached to (first suitable NIC if empty)
+	// Example: eth0
+	Device string `json:"device" yaml:"device"`
+}
+
+// Normalise normalises the fields of the floating IP so that they are comparable with ones stored.
+func (f *NetworkFloatingIPPut) Normalise() {
+	f.Description = strings.TrimSpace(f.Description)
+	f.Instance = strings.TrimSpace(f.Instance)
+	f.Device = strings.TrimSpace(f.Device)
+}
+
+// NetworkFloatingIP represents a floating IP
+//
+// swagger:model
+//
+// API extension: network_floating_ips.
+type NetworkFloatingIP struct {
+	NetworkFloatingIPPut `yaml:",inline"`
+
+	// The floating IP address
+	// Example: 192.0.2.50
+	Address string `json:"address" yaml:"address"`
+
+	// Name of the network currently holding the floating IP
+	// Example: ovn0
+	Network string `json:"network" yaml:"network"`
+
+	// Name of the network the floating IP is allocated from
+	// Example: UPLINK
+	Pool string `json:"pool" yaml:"pool"`
+
+	// Address of the instance NIC the traffic is forwarded to
+	// Example: 10.0.0.2
+	TargetAddress string `json:"target_address" yaml:"target_address"`
+
+	// What cluster member this record was found on
+	// Example: server01
+	Location string `json:"location" yaml:"location"`
+}
+
+// Etag returns the values used for etag generation.
+func (f *NetworkFloatingIP) Etag() []any {
+	return []any{f.Address, f.Network, f.Description, f.Instance, f.Device}
+}
+
+// Writable converts a full NetworkFloatingIP struct into a NetworkFloatingIPPut struct (filters read-only fields).
+func (f *NetworkFloatingIP) Writable() NetworkFloatingIPPut {
+	return f.NetworkFloatingIPPut
+}
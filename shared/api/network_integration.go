@@ -12,7 +12,7 @@ type NetworkIntegrationsPost struct {
 	// Example: region1
 	Name string `json:"name" yaml:"name"`
 
-	// The type of integration
+	// The type of integration (ovn, bgp-evpn or wireguard)
 	// Example: ovn
 	Type string `json:"type" yaml:"type"`
 }
@@ -44,7 +44,7 @@ type NetworkIntegration struct {
 	// Example: region1
 	Name string `json:"name" yaml:"name"`
 
-	// The type of integration
+	// The type of integration (ovn, bgp-evpn or wireguard)
 	// Example: ovn
 	Type string `json:"type" yaml:"type"`
 
@@ -57,7 +57,7 @@ type NetworkLoadBalancerPort struct {
 	// Example: My web server load balancer
 	Description string `json:"description" yaml:"description"`
 
-	// Protocol for load balancer port (either tcp or udp)
+	// Protocol for load balancer port (tcp, udp, http, https or tls)
 	// Example: tcp
 	Protocol string `json:"protocol" yaml:"protocol"`
 
@@ -68,12 +68,26 @@ type NetworkLoadBalancerPort struct {
 	// TargetBackend backend names to load balance ListenPorts to
 	// Example: ["c1-http","c2-http"]
 	TargetBackend []string `json:"target_backend" yaml:"target_backend"`
+
+	// Host name (HTTP host or TLS server name) to route to the backends (http, https and tls only)
+	// Example: www.example.net
+	//
+	// API extension: network_load_balancer_l7.
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+
+	// Path prefix to route to the backends (http and https only)
+	// Example: /api
+	//
+	// API extension: network_load_balancer_l7.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
 }
 
 // Normalise normalises the fields in the load balancer port so that they are comparable with ones stored.
 func (p *NetworkLoadBalancerPort) Normalise() {
 	p.Description = strings.TrimSpace(p.Description)
 	p.Protocol = strings.TrimSpace(p.Protocol)
+	p.Host = strings.ToLower(strings.TrimSpace(p.Host))
+	p.Path = strings.TrimSpace(p.Path)
 
 	// Remove space from ListenPort list.
 	subjects := strings.Split(p.ListenPort, ",")
@@ -165,6 +179,11 @@ func (f *NetworkLoadBalancer) Writable() NetworkLoadBalancerPut {
 // API extension: network_load_balancer_state.
 type NetworkLoadBalancerState struct {
 	BackendHealth map[string]NetworkLoadBalancerStateBackendHealth `json:"backend_health" yaml:"backend_health"`
+
+	// Request metrics by backend name (http, https and tls ports only)
+	//
+	// API extension: network_load_balancer_l7.
+	BackendMetrics map[string]NetworkLoadBalancerStateBackendMetrics `json:"backend_metrics,omitempty" yaml:"backend_metrics,omitempty"`
 }
 
 // NetworkLoadBalancerStateBackendHealth represents the health of a particular load-balancer backend
@@ -187,3 +206,18 @@ type NetworkLoadBalancerStateBackendHealthPort struct {
 	Port     int    `json:"port" yaml:"port"`
 	Status   string `json:"status" yaml:"status"`
 }
+
+// NetworkLoadBalancerStateBackendMetrics represents the requests handled by a particular load-balancer backend.
+//
+// swagger:model
+//
+// API extension: network_load_balancer_l7.
+type NetworkLoadBalancerStateBackendMetrics struct {
+	// Number of requests (or TLS connections) sent to the backend
+	// Example: 1024
+	Requests uint64 `json:"requests" yaml:"requests"`
+
+	// Number of requests which failed (connection errors or HTTP 5xx replies)
+	// Example: 3
+	Failures uint64 `json:"failures" yaml:"failures"`
+}
@@ -0,0 +1,159 @@
+package api
+
+// StackManifest represents the resources of a stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackManifest struct {
+	// Profiles of the stack
+	Profiles []ProfilesPost `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// Networks of the stack
+	Networks []NetworksPost `json:"networks,omitempty" yaml:"networks,omitempty"`
+
+	// Storage volumes of the stack
+	Volumes []StackVolume `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+
+	// Instances of the stack
+	Instances []InstancesPost `json:"instances,omitempty" yaml:"instances,omitempty"`
+
+	// Network forwards of the stack
+	Forwards []StackNetworkForward `json:"forwards,omitempty" yaml:"forwards,omitempty"`
+}
+
+// StackVolume represents a storage volume of a stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackVolume struct {
+	StorageVolumesPost `yaml:",inline"`
+
+	// Name of the storage pool of the volume
+	// Example: default
+	Pool string `json:"pool" yaml:"pool"`
+}
+
+// StackNetworkForward represents a network forward of a stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackNetworkForward struct {
+	NetworkForwardsPost `yaml:",inline"`
+
+	// Name of the network of the forward
+	// Example: incusbr0
+	Network string `json:"network" yaml:"network"`
+}
+
+// StackPut represents the modifiable fields of a stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackPut struct {
+	// Description of the stack
+	// Example: Web application
+	Description string `json:"description" yaml:"description"`
+
+	// Resources of the stack
+	Manifest StackManifest `json:"manifest" yaml:"manifest"`
+}
+
+// StacksPost represents the fields of a new stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StacksPost struct {
+	StackPut `yaml:",inline"`
+
+	// Name of the stack
+	// Example: webapp
+	Name string `json:"name" yaml:"name"`
+}
+
+// Stack represents a stack of instances, profiles, storage volumes, networks and network forwards.
+//
+// swagger:model
+//
+// API extension: stacks.
+type Stack struct {
+	StackPut `yaml:",inline"`
+
+	// Name of the stack
+	// Example: webapp
+	Name string `json:"name" yaml:"name"`
+
+	// Project name
+	// Example: project1
+	Project string `json:"project" yaml:"project"`
+
+	// List of URLs of the resources of the stack
+	// Read only: true
+	// Example: ["/1.0/instances/web", "/1.0/profiles/web"]
+	UsedBy []string `json:"used_by" yaml:"used_by"`
+
+	// Resources managed by the stack
+	// Read only: true
+	Resources []StackResource `json:"resources" yaml:"resources"`
+}
+
+// Writable converts a full Stack struct into a StackPut struct (filters read-only fields).
+func (s *Stack) Writable() StackPut {
+	return s.StackPut
+}
+
+// StackResource represents a resource managed by a stack.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackResource struct {
+	// Type of the resource (profile, network, storage-volume, instance or network-forward)
+	// Example: instance
+	Type string `json:"type" yaml:"type"`
+
+	// Name of the resource
+	// Example: web
+	Name string `json:"name" yaml:"name"`
+
+	// Whether the resource was created by the stack, as opposed to adopted from existing resources
+	// Example: true
+	Created bool `json:"created" yaml:"created"`
+}
+
+// StackChange represents a change needed to apply a stack manifest.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackChange struct {
+	// Action to take on the resource (create, update, adopt, delete or release)
+	// Example: update
+	Action string `json:"action" yaml:"action"`
+
+	// Type of the resource (profile, network, storage-volume, instance or network-forward)
+	// Example: instance
+	Type string `json:"type" yaml:"type"`
+
+	// Name of the resource
+	// Example: web
+	Name string `json:"name" yaml:"name"`
+
+	// Fields of the resource which are updated
+	// Example: ["config", "devices"]
+	Fields []string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// StackDiff represents the changes needed to apply a stack manifest.
+//
+// swagger:model
+//
+// API extension: stacks.
+type StackDiff struct {
+	// Changes to apply
+	Changes []StackChange `json:"changes" yaml:"changes"`
+}